@@ -6,6 +6,7 @@ import (
 
 	"github.com/itsmrshow/bulwark/internal/cli"
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/redact"
 	"github.com/spf13/cobra"
 )
 
@@ -16,10 +17,20 @@ var (
 )
 
 func main() {
+	if err := redact.Configure(redact.SplitPatterns(os.Getenv("BULWARK_REDACT_PATTERNS"))); err != nil {
+		fmt.Fprintf(os.Stderr, "ignoring BULWARK_REDACT_PATTERNS: %v\n", err)
+	}
+
+	components, err := logging.ParseLevels(os.Getenv("BULWARK_LOG_LEVELS"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ignoring BULWARK_LOG_LEVELS: %v\n", err)
+	}
+
 	// Initialize default logger
 	logging.Init(logging.Config{
 		Level:         getEnv("BULWARK_LOG_LEVEL", "info"),
 		Format:        getEnv("BULWARK_LOG_FORMAT", "console"),
+		Components:    components,
 		RedactSecrets: true,
 	})
 
@@ -45,10 +56,23 @@ using digest comparison, and applies updates safely with health probes.`,
 	rootCmd.AddCommand(cli.NewPlanCommand())
 	rootCmd.AddCommand(cli.NewApplyCommand())
 	rootCmd.AddCommand(cli.NewServeCommand())
+	rootCmd.AddCommand(cli.NewPreflightCommand())
+	rootCmd.AddCommand(cli.NewSnoozeCommand())
+	rootCmd.AddCommand(cli.NewMaintenanceCommand())
+	rootCmd.AddCommand(cli.NewTagCommand())
+	rootCmd.AddCommand(cli.NewRollbackCommand())
+	rootCmd.AddCommand(cli.NewDBCommand())
+	rootCmd.AddCommand(cli.NewMetricsCommand())
+	rootCmd.AddCommand(cli.NewImportCommand())
+	rootCmd.AddCommand(cli.NewLabelsCommand())
 
+	rootCmd.SetFlagErrorFunc(cli.UsageError)
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		code := cli.ExitCode(err)
+		if code != cli.ExitUpdatesAvailable {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(code)
 	}
 }
 
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// APIVersion is the version of the HTTP API this server speaks. Routes are
+// served under /api/v{APIVersion}/; a breaking change to a response shape
+// gets a new version rather than changing the current one.
+const APIVersion = "1"
+
+// APIVersionHeader names the header a client sends to ask for an API
+// version and the server answers with the version it served.
+const APIVersionHeader = "Bulwark-API-Version"
+
+// supportedAPIVersions lists the versions a client may ask for.
+var supportedAPIVersions = []string{APIVersion}
+
+var versionedPath = regexp.MustCompile(`^/api/v([0-9]+)(/.*)?$`)
+
+// versionMiddleware serves /api/v1/... by the handlers of /api/..., so
+// handlers keep matching on the unversioned path. Unversioned paths stay
+// available as deprecated aliases unless legacy is false; their responses
+// carry a Deprecation header and a Link to the versioned path.
+//
+// A client may send Bulwark-API-Version to state the version it expects.
+// A version the server does not speak is refused with 406 instead of
+// answering with a shape the client cannot read.
+func versionMiddleware(next http.Handler, legacy bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api" && !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		header := w.Header()
+		header.Set(APIVersionHeader, APIVersion)
+
+		if requested := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(APIVersionHeader)), "v"); requested != "" && !isSupportedAPIVersion(requested) {
+			writeError(w, http.StatusNotAcceptable, "unsupported API version", supportedVersionsDetail(requested))
+			return
+		}
+
+		match := versionedPath.FindStringSubmatch(r.URL.Path)
+		if match == nil {
+			if !legacy {
+				writeError(w, http.StatusNotFound, "not found", "Unversioned API paths are disabled; use /api/v"+APIVersion+strings.TrimPrefix(r.URL.Path, "/api"))
+				return
+			}
+			header.Set("Deprecation", "true")
+			header.Set("Link", fmt.Sprintf("</api/v%s%s>; rel=\"successor-version\"", APIVersion, strings.TrimPrefix(r.URL.Path, "/api")))
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !isSupportedAPIVersion(match[1]) {
+			writeError(w, http.StatusNotFound, "unsupported API version", supportedVersionsDetail(match[1]))
+			return
+		}
+
+		rest := match[2]
+		if rest == "" {
+			rest = "/"
+		}
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/api" + rest
+		r2.URL.RawPath = ""
+		next.ServeHTTP(w, r2)
+	})
+}
+
+func isSupportedAPIVersion(version string) bool {
+	for _, supported := range supportedAPIVersions {
+		if version == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func supportedVersionsDetail(requested string) string {
+	return fmt.Sprintf("Version %s is not supported; supported versions: %s", requested, strings.Join(supportedAPIVersions, ", "))
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	s := setupTestServer(t)
+	if err := s.store.SaveTarget(context.Background(), &state.Target{ID: "target-1", Type: state.TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	do := func(path, version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if version != "" {
+			req.Header.Set(APIVersionHeader, version)
+		}
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/api/v1/targets/target-1", "")
+	if w.Code != http.StatusOK || w.Header().Get(APIVersionHeader) != APIVersion {
+		t.Fatalf("expected the versioned route to answer with its version, got %d %q", w.Code, w.Header().Get(APIVersionHeader))
+	}
+	if w.Header().Get("Deprecation") != "" {
+		t.Error("expected no deprecation notice on a versioned route")
+	}
+
+	w = do("/api/targets/target-1", "")
+	if w.Code != http.StatusOK || w.Header().Get("Deprecation") != "true" {
+		t.Errorf("expected the unversioned alias to be served as deprecated, got %d %q", w.Code, w.Header().Get("Deprecation"))
+	}
+	if link := w.Header().Get("Link"); link != `</api/v1/targets/target-1>; rel="successor-version"` {
+		t.Errorf("expected a link to the versioned route, got %q", link)
+	}
+
+	if w := do("/api/v1/health", "1"); w.Code != http.StatusOK {
+		t.Errorf("expected the requested version to be served, got %d", w.Code)
+	}
+	if w := do("/api/v1/health", "2"); w.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406 for an unsupported requested version, got %d", w.Code)
+	}
+	if w := do("/api/v2/health", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unsupported version path, got %d", w.Code)
+	}
+	if w := do("/api/v1/unknown", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown versioned route, got %d", w.Code)
+	}
+
+	s.cfg.LegacyAPIPaths = false
+	if w := do("/api/targets/target-1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unversioned paths once legacy paths are disabled, got %d", w.Code)
+	}
+	if w := do("/api/v1/targets/target-1", ""); w.Code != http.StatusOK {
+		t.Errorf("expected the versioned route to keep working, got %d", w.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/itsmrshow/bulwark/internal/artifacts"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/redact"
+)
+
+// handleRunArtifacts serves the files a run kept for post-mortem analysis:
+//
+//	GET /api/runs/{id}/artifacts         the run's artifacts, in the order they were kept
+//	GET /api/runs/{id}/artifacts/{name}  the content of one of them
+func (s *Server) handleRunArtifacts(w http.ResponseWriter, r *http.Request, runID, name string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	if s.artifacts == nil {
+		writeError(w, http.StatusNotFound, "run artifacts are disabled", "Set BULWARK_RUN_ARTIFACTS=true to keep them")
+		return
+	}
+
+	if name == "" {
+		list, err := s.artifacts.List(runID)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid run id", err.Error())
+			return
+		}
+		if _, ok := s.runs.Get(runID); !ok && len(list) == 0 {
+			writeError(w, http.StatusNotFound, "run not found", runID)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"artifacts": list})
+		return
+	}
+
+	artifact, content, err := s.artifacts.Read(runID, name)
+	if errors.Is(err, artifacts.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "artifact not found", name)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to read artifact", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", artifact.Name))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
+
+// withArtifacts returns ctx with a recorder keeping the artifacts of item's
+// steps in run, such as the override file of a rollback.
+func (s *Server) withArtifacts(ctx context.Context, runID string, item planner.PlanItem) context.Context {
+	if s.artifacts == nil {
+		return ctx
+	}
+	return artifacts.WithRecorder(ctx, s.artifacts.Recorder(runID, item.TargetName, item.ServiceName))
+}
+
+// keepArtifact keeps content as an artifact of item in run, with secrets
+// masked. Empty content is not kept, and a failure to keep it only logs a
+// warning.
+func (s *Server) keepArtifact(runID string, item planner.PlanItem, kind, name string, content []byte) {
+	if s.artifacts == nil || len(content) == 0 {
+		return
+	}
+	content = []byte(redact.String(string(content)))
+	if err := s.artifacts.Recorder(runID, item.TargetName, item.ServiceName).Record(kind, name, content); err != nil {
+		s.logger.Warn().Err(err).Str("run_id", runID).Str("artifact", name).Msg("Failed to keep run artifact")
+	}
+}
+
+// pruneArtifacts drops the artifacts of all but the most recent runs.
+func (s *Server) pruneArtifacts() {
+	if s.artifacts == nil {
+		return
+	}
+	if _, err := s.artifacts.Prune(max(s.cfg.RunArtifactsKeep, 1)); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to prune run artifacts")
+	}
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/artifacts"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/redact"
+)
+
+func TestRunArtifacts(t *testing.T) {
+	s := setupTestServer(t)
+	h := s.Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	run := s.runs.CreateRun("apply")
+	if w := get("/api/runs/" + run.ID + "/artifacts"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 while artifacts are disabled, got %d", w.Code)
+	}
+
+	s.artifacts = artifacts.NewStore(t.TempDir())
+	w := get("/api/runs/" + run.ID + "/artifacts")
+	var list struct {
+		Artifacts []artifacts.Artifact `json:"artifacts"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || w.Code != http.StatusOK || list.Artifacts == nil || len(list.Artifacts) != 0 {
+		t.Fatalf("expected an empty list for a run without artifacts, got %d %+v (%v)", w.Code, list, err)
+	}
+	if w := get("/api/runs/unknown/artifacts"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown run, got %d", w.Code)
+	}
+
+	item := planner.PlanItem{TargetName: "app", ServiceName: "web"}
+	s.keepArtifact(run.ID, item, artifacts.KindComposeOutput, "update.log", []byte("03:04:05 stderr Pulling web\n"))
+	s.keepArtifact(run.ID, item, artifacts.KindProbeTranscript, "probes.txt", nil)
+
+	w = get("/api/runs/" + run.ID + "/artifacts")
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || len(list.Artifacts) != 1 || list.Artifacts[0].Name != "app-web-update.log" {
+		t.Fatalf("expected the kept log only, got %+v (%v)", list, err)
+	}
+	w = get("/api/runs/" + run.ID + "/artifacts/app-web-update.log")
+	if w.Code != http.StatusOK || w.Body.String() != "03:04:05 stderr Pulling web\n" || w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("expected the log content, got %d %q", w.Code, w.Body.String())
+	}
+	s.keepArtifact(run.ID, item, artifacts.KindComposeOutput, "pull.log", []byte("03:04:05 stderr Error: DB_PASSWORD=hunter22 is not set\n"))
+	w = get("/api/runs/" + run.ID + "/artifacts/app-web-pull.log")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "hunter22") || !strings.Contains(w.Body.String(), redact.Mask) {
+		t.Errorf("expected the secret masked in the kept log, got %d %q", w.Code, w.Body.String())
+	}
+	if w := get("/api/runs/" + run.ID + "/artifacts/missing.log"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown artifact, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/runs/"+run.ID+"/artifacts", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for DELETE, got %d", w.Code)
+	}
+}
@@ -2,28 +2,63 @@ package api
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
 
+// accessLevel is what a request's credentials allow.
+type accessLevel int
+
+const (
+	accessNone accessLevel = iota
+	accessRead
+	accessWrite
+	// accessAdmin also manages user accounts.
+	accessAdmin
+)
+
+func (a accessLevel) String() string {
+	switch a {
+	case accessRead:
+		return "read"
+	case accessWrite:
+		return "write"
+	case accessAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// session is what a session cookie stands for. Username is empty for
+// sessions opened with a token.
+type session struct {
+	access   accessLevel
+	username string
+	issued   time.Time
+	expiry   time.Time
+}
+
 // Session storage (in-memory for now)
 type sessionStore struct {
 	mu       sync.RWMutex
-	sessions map[string]time.Time // sessionID -> expiry
+	sessions map[string]session // sessionID -> session
 }
 
 func newSessionStore() *sessionStore {
 	store := &sessionStore{
-		sessions: make(map[string]time.Time),
+		sessions: make(map[string]session),
 	}
 	// Cleanup expired sessions every hour
 	go store.cleanup()
 	return store
 }
 
-func (ss *sessionStore) create() string {
+func (ss *sessionStore) create(access accessLevel, username string) string {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
@@ -33,20 +68,21 @@ func (ss *sessionStore) create() string {
 	sessionID := hex.EncodeToString(b)
 
 	// Sessions expire after 24 hours
-	ss.sessions[sessionID] = time.Now().Add(24 * time.Hour)
+	now := time.Now()
+	ss.sessions[sessionID] = session{access: access, username: username, issued: now, expiry: now.Add(sessionTTL)}
 	return sessionID
 }
 
-func (ss *sessionStore) validate(sessionID string) bool {
+// validate returns a live session; the zero session grants nothing.
+func (ss *sessionStore) validate(sessionID string) session {
 	ss.mu.RLock()
 	defer ss.mu.RUnlock()
 
-	expiry, exists := ss.sessions[sessionID]
-	if !exists {
-		return false
+	sess, exists := ss.sessions[sessionID]
+	if !exists || !time.Now().Before(sess.expiry) {
+		return session{}
 	}
-
-	return time.Now().Before(expiry)
+	return sess
 }
 
 func (ss *sessionStore) delete(sessionID string) {
@@ -62,8 +98,8 @@ func (ss *sessionStore) cleanup() {
 	for range ticker.C {
 		ss.mu.Lock()
 		now := time.Now()
-		for id, expiry := range ss.sessions {
-			if now.After(expiry) {
+		for id, sess := range ss.sessions {
+			if now.After(sess.expiry) {
 				delete(ss.sessions, id)
 			}
 		}
@@ -71,6 +107,67 @@ func (ss *sessionStore) cleanup() {
 	}
 }
 
+// tokenAccess returns the access a token grants: the write token
+// (BULWARK_WEB_TOKEN_WRITE or BULWARK_WEB_TOKEN) allows everything, including
+// managing users; BULWARK_WEB_TOKEN_READ only reads.
+func (s *Server) tokenAccess(token string) accessLevel {
+	if token == "" {
+		return accessNone
+	}
+	if tokenMatches(token, s.webToken()) {
+		return accessAdmin
+	}
+	if tokenMatches(token, s.cfg.ReadToken) {
+		return accessRead
+	}
+	return accessNone
+}
+
+// caller is who sent a request and what their credentials allow.
+type caller struct {
+	access   accessLevel
+	username string
+}
+
+// credentials returns the caller identified by the request's session cookie
+// or Bearer token, whichever grants more.
+func (s *Server) credentials(r *http.Request) caller {
+	var c caller
+	if cookie, err := r.Cookie("bulwark_session"); err == nil {
+		sess := s.sessions.validate(cookie.Value)
+		if sess.username != "" {
+			sess.access = s.userSessionAccess(r.Context(), sess)
+		}
+		if sess.access > accessNone {
+			c = caller{access: sess.access, username: sess.username}
+		}
+	}
+	if c.access < accessAdmin {
+		if access := s.tokenAccess(bearerToken(r.Header.Get("Authorization"))); access > c.access {
+			c = caller{access: access}
+		}
+	}
+	return c
+}
+
+// access returns what the request may do. Reads are open to anyone without
+// a read token, and writes to anyone who may read while neither a write
+// token nor any user account exists.
+func (s *Server) access(r *http.Request) accessLevel {
+	access := s.credentials(r).access
+	if s.cfg.ReadToken == "" {
+		access = max(access, accessRead)
+	}
+	if s.webToken() == "" && !s.hasUsers.Load() && access >= accessRead {
+		access = max(access, accessWrite)
+	}
+	return access
+}
+
+func tokenMatches(token, want string) bool {
+	return want != "" && subtle.ConstantTimeCompare([]byte(token), []byte(want)) == 1
+}
+
 func (s *Server) requireWrite(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if s.cfg.ReadOnly {
@@ -83,30 +180,77 @@ func (s *Server) requireWrite(next http.Handler) http.Handler {
 			return
 		}
 
-		if s.cfg.WebToken == "" {
-			next.ServeHTTP(w, r)
+		if s.access(r) < accessWrite {
+			writeError(w, http.StatusUnauthorized, "invalid token", "Login as an operator, or provide the write token as a Bearer token")
 			return
 		}
 
-		// Check for valid session cookie first
-		if cookie, err := r.Cookie("bulwark_session"); err == nil {
-			if s.sessions.validate(cookie.Value) {
-				next.ServeHTTP(w, r)
-				return
-			}
-		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// publicPaths answer without credentials even when BULWARK_WEB_TOKEN_READ is
+// set. The widget checks its own token.
+var publicPaths = map[string]bool{
+	"/api/health": true,
+	"/api/setup":  true,
+	"/api/login":  true,
+	"/api/logout": true,
+	"/api/widget": true,
+}
 
-		// Fall back to Bearer token (for API clients)
-		token := bearerToken(r.Header.Get("Authorization"))
-		if token == "" || token != s.cfg.WebToken {
-			writeError(w, http.StatusUnauthorized, "invalid token", "Login required or provide a valid Bearer token")
+// requireRead guards every /api/ route outside publicPaths once a read
+// token is configured.
+func (s *Server) requireRead(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.ReadToken == "" || !strings.HasPrefix(r.URL.Path, "/api/") || publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if s.access(r) < accessRead {
+			writeError(w, http.StatusUnauthorized, "invalid token", "Login or provide the read token as a Bearer token")
 			return
 		}
+		next.ServeHTTP(w, r)
+	})
+}
 
+// requireAdmin guards user management. It needs the state database, where
+// accounts live.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.store == nil {
+			writeError(w, http.StatusServiceUnavailable, "accounts unavailable", "User accounts need a state database; set BULWARK_STATE_DB")
+			return
+		}
+		switch access := s.credentials(r).access; {
+		case access == accessNone:
+			writeError(w, http.StatusUnauthorized, "login required", "Login as an admin, or provide the write token as a Bearer token")
+			return
+		case access < accessAdmin:
+			writeError(w, http.StatusForbidden, "admin role required", "")
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+// startSession creates a session for username (empty for a token login)
+// and sets its cookie on w.
+func (s *Server) startSession(w http.ResponseWriter, access accessLevel, username string) {
+	sessionID := s.sessions.create(access, username)
+
+	// Set httpOnly cookie (secure in production with HTTPS)
+	http.SetCookie(w, &http.Cookie{
+		Name:     "bulwark_session",
+		Value:    sessionID,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+		MaxAge:   int(sessionTTL.Seconds()),
+	})
+}
+
 func (s *Server) methodOnly(method string, next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != method {
@@ -3,6 +3,7 @@ package api
 import (
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -51,3 +52,105 @@ func TestRequireWriteValidToken(t *testing.T) {
 		t.Fatalf("expected 200, got %d", res.Code)
 	}
 }
+
+func TestRequireWriteRejectsReadToken(t *testing.T) {
+	srv := &Server{cfg: Config{ReadOnly: false, WebToken: "secret", ReadToken: "reader"}}
+	h := srv.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/apply", nil)
+	req.Header.Set("Authorization", "Bearer reader")
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	if res.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", res.Code)
+	}
+}
+
+func TestRequireRead(t *testing.T) {
+	srv := &Server{cfg: Config{WebToken: "secret", ReadToken: "reader"}, sessions: newSessionStore()}
+	h := srv.requireRead(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	readSession := srv.sessions.create(accessRead, "")
+
+	cases := []struct {
+		name    string
+		path    string
+		bearer  string
+		session string
+		want    int
+	}{
+		{"no credentials", "/api/plan", "", "", http.StatusUnauthorized},
+		{"wrong token", "/api/plan", "nope", "", http.StatusUnauthorized},
+		{"read token", "/api/plan", "reader", "", http.StatusOK},
+		{"write token", "/api/plan", "secret", "", http.StatusOK},
+		{"read session", "/api/history", "", readSession, http.StatusOK},
+		{"public path", "/api/health", "", "", http.StatusOK},
+		{"ui asset", "/assets/app.js", "", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.bearer)
+			}
+			if tc.session != "" {
+				req.AddCookie(&http.Cookie{Name: "bulwark_session", Value: tc.session})
+			}
+			res := httptest.NewRecorder()
+			h.ServeHTTP(res, req)
+			if res.Code != tc.want {
+				t.Fatalf("expected %d, got %d", tc.want, res.Code)
+			}
+		})
+	}
+}
+
+func TestRequireReadOpenWithoutReadToken(t *testing.T) {
+	srv := &Server{cfg: Config{WebToken: "secret"}}
+	h := srv.requireRead(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/api/plan", nil))
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", res.Code)
+	}
+}
+
+func TestLoginGrantsTokenAccess(t *testing.T) {
+	srv := &Server{cfg: Config{ReadOnly: false, WebToken: "secret", ReadToken: "reader"}, sessions: newSessionStore()}
+	write := srv.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for token, want := range map[string]int{"reader": http.StatusUnauthorized, "secret": http.StatusOK} {
+		login := httptest.NewRecorder()
+		srv.handleLogin(login, httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"token":"`+token+`"}`)))
+		if login.Code != http.StatusOK {
+			t.Fatalf("login with %s: expected 200, got %d", token, login.Code)
+		}
+		cookies := login.Result().Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("login with %s: expected a session cookie", token)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/api/apply", nil)
+		req.AddCookie(cookies[0])
+		res := httptest.NewRecorder()
+		write.ServeHTTP(res, req)
+		if res.Code != want {
+			t.Errorf("session from %s: expected %d, got %d", token, want, res.Code)
+		}
+	}
+
+	bad := httptest.NewRecorder()
+	srv.handleLogin(bad, httptest.NewRequest(http.MethodPost, "/api/login", strings.NewReader(`{"token":"nope"}`)))
+	if bad.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for an unknown token, got %d", bad.Code)
+	}
+}
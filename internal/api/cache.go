@@ -8,17 +8,45 @@ import (
 )
 
 type planCache struct {
-	mu      sync.RWMutex
-	plan    *planner.Plan
-	expires time.Time
-	ttl     time.Duration
+	mu          sync.RWMutex
+	plan        *planner.Plan
+	fingerprint string
+	expires     time.Time
+	ttl         time.Duration
+	// stale is the plan a previous process persisted. It is served, flagged
+	// stale, until this process caches a plan of its own.
+	stale *planner.Plan
+	// changed is closed, and replaced, whenever the cached plan is replaced
+	// or dropped, to wake long polls of GET /api/plan/updates.
+	changed chan struct{}
+}
+
+// planCacheInfo describes a cached plan so clients can show how stale it is.
+type planCacheInfo struct {
+	Cached          bool      `json:"cached"`
+	GeneratedAt     time.Time `json:"generated_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	TTLRemainingSec int       `json:"ttl_remaining_sec"`
+	// Stale is set on a plan restored from the state database after a
+	// restart, while a fresh plan is built in the background.
+	Stale bool `json:"stale,omitempty"`
 }
 
 func newPlanCache(ttl time.Duration) *planCache {
 	if ttl <= 0 {
 		ttl = 60 * time.Second
 	}
-	return &planCache{ttl: ttl}
+	return &planCache{ttl: ttl, changed: make(chan struct{})}
+}
+
+// SetTTL changes the lifetime of plans cached from now on.
+func (c *planCache) SetTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
 }
 
 func (c *planCache) Get() (*planner.Plan, bool) {
@@ -31,15 +59,99 @@ func (c *planCache) Get() (*planner.Plan, bool) {
 }
 
 func (c *planCache) Set(plan *planner.Plan) {
+	c.SetWithFingerprint(plan, "")
+}
+
+// SetWithFingerprint caches plan along with a summary of the discovery state
+// it was built from. An empty fingerprint disables change detection.
+func (c *planCache) SetWithFingerprint(plan *planner.Plan, fingerprint string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.plan = plan
+	c.fingerprint = fingerprint
 	c.expires = time.Now().Add(c.ttl)
+	c.stale = nil
+	c.notifyLocked()
+}
+
+// SetStale keeps plan to serve until a fresh plan is cached.
+func (c *planCache) SetStale(plan *planner.Plan) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stale = plan
+	c.notifyLocked()
+}
+
+// Stale returns the persisted plan of a previous process, if no fresh plan
+// replaced it yet.
+func (c *planCache) Stale() (*planner.Plan, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stale, c.stale != nil
+}
+
+// InvalidateIfChanged drops the cached plan when fingerprint differs from the
+// one it was stored with, and reports whether it did.
+func (c *planCache) InvalidateIfChanged(fingerprint string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.plan == nil || c.fingerprint == "" || fingerprint == "" || c.fingerprint == fingerprint {
+		return false
+	}
+	c.plan = nil
+	c.fingerprint = ""
+	c.expires = time.Time{}
+	c.notifyLocked()
+	return true
+}
+
+// Info returns cache metadata if plan is the one currently cached.
+func (c *planCache) Info(plan *planner.Plan) (planCacheInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if plan != nil && plan == c.stale {
+		return planCacheInfo{Cached: true, GeneratedAt: plan.GeneratedAt, Stale: true}, true
+	}
+	if plan == nil || c.plan != plan {
+		return planCacheInfo{}, false
+	}
+	remaining := time.Until(c.expires)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return planCacheInfo{
+		Cached:          true,
+		GeneratedAt:     plan.GeneratedAt,
+		ExpiresAt:       c.expires,
+		TTLRemainingSec: int(remaining.Round(time.Second) / time.Second),
+	}, true
 }
 
 func (c *planCache) Invalidate() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.plan = nil
+	c.fingerprint = ""
 	c.expires = time.Time{}
+	c.stale = nil
+	c.notifyLocked()
+}
+
+// Changed returns a channel that is closed the next time the cached plan is
+// replaced or dropped, and the time the current plan expires, if one is
+// cached.
+func (c *planCache) Changed() (<-chan struct{}, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var expires time.Time
+	if c.plan != nil {
+		expires = c.expires
+	}
+	return c.changed, expires
+}
+
+// notifyLocked wakes everyone waiting on Changed. c.mu must be held.
+func (c *planCache) notifyLocked() {
+	close(c.changed)
+	c.changed = make(chan struct{})
 }
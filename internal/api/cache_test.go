@@ -87,3 +87,47 @@ func TestPlanCache_Overwrite(t *testing.T) {
 		t.Errorf("expected update count 5, got %d", got.UpdateCount)
 	}
 }
+
+func TestPlanCache_InvalidateIfChanged(t *testing.T) {
+	c := newPlanCache(time.Minute)
+	c.SetWithFingerprint(&planner.Plan{}, "abc")
+
+	if c.InvalidateIfChanged("abc") {
+		t.Fatal("expected unchanged fingerprint to keep the plan")
+	}
+	if c.InvalidateIfChanged("") {
+		t.Fatal("expected unknown fingerprint to keep the plan")
+	}
+	if !c.InvalidateIfChanged("def") {
+		t.Fatal("expected changed fingerprint to invalidate")
+	}
+	if _, ok := c.Get(); ok {
+		t.Error("expected cache miss after invalidation")
+	}
+
+	c.Set(&planner.Plan{})
+	if c.InvalidateIfChanged("def") {
+		t.Error("expected plan cached without fingerprint to be kept")
+	}
+}
+
+func TestPlanCache_Info(t *testing.T) {
+	c := newPlanCache(time.Minute)
+	p := &planner.Plan{GeneratedAt: time.Now()}
+	c.Set(p)
+
+	info, ok := c.Info(p)
+	if !ok || !info.Cached {
+		t.Fatal("expected info for cached plan")
+	}
+	if info.TTLRemainingSec < 59 || info.TTLRemainingSec > 60 {
+		t.Errorf("expected ~60s remaining, got %d", info.TTLRemainingSec)
+	}
+	if !info.GeneratedAt.Equal(p.GeneratedAt) {
+		t.Errorf("expected generated_at %v, got %v", p.GeneratedAt, info.GeneratedAt)
+	}
+
+	if _, ok := c.Info(&planner.Plan{}); ok {
+		t.Error("expected no info for a plan that is not cached")
+	}
+}
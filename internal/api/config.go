@@ -7,17 +7,36 @@ import (
 	"strings"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/metrics"
+	"github.com/itsmrshow/bulwark/internal/planner"
 	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/telemetry"
+)
+
+// Default operation timeouts. A plan resolves every image digest, so it gets
+// far longer than a discovery pass, which only talks to the local daemon.
+const (
+	defaultPlanTimeout          = 2 * time.Minute
+	defaultDiscoveryTimeout     = 30 * time.Second
+	defaultServiceUpdateTimeout = 15 * time.Minute
 )
 
 // Config holds API/UI server configuration.
 type Config struct {
-	Addr           string
-	Root           string
-	StateDB        string
-	UIEnabled      bool
-	ReadOnly       bool
+	Addr string
+	Root string
+	// RootLocked is set when BULWARK_ROOT is, so setup cannot move the root.
+	RootLocked bool
+	StateDB    string
+	UIEnabled  bool
+	ReadOnly   bool
+	// Profile is ProfileFull or ProfileObserver.
+	Profile string
+	// WebToken guards writes; ReadToken, when set, guards reads as well.
 	WebToken       string
+	ReadToken      string
+	WidgetToken    string
 	DistDir        string
 	DataDir        string
 	ConfigPath     string
@@ -26,7 +45,99 @@ type Config struct {
 	PlanCacheTTL   time.Duration
 	DigestCacheTTL time.Duration
 	LockTimeout    time.Duration
+	// PlanTimeout, DiscoveryTimeout and ServiceUpdateTimeout bound a plan
+	// build, a discovery pass and the update of one service in an apply run.
+	PlanTimeout          time.Duration
+	DiscoveryTimeout     time.Duration
+	ServiceUpdateTimeout time.Duration
+	// SessionJWT issues sessions as signed JWTs that any replica sharing the
+	// state database can verify. SessionKeyRotation is how long one signing
+	// key is used.
+	SessionJWT         bool
+	SessionKeyRotation time.Duration
+	// AdminUsername and AdminPassword create the first admin account when
+	// the state database has none.
+	AdminUsername string
+	AdminPassword string
+	// DowntimeSLA is the longest downtime an update may cause; /api/stats
+	// reports the updates that exceeded it. Zero turns SLA tracking off.
+	DowntimeSLA time.Duration
+	// CheckConcurrency caps the digest lookups a plan build runs at once.
+	CheckConcurrency int
+	// CleanupPolicy is what an apply run removes once it has updated a
+	// service: "none" or "dangling".
+	CleanupPolicy string
+	// CleanupKeepDigests is how many of each service's latest successfully
+	// applied digests the cleanup keeps as rollback targets.
+	CleanupKeepDigests int
+	// LockedSettings lists the runtime settings pinned by an environment
+	// variable; /api/settings refuses to change them.
+	LockedSettings []string
+	// RegistryHTTP tunes timeouts and connection reuse for registry requests.
+	RegistryHTTP registry.HTTPOptions
+	// DockerDataRoot is where Bulwark can see the filesystem holding Docker's
+	// data root, for the free space check before pulls.
+	DockerDataRoot string
+	// MaxConcurrentPulls caps the image pulls running at once across all
+	// runs; zero leaves them uncapped. PullDelay is the pause after a pull
+	// before the next one starts. Images of at least SequentialPullMB
+	// megabytes, by the registry's count, pull one at a time.
+	MaxConcurrentPulls int
+	PullDelay          time.Duration
+	SequentialPullMB   int
+	// ReconcileInterval is how often the state database is compared with
+	// discovery to flag orphaned targets and services; zero turns it off.
+	ReconcileInterval time.Duration
+	// ResourceSnapshots samples each service's CPU and memory use around
+	// its update. MemoryJumpPercent is the memory increase after the update
+	// that gets flagged; zero never flags.
+	ResourceSnapshots bool
+	MemoryJumpPercent int
+	// RunArtifacts keeps each run's compose output, probe transcripts and
+	// rollback override files under DataDir, for the RunArtifactsKeep most
+	// recent runs.
+	RunArtifacts     bool
+	RunArtifactsKeep int
+	// SBOMEnabled captures an SBOM with syft for every applied digest.
+	SBOMEnabled    bool
+	SBOMFormat     string
+	SyftPath       string
 	MetricsEnabled bool
+	// MetricsServiceLabels labels per-service metrics with their target and
+	// service, for at most MetricsMaxServices distinct services.
+	MetricsServiceLabels bool
+	MetricsMaxServices   int
+	// TelemetryEnabled opts in to sending an anonymous usage report to
+	// TelemetryURL every TelemetryInterval.
+	TelemetryEnabled  bool
+	TelemetryURL      string
+	TelemetryInterval time.Duration
+	// Version is the version of the running binary, as telemetry reports it.
+	Version string
+	// SchedulerJitter is the maximum random delay added to scheduled jobs.
+	SchedulerJitter   time.Duration
+	NotifyJobTimeout  time.Duration
+	AutoUpdateTimeout time.Duration
+	// MaxConcurrentRuns caps how many apply runs may execute at once.
+	MaxConcurrentRuns int
+	// Timezone is the IANA zone applied to cron schedules without a CRON_TZ= prefix.
+	Timezone string
+	// CORSOrigins lists browser origins allowed to call the API ("*" for any).
+	CORSOrigins []string
+	// CORSCredentials lets listed origins send cookies and Authorization headers.
+	CORSCredentials bool
+	// LegacyAPIPaths keeps serving the unversioned /api/ paths as deprecated
+	// aliases of /api/v1/.
+	LegacyAPIPaths bool
+	// IncrementalPlan makes plan builds reuse recently resolved digests and
+	// only look up images whose result expired or whose local digest changed.
+	IncrementalPlan bool
+	// Registries are the self-hosted registries whose catalogs the UI may
+	// browse for tag pickers.
+	Registries []registry.Endpoint
+	// Mock runs the server against a simulated Docker engine and registry,
+	// which the UI flags with a banner.
+	Mock bool
 }
 
 // LoadConfig loads configuration from environment variables.
@@ -34,10 +145,14 @@ func LoadConfig() Config {
 	return Config{
 		Addr:           getEnv("BULWARK_UI_ADDR", ":8080"),
 		Root:           getEnv("BULWARK_ROOT", "/docker_data"),
+		RootLocked:     os.Getenv("BULWARK_ROOT") != "",
 		StateDB:        os.Getenv("BULWARK_STATE_DB"),
 		UIEnabled:      getEnvBool("BULWARK_UI_ENABLED", true),
 		ReadOnly:       getEnvBool("BULWARK_UI_READONLY", true),
-		WebToken:       os.Getenv("BULWARK_WEB_TOKEN"),
+		Profile:        ResolveProfile(os.Getenv("BULWARK_PROFILE")),
+		WebToken:       getEnv("BULWARK_WEB_TOKEN_WRITE", os.Getenv("BULWARK_WEB_TOKEN")),
+		ReadToken:      os.Getenv("BULWARK_WEB_TOKEN_READ"),
+		WidgetToken:    os.Getenv("BULWARK_WIDGET_TOKEN"),
 		DistDir:        getEnv("BULWARK_UI_DIST", "web/dist"),
 		DataDir:        getEnv("BULWARK_DATA_DIR", "/data"),
 		WriteRateRPS:   getEnvFloat("BULWARK_WEB_WRITE_RPS", 1.0),
@@ -45,14 +160,56 @@ func LoadConfig() Config {
 		// The UI polls /api/overview and /api/plan every 60s. A TTL below that
 		// meant every poll missed the cache and rebuilt the whole plan, which
 		// is what exhausted Docker Hub's anonymous pull limit.
-		PlanCacheTTL:   getEnvDuration("BULWARK_PLAN_CACHE_TTL", 5*time.Minute),
-		DigestCacheTTL: getEnvDuration("BULWARK_DIGEST_CACHE_TTL", registry.DefaultDigestTTL),
-		LockTimeout:    getEnvDuration("BULWARK_LOCK_TIMEOUT", 5*time.Minute),
-		MetricsEnabled: getEnvBool("BULWARK_METRICS_ENABLED", false),
+		PlanCacheTTL:         getEnvDuration("BULWARK_PLAN_CACHE_TTL", 5*time.Minute),
+		DigestCacheTTL:       getEnvDuration("BULWARK_DIGEST_CACHE_TTL", registry.DefaultDigestTTL),
+		LockTimeout:          getEnvDuration("BULWARK_LOCK_TIMEOUT", 5*time.Minute),
+		PlanTimeout:          getEnvDuration("BULWARK_PLAN_TIMEOUT", defaultPlanTimeout),
+		DiscoveryTimeout:     getEnvDuration("BULWARK_DISCOVERY_TIMEOUT", defaultDiscoveryTimeout),
+		ServiceUpdateTimeout: getEnvDuration("BULWARK_SERVICE_UPDATE_TIMEOUT", defaultServiceUpdateTimeout),
+		SessionJWT:           getEnvBool("BULWARK_SESSION_JWT", false),
+		SessionKeyRotation:   getEnvDuration("BULWARK_SESSION_KEY_ROTATION", defaultKeyRotation),
+		AdminUsername:        strings.TrimSpace(os.Getenv("BULWARK_ADMIN_USERNAME")),
+		AdminPassword:        os.Getenv("BULWARK_ADMIN_PASSWORD"),
+		DowntimeSLA:          getEnvDuration("BULWARK_DOWNTIME_SLA", 0),
+		CheckConcurrency:     getEnvInt("BULWARK_CHECK_CONCURRENCY", planner.DefaultConcurrency),
+		CleanupPolicy:        strings.ToLower(getEnv("BULWARK_CLEANUP_POLICY", cleanupNone)),
+		CleanupKeepDigests:   getEnvInt("BULWARK_CLEANUP_KEEP_DIGESTS", defaultCleanupKeepDigests),
+		LockedSettings:       envLockedSettings(),
+		RegistryHTTP:         registry.HTTPOptionsFromEnv(),
+		DockerDataRoot:       strings.TrimSpace(os.Getenv("BULWARK_DOCKER_DATA_ROOT")),
+		MaxConcurrentPulls:   getEnvInt("BULWARK_MAX_CONCURRENT_PULLS", 0),
+		PullDelay:            getEnvDuration("BULWARK_PULL_DELAY", 0),
+		SequentialPullMB:     getEnvInt("BULWARK_SEQUENTIAL_PULL_MB", 0),
+		ReconcileInterval:    getEnvDuration("BULWARK_RECONCILE_INTERVAL", 6*time.Hour),
+		ResourceSnapshots:    getEnvBool("BULWARK_RESOURCE_SNAPSHOTS", true),
+		MemoryJumpPercent:    getEnvInt("BULWARK_MEMORY_JUMP_PERCENT", executor.DefaultMemoryJumpPercent),
+		SBOMEnabled:          getEnvBool("BULWARK_SBOM_ENABLED", false),
+		SBOMFormat:           getEnv("BULWARK_SBOM_FORMAT", "cyclonedx-json"),
+		SyftPath:             getEnv("BULWARK_SYFT_PATH", "syft"),
+		RunArtifacts:         getEnvBool("BULWARK_RUN_ARTIFACTS", true),
+		RunArtifactsKeep:     getEnvInt("BULWARK_RUN_ARTIFACTS_KEEP", 50),
+		MetricsEnabled:       getEnvBool("BULWARK_METRICS_ENABLED", false),
+		MetricsServiceLabels: getEnvBool("BULWARK_METRICS_SERVICE_LABELS", true),
+		MetricsMaxServices:   getEnvInt("BULWARK_METRICS_MAX_SERVICES", metrics.DefaultMaxServiceLabels),
+		TelemetryEnabled:     getEnvBool("BULWARK_TELEMETRY_ENABLED", false),
+		TelemetryURL:         os.Getenv("BULWARK_TELEMETRY_URL"),
+		TelemetryInterval:    getEnvDuration("BULWARK_TELEMETRY_INTERVAL", telemetry.DefaultInterval),
+		SchedulerJitter:      getEnvDuration("BULWARK_SCHEDULER_JITTER", 0),
+		NotifyJobTimeout:     getEnvDuration("BULWARK_NOTIFY_JOB_TIMEOUT", 10*time.Minute),
+		AutoUpdateTimeout:    getEnvDuration("BULWARK_AUTO_UPDATE_TIMEOUT", time.Hour),
+		Timezone:             strings.TrimSpace(os.Getenv("BULWARK_TZ")),
+		MaxConcurrentRuns:    getEnvInt("BULWARK_MAX_CONCURRENT_RUNS", 1),
+		CORSOrigins:          getEnvList("BULWARK_CORS_ORIGINS"),
+		CORSCredentials:      getEnvBool("BULWARK_CORS_CREDENTIALS", false),
+		LegacyAPIPaths:       getEnvBool("BULWARK_API_LEGACY_PATHS", true),
+		IncrementalPlan:      getEnvBool("BULWARK_INCREMENTAL_PLAN", true),
+		Registries:           registry.EndpointsFromEnv(),
+		Mock:                 getEnvBool("BULWARK_MOCK", false),
 	}
 }
 
 func (c Config) WithDefaults() Config {
+	c.Profile = ResolveProfile(c.Profile)
 	if c.ConfigPath == "" {
 		c.ConfigPath = getEnv("BULWARK_CONFIG_PATH", filepath.Join(c.DataDir, "bulwark.json"))
 	}
@@ -64,6 +221,36 @@ func (c Config) WithDefaults() Config {
 	if c.PlanCacheTTL <= 0 {
 		c.PlanCacheTTL = 5 * time.Minute
 	}
+	if c.PlanTimeout <= 0 {
+		c.PlanTimeout = defaultPlanTimeout
+	}
+	if c.DiscoveryTimeout <= 0 {
+		c.DiscoveryTimeout = defaultDiscoveryTimeout
+	}
+	if c.ServiceUpdateTimeout <= 0 {
+		c.ServiceUpdateTimeout = defaultServiceUpdateTimeout
+	}
+	if c.CheckConcurrency < 1 {
+		c.CheckConcurrency = planner.DefaultConcurrency
+	}
+	if c.CleanupPolicy != cleanupDangling {
+		c.CleanupPolicy = cleanupNone
+	}
+	if c.CleanupKeepDigests < 1 {
+		c.CleanupKeepDigests = defaultCleanupKeepDigests
+	}
+	if c.NotifyJobTimeout <= 0 {
+		c.NotifyJobTimeout = 10 * time.Minute
+	}
+	if c.AutoUpdateTimeout <= 0 {
+		c.AutoUpdateTimeout = time.Hour
+	}
+	if c.MaxConcurrentRuns < 1 {
+		c.MaxConcurrentRuns = 1
+	}
+	if c.SessionKeyRotation <= 0 {
+		c.SessionKeyRotation = defaultKeyRotation
+	}
 	return c
 }
 
@@ -74,6 +261,16 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	value := strings.TrimSpace(strings.ToLower(os.Getenv(key)))
 	if value == "" {
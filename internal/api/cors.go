@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// corsMiddleware lets browser dashboards on the configured origins call the
+// API. Requests from other origins get no CORS headers, so browsers block
+// them as before. Credentials are never allowed for the "*" wildcard.
+func corsMiddleware(next http.Handler, origins []string, credentials bool) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+
+	allowAny := false
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAny = true
+			continue
+		}
+		allowed[strings.TrimSuffix(origin, "/")] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		switch {
+		case allowed[origin]:
+			header.Set("Access-Control-Allow-Origin", origin)
+			if credentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		case allowAny:
+			header.Set("Access-Control-Allow-Origin", "*")
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		header.Set("Access-Control-Expose-Headers", "ETag, "+APIVersionHeader+", "+RequestIDHeader+", Deprecation, Link")
+
+		// Answer preflight requests directly
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			header.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-None-Match, "+APIVersionHeader+", "+RequestIDHeader)
+			header.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func corsTestHandler(origins []string, credentials bool) http.Handler {
+	return corsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+	}), origins, credentials)
+}
+
+func TestCORS_AllowedOrigin(t *testing.T) {
+	handler := corsTestHandler([]string{"https://home.example.com/"}, true)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/overview", nil)
+	req.Header.Set("Origin", "https://home.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://home.example.com" {
+		t.Errorf("expected origin echoed, got %q", got)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Error("expected credentials allowed")
+	}
+}
+
+func TestCORS_UnknownOrigin(t *testing.T) {
+	handler := corsTestHandler([]string{"https://home.example.com"}, false)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/overview", nil)
+	req.Header.Set("Origin", "https://evil.example.net")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("expected no CORS headers for unlisted origin")
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("expected request to be served, got %d", w.Code)
+	}
+}
+
+func TestCORS_WildcardPreflight(t *testing.T) {
+	handler := corsTestHandler([]string{"*"}, true)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/plan", nil)
+	req.Header.Set("Origin", "https://grafana.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 preflight, got %d", w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("expected wildcard origin, got %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Error("credentials must not be allowed with a wildcard origin")
+	}
+}
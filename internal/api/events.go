@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// eventLevels ranks run event levels from least to most severe.
+var eventLevels = map[string]int{
+	"debug": 0,
+	"info":  1,
+	"warn":  2,
+	"error": 3,
+}
+
+// eventFilter selects run events by the query of GET /api/runs/{id}:
+// ?level= is the least severe level kept, ?step=, ?target= and ?service=
+// match exactly, and ?q= searches messages, targets, services and steps
+// case-insensitively.
+type eventFilter struct {
+	minLevel int
+	step     string
+	target   string
+	service  string
+	text     string
+}
+
+// parseEventFilter reads an eventFilter from query. The filter is nil when
+// the query selects nothing.
+func parseEventFilter(query url.Values) (*eventFilter, error) {
+	filter := &eventFilter{
+		step:    strings.TrimSpace(query.Get("step")),
+		target:  strings.TrimSpace(query.Get("target")),
+		service: strings.TrimSpace(query.Get("service")),
+		text:    strings.ToLower(strings.TrimSpace(query.Get("q"))),
+	}
+	if level := strings.ToLower(strings.TrimSpace(query.Get("level"))); level != "" {
+		rank, ok := eventLevels[level]
+		if !ok {
+			return nil, fmt.Errorf("unknown level %q; use debug, info, warn or error", level)
+		}
+		filter.minLevel = rank
+	}
+	if *filter == (eventFilter{}) {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+func (f *eventFilter) match(event RunEvent) bool {
+	rank, ok := eventLevels[event.Level]
+	if !ok {
+		rank = eventLevels["info"]
+	}
+	if rank < f.minLevel {
+		return false
+	}
+	if f.step != "" && event.Step != f.step {
+		return false
+	}
+	if f.target != "" && event.Target != f.target {
+		return false
+	}
+	if f.service != "" && event.Service != f.service {
+		return false
+	}
+	if f.text != "" {
+		haystack := strings.ToLower(strings.Join([]string{event.Message, event.Target, event.Service, event.Step}, "\n"))
+		if !strings.Contains(haystack, f.text) {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns the events f keeps, in their original order.
+func (f *eventFilter) apply(events []RunEvent) []RunEvent {
+	matched := make([]RunEvent, 0, len(events))
+	for _, event := range events {
+		if f.match(event) {
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleRun_FiltersEvents(t *testing.T) {
+	s := testServer()
+	run := s.runs.CreateRun("apply")
+	s.runs.AddEvent(run.ID, RunEvent{Level: "info", Step: "plan", Message: "Building update plan"})
+	s.runs.AddEvent(run.ID, RunEvent{Level: "warn", Target: "app", Service: "web", Step: "pull", Message: "Pre-pull failed: timeout"})
+	s.runs.AddEvent(run.ID, RunEvent{Level: "error", Target: "app", Service: "db", Step: "probe", Message: "Probe failed"})
+	s.runs.AddEvent(run.ID, RunEvent{Level: "info", Target: "app", Service: "web", Step: "update", Message: "Updated"})
+
+	get := func(query string) []RunEvent {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/runs/"+run.ID+query, nil)
+		w := httptest.NewRecorder()
+		s.handleRun(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", query, w.Code)
+		}
+		var resp Run
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		return resp.Events
+	}
+
+	if events := get(""); len(events) != 4 {
+		t.Errorf("expected every event without a filter, got %d", len(events))
+	}
+	if events := get("?level=warn"); len(events) != 2 || events[0].Step != "pull" || events[1].Step != "probe" {
+		t.Errorf("expected the warn and error events, got %+v", events)
+	}
+	if events := get("?service=web&level=info"); len(events) != 2 {
+		t.Errorf("expected web's events, got %+v", events)
+	}
+	if events := get("?step=probe"); len(events) != 1 || events[0].Service != "db" {
+		t.Errorf("expected the probe event, got %+v", events)
+	}
+	if events := get("?q=TIMEOUT"); len(events) != 1 || events[0].Step != "pull" {
+		t.Errorf("expected a case-insensitive message match, got %+v", events)
+	}
+	if events := get("?q=nothing"); len(events) != 0 {
+		t.Errorf("expected no events, got %+v", events)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/runs/"+run.ID+"?level=loud", nil)
+	w := httptest.NewRecorder()
+	s.handleRun(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown level, got %d", w.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// fieldSet is a parsed ?fields= selection. Each key maps to the selection
+// applied beneath it; a nil value keeps the whole subtree.
+type fieldSet map[string]fieldSet
+
+// parseFields parses a comma-separated list of dotted paths such as
+// "update_count,items.service_name,items.update_available".
+func parseFields(raw string) fieldSet {
+	fields := fieldSet{}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		node := fields
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, seen := node[part]
+			if i == len(parts)-1 {
+				// The full path wins over a narrower selection of the same key
+				node[part] = nil
+				break
+			}
+			if seen && child == nil {
+				break
+			}
+			if child == nil {
+				child = fieldSet{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return fields
+}
+
+// apply trims a decoded JSON value to the selected fields. Arrays are
+// filtered element by element so "items.name" selects across a list.
+func (f fieldSet) apply(value interface{}) interface{} {
+	if f == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(f))
+		for key, sub := range f {
+			if child, ok := v[key]; ok {
+				out[key] = sub.apply(child)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = f.apply(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// fieldsMiddleware lets clients request a subset of a JSON response with
+// ?fields=. Error responses and non-JSON content are passed through whole.
+func fieldsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw := r.URL.Query().Get("fields")
+		if raw == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedResponseWriter{w: w, header: w.Header(), status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		body := rec.body.Bytes()
+		if rec.status == http.StatusOK && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+			var decoded interface{}
+			if err := json.Unmarshal(body, &decoded); err == nil {
+				if filtered, err := json.Marshal(parseFields(raw).apply(decoded)); err == nil {
+					body = append(filtered, '\n')
+					w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+				}
+			}
+		}
+
+		w.WriteHeader(rec.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// bufferedResponseWriter collects a response so it can be rewritten. It
+// unwraps to w, so handlers can still extend the write deadline of a long
+// request through http.ResponseController.
+type bufferedResponseWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) Header() http.Header { return b.header }
+
+func (b *bufferedResponseWriter) WriteHeader(status int) { b.status = status }
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *bufferedResponseWriter) Unwrap() http.ResponseWriter { return b.w }
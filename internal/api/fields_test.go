@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFieldsMiddleware(t *testing.T) {
+	handler := fieldsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"update_count": 2,
+			"target_count": 1,
+			"items": []map[string]interface{}{
+				{"service_name": "web", "image": "nginx", "update_available": true},
+				{"service_name": "db", "image": "postgres", "update_available": false},
+			},
+		})
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/plan?fields=update_count,items.service_name", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	var got map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != 2 || got["update_count"] != float64(2) {
+		t.Fatalf("unexpected top-level fields: %v", got)
+	}
+	items := got["items"].([]interface{})
+	first := items[0].(map[string]interface{})
+	if len(first) != 1 || first["service_name"] != "web" {
+		t.Errorf("unexpected item fields: %v", first)
+	}
+}
+
+func TestParseFields_WholeKeyWins(t *testing.T) {
+	fields := parseFields("items.name, items")
+	if sub, ok := fields["items"]; !ok || sub != nil {
+		t.Errorf("expected full items selection, got %v", fields)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/metrics"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type containerLister interface {
+	ListContainers(ctx context.Context, all bool) ([]docker.Container, error)
+}
+
+// discoveryFingerprint summarizes what discovery sees for plan: the running
+// containers and the modification times of the compose files behind its
+// targets. Starting or stopping a container, or editing a compose file,
+// changes the fingerprint.
+func discoveryFingerprint(ctx context.Context, lister containerLister, plan *planner.Plan) (string, error) {
+	containers, err := lister.ListContainers(ctx, false)
+	if err != nil {
+		return "", err
+	}
+
+	var entries []string
+	for _, c := range containers {
+		entries = append(entries, "container:"+c.ID+":"+c.ImageID)
+	}
+
+	seen := make(map[string]bool)
+	for _, item := range plan.Items {
+		if item.TargetType != state.TargetTypeCompose || item.Target == nil || seen[item.Target.Path] {
+			continue
+		}
+		seen[item.Target.Path] = true
+		modTime := "missing"
+		if info, err := os.Stat(item.Target.Path); err == nil {
+			modTime = fmt.Sprint(info.ModTime().UnixNano())
+		}
+		entries = append(entries, "compose:"+item.Target.Path+":"+modTime)
+	}
+
+	sort.Strings(entries)
+	h := sha256.New()
+	for _, entry := range entries {
+		h.Write([]byte(entry))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachedPlan returns the cached plan unless discovery has changed since it
+// was built, in which case the cache is dropped.
+func (s *Server) cachedPlan(ctx context.Context) (*planner.Plan, bool) {
+	cached, ok := s.planCache.Get()
+	if !ok {
+		return nil, false
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return cached, true
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	fingerprint, err := discoveryFingerprint(ctx, dockerClient, cached)
+	if err != nil {
+		return cached, true
+	}
+	if s.planCache.InvalidateIfChanged(fingerprint) {
+		s.logger.Debug().Msg("Discovery changed, invalidated cached plan")
+		return nil, false
+	}
+	return cached, true
+}
+
+// cachePlan stores plan with the discovery fingerprint it was built from.
+// Without a fingerprint the plan still expires by TTL.
+func (s *Server) cachePlan(ctx context.Context, plan *planner.Plan) {
+	var fingerprint string
+	dockerClient, err := docker.NewClient()
+	if err == nil {
+		defer func() { _ = dockerClient.Close() }()
+		fingerprint, err = discoveryFingerprint(ctx, dockerClient, plan)
+	}
+	if err != nil {
+		s.logger.Debug().Err(err).Msg("Failed to fingerprint discovery, caching plan by TTL only")
+	}
+	s.planCache.SetWithFingerprint(plan, fingerprint)
+	s.persistPlan(ctx, plan)
+	recordUpdatesAvailable(plan)
+}
+
+// recordUpdatesAvailable exports the pending updates of each target in a
+// full plan. Targets without updates report zero.
+func recordUpdatesAvailable(plan *planner.Plan) {
+	counts := make(map[string]int)
+	for _, item := range plan.Items {
+		if item.UpdateAvailable {
+			counts[item.TargetName]++
+		} else if _, ok := counts[item.TargetName]; !ok {
+			counts[item.TargetName] = 0
+		}
+	}
+	metrics.SetUpdatesAvailable(counts)
+}
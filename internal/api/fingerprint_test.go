@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type fakeLister struct {
+	containers []docker.Container
+}
+
+func (f *fakeLister) ListContainers(ctx context.Context, all bool) ([]docker.Container, error) {
+	return f.containers, nil
+}
+
+func TestDiscoveryFingerprint(t *testing.T) {
+	composePath := filepath.Join(t.TempDir(), "compose.yml")
+	if err := os.WriteFile(composePath, []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &planner.Plan{Items: []planner.PlanItem{{
+		TargetType: state.TargetTypeCompose,
+		Target:     &state.Target{Type: state.TargetTypeCompose, Path: composePath},
+	}}}
+	lister := &fakeLister{containers: []docker.Container{{ID: "a", ImageID: "sha256:1"}}}
+	ctx := context.Background()
+
+	first, err := discoveryFingerprint(ctx, lister, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := discoveryFingerprint(ctx, lister, plan); again != first {
+		t.Fatal("expected stable fingerprint")
+	}
+
+	lister.containers = append(lister.containers, docker.Container{ID: "b", ImageID: "sha256:2"})
+	started, _ := discoveryFingerprint(ctx, lister, plan)
+	if started == first {
+		t.Fatal("expected fingerprint to change when a container starts")
+	}
+
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(composePath, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if edited, _ := discoveryFingerprint(ctx, lister, plan); edited == started {
+		t.Fatal("expected fingerprint to change when the compose file is modified")
+	}
+}
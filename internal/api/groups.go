@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// groupSummary describes one target group (bulwark.group) of the plan.
+type groupSummary struct {
+	Name             string `json:"name"`
+	Targets          int    `json:"targets"`
+	Services         int    `json:"services"`
+	UpdatesAvailable int    `json:"updates_available"`
+	Paused           bool   `json:"paused"`
+	// LastFailure is the group's latest failed update in the last 14 days.
+	LastFailure *overviewFailure `json:"last_failure,omitempty"`
+}
+
+type groupsResponse struct {
+	Groups []groupSummary `json:"groups"`
+}
+
+// groupApplyRequest is an apply run limited to one group.
+type groupApplyRequest struct {
+	Mode     string `json:"mode"`
+	Force    bool   `json:"force,omitempty"`
+	PullOnly bool   `json:"pull_only,omitempty"`
+	RunNotes
+}
+
+// groupSummaries summarizes the target groups of plan, including paused
+// groups whose targets are currently not discovered. failures maps target
+// IDs to their latest failed update.
+func (s *Server) groupSummaries(ctx context.Context, plan *planner.Plan, failures map[string]*overviewFailure) []groupSummary {
+	byName := make(map[string]*groupSummary)
+	targets := make(map[string]map[string]bool)
+	summary := func(name string) *groupSummary {
+		if _, ok := byName[name]; !ok {
+			byName[name] = &groupSummary{Name: name}
+			targets[name] = make(map[string]bool)
+		}
+		return byName[name]
+	}
+
+	if plan != nil {
+		for _, item := range plan.Items {
+			if item.Group == "" {
+				continue
+			}
+			group := summary(item.Group)
+			group.Services++
+			if item.UpdateAvailable {
+				group.UpdatesAvailable++
+			}
+			targets[item.Group][item.TargetID] = true
+		}
+	}
+	if s.store != nil {
+		pauses, err := s.store.ListPausedGroups(ctx)
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to load paused groups")
+		}
+		for _, pause := range pauses {
+			summary(pause.Group).Paused = true
+		}
+	}
+
+	groups := make([]groupSummary, 0, len(byName))
+	for name, group := range byName {
+		group.Targets = len(targets[name])
+		group.LastFailure = latestFailure(failures, targets[name])
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	plan, err := s.getPlan(r.Context(), planRequest{})
+	if err != nil {
+		writeError(w, statusForError(err), "plan failed", err.Error())
+		return
+	}
+	failures := lastFailures(s.trendHistory(r.Context(), time.Now()))
+	writeJSON(w, http.StatusOK, groupsResponse{Groups: s.groupSummaries(r.Context(), plan, failures)})
+}
+
+// handleGroup routes /api/groups/{name}/{action}.
+func (s *Server) handleGroup(w http.ResponseWriter, r *http.Request) {
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/groups/"), "/")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "missing group name", "")
+		return
+	}
+	switch action {
+	case "plan":
+		s.handleGroupPlan(w, r, name)
+	case "apply":
+		if s.cfg.Observer() {
+			writeError(w, http.StatusNotFound, "not found", r.URL.Path)
+			return
+		}
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleGroupApply(w, r, name)
+		})).ServeHTTP(w, r)
+	case "pause":
+		s.handleGroupPause(w, r, name)
+	default:
+		writeError(w, http.StatusNotFound, "not found", r.URL.Path)
+	}
+}
+
+func (s *Server) handleGroupPlan(w http.ResponseWriter, r *http.Request, group string) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	plan, err := s.getPlan(r.Context(), planRequest{Group: group})
+	if err != nil {
+		writeError(w, statusForError(err), "plan failed", err.Error())
+		return
+	}
+	if plan.TargetCount == 0 {
+		writeError(w, http.StatusNotFound, "group not found", group)
+		return
+	}
+	writeJSON(w, http.StatusOK, planResponse{Plan: plan})
+}
+
+func (s *Server) handleGroupApply(w http.ResponseWriter, r *http.Request, group string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req groupApplyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if mode == "" {
+		mode = "safe"
+	}
+	if mode != "safe" && mode != "all" {
+		writeError(w, http.StatusBadRequest, "invalid mode", "use safe or all")
+		return
+	}
+	if err := req.RunNotes.normalize(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid run notes", err.Error())
+		return
+	}
+
+	apply := applyRequest{
+		Mode:     mode,
+		Group:    group,
+		Force:    req.Force,
+		PullOnly: req.PullOnly,
+		Trigger:  state.TriggerManualUI,
+		Actor:    s.actor(r),
+		RunNotes: req.RunNotes,
+	}
+	run, position, _, err := s.enqueueApply("apply", priorityManual, apply, mode)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "run queue unavailable", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, applyResponse{RunID: run.ID, Status: "queued", QueuePosition: position})
+}
+
+// handleGroupPause serves a group's pause: GET reports it, POST pauses the
+// group and DELETE resumes it.
+func (s *Server) handleGroupPause(w http.ResponseWriter, r *http.Request, group string) {
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "pausing unavailable", "state persistence is disabled")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		pauses, err := s.store.ListPausedGroups(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list paused groups", err.Error())
+			return
+		}
+		for _, pause := range pauses {
+			if pause.Group == group {
+				writeJSON(w, http.StatusOK, pause)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "group not paused", group)
+	case http.MethodPost:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			pause := &state.GroupPause{Group: group}
+			if err := s.store.PauseGroup(r.Context(), pause); err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to pause group", err.Error())
+				return
+			}
+			s.planCache.Invalidate()
+			s.requestLogger(r).Info().Str("group", group).Msg("Group paused")
+			writeJSON(w, http.StatusCreated, pause)
+		})).ServeHTTP(w, r)
+	case http.MethodDelete:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := s.store.ResumeGroup(r.Context(), group); err != nil {
+				writeError(w, statusForError(err), "failed to resume group", err.Error())
+				return
+			}
+			s.planCache.Invalidate()
+			s.requestLogger(r).Info().Str("group", group).Msg("Group resumed")
+			w.WriteHeader(http.StatusNoContent)
+		})).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
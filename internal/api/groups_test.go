@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestGroupPause(t *testing.T) {
+	do := serviceServer(t)
+
+	if w := do(http.MethodGet, "/api/groups/media/pause", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 before pausing, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/groups/media/pause", ""); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := do(http.MethodGet, "/api/groups/media/pause", "")
+	var pause state.GroupPause
+	if err := json.NewDecoder(w.Body).Decode(&pause); err != nil || pause.Group != "media" {
+		t.Fatalf("expected the media pause, got %+v (%v)", pause, err)
+	}
+
+	if w := do(http.MethodDelete, "/api/groups/media/pause", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/groups/media/pause", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 resuming twice, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/groups/media/unknown", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown action, got %d", w.Code)
+	}
+}
+
+func TestGroupSummaries(t *testing.T) {
+	s := setupTestServer(t)
+	if err := s.store.PauseGroup(context.Background(), &state.GroupPause{Group: "backup"}); err != nil {
+		t.Fatalf("PauseGroup failed: %v", err)
+	}
+
+	plan := &planner.Plan{Items: []planner.PlanItem{
+		{TargetID: "t1", Group: "media", UpdateAvailable: true},
+		{TargetID: "t1", Group: "media"},
+		{TargetID: "t2", Group: "media", UpdateAvailable: true},
+		{TargetID: "t3"},
+	}}
+	groups := s.groupSummaries(context.Background(), plan, nil)
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+	if got := groups[0]; got != (groupSummary{Name: "backup", Paused: true}) {
+		t.Errorf("unexpected backup summary: %+v", got)
+	}
+	if got := groups[1]; got != (groupSummary{Name: "media", Targets: 2, Services: 3, UpdatesAvailable: 2}) {
+		t.Errorf("unexpected media summary: %+v", got)
+	}
+}
@@ -10,14 +10,22 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"golang.org/x/sync/errgroup"
+
+	"github.com/itsmrshow/bulwark/internal/artifacts"
 	"github.com/itsmrshow/bulwark/internal/discovery"
 	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/notify"
 	"github.com/itsmrshow/bulwark/internal/planner"
 	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/probe"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/sbom"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
 
@@ -25,6 +33,17 @@ type healthResponse struct {
 	Status    string `json:"status"`
 	ReadOnly  bool   `json:"read_only"`
 	UIEnabled bool   `json:"ui_enabled"`
+	Profile   string `json:"profile"`
+	// Access is what the caller's credentials allow: "none", "read", "write"
+	// or "admin".
+	Access string `json:"access"`
+	// User is the logged-in username; Accounts is set once any user exists.
+	User     string `json:"user,omitempty"`
+	Accounts bool   `json:"accounts"`
+	// Maintenance is the active maintenance window, shown as a banner.
+	Maintenance *state.Maintenance `json:"maintenance,omitempty"`
+	// Mock is set when the server runs against a simulated Docker host.
+	Mock bool `json:"mock,omitempty"`
 }
 
 type overviewResponse struct {
@@ -33,10 +52,18 @@ type overviewResponse struct {
 	ManagedTargets   int            `json:"managed_targets"`
 	ManagedServices  int            `json:"managed_services"`
 	UpdatesAvailable int            `json:"updates_available"`
+	MutableTags      int            `json:"mutable_tags"` // Services tracking tags such as latest
 	LastRun          *overviewRun   `json:"last_run,omitempty"`
 	Failures         int            `json:"failures"`
 	Rollbacks        int            `json:"rollbacks"`
 	Activity         []activityItem `json:"activity"`
+	Groups           []groupSummary `json:"groups,omitempty"`
+	Hosts            []hostSummary  `json:"hosts"`
+	Trends           overviewTrends `json:"trends"`
+	PlanStale        bool           `json:"plan_stale,omitempty"` // Counts come from the plan persisted before a restart
+	// Maintenance is the active maintenance window; scheduled applies are
+	// paused while it lasts.
+	Maintenance *state.Maintenance `json:"maintenance,omitempty"`
 }
 
 type overviewRun struct {
@@ -52,20 +79,45 @@ type activityItem struct {
 	Message   string    `json:"message"`
 }
 
+// maxHistoryPageSize caps how many history entries one response may carry.
+const maxHistoryPageSize = 500
+
 type planRequest struct {
 	Target          string `json:"target,omitempty"`
+	Group           string `json:"group,omitempty"`
 	IncludeDisabled bool   `json:"include_disabled,omitempty"`
 }
 
 type applyRequest struct {
 	Mode       string   `json:"mode"`
 	Target     string   `json:"target,omitempty"`
+	Group      string   `json:"group,omitempty"`
 	ServiceIDs []string `json:"service_ids,omitempty"`
-	Force      bool     `json:"force,omitempty"`
+	// Select narrows the apply to the plan items it matches, e.g. every
+	// image under linuxserver/. Unlike service_ids it does not force items
+	// past their policy.
+	Select *planner.Selector `json:"select,omitempty"`
+	Force  bool              `json:"force,omitempty"`
+	// PullOnly stops after the pull phase, e.g. to fetch images during the day
+	// ahead of a maintenance window.
+	PullOnly bool `json:"pull_only,omitempty"`
+
+	// Trigger and Actor record what queued the apply and who, for history.
+	Trigger state.UpdateTrigger `json:"-"`
+	Actor   string              `json:"-"`
+
+	// RunNotes are stored with the run the apply starts.
+	RunNotes
 }
 
 type applyResponse struct {
-	RunID string `json:"run_id"`
+	RunID         string `json:"run_id"`
+	Status        string `json:"status,omitempty"`
+	QueuePosition int    `json:"queue_position,omitempty"`
+}
+
+type runsResponse struct {
+	Runs []Run `json:"runs"`
 }
 
 type historyResponse struct {
@@ -82,52 +134,62 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	}
 
 	writeJSON(w, http.StatusOK, healthResponse{
-		Status:    "ok",
-		ReadOnly:  s.cfg.ReadOnly,
-		UIEnabled: s.cfg.UIEnabled,
+		Status:      "ok",
+		ReadOnly:    s.cfg.ReadOnly,
+		UIEnabled:   s.cfg.UIEnabled,
+		Profile:     ResolveProfile(s.cfg.Profile),
+		Access:      s.access(r).String(),
+		User:        s.credentials(r).username,
+		Accounts:    s.hasUsers.Load(),
+		Maintenance: s.maintenance(r.Context()),
+		Mock:        s.cfg.Mock,
 	})
 }
 
+// handleLogin opens a session for a username and password, or for one of
+// the configured tokens.
 func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
 		return
 	}
 
-	if s.cfg.WebToken == "" {
-		writeError(w, http.StatusServiceUnavailable, "authentication disabled", "BULWARK_WEB_TOKEN is not configured")
-		return
-	}
-
 	var req struct {
-		Token string `json:"token"`
+		Token    string `json:"token"`
+		Username string `json:"username"`
+		Password string `json:"password"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
 		return
 	}
 
-	if req.Token != s.cfg.WebToken {
-		writeError(w, http.StatusUnauthorized, "invalid token", "")
-		return
+	var access accessLevel
+	if req.Username != "" {
+		user, err := s.authenticate(r.Context(), req.Username, req.Password)
+		if err != nil {
+			s.requestLogger(r).Warn().Str("username", req.Username).Msg("Failed login")
+			writeError(w, http.StatusUnauthorized, "invalid credentials", "")
+			return
+		}
+		access = roleAccess[user.Role]
+	} else {
+		if s.webToken() == "" && s.cfg.ReadToken == "" {
+			writeError(w, http.StatusServiceUnavailable, "authentication disabled", "BULWARK_WEB_TOKEN is not configured")
+			return
+		}
+		if access = s.tokenAccess(req.Token); access == accessNone {
+			writeError(w, http.StatusUnauthorized, "invalid token", "")
+			return
+		}
 	}
 
-	// Create session
-	sessionID := s.sessions.create()
-
-	// Set httpOnly cookie (secure in production with HTTPS)
-	http.SetCookie(w, &http.Cookie{
-		Name:     "bulwark_session",
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   86400, // 24 hours
-	})
+	s.startSession(w, access, req.Username)
 
 	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"message": "Logged in successfully",
+		"access":  access.String(),
 	})
 }
 
@@ -157,48 +219,22 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func (s *Server) handleEnableWrites(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
 		return
 	}
 
-	if s.cfg.ReadOnly {
-		writeError(w, http.StatusForbidden, "read-only mode", "Bulwark is in global read-only mode (BULWARK_UI_READONLY=true)")
-		return
-	}
-
-	if s.cfg.WebToken == "" {
-		writeError(w, http.StatusServiceUnavailable, "authentication disabled", "BULWARK_WEB_TOKEN is not configured")
-		return
-	}
-
-	// Auto-authenticate using the backend token (no user token required)
-	sessionID := s.sessions.create()
-
-	// Set httpOnly cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "bulwark_session",
-		Value:    sessionID,
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   86400, // 24 hours
-	})
+	resp := s.buildOverview(r.Context())
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"message": "Write mode enabled",
-	})
+	// generated_at changes on every request, so leave it out of the ETag
+	tagged := resp
+	tagged.GeneratedAt = time.Time{}
+	writeJSONConditional(w, r, jsonETag(tagged), resp)
 }
 
-func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
-		return
-	}
-
-	ctx := r.Context()
+// buildOverview summarizes the current plan and recent update history.
+func (s *Server) buildOverview(ctx context.Context) overviewResponse {
 	plan, planErr := s.getPlan(ctx, planRequest{})
 
 	var managedTargets int
@@ -208,9 +244,10 @@ func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
 		managedServices = plan.ServiceCount
 	}
 
-	updatesAvailable := 0
+	updatesAvailable, mutableTags := 0, 0
 	if plan != nil {
 		updatesAvailable = plan.UpdateCount
+		mutableTags = plan.MutableTagCount
 	}
 
 	var failures, rollbacks int
@@ -221,7 +258,7 @@ func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
 		history, err := s.store.GetUpdateHistory(ctx, 50)
 		if err == nil {
 			for i, item := range history {
-				if !item.Success {
+				if !item.Success && !item.ResultCode.IsSkip() {
 					failures++
 				}
 				if item.RollbackPerformed {
@@ -238,23 +275,35 @@ func (s *Server) handleOverview(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	now := time.Now()
+	recent := s.trendHistory(ctx, now)
+	failed := lastFailures(recent)
+
 	resp := overviewResponse{
-		GeneratedAt:      time.Now().UTC(),
+		GeneratedAt:      now.UTC(),
 		ReadOnly:         s.cfg.ReadOnly,
 		ManagedTargets:   managedTargets,
 		ManagedServices:  managedServices,
 		UpdatesAvailable: updatesAvailable,
+		MutableTags:      mutableTags,
 		LastRun:          lastRun,
 		Failures:         failures,
 		Rollbacks:        rollbacks,
 		Activity:         activity,
+		Groups:           s.groupSummaries(ctx, plan, failed),
+		Hosts:            s.hostSummaries(ctx, plan, failed),
+		Trends:           buildTrends(recent, now),
+		Maintenance:      s.maintenance(ctx),
+	}
+	if info, ok := s.planCache.Info(plan); ok {
+		resp.PlanStale = info.Stale
 	}
 
 	if planErr != nil {
 		s.logger.Warn().Err(planErr).Msg("overview plan failed")
 	}
 
-	writeJSON(w, http.StatusOK, resp)
+	return resp
 }
 
 func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
@@ -263,32 +312,72 @@ func (s *Server) handleTargets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	switch r.URL.Query().Get("state") {
+	case "":
+	case "orphaned":
+		s.handleOrphanedTargets(w, r)
+		return
+	default:
+		writeError(w, http.StatusBadRequest, "invalid state", "state must be orphaned")
+		return
+	}
+
 	ctx := r.Context()
 	targets, err := s.discoverTargets(ctx, "")
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "discovery failed", err.Error())
+		writeError(w, statusForError(err), "discovery failed", err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{"targets": targets})
+	writeJSONConditional(w, r, targetsETag(targets), map[string]interface{}{"targets": targets})
 }
 
-func (s *Server) handleTargetByID(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
-		return
+// targetsETag tags discovered targets by content. Discovery stamps fresh
+// created/updated times on every scan, so those are ignored.
+func targetsETag(targets []state.Target) string {
+	stripped := make([]state.Target, len(targets))
+	for i, target := range targets {
+		target.CreatedAt, target.UpdatedAt = time.Time{}, time.Time{}
+		services := make([]state.Service, len(target.Services))
+		for j, service := range target.Services {
+			service.CreatedAt, service.UpdatedAt = time.Time{}, time.Time{}
+			services[j] = service
+		}
+		target.Services = services
+		stripped[i] = target
 	}
+	return jsonETag(stripped)
+}
 
-	id := strings.TrimPrefix(r.URL.Path, "/api/targets/")
+func (s *Server) handleTargetByID(w http.ResponseWriter, r *http.Request) {
+	id, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/targets/"), "/")
 	if id == "" {
 		writeError(w, http.StatusBadRequest, "missing target id", "")
 		return
 	}
+	if rest == "compose/history" || strings.HasPrefix(rest, "compose/history/") {
+		s.handleComposeHistory(w, r, id, strings.TrimPrefix(strings.TrimPrefix(rest, "compose/history"), "/"))
+		return
+	}
+	if r.Method == http.MethodDelete || rest == "relink" || strings.HasPrefix(rest, "services/") {
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleTargetCleanup(w, r, id, rest)
+		})).ServeHTTP(w, r)
+		return
+	}
+	if rest != "" {
+		writeError(w, http.StatusNotFound, "not found", "")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
 
 	ctx := r.Context()
 	target, err := s.discoverTarget(ctx, id)
 	if err != nil {
-		writeError(w, http.StatusNotFound, "target not found", err.Error())
+		writeError(w, statusForError(err), "target not found", err.Error())
 		return
 	}
 
@@ -306,30 +395,158 @@ func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
 		// built from, otherwise the rebuild just replays cached digests.
 		s.registry.InvalidateDigests()
 	}
+	if s.digestMemory != nil {
+		s.digestMemory.Reset()
+	}
 	writeJSON(w, http.StatusOK, map[string]interface{}{"refreshed": true})
 }
 
 func (s *Server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	var req planRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Target = r.URL.Query().Get("target")
+		req.Group = r.URL.Query().Get("group")
+		req.IncludeDisabled = r.URL.Query().Get("include_disabled") == "true"
+	case http.MethodPost:
+		if r.Body != nil {
+			if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
+				writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+				return
+			}
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	plan, err := s.getPlan(r.Context(), req)
+	if err != nil {
+		writeError(w, statusForError(err), "plan failed", err.Error())
+		return
+	}
+
+	resp := planResponse{Plan: plan}
+	if info, ok := s.planCache.Info(plan); ok {
+		resp.Cache = &info
+	}
+	// A plan never changes after it is built, so its build time identifies it
+	// and a revalidation of the cached plan costs no encoding.
+	etag := fmt.Sprintf(`W/"plan-%d"`, plan.GeneratedAt.UnixNano())
+	writeJSONConditional(w, r, etag, resp)
+}
+
+// planResponse is a plan plus metadata about the cache entry it was served from.
+type planResponse struct {
+	*planner.Plan
+	Cache *planCacheInfo `json:"cache,omitempty"`
+}
+
+func (s *Server) handlePlanCache(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	s.planCache.Invalidate()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"invalidated": true})
+}
+
+// checkRequest selects the services a targeted check re-resolves. At least one
+// field is required; all given fields must match.
+type checkRequest struct {
+	Image   string `json:"image,omitempty"`
+	Target  string `json:"target,omitempty"`
+	Service string `json:"service,omitempty"`
+}
+
+type checkResponse struct {
+	CheckedAt time.Time          `json:"checked_at"`
+	Items     []planner.PlanItem `json:"items"`
+}
+
+// handleCheck refreshes the remote digest of the selected services only and
+// returns their plan items, leaving the rest of the digest cache warm.
+func (s *Server) handleCheck(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
 		return
 	}
 
-	var req planRequest
-	if r.Body != nil {
-		if err := decodeJSON(r, &req); err != nil && !errors.Is(err, io.EOF) {
-			writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+	var req checkRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	if req.Image == "" && req.Target == "" && req.Service == "" {
+		writeError(w, http.StatusBadRequest, "invalid request", "one of image, target or service is required")
+		return
+	}
+
+	ctx := r.Context()
+	plan, err := s.buildPlan(ctx, planRequest{Target: req.Target})
+	if err != nil {
+		writeError(w, statusForError(err), "check failed", err.Error())
+		return
+	}
+	items := matchCheckItems(plan.Items, req)
+	if len(items) == 0 {
+		writeError(w, http.StatusNotFound, "no matching services", "")
+		return
+	}
+
+	// The first plan may have been built from cached digests; drop those and
+	// plan again so the answer reflects the registry right now.
+	if s.registry != nil {
+		for _, item := range items {
+			s.registry.InvalidateDigest(item.Image)
+			if s.digestMemory != nil {
+				s.digestMemory.Forget(item.Image)
+			}
+		}
+		plan, err = s.buildPlan(ctx, planRequest{Target: req.Target})
+		if err != nil {
+			writeError(w, statusForError(err), "check failed", err.Error())
 			return
 		}
+		items = matchCheckItems(plan.Items, req)
 	}
+	s.planCache.Invalidate()
 
-	plan, err := s.getPlan(r.Context(), req)
-	if err != nil {
-		writeError(w, http.StatusInternalServerError, "plan failed", err.Error())
+	if allUnavailable(items) {
+		writeError(w, http.StatusServiceUnavailable, "registry unavailable", items[0].FetchErr.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, plan)
+	writeJSON(w, http.StatusOK, checkResponse{CheckedAt: time.Now().UTC(), Items: items})
+}
+
+// allUnavailable reports whether no item could be checked because its
+// registry was unreachable.
+func allUnavailable(items []planner.PlanItem) bool {
+	for _, item := range items {
+		if !errors.Is(item.FetchErr, registry.ErrRegistryUnavailable) {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+// matchCheckItems returns the plan items selected by req.
+func matchCheckItems(items []planner.PlanItem, req checkRequest) []planner.PlanItem {
+	matched := make([]planner.PlanItem, 0)
+	for _, item := range items {
+		if req.Target != "" && item.TargetID != req.Target && item.TargetName != req.Target {
+			continue
+		}
+		if req.Service != "" && item.ServiceID != req.Service && item.ServiceName != req.Service {
+			continue
+		}
+		if req.Image != "" && !registry.MatchesImage(req.Image, item.Image) {
+			continue
+		}
+		matched = append(matched, item)
+	}
+	return matched
 }
 
 func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
@@ -352,14 +569,97 @@ func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid mode", "use safe, selected, or all")
 		return
 	}
+	if err := req.RunNotes.normalize(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid run notes", err.Error())
+		return
+	}
+	if err := req.Select.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid select", err.Error())
+		return
+	}
 
-	run := s.runs.CreateRun("apply")
-	writeJSON(w, http.StatusAccepted, applyResponse{RunID: run.ID})
+	req.Trigger, req.Actor = state.TriggerManualUI, s.actor(r)
+	run, position, _, err := s.enqueueApply("apply", priorityManual, req, mode)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "run queue unavailable", err.Error())
+		return
+	}
 
-	go s.executeApply(run.ID, req, mode)
+	writeJSON(w, http.StatusAccepted, applyResponse{RunID: run.ID, Status: "queued", QueuePosition: position})
+}
+
+// enqueueApply creates a queued run and submits it to the run queue.
+func (s *Server) enqueueApply(runMode string, priority int, req applyRequest, mode string) (*Run, int, <-chan struct{}, error) {
+	if s.queue == nil {
+		return nil, 0, nil, errQueueStopped
+	}
+	run := s.runs.CreateQueuedRun(runMode, req.RunNotes)
+	position, done, err := s.queue.enqueue(run.ID, priority, func() {
+		s.runs.MarkStarted(run.ID)
+		s.executeApply(run.ID, req, mode)
+	})
+	if err != nil {
+		s.runs.Complete(run.ID, "cancelled")
+		return nil, 0, nil, err
+	}
+	return run, position, done, nil
+}
+
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	runs := s.runs.List(strings.TrimSpace(r.URL.Query().Get("status")))
+	for i := range runs {
+		if runs[i].Status == "queued" && s.queue != nil {
+			runs[i].QueuePosition = s.queue.position(runs[i].ID)
+		}
+	}
+	writeJSON(w, http.StatusOK, runsResponse{Runs: runs})
+}
+
+func (s *Server) handleRunCancel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/runs/"), "/cancel")
+	if s.queue != nil && s.queue.cancel(id) {
+		s.runs.Complete(id, "cancelled")
+		writeJSON(w, http.StatusOK, map[string]interface{}{"run_id": id, "status": "cancelled"})
+		return
+	}
+	// A running run stops before its next service; the update in progress
+	// is interrupted and rolled back where the policy asks for it.
+	if s.runs.CancelRunning(id) {
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{"run_id": id, "status": "cancelling"})
+		return
+	}
+
+	if _, ok := s.runs.Get(id); !ok {
+		writeError(w, http.StatusNotFound, "run not found", "")
+		return
+	}
+	writeError(w, http.StatusConflict, "run already finished", "")
 }
 
 func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
+	if id, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/runs/"), "/artifacts"); ok && (rest == "" || strings.HasPrefix(rest, "/")) {
+		s.handleRunArtifacts(w, r, id, strings.TrimPrefix(rest, "/"))
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/cancel") {
+		s.requireWrite(http.HandlerFunc(s.handleRunCancel)).ServeHTTP(w, r)
+		return
+	}
+	if r.Method == http.MethodPatch {
+		s.requireWrite(http.HandlerFunc(s.handleRunNotes)).ServeHTTP(w, r)
+		return
+	}
+
 	if r.Method != http.MethodGet {
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
 		return
@@ -370,12 +670,20 @@ func (s *Server) handleRun(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "missing run id", "")
 		return
 	}
+	filter, err := parseEventFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid event filter", err.Error())
+		return
+	}
 
 	run, ok := s.runs.Get(id)
 	if !ok {
 		writeError(w, http.StatusNotFound, "run not found", "")
 		return
 	}
+	if filter != nil {
+		run.Events = filter.apply(run.Events)
+	}
 
 	writeJSON(w, http.StatusOK, run)
 }
@@ -399,11 +707,24 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	if pageSize < 1 {
 		pageSize = 50
 	}
+	if pageSize > maxHistoryPageSize {
+		pageSize = maxHistoryPageSize
+	}
 
 	filters := planner.HistoryFilter{
-		TargetID:  r.URL.Query().Get("target_id"),
-		ServiceID: r.URL.Query().Get("service_id"),
-		Result:    r.URL.Query().Get("result"),
+		TargetID:   r.URL.Query().Get("target_id"),
+		ServiceID:  r.URL.Query().Get("service_id"),
+		Result:     r.URL.Query().Get("result"),
+		ResultCode: r.URL.Query().Get("result_code"),
+		ReasonCode: r.URL.Query().Get("reason_code"),
+		Kind:       r.URL.Query().Get("kind"),
+		Actor:      r.URL.Query().Get("actor"),
+	}
+	// trigger=manual-ui:alice is shorthand for trigger=manual-ui&actor=alice.
+	trigger, actor, hasActor := strings.Cut(r.URL.Query().Get("trigger"), ":")
+	filters.Trigger = trigger
+	if hasActor {
+		filters.Actor = actor
 	}
 
 	items, hasMore, err := s.getHistory(r.Context(), filters, page, pageSize)
@@ -420,10 +741,63 @@ func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleHistorySBOM serves /api/history/{id}/sbom, the SBOM document
+// captured for the digest a history entry applied.
+func (s *Server) handleHistorySBOM(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/api/history/")
+	idPart, ok := strings.CutSuffix(rest, "/sbom")
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found", "")
+		return
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id < 1 {
+		writeError(w, http.StatusBadRequest, "invalid history id", "")
+		return
+	}
+	if s.store == nil {
+		writeError(w, http.StatusNotFound, "history entry not found", "")
+		return
+	}
+
+	result, err := s.store.GetUpdateResult(r.Context(), id)
+	if errors.Is(err, state.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "history entry not found", "")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "history failed", err.Error())
+		return
+	}
+	if result.SBOM == nil {
+		writeError(w, http.StatusNotFound, "no sbom captured for this update", "")
+		return
+	}
+
+	data, err := os.ReadFile(result.SBOM.Path)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "sbom document missing", err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(result.SBOM.Path)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// handleRollback rolls a service back past its last update. With
+// dry_run=true it only verifies that the rollback can go ahead and reports
+// what it would do.
 func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
 	// Parse request
 	target := r.URL.Query().Get("target")
 	service := r.URL.Query().Get("service")
+	dryRun := r.URL.Query().Get("dry_run") == "true"
 
 	if target == "" || service == "" {
 		writeError(w, http.StatusBadRequest, "missing required parameters: target and service", "")
@@ -434,7 +808,7 @@ func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	targets, err := s.discoverTargets(ctx, target)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "discovery failed", err.Error())
+		writeError(w, statusForError(err), "discovery failed", err.Error())
 		return
 	}
 
@@ -460,19 +834,23 @@ func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get last successful update from history
+	var lastUpdate *state.UpdateResult
+	if s.store != nil {
+		lastUpdate, _ = executor.LastUpdate(ctx, s.store, discoveredService.ID)
+	}
+	if dryRun {
+		s.verifyRollback(w, r, discoveredTarget, discoveredService, lastUpdate)
+		return
+	}
 	if s.store == nil {
 		writeError(w, http.StatusInternalServerError, "state store not configured", "")
 		return
 	}
-
-	history, err := s.store.GetUpdateHistoryByService(ctx, discoveredService.ID, 1)
-	if err != nil || len(history) == 0 {
+	if lastUpdate == nil {
 		writeError(w, http.StatusNotFound, "no update history found for service", service)
 		return
 	}
 
-	lastUpdate := history[0]
-
 	// Create executor and perform rollback
 	dockerClient, err := docker.NewClient()
 	if err != nil {
@@ -482,7 +860,9 @@ func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
 	defer func() { _ = dockerClient.Close() }()
 
 	policyEngine := policy.NewEngine(s.logger)
-	exec := executor.NewExecutor(dockerClient, policyEngine, s.store, s.logger, false).WithLockTimeout(s.cfg.LockTimeout)
+	exec := executor.NewExecutor(dockerClient, policyEngine, s.store, s.logger, false).
+		WithLockManager(s.locks).
+		WithLockTimeout(s.serverTunables().lockTimeout)
 
 	// Create a fake update result to pass to rollback
 	result := &state.UpdateResult{
@@ -493,10 +873,10 @@ func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
 		NewDigest:   discoveredService.CurrentDigest,
 	}
 
-	// Execute rollback
-	err = exec.ExecuteRollback(ctx, discoveredTarget, discoveredService, result)
+	// Execute rollback under the target's lock
+	err = exec.RollbackService(ctx, discoveredTarget, discoveredService, result)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "rollback failed", err.Error())
+		writeError(w, statusForError(err), "rollback failed", err.Error())
 		return
 	}
 
@@ -504,12 +884,15 @@ func (s *Server) handleRollback(w http.ResponseWriter, r *http.Request) {
 		"success":        true,
 		"target":         target,
 		"service":        service,
-		"rolled_back_to": lastUpdate.OldDigest[:12],
+		"rolled_back_to": lastUpdate.OldDigest[:min(12, len(lastUpdate.OldDigest))],
 		"message":        "Successfully rolled back to previous version",
 	})
 }
 
 func (s *Server) discoverTargets(ctx context.Context, target string) ([]state.Target, error) {
+	ctx, cancel := withTimeout(ctx, s.cfg.DiscoveryTimeout)
+	defer cancel()
+
 	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return nil, err
@@ -522,14 +905,14 @@ func (s *Server) discoverTargets(ctx context.Context, target string) ([]state.Ta
 	}
 
 	if target != "" {
-		found, err := discoverer.DiscoverTarget(ctx, s.cfg.Root, target)
+		found, err := discoverer.DiscoverTarget(ctx, s.rootPath(), target)
 		if err != nil {
 			return nil, err
 		}
 		return []state.Target{*found}, nil
 	}
 
-	return discoverer.Discover(ctx, s.cfg.Root)
+	return discoverer.Discover(ctx, s.rootPath())
 }
 
 func (s *Server) discoverTarget(ctx context.Context, targetID string) (*state.Target, error) {
@@ -546,37 +929,51 @@ func (s *Server) discoverTarget(ctx context.Context, targetID string) (*state.Ta
 	if err != nil {
 		return nil, err
 	}
-	return nil, errors.New("target not found")
+	return nil, fmt.Errorf("target %w", state.ErrNotFound)
 }
 
 func (s *Server) getPlan(ctx context.Context, req planRequest) (*planner.Plan, error) {
-	if req.Target == "" && !req.IncludeDisabled {
-		if cached, ok := s.planCache.Get(); ok {
+	if req.Target == "" && req.Group == "" && !req.IncludeDisabled {
+		if cached, ok := s.cachedPlan(ctx); ok {
 			return cached, nil
 		}
+		if stale, ok := s.planCache.Stale(); ok {
+			s.refreshStalePlan()
+			return stale, nil
+		}
+		return s.sharedPlan(ctx)
+	}
 
-		plan, err, _ := s.planGroup.Do("default", func() (interface{}, error) {
-			if cached, ok := s.planCache.Get(); ok {
-				return cached, nil
-			}
+	return s.buildPlan(ctx, req)
+}
 
-			plan, err := s.buildPlan(ctx, req)
-			if err != nil {
-				return nil, err
-			}
-			s.planCache.Set(plan)
-			return plan, nil
-		})
+// sharedPlan builds and caches the full plan once for every caller waiting
+// on it.
+func (s *Server) sharedPlan(ctx context.Context) (*planner.Plan, error) {
+	plan, err, _ := s.planGroup.Do("default", func() (interface{}, error) {
+		if cached, ok := s.planCache.Get(); ok {
+			return cached, nil
+		}
+
+		// The build is shared by every waiting caller, so it must not end
+		// when the caller that started it goes away.
+		plan, err := s.buildPlan(s.baseContext(), planRequest{})
 		if err != nil {
 			return nil, err
 		}
-		return plan.(*planner.Plan), nil
+		s.cachePlan(ctx, plan)
+		return plan, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return s.buildPlan(ctx, req)
+	return plan.(*planner.Plan), nil
 }
 
 func (s *Server) buildPlan(ctx context.Context, req planRequest) (*planner.Plan, error) {
+	ctx, cancel := withTimeout(ctx, s.cfg.PlanTimeout)
+	defer cancel()
+
 	dockerClient, err := docker.NewClient()
 	if err != nil {
 		return nil, err
@@ -590,17 +987,36 @@ func (s *Server) buildPlan(ctx context.Context, req planRequest) (*planner.Plan,
 
 	policyEngine := policy.NewEngine(s.logger)
 
-	plannerSvc := planner.NewPlanner(s.logger, discoverer, s.registry, policyEngine)
+	plannerSvc := planner.NewPlanner(s.logger, discoverer, s.registry, policyEngine).
+		WithConcurrency(s.serverTunables().checkConcurrency).
+		WithRollbackCheck(dockerClient, s.registry).
+		WithTagSuggestions(s.registry)
+	if s.digestMemory != nil {
+		plannerSvc.WithIncremental(s.digestMemory, s.serverTunables().digestCacheTTL)
+	}
+	if s.store != nil {
+		plannerSvc.WithIgnoredUpdates(s.store).WithSnoozes(s.store).WithPausedGroups(s.store)
+	}
+	// Drift detection shells out to compose, which observers do not need.
+	if s.store != nil && !s.cfg.Observer() {
+		plannerSvc.WithConfigDrift(docker.NewComposeRunner(), s.store)
+	}
 	plan, err := plannerSvc.BuildPlan(ctx, planner.PlanOptions{
-		Root:            s.cfg.Root,
+		Root:            s.rootPath(),
 		TargetFilter:    req.Target,
 		IncludeDisabled: req.IncludeDisabled,
+		Group:           req.Group,
 	})
 	return plan, err
 }
 
+// maxParallelUpdates caps how many services of one project update at once.
+const maxParallelUpdates = 4
+
 func (s *Server) executeApply(runID string, req applyRequest, mode string) {
-	ctx := context.Background()
+	// The run ends early when it is cancelled or the server shuts down.
+	ctx, cancelRun := s.runs.RunContext(s.baseContext(), runID)
+	defer cancelRun()
 	runStartedAt := time.Now().UTC()
 
 	// An apply changes the digests running locally, so any cached plan is stale
@@ -609,15 +1025,15 @@ func (s *Server) executeApply(runID string, req applyRequest, mode string) {
 	defer s.planCache.Invalidate()
 
 	logger := s.logger.WithComponent("apply")
+	s.pruneArtifacts()
 	s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "start", Message: "Apply run started"})
 	s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "plan", Message: "Building update plan"})
-	autoUpdateItems := make([]notify.AutoUpdateRunItem, 0)
 
 	dockerClient, err := docker.NewClient()
 	if err != nil {
 		s.runs.AddEvent(runID, RunEvent{Level: "error", Step: "docker", Message: "Failed to create Docker client", Data: map[string]interface{}{"error": err.Error()}})
 		s.runs.Complete(runID, "failed")
-		s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, "failed", RunSummary{}, autoUpdateItems)
+		s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, "failed", RunSummary{})
 		return
 	}
 	defer func() { _ = dockerClient.Close() }()
@@ -629,31 +1045,44 @@ func (s *Server) executeApply(runID string, req applyRequest, mode string) {
 
 	registryClient := s.registry
 	policyEngine := policy.NewEngine(logger)
-	plannerSvc := planner.NewPlanner(logger, discoverer, registryClient, policyEngine)
+	plannerSvc := planner.NewPlanner(logger, discoverer, registryClient, policyEngine).
+		WithConcurrency(s.serverTunables().checkConcurrency).
+		WithRollbackCheck(dockerClient, registryClient)
+	if s.store != nil {
+		plannerSvc.WithConfigDrift(docker.NewComposeRunner(), s.store).
+			WithIgnoredUpdates(s.store).
+			WithSnoozes(s.store).
+			WithPausedGroups(s.store)
+	}
 
+	// Only the full plan is cached; target and group runs plan their own.
+	fullPlan := req.Target == "" && req.Group == ""
 	var plan *planner.Plan
-	if req.Target == "" {
-		if cached, ok := s.planCache.Get(); ok {
+	if fullPlan {
+		if cached, ok := s.cachedPlan(ctx); ok {
 			plan = cached
 			s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "plan", Message: "Using cached plan"})
 		}
 	}
 
 	if plan == nil {
+		planCtx, cancelPlan := withTimeout(ctx, s.cfg.PlanTimeout)
 		var planErr error
-		plan, planErr = plannerSvc.BuildPlan(ctx, planner.PlanOptions{
-			Root:            s.cfg.Root,
+		plan, planErr = plannerSvc.BuildPlan(planCtx, planner.PlanOptions{
+			Root:            s.rootPath(),
 			TargetFilter:    req.Target,
 			IncludeDisabled: false,
+			Group:           req.Group,
 		})
+		cancelPlan()
 		if planErr != nil {
 			s.runs.AddEvent(runID, RunEvent{Level: "error", Step: "plan", Message: "Failed to build plan", Data: map[string]interface{}{"error": planErr.Error()}})
 			s.runs.Complete(runID, "failed")
-			s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, "failed", RunSummary{}, autoUpdateItems)
+			s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, "failed", RunSummary{})
 			return
 		}
-		if req.Target == "" {
-			s.planCache.Set(plan)
+		if fullPlan {
+			s.cachePlan(ctx, plan)
 		}
 	}
 
@@ -677,7 +1106,7 @@ func (s *Server) executeApply(runID string, req applyRequest, mode string) {
 		s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "complete", Message: "No updates available; nothing to apply"})
 		updateSummary()
 		s.runs.Complete(runID, "completed")
-		s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, "completed", summary, autoUpdateItems)
+		s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, "completed", summary)
 		return
 	}
 
@@ -685,14 +1114,68 @@ func (s *Server) executeApply(runID string, req applyRequest, mode string) {
 	for _, id := range req.ServiceIDs {
 		serviceFilter[id] = true
 	}
+	if !req.Select.Empty() {
+		selected := 0
+		for _, item := range req.Select.Select(plan.Items) {
+			if item.UpdateAvailable {
+				selected++
+			}
+		}
+		s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "plan", Message: fmt.Sprintf("Selection matches %d of %d updates", selected, plan.UpdateCount)})
+	}
+
+	exec := executor.NewExecutor(dockerClient, policyEngine, s.store, logger, false).
+		WithLockManager(s.locks).
+		WithLockTimeout(s.serverTunables().lockTimeout).
+		WithDiskSpaceCheck(s.diskSpaceChecker(ctx, dockerClient, logger)).
+		WithPullScheduler(s.pulls).
+		WithWatchdog(s.watchdog).
+		WithSBOM(s.sbomGenerator(logger)).
+		WithTrigger(req.Trigger, req.Actor)
+	exec = s.withResourceSnapshots(exec)
+
+	saveHistory := func(item planner.PlanItem, result *state.UpdateResult) {
+		if s.store == nil || req.PullOnly {
+			return
+		}
+		result.Trigger, result.Actor = req.Trigger, req.Actor
+		// History is written even for a cancelled run.
+		if err := s.store.SaveUpdateResult(context.WithoutCancel(ctx), result); err != nil {
+			s.runs.AddEvent(runID, RunEvent{
+				Level:   "warn",
+				Target:  item.TargetName,
+				Service: item.ServiceName,
+				Step:    "history",
+				Message: fmt.Sprintf("Failed to save update history: %v", err),
+			})
+		}
+	}
+
+	// Services held back run after run keep one history row until the
+	// reason they are held back changes.
+	saveSkip := func(item planner.PlanItem, result *state.UpdateResult) {
+		if s.store != nil {
+			latest, err := s.store.GetUpdateHistoryByService(ctx, item.ServiceID, 1)
+			if err == nil && len(latest) == 1 && sameSkip(&latest[0], result) {
+				return
+			}
+		}
+		saveHistory(item, result)
+	}
 
-	exec := executor.NewExecutor(dockerClient, policyEngine, s.store, logger, false).WithLockTimeout(s.cfg.LockTimeout)
+	updatedTargets := make(map[string]bool)
+	recreated := make(map[string]bool)
+	var queued []planner.PlanItem
 
 	for _, item := range plan.Items {
 		if !item.UpdateAvailable {
 			continue
 		}
 
+		if !req.Select.Match(item) {
+			continue
+		}
+
 		// Track if this service is explicitly selected
 		isExplicitlySelected := false
 		if mode == "selected" && len(serviceFilter) > 0 {
@@ -705,7 +1188,8 @@ func (s *Server) executeApply(runID string, req applyRequest, mode string) {
 		if mode == "safe" && item.Risk != planner.RiskSafe {
 			summary.UpdatesSkipped++
 			s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "skip", Message: "Skipped (not safe)"})
-			autoUpdateItems = appendAutoUpdateItem(autoUpdateItems, item, "skipped", time.Now().UTC(), "Skipped (not safe)")
+			summary.record(item, outcomeSkipped, "Skipped (not safe)", time.Time{}, time.Now())
+			saveSkip(item, skippedResult(item, state.ResultNotSafe, "Skipped (not safe)"))
 			updateSummary()
 			continue
 		}
@@ -715,101 +1199,393 @@ func (s *Server) executeApply(runID string, req applyRequest, mode string) {
 		if !item.Allowed && !forceUpdate {
 			summary.UpdatesSkipped++
 			s.runs.AddEvent(runID, RunEvent{Level: "warn", Target: item.TargetName, Service: item.ServiceName, Step: "skip", Message: item.Reason})
-			autoUpdateItems = appendAutoUpdateItem(autoUpdateItems, item, "skipped", time.Now().UTC(), item.Reason)
+			summary.record(item, outcomeSkipped, item.Reason, time.Time{}, time.Now())
+			saveSkip(item, skippedResult(item, state.ResultPolicyBlocked, item.Reason))
 			updateSummary()
 			continue
 		}
 
+		queued = append(queued, item)
+	}
+
+	// Pull every image before recreating anything, so the switch phase is
+	// only container restarts. A failed pre-pull is retried by the update.
+	if len(queued) > 0 {
+		s.runs.SetPhase(runID, "pull")
+		s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "pull", Message: fmt.Sprintf("Pre-pulling images for %d services", len(queued))})
+		for _, item := range queued {
+			if ctx.Err() != nil {
+				break
+			}
+			if item.Build {
+				continue
+			}
+			output := newOutputEvents(s.runs, runID, item)
+			err := exec.PrePull(docker.WithOutput(ctx, output.write), item.Target, item.Service)
+			output.flush()
+			s.keepArtifact(runID, item, artifacts.KindComposeOutput, "pull.log", output.log.Bytes())
+			if err != nil {
+				if !executor.IsSkipError(err) {
+					s.runs.AddEvent(runID, RunEvent{Level: "warn", Target: item.TargetName, Service: item.ServiceName, Step: "pull", Message: fmt.Sprintf("Pre-pull failed: %v", err)})
+				}
+				continue
+			}
+			summary.ImagesPulled++
+			s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "pull", Message: "Image pulled"})
+			updateSummary()
+		}
+	}
+
+	if req.PullOnly {
+		s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "complete", Message: fmt.Sprintf("Pulled %d images; services were not recreated", summary.ImagesPulled)})
+		updateSummary()
+		s.runs.Complete(runID, "completed")
+		return
+	}
+	s.runs.SetPhase(runID, "switch")
+
+	// Services of a project with bulwark.project.parallel update side by side;
+	// shared run state is guarded by mu while they do.
+	var mu sync.Mutex
+	applyItem := func(item planner.PlanItem) {
+		if ctx.Err() != nil {
+			mu.Lock()
+			defer mu.Unlock()
+			summary.UpdatesSkipped++
+			summary.record(item, outcomeSkipped, "Run cancelled", time.Time{}, time.Now())
+			updateSummary()
+			return
+		}
 		s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "update", Message: "Applying update"})
 
-		result := exec.ExecuteUpdate(ctx, item.Target, item.Service, item.RemoteDigest)
+		updateCtx, cancelUpdate := withTimeout(s.withArtifacts(ctx, runID, item), s.cfg.ServiceUpdateTimeout)
+		defer cancelUpdate()
+		output := newOutputEvents(s.runs, runID, item)
+		result := exec.ExecuteUpdate(docker.WithOutput(updateCtx, output.write), item.Target, item.Service, item.RemoteDigest)
+		output.flush()
+		s.keepArtifact(runID, item, artifacts.KindComposeOutput, "update.log", output.log.Bytes())
+		if len(result.ProbeResults) > 0 {
+			s.keepArtifact(runID, item, artifacts.KindProbeTranscript, "probes.txt", artifacts.ProbeTranscript(result.ProbeResults))
+		}
 
 		go s.notify.NotifyResult(context.Background(), result, item.Image)
 
 		if result.Success {
+			mu.Lock()
+			defer mu.Unlock()
 			summary.UpdatesApplied++
+			updatedTargets[item.TargetName] = true
+			recreated[item.ServiceID] = true
 			s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "complete", Message: "Update applied"})
-			autoUpdateItems = appendAutoUpdateItem(autoUpdateItems, item, "updated", result.CompletedAt.UTC(), "Update applied successfully")
+			if resources := result.Resources; resources != nil && resources.MemoryWarning {
+				s.runs.AddEvent(runID, RunEvent{
+					Level:   "warn",
+					Target:  item.TargetName,
+					Service: item.ServiceName,
+					Step:    "resources",
+					Message: fmt.Sprintf("Memory use rose %.0f%% after the update", resources.MemoryChangePercent),
+					Data: map[string]interface{}{
+						"memory_before": resources.Before.MemoryBytes,
+						"memory_after":  resources.After.MemoryBytes,
+					},
+				})
+			}
+			summary.record(item, outcomeApplied, "Update applied successfully", result.StartedAt, result.CompletedAt)
 			updateSummary()
-			continue
+			return
 		}
 
-		if executor.IsSkipError(result.Error) {
+		if result.ResultCode.IsSkip() {
+			mu.Lock()
+			defer mu.Unlock()
 			summary.UpdatesSkipped++
-			s.runs.AddEvent(runID, RunEvent{Level: "warn", Target: item.TargetName, Service: item.ServiceName, Step: "skip", Message: executor.SkipReason(result.Error)})
-			autoUpdateItems = appendAutoUpdateItem(autoUpdateItems, item, "skipped", result.CompletedAt.UTC(), executor.SkipReason(result.Error))
+			s.runs.AddEvent(runID, RunEvent{Level: "warn", Target: item.TargetName, Service: item.ServiceName, Step: "skip", Message: result.ErrorMessage})
+			summary.record(item, outcomeSkipped, result.ErrorMessage, result.StartedAt, result.CompletedAt)
+			saveHistory(item, result)
 			updateSummary()
-			continue
+			return
 		}
 
+		mu.Lock()
 		summary.UpdatesFailed++
-		s.runs.AddEvent(runID, RunEvent{Level: "error", Target: item.TargetName, Service: item.ServiceName, Step: "failed", Message: fmt.Sprintf("Update failed: %v", result.Error)})
-		resultLabel := "failed"
-		resultDetails := fmt.Sprintf("Update failed: %v", result.Error)
 		updateSummary()
+		mu.Unlock()
+		s.runs.AddEvent(runID, RunEvent{Level: "error", Target: item.TargetName, Service: item.ServiceName, Step: "failed", Message: "Update failed: " + result.ErrorMessage})
+		outcome := outcomeFailed
+		resultDetails := "Update failed: " + result.ErrorMessage
 
-		if result.RollbackPerformed {
-			summary.Rollbacks++
-			s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "rollback", Message: "Rollback complete"})
-			resultLabel = "rolled_back"
-			resultDetails = "Update failed and rollback completed"
-			updateSummary()
-		} else if policyEngine.ShouldRollback(ctx, result) {
+		rolledBack := result.RollbackPerformed
+		if !rolledBack && policyEngine.ShouldRollback(ctx, result) {
 			s.runs.AddEvent(runID, RunEvent{Level: "warn", Target: item.TargetName, Service: item.ServiceName, Step: "rollback", Message: "Attempting rollback"})
-			if err := exec.ExecuteRollback(ctx, item.Target, item.Service, result); err != nil {
+			// A timed-out or cancelled update still needs its rollback, so it
+			// gets a fresh deadline rather than the expired update context.
+			rollbackCtx, cancelRollback := withTimeout(s.withArtifacts(context.WithoutCancel(ctx), runID, item), s.cfg.ServiceUpdateTimeout)
+			defer cancelRollback()
+			rollbackOutput := newOutputEvents(s.runs, runID, item)
+			err := exec.ExecuteRollback(docker.WithOutput(rollbackCtx, rollbackOutput.write), item.Target, item.Service, result)
+			rollbackOutput.flush()
+			s.keepArtifact(runID, item, artifacts.KindComposeOutput, "rollback.log", rollbackOutput.log.Bytes())
+			if err != nil {
 				s.runs.AddEvent(runID, RunEvent{Level: "error", Target: item.TargetName, Service: item.ServiceName, Step: "rollback", Message: fmt.Sprintf("Rollback failed: %v", err)})
 				resultDetails = fmt.Sprintf("%s; rollback failed: %v", resultDetails, err)
 			} else {
-				summary.Rollbacks++
-				s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "rollback", Message: "Rollback complete"})
-				resultLabel = "rolled_back"
-				resultDetails = "Update failed and rollback completed"
-				updateSummary()
+				rolledBack = true
 			}
 		}
-		autoUpdateItems = appendAutoUpdateItem(autoUpdateItems, item, resultLabel, result.CompletedAt.UTC(), resultDetails)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if rolledBack {
+			summary.Rollbacks++
+			s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "rollback", Message: "Rollback complete"})
+			outcome = outcomeRolledBack
+			resultDetails += "; rollback completed"
+		}
+		summary.record(item, outcome, resultDetails, result.StartedAt, time.Now())
+		updateSummary()
 
 		// Update-path failures without probes are not persisted by executor; store once here
 		// after rollback handling so history reflects the final outcome.
-		if s.store != nil && len(result.ProbeResults) == 0 {
-			if err := s.store.SaveUpdateResult(ctx, result); err != nil {
-				s.runs.AddEvent(runID, RunEvent{
-					Level:   "warn",
-					Target:  item.TargetName,
-					Service: item.ServiceName,
-					Step:    "history",
-					Message: fmt.Sprintf("Failed to save update history: %v", err),
-				})
-			}
+		if len(result.ProbeResults) == 0 {
+			saveHistory(item, result)
+		}
+	}
+
+	for _, batch := range planner.UpdateBatches(queued) {
+		if len(batch) == 1 {
+			applyItem(batch[0])
+			continue
+		}
+		s.runs.AddEvent(runID, RunEvent{Level: "info", Target: batch[0].TargetName, Step: "update", Message: fmt.Sprintf("Updating %d services in parallel", len(batch))})
+		var g errgroup.Group
+		g.SetLimit(maxParallelUpdates)
+		for _, item := range batch {
+			g.Go(func() error {
+				applyItem(item)
+				return nil
+			})
+		}
+		_ = g.Wait()
+	}
+
+	for _, dependent := range planner.DependentItems(plan.Items, updatedTargets, recreated) {
+		if ctx.Err() != nil {
+			break
+		}
+		if !s.refreshDependent(ctx, runID, exec, dependent) {
+			summary.DependentsFailed++
+			updateSummary()
 		}
 	}
 
 	s.runs.UpdateSummary(runID, summary)
 	status := "completed"
-	if summary.UpdatesFailed > 0 {
+	if summary.UpdatesFailed > 0 || summary.DependentsFailed > 0 {
 		status = "failed"
 	}
 	if len(plan.Items) == 0 {
 		status = "completed"
 	}
+	if ctx.Err() != nil {
+		s.runs.AddEvent(runID, RunEvent{Level: "warn", Step: "cancelled", Message: "Run cancelled; remaining updates were not started"})
+		status = "cancelled"
+	} else if summary.UpdatesApplied > 0 && !req.PullOnly {
+		s.cleanupAfterApply(ctx, runID, dockerClient)
+	}
 	s.runs.Complete(runID, status)
-	s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, status, summary, autoUpdateItems)
+	s.notifyAutoUpdateCompletion(runID, mode, runStartedAt, status, summary)
 }
 
-func appendAutoUpdateItem(items []notify.AutoUpdateRunItem, item planner.PlanItem, result string, completedAt time.Time, details string) []notify.AutoUpdateRunItem {
-	return append(items, notify.AutoUpdateRunItem{
-		Target:      item.TargetName,
-		Service:     item.ServiceName,
-		Image:       item.Image,
-		Result:      result,
-		CompletedAt: completedAt,
-		Details:     details,
+// cleanupAfterApply removes what the cleanup policy names once a run has
+// replaced images. A failed cleanup is reported but does not fail the run.
+func (s *Server) cleanupAfterApply(ctx context.Context, runID string, dockerClient *docker.Client) {
+	if s.serverTunables().cleanupPolicy != cleanupDangling {
+		return
+	}
+	// Without the protected digests the prune could remove the images a
+	// rollback needs, so a failed lookup skips the cleanup.
+	protected, err := s.protectedDigests(ctx)
+	if err != nil {
+		s.runs.AddEvent(runID, RunEvent{Level: "warn", Step: "cleanup", Message: "Skipped image cleanup", Data: map[string]interface{}{"error": err.Error()}})
+		return
+	}
+	removed, err := dockerClient.PruneImages(ctx, protected)
+	if err != nil {
+		s.runs.AddEvent(runID, RunEvent{Level: "warn", Step: "cleanup", Message: "Failed to prune dangling images", Data: map[string]interface{}{"error": err.Error()}})
+		return
+	}
+	s.runs.AddEvent(runID, RunEvent{Level: "info", Step: "cleanup", Message: "Pruned dangling images", Data: map[string]interface{}{"removed": removed, "protected": len(protected)}})
+}
+
+// newPullScheduler limits pulls as configured, or returns nil when no limit
+// is set.
+func newPullScheduler(cfg Config, sizer *registry.Client) *executor.PullScheduler {
+	if cfg.MaxConcurrentPulls <= 0 && cfg.PullDelay <= 0 && cfg.SequentialPullMB <= 0 {
+		return nil
+	}
+	if cfg.SequentialPullMB <= 0 || sizer == nil {
+		return executor.NewPullScheduler(cfg.MaxConcurrentPulls, cfg.PullDelay, nil, 0)
+	}
+	return executor.NewPullScheduler(cfg.MaxConcurrentPulls, cfg.PullDelay, sizer, int64(cfg.SequentialPullMB)<<20)
+}
+
+// diskSpaceChecker checks the configured data root, falling back to the
+// daemon's own data root, which is only meaningful when Bulwark can see it.
+func (s *Server) diskSpaceChecker(ctx context.Context, dockerClient *docker.Client, logger *logging.Logger) *executor.DiskSpaceChecker {
+	if s.registry == nil {
+		return nil
+	}
+	root := s.cfg.DockerDataRoot
+	if root == "" {
+		var err error
+		if root, err = dockerClient.DataRoot(ctx); err != nil || root == "" {
+			return nil
+		}
+	}
+	return executor.NewDiskSpaceChecker(s.registry, root, logger)
+}
+
+// watchdogRolledBack reports a rollback the watchdog started because an
+// updated service failed after its probes passed.
+func (s *Server) watchdogRolledBack(target *state.Target, service *state.Service, result *state.UpdateResult) {
+	s.planCache.Invalidate()
+	if s.digestMemory != nil {
+		s.digestMemory.Forget(service.Image)
+	}
+	s.logger.Warn().
+		Str("target", target.Name).
+		Str("service", service.Name).
+		Bool("rolled_back", result.RollbackPerformed).
+		Str("error", result.ErrorMessage).
+		Msg("Watchdog rolled back an update")
+	if s.notify != nil {
+		s.notify.NotifyResult(s.baseContext(), result, service.Image)
+	}
+}
+
+// withResourceSnapshots makes exec sample CPU and memory use around updates
+// unless that is turned off.
+func (s *Server) withResourceSnapshots(exec *executor.Executor) *executor.Executor {
+	if !s.cfg.ResourceSnapshots {
+		return exec
+	}
+	return exec.WithResourceSnapshots(float64(s.cfg.MemoryJumpPercent))
+}
+
+// sbomGenerator returns the syft generator when SBOM capture is enabled and
+// syft is installed.
+func (s *Server) sbomGenerator(logger *logging.Logger) *sbom.SyftGenerator {
+	if !s.cfg.SBOMEnabled {
+		return nil
+	}
+	generator := sbom.NewSyftGenerator(s.cfg.SyftPath, filepath.Join(s.cfg.DataDir, "sbom"), s.cfg.SBOMFormat, logger)
+	if !generator.Available() {
+		logger.Warn().Str("binary", s.cfg.SyftPath).Msg("SBOM capture enabled but syft was not found")
+		return nil
+	}
+	return generator
+}
+
+// refreshDependent restarts and/or probes a service after a target it depends
+// on was updated in this run. It reports whether the service is healthy.
+func (s *Server) refreshDependent(ctx context.Context, runID string, exec *executor.Executor, item planner.PlanItem) bool {
+	restart := item.Service.Labels.DependentAction == state.DependentActionRestart
+	action := "Probing"
+	if restart {
+		action = "Restarting"
+	}
+	s.runs.AddEvent(runID, RunEvent{
+		Level:   "info",
+		Target:  item.TargetName,
+		Service: item.ServiceName,
+		Step:    "dependent",
+		Message: fmt.Sprintf("%s dependent service after update of %s", action, strings.Join(item.DependsOn, ", ")),
 	})
+
+	results, err := exec.RefreshDependent(ctx, item.Target, item.Service, restart)
+	if err != nil {
+		s.runs.AddEvent(runID, RunEvent{Level: "error", Target: item.TargetName, Service: item.ServiceName, Step: "dependent", Message: fmt.Sprintf("Dependent refresh failed: %v", err)})
+		return false
+	}
+	if !probe.AllProbesPassed(results) {
+		s.runs.AddEvent(runID, RunEvent{Level: "error", Target: item.TargetName, Service: item.ServiceName, Step: "dependent", Message: "Dependent service probes failed"})
+		return false
+	}
+
+	message := "Dependent service healthy"
+	if len(results) == 0 && !restart {
+		message = "Dependent service has no probes configured"
+	}
+	s.runs.AddEvent(runID, RunEvent{Level: "info", Target: item.TargetName, Service: item.ServiceName, Step: "dependent", Message: message})
+	return true
+}
+
+// skippedResult builds the history record for an update the run chose not to attempt.
+func skippedResult(item planner.PlanItem, code state.ResultCode, reason string) *state.UpdateResult {
+	now := time.Now()
+	err := errors.New(reason)
+	if code == state.ResultPolicyBlocked {
+		err = policy.Blocked(reason)
+	}
+	result := &state.UpdateResult{
+		TargetID:     item.TargetID,
+		ServiceID:    item.ServiceID,
+		ServiceName:  item.ServiceName,
+		OldDigest:    item.CurrentDigest,
+		NewDigest:    item.CurrentDigest,
+		ProbeResults: []state.ProbeResult{},
+		ResultCode:   code,
+		ReasonCode:   item.ReasonCode,
+		StartedAt:    now,
+		CompletedAt:  now,
+	}
+	result.SetError(err, code)
+	platform := ""
+	if item.Service != nil {
+		platform = item.Service.Platform
+	}
+	executor.DescribeImage(result, item.Image, platform)
+	return result
+}
+
+// sameSkip reports whether skip repeats the recorded result previous: the
+// same kind of skip for the same reason, with the service on the same digest.
+func sameSkip(previous, skip *state.UpdateResult) bool {
+	return previous.ResultCode == skip.ResultCode &&
+		previous.ReasonCode == skip.ReasonCode &&
+		previous.ErrorMessage == skip.ErrorMessage &&
+		previous.OldDigest == skip.OldDigest
 }
 
-func (s *Server) notifyAutoUpdateCompletion(runID, mode string, startedAt time.Time, status string, summary RunSummary, items []notify.AutoUpdateRunItem) {
+// autoUpdateItems lists the service outcomes of summary for notifications.
+func autoUpdateItems(summary RunSummary) []notify.AutoUpdateRunItem {
+	items := make([]notify.AutoUpdateRunItem, 0)
+	for _, target := range summary.Targets {
+		for _, service := range target.Services {
+			result := service.Outcome
+			if result == outcomeApplied {
+				result = "updated"
+			}
+			items = append(items, notify.AutoUpdateRunItem{
+				Target:      target.Target,
+				Service:     service.Service,
+				Image:       service.Image,
+				Result:      result,
+				CompletedAt: service.CompletedAt,
+				Duration:    time.Duration(service.DurationMs) * time.Millisecond,
+				Details:     service.Reason,
+			})
+		}
+	}
+	return items
+}
+
+// notifyAutoUpdateCompletion reports a finished run to Home Assistant and, for
+// scheduled auto-updates and their catch-up runs, sends the completion
+// notification.
+func (s *Server) notifyAutoUpdateCompletion(runID, mode string, startedAt time.Time, status string, summary RunSummary) {
 	run, ok := s.runs.Get(runID)
-	if !ok || run.Mode != "auto-update" || s.notify == nil {
+	if !ok || s.notify == nil {
 		return
 	}
 
@@ -817,7 +1593,7 @@ func (s *Server) notifyAutoUpdateCompletion(runID, mode string, startedAt time.T
 	if run.CompletedAt != nil {
 		completedAt = run.CompletedAt.UTC()
 	}
-	go s.notify.NotifyAutoUpdateRun(context.Background(), notify.AutoUpdateRunReport{
+	report := notify.AutoUpdateRunReport{
 		RunID:       run.ID,
 		Mode:        mode,
 		Status:      status,
@@ -829,14 +1605,24 @@ func (s *Server) notifyAutoUpdateCompletion(runID, mode string, startedAt time.T
 			UpdatesFailed:  summary.UpdatesFailed,
 			Rollbacks:      summary.Rollbacks,
 		},
-		Items: items,
-	})
+		Items: autoUpdateItems(summary),
+	}
+	s.notify.PublishRunStatus(report)
+	if run.Mode == "auto-update" || run.Mode == "catch-up" {
+		go s.notify.NotifyAutoUpdateRun(context.Background(), report)
+	}
 }
 
 func (s *Server) uiHandler() http.Handler {
 	fileServer := http.FileServer(http.Dir(s.cfg.DistDir))
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Unknown API routes, such as apply on an observer, must not fall
+		// through to the UI's index page and answer 200.
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			writeError(w, http.StatusNotFound, "not found", "")
+			return
+		}
 		path := filepath.Clean(r.URL.Path)
 		if strings.Contains(path, "..") {
 			writeError(w, http.StatusBadRequest, "invalid path", "")
@@ -887,8 +1673,8 @@ func activityFromHistory(history []state.UpdateResult, limit int) []activityItem
 		}
 		action := statusFromResult(result)
 		message := "Update completed"
-		if result.Error != nil {
-			message = result.Error.Error()
+		if result.ErrorMessage != "" {
+			message = result.ErrorMessage
 		}
 		items = append(items, activityItem{
 			Timestamp: result.CompletedAt,
@@ -905,6 +1691,9 @@ func statusFromResult(result state.UpdateResult) string {
 	if result.RollbackPerformed {
 		return "rolled_back"
 	}
+	if result.ResultCode.IsSkip() {
+		return "skipped"
+	}
 	if result.Success {
 		return "updated"
 	}
@@ -912,12 +1701,26 @@ func statusFromResult(result state.UpdateResult) string {
 }
 
 func (s *Server) getHistory(ctx context.Context, filters planner.HistoryFilter, page, pageSize int) ([]planner.HistoryItem, bool, error) {
+	// IDs of relinked targets and services keep finding their history.
+	for _, id := range []*string{&filters.TargetID, &filters.ServiceID} {
+		if *id == "" {
+			continue
+		}
+		if newID, err := s.store.ResolveAlias(ctx, *id); err == nil {
+			*id = newID
+		}
+	}
 	results, err := s.store.ListUpdateHistory(ctx, state.HistoryQuery{
-		TargetID:  filters.TargetID,
-		ServiceID: filters.ServiceID,
-		Result:    filters.Result,
-		Limit:     pageSize + 1,
-		Offset:    (page - 1) * pageSize,
+		TargetID:   filters.TargetID,
+		ServiceID:  filters.ServiceID,
+		Result:     filters.Result,
+		ResultCode: state.ResultCode(filters.ResultCode),
+		ReasonCode: state.ReasonCode(filters.ReasonCode),
+		Kind:       filters.Kind,
+		Trigger:    state.UpdateTrigger(filters.Trigger),
+		Actor:      filters.Actor,
+		Limit:      pageSize + 1,
+		Offset:     (page - 1) * pageSize,
 	})
 	if err != nil {
 		return nil, false, err
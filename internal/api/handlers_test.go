@@ -1,10 +1,24 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
 )
 
 // testServer creates a minimal Server suitable for handler unit tests.
@@ -13,10 +27,12 @@ import (
 func testServer() *Server {
 	return &Server{
 		cfg: Config{
-			ReadOnly:  true,
-			UIEnabled: true,
+			ReadOnly:       true,
+			UIEnabled:      true,
+			LegacyAPIPaths: true,
 		},
 		runs:      NewRunManager(10, 100, 50, nil),
+		queue:     newRunQueue(1),
 		planCache: newPlanCache(0),
 		sessions:  newSessionStore(),
 	}
@@ -59,6 +75,147 @@ func TestHandleHealth_MethodNotAllowed(t *testing.T) {
 	}
 }
 
+func TestHandlePlanCache_Delete(t *testing.T) {
+	s := testServer()
+	s.planCache.Set(&planner.Plan{UpdateCount: 1})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/plan/cache", nil)
+	w := httptest.NewRecorder()
+	s.handlePlanCache(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	if _, ok := s.planCache.Get(); ok {
+		t.Error("expected plan cache to be cleared")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/plan/cache", nil)
+	w = httptest.NewRecorder()
+	s.handlePlanCache(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestHandleCheck_RequiresSelector(t *testing.T) {
+	s := testServer()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/check", strings.NewReader(`{}`))
+	w := httptest.NewRecorder()
+	s.handleCheck(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/check", nil)
+	w = httptest.NewRecorder()
+	s.handleCheck(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestMatchCheckItems(t *testing.T) {
+	items := []planner.PlanItem{
+		{TargetID: "t1", TargetName: "media", ServiceID: "t1/web", ServiceName: "web", Image: "nginx:1.25"},
+		{TargetID: "t1", TargetName: "media", ServiceID: "t1/db", ServiceName: "db", Image: "postgres:16"},
+		{TargetID: "t2", TargetName: "blog", ServiceID: "t2/web", ServiceName: "web", Image: "ghcr.io/acme/blog:latest"},
+	}
+
+	tests := []struct {
+		name string
+		req  checkRequest
+		want []string
+	}{
+		{"by service name", checkRequest{Service: "web"}, []string{"t1/web", "t2/web"}},
+		{"by service id", checkRequest{Service: "t1/db"}, []string{"t1/db"}},
+		{"by image", checkRequest{Image: "docker.io/library/nginx"}, []string{"t1/web"}},
+		{"by target and service", checkRequest{Target: "blog", Service: "web"}, []string{"t2/web"}},
+		{"no match", checkRequest{Image: "redis"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchCheckItems(items, tt.req)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d items, got %d", len(tt.want), len(got))
+			}
+			for i, item := range got {
+				if item.ServiceID != tt.want[i] {
+					t.Errorf("item %d = %s, want %s", i, item.ServiceID, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePlan_ConditionalGet(t *testing.T) {
+	s := testServer()
+	s.planCache.Set(&planner.Plan{GeneratedAt: time.Now(), UpdateCount: 2})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/plan", nil)
+	w := httptest.NewRecorder()
+	s.handlePlan(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "private, no-cache" {
+		t.Errorf("unexpected Cache-Control %q", cc)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/plan", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	s.handlePlan(w, req)
+
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", w.Body.String())
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{`W/"abc"`, true},
+		{`"abc"`, true},
+		{`W/"other", W/"abc"`, true},
+		{"*", true},
+		{`W/"other"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `W/"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestTargetsETagIgnoresTimestamps(t *testing.T) {
+	targets := []state.Target{{ID: "t1", Name: "app", CreatedAt: time.Now(), Services: []state.Service{{ID: "s1", UpdatedAt: time.Now()}}}}
+	first := targetsETag(targets)
+
+	targets[0].CreatedAt = time.Now().Add(time.Minute)
+	targets[0].Services[0].UpdatedAt = time.Now().Add(time.Minute)
+	if targetsETag(targets) != first {
+		t.Error("expected timestamps to be ignored")
+	}
+
+	targets[0].Services[0].CurrentDigest = "sha256:new"
+	if targetsETag(targets) == first {
+		t.Error("expected digest change to change the ETag")
+	}
+}
+
 func TestHandleRun_NotFound(t *testing.T) {
 	s := testServer()
 	req := httptest.NewRequest(http.MethodGet, "/api/runs/nonexistent", nil)
@@ -224,3 +381,248 @@ func TestWriteError(t *testing.T) {
 		t.Errorf("expected details 'details here', got %s", resp.Details)
 	}
 }
+
+func TestHandleHistorySBOM(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := state.NewSQLiteStore(filepath.Join(dir, "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	target := &state.Target{ID: "target-1", Type: state.TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: state.DefaultLabels()}
+	if err := store.SaveTarget(ctx, target); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	service := &state.Service{ID: "service-1", TargetID: target.ID, Name: "web", Image: "nginx:latest", Labels: state.DefaultLabels()}
+	if err := store.SaveService(ctx, service); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+
+	document := filepath.Join(dir, "sha256-new.cdx.json")
+	if err := os.WriteFile(document, []byte(`{"components":[]}`), 0o644); err != nil {
+		t.Fatalf("write sbom: %v", err)
+	}
+	withSBOM := &state.UpdateResult{
+		TargetID:    target.ID,
+		ServiceID:   service.ID,
+		ServiceName: "web",
+		Success:     true,
+		SBOM:        &state.SBOM{Format: "cyclonedx-json", Path: document},
+		StartedAt:   time.Now(),
+		CompletedAt: time.Now(),
+	}
+	without := &state.UpdateResult{TargetID: target.ID, ServiceID: service.ID, ServiceName: "web", StartedAt: time.Now(), CompletedAt: time.Now()}
+	for _, result := range []*state.UpdateResult{withSBOM, without} {
+		if err := store.SaveUpdateResult(ctx, result); err != nil {
+			t.Fatalf("SaveUpdateResult failed: %v", err)
+		}
+	}
+
+	s := testServer()
+	s.store = store
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{fmt.Sprintf("/api/history/%d/sbom", withSBOM.ID), http.StatusOK},
+		{fmt.Sprintf("/api/history/%d/sbom", without.ID), http.StatusNotFound},
+		{"/api/history/999/sbom", http.StatusNotFound},
+		{"/api/history/abc/sbom", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		s.handleHistorySBOM(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
+
+func TestHandleHistoryFiltersByTrigger(t *testing.T) {
+	ctx := context.Background()
+	store := state.NewMemoryStore()
+	target := &state.Target{ID: "target-1", Type: state.TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: state.DefaultLabels()}
+	if err := store.SaveTarget(ctx, target); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	for _, actor := range []string{"alice", "bob"} {
+		result := &state.UpdateResult{TargetID: target.ID, ServiceID: "service-1", ServiceName: "web", Success: true, Trigger: state.TriggerManualUI, Actor: actor}
+		if err := store.SaveUpdateResult(ctx, result); err != nil {
+			t.Fatalf("SaveUpdateResult failed: %v", err)
+		}
+	}
+	if err := store.SaveUpdateResult(ctx, &state.UpdateResult{TargetID: target.ID, ServiceID: "service-1", ServiceName: "web", Trigger: state.TriggerWebhook, Actor: "home-assistant"}); err != nil {
+		t.Fatalf("SaveUpdateResult failed: %v", err)
+	}
+
+	s := testServer()
+	s.store = store
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"trigger=manual-ui", 2},
+		{"trigger=manual-ui&actor=bob", 1},
+		{"trigger=manual-ui:alice", 1},
+		{"trigger=webhook:home-assistant", 1},
+		{"trigger=scheduled", 0},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		s.handleHistory(w, httptest.NewRequest(http.MethodGet, "/api/history?"+tt.query, nil))
+		var resp historyResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode %s: %v", tt.query, err)
+		}
+		if len(resp.Items) != tt.want {
+			t.Errorf("GET /api/history?%s returned %d items, want %d", tt.query, len(resp.Items), tt.want)
+		}
+		for _, item := range resp.Items {
+			if item.Trigger == "" || item.Actor == "" {
+				t.Errorf("expected trigger and actor in %+v", item)
+			}
+		}
+	}
+}
+
+func TestHandleRunNotes(t *testing.T) {
+	s := testServer()
+	s.logger = logging.Default()
+	run := s.runs.CreateQueuedRun("apply", RunNotes{Note: "before game night", Labels: map[string]string{"ticket": "42"}})
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handleRunNotes(w, httptest.NewRequest(http.MethodPatch, "/api/runs/"+run.ID, strings.NewReader(body)))
+		return w
+	}
+
+	if w := patch(`{"labels": {"reason": " holiday "}}`); w.Code != http.StatusOK {
+		t.Fatalf("PATCH = %d: %s", w.Code, w.Body.String())
+	}
+	got, _ := s.runs.Get(run.ID)
+	if got.Note != "before game night" || len(got.Labels) != 1 || got.Labels["reason"] != "holiday" {
+		t.Errorf("expected labels replaced and the note kept, got %+v", got.RunNotes)
+	}
+
+	for _, body := range []string{
+		`{"labels": {"bad key": "x"}}`,
+		`{"note": "` + strings.Repeat("x", maxRunNoteLength+1) + `"}`,
+		`{"unknown": true}`,
+	} {
+		if w := patch(body); w.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %.40s = %d, want 400", body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleRunNotes(w, httptest.NewRequest(http.MethodPatch, "/api/runs/missing", strings.NewReader(`{"note": "x"}`)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("PATCH unknown run = %d, want 404", w.Code)
+	}
+}
+
+func TestStatusForError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("target %w", state.ErrNotFound), http.StatusNotFound},
+		{policy.Blocked("Policy is 'notify'"), http.StatusForbidden},
+		{fmt.Errorf("failed to acquire lock: %w", executor.ErrLockTimeout), http.StatusConflict},
+		{fmt.Errorf("failed to fetch manifest: %w", registry.ErrRegistryUnavailable), http.StatusServiceUnavailable},
+		{executor.ErrProbeFailed, http.StatusBadGateway},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout},
+		{fmt.Errorf("boom"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		if got := statusForError(tt.err); got != tt.want {
+			t.Errorf("statusForError(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestHandleLoggingSettings(t *testing.T) {
+	t.Cleanup(func() { _ = logging.SetLevels(logging.LevelSettings{Default: "info"}) })
+	s := testServer()
+	s.logger = logging.Default()
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handleLoggingSettings(w, httptest.NewRequest(http.MethodPut, "/api/settings/logging", strings.NewReader(body)))
+		return w
+	}
+
+	if w := put(`{"components":{"registry":"debug"}}`); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 in read-only mode, got %d", w.Code)
+	}
+
+	s.cfg.ReadOnly = false
+	if w := put(`{"components":{"registry":"verbose"}}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown level, got %d", w.Code)
+	}
+	w := put(`{"components":{"registry":"debug"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	s.handleLoggingSettings(w, httptest.NewRequest(http.MethodGet, "/api/settings/logging", nil))
+	var got logging.LevelSettings
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Default != "info" || got.Components["registry"] != "debug" {
+		t.Errorf("unexpected levels %+v", got)
+	}
+}
+
+func TestSameSkip(t *testing.T) {
+	item := planner.PlanItem{ServiceID: "svc-1", ServiceName: "web", CurrentDigest: "sha256:old", ReasonCode: "policy_notify"}
+	previous := skippedResult(item, state.ResultPolicyBlocked, "Policy is notify")
+
+	if !sameSkip(previous, skippedResult(item, state.ResultPolicyBlocked, "Policy is notify")) {
+		t.Error("a repeated skip should not be recorded again")
+	}
+	if sameSkip(previous, skippedResult(item, state.ResultNotSafe, "Skipped (not safe)")) {
+		t.Error("a different skip should be recorded")
+	}
+	item.CurrentDigest = "sha256:newer"
+	if sameSkip(previous, skippedResult(item, state.ResultPolicyBlocked, "Policy is notify")) {
+		t.Error("a skip on another digest should be recorded")
+	}
+}
+
+func TestNotifyAutoUpdateCompletion_CatchUpRun(t *testing.T) {
+	bodies := make(chan string, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+	t.Setenv("DISCORD_WEBHOOK_URL", webhook.URL)
+
+	s := setupTestServer(t)
+	if err := s.notify.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	run := s.runs.CreateRun("catch-up")
+	s.runs.Complete(run.ID, "completed")
+
+	s.notifyAutoUpdateCompletion(run.ID, "safe", time.Now(), "completed", RunSummary{UpdatesApplied: 1})
+
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, run.ID) {
+			t.Errorf("expected the completion notification of run %s, got %s", run.ID, body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a completion notification for the catch-up run")
+	}
+}
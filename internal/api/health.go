@@ -0,0 +1,124 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+)
+
+// readinessTimeout bounds each dependency check so a hung daemon cannot hang
+// the orchestrator's health check.
+const readinessTimeout = 3 * time.Second
+
+// Dependency states reported by /readyz.
+const (
+	checkOK       = "ok"
+	checkFailed   = "failed"
+	checkDisabled = "disabled"
+)
+
+type dependencyCheck struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type readinessResponse struct {
+	Status string                     `json:"status"`
+	Checks map[string]dependencyCheck `json:"checks"`
+}
+
+// handleLiveness reports that the process is up and serving requests.
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadiness checks the Docker daemon, the state database and the
+// scheduler, answering 503 if any of them is failing.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	ctx := r.Context()
+	resp := readinessResponse{
+		Status: "ready",
+		Checks: map[string]dependencyCheck{
+			"docker":    timedCheck(ctx, s.checkDocker),
+			"database":  timedCheck(ctx, s.checkDatabase),
+			"scheduler": s.checkScheduler(),
+		},
+	}
+
+	status := http.StatusOK
+	for _, check := range resp.Checks {
+		if check.Status == checkFailed {
+			resp.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, status, resp)
+}
+
+// timedCheck runs check with readinessTimeout. A check returns false when its
+// dependency is not configured.
+func timedCheck(ctx context.Context, check func(context.Context) (bool, error)) dependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	start := time.Now()
+	enabled, err := check(ctx)
+	if !enabled {
+		return dependencyCheck{Status: checkDisabled}
+	}
+	result := dependencyCheck{Status: checkOK, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = checkFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (s *Server) checkDocker(ctx context.Context) (bool, error) {
+	if s.dockerPing != nil {
+		return true, s.dockerPing(ctx)
+	}
+	client, err := docker.NewClient()
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = client.Close() }()
+	return true, client.Ping(ctx)
+}
+
+func (s *Server) checkDatabase(ctx context.Context) (bool, error) {
+	if s.store == nil {
+		return false, nil
+	}
+	return true, s.store.Ping(ctx)
+}
+
+func (s *Server) checkScheduler() dependencyCheck {
+	if s.notify == nil {
+		return dependencyCheck{Status: checkDisabled}
+	}
+	expected, scheduled := s.notify.ScheduleHealth()
+	if expected == 0 {
+		return dependencyCheck{Status: checkDisabled}
+	}
+	if scheduled < expected {
+		return dependencyCheck{
+			Status: checkFailed,
+			Error:  fmt.Sprintf("%d of %d scheduled jobs registered", scheduled, expected),
+		}
+	}
+	return dependencyCheck{Status: checkOK}
+}
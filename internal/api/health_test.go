@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleLiveness(t *testing.T) {
+	s := testServer()
+	w := httptest.NewRecorder()
+	s.handleLiveness(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+}
+
+func TestHandleReadiness_DisabledDependencies(t *testing.T) {
+	s := testServer()
+	w := httptest.NewRecorder()
+	s.handleReadiness(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var resp readinessResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Checks["database"].Status != checkDisabled || resp.Checks["scheduler"].Status != checkDisabled {
+		t.Errorf("expected database and scheduler disabled, got %+v", resp.Checks)
+	}
+	// Docker is unreachable or reachable depending on the environment; the
+	// HTTP status must follow whichever it is.
+	wantStatus := http.StatusOK
+	if resp.Checks["docker"].Status == checkFailed {
+		wantStatus = http.StatusServiceUnavailable
+	}
+	if w.Code != wantStatus {
+		t.Errorf("expected %d, got %d", wantStatus, w.Code)
+	}
+}
+
+func TestTimedCheck(t *testing.T) {
+	failing := timedCheck(context.Background(), func(context.Context) (bool, error) {
+		return true, errors.New("socket closed")
+	})
+	if failing.Status != checkFailed || failing.Error != "socket closed" {
+		t.Errorf("unexpected failing check %+v", failing)
+	}
+
+	disabled := timedCheck(context.Background(), func(context.Context) (bool, error) {
+		return false, nil
+	})
+	if disabled.Status != checkDisabled {
+		t.Errorf("expected disabled, got %+v", disabled)
+	}
+}
@@ -1,17 +1,34 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/executor"
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/redact"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
 )
 
+// apiError is the body of every error response. Code is a stable, machine
+// readable name for the status, Message says what went wrong and Details
+// why, and RequestID matches the request's log lines.
 type apiError struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   string `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Error repeats Message for clients written before the envelope had
+	// code and message.
+	Error string `json:"error"`
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
@@ -22,8 +39,82 @@ func writeJSON(w http.ResponseWriter, status int, payload interface{}) {
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+// writeJSONConditional writes payload tagged with etag. Clients may keep the
+// response but must revalidate it; a GET whose If-None-Match matches gets a
+// 304 without the payload being encoded.
+func writeJSONConditional(w http.ResponseWriter, r *http.Request, etag string, payload interface{}) {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+// jsonETag returns a weak ETag for the JSON encoding of v.
+func jsonETag(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using
+// weak comparison as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
 func writeError(w http.ResponseWriter, status int, message string, details string) {
-	writeJSON(w, status, apiError{Error: message, Details: details})
+	writeJSON(w, status, apiError{
+		Code:    errorCode(status),
+		Message: message,
+		// Details usually carry an error string, which can quote a token or URL.
+		Details:   redact.String(details),
+		RequestID: w.Header().Get(RequestIDHeader),
+		Error:     message,
+	})
+}
+
+// errorCode names status in snake case, e.g. "method_not_allowed".
+func errorCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.ReplaceAll(text, "-", " ")), " ", "_")
+}
+
+// statusForError maps the typed errors of the update pipeline to an HTTP
+// status; anything unclassified is a 500.
+func statusForError(err error) int {
+	switch {
+	case errors.Is(err, state.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, policy.ErrPolicyBlocked):
+		return http.StatusForbidden
+	case errors.Is(err, executor.ErrLockTimeout):
+		return http.StatusConflict
+	case errors.Is(err, registry.ErrRegistryUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, executor.ErrProbeFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func decodeJSON(r *http.Request, out interface{}) error {
@@ -32,18 +123,60 @@ func decodeJSON(r *http.Request, out interface{}) error {
 	return decoder.Decode(out)
 }
 
+// loggingMiddleware logs every request at debug level, and server errors
+// at warn level so they can be found by the request ID a client reports.
 func loggingMiddleware(next http.Handler, logger *logging.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		next.ServeHTTP(w, r)
-		logger.Debug().
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		event := logger.Debug()
+		if sw.status >= http.StatusInternalServerError {
+			event = logger.Warn()
+		}
+		event.
+			Str("request_id", requestID(r.Context())).
 			Str("method", r.Method).
 			Str("path", r.URL.Path).
+			Int("status", sw.Status()).
 			Dur("duration", time.Since(start)).
 			Msg("request")
 	})
 }
 
+// statusWriter records the status a handler responded with.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Status returns the response status; a handler that wrote nothing answered 200.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func bearerToken(header string) string {
 	if header == "" {
 		return ""
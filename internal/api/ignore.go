@@ -0,0 +1,118 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// tagPattern is the grammar Docker accepts for image tags.
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]{0,127}$`)
+
+type ignoreRequest struct {
+	Digest string `json:"digest"`
+	Tag    string `json:"tag"`
+}
+
+// handleIgnore serves a service's ignored update: GET returns it, POST
+// ignores a digest or the digest a tag points to, and DELETE clears it.
+func (s *Server) handleIgnore(w http.ResponseWriter, r *http.Request, serviceID string) {
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "ignoring unavailable", "state persistence is disabled")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		ignores, err := s.store.ListIgnoredUpdates(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list ignored updates", err.Error())
+			return
+		}
+		for _, ignore := range ignores {
+			if ignore.ServiceID == serviceID {
+				writeJSON(w, http.StatusOK, ignore)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "no ignored update", serviceID)
+	case http.MethodPost:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleIgnoreUpdate(w, r, serviceID)
+		})).ServeHTTP(w, r)
+	case http.MethodDelete:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := s.store.DeleteIgnoredUpdate(r.Context(), serviceID); err != nil {
+				writeError(w, statusForError(err), "failed to clear ignored update", err.Error())
+				return
+			}
+			s.planCache.Invalidate()
+			s.requestLogger(r).Info().Str("service_id", serviceID).Msg("Ignored update cleared")
+			w.WriteHeader(http.StatusNoContent)
+		})).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+func (s *Server) handleIgnoreUpdate(w http.ResponseWriter, r *http.Request, serviceID string) {
+	var req ignoreRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	req.Digest = strings.TrimSpace(req.Digest)
+	req.Tag = strings.TrimSpace(req.Tag)
+	switch {
+	case (req.Digest == "") == (req.Tag == ""):
+		writeError(w, http.StatusBadRequest, "invalid request", "Set either digest or tag")
+		return
+	case req.Digest != "" && !digestPattern.MatchString(req.Digest):
+		writeError(w, http.StatusBadRequest, "invalid digest", "Digest must be sha256: followed by 64 hex characters")
+		return
+	case req.Tag != "" && !tagPattern.MatchString(req.Tag):
+		writeError(w, http.StatusBadRequest, "invalid tag", req.Tag)
+		return
+	}
+
+	ctx := r.Context()
+	service, err := s.store.GetService(ctx, serviceID)
+	if err != nil {
+		writeError(w, statusForError(err), "service not found", err.Error())
+		return
+	}
+
+	ignore := &state.IgnoredUpdate{ServiceID: service.ID, Digest: req.Digest, Tag: req.Tag}
+	if req.Tag != "" {
+		if s.registry == nil {
+			writeError(w, http.StatusServiceUnavailable, "registry unavailable", "cannot resolve tags")
+			return
+		}
+		image := imageWithTag(service.Image, req.Tag)
+		if ignore.Digest, err = s.registry.FetchDigest(ctx, image); err != nil {
+			writeError(w, http.StatusBadGateway, "failed to resolve tag", err.Error())
+			return
+		}
+	}
+
+	if err := s.store.SaveIgnoredUpdate(ctx, ignore); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to ignore update", err.Error())
+		return
+	}
+	s.planCache.Invalidate()
+	s.requestLogger(r).Info().Str("service", service.Name).Str("digest", ignore.Digest).Str("tag", ignore.Tag).Msg("Update ignored")
+	writeJSON(w, http.StatusCreated, ignore)
+}
+
+// imageWithTag returns image's repository with tag, e.g. nginx:1.27 for
+// nginx:latest.
+func imageWithTag(image, tag string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image + ":" + tag
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// serviceServer returns a writable server whose state database holds the
+// service service-1, and a helper that sends requests with the write token.
+func serviceServer(t *testing.T) func(method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	s := setupTestServer(t)
+	s.cfg.ReadOnly = false
+	s.cfg.WebToken = "write-token-secret"
+	ctx := context.Background()
+	if err := s.store.SaveTarget(ctx, &state.Target{ID: "target-1", Type: state.TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	if err := s.store.SaveService(ctx, &state.Service{ID: "service-1", TargetID: "target-1", Name: "web", Image: "nginx:latest", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	h := s.Handler()
+	return func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer write-token-secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+}
+
+func TestIgnoreUpdate(t *testing.T) {
+	do := serviceServer(t)
+	digest := "sha256:" + strings.Repeat("cd", 32)
+
+	if w := do(http.MethodPost, "/api/services/service-1/ignore", `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a digest or tag, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/services/service-1/ignore", `{"tag":"bad tag"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid tag, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/services/missing/ignore", `{"digest":"`+digest+`"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown service, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/services/service-1/ignore", `{"digest":"`+digest+`"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := do(http.MethodGet, "/api/services/service-1/ignore", "")
+	var ignore state.IgnoredUpdate
+	if err := json.NewDecoder(w.Body).Decode(&ignore); err != nil || ignore.Digest != digest {
+		t.Fatalf("expected the ignored digest, got %+v (%v)", ignore, err)
+	}
+
+	if w := do(http.MethodDelete, "/api/services/service-1/ignore", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/services/service-1/ignore", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 once cleared, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/services/service-1/ignore", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 clearing twice, got %d", w.Code)
+	}
+}
+
+func TestImageWithTag(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                        "nginx:1.27",
+		"nginx:latest":                 "nginx:1.27",
+		"registry:5000/app":            "registry:5000/app:1.27",
+		"ghcr.io/org/app:2@sha256:abc": "ghcr.io/org/app:1.27",
+	}
+	for image, want := range tests {
+		if got := imageWithTag(image, "1.27"); got != want {
+			t.Errorf("%s: got %s, want %s", image, got, want)
+		}
+	}
+}
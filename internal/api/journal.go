@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/journal"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type composeVersion struct {
+	journal.Entry
+	Content string `json:"content"`
+}
+
+// handleComposeHistory serves the journal of a compose target's file:
+//
+//	GET  /api/targets/{id}/compose/history             journal, newest first
+//	GET  /api/targets/{id}/compose/history/{n}         change n with the content before it
+//	POST /api/targets/{id}/compose/history/{n}/restore put that content back
+//
+// A restore only rewrites the compose file; the next update or apply brings
+// the running services in line with it.
+func (s *Server) handleComposeHistory(w http.ResponseWriter, r *http.Request, targetID, rest string) {
+	ctx := r.Context()
+	target, err := s.discoverTarget(ctx, targetID)
+	if err != nil {
+		writeError(w, statusForError(err), "target not found", err.Error())
+		return
+	}
+	if target.Type != state.TargetTypeCompose || target.Path == "" {
+		writeError(w, http.StatusNotFound, "target has no compose file", target.Name)
+		return
+	}
+
+	if rest == "" {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+			return
+		}
+		entries, err := journal.List(target.Path)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read compose journal", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"entries": entries})
+		return
+	}
+
+	idText, action, _ := strings.Cut(rest, "/")
+	id, err := strconv.Atoi(idText)
+	if err != nil || id < 1 {
+		writeError(w, http.StatusBadRequest, "invalid journal entry", idText)
+		return
+	}
+
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+			return
+		}
+		entry, content, err := journal.Backup(target.Path, id)
+		if err != nil {
+			writeError(w, journalStatus(err), "failed to read compose version", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, composeVersion{Entry: *entry, Content: string(content)})
+	case "restore":
+		if s.cfg.Observer() {
+			writeError(w, http.StatusNotFound, "not found", "")
+			return
+		}
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+				return
+			}
+			entry, err := journal.Restore(target.Path, id, s.actor(r))
+			if err != nil {
+				writeError(w, journalStatus(err), "failed to restore compose version", err.Error())
+				return
+			}
+			s.planCache.Invalidate()
+			s.requestLogger(r).Info().
+				Str("target", target.Name).
+				Int("version", id).
+				Msg("Compose file restored")
+			writeJSON(w, http.StatusOK, map[string]interface{}{"entry": entry})
+		})).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not found", "")
+	}
+}
+
+// actor names the caller in the compose journal.
+func (s *Server) actor(r *http.Request) string {
+	if username := s.credentials(r).username; username != "" {
+		return username
+	}
+	return "api"
+}
+
+func journalStatus(err error) int {
+	if errors.Is(err, journal.ErrNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
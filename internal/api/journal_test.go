@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/journal"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestComposeHistory(t *testing.T) {
+	s := setupTestServer(t)
+	s.cfg.ReadOnly = false
+	s.cfg.WebToken = "write-token-secret"
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	v1 := "services:\n  web:\n    image: nginx:1.25\n"
+	if err := os.WriteFile(path, []byte(v1), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if _, err := journal.Write(path, []byte("services:\n  web:\n    image: nginx:1.27\n"), "alice", "tag change"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := s.store.SaveTarget(context.Background(), &state.Target{ID: "target-1", Type: state.TargetTypeCompose, Name: "app", Path: path, Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	h := s.Handler()
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/targets/target-1/compose/history", "")
+	var list struct {
+		Entries []journal.Entry `json:"entries"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil || len(list.Entries) != 1 || list.Entries[0].Actor != "alice" {
+		t.Fatalf("expected one journal entry, got %d %+v (%v)", w.Code, list, err)
+	}
+
+	w = do(http.MethodGet, "/api/targets/target-1/compose/history/1", "")
+	var version composeVersion
+	if err := json.NewDecoder(w.Body).Decode(&version); err != nil || version.Content != v1 {
+		t.Fatalf("expected the content before change 1, got %d %+v (%v)", w.Code, version, err)
+	}
+	if w := do(http.MethodGet, "/api/targets/target-1/compose/history/7", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown entry, got %d", w.Code)
+	}
+
+	if w := do(http.MethodPost, "/api/targets/target-1/compose/history/1/restore", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the write token, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/targets/target-1/compose/history/1/restore", "write-token-secret"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != v1 {
+		t.Errorf("expected the compose file to be restored, got %q", data)
+	}
+	entries, _ := journal.List(path)
+	if len(entries) != 2 || entries[0].Actor != "api" {
+		t.Errorf("expected the restore to be journaled, got %+v", entries)
+	}
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+)
+
+// sessionTokens issues and checks bulwark_session cookie values.
+type sessionTokens interface {
+	create(access accessLevel, username string) string
+	validate(token string) session
+	delete(token string)
+}
+
+const (
+	sessionTTL = 24 * time.Hour
+
+	signingKeysSetting = "session_signing_keys"
+
+	// defaultKeyRotation is how long a signing key issues new sessions.
+	defaultKeyRotation = 7 * 24 * time.Hour
+)
+
+type settingsStore interface {
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+}
+
+// signingKey is an HS256 secret. Keys are kept for a session lifetime after
+// they stop signing, so sessions issued just before a rotation stay valid.
+type signingKey struct {
+	ID        string    `json:"id"`
+	Secret    string    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	ID      string `json:"jti"`
+	Subject string `json:"sub,omitempty"`
+	Role    string `json:"role"`
+	// IssuedAt is fractional so that sessions issued just before a password
+	// change can be told apart from those issued just after it.
+	IssuedAt  float64 `json:"iat"`
+	ExpiresAt int64   `json:"exp"`
+}
+
+// jwtSessions issues sessions as signed JWTs. Any replica sharing the state
+// database can verify them without a shared session table; the signing keys
+// live in the settings table and rotate every rotation period. Logout only
+// revokes a token on the replica that handled it.
+type jwtSessions struct {
+	store    settingsStore
+	rotation time.Duration
+	now      func() time.Time
+	logger   *logging.Logger
+
+	mu      sync.Mutex
+	keys    []signingKey // newest first
+	revoked map[string]time.Time
+}
+
+func newJWTSessions(ctx context.Context, store settingsStore, rotation time.Duration, logger *logging.Logger) *jwtSessions {
+	if rotation <= 0 {
+		rotation = defaultKeyRotation
+	}
+	j := &jwtSessions{
+		store:    store,
+		rotation: rotation,
+		now:      time.Now,
+		logger:   logger,
+		revoked:  make(map[string]time.Time),
+	}
+	j.mu.Lock()
+	j.keys = j.loadKeys(ctx)
+	j.mu.Unlock()
+	return j
+}
+
+func (j *jwtSessions) create(access accessLevel, username string) string {
+	j.mu.Lock()
+	key, err := j.signingKey(context.Background())
+	j.mu.Unlock()
+	if err != nil {
+		j.logger.Error().Err(err).Msg("Failed to rotate session signing key")
+		return ""
+	}
+
+	now := j.now()
+	jti, err := generateToken()
+	if err != nil {
+		return ""
+	}
+	header, _ := json.Marshal(jwtHeader{Alg: "HS256", Typ: "JWT", Kid: key.ID})
+	claims, _ := json.Marshal(jwtClaims{
+		ID:        jti,
+		Subject:   username,
+		Role:      access.String(),
+		IssuedAt:  float64(now.UnixMicro()) / 1e6,
+		ExpiresAt: now.Add(sessionTTL).Unix(),
+	})
+	unsigned := encodeSegment(header) + "." + encodeSegment(claims)
+	return unsigned + "." + encodeSegment(sign(key, unsigned))
+}
+
+func (j *jwtSessions) validate(token string) session {
+	claims, err := j.verify(token)
+	if err != nil {
+		return session{}
+	}
+	var access accessLevel
+	for _, level := range []accessLevel{accessRead, accessWrite, accessAdmin} {
+		if claims.Role == level.String() {
+			access = level
+		}
+	}
+	return session{
+		access:   access,
+		username: claims.Subject,
+		issued:   time.UnixMicro(int64(math.Round(claims.IssuedAt * 1e6))),
+		expiry:   time.Unix(claims.ExpiresAt, 0),
+	}
+}
+
+func (j *jwtSessions) delete(token string) {
+	claims, err := j.verify(token)
+	if err != nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := j.now()
+	for id, expiry := range j.revoked {
+		if now.After(expiry) {
+			delete(j.revoked, id)
+		}
+	}
+	j.revoked[claims.ID] = time.Unix(claims.ExpiresAt, 0)
+}
+
+func (j *jwtSessions) verify(token string) (jwtClaims, error) {
+	var claims jwtClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("malformed token")
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims, err
+	}
+	if header.Alg != "HS256" {
+		return claims, errors.New("unsupported algorithm")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, err
+	}
+
+	j.mu.Lock()
+	key, ok := j.key(header.Kid)
+	if !ok {
+		// Another replica may have rotated the key.
+		j.keys = j.loadKeys(context.Background())
+		key, ok = j.key(header.Kid)
+	}
+	j.mu.Unlock()
+	if !ok {
+		return claims, errors.New("unknown signing key")
+	}
+	if !hmac.Equal(signature, sign(key, parts[0]+"."+parts[1])) {
+		return claims, errors.New("invalid signature")
+	}
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, err
+	}
+	if !j.now().Before(time.Unix(claims.ExpiresAt, 0)) {
+		return claims, errors.New("token expired")
+	}
+	j.mu.Lock()
+	_, revoked := j.revoked[claims.ID]
+	j.mu.Unlock()
+	if revoked {
+		return claims, errors.New("token revoked")
+	}
+	return claims, nil
+}
+
+// key must be called with j.mu held.
+func (j *jwtSessions) key(id string) (signingKey, bool) {
+	for _, key := range j.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return signingKey{}, false
+}
+
+// signingKey returns the newest key, rotating first when it is older than
+// the rotation period. It must be called with j.mu held.
+func (j *jwtSessions) signingKey(ctx context.Context) (signingKey, error) {
+	now := j.now()
+	if len(j.keys) > 0 && now.Sub(j.keys[0].CreatedAt) < j.rotation {
+		return j.keys[0], nil
+	}
+	// Pick up a key another replica may have added since.
+	j.keys = j.loadKeys(ctx)
+	if len(j.keys) > 0 && now.Sub(j.keys[0].CreatedAt) < j.rotation {
+		return j.keys[0], nil
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return signingKey{}, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return signingKey{}, err
+	}
+	key := signingKey{ID: hex.EncodeToString(id), Secret: hex.EncodeToString(secret), CreatedAt: now.UTC()}
+	j.keys = append([]signingKey{key}, j.keys...)
+	if j.store != nil {
+		encoded, err := json.Marshal(j.keys)
+		if err != nil {
+			return signingKey{}, err
+		}
+		if err := j.store.SetSetting(ctx, signingKeysSetting, string(encoded)); err != nil {
+			return signingKey{}, err
+		}
+	}
+	return key, nil
+}
+
+// loadKeys returns the stored keys that may still verify a session, newest
+// first. Without a store the in-memory keys are all there is.
+func (j *jwtSessions) loadKeys(ctx context.Context) []signingKey {
+	keys := j.keys
+	if j.store != nil {
+		if raw, err := j.store.GetSetting(ctx, signingKeysSetting); err == nil {
+			var stored []signingKey
+			if err := json.Unmarshal([]byte(raw), &stored); err != nil {
+				j.logger.Warn().Err(err).Msg("Ignoring unreadable session signing keys")
+			} else {
+				keys = stored
+			}
+		}
+	}
+
+	cutoff := j.now().Add(-(j.rotation + sessionTTL))
+	live := make([]signingKey, 0, len(keys))
+	for _, key := range keys {
+		if key.CreatedAt.After(cutoff) && key.Secret != "" {
+			live = append(live, key)
+		}
+	}
+	return live
+}
+
+func sign(key signingKey, unsigned string) []byte {
+	secret, _ := hex.DecodeString(key.Secret)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
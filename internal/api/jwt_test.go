@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+)
+
+type memorySettings struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (m *memorySettings) GetSetting(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.values[key]
+	if !ok {
+		return "", fmt.Errorf("setting not found: %s", key)
+	}
+	return value, nil
+}
+
+func (m *memorySettings) SetSetting(_ context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.values == nil {
+		m.values = make(map[string]string)
+	}
+	m.values[key] = value
+	return nil
+}
+
+func TestJWTSessionsRoundTrip(t *testing.T) {
+	j := newJWTSessions(context.Background(), nil, 0, logging.Default())
+
+	for _, access := range []accessLevel{accessRead, accessWrite, accessAdmin} {
+		token := j.create(access, "alice")
+		if strings.Count(token, ".") != 2 {
+			t.Fatalf("expected a JWT, got %q", token)
+		}
+		sess := j.validate(token)
+		if sess.access != access || sess.username != "alice" {
+			t.Errorf("expected %s for alice, got %s for %q", access, sess.access, sess.username)
+		}
+	}
+}
+
+func TestJWTSessionsRejectTampering(t *testing.T) {
+	j := newJWTSessions(context.Background(), nil, 0, logging.Default())
+	token := j.create(accessRead, "")
+	parts := strings.Split(token, ".")
+
+	forged := encodeSegment([]byte(`{"jti":"x","role":"write","iat":0,"exp":9999999999}`))
+	cases := map[string]string{
+		"forged claims":   parts[0] + "." + forged + "." + parts[2],
+		"no signature":    parts[0] + "." + parts[1] + ".",
+		"alg none":        encodeSegment([]byte(`{"alg":"none","kid":"x"}`)) + "." + parts[1] + "." + parts[2],
+		"opaque garbage":  "not-a-token",
+		"other secret":    newJWTSessions(context.Background(), nil, 0, logging.Default()).create(accessWrite, ""),
+		"empty cookie":    "",
+		"truncated parts": parts[0] + "." + parts[1],
+	}
+	for name, value := range cases {
+		if got := j.validate(value).access; got != accessNone {
+			t.Errorf("%s: expected none, got %s", name, got)
+		}
+	}
+}
+
+func TestJWTSessionsExpiryAndLogout(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	j := newJWTSessions(context.Background(), nil, 0, logging.Default())
+	j.now = func() time.Time { return now }
+
+	token := j.create(accessWrite, "")
+	now = now.Add(sessionTTL)
+	if got := j.validate(token).access; got != accessNone {
+		t.Errorf("expected an expired session, got %s", got)
+	}
+
+	now = now.Add(time.Hour)
+	token = j.create(accessWrite, "")
+	j.delete(token)
+	if got := j.validate(token).access; got != accessNone {
+		t.Errorf("expected a revoked session, got %s", got)
+	}
+}
+
+func TestJWTSessionsShareRotatingKeys(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := &memorySettings{}
+
+	a := newJWTSessions(context.Background(), store, 12*time.Hour, logging.Default())
+	a.now = clock
+	b := newJWTSessions(context.Background(), store, 12*time.Hour, logging.Default())
+	b.now = clock
+
+	first := a.create(accessWrite, "")
+	if got := b.validate(first).access; got != accessWrite {
+		t.Fatalf("expected another replica to accept the session, got %s", got)
+	}
+
+	now = now.Add(13 * time.Hour)
+	rotated := a.create(accessRead, "")
+	if got := b.validate(rotated).access; got != accessRead {
+		t.Errorf("expected a session signed with the rotated key to verify, got %s", got)
+	}
+	if got := b.validate(first).access; got != accessWrite {
+		t.Errorf("expected a session signed before rotation to verify, got %s", got)
+	}
+	b.create(accessRead, "")
+	if len(b.keys) != 2 {
+		t.Errorf("expected the old key to be kept after rotation, got %d keys", len(b.keys))
+	}
+
+	now = now.Add(72 * time.Hour)
+	b.create(accessRead, "")
+	if len(b.keys) != 1 {
+		t.Errorf("expected retired keys to be dropped, got %d keys", len(b.keys))
+	}
+}
@@ -0,0 +1,178 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/journal"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type labelsRequest struct {
+	discovery.LabelSettings
+	Apply bool `json:"apply"`
+}
+
+type labelsResponse struct {
+	Service  string                  `json:"service"`
+	Settings discovery.LabelSettings `json:"settings"`
+	Labels   map[string]string       `json:"labels"`
+	Block    string                  `json:"block"`
+	Path     string                  `json:"path,omitempty"`
+	Diff     string                  `json:"diff,omitempty"`
+	Applied  bool                    `json:"applied"`
+	Entry    *journal.Entry          `json:"entry,omitempty"`
+}
+
+// handleServiceLabels generates the Bulwark labels of a service from its
+// settings, so services can be configured without hand-editing YAML:
+//
+//	GET  /api/services/{id}/labels  current settings and their label block
+//	POST /api/services/{id}/labels  {"policy": "safe", "probe": {...}, "apply": false}
+//
+// A POST answers with the label block and, for compose services, the diff
+// of the compose file. With "apply": true the labels are written to the
+// compose file, keeping its other content and formatting, and the change
+// is journaled. The running container keeps its old labels until it is
+// next recreated; Bulwark itself reads the compose file.
+func (s *Server) handleServiceLabels(w http.ResponseWriter, r *http.Request, serviceID string) {
+	var req labelsRequest
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+			return
+		}
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	target, service, err := s.findService(r.Context(), serviceID)
+	if err != nil {
+		writeError(w, statusForError(err), "service not found", err.Error())
+		return
+	}
+	compose := target.Type == state.TargetTypeCompose && target.Path != ""
+
+	var content []byte
+	if compose {
+		if content, err = os.ReadFile(target.Path); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to read compose file", err.Error())
+			return
+		}
+	}
+	if r.Method == http.MethodGet {
+		if compose {
+			current, err := discovery.ComposeLabels(content, service.Name)
+			if err != nil {
+				writeError(w, http.StatusUnprocessableEntity, "failed to read labels", err.Error())
+				return
+			}
+			req.LabelSettings = discovery.SettingsFromLabels(current)
+		} else {
+			req.LabelSettings = settingsOf(service.Labels)
+		}
+	}
+
+	labels, err := req.Labels()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid settings", err.Error())
+		return
+	}
+	resp := labelsResponse{
+		Service:  service.Name,
+		Settings: req.LabelSettings,
+		Labels:   labels,
+		Block:    discovery.LabelBlock(labels),
+	}
+	if !compose {
+		if req.Apply {
+			writeError(w, http.StatusUnprocessableEntity, "labels cannot be written back",
+				fmt.Sprintf("%s is a %s target; set the labels where the container is created", target.Name, target.Type))
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	updated, err := discovery.SetComposeLabels(content, service.Name, labels)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "labels cannot be written back", err.Error())
+		return
+	}
+	resp.Path = target.Path
+	resp.Diff = journal.Diff(string(content), string(updated))
+	if !req.Apply || string(updated) == string(content) {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	if s.cfg.Observer() {
+		writeError(w, http.StatusNotFound, "not found", r.URL.Path)
+		return
+	}
+	s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if current, err := os.ReadFile(target.Path); err != nil || string(current) != string(content) {
+			writeError(w, http.StatusConflict, "compose file changed", "The compose file changed while the labels were generated; try again")
+			return
+		}
+		entry, err := journal.Write(target.Path, updated, s.actor(r), fmt.Sprintf("labels of %s set", service.Name))
+		if err != nil {
+			writeError(w, statusForError(err), "failed to write compose file", err.Error())
+			return
+		}
+		s.planCache.Invalidate()
+		s.requestLogger(r).Info().
+			Str("target", target.Name).
+			Str("service", service.Name).
+			Msg("Service labels written")
+		resp.Applied = true
+		resp.Entry = entry
+		writeJSON(w, http.StatusOK, resp)
+	})).ServeHTTP(w, r)
+}
+
+// findService returns the service with id and its target, discovered when
+// Docker is reachable and read from the store otherwise.
+func (s *Server) findService(ctx context.Context, id string) (*state.Target, *state.Service, error) {
+	targets, err := s.discoverTargets(ctx, "")
+	if err == nil {
+		if target, service := findDiscoveredService(targets, id); service != nil {
+			return target, service, nil
+		}
+	}
+	if s.store != nil {
+		if service, storeErr := s.store.GetService(ctx, id); storeErr == nil {
+			if target, storeErr := s.store.GetTarget(ctx, service.TargetID); storeErr == nil {
+				return target, service, nil
+			}
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, nil, fmt.Errorf("service %s %w", id, state.ErrNotFound)
+}
+
+// settingsOf returns the settings of parsed labels, for services whose raw
+// labels Bulwark cannot read back, such as loose containers.
+func settingsOf(labels state.Labels) discovery.LabelSettings {
+	return discovery.LabelSettings{
+		Policy: string(labels.Policy),
+		Tier:   string(labels.Tier),
+		Probe: discovery.ProbeSettings{
+			Type:         string(labels.Probe.Type),
+			URL:          labels.Probe.HTTPUrl,
+			ExpectStatus: labels.Probe.HTTPStatus,
+			TCPHost:      labels.Probe.TCPHost,
+			TCPPort:      labels.Probe.TCPPort,
+			LogPattern:   labels.Probe.LogPattern,
+			WindowSec:    labels.Probe.WindowSec,
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/journal"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestServiceLabels(t *testing.T) {
+	s := setupTestServer(t)
+	s.cfg.ReadOnly = false
+	s.cfg.WebToken = "write-token-secret"
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	compose := "services:\n  web:\n    image: nginx:1.27 # pinned\n    labels:\n      bulwark.enabled: \"true\"\n      bulwark.policy: notify\n"
+	if err := os.WriteFile(path, []byte(compose), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	ctx := context.Background()
+	if err := s.store.SaveTarget(ctx, &state.Target{ID: "target-1", Type: state.TargetTypeCompose, Name: "app", Path: path, Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	if err := s.store.SaveService(ctx, &state.Service{ID: "service-1", TargetID: "target-1", Name: "web", Image: "nginx:1.27", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	h := s.Handler()
+	do := func(method, body, token string) (*httptest.ResponseRecorder, labelsResponse) {
+		req := httptest.NewRequest(method, "/api/services/service-1/labels", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var resp labelsResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, resp := do(http.MethodGet, "", "")
+	if w.Code != http.StatusOK || resp.Settings.Policy != "notify" || resp.Diff != "" {
+		t.Fatalf("expected the current settings, got %d %+v", w.Code, resp)
+	}
+
+	settings := `{"policy": "safe", "probe": {"type": "http", "url": "http://localhost/health"}`
+	w, resp = do(http.MethodPost, settings+`}`, "")
+	if w.Code != http.StatusOK || resp.Applied || !strings.Contains(resp.Diff, "+      bulwark.policy: safe") {
+		t.Fatalf("expected a preview, got %d %+v", w.Code, resp)
+	}
+	if !strings.Contains(resp.Block, "bulwark.probe.url: http://localhost/health") {
+		t.Errorf("expected the probe URL in the block, got %q", resp.Block)
+	}
+	if data, _ := os.ReadFile(path); string(data) != compose {
+		t.Errorf("expected a preview to leave the compose file alone, got %q", data)
+	}
+
+	if w, _ := do(http.MethodPost, `{"policy": "careful"}`, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown policy, got %d", w.Code)
+	}
+	if w, _ := do(http.MethodPost, settings+`, "apply": true}`, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the write token, got %d", w.Code)
+	}
+	w, resp = do(http.MethodPost, settings+`, "apply": true}`, "write-token-secret")
+	if w.Code != http.StatusOK || !resp.Applied || resp.Entry == nil {
+		t.Fatalf("expected the labels to be written, got %d %s", w.Code, w.Body.String())
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "image: nginx:1.27 # pinned\n") || !strings.Contains(string(data), "bulwark.probe.type: http\n") {
+		t.Errorf("unexpected compose file:\n%s", data)
+	}
+	if entries, _ := journal.List(path); len(entries) != 1 || entries[0].Reason != "labels of web set" {
+		t.Errorf("expected the change to be journaled, got %+v", entries)
+	}
+}
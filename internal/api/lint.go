@@ -0,0 +1,59 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/docker"
+)
+
+// lintResponse lists the running containers whose bulwark.* labels have
+// issues.
+type lintResponse struct {
+	SchemaVersion int                     `json:"schema_version"`
+	CheckedAt     time.Time               `json:"checked_at"`
+	IssueCount    int                     `json:"issue_count"`
+	Containers    []discovery.LabelReport `json:"containers"`
+}
+
+// handleLint checks the labels of every running container, managed or
+// not, so a misspelled bulwark.enabled shows up too.
+func (s *Server) handleLint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	reports, err := s.lintLabels(r.Context())
+	if err != nil {
+		writeError(w, statusForError(err), "label lint failed", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, newLintResponse(reports))
+}
+
+func (s *Server) lintLabels(ctx context.Context) ([]discovery.LabelReport, error) {
+	ctx, cancel := withTimeout(ctx, s.cfg.DiscoveryTimeout)
+	defer cancel()
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = dockerClient.Close() }()
+	return discovery.NewDiscoverer(s.logger, dockerClient).Lint(ctx)
+}
+
+func newLintResponse(reports []discovery.LabelReport) lintResponse {
+	resp := lintResponse{
+		SchemaVersion: discovery.SchemaVersion,
+		CheckedAt:     time.Now().UTC(),
+		Containers:    reports,
+	}
+	for _, report := range reports {
+		resp.IssueCount += len(report.Issues)
+	}
+	return resp
+}
@@ -0,0 +1,28 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestNewLintResponse(t *testing.T) {
+	resp := newLintResponse([]discovery.LabelReport{
+		{Container: "web", Issues: []state.LabelIssue{{Label: "bulwark.polcy"}, {Label: "bulwark.tier"}}},
+		{Container: "db", Issues: []state.LabelIssue{{Label: "bulwark.enable"}}},
+	})
+	if resp.SchemaVersion != discovery.SchemaVersion || resp.IssueCount != 3 || len(resp.Containers) != 2 {
+		t.Errorf("unexpected lint response: %+v", resp)
+	}
+}
+
+func TestHandleLint_Method(t *testing.T) {
+	w := httptest.NewRecorder()
+	testServer().handleLint(w, httptest.NewRequest(http.MethodPost, "/api/lint", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+const maxMaintenanceMessage = 500
+
+type maintenanceRequest struct {
+	Message string `json:"message"`
+	// Until ends the window at a time, e.g. "18:00" or an RFC 3339 time, or
+	// after a length such as "2h". The window is open-ended without it.
+	Until string `json:"until"`
+}
+
+type maintenanceResponse struct {
+	Maintenance *state.Maintenance `json:"maintenance"`
+}
+
+// maintenance returns the active maintenance window, or nil. With a state
+// database the window is read from it on every call, so one started with
+// the CLI takes effect without a restart.
+func (s *Server) maintenance(ctx context.Context) *state.Maintenance {
+	var current *state.Maintenance
+	if s.store != nil {
+		current = state.LoadMaintenance(ctx, s.store)
+	} else {
+		current = s.maintenanceWindow.Load()
+	}
+	if !current.Active(time.Now()) {
+		return nil
+	}
+	return current
+}
+
+func (s *Server) saveMaintenance(ctx context.Context, maintenance *state.Maintenance) error {
+	if s.store != nil {
+		return state.SaveMaintenance(ctx, s.store, maintenance)
+	}
+	s.maintenanceWindow.Store(maintenance)
+	return nil
+}
+
+// handleMaintenance serves the maintenance window:
+//
+//	GET    /api/maintenance  the active window, or null
+//	PUT    /api/maintenance  start or change the window
+//	DELETE /api/maintenance  end it
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, maintenanceResponse{Maintenance: s.maintenance(r.Context())})
+	case http.MethodPut:
+		s.requireWrite(http.HandlerFunc(s.handleMaintenanceStart)).ServeHTTP(w, r)
+	case http.MethodDelete:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := s.saveMaintenance(r.Context(), nil); err != nil {
+				writeError(w, http.StatusInternalServerError, "failed to end maintenance", err.Error())
+				return
+			}
+			s.requestLogger(r).Info().Str("actor", s.actor(r)).Msg("Maintenance ended")
+			w.WriteHeader(http.StatusNoContent)
+		})).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+func (s *Server) handleMaintenanceStart(w http.ResponseWriter, r *http.Request) {
+	var req maintenanceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	now := time.Now()
+	maintenance := &state.Maintenance{
+		Message:   strings.TrimSpace(req.Message),
+		StartedBy: s.actor(r),
+		StartedAt: now.UTC(),
+	}
+	if utf8.RuneCountInString(maintenance.Message) > maxMaintenanceMessage {
+		writeError(w, http.StatusBadRequest, "invalid message", "the message may be up to 500 characters")
+		return
+	}
+	if strings.TrimSpace(req.Until) != "" {
+		until, err := state.ParseMaintenanceUntil(req.Until, now)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid until", err.Error())
+			return
+		}
+		until = until.UTC()
+		maintenance.Until = &until
+	}
+	// Changing an active window keeps when it started.
+	if current := s.maintenance(r.Context()); current != nil {
+		maintenance.StartedAt = current.StartedAt
+	}
+	if err := s.saveMaintenance(r.Context(), maintenance); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to start maintenance", err.Error())
+		return
+	}
+	event := s.requestLogger(r).Info().Str("actor", maintenance.StartedBy).Str("message", maintenance.Message)
+	if maintenance.Until != nil {
+		event = event.Time("until", *maintenance.Until)
+	}
+	event.Msg("Maintenance started")
+	writeJSON(w, http.StatusOK, maintenanceResponse{Maintenance: maintenance})
+}
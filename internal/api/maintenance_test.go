@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestMaintenance(t *testing.T) {
+	s := setupTestServer(t)
+	s.cfg.ReadOnly = false
+	s.cfg.WebToken = "write-token-secret"
+	t.Cleanup(s.stopScheduledRuns)
+	h := s.Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer write-token-secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	health := func() healthResponse {
+		var resp healthResponse
+		if err := json.NewDecoder(do(http.MethodGet, "/api/health", "").Body).Decode(&resp); err != nil {
+			t.Fatalf("decode health: %v", err)
+		}
+		return resp
+	}
+
+	if w := do(http.MethodPut, "/api/maintenance", `{"message":"Host maintenance","until":"tonight"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unreadable end, got %d", w.Code)
+	}
+	w := do(http.MethodPut, "/api/maintenance", `{"message":"Host maintenance","until":"2h"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	got := health().Maintenance
+	if got == nil || got.Message != "Host maintenance" || got.Until == nil || got.StartedBy != "api" {
+		t.Fatalf("expected the window in /api/health, got %+v", got)
+	}
+
+	// Scheduled runs coming due during maintenance are skipped.
+	ctx := context.Background()
+	if err := s.store.SaveScheduledRun(ctx, &state.ScheduledRun{ID: "due", RunAt: time.Now(), Mode: "safe", Status: state.ScheduledRunPending}); err != nil {
+		t.Fatalf("SaveScheduledRun failed: %v", err)
+	}
+	s.startScheduledRun("due")
+	if run, err := s.scheduledRun(ctx, "due"); err != nil || run.Status != state.ScheduledRunSkipped {
+		t.Errorf("expected the run to be skipped, got %+v (%v)", run, err)
+	}
+	if runs := s.runs.List(""); len(runs) != 0 {
+		t.Errorf("expected no apply to be queued, got %+v", runs)
+	}
+
+	if w := do(http.MethodDelete, "/api/maintenance", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if got := health().Maintenance; got != nil {
+		t.Errorf("expected no window once ended, got %+v", got)
+	}
+}
+
+func TestMaintenanceEndsOnItsOwn(t *testing.T) {
+	s := testServer()
+	past := time.Now().Add(-time.Minute)
+	s.maintenanceWindow.Store(&state.Maintenance{Message: "done", Until: &past})
+	if got := s.maintenance(context.Background()); got != nil {
+		t.Errorf("expected an expired window to be inactive, got %+v", got)
+	}
+	s.maintenanceWindow.Store(&state.Maintenance{Message: "open-ended"})
+	if got := s.maintenance(context.Background()); got == nil {
+		t.Error("expected an open-ended window to be active")
+	}
+}
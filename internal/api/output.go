@@ -0,0 +1,107 @@
+package api
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/artifacts"
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+// outputInterval is the minimum gap between two output events of one
+// service. A pull prints a line per layer per progress tick; without a limit
+// a single large image would fill the run's event log.
+const outputInterval = 2 * time.Second
+
+// outputEvents records compose output of one service as "output" run events,
+// at most one per interval. Lines arriving in between are dropped; the newest
+// of them is kept for flush, so the command's last line is always recorded.
+// Pull progress is tracked from every line, dropped or not, and each event
+// carries the percentage of every layer seen so far. Every line is also kept
+// in log, for the run's artifacts.
+type outputEvents struct {
+	runs     *RunManager
+	runID    string
+	target   string
+	service  string
+	interval time.Duration
+	now      func() time.Time
+
+	mu            sync.Mutex
+	last          time.Time
+	pending       string
+	pendingStream string
+	layers        map[string]int // Layer ID -> percent pulled
+
+	log artifacts.Log
+}
+
+func newOutputEvents(runs *RunManager, runID string, item planner.PlanItem) *outputEvents {
+	return &outputEvents{
+		runs:     runs,
+		runID:    runID,
+		target:   item.TargetName,
+		service:  item.ServiceName,
+		interval: outputInterval,
+		now:      time.Now,
+	}
+}
+
+// write is a docker.OutputFunc.
+func (o *outputEvents) write(stream, line string) {
+	o.log.Write(stream, line)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if progress, ok := docker.ParsePullProgress(line); ok {
+		if o.layers == nil {
+			o.layers = make(map[string]int)
+		}
+		o.layers[progress.Layer] = progress.Percent()
+	}
+
+	now := o.now()
+	if now.Sub(o.last) < o.interval {
+		o.pending, o.pendingStream = line, stream
+		return
+	}
+	o.last = now
+	o.pending = ""
+	o.add(stream, line)
+}
+
+// flush writes the newest line that was held back by the interval.
+func (o *outputEvents) flush() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.pending == "" {
+		return
+	}
+	o.add(o.pendingStream, o.pending)
+	o.pending = ""
+	o.last = o.now()
+}
+
+func (o *outputEvents) add(stream, line string) {
+	data := map[string]interface{}{"stream": stream}
+	if len(o.layers) > 0 {
+		total := 0
+		for _, percent := range o.layers {
+			total += percent
+		}
+		data["layers"] = maps.Clone(o.layers)
+		data["percent"] = total / len(o.layers)
+	}
+	o.runs.AddEvent(o.runID, RunEvent{
+		Level:   "info",
+		Target:  o.target,
+		Service: o.service,
+		Step:    "output",
+		Message: line,
+		Data:    data,
+	})
+}
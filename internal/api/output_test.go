@@ -0,0 +1,72 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+func TestOutputEvents_Throttles(t *testing.T) {
+	rm := NewRunManager(10, 100, 50, nil)
+	run := rm.CreateRun("apply")
+
+	now := time.Now()
+	output := newOutputEvents(rm, run.ID, planner.PlanItem{TargetName: "media", ServiceName: "web"})
+	output.now = func() time.Time { return now }
+
+	output.write("stderr", "layer 1 downloading")
+	output.write("stderr", "layer 2 downloading")
+	output.write("stderr", "layer 2 extracting")
+	now = now.Add(outputInterval)
+	output.write("stderr", "layer 3 downloading")
+	output.write("stderr", "web Pulled")
+	output.flush()
+
+	got, _ := rm.Get(run.ID)
+	var messages []string
+	for _, event := range got.Events {
+		if event.Step == "output" {
+			messages = append(messages, event.Message)
+		}
+	}
+	want := []string{"layer 1 downloading", "layer 3 downloading", "web Pulled"}
+	if len(messages) != len(want) {
+		t.Fatalf("messages = %q, want %q", messages, want)
+	}
+	for i := range want {
+		if messages[i] != want[i] {
+			t.Errorf("message %d = %q, want %q", i, messages[i], want[i])
+		}
+	}
+	if got.Events[0].Service != "web" || got.Events[0].Data["stream"] != "stderr" {
+		t.Errorf("unexpected event %+v", got.Events[0])
+	}
+}
+
+func TestOutputEvents_LayerProgress(t *testing.T) {
+	rm := NewRunManager(10, 100, 50, nil)
+	run := rm.CreateRun("apply")
+
+	now := time.Now()
+	output := newOutputEvents(rm, run.ID, planner.PlanItem{TargetName: "media", ServiceName: "web"})
+	output.now = func() time.Time { return now }
+
+	output.write("stderr", "web Pulling")
+	output.write("stderr", "aaaaaaaaaaaa Downloading [=====>     ]  5MB/10MB")
+	output.write("stderr", "bbbbbbbbbbbb Pull complete")
+	output.flush()
+
+	got, _ := rm.Get(run.ID)
+	last := got.Events[len(got.Events)-1]
+	layers, _ := last.Data["layers"].(map[string]int)
+	if layers["aaaaaaaaaaaa"] != 50 || layers["bbbbbbbbbbbb"] != 100 {
+		t.Errorf("layers = %v, want aaaaaaaaaaaa at 50 and bbbbbbbbbbbb at 100", last.Data["layers"])
+	}
+	if last.Data["percent"] != 75 {
+		t.Errorf("percent = %v, want 75", last.Data["percent"])
+	}
+	if _, ok := got.Events[0].Data["layers"]; ok {
+		t.Errorf("first event has layers before any were reported: %v", got.Events[0].Data)
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// trendWindow is the period the overview trends compare: the last week
+// against the week before.
+const trendWindow = 7 * 24 * time.Hour
+
+// overviewFailure is the latest failed update of a group or host.
+type overviewFailure struct {
+	CompletedAt time.Time        `json:"completed_at"`
+	TargetID    string           `json:"target_id"`
+	Service     string           `json:"service"`
+	Message     string           `json:"message,omitempty"`
+	Code        state.ResultCode `json:"code,omitempty"`
+}
+
+// hostSummary rolls up the targets running on one Docker host.
+type hostSummary struct {
+	Name             string           `json:"name"`
+	Targets          int              `json:"targets"`
+	Services         int              `json:"services"`
+	UpdatesAvailable int              `json:"updates_available"`
+	LastFailure      *overviewFailure `json:"last_failure,omitempty"`
+}
+
+// overviewTrends compares the updates of the last 7 days with the 7 days
+// before.
+type overviewTrends struct {
+	Applied   trendDelta `json:"applied"`
+	Failures  trendDelta `json:"failures"`
+	Rollbacks trendDelta `json:"rollbacks"`
+}
+
+type trendDelta struct {
+	Current  int `json:"current"`
+	Previous int `json:"previous"`
+	Delta    int `json:"delta"`
+}
+
+func (d *trendDelta) add(at, now time.Time) {
+	switch {
+	case at.After(now.Add(-trendWindow)):
+		d.Current++
+	case at.After(now.Add(-2 * trendWindow)):
+		d.Previous++
+	}
+	d.Delta = d.Current - d.Previous
+}
+
+// trendHistory returns the updates completed in the last two trend windows,
+// newest first.
+func (s *Server) trendHistory(ctx context.Context, now time.Time) []state.UpdateResult {
+	if s.store == nil {
+		return nil
+	}
+	history, err := s.store.ListUpdateHistory(ctx, state.HistoryQuery{
+		Since: now.Add(-2 * trendWindow),
+		Limit: maxStatsUpdates,
+	})
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to load history for overview")
+		return nil
+	}
+	return history
+}
+
+// buildTrends counts applied, failed and rolled back updates per window.
+// Skipped updates count as neither.
+func buildTrends(history []state.UpdateResult, now time.Time) overviewTrends {
+	var trends overviewTrends
+	for _, result := range history {
+		if result.ResultCode.IsSkip() {
+			continue
+		}
+		if result.Success {
+			trends.Applied.add(result.CompletedAt, now)
+		} else {
+			trends.Failures.add(result.CompletedAt, now)
+		}
+		if result.RollbackPerformed {
+			trends.Rollbacks.add(result.CompletedAt, now)
+		}
+	}
+	return trends
+}
+
+// lastFailures maps target IDs to their latest failed update in history,
+// which is ordered newest first.
+func lastFailures(history []state.UpdateResult) map[string]*overviewFailure {
+	failures := make(map[string]*overviewFailure)
+	for _, result := range history {
+		if result.Success || result.ResultCode.IsSkip() {
+			continue
+		}
+		if _, ok := failures[result.TargetID]; ok {
+			continue
+		}
+		failures[result.TargetID] = &overviewFailure{
+			CompletedAt: result.CompletedAt,
+			TargetID:    result.TargetID,
+			Service:     result.ServiceName,
+			Message:     result.ErrorMessage,
+			Code:        result.ErrorCode,
+		}
+	}
+	return failures
+}
+
+// latestFailure returns the newest of the failures of targets.
+func latestFailure(failures map[string]*overviewFailure, targets map[string]bool) *overviewFailure {
+	var latest *overviewFailure
+	for id := range targets {
+		if failure := failures[id]; failure != nil && (latest == nil || failure.CompletedAt.After(latest.CompletedAt)) {
+			latest = failure
+		}
+	}
+	return latest
+}
+
+// hostSummaries rolls up plan by Docker host. Bulwark manages the one host
+// its Docker endpoint points at.
+func (s *Server) hostSummaries(ctx context.Context, plan *planner.Plan, failures map[string]*overviewFailure) []hostSummary {
+	if plan == nil {
+		return []hostSummary{}
+	}
+	host := hostSummary{Name: s.dockerHostName(ctx)}
+	targets := make(map[string]bool)
+	for _, item := range plan.Items {
+		host.Services++
+		if item.UpdateAvailable {
+			host.UpdatesAvailable++
+		}
+		targets[item.TargetID] = true
+	}
+	host.Targets = len(targets)
+	host.LastFailure = latestFailure(failures, targets)
+	return []hostSummary{host}
+}
+
+// dockerHostName returns the Docker host's name, or "local" while the daemon
+// cannot be asked. A name once resolved is kept.
+func (s *Server) dockerHostName(ctx context.Context) string {
+	s.hostMu.Lock()
+	defer s.hostMu.Unlock()
+	if s.hostName != "" {
+		return s.hostName
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return "local"
+	}
+	defer func() { _ = dockerClient.Close() }()
+	ctx, cancel := withTimeout(ctx, s.cfg.DiscoveryTimeout)
+	defer cancel()
+	name, err := dockerClient.HostName(ctx)
+	if err != nil || name == "" {
+		return "local"
+	}
+	s.hostName = name
+	return name
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestBuildTrends(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	history := []state.UpdateResult{
+		{Success: true, CompletedAt: now.Add(-time.Hour)},
+		{Success: true, CompletedAt: now.Add(-2 * day)},
+		{Success: false, RollbackPerformed: true, CompletedAt: now.Add(-3 * day)},
+		{Success: false, ResultCode: state.ResultPolicyBlocked, CompletedAt: now.Add(-3 * day)},
+		{Success: false, CompletedAt: now.Add(-8 * day)},
+		{Success: false, CompletedAt: now.Add(-9 * day)},
+		{Success: true, CompletedAt: now.Add(-10 * day)},
+		{Success: true, CompletedAt: now.Add(-20 * day)},
+	}
+
+	trends := buildTrends(history, now)
+	if trends.Applied != (trendDelta{Current: 2, Previous: 1, Delta: 1}) {
+		t.Errorf("unexpected applied trend: %+v", trends.Applied)
+	}
+	if trends.Failures != (trendDelta{Current: 1, Previous: 2, Delta: -1}) {
+		t.Errorf("unexpected failures trend: %+v", trends.Failures)
+	}
+	if trends.Rollbacks != (trendDelta{Current: 1, Delta: 1}) {
+		t.Errorf("unexpected rollbacks trend: %+v", trends.Rollbacks)
+	}
+}
+
+func TestHostSummariesLastFailure(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	history := []state.UpdateResult{
+		{TargetID: "t1", ServiceName: "web", Success: true, CompletedAt: now},
+		{TargetID: "t1", ServiceName: "db", ErrorMessage: "probe failed", CompletedAt: now.Add(-time.Hour)},
+		{TargetID: "t2", ServiceName: "app", ErrorMessage: "pull failed", CompletedAt: now.Add(-2 * time.Hour)},
+		{TargetID: "t1", ServiceName: "web", ErrorMessage: "older", CompletedAt: now.Add(-3 * time.Hour)},
+	}
+	failures := lastFailures(history)
+	if len(failures) != 2 || failures["t1"].Service != "db" || failures["t2"].Message != "pull failed" {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+
+	s := testServer()
+	s.hostName = "nas"
+	plan := &planner.Plan{Items: []planner.PlanItem{
+		{TargetID: "t1", UpdateAvailable: true},
+		{TargetID: "t1"},
+		{TargetID: "t2"},
+	}}
+	hosts := s.hostSummaries(context.Background(), plan, failures)
+	if len(hosts) != 1 {
+		t.Fatalf("expected one host, got %+v", hosts)
+	}
+	host := hosts[0]
+	if host.Name != "nas" || host.Targets != 2 || host.Services != 3 || host.UpdatesAvailable != 1 {
+		t.Errorf("unexpected host summary: %+v", host)
+	}
+	if host.LastFailure == nil || host.LastFailure.Service != "db" {
+		t.Errorf("expected the latest failure to be db's, got %+v", host.LastFailure)
+	}
+}
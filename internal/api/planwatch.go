@@ -0,0 +1,179 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+const (
+	// defaultPlanWait is how long GET /api/plan/updates waits for a change.
+	defaultPlanWait = 25 * time.Second
+	maxPlanWait     = 25 * time.Second
+	// planRebuildAllowance is how long a long-poll may still take once its
+	// wait ends, for a plan rebuild started just before. It matches the
+	// server's write timeout, which is what a plain plan request gets.
+	planRebuildAllowance = 30 * time.Second
+)
+
+// planRevision identifies the material state of plan: which services have
+// an update, whether it is allowed, held back or skipped, and the digests
+// involved. Rebuilding a plan that found nothing new keeps its revision.
+func planRevision(plan *planner.Plan) string {
+	entries := make([]string, 0, len(plan.Items))
+	for _, item := range plan.Items {
+		entries = append(entries, fmt.Sprintf("%s|%s|%s|%t|%t|%t|%t|%t",
+			item.ServiceID, item.CurrentDigest, item.RemoteDigest,
+			item.UpdateAvailable, item.Allowed, item.Ignored, item.Paused, item.SnoozedUntil != nil))
+	}
+	sort.Strings(entries)
+	h := sha256.New()
+	for _, entry := range entries {
+		h.Write([]byte(entry))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// planUpdate is an available update as GET /api/plan/updates lists it.
+type planUpdate struct {
+	TargetID      string `json:"target_id"`
+	TargetName    string `json:"target_name"`
+	ServiceID     string `json:"service_id"`
+	ServiceName   string `json:"service_name"`
+	Image         string `json:"image"`
+	CurrentDigest string `json:"current_digest"`
+	RemoteDigest  string `json:"remote_digest"`
+	Allowed       bool   `json:"allowed"`
+	Risk          string `json:"risk"`
+}
+
+type planUpdatesResponse struct {
+	// Revision identifies the plan; pass it as ?since= to wait for the next
+	// change. Changed is false when the wait ended without one.
+	Revision     string       `json:"revision"`
+	Changed      bool         `json:"changed"`
+	GeneratedAt  time.Time    `json:"generated_at"`
+	UpdateCount  int          `json:"update_count"`
+	AllowedCount int          `json:"allowed_count"`
+	Updates      []planUpdate `json:"updates"`
+}
+
+func newPlanUpdatesResponse(plan *planner.Plan, revision string, changed bool) planUpdatesResponse {
+	resp := planUpdatesResponse{
+		Revision:     revision,
+		Changed:      changed,
+		GeneratedAt:  plan.GeneratedAt,
+		UpdateCount:  plan.UpdateCount,
+		AllowedCount: plan.AllowedCount,
+		Updates:      []planUpdate{},
+	}
+	for _, item := range plan.Items {
+		if !item.UpdateAvailable || item.Ignored {
+			continue
+		}
+		resp.Updates = append(resp.Updates, planUpdate{
+			TargetID:      item.TargetID,
+			TargetName:    item.TargetName,
+			ServiceID:     item.ServiceID,
+			ServiceName:   item.ServiceName,
+			Image:         item.Image,
+			CurrentDigest: item.CurrentDigest,
+			RemoteDigest:  item.RemoteDigest,
+			Allowed:       item.Allowed,
+			Risk:          item.Risk,
+		})
+	}
+	return resp
+}
+
+// parsePlanWait reads ?timeout= of GET /api/plan/updates: a duration such
+// as 10s, or a number of seconds. Longer waits are cut to maxPlanWait.
+func parsePlanWait(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultPlanWait, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid timeout %q: expected a duration such as 20s", value)
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must not be negative", value)
+	}
+	if wait > maxPlanWait {
+		wait = maxPlanWait
+	}
+	return wait, nil
+}
+
+// handlePlanUpdates long-polls for plan changes. Without ?since= it answers
+// at once with the current revision. With it, it answers as soon as the
+// plan's revision differs, e.g. because a check found new updates or an
+// apply run finished, or with changed=false once ?timeout= elapses. A
+// waiting request rebuilds the plan when the cached one is dropped or
+// expires, so it notices new updates without the client polling the plan.
+func (s *Server) handlePlanUpdates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	since := r.URL.Query().Get("since")
+	wait, err := parsePlanWait(r.URL.Query().Get("timeout"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	// The wait plus a plan rebuild inside it can outlast the server's write
+	// timeout, so the response's write deadline is moved to cover both.
+	// Writers without deadline support, as in tests, have no timeout to beat.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + planRebuildAllowance))
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	for {
+		// Take the channel before reading the plan, so a change in between
+		// is not missed.
+		changed, expires := s.planCache.Changed()
+		plan, err := s.getPlan(r.Context(), planRequest{})
+		if err != nil {
+			writeError(w, statusForError(err), "plan failed", err.Error())
+			return
+		}
+		revision := planRevision(plan)
+		if revision != since {
+			writeJSON(w, http.StatusOK, newPlanUpdatesResponse(plan, revision, true))
+			return
+		}
+
+		// An expired plan is rebuilt, which is when new updates show up.
+		var expired <-chan time.Time
+		var expiry *time.Timer
+		if !expires.IsZero() {
+			expiry = time.NewTimer(time.Until(expires))
+			expired = expiry.C
+		}
+		select {
+		case <-changed:
+		case <-expired:
+		case <-deadline.C:
+			writeJSON(w, http.StatusOK, newPlanUpdatesResponse(plan, revision, false))
+			return
+		case <-r.Context().Done():
+			return
+		}
+		if expiry != nil {
+			expiry.Stop()
+		}
+	}
+}
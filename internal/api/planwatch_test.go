@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+func watchPlan(digest string) *planner.Plan {
+	return &planner.Plan{GeneratedAt: time.Now(), UpdateCount: 1, Items: []planner.PlanItem{
+		{TargetName: "media", ServiceID: "svc-1", ServiceName: "sonarr", CurrentDigest: "sha256:old", RemoteDigest: digest, UpdateAvailable: digest != "sha256:old", Allowed: true},
+		{TargetName: "media", ServiceID: "svc-2", ServiceName: "radarr", CurrentDigest: "sha256:same", RemoteDigest: "sha256:same"},
+	}}
+}
+
+func getPlanUpdates(t *testing.T, s *Server, query string) (int, planUpdatesResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.handlePlanUpdates(w, httptest.NewRequest(http.MethodGet, "/api/plan/updates"+query, nil))
+	var resp planUpdatesResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestPlanRevision(t *testing.T) {
+	a, b := watchPlan("sha256:new"), watchPlan("sha256:new")
+	b.GeneratedAt = a.GeneratedAt.Add(time.Minute)
+	b.Items[0], b.Items[1] = b.Items[1], b.Items[0]
+	if planRevision(a) != planRevision(b) {
+		t.Error("a rebuild that found nothing new should keep the revision")
+	}
+	if planRevision(a) == planRevision(watchPlan("sha256:newer")) {
+		t.Error("a new remote digest should change the revision")
+	}
+	applied := watchPlan("sha256:new")
+	applied.Items[0].CurrentDigest, applied.Items[0].UpdateAvailable = "sha256:new", false
+	if planRevision(a) == planRevision(applied) {
+		t.Error("an applied update should change the revision")
+	}
+}
+
+func TestHandlePlanUpdates(t *testing.T) {
+	s := testServer()
+	s.planCache.Set(watchPlan("sha256:new"))
+
+	code, first := getPlanUpdates(t, s, "")
+	if code != http.StatusOK || !first.Changed || first.Revision == "" {
+		t.Fatalf("expected the current revision at once, got %d %+v", code, first)
+	}
+	if len(first.Updates) != 1 || first.Updates[0].ServiceName != "sonarr" {
+		t.Errorf("expected sonarr's update, got %+v", first.Updates)
+	}
+
+	t.Run("times out without a change", func(t *testing.T) {
+		s.planCache.Set(watchPlan("sha256:new"))
+		code, resp := getPlanUpdates(t, s, "?since="+first.Revision+"&timeout=50ms")
+		if code != http.StatusOK || resp.Changed || resp.Revision != first.Revision {
+			t.Errorf("expected changed=false with the same revision, got %d %+v", code, resp)
+		}
+	})
+
+	t.Run("wakes on a change", func(t *testing.T) {
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			s.planCache.Set(watchPlan("sha256:newer"))
+		}()
+		start := time.Now()
+		code, resp := getPlanUpdates(t, s, "?since="+first.Revision+"&timeout=5s")
+		if code != http.StatusOK || !resp.Changed || resp.Revision == first.Revision {
+			t.Fatalf("expected a new revision, got %d %+v", code, resp)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("long poll answered after %v, expected right after the change", elapsed)
+		}
+		if resp.Updates[0].RemoteDigest != "sha256:newer" {
+			t.Errorf("expected the new digest, got %+v", resp.Updates)
+		}
+	})
+
+	t.Run("invalid timeout", func(t *testing.T) {
+		if code, _ := getPlanUpdates(t, s, "?timeout=soon"); code != http.StatusBadRequest {
+			t.Errorf("expected 400, got %d", code)
+		}
+	})
+}
+
+func TestParsePlanWait(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", defaultPlanWait},
+		{"10s", 10 * time.Second},
+		{"5", 5 * time.Second},
+		{"10m", maxPlanWait},
+	}
+	for _, tt := range tests {
+		got, err := parsePlanWait(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parsePlanWait(%q) = %v, %v; want %v", tt.value, got, err, tt.want)
+		}
+	}
+	if _, err := parsePlanWait("-1s"); err == nil {
+		t.Error("expected a negative timeout to be rejected")
+	}
+}
+
+func TestHandlePlanUpdates_OutlastsWriteTimeout(t *testing.T) {
+	s := testServer()
+	s.logger = logging.Default()
+	s.planCache.Set(watchPlan("sha256:new"))
+	_, first := getPlanUpdates(t, s, "")
+
+	srv := httptest.NewUnstartedServer(s.Handler())
+	srv.Config.WriteTimeout = 50 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	for _, tc := range []struct{ encoding, query string }{
+		{"", ""},
+		{"gzip", ""},
+		{"", "&fields=revision"},
+		{"gzip", "&fields=revision"},
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/api/plan/updates?since="+first.Revision+"&timeout=200ms"+tc.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tc.encoding != "" {
+			req.Header.Set("Accept-Encoding", tc.encoding)
+		}
+		res, err := srv.Client().Do(req)
+		if err != nil {
+			t.Fatalf("%+v: expected the response to outlast the write timeout, got %v", tc, err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("%+v: expected 200, got %d", tc, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type policySimulationRequest struct {
+	// Labels is the complete hypothetical label set, as in a compose file.
+	Labels map[string]string `json:"labels"`
+	Image  string            `json:"image,omitempty"`
+	// ServiceID evaluates the labels against an existing service, taking its
+	// image and target unless Image overrides the image.
+	ServiceID string `json:"service_id,omitempty"`
+	// UpdateAvailable defaults to true, so the decision says whether an
+	// update would be applied.
+	UpdateAvailable *bool `json:"update_available,omitempty"`
+}
+
+type policySimulationResponse struct {
+	Image      string           `json:"image"`
+	Labels     state.Labels     `json:"labels"` // The labels as Bulwark parses them
+	Allowed    bool             `json:"allowed"`
+	Reason     string           `json:"reason"`
+	ReasonCode state.ReasonCode `json:"reason_code"`
+	Policy     state.Policy     `json:"policy"`
+	Tier       state.Tier       `json:"tier"`
+	Risk       string           `json:"risk"`
+	Warnings   []string         `json:"warnings"`
+}
+
+// handlePolicySimulate evaluates a hypothetical label set without touching
+// any compose file: POST /api/policy/simulate with {"labels": {...}, "image":
+// "nginx:1.27"} or {"labels": {...}, "service_id": "..."}.
+func (s *Server) handlePolicySimulate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	var req policySimulationRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	req.Image = strings.TrimSpace(req.Image)
+	if req.Image == "" && req.ServiceID == "" {
+		writeError(w, http.StatusBadRequest, "image or service_id required", "")
+		return
+	}
+
+	target := &state.Target{Type: state.TargetTypeCompose}
+	service := &state.Service{Image: req.Image}
+	if req.ServiceID != "" {
+		targets, err := s.discoverTargets(r.Context(), "")
+		if err != nil {
+			writeError(w, statusForError(err), "discovery failed", err.Error())
+			return
+		}
+		foundTarget, found := findDiscoveredService(targets, req.ServiceID)
+		if found == nil {
+			writeError(w, http.StatusNotFound, "service not found", req.ServiceID)
+			return
+		}
+		simulated := *found
+		target, service = foundTarget, &simulated
+		if req.Image != "" {
+			service.Image = req.Image
+		}
+	}
+	if _, err := registry.ParseImageReference(service.Image); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid image", err.Error())
+		return
+	}
+
+	service.Labels = discovery.ParseLabels(req.Labels, service.Image)
+	updateAvailable := req.UpdateAvailable == nil || *req.UpdateAvailable
+	engine := policy.NewEngine(s.logger)
+	decision := engine.Evaluate(r.Context(), target, service, updateAvailable)
+
+	warnings := engine.ValidateProbeConfiguration(service.Labels)
+	for _, issue := range service.Labels.Issues {
+		warnings = append(warnings, issue.Message)
+	}
+	for _, flag := range service.Labels.ComposeUpFlags {
+		if !docker.AllowedUpFlag(flag) {
+			warnings = append(warnings, fmt.Sprintf("Compose up flag %s is not supported and will be ignored", flag))
+		}
+	}
+	if tag, mutable := registry.MutableTag(service.Image); mutable && !service.Build {
+		warnings = append(warnings, fmt.Sprintf("Image tracks mutable tag :%s and may change without a version bump; pin a release version", tag))
+	}
+	if warnings == nil {
+		warnings = []string{}
+	}
+
+	writeJSON(w, http.StatusOK, policySimulationResponse{
+		Image:      service.Image,
+		Labels:     service.Labels,
+		Allowed:    decision.Allowed,
+		Reason:     decision.Reason,
+		ReasonCode: decision.Code,
+		Policy:     decision.Policy,
+		Tier:       decision.Tier,
+		Risk:       planner.RiskFromLabels(service.Labels),
+		Warnings:   warnings,
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestPolicySimulate(t *testing.T) {
+	s := setupTestServer(t)
+	h := s.Handler()
+	simulate := func(body string) (*httptest.ResponseRecorder, policySimulationResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/policy/simulate", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var resp policySimulationResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w, resp
+	}
+
+	w, resp := simulate(`{"image":"postgres:16","labels":{"bulwark.enabled":"true","bulwark.policy":"safe","bulwark.polcy":"x"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if resp.Allowed || resp.ReasonCode != state.ReasonStatefulBlocked || resp.Tier != state.TierStateful || resp.Risk != "stateful" {
+		t.Errorf("expected a database under the safe policy to be blocked as stateful, got %+v", resp)
+	}
+	if !strings.Contains(strings.Join(resp.Warnings, "\n"), "Unknown label bulwark.polcy") {
+		t.Errorf("expected the misspelled label to be reported, got %v", resp.Warnings)
+	}
+
+	_, resp = simulate(`{"image":"nginx:1.27","labels":{"bulwark.enabled":"true","bulwark.policy":"aggressive","bulwark.probe.type":"http","bulwark.probe.url":"http://localhost"}}`)
+	if !resp.Allowed || resp.Risk != "safe" || resp.Labels.Probe.HTTPUrl != "http://localhost" {
+		t.Errorf("expected the update to be allowed, got %+v", resp)
+	}
+
+	if w, _ := simulate(`{"labels":{}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without an image or service, got %d", w.Code)
+	}
+	if w, _ := simulate(`{"image":"Bad Image","labels":{}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid image, got %d", w.Code)
+	}
+}
@@ -0,0 +1,30 @@
+package api
+
+import "strings"
+
+// Deployment profiles. An observer checks for updates and notifies, but never
+// changes a container: the apply and rollback endpoints are not registered,
+// scheduled auto-updates and Home Assistant buttons are off, and no compose
+// binary is needed.
+const (
+	ProfileFull     = "full"
+	ProfileObserver = "observer"
+)
+
+// ResolveProfile returns the profile selected by value, as read from
+// BULWARK_PROFILE. A binary built with the observer tag is always an
+// observer, whatever value says.
+func ResolveProfile(value string) string {
+	if buildProfile != "" {
+		return buildProfile
+	}
+	if strings.EqualFold(strings.TrimSpace(value), ProfileObserver) {
+		return ProfileObserver
+	}
+	return ProfileFull
+}
+
+// Observer reports whether c runs the observer profile.
+func (c Config) Observer() bool {
+	return c.Profile == ProfileObserver
+}
@@ -0,0 +1,7 @@
+//go:build !observer
+
+package api
+
+// buildProfile is empty in regular builds, leaving the profile to
+// BULWARK_PROFILE.
+const buildProfile = ""
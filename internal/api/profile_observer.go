@@ -0,0 +1,7 @@
+//go:build observer
+
+package api
+
+// buildProfile pins binaries built with -tags observer to the observer
+// profile, so no configuration can turn updates back on.
+const buildProfile = ProfileObserver
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+)
+
+func TestResolveProfile(t *testing.T) {
+	if buildProfile != "" {
+		t.Skip("profile is fixed by a build tag")
+	}
+	tests := map[string]string{
+		"":          ProfileFull,
+		"full":      ProfileFull,
+		"Observer":  ProfileObserver,
+		" observer": ProfileObserver,
+		"unknown":   ProfileFull,
+	}
+	for value, want := range tests {
+		if got := ResolveProfile(value); got != want {
+			t.Errorf("ResolveProfile(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestHandler_ObserverHasNoUpdateRoutes(t *testing.T) {
+	tests := []struct {
+		profile string
+		want    int
+	}{
+		{ProfileFull, http.StatusForbidden}, // registered, refused by read-only mode
+		{ProfileObserver, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		s := testServer()
+		s.cfg.Profile = tt.profile
+		s.logger = logging.Default()
+		handler := s.Handler()
+		for _, path := range []string{"/api/apply", "/api/rollback"} {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+			if w.Code != tt.want {
+				t.Errorf("%s: POST %s = %d, want %d", tt.profile, path, w.Code, tt.want)
+			}
+		}
+	}
+}
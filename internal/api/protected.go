@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+const (
+	pinnedDigestsKey          = "protected_digests"
+	defaultCleanupKeepDigests = 3
+	// maxProtectedHistory caps the history rows scanned for recent digests.
+	maxProtectedHistory = 5000
+)
+
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// pinnedDigest is a digest a user asked the cleanup to keep.
+type pinnedDigest struct {
+	Digest   string    `json:"digest"`
+	Note     string    `json:"note,omitempty"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// recentDigest is a digest kept because a service ran it recently.
+type recentDigest struct {
+	TargetID    string `json:"target_id"`
+	ServiceID   string `json:"service_id"`
+	ServiceName string `json:"service_name"`
+	Digest      string `json:"digest"`
+}
+
+// protectedResponse lists the digests the image cleanup keeps.
+type protectedResponse struct {
+	Keep   int            `json:"keep"`
+	Recent []recentDigest `json:"recent"`
+	Pinned []pinnedDigest `json:"pinned"`
+}
+
+// handleProtectedDigests lists the protected digests, and with POST pins a
+// digest.
+func (s *Server) handleProtectedDigests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		recent, err := s.recentDigests(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list digests", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, protectedResponse{
+			Keep:   s.cfg.CleanupKeepDigests,
+			Recent: recent,
+			Pinned: s.pinnedDigests(r.Context()),
+		})
+	case http.MethodPost:
+		s.requireWrite(http.HandlerFunc(s.handlePinDigest)).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+func (s *Server) handlePinDigest(w http.ResponseWriter, r *http.Request) {
+	var req pinnedDigest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	if !digestPattern.MatchString(req.Digest) {
+		writeError(w, http.StatusBadRequest, "invalid digest", "Digest must be sha256: followed by 64 hex characters")
+		return
+	}
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "pinning unavailable", "state persistence is disabled")
+		return
+	}
+
+	s.pinnedMu.Lock()
+	defer s.pinnedMu.Unlock()
+	pinned := s.pinnedDigests(r.Context())
+	if slices.ContainsFunc(pinned, func(p pinnedDigest) bool { return p.Digest == req.Digest }) {
+		writeError(w, http.StatusConflict, "digest already pinned", req.Digest)
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	req.PinnedAt = time.Now().UTC()
+	if err := s.savePinnedDigests(r.Context(), append(pinned, req)); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to pin digest", err.Error())
+		return
+	}
+	s.requestLogger(r).Info().Str("digest", req.Digest).Msg("Digest pinned")
+	writeJSON(w, http.StatusCreated, req)
+}
+
+// handleUnpinDigest serves DELETE /api/images/protected/{digest}.
+func (s *Server) handleUnpinDigest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	digest := strings.TrimPrefix(r.URL.Path, "/api/images/protected/")
+	if s.store == nil {
+		writeError(w, http.StatusNotFound, "digest not pinned", digest)
+		return
+	}
+
+	s.pinnedMu.Lock()
+	defer s.pinnedMu.Unlock()
+	pinned := s.pinnedDigests(r.Context())
+	kept := slices.DeleteFunc(slices.Clone(pinned), func(p pinnedDigest) bool { return p.Digest == digest })
+	if len(kept) == len(pinned) {
+		writeError(w, http.StatusNotFound, "digest not pinned", digest)
+		return
+	}
+	if err := s.savePinnedDigests(r.Context(), kept); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to unpin digest", err.Error())
+		return
+	}
+	s.requestLogger(r).Info().Str("digest", digest).Msg("Digest unpinned")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// protectedDigests returns every digest the image cleanup must keep: each
+// service's recent digests and the pinned ones.
+func (s *Server) protectedDigests(ctx context.Context) ([]string, error) {
+	recent, err := s.recentDigests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var digests []string
+	for _, r := range recent {
+		digests = append(digests, r.Digest)
+	}
+	for _, p := range s.pinnedDigests(ctx) {
+		digests = append(digests, p.Digest)
+	}
+	slices.Sort(digests)
+	return slices.Compact(digests), nil
+}
+
+func (s *Server) recentDigests(ctx context.Context) ([]recentDigest, error) {
+	if s.store == nil {
+		return []recentDigest{}, nil
+	}
+	results, err := s.store.ListUpdateHistory(ctx, state.HistoryQuery{
+		ResultCode: state.ResultSuccess,
+		Limit:      maxProtectedHistory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return latestDigests(results, s.cfg.CleanupKeepDigests), nil
+}
+
+// latestDigests returns up to keep distinct digests per service from
+// successful updates, newest first as the store returns them. Each update
+// contributes the digest it applied and then the one it replaced, so the
+// latest rollback target is always among them.
+func latestDigests(results []state.UpdateResult, keep int) []recentDigest {
+	digests := []recentDigest{}
+	seen := make(map[string][]string)
+	for _, result := range results {
+		for _, digest := range []string{result.NewDigest, result.OldDigest} {
+			kept := seen[result.ServiceID]
+			if digest == "" || len(kept) >= keep || slices.Contains(kept, digest) {
+				continue
+			}
+			seen[result.ServiceID] = append(kept, digest)
+			digests = append(digests, recentDigest{
+				TargetID:    result.TargetID,
+				ServiceID:   result.ServiceID,
+				ServiceName: result.ServiceName,
+				Digest:      digest,
+			})
+		}
+	}
+	return digests
+}
+
+// pinnedDigests returns the pinned digests, or none when they were never
+// saved or cannot be read.
+func (s *Server) pinnedDigests(ctx context.Context) []pinnedDigest {
+	pinned := []pinnedDigest{}
+	if s.store == nil {
+		return pinned
+	}
+	raw, err := s.store.GetSetting(ctx, pinnedDigestsKey)
+	if err != nil {
+		return pinned
+	}
+	if err := json.Unmarshal([]byte(raw), &pinned); err != nil {
+		s.logger.Warn().Err(err).Msg("Ignoring unreadable pinned digests")
+		return []pinnedDigest{}
+	}
+	return pinned
+}
+
+func (s *Server) savePinnedDigests(ctx context.Context, pinned []pinnedDigest) error {
+	encoded, err := json.Marshal(pinned)
+	if err != nil {
+		return err
+	}
+	return s.store.SetSetting(ctx, pinnedDigestsKey, string(encoded))
+}
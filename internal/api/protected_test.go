@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestLatestDigests(t *testing.T) {
+	update := func(service, oldDigest, newDigest string) state.UpdateResult {
+		return state.UpdateResult{ServiceID: service, ServiceName: service, OldDigest: oldDigest, NewDigest: newDigest}
+	}
+	// Newest first, as the store returns them.
+	results := []state.UpdateResult{
+		update("web", "sha256:c", "sha256:d"),
+		update("db", "sha256:x", "sha256:y"),
+		update("web", "sha256:b", "sha256:c"),
+		update("web", "sha256:a", "sha256:b"),
+	}
+
+	var web, db []string
+	for _, d := range latestDigests(results, 3) {
+		switch d.ServiceID {
+		case "web":
+			web = append(web, d.Digest)
+		case "db":
+			db = append(db, d.Digest)
+		}
+	}
+	if strings.Join(web, ",") != "sha256:d,sha256:c,sha256:b" {
+		t.Errorf("expected the three latest web digests, got %v", web)
+	}
+	if strings.Join(db, ",") != "sha256:y,sha256:x" {
+		t.Errorf("expected both db digests, got %v", db)
+	}
+}
+
+func TestPinnedDigests(t *testing.T) {
+	s := setupTestServer(t)
+	s.cfg.ReadOnly = false
+	s.cfg.WebToken = "write-token-secret"
+	s.cfg.CleanupKeepDigests = 2
+	h := s.Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer write-token-secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	digest := "sha256:" + strings.Repeat("ab", 32)
+
+	if w := do(http.MethodPost, "/api/images/protected", `{"digest":"sha256:nope"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed digest, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/images/protected", `{"digest":"`+digest+`","note":"known good"}`); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/images/protected", `{"digest":"`+digest+`"}`); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a pinned digest, got %d", w.Code)
+	}
+
+	var resp protectedResponse
+	if err := json.NewDecoder(do(http.MethodGet, "/api/images/protected", "").Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if resp.Keep != 2 || len(resp.Pinned) != 1 || resp.Pinned[0].Note != "known good" {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	protected, err := s.protectedDigests(context.Background())
+	if err != nil || len(protected) != 1 || protected[0] != digest {
+		t.Errorf("expected the pinned digest to be protected, got %v (%v)", protected, err)
+	}
+
+	if w := do(http.MethodDelete, "/api/images/protected/"+digest, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/images/protected/"+digest, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
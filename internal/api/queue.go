@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Run priorities. Higher values run first; equal priorities run in submission order.
+const (
+	priorityScheduled = 0
+	priorityManual    = 10
+)
+
+var errQueueStopped = errors.New("run queue stopped")
+
+type queuedRun struct {
+	runID    string
+	priority int
+	seq      uint64
+	fn       func()
+	done     chan struct{}
+}
+
+// runQueue executes submitted runs on a fixed pool of workers in priority order.
+// Runs wait in the queue until a worker is free and can be cancelled until then.
+type runQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*queuedRun
+	seq     uint64
+	stopped bool
+}
+
+func newRunQueue(workers int) *runQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	q := &runQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	for i := 0; i < workers; i++ {
+		go q.worker()
+	}
+	return q
+}
+
+// enqueue submits fn for runID. It returns the run's 1-based queue position and
+// a channel closed once the run finishes or is cancelled.
+func (q *runQueue) enqueue(runID string, priority int, fn func()) (int, <-chan struct{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		return 0, nil, errQueueStopped
+	}
+
+	q.seq++
+	item := &queuedRun{runID: runID, priority: priority, seq: q.seq, fn: fn, done: make(chan struct{})}
+	q.pending = append(q.pending, item)
+	q.sortLocked()
+	q.cond.Signal()
+	return q.positionLocked(runID), item.done, nil
+}
+
+// cancel removes a run that has not started yet.
+func (q *runQueue) cancel(runID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, item := range q.pending {
+		if item.runID == runID {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			close(item.done)
+			return true
+		}
+	}
+	return false
+}
+
+// position returns the 1-based queue position of runID, or 0 if it is not queued.
+func (q *runQueue) position(runID string) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.positionLocked(runID)
+}
+
+// stop stops accepting runs and returns the IDs of runs that never started.
+// Runs already executing are left to finish.
+func (q *runQueue) stop() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.stopped = true
+	ids := make([]string, 0, len(q.pending))
+	for _, item := range q.pending {
+		ids = append(ids, item.runID)
+		close(item.done)
+	}
+	q.pending = nil
+	q.cond.Broadcast()
+	return ids
+}
+
+func (q *runQueue) worker() {
+	for {
+		q.mu.Lock()
+		for len(q.pending) == 0 && !q.stopped {
+			q.cond.Wait()
+		}
+		if q.stopped {
+			q.mu.Unlock()
+			return
+		}
+		item := q.pending[0]
+		q.pending = q.pending[1:]
+		q.mu.Unlock()
+
+		item.fn()
+		close(item.done)
+	}
+}
+
+func (q *runQueue) sortLocked() {
+	sort.SliceStable(q.pending, func(i, j int) bool {
+		if q.pending[i].priority != q.pending[j].priority {
+			return q.pending[i].priority > q.pending[j].priority
+		}
+		return q.pending[i].seq < q.pending[j].seq
+	})
+}
+
+func (q *runQueue) positionLocked(runID string) int {
+	for i, item := range q.pending {
+		if item.runID == runID {
+			return i + 1
+		}
+	}
+	return 0
+}
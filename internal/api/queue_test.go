@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRunQueue_PriorityOrder(t *testing.T) {
+	q := newRunQueue(1)
+	defer q.stop()
+
+	release := make(chan struct{})
+	_, blockerDone, _ := q.enqueue("blocker", priorityManual, func() { <-release })
+
+	order := make(chan string, 3)
+	q.enqueue("scheduled", priorityScheduled, func() { order <- "scheduled" })
+	q.enqueue("manual-1", priorityManual, func() { order <- "manual-1" })
+	_, lastDone, _ := q.enqueue("manual-2", priorityManual, func() { order <- "manual-2" })
+
+	// The blocker may still be pending if the worker has not picked it up yet.
+	if pos := q.position("scheduled"); pos < 3 {
+		t.Errorf("expected scheduled run behind manual runs, got position %d", pos)
+	}
+
+	close(release)
+	<-blockerDone
+	<-lastDone
+
+	want := []string{"manual-1", "manual-2", "scheduled"}
+	for i, name := range want {
+		select {
+		case got := <-order:
+			if got != name {
+				t.Fatalf("run %d: expected %s, got %s", i, name, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("run %d: timed out waiting for %s", i, name)
+		}
+	}
+}
+
+func TestRunQueue_Cancel(t *testing.T) {
+	q := newRunQueue(1)
+	defer q.stop()
+
+	release := make(chan struct{})
+	defer close(release)
+	q.enqueue("blocker", priorityManual, func() { <-release })
+
+	ran := false
+	_, done, _ := q.enqueue("victim", priorityScheduled, func() { ran = true })
+	if !q.cancel("victim") {
+		t.Fatal("expected queued run to be cancellable")
+	}
+	<-done
+	if ran {
+		t.Error("cancelled run should not execute")
+	}
+	if q.cancel("victim") {
+		t.Error("expected second cancel to fail")
+	}
+}
+
+func TestHandleRuns_FilterQueued(t *testing.T) {
+	s := testServer()
+	s.runs.CreateRun("apply")
+	queued := s.runs.CreateQueuedRun("apply", RunNotes{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/runs?status=queued", nil)
+	w := httptest.NewRecorder()
+	s.handleRuns(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp runsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if len(resp.Runs) != 1 || resp.Runs[0].ID != queued.ID {
+		t.Errorf("expected only the queued run, got %+v", resp.Runs)
+	}
+}
+
+func TestHandleRunCancel_StartedRunConflicts(t *testing.T) {
+	s := testServer()
+	run := s.runs.CreateRun("apply")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/runs/"+run.ID+"/cancel", nil)
+	w := httptest.NewRecorder()
+	s.handleRunCancel(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409, got %d", w.Code)
+	}
+}
+
+func TestHandleRunCancel_CancelsRunningRun(t *testing.T) {
+	s := testServer()
+	run := s.runs.CreateRun("apply")
+	ctx, done := s.runs.RunContext(s.baseContext(), run.ID)
+	defer done()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/runs/"+run.ID+"/cancel", nil)
+	w := httptest.NewRecorder()
+	s.handleRunCancel(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", w.Code)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the run context to be cancelled")
+	}
+}
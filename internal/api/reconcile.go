@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// orphanedTarget is a target in the state database that discovery no
+// longer finds, e.g. because its stack was removed, renamed or moved.
+type orphanedTarget struct {
+	ID         string           `json:"id"`
+	Type       state.TargetType `json:"type"`
+	Name       string           `json:"name"`
+	Path       string           `json:"path"`
+	LastSeen   time.Time        `json:"last_seen"`
+	Services   []string         `json:"services"`
+	HasHistory bool             `json:"has_history"`
+	// RelinkTo is a discovered target that appeared after this one was last
+	// seen and shares its services, most likely where it went.
+	RelinkTo string `json:"relink_to,omitempty"`
+}
+
+// orphanedService is a service in the state database that its discovered
+// target no longer has.
+type orphanedService struct {
+	ID         string    `json:"id"`
+	TargetID   string    `json:"target_id"`
+	TargetName string    `json:"target_name"`
+	Name       string    `json:"name"`
+	LastSeen   time.Time `json:"last_seen"`
+	HasHistory bool      `json:"has_history"`
+}
+
+// untrackedTarget is a discovered target missing from the state database,
+// which happens when saving it failed.
+type untrackedTarget struct {
+	ID   string           `json:"id"`
+	Type state.TargetType `json:"type"`
+	Name string           `json:"name"`
+	Path string           `json:"path"`
+}
+
+// reconciliation compares the state database with what discovery finds.
+type reconciliation struct {
+	CheckedAt        time.Time         `json:"checked_at"`
+	OrphanedTargets  []orphanedTarget  `json:"orphaned_targets"`
+	OrphanedServices []orphanedService `json:"orphaned_services"`
+	Untracked        []untrackedTarget `json:"untracked"`
+}
+
+// reconcileTargets lists the stored targets and services missing from the
+// discovered ones, and the discovered targets missing from the stored ones.
+func reconcileTargets(discovered, stored []state.Target) reconciliation {
+	result := reconciliation{
+		OrphanedTargets:  []orphanedTarget{},
+		OrphanedServices: []orphanedService{},
+		Untracked:        []untrackedTarget{},
+	}
+	found := make(map[string]state.Target, len(discovered))
+	for _, target := range discovered {
+		found[target.ID] = target
+	}
+	kept := make(map[string]state.Target, len(stored))
+	for _, target := range stored {
+		kept[target.ID] = target
+	}
+
+	for _, target := range stored {
+		current, ok := found[target.ID]
+		if !ok {
+			orphan := orphanedTarget{
+				ID:       target.ID,
+				Type:     target.Type,
+				Name:     target.Name,
+				Path:     target.Path,
+				LastSeen: target.UpdatedAt,
+				Services: serviceNames(target),
+				RelinkTo: relinkCandidate(target, discovered, kept),
+			}
+			result.OrphanedTargets = append(result.OrphanedTargets, orphan)
+			continue
+		}
+		names := make(map[string]bool, len(current.Services))
+		for _, service := range current.Services {
+			names[service.Name] = true
+		}
+		for _, service := range target.Services {
+			if !names[service.Name] {
+				result.OrphanedServices = append(result.OrphanedServices, orphanedService{
+					ID:         service.ID,
+					TargetID:   target.ID,
+					TargetName: target.Name,
+					Name:       service.Name,
+					LastSeen:   service.UpdatedAt,
+				})
+			}
+		}
+	}
+	for _, target := range discovered {
+		if _, ok := kept[target.ID]; !ok {
+			result.Untracked = append(result.Untracked, untrackedTarget{ID: target.ID, Type: target.Type, Name: target.Name, Path: target.Path})
+		}
+	}
+
+	sort.Slice(result.OrphanedTargets, func(i, j int) bool { return result.OrphanedTargets[i].Name < result.OrphanedTargets[j].Name })
+	sort.Slice(result.OrphanedServices, func(i, j int) bool {
+		a, b := result.OrphanedServices[i], result.OrphanedServices[j]
+		if a.TargetName != b.TargetName {
+			return a.TargetName < b.TargetName
+		}
+		return a.Name < b.Name
+	})
+	sort.Slice(result.Untracked, func(i, j int) bool { return result.Untracked[i].Name < result.Untracked[j].Name })
+	return result
+}
+
+// relinkCandidate returns the discovered target of the same type, first
+// stored after orphan was last seen, that shares the most service names with
+// it, or "" when none shares any.
+func relinkCandidate(orphan state.Target, discovered []state.Target, stored map[string]state.Target) string {
+	orphanServices := make(map[string]bool, len(orphan.Services))
+	for _, service := range orphan.Services {
+		orphanServices[service.Name] = true
+	}
+	best, bestShared := "", 0
+	for _, target := range discovered {
+		if target.Type != orphan.Type {
+			continue
+		}
+		if record, ok := stored[target.ID]; ok && record.CreatedAt.Before(orphan.UpdatedAt) {
+			continue
+		}
+		shared := 0
+		for _, service := range target.Services {
+			if orphanServices[service.Name] {
+				shared++
+			}
+		}
+		if shared > bestShared || (shared == bestShared && shared > 0 && target.Name == orphan.Name) {
+			best, bestShared = target.ID, shared
+		}
+	}
+	return best
+}
+
+func serviceNames(target state.Target) []string {
+	names := make([]string, 0, len(target.Services))
+	for _, service := range target.Services {
+		names = append(names, service.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// reconcile runs discovery and compares its targets with the state database.
+func (s *Server) reconcile(ctx context.Context) (*reconciliation, error) {
+	discovered, err := s.discoverTargets(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.store.ListTargets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := reconcileTargets(discovered, stored)
+	result.CheckedAt = time.Now().UTC()
+
+	hasHistory := func(query state.HistoryQuery) bool {
+		query.Limit = 1
+		history, err := s.store.ListUpdateHistory(ctx, query)
+		return err == nil && len(history) > 0
+	}
+	for i := range result.OrphanedTargets {
+		result.OrphanedTargets[i].HasHistory = hasHistory(state.HistoryQuery{TargetID: result.OrphanedTargets[i].ID})
+	}
+	for i := range result.OrphanedServices {
+		result.OrphanedServices[i].HasHistory = hasHistory(state.HistoryQuery{ServiceID: result.OrphanedServices[i].ID})
+	}
+	return &result, nil
+}
+
+// reconcileLoop flags orphaned targets and services every interval. It only
+// reports them; cleaning up is left to the operator.
+func (s *Server) reconcileLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		result, err := s.reconcile(ctx)
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("Reconciliation failed")
+			continue
+		}
+		for _, orphan := range result.OrphanedTargets {
+			event := s.logger.Warn().Str("target_id", orphan.ID).Str("target", orphan.Name).Time("last_seen", orphan.LastSeen).Bool("has_history", orphan.HasHistory)
+			if orphan.RelinkTo != "" {
+				event = event.Str("relink_to", orphan.RelinkTo)
+			}
+			event.Msg("Target is no longer discovered")
+		}
+		for _, orphan := range result.OrphanedServices {
+			s.logger.Warn().Str("service_id", orphan.ID).Str("target", orphan.TargetName).Str("service", orphan.Name).Msg("Service is no longer discovered")
+		}
+	}
+}
+
+// handleOrphanedTargets serves GET /api/targets?state=orphaned.
+func (s *Server) handleOrphanedTargets(w http.ResponseWriter, r *http.Request) {
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "reconciliation unavailable", "state persistence is disabled")
+		return
+	}
+	result, err := s.reconcile(r.Context())
+	if err != nil {
+		writeError(w, statusForError(err), "reconciliation failed", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+type relinkRequest struct {
+	TargetID string            `json:"target_id"`
+	Services map[string]string `json:"services,omitempty"` // Old service name -> new
+}
+
+// handleTargetCleanup serves the actions on orphans:
+//
+//	DELETE /api/targets/{id}                      delete an orphaned target and its history
+//	DELETE /api/targets/{id}/services/{serviceID} delete an orphaned service and its history
+//	POST   /api/targets/{id}/relink               merge an orphaned target into another
+//
+// Targets and services discovery still finds are refused.
+func (s *Server) handleTargetCleanup(w http.ResponseWriter, r *http.Request, id, rest string) {
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "reconciliation unavailable", "state persistence is disabled")
+		return
+	}
+	ctx := r.Context()
+	result, err := s.reconcile(ctx)
+	if err != nil {
+		writeError(w, statusForError(err), "reconciliation failed", err.Error())
+		return
+	}
+	orphaned := func() bool {
+		for _, orphan := range result.OrphanedTargets {
+			if orphan.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	serviceID, isService := strings.CutPrefix(rest, "services/")
+	switch {
+	case rest == "" && r.Method == http.MethodDelete:
+		if !orphaned() {
+			writeError(w, http.StatusConflict, "target is not orphaned", "discovery still finds this target")
+			return
+		}
+		if err := s.store.DeleteTarget(ctx, id); err != nil {
+			writeError(w, statusForError(err), "failed to delete target", err.Error())
+			return
+		}
+		s.requestLogger(r).Info().Str("target_id", id).Str("actor", s.actor(r)).Msg("Orphaned target deleted")
+		w.WriteHeader(http.StatusNoContent)
+
+	case rest == "relink" && r.Method == http.MethodPost:
+		var req relinkRequest
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+			return
+		}
+		if req.TargetID == "" {
+			writeError(w, http.StatusBadRequest, "missing target_id", "")
+			return
+		}
+		if !orphaned() {
+			writeError(w, http.StatusConflict, "target is not orphaned", "discovery still finds this target")
+			return
+		}
+		moved, err := s.store.RelinkTarget(ctx, id, req.TargetID, req.Services)
+		if err != nil {
+			status := http.StatusBadRequest
+			if errors.Is(err, state.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			writeError(w, status, "relink failed", err.Error())
+			return
+		}
+		s.requestLogger(r).Info().Str("target_id", id).Str("relinked_to", req.TargetID).Int("history_moved", moved).Str("actor", s.actor(r)).Msg("Orphaned target relinked")
+		writeJSON(w, http.StatusOK, map[string]interface{}{"target_id": req.TargetID, "history_moved": moved})
+
+	case isService && serviceID != "" && r.Method == http.MethodDelete:
+		found := false
+		for _, orphan := range result.OrphanedServices {
+			if orphan.ID == serviceID && orphan.TargetID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			writeError(w, http.StatusConflict, "service is not orphaned", "discovery still finds this service, or it belongs to another target")
+			return
+		}
+		if err := s.store.DeleteService(ctx, serviceID); err != nil {
+			writeError(w, statusForError(err), "failed to delete service", err.Error())
+			return
+		}
+		s.requestLogger(r).Info().Str("service_id", serviceID).Str("actor", s.actor(r)).Msg("Orphaned service deleted")
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
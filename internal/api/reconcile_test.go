@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestReconcileTargets(t *testing.T) {
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	yesterday := time.Now().Add(-24 * time.Hour)
+	services := func(names ...string) []state.Service {
+		var out []state.Service
+		for _, name := range names {
+			out = append(out, state.Service{ID: name + "-id", Name: name, UpdatedAt: lastWeek})
+		}
+		return out
+	}
+
+	stored := []state.Target{
+		{ID: "old-media", Type: state.TargetTypeCompose, Name: "media", CreatedAt: lastWeek, UpdatedAt: lastWeek, Services: services("plex", "sonarr")},
+		{ID: "apps", Type: state.TargetTypeCompose, Name: "apps", CreatedAt: lastWeek, UpdatedAt: time.Now(), Services: services("web", "worker")},
+		{ID: "media-stack", Type: state.TargetTypeCompose, Name: "media-stack", CreatedAt: yesterday, UpdatedAt: time.Now(), Services: services("plex", "sonarr")},
+	}
+	discovered := []state.Target{
+		{ID: "apps", Type: state.TargetTypeCompose, Name: "apps", Services: services("web")},
+		{ID: "media-stack", Type: state.TargetTypeCompose, Name: "media-stack", Services: services("plex", "sonarr")},
+		{ID: "new", Type: state.TargetTypeCompose, Name: "new", Services: services("db")},
+	}
+
+	result := reconcileTargets(discovered, stored)
+	if len(result.OrphanedTargets) != 1 || result.OrphanedTargets[0].ID != "old-media" {
+		t.Fatalf("orphaned targets = %+v, want old-media", result.OrphanedTargets)
+	}
+	if got := result.OrphanedTargets[0].RelinkTo; got != "media-stack" {
+		t.Errorf("relink_to = %q, want media-stack", got)
+	}
+	if len(result.OrphanedServices) != 1 || result.OrphanedServices[0].Name != "worker" || result.OrphanedServices[0].TargetID != "apps" {
+		t.Errorf("orphaned services = %+v, want apps/worker", result.OrphanedServices)
+	}
+	if len(result.Untracked) != 1 || result.Untracked[0].ID != "new" {
+		t.Errorf("untracked = %+v, want new", result.Untracked)
+	}
+}
+
+func TestRelinkCandidateSkipsOlderTargets(t *testing.T) {
+	lastWeek := time.Now().Add(-7 * 24 * time.Hour)
+	orphan := state.Target{ID: "old", Type: state.TargetTypeCompose, UpdatedAt: time.Now().Add(-time.Hour), Services: []state.Service{{Name: "web"}}}
+	other := state.Target{ID: "other", Type: state.TargetTypeCompose, CreatedAt: lastWeek, Services: []state.Service{{Name: "web"}}}
+	stored := map[string]state.Target{"other": other}
+	if got := relinkCandidate(orphan, []state.Target{other}, stored); got != "" {
+		t.Errorf("relinkCandidate = %q, want none for a target that existed alongside the orphan", got)
+	}
+}
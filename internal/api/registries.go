@@ -0,0 +1,101 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/registry"
+)
+
+type registriesResponse struct {
+	Registries []registry.Endpoint `json:"registries"`
+}
+
+type reposResponse struct {
+	Registry     string   `json:"registry"`
+	Repositories []string `json:"repositories"`
+}
+
+type tagsResponse struct {
+	Registry   string   `json:"registry"`
+	Repository string   `json:"repository"`
+	Tags       []string `json:"tags"`
+}
+
+// registryEndpoint returns the configured registry called name.
+func (s *Server) registryEndpoint(name string) (registry.Endpoint, bool) {
+	for _, endpoint := range s.cfg.Registries {
+		if endpoint.Name == name {
+			return endpoint, true
+		}
+	}
+	return registry.Endpoint{}, false
+}
+
+func (s *Server) handleRegistries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	registries := s.cfg.Registries
+	if registries == nil {
+		registries = []registry.Endpoint{}
+	}
+	writeJSON(w, http.StatusOK, registriesResponse{Registries: registries})
+}
+
+// handleRegistry routes /api/registries/{name}/repos and
+// /api/registries/{name}/tags?repo=, which browse a configured registry's
+// catalog.
+func (s *Server) handleRegistry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	name, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/registries/"), "/")
+	endpoint, ok := s.registryEndpoint(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "registry not configured", name)
+		return
+	}
+
+	switch action {
+	case "repos":
+		repos, err := s.registry.ListRepositories(r.Context(), endpoint)
+		if err != nil {
+			writeError(w, registryStatus(err), "failed to list repositories", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, reposResponse{Registry: name, Repositories: nonNil(repos)})
+	case "tags":
+		repo := strings.Trim(strings.TrimSpace(r.URL.Query().Get("repo")), "/")
+		if repo == "" {
+			writeError(w, http.StatusBadRequest, "missing repo", "use ?repo=<repository>")
+			return
+		}
+		tags, err := s.registry.ListTags(r.Context(), endpoint, repo)
+		if err != nil {
+			writeError(w, registryStatus(err), "failed to list tags", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, tagsResponse{Registry: name, Repository: repo, Tags: nonNil(tags)})
+	default:
+		writeError(w, http.StatusNotFound, "not found", r.URL.Path)
+	}
+}
+
+// registryStatus reports a registry's rejection as a bad gateway rather than
+// an internal error.
+func registryStatus(err error) int {
+	if status := statusForError(err); status != http.StatusInternalServerError {
+		return status
+	}
+	return http.StatusBadGateway
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/registry"
+)
+
+func TestHandleRegistry(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/_catalog":
+			_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": {"org/app"}})
+		case "/v2/org/app/tags/list":
+			_ = json.NewEncoder(w).Encode(map[string][]string{"tags": {"1.0", "2.0"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	s := testServer()
+	s.logger = logging.Default()
+	s.registry = registry.NewClient(s.logger)
+	s.cfg.Registries = []registry.Endpoint{{Name: "home", URL: upstream.URL}}
+	handler := s.Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/api/registries/home/repos")
+	var repos reposResponse
+	if err := json.NewDecoder(w.Body).Decode(&repos); err != nil || !reflect.DeepEqual(repos.Repositories, []string{"org/app"}) {
+		t.Fatalf("unexpected repos %d %+v (%v)", w.Code, repos, err)
+	}
+
+	w = get("/api/registries/home/tags?repo=org/app")
+	var tags tagsResponse
+	if err := json.NewDecoder(w.Body).Decode(&tags); err != nil || !reflect.DeepEqual(tags.Tags, []string{"1.0", "2.0"}) {
+		t.Fatalf("unexpected tags %d %+v (%v)", w.Code, tags, err)
+	}
+
+	if w := get("/api/registries/home/tags"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without repo, got %d", w.Code)
+	}
+	if w := get("/api/registries/home/tags?repo=missing"); w.Code != http.StatusBadGateway {
+		t.Errorf("expected 502 for an unknown repository, got %d", w.Code)
+	}
+	if w := get("/api/registries/other/repos"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unconfigured registry, got %d", w.Code)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+)
+
+// RequestIDHeader carries the ID of a request. A proxy in front of Bulwark
+// may set it on the request; otherwise the server picks one. Either way
+// the response carries it, as do error responses and the request's logs.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits an incoming ID to what is safe to echo and log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// requestIDMiddleware assigns every request an ID, keeping one a proxy
+// sent. The ID is set on the response header before the handler runs, so
+// writeError can put it into the error body.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestID returns the ID of the request ctx belongs to, or "" outside
+// a request.
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestLogger returns the server's logger tagged with r's request ID.
+func (s *Server) requestLogger(r *http.Request) *logging.Logger {
+	id := requestID(r.Context())
+	if id == "" {
+		return s.logger
+	}
+	return s.logger.WithRequestID(id)
+}
+
+func newRequestID() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorEnvelopeCarriesRequestID(t *testing.T) {
+	s := setupTestServer(t)
+	h := s.Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/runs/missing", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	id := w.Header().Get(RequestIDHeader)
+	if len(id) != 24 {
+		t.Fatalf("expected a generated request ID, got %q", id)
+	}
+	var resp apiError
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if resp.Code != "not_found" || resp.Message == "" || resp.Error != resp.Message || resp.RequestID != id {
+		t.Errorf("expected a not_found envelope with the request ID %s, got %+v", id, resp)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/health", nil)
+	req.Header.Set(RequestIDHeader, "proxy-7f3a")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if w.Header().Get(RequestIDHeader) != "proxy-7f3a" || resp.RequestID != "proxy-7f3a" || resp.Code != "method_not_allowed" {
+		t.Errorf("expected the proxy's request ID to be kept, got %q %+v", w.Header().Get(RequestIDHeader), resp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+	req.Header.Set(RequestIDHeader, "bad id\nwith newline")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if got := w.Header().Get(RequestIDHeader); got == "" || got == "bad id\nwith newline" {
+		t.Errorf("expected an unsafe request ID to be replaced, got %q", got)
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	for status, want := range map[int]string{
+		http.StatusBadRequest:          "bad_request",
+		http.StatusTooManyRequests:     "too_many_requests",
+		http.StatusInternalServerError: "internal_server_error",
+		http.StatusMultiStatus:         "multi_status",
+		599:                            "error",
+	} {
+		if got := errorCode(status); got != want {
+			t.Errorf("errorCode(%d) = %q, want %q", status, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// verifyRollback answers a dry-run rollback with what
+// executor.VerifyRollback found, adding a check that no run is applying
+// updates at the same time.
+func (s *Server) verifyRollback(w http.ResponseWriter, r *http.Request, target *state.Target, service *state.Service, last *state.UpdateResult) {
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create Docker client", err.Error())
+		return
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	exec := executor.NewExecutor(dockerClient, policy.NewEngine(s.logger), s.store, s.logger, true).WithLockManager(s.locks)
+	var manifests executor.ManifestChecker
+	if s.registry != nil {
+		manifests = s.registry
+	}
+	report := exec.VerifyRollback(r.Context(), target, service, last, manifests)
+
+	var active []string
+	for _, run := range s.runs.List("running") {
+		active = append(active, run.ID)
+	}
+	if len(active) > 0 {
+		report.Check("runs", false, fmt.Sprintf("Run %s is applying updates", strings.Join(active, ", ")))
+	} else {
+		report.Check("runs", true, "No run is applying updates")
+	}
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxRunNoteLength = 2000
+	maxRunLabels     = 20
+	maxRunLabelValue = 256
+)
+
+// runLabelKeyPattern is the grammar for run label keys, e.g. "reason" or
+// "change.ticket".
+var runLabelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,62}$`)
+
+// RunNotes documents a run with a free-text note and key/value labels, set
+// with the apply request or afterwards with PATCH /api/runs/{id}.
+type RunNotes struct {
+	Note   string            `json:"note,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// normalize trims the note and labels and checks them against the limits.
+func (n *RunNotes) normalize() error {
+	n.Note = strings.TrimSpace(n.Note)
+	if utf8.RuneCountInString(n.Note) > maxRunNoteLength {
+		return fmt.Errorf("note exceeds %d characters", maxRunNoteLength)
+	}
+	if len(n.Labels) > maxRunLabels {
+		return fmt.Errorf("at most %d labels are allowed", maxRunLabels)
+	}
+	if len(n.Labels) == 0 {
+		n.Labels = nil
+		return nil
+	}
+	labels := make(map[string]string, len(n.Labels))
+	for key, value := range n.Labels {
+		key = strings.TrimSpace(key)
+		if !runLabelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q", key)
+		}
+		value = strings.TrimSpace(value)
+		if utf8.RuneCountInString(value) > maxRunLabelValue {
+			return fmt.Errorf("label %s exceeds %d characters", key, maxRunLabelValue)
+		}
+		labels[key] = value
+	}
+	n.Labels = labels
+	return nil
+}
+
+// runNotesPatch is the body of PATCH /api/runs/{id}. Fields left out keep
+// their value; labels, when given, replace all labels of the run.
+type runNotesPatch struct {
+	Note   *string            `json:"note"`
+	Labels *map[string]string `json:"labels"`
+}
+
+// handleRunNotes serves PATCH /api/runs/{id}, which changes the note and
+// labels of a run, finished or not.
+func (s *Server) handleRunNotes(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/api/runs/")
+	if id == "" || strings.Contains(id, "/") {
+		writeError(w, http.StatusNotFound, "run not found", "")
+		return
+	}
+	var patch runNotesPatch
+	if err := decodeJSON(r, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	run, ok := s.runs.Get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "run not found", "")
+		return
+	}
+	notes := run.RunNotes
+	if patch.Note != nil {
+		notes.Note = *patch.Note
+	}
+	if patch.Labels != nil {
+		notes.Labels = *patch.Labels
+	}
+	if err := notes.normalize(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid run notes", err.Error())
+		return
+	}
+	if !s.runs.SetNotes(id, notes) {
+		writeError(w, http.StatusNotFound, "run not found", "")
+		return
+	}
+	s.requestLogger(r).Info().Str("run_id", id).Str("actor", s.actor(r)).Msg("Run notes updated")
+
+	run.RunNotes = notes
+	run.Events = []RunEvent{}
+	writeJSON(w, http.StatusOK, run)
+}
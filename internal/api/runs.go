@@ -8,6 +8,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/redact"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
 
@@ -18,6 +20,7 @@ type RunEvent struct {
 	Target    string                 `json:"target,omitempty"`
 	Service   string                 `json:"service,omitempty"`
 	Step      string                 `json:"step,omitempty"`
+	Phase     string                 `json:"phase,omitempty"` // Apply phase: pull or switch
 	Message   string                 `json:"message"`
 	Data      map[string]interface{} `json:"data,omitempty"`
 }
@@ -28,6 +31,72 @@ type RunSummary struct {
 	UpdatesSkipped int `json:"updates_skipped"`
 	UpdatesFailed  int `json:"updates_failed"`
 	Rollbacks      int `json:"rollbacks"`
+	// ImagesPulled counts images fetched in the pull phase ahead of recreating.
+	ImagesPulled int `json:"images_pulled,omitempty"`
+	// DependentsFailed counts dependent services whose probes failed after a
+	// target they depend on was updated.
+	DependentsFailed int `json:"dependents_failed,omitempty"`
+	// Targets lists the outcome of every service the run considered, grouped
+	// by target in the order they were handled.
+	Targets []TargetOutcome `json:"targets,omitempty"`
+}
+
+// Service outcomes recorded in a run summary.
+const (
+	outcomeApplied    = "applied"
+	outcomeSkipped    = "skipped"
+	outcomeFailed     = "failed"
+	outcomeRolledBack = "rolled_back"
+)
+
+// TargetOutcome is what a run did to the services of one target.
+type TargetOutcome struct {
+	Target   string           `json:"target"`
+	Services []ServiceOutcome `json:"services"`
+}
+
+// ServiceOutcome is the result of one service in a run.
+type ServiceOutcome struct {
+	Service     string    `json:"service"`
+	Image       string    `json:"image,omitempty"`
+	Outcome     string    `json:"outcome"`
+	Reason      string    `json:"reason,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// record adds the outcome of item. Counters are kept by the caller.
+func (s *RunSummary) record(item planner.PlanItem, outcome, reason string, started, completed time.Time) {
+	entry := ServiceOutcome{
+		Service:     item.ServiceName,
+		Image:       item.Image,
+		Outcome:     outcome,
+		Reason:      reason,
+		CompletedAt: completed.UTC(),
+	}
+	if !started.IsZero() && completed.After(started) {
+		entry.DurationMs = completed.Sub(started).Milliseconds()
+	}
+	for i := range s.Targets {
+		if s.Targets[i].Target == item.TargetName {
+			s.Targets[i].Services = append(s.Targets[i].Services, entry)
+			return
+		}
+	}
+	s.Targets = append(s.Targets, TargetOutcome{Target: item.TargetName, Services: []ServiceOutcome{entry}})
+}
+
+// clone returns a copy of s that shares no slices with it.
+func (s RunSummary) clone() RunSummary {
+	if s.Targets == nil {
+		return s
+	}
+	targets := make([]TargetOutcome, len(s.Targets))
+	for i, target := range s.Targets {
+		targets[i] = TargetOutcome{Target: target.Target, Services: append([]ServiceOutcome(nil), target.Services...)}
+	}
+	s.Targets = targets
+	return s
 }
 
 // Run represents an apply or plan run.
@@ -38,8 +107,12 @@ type Run struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	StartedAt   time.Time  `json:"started_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	Summary     RunSummary `json:"summary"`
-	Events      []RunEvent `json:"events"`
+	// QueuePosition is the 1-based position of a queued run; zero once started.
+	QueuePosition int        `json:"queue_position,omitempty"`
+	Phase         string     `json:"phase,omitempty"` // Current apply phase, stamped on new events
+	Summary       RunSummary `json:"summary"`
+	Events        []RunEvent `json:"events"`
+	RunNotes
 }
 
 // RunManager stores recent runs in memory with optional SQLite write-through.
@@ -51,11 +124,13 @@ type RunManager struct {
 	maxEvents    int
 	recentEvents []RunEvent
 	maxRecent    int
-	store        state.Store
+	store        state.RunStore
+	// cancels holds the cancel function of every run currently executing.
+	cancels map[string]context.CancelFunc
 }
 
 // NewRunManager creates a run manager with optional store for persistence.
-func NewRunManager(maxRuns, maxEvents, maxRecent int, store state.Store) *RunManager {
+func NewRunManager(maxRuns, maxEvents, maxRecent int, store state.RunStore) *RunManager {
 	rm := &RunManager{
 		runs:         make(map[string]*Run),
 		order:        make([]string, 0, maxRuns),
@@ -64,6 +139,7 @@ func NewRunManager(maxRuns, maxEvents, maxRecent int, store state.Store) *RunMan
 		recentEvents: make([]RunEvent, 0, maxRecent),
 		maxRecent:    maxRecent,
 		store:        store,
+		cancels:      make(map[string]context.CancelFunc),
 	}
 
 	// Load recent runs from store on startup
@@ -81,6 +157,7 @@ func NewRunManager(maxRuns, maxEvents, maxRecent int, store state.Store) *RunMan
 					StartedAt:   r.StartedAt,
 					CompletedAt: r.CompletedAt,
 					Events:      []RunEvent{},
+					RunNotes:    RunNotes{Note: r.Note, Labels: r.Labels},
 				}
 				if r.SummaryJSON != "" {
 					_ = json.Unmarshal([]byte(r.SummaryJSON), &apiRun.Summary)
@@ -94,6 +171,7 @@ func NewRunManager(maxRuns, maxEvents, maxRecent int, store state.Store) *RunMan
 							Target:    e.Target,
 							Service:   e.Service,
 							Step:      e.Step,
+							Phase:     e.Phase,
 							Message:   e.Message,
 						}
 						if e.DataJSON != "" {
@@ -113,14 +191,120 @@ func NewRunManager(maxRuns, maxEvents, maxRecent int, store state.Store) *RunMan
 
 // CreateRun creates a new run entry.
 func (m *RunManager) CreateRun(mode string) *Run {
-	run := &Run{
+	now := time.Now()
+	return m.addRun(&Run{
 		ID:        newRunID(),
 		Mode:      mode,
 		Status:    "running",
+		CreatedAt: now,
+		StartedAt: now,
+		Events:    []RunEvent{},
+	})
+}
+
+// CreateQueuedRun creates a run that waits in the run queue until MarkStarted.
+func (m *RunManager) CreateQueuedRun(mode string, notes RunNotes) *Run {
+	return m.addRun(&Run{
+		ID:        newRunID(),
+		Mode:      mode,
+		Status:    "queued",
 		CreatedAt: time.Now(),
-		StartedAt: time.Now(),
 		Events:    []RunEvent{},
+		RunNotes:  notes,
+	})
+}
+
+// MarkStarted moves a queued run to running.
+func (m *RunManager) MarkStarted(runID string) {
+	m.mu.Lock()
+	run, ok := m.runs[runID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	run.Status = "running"
+	run.StartedAt = time.Now()
+	storedRun := state.Run{
+		ID:        run.ID,
+		Mode:      run.Mode,
+		Status:    run.Status,
+		CreatedAt: run.CreatedAt,
+		StartedAt: run.StartedAt,
+		Note:      run.Note,
+		Labels:    run.Labels,
+	}
+	m.mu.Unlock()
+
+	if m.store != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = m.store.SaveRun(ctx, &storedRun)
+	}
+}
+
+// redactData masks secrets in the string values of event data.
+func redactData(data map[string]interface{}) map[string]interface{} {
+	if len(data) == 0 {
+		return data
+	}
+	redacted := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			value = redact.String(s)
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// RunContext derives the context a run executes with from parent and
+// registers it so CancelRunning can stop the run. The returned function must
+// be called once the run ends.
+func (m *RunManager) RunContext(parent context.Context, runID string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	m.mu.Lock()
+	m.cancels[runID] = cancel
+	m.mu.Unlock()
+	return ctx, func() {
+		m.mu.Lock()
+		delete(m.cancels, runID)
+		m.mu.Unlock()
+		cancel()
+	}
+}
+
+// CancelRunning cancels the context of an executing run. It reports false
+// when the run is not executing.
+func (m *RunManager) CancelRunning(runID string) bool {
+	m.mu.Lock()
+	cancel, ok := m.cancels[runID]
+	m.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// List returns in-memory runs newest first, optionally filtered by status.
+// Events are omitted; fetch a single run for its event log.
+func (m *RunManager) List(status string) []Run {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	runs := make([]Run, 0, len(m.order))
+	for i := len(m.order) - 1; i >= 0; i-- {
+		run, ok := m.runs[m.order[i]]
+		if !ok || (status != "" && run.Status != status) {
+			continue
+		}
+		clone := *run
+		clone.Events = []RunEvent{}
+		runs = append(runs, clone)
 	}
+	return runs
+}
+
+func (m *RunManager) addRun(run *Run) *Run {
 
 	m.mu.Lock()
 	m.runs[run.ID] = run
@@ -142,6 +326,8 @@ func (m *RunManager) CreateRun(mode string) *Run {
 			Status:    run.Status,
 			CreatedAt: run.CreatedAt,
 			StartedAt: run.StartedAt,
+			Note:      run.Note,
+			Labels:    run.Labels,
 		})
 	}
 
@@ -156,6 +342,11 @@ func (m *RunManager) AddEvent(runID string, event RunEvent) {
 		m.mu.Unlock()
 		return
 	}
+	if event.Phase == "" {
+		event.Phase = run.Phase
+	}
+	event.Message = redact.String(event.Message)
+	event.Data = redactData(event.Data)
 	if event.Timestamp.IsZero() {
 		event.Timestamp = time.Now().UTC()
 	} else {
@@ -189,19 +380,32 @@ func (m *RunManager) AddEvent(runID string, event RunEvent) {
 			Target:    event.Target,
 			Service:   event.Service,
 			Step:      event.Step,
+			Phase:     event.Phase,
 			Message:   event.Message,
 			DataJSON:  dataJSON,
 		})
 	}
 }
 
+// SetPhase records the apply phase a run is in. Events added afterwards are
+// tagged with it.
+func (m *RunManager) SetPhase(runID, phase string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if run, ok := m.runs[runID]; ok {
+		run.Phase = phase
+	}
+}
+
 // UpdateSummary updates the run summary.
 func (m *RunManager) UpdateSummary(runID string, summary RunSummary) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if run, ok := m.runs[runID]; ok {
-		run.Summary = summary
+		// The caller keeps appending to summary while the run is read.
+		run.Summary = summary.clone()
 	}
 }
 
@@ -223,6 +427,8 @@ func (m *RunManager) Complete(runID string, status string) {
 		CreatedAt:   run.CreatedAt,
 		StartedAt:   run.StartedAt,
 		CompletedAt: run.CompletedAt,
+		Note:        run.Note,
+		Labels:      run.Labels,
 	}
 	if b, err := json.Marshal(run.Summary); err == nil {
 		storedRun.SummaryJSON = string(b)
@@ -237,6 +443,50 @@ func (m *RunManager) Complete(runID string, status string) {
 	}
 }
 
+// SetNotes replaces the note and labels of a run, in memory and in the
+// store. It reports false when the run is unknown.
+func (m *RunManager) SetNotes(runID string, notes RunNotes) bool {
+	m.mu.Lock()
+	run, ok := m.runs[runID]
+	var storedRun *state.Run
+	if ok {
+		run.RunNotes = notes
+		storedRun = &state.Run{
+			ID:          run.ID,
+			Mode:        run.Mode,
+			Status:      run.Status,
+			CreatedAt:   run.CreatedAt,
+			StartedAt:   run.StartedAt,
+			CompletedAt: run.CompletedAt,
+			Note:        run.Note,
+			Labels:      run.Labels,
+		}
+		if run.CompletedAt != nil {
+			if b, err := json.Marshal(run.Summary); err == nil {
+				storedRun.SummaryJSON = string(b)
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if m.store == nil {
+		return ok
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if !ok {
+		// Runs that fell out of memory are annotated in the store alone.
+		existing, err := m.store.GetRun(ctx, runID)
+		if err != nil {
+			return false
+		}
+		existing.Note, existing.Labels = notes.Note, notes.Labels
+		storedRun = existing
+	}
+	_ = m.store.SaveRun(ctx, storedRun)
+	return true
+}
+
 // Get returns a run by ID. Falls back to store if not in memory.
 func (m *RunManager) Get(runID string) (*Run, bool) {
 	m.mu.RLock()
@@ -266,6 +516,7 @@ func (m *RunManager) Get(runID string) (*Run, bool) {
 			StartedAt:   storedRun.StartedAt,
 			CompletedAt: storedRun.CompletedAt,
 			Events:      []RunEvent{},
+			RunNotes:    RunNotes{Note: storedRun.Note, Labels: storedRun.Labels},
 		}
 		if storedRun.SummaryJSON != "" {
 			_ = json.Unmarshal([]byte(storedRun.SummaryJSON), &apiRun.Summary)
@@ -278,6 +529,7 @@ func (m *RunManager) Get(runID string) (*Run, bool) {
 					Target:    e.Target,
 					Service:   e.Service,
 					Step:      e.Step,
+					Phase:     e.Phase,
 					Message:   e.Message,
 				}
 				if e.DataJSON != "" {
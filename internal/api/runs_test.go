@@ -1,8 +1,15 @@
 package api
 
 import (
+	"context"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
 )
 
 func TestRunManager_CreateRun(t *testing.T) {
@@ -66,6 +73,49 @@ func TestRunManager_AddEvent(t *testing.T) {
 	}
 }
 
+func TestRunManager_AddEventRedactsSecrets(t *testing.T) {
+	rm := NewRunManager(10, 100, 50, nil)
+	run := rm.CreateRun("apply")
+
+	rm.AddEvent(run.ID, RunEvent{
+		Level:   "error",
+		Message: "notify failed: POST https://discord.com/api/webhooks/1/s3cr3t",
+		Data:    map[string]interface{}{"stream": "stderr", "line": "DB_PASSWORD=hunter2", "attempt": 2},
+	})
+
+	got, _ := rm.Get(run.ID)
+	event := got.Events[0]
+	if strings.Contains(event.Message, "s3cr3t") {
+		t.Errorf("webhook token leaked in message %q", event.Message)
+	}
+	if event.Data["line"] != "DB_PASSWORD=[REDACTED]" {
+		t.Errorf("unexpected data line %v", event.Data["line"])
+	}
+	if event.Data["attempt"] != 2 || event.Data["stream"] != "stderr" {
+		t.Errorf("non-secret data changed: %v", event.Data)
+	}
+}
+
+func TestRunManager_SetPhaseTagsEvents(t *testing.T) {
+	rm := NewRunManager(10, 100, 50, nil)
+	run := rm.CreateRun("apply")
+
+	rm.AddEvent(run.ID, RunEvent{Level: "info", Message: "before"})
+	rm.SetPhase(run.ID, "pull")
+	rm.AddEvent(run.ID, RunEvent{Level: "info", Message: "pulling"})
+	rm.SetPhase(run.ID, "switch")
+	rm.AddEvent(run.ID, RunEvent{Level: "info", Message: "recreating"})
+
+	got, _ := rm.Get(run.ID)
+	phases := []string{got.Events[0].Phase, got.Events[1].Phase, got.Events[2].Phase}
+	if phases[0] != "" || phases[1] != "pull" || phases[2] != "switch" {
+		t.Errorf("unexpected event phases: %v", phases)
+	}
+	if got.Phase != "switch" {
+		t.Errorf("expected run phase switch, got %q", got.Phase)
+	}
+}
+
 func TestRunManager_AddEvent_NonexistentRun(t *testing.T) {
 	rm := NewRunManager(10, 100, 50, nil)
 	// Should not panic
@@ -228,3 +278,108 @@ func TestRunManager_EventTimestamp(t *testing.T) {
 		t.Errorf("expected timestamp %v, got %v", ts, got.Events[0].Timestamp)
 	}
 }
+
+func TestRunManager_RunContext(t *testing.T) {
+	rm := NewRunManager(10, 100, 50, nil)
+	run := rm.CreateRun("apply")
+
+	parent, stop := context.WithCancel(context.Background())
+	ctx, done := rm.RunContext(parent, run.ID)
+	stop()
+	if ctx.Err() == nil {
+		t.Fatal("expected the run context to end with its parent")
+	}
+
+	done()
+	if rm.CancelRunning(run.ID) {
+		t.Error("expected a finished run to no longer be cancellable")
+	}
+}
+
+func TestRunSummary_RecordGroupsByTarget(t *testing.T) {
+	start := time.Date(2026, 3, 18, 7, 0, 0, 0, time.UTC)
+	var summary RunSummary
+	summary.record(planner.PlanItem{TargetName: "media", ServiceName: "sonarr", Image: "sonarr:latest"}, outcomeApplied, "Update applied successfully", start, start.Add(1500*time.Millisecond))
+	summary.record(planner.PlanItem{TargetName: "dns", ServiceName: "pihole"}, outcomeSkipped, "Skipped (not safe)", time.Time{}, start)
+	summary.record(planner.PlanItem{TargetName: "media", ServiceName: "radarr"}, outcomeRolledBack, "Update failed: probe; rollback completed", start, start.Add(4*time.Second))
+
+	if len(summary.Targets) != 2 || summary.Targets[0].Target != "media" || summary.Targets[1].Target != "dns" {
+		t.Fatalf("expected media then dns, got %+v", summary.Targets)
+	}
+	media := summary.Targets[0].Services
+	if len(media) != 2 || media[0].Service != "sonarr" || media[1].Outcome != outcomeRolledBack {
+		t.Fatalf("unexpected media outcomes: %+v", media)
+	}
+	if media[0].DurationMs != 1500 || summary.Targets[1].Services[0].DurationMs != 0 {
+		t.Errorf("unexpected durations: %d and %d", media[0].DurationMs, summary.Targets[1].Services[0].DurationMs)
+	}
+
+	items := autoUpdateItems(summary)
+	if len(items) != 3 || items[0].Result != "updated" || items[0].Duration != 1500*time.Millisecond {
+		t.Errorf("unexpected notification items: %+v", items)
+	}
+}
+
+func TestRunManager_UpdateSummaryIsPersisted(t *testing.T) {
+	store, err := state.NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	rm := NewRunManager(10, 100, 50, store)
+	run := rm.CreateRun("apply")
+	var summary RunSummary
+	summary.UpdatesApplied = 1
+	summary.record(planner.PlanItem{TargetName: "media", ServiceName: "sonarr"}, outcomeApplied, "", time.Time{}, time.Now())
+	rm.UpdateSummary(run.ID, summary)
+
+	// Later records must not leak into the run through a shared slice.
+	summary.record(planner.PlanItem{TargetName: "media", ServiceName: "radarr"}, outcomeSkipped, "", time.Time{}, time.Now())
+	if got, _ := rm.Get(run.ID); len(got.Summary.Targets[0].Services) != 1 {
+		t.Fatalf("expected the run's summary to be a copy, got %+v", got.Summary.Targets)
+	}
+	rm.Complete(run.ID, "completed")
+
+	reloaded := NewRunManager(10, 100, 50, store)
+	got, ok := reloaded.Get(run.ID)
+	if !ok {
+		t.Fatal("expected the run from the store")
+	}
+	if len(got.Summary.Targets) != 1 || got.Summary.Targets[0].Services[0].Service != "sonarr" {
+		t.Errorf("expected the breakdown to be persisted, got %+v", got.Summary.Targets)
+	}
+}
+
+func TestRunManager_NotesArePersisted(t *testing.T) {
+	store := state.NewMemoryStore()
+	rm := NewRunManager(1, 100, 50, store)
+	run := rm.CreateQueuedRun("apply", RunNotes{Note: "upgrading before game night", Labels: map[string]string{"ticket": "42"}})
+	rm.MarkStarted(run.ID)
+	rm.Complete(run.ID, "completed")
+
+	reloaded := NewRunManager(10, 100, 50, store)
+	got, ok := reloaded.Get(run.ID)
+	if !ok {
+		t.Fatal("expected the run from the store")
+	}
+	if got.Note != "upgrading before game night" || got.Labels["ticket"] != "42" {
+		t.Fatalf("expected the notes to survive start and completion, got %+v", got.RunNotes)
+	}
+
+	// A newer run pushes the first one out of memory; its notes are then
+	// changed in the store alone.
+	rm.CreateRun("apply")
+	if !rm.SetNotes(run.ID, RunNotes{Note: "done"}) {
+		t.Fatal("expected SetNotes to find the stored run")
+	}
+	if got, _ := rm.Get(run.ID); got.Note != "done" || got.Labels != nil || got.Status != "completed" {
+		t.Errorf("expected only the notes to change, got %+v", got)
+	}
+	if rm.SetNotes("missing", RunNotes{}) {
+		t.Error("expected SetNotes to report an unknown run")
+	}
+}
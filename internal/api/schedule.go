@@ -0,0 +1,258 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// scheduledRunGrace is how late a scheduled run may still start after
+// Bulwark was down at its time; later ones are marked missed instead, so an
+// after-hours apply does not run in the middle of the day.
+const scheduledRunGrace = time.Hour
+
+// maxScheduleAhead bounds how far ahead a run may be scheduled.
+const maxScheduleAhead = 30 * 24 * time.Hour
+
+type scheduleRunRequest struct {
+	RunAt time.Time `json:"run_at"`
+	applyRequest
+}
+
+type scheduledRunsResponse struct {
+	Runs []state.ScheduledRun `json:"runs"`
+}
+
+// handleScheduledRuns serves one-shot apply runs queued for a later time:
+//
+//	GET    /api/runs/schedule       scheduled runs, earliest first
+//	POST   /api/runs/schedule       schedule an apply at run_at
+//	DELETE /api/runs/schedule/{id}  drop a pending run
+func (s *Server) handleScheduledRuns(w http.ResponseWriter, r *http.Request) {
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "scheduling unavailable", "state persistence is disabled")
+		return
+	}
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/runs/schedule"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		runs, err := s.store.ListScheduledRuns(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list scheduled runs", err.Error())
+			return
+		}
+		if runs == nil {
+			runs = []state.ScheduledRun{}
+		}
+		writeJSON(w, http.StatusOK, scheduledRunsResponse{Runs: runs})
+	case id == "" && r.Method == http.MethodPost:
+		s.requireWrite(http.HandlerFunc(s.handleScheduleRun)).ServeHTTP(w, r)
+	case id != "" && r.Method == http.MethodDelete:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleUnscheduleRun(w, r, id)
+		})).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+func (s *Server) handleScheduleRun(w http.ResponseWriter, r *http.Request) {
+	var req scheduleRunRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	now := time.Now()
+	if req.RunAt.IsZero() || !req.RunAt.After(now) {
+		writeError(w, http.StatusBadRequest, "invalid run_at", "run_at must be an RFC 3339 time in the future")
+		return
+	}
+	if req.RunAt.After(now.Add(maxScheduleAhead)) {
+		writeError(w, http.StatusBadRequest, "invalid run_at", "runs can be scheduled at most 30 days ahead")
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if mode == "" {
+		mode = "safe"
+	}
+	if mode != "safe" && mode != "selected" && mode != "all" {
+		writeError(w, http.StatusBadRequest, "invalid mode", "use safe, selected, or all")
+		return
+	}
+	if mode == "selected" && len(req.ServiceIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "service_ids required", "selected mode needs the services to apply")
+		return
+	}
+	if err := req.RunNotes.normalize(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid run notes", err.Error())
+		return
+	}
+	if !req.Select.Empty() {
+		writeError(w, http.StatusBadRequest, "select is not supported", "scheduled runs apply service_ids; resolve the selection with POST /api/plan/select first")
+		return
+	}
+
+	run := &state.ScheduledRun{
+		ID:         newRunID(),
+		RunAt:      req.RunAt.UTC(),
+		Mode:       mode,
+		Target:     req.Target,
+		Group:      req.Group,
+		ServiceIDs: req.ServiceIDs,
+		Force:      req.Force,
+		PullOnly:   req.PullOnly,
+		CreatedBy:  s.actor(r),
+		CreatedAt:  now.UTC(),
+		Status:     state.ScheduledRunPending,
+		Note:       req.Note,
+		Labels:     req.Labels,
+	}
+	if err := s.store.SaveScheduledRun(r.Context(), run); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to schedule run", err.Error())
+		return
+	}
+	s.armScheduledRun(*run)
+	s.requestLogger(r).Info().Str("id", run.ID).Str("mode", mode).Time("run_at", run.RunAt).Msg("Apply run scheduled")
+	writeJSON(w, http.StatusCreated, run)
+}
+
+func (s *Server) handleUnscheduleRun(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	run, err := s.scheduledRun(ctx, id)
+	if err != nil {
+		writeError(w, statusForError(err), "scheduled run not found", err.Error())
+		return
+	}
+	if run.Status == state.ScheduledRunStarted {
+		writeError(w, http.StatusConflict, "scheduled run already started", "cancel run "+run.RunID+" instead")
+		return
+	}
+	s.disarmScheduledRun(id)
+	if err := s.store.DeleteScheduledRun(ctx, id); err != nil {
+		writeError(w, statusForError(err), "failed to delete scheduled run", err.Error())
+		return
+	}
+	s.requestLogger(r).Info().Str("id", id).Msg("Scheduled run removed")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) scheduledRun(ctx context.Context, id string) (*state.ScheduledRun, error) {
+	runs, err := s.store.ListScheduledRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		if runs[i].ID == id {
+			return &runs[i], nil
+		}
+	}
+	return nil, state.ErrNotFound
+}
+
+// loadScheduledRuns arms the pending scheduled runs after a restart. Runs
+// whose time passed more than scheduledRunGrace ago are marked missed.
+func (s *Server) loadScheduledRuns(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	runs, err := s.store.ListScheduledRuns(ctx)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to load scheduled runs")
+		return
+	}
+	for _, run := range runs {
+		if run.Status != state.ScheduledRunPending {
+			continue
+		}
+		if time.Since(run.RunAt) > scheduledRunGrace {
+			run.Status = state.ScheduledRunMissed
+			if err := s.store.SaveScheduledRun(ctx, &run); err != nil {
+				s.logger.Warn().Err(err).Str("id", run.ID).Msg("Failed to mark scheduled run missed")
+			}
+			s.logger.Warn().Str("id", run.ID).Time("run_at", run.RunAt).Msg("Scheduled run missed while Bulwark was down")
+			continue
+		}
+		s.armScheduledRun(run)
+	}
+}
+
+// armScheduledRun starts run at its time; a time already passed starts it
+// right away.
+func (s *Server) armScheduledRun(run state.ScheduledRun) {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	if s.scheduleTimers == nil {
+		s.scheduleTimers = make(map[string]*time.Timer)
+	}
+	if timer, ok := s.scheduleTimers[run.ID]; ok {
+		timer.Stop()
+	}
+	s.scheduleTimers[run.ID] = time.AfterFunc(time.Until(run.RunAt), func() {
+		s.startScheduledRun(run.ID)
+	})
+}
+
+func (s *Server) disarmScheduledRun(id string) {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	if timer, ok := s.scheduleTimers[id]; ok {
+		timer.Stop()
+		delete(s.scheduleTimers, id)
+	}
+}
+
+// stopScheduledRuns stops every timer; the runs stay pending in the store.
+func (s *Server) stopScheduledRuns() {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	for id, timer := range s.scheduleTimers {
+		timer.Stop()
+		delete(s.scheduleTimers, id)
+	}
+}
+
+// startScheduledRun queues the apply of a scheduled run that is due and
+// records the run it started.
+func (s *Server) startScheduledRun(id string) {
+	s.disarmScheduledRun(id)
+	ctx := s.baseContext()
+	if ctx.Err() != nil {
+		return
+	}
+	run, err := s.scheduledRun(ctx, id)
+	if err != nil || run.Status != state.ScheduledRunPending {
+		return
+	}
+
+	req := applyRequest{
+		Mode:       run.Mode,
+		Target:     run.Target,
+		Group:      run.Group,
+		ServiceIDs: run.ServiceIDs,
+		Force:      run.Force,
+		PullOnly:   run.PullOnly,
+		Trigger:    state.TriggerScheduled,
+		Actor:      run.CreatedBy,
+		RunNotes:   RunNotes{Note: run.Note, Labels: run.Labels},
+	}
+	run.Status = state.ScheduledRunStarted
+	if s.writesBlocked {
+		s.logger.Warn().Str("id", id).Msg("Skipping scheduled run: Docker endpoint does not allow updates")
+		run.Status = state.ScheduledRunFailed
+	} else if maintenance := s.maintenance(ctx); maintenance != nil {
+		s.logger.Warn().Str("id", id).Str("maintenance", maintenance.Message).Msg("Skipping scheduled run: maintenance is on")
+		run.Status = state.ScheduledRunSkipped
+	} else if applyRun, _, _, err := s.enqueueApply("scheduled", priorityManual, req, run.Mode); err != nil {
+		s.logger.Warn().Err(err).Str("id", id).Msg("Failed to queue scheduled run")
+		run.Status = state.ScheduledRunFailed
+	} else {
+		run.RunID = applyRun.ID
+		s.logger.Info().Str("id", id).Str("run_id", applyRun.ID).Msg("Scheduled run queued")
+	}
+	if err := s.store.SaveScheduledRun(context.WithoutCancel(ctx), run); err != nil {
+		s.logger.Warn().Err(err).Str("id", id).Msg("Failed to record scheduled run")
+	}
+}
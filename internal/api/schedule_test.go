@@ -0,0 +1,96 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestScheduledRuns(t *testing.T) {
+	s := setupTestServer(t)
+	s.cfg.ReadOnly = false
+	s.cfg.WebToken = "write-token-secret"
+	t.Cleanup(s.stopScheduledRuns)
+	h := s.Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer write-token-secret")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	past := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	if w := do(http.MethodPost, "/api/runs/schedule", `{"run_at":"`+past+`","mode":"safe"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a time in the past, got %d", w.Code)
+	}
+	tonight := time.Now().Add(6 * time.Hour).Format(time.RFC3339)
+	if w := do(http.MethodPost, "/api/runs/schedule", `{"run_at":"`+tonight+`","mode":"selected"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a selection without services, got %d", w.Code)
+	}
+
+	w := do(http.MethodPost, "/api/runs/schedule", `{"run_at":"`+tonight+`","mode":"selected","service_ids":["service-1"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var run state.ScheduledRun
+	if err := json.NewDecoder(w.Body).Decode(&run); err != nil || run.Status != state.ScheduledRunPending || run.CreatedBy != "api" {
+		t.Fatalf("expected a pending run, got %+v (%v)", run, err)
+	}
+	if _, armed := s.scheduleTimers[run.ID]; !armed {
+		t.Errorf("expected the run to be armed")
+	}
+
+	var list scheduledRunsResponse
+	if err := json.NewDecoder(do(http.MethodGet, "/api/runs/schedule", "").Body).Decode(&list); err != nil || len(list.Runs) != 1 {
+		t.Fatalf("expected one scheduled run, got %+v (%v)", list, err)
+	}
+	if got := list.Runs[0].ServiceIDs; len(got) != 1 || got[0] != "service-1" {
+		t.Errorf("expected the selection to be stored, got %v", got)
+	}
+
+	if w := do(http.MethodDelete, "/api/runs/schedule/"+run.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if _, armed := s.scheduleTimers[run.ID]; armed {
+		t.Errorf("expected the run to be disarmed")
+	}
+	if w := do(http.MethodDelete, "/api/runs/schedule/"+run.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 once removed, got %d", w.Code)
+	}
+}
+
+func TestLoadScheduledRunsMarksMissedRuns(t *testing.T) {
+	s := setupTestServer(t)
+	t.Cleanup(s.stopScheduledRuns)
+	ctx := context.Background()
+	missed := &state.ScheduledRun{ID: "missed", RunAt: time.Now().Add(-3 * time.Hour), Mode: "safe", Status: state.ScheduledRunPending}
+	due := &state.ScheduledRun{ID: "due", RunAt: time.Now().Add(time.Hour), Mode: "safe", Status: state.ScheduledRunPending}
+	for _, run := range []*state.ScheduledRun{missed, due} {
+		if err := s.store.SaveScheduledRun(ctx, run); err != nil {
+			t.Fatalf("SaveScheduledRun failed: %v", err)
+		}
+	}
+
+	s.loadScheduledRuns(ctx)
+	if run, err := s.scheduledRun(ctx, "missed"); err != nil || run.Status != state.ScheduledRunMissed {
+		t.Errorf("expected the overdue run to be missed, got %+v (%v)", run, err)
+	}
+	if _, armed := s.scheduleTimers["due"]; !armed {
+		t.Errorf("expected the upcoming run to be armed")
+	}
+
+	// Starting the run while Docker refuses updates records the failure
+	// instead of queueing an apply.
+	s.writesBlocked = true
+	s.startScheduledRun("due")
+	if run, err := s.scheduledRun(ctx, "due"); err != nil || run.Status != state.ScheduledRunFailed {
+		t.Errorf("expected the run to fail, got %+v (%v)", run, err)
+	}
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/scheduler"
+)
+
+type schedulerJobsResponse struct {
+	Timezone string                `json:"timezone"`
+	Jobs     []scheduler.JobStatus `json:"jobs"`
+}
+
+func (s *Server) handleSchedulerJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	resp := schedulerJobsResponse{Timezone: time.Local.String(), Jobs: []scheduler.JobStatus{}}
+	if s.notify != nil {
+		resp.Timezone = s.notify.Location().String()
+		resp.Jobs = s.notify.Jobs()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSchedulerJobRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/api/scheduler/jobs/")
+	name, action, ok := strings.Cut(rest, "/")
+	if !ok || name == "" || action != "run" {
+		writeError(w, http.StatusNotFound, "not found", "")
+		return
+	}
+
+	if s.notify == nil {
+		writeError(w, http.StatusServiceUnavailable, "scheduler unavailable", "notifications manager not initialized")
+		return
+	}
+
+	if err := s.notify.RunJob(name); err != nil {
+		if errors.Is(err, scheduler.ErrJobNotFound) {
+			writeError(w, http.StatusNotFound, "job not found", name)
+			return
+		}
+		if errors.Is(err, scheduler.ErrJobRunning) {
+			writeError(w, http.StatusConflict, "job already running", name)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to run job", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{"job": name, "triggered": true})
+}
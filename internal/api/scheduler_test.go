@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandleSchedulerJobs_NoManager(t *testing.T) {
+	s := testServer()
+	req := httptest.NewRequest(http.MethodGet, "/api/scheduler/jobs", nil)
+	w := httptest.NewRecorder()
+	s.handleSchedulerJobs(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp schedulerJobsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if resp.Jobs == nil || len(resp.Jobs) != 0 {
+		t.Errorf("expected empty job list, got %+v", resp.Jobs)
+	}
+}
+
+func TestHandleSchedulerJobRun_BadPath(t *testing.T) {
+	s := testServer()
+	req := httptest.NewRequest(http.MethodPost, "/api/scheduler/jobs/auto-update/stop", nil)
+	w := httptest.NewRecorder()
+	s.handleSchedulerJobRun(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
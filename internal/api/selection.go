@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+type planSelectRequest struct {
+	planRequest
+	Select *planner.Selector `json:"select"`
+}
+
+type planSelectResponse struct {
+	Items []planner.PlanItem `json:"items"`
+	// UpdateCount counts the selected items with an update available, and
+	// ServiceIDs lists their services, e.g. for a scheduled run.
+	UpdateCount int      `json:"update_count"`
+	ServiceIDs  []string `json:"service_ids"`
+}
+
+// handlePlanSelect previews what an apply with the same select would touch:
+// POST /api/plan/select with {"select": {"images": ["linuxserver/*"]}}
+// answers with the plan items the selection matches.
+func (s *Server) handlePlanSelect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	var req planSelectRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	if err := req.Select.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid select", err.Error())
+		return
+	}
+
+	plan, err := s.getPlan(r.Context(), req.planRequest)
+	if err != nil {
+		writeError(w, statusForError(err), "plan failed", err.Error())
+		return
+	}
+	resp := planSelectResponse{Items: req.Select.Select(plan.Items), ServiceIDs: []string{}}
+	for _, item := range resp.Items {
+		if item.UpdateAvailable {
+			resp.UpdateCount++
+			resp.ServiceIDs = append(resp.ServiceIDs, item.ServiceID)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
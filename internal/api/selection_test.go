@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+func TestHandlePlanSelect(t *testing.T) {
+	s := testServer()
+	s.planCache.Set(&planner.Plan{GeneratedAt: time.Now(), Items: []planner.PlanItem{
+		{ServiceID: "s1", ServiceName: "sonarr", Image: "lscr.io/linuxserver/sonarr:latest", UpdateAvailable: true},
+		{ServiceID: "s2", ServiceName: "radarr", Image: "linuxserver/radarr"},
+		{ServiceID: "s3", ServiceName: "nginx", Image: "nginx:1.27", UpdateAvailable: true},
+	}})
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handlePlanSelect(w, httptest.NewRequest(http.MethodPost, "/api/plan/select", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`{"select": {"images": ["linuxserver/*"]}}`)
+	var resp planSelectResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d (%v)", w.Code, err)
+	}
+	if len(resp.Items) != 2 || resp.UpdateCount != 1 || len(resp.ServiceIDs) != 1 || resp.ServiceIDs[0] != "s1" {
+		t.Errorf("expected both linuxserver items with one update, got %+v", resp)
+	}
+
+	if w := post(`{"select": {"risks": ["risky"]}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown risk, got %d", w.Code)
+	}
+}
+
+func TestApplyRejectsInvalidSelect(t *testing.T) {
+	s := testServer()
+	w := httptest.NewRecorder()
+	s.handleApply(w, httptest.NewRequest(http.MethodPost, "/api/apply", strings.NewReader(`{"mode": "all", "select": {"labels": ["tier"]}}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed label selector, got %d", w.Code)
+	}
+}
@@ -3,16 +3,25 @@ package api
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/itsmrshow/bulwark/internal/artifacts"
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/executor"
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/metrics"
 	"github.com/itsmrshow/bulwark/internal/notify"
 	"github.com/itsmrshow/bulwark/internal/planner"
 	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/scheduler"
 	"github.com/itsmrshow/bulwark/internal/state"
 	"golang.org/x/sync/singleflight"
 	"golang.org/x/time/rate"
@@ -24,15 +33,70 @@ type Server struct {
 	logger       *logging.Logger
 	store        state.Store
 	runs         *RunManager
+	queue        *runQueue
 	writeLimiter *rate.Limiter
 	planCache    *planCache
 	planGroup    singleflight.Group
-	sessions     *sessionStore
+	sessions     sessionTokens
+	hasUsers     atomic.Bool
 	notify       *notify.Manager
 	// registry is shared across every plan build so its auth-token and digest
 	// caches survive between requests. Constructing one per build discarded
 	// both, turning each poll into a full re-fetch of every image.
 	registry *registry.Client
+
+	// artifacts keeps the files runs produce; nil when BULWARK_RUN_ARTIFACTS
+	// is off.
+	artifacts *artifacts.Store
+	// writesBlocked is set when the Docker endpoint denies the calls updates
+	// need, e.g. behind a read-only socket proxy.
+	writesBlocked bool
+	// tunables are the settings /api/settings changes at runtime; they start
+	// out from cfg.
+	tunablesMu sync.RWMutex
+	tunables   tunables
+	// setup is the result of the first-run setup wizard; setupRun serializes
+	// attempts to complete it.
+	setupMu  sync.RWMutex
+	setup    setupRecord
+	setupRun sync.Mutex
+	// pinnedMu serializes changes to the pinned digests.
+	pinnedMu sync.Mutex
+	// hostName is the Docker host's name once the daemon reported it.
+	hostMu   sync.Mutex
+	hostName string
+	// staleRefresh is set while a fresh plan replacing the persisted one is
+	// built in the background.
+	staleRefresh atomic.Bool
+	// digestMemory holds the digests incremental plan builds reuse; nil when
+	// BULWARK_INCREMENTAL_PLAN is off.
+	digestMemory *planner.DigestMemory
+	// scheduleTimers start the pending one-shot scheduled runs, by ID.
+	scheduleMu     sync.Mutex
+	scheduleTimers map[string]*time.Timer
+	// maintenanceWindow holds the maintenance window when there is no state
+	// database to keep it in.
+	maintenanceWindow atomic.Pointer[state.Maintenance]
+	// locks holds the target locks of every executor the server builds, so
+	// runs, rollbacks and version changes of one target exclude each other.
+	locks *executor.LockManager
+	// pulls spaces out the image pulls of every run; nil when pulls are not
+	// limited.
+	pulls *executor.PullScheduler
+	// watchdog watches services with a fail pattern after their update;
+	// nil for observers.
+	watchdog *executor.Watchdog
+	// telemetryInstallID is the installation ID of telemetry reports and
+	// telemetrySentAt when the last one was sent.
+	telemetryMu        sync.Mutex
+	telemetryInstallID string
+	telemetrySentAt    time.Time
+	// dockerPing replaces the Docker connectivity check in tests.
+	dockerPing func(ctx context.Context) error
+	// ctx is the parent of every plan, discovery and apply run; stop cancels
+	// it on shutdown.
+	ctx  context.Context
+	stop context.CancelFunc
 }
 
 // NewServer constructs a new API server.
@@ -68,36 +132,236 @@ func NewServer(cfg Config, logger *logging.Logger) (*Server, error) {
 		logger:       logger.WithComponent("api"),
 		store:        store,
 		runs:         NewRunManager(25, 1500, 200, store),
+		queue:        newRunQueue(cfg.MaxConcurrentRuns),
 		writeLimiter: limiter,
 		planCache:    newPlanCache(cfg.PlanCacheTTL),
 		sessions:     newSessionStore(),
-		registry:     registry.NewClient(logger).WithDigestTTL(cfg.DigestCacheTTL),
+		registry:     registry.NewClient(logger).WithDigestTTL(cfg.DigestCacheTTL).WithHTTPOptions(cfg.RegistryHTTP),
+	}
+	server.locks = executor.NewLockManager(logger)
+	server.pulls = newPullScheduler(cfg, server.registry)
+	if cfg.RunArtifacts {
+		server.artifacts = artifacts.NewStore(filepath.Join(cfg.DataDir, "artifacts"))
+	}
+	if cfg.IncrementalPlan {
+		server.digestMemory = planner.NewDigestMemory()
+	}
+	metrics.ConfigureServiceLabels(cfg.MetricsServiceLabels, cfg.MetricsMaxServices)
+	server.ctx, server.stop = context.WithCancel(context.Background())
+	server.loadTunables(server.ctx)
+	server.loadSetup(server.ctx)
+	server.loadUsers(server.ctx)
+	server.loadPersistedPlan(server.ctx)
+	if cfg.SessionJWT {
+		var keys settingsStore
+		if store != nil {
+			keys = store
+		} else {
+			logger.Warn().Msg("Session signing keys are not persisted without BULWARK_STATE_DB; sessions end on restart")
+		}
+		server.sessions = newJWTSessions(server.ctx, keys, cfg.SessionKeyRotation, server.logger)
+	}
+
+	if !cfg.Observer() {
+		server.checkDockerCapabilities()
+		server.watchdog = executor.NewWatchdog(server.ctx, store, logger).
+			WithLockManager(server.locks).
+			OnRollback(server.watchdogRolledBack)
+	}
+
+	location := time.Local
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BULWARK_TZ %q: %w", cfg.Timezone, err)
+		}
+		location = loc
 	}
 
 	settingsStore := notify.NewStore(cfg.ConfigPath, store, logger)
 	server.notify = notify.NewManager(settingsStore, func(ctx context.Context) (*planner.Plan, error) {
 		return server.getPlan(ctx, planRequest{})
-	}, logger).WithApplyFunc(func(ctx context.Context, safe bool, unsafe bool) {
-		mode := "safe"
-		force := false
-		if unsafe {
-			mode = "all"
-			force = true
-		}
-		req := applyRequest{Mode: mode, Force: force}
-		run := server.runs.CreateRun("auto-update")
-		go server.executeApply(run.ID, req, mode)
+	}, logger).WithJobHistory(store).WithJobTuning(notify.JobTuning{
+		Jitter:            cfg.SchedulerJitter,
+		NotifyTimeout:     cfg.NotifyJobTimeout,
+		AutoUpdateTimeout: cfg.AutoUpdateTimeout,
+		Location:          location,
 	})
+	if !cfg.Observer() {
+		server.notify.WithApplyFunc(server.autoUpdate).WithCommandFunc(server.applyFromHomeAssistant)
+		server.loadScheduledRuns(server.ctx)
+	}
 	server.notify.Start(context.Background())
+	if store != nil && cfg.ReconcileInterval > 0 {
+		go server.reconcileLoop(server.ctx, cfg.ReconcileInterval)
+	}
+	if cfg.TelemetryEnabled && cfg.TelemetryURL == "" {
+		logger.Warn().Msg("BULWARK_TELEMETRY_ENABLED is set without BULWARK_TELEMETRY_URL; no telemetry is sent")
+	}
+	if server.telemetryActive() {
+		logger.Info().Str("endpoint", cfg.TelemetryURL).Msg("Anonymous telemetry enabled; GET /api/telemetry shows the report")
+		go server.telemetryLoop(server.ctx)
+	}
 
 	return server, nil
 }
 
+// autoUpdate queues a scheduled auto-update run and waits for it to finish.
+func (s *Server) autoUpdate(ctx context.Context, safe bool, unsafe bool) {
+	mode := "safe"
+	force := false
+	if unsafe {
+		mode = "all"
+		force = true
+	}
+	if s.writesBlocked {
+		s.logger.Warn().Msg("Skipping auto-update: Docker endpoint does not allow updates")
+		return
+	}
+	if maintenance := s.maintenance(ctx); maintenance != nil {
+		s.logger.Info().Str("maintenance", maintenance.Message).Msg("Skipping auto-update: maintenance is on")
+		return
+	}
+	runMode := "auto-update"
+	if scheduler.TriggerFromContext(ctx) == scheduler.TriggerCatchUp {
+		runMode = "catch-up"
+	}
+	req := applyRequest{Mode: mode, Force: force, Trigger: state.TriggerAutoApprove, Actor: runMode}
+	run, _, done, err := s.enqueueApply(runMode, priorityScheduled, req, mode)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to queue auto-update run")
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if s.queue.cancel(run.ID) {
+			s.runs.Complete(run.ID, "cancelled")
+			return
+		}
+		// A run already applying stops before its next service and records
+		// itself as cancelled; the update in progress is rolled back where
+		// the policy asks for it. A run just leaving the queue may not be
+		// cancellable yet, so keep trying until it is or it finishes.
+		s.logger.Warn().Str("run_id", run.ID).Msg("Auto-update timed out, cancelling the running apply")
+		for !s.runs.CancelRunning(run.ID) {
+			select {
+			case <-done:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+		<-done
+	}
+}
+
+// applyFromHomeAssistant queues an apply requested through MQTT: the selected
+// service, or all safe updates when serviceID is empty. It follows the same
+// read-only gate as the web console.
+func (s *Server) applyFromHomeAssistant(_ context.Context, serviceID string) error {
+	if s.cfg.ReadOnly || s.writesBlocked {
+		return fmt.Errorf("read-only mode: updates are disabled")
+	}
+	req := applyRequest{Mode: "safe", Trigger: state.TriggerWebhook, Actor: "home-assistant"}
+	if serviceID != "" {
+		req.Mode, req.ServiceIDs = "selected", []string{serviceID}
+	}
+	_, _, _, err := s.enqueueApply("home-assistant", priorityManual, req, req.Mode)
+	return err
+}
+
+// checkDockerCapabilities runs the Docker preflight and drops to read-only
+// mode if the endpoint denies the calls updates need. An unreachable daemon
+// is left alone, since it may come up later.
+func (s *Server) checkDockerCapabilities() {
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	report := dockerClient.Preflight(ctx)
+	if !report.Reachable {
+		s.logger.Warn().Msg("Docker endpoint unreachable during preflight")
+		return
+	}
+
+	for _, c := range report.Missing() {
+		s.logger.Warn().
+			Str("capability", c.Name).
+			Str("endpoint", c.Endpoint).
+			Str("needed_for", c.Purpose).
+			Str("error", c.Error).
+			Msg("Docker API call denied")
+	}
+	if !report.CanWrite() {
+		s.writesBlocked = true
+		s.cfg.ReadOnly = true
+		s.logger.Warn().Msg("Docker endpoint does not allow updates, running read-only")
+	}
+}
+
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "docker client failed", err.Error())
+		return
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	writeJSON(w, http.StatusOK, dockerClient.Preflight(r.Context()))
+}
+
+// StopOperations cancels in-flight plan builds, discoveries and apply runs.
+// Call it before draining the HTTP server so requests end promptly; Close
+// calls it as well.
+func (s *Server) StopOperations() {
+	if s.stop != nil {
+		s.stop()
+	}
+}
+
+// baseContext is the parent context of server operations.
+func (s *Server) baseContext() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// BaseContext serves as http.Server.BaseContext, so request contexts end when
+// StopOperations is called.
+func (s *Server) BaseContext(net.Listener) context.Context {
+	return s.baseContext()
+}
+
+// withTimeout bounds ctx by timeout; a non-positive timeout leaves it unbounded.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // Close releases server resources.
 func (s *Server) Close() error {
+	s.StopOperations()
+	s.stopScheduledRuns()
 	if s.notify != nil {
 		s.notify.Stop()
 	}
+	if s.queue != nil {
+		for _, id := range s.queue.stop() {
+			s.runs.Complete(id, "cancelled")
+		}
+	}
 	if s.store != nil {
 		return s.store.Close()
 	}
@@ -107,21 +371,53 @@ func (s *Server) Close() error {
 // Handler returns the http.Handler with routes configured.
 func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleLiveness)
+	mux.HandleFunc("/readyz", s.handleReadiness)
 	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/api/setup", s.handleSetup)
 	mux.HandleFunc("/api/login", s.handleLogin)
 	mux.HandleFunc("/api/logout", s.handleLogout)
-	mux.HandleFunc("/api/enable-writes", s.handleEnableWrites)
+	mux.Handle("/api/users", s.requireAdmin(http.HandlerFunc(s.handleUsers)))
+	mux.Handle("/api/users/", s.requireAdmin(http.HandlerFunc(s.handleUser)))
+	mux.HandleFunc("/api/account/password", s.methodOnly(http.MethodPost, s.handleChangePassword))
 	mux.HandleFunc("/api/overview", s.handleOverview)
+	mux.HandleFunc("/api/widget", s.handleWidget)
 	mux.HandleFunc("/api/settings", s.handleSettings)
+	mux.HandleFunc("/api/settings/logging", s.handleLoggingSettings)
+	mux.HandleFunc("/api/capabilities", s.handleCapabilities)
+	mux.HandleFunc("/api/maintenance", s.handleMaintenance)
 	mux.Handle("/api/notifications/test", s.requireWrite(http.HandlerFunc(s.handleNotificationsTest)))
 	mux.HandleFunc("/api/targets", s.handleTargets)
 	mux.HandleFunc("/api/targets/", s.handleTargetByID)
 	mux.HandleFunc("/api/refresh", s.handleRefresh)
+	mux.HandleFunc("/api/check", s.handleCheck)
 	mux.HandleFunc("/api/plan", s.handlePlan)
-	mux.Handle("/api/apply", s.requireWrite(http.HandlerFunc(s.handleApply)))
+	mux.HandleFunc("/api/plan/cache", s.handlePlanCache)
+	mux.HandleFunc("/api/plan/updates", s.handlePlanUpdates)
+	mux.HandleFunc("/api/plan/select", s.handlePlanSelect)
+	mux.HandleFunc("/api/policy/simulate", s.handlePolicySimulate)
+	mux.HandleFunc("/api/lint", s.handleLint)
+	mux.HandleFunc("/api/runs", s.handleRuns)
 	mux.HandleFunc("/api/runs/", s.handleRun)
 	mux.HandleFunc("/api/history", s.handleHistory)
-	mux.Handle("/api/rollback", s.requireWrite(http.HandlerFunc(s.handleRollback)))
+	mux.HandleFunc("/api/history/", s.handleHistorySBOM)
+	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/telemetry", s.handleTelemetry)
+	mux.HandleFunc("/api/services/", s.handleService)
+	mux.HandleFunc("/api/groups", s.handleGroups)
+	mux.HandleFunc("/api/groups/", s.handleGroup)
+	mux.HandleFunc("/api/registries", s.handleRegistries)
+	mux.HandleFunc("/api/registries/", s.handleRegistry)
+	mux.HandleFunc("/api/images/protected", s.handleProtectedDigests)
+	mux.Handle("/api/images/protected/", s.requireWrite(http.HandlerFunc(s.handleUnpinDigest)))
+	if !s.cfg.Observer() {
+		mux.Handle("/api/apply", s.requireWrite(http.HandlerFunc(s.handleApply)))
+		mux.Handle("/api/rollback", s.requireWrite(http.HandlerFunc(s.handleRollback)))
+		mux.HandleFunc("/api/runs/schedule", s.handleScheduledRuns)
+		mux.HandleFunc("/api/runs/schedule/", s.handleScheduledRuns)
+	}
+	mux.HandleFunc("/api/scheduler/jobs", s.handleSchedulerJobs)
+	mux.Handle("/api/scheduler/jobs/", s.requireWrite(http.HandlerFunc(s.handleSchedulerJobRun)))
 
 	if s.cfg.MetricsEnabled {
 		mux.Handle("/metrics", promhttp.Handler())
@@ -135,7 +431,8 @@ func (s *Server) Handler() http.Handler {
 		})
 	}
 
-	return loggingMiddleware(mux, s.logger)
+	handler := corsMiddleware(versionMiddleware(compressionMiddleware(fieldsMiddleware(s.requireRead(mux))), s.cfg.LegacyAPIPaths), s.cfg.CORSOrigins, s.cfg.CORSCredentials)
+	return requestIDMiddleware(loggingMiddleware(handler, s.logger))
 }
 
 // ListenAndServe starts the HTTP server.
@@ -149,6 +446,7 @@ func (s *Server) ListenAndServe() error {
 	server := &http.Server{
 		Addr:              s.cfg.Addr,
 		Handler:           s.Handler(),
+		BaseContext:       s.BaseContext,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      30 * time.Second,
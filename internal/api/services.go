@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// handleService routes /api/services/{id}/{action}.
+func (s *Server) handleService(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/services/"), "/")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing service id", "")
+		return
+	}
+	switch action {
+	case "ignore":
+		s.handleIgnore(w, r, id)
+	case "snooze":
+		s.handleSnooze(w, r, id)
+	case "labels":
+		s.handleServiceLabels(w, r, id)
+	case "tag":
+		if s.cfg.Observer() {
+			writeError(w, http.StatusNotFound, "not found", r.URL.Path)
+			return
+		}
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleServiceTag(w, r, id)
+		})).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not found", r.URL.Path)
+	}
+}
@@ -4,30 +4,29 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/notify"
 )
 
 type settingsResponse struct {
 	Notifications notify.Settings `json:"notifications"`
 	Locked        notify.Settings `json:"locked,omitempty"`
+	Server        ServerSettings  `json:"server"`
+	// ServerLocked names the server settings pinned by environment variables.
+	ServerLocked []string `json:"server_locked,omitempty"`
+}
+
+// settingsRequest updates either section or both; an omitted section is
+// left alone.
+type settingsRequest struct {
+	Notifications *notify.Settings `json:"notifications"`
+	Server        *ServerSettings  `json:"server"`
 }
 
 func (s *Server) handleSettings(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		if s.notify == nil {
-			writeJSON(w, http.StatusOK, settingsResponse{Notifications: notify.Defaults()})
-			return
-		}
-		settings := s.notify.Settings()
-		locked := s.notify.EnvLocked()
-		if locked.DiscordWebhook != "" {
-			settings.DiscordWebhook = "ENV:configured"
-		}
-		if locked.SlackWebhook != "" {
-			settings.SlackWebhook = "ENV:configured"
-		}
-		writeJSON(w, http.StatusOK, settingsResponse{Notifications: settings, Locked: locked})
+		writeJSON(w, http.StatusOK, s.settingsResponse())
 	case http.MethodPut:
 		s.requireWrite(http.HandlerFunc(s.handleSettingsUpdate)).ServeHTTP(w, r)
 	default:
@@ -41,24 +40,90 @@ func (s *Server) handleSettingsUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload settingsResponse
+	var payload settingsRequest
 	if err := decodeJSON(r, &payload); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
 		return
 	}
 
-	if s.notify == nil {
-		writeError(w, http.StatusServiceUnavailable, "settings unavailable", "notifications manager not initialized")
-		return
+	// Validate the server section before anything is changed, so a bad value
+	// there does not leave the notification settings half applied.
+	if payload.Server != nil {
+		if _, err := s.serverTunables().merge(*payload.Server, s.cfg.LockedSettings); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid settings", err.Error())
+			return
+		}
+	}
+
+	if payload.Notifications != nil {
+		if s.notify == nil {
+			writeError(w, http.StatusServiceUnavailable, "settings unavailable", "notifications manager not initialized")
+			return
+		}
+		if err := s.notify.Update(r.Context(), *payload.Notifications); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid settings", err.Error())
+			return
+		}
+		s.notify.Reload(context.Background())
+	}
+
+	if payload.Server != nil {
+		if err := s.updateTunables(r.Context(), *payload.Server); err != nil {
+			writeError(w, statusForError(err), "failed to save settings", err.Error())
+			return
+		}
+		s.requestLogger(r).Info().Interface("settings", s.serverTunables().settings()).Msg("Server settings changed")
+	}
+
+	writeJSON(w, http.StatusOK, s.settingsResponse())
+}
+
+func (s *Server) settingsResponse() settingsResponse {
+	resp := settingsResponse{
+		Notifications: notify.Defaults(),
+		Server:        s.serverTunables().settings(),
+		ServerLocked:  s.cfg.LockedSettings,
 	}
+	if s.notify != nil {
+		resp.Notifications, resp.Locked = s.maskedSettings()
+	}
+	return resp
+}
 
-	if err := s.notify.Update(context.Background(), payload.Notifications); err != nil {
-		writeError(w, http.StatusBadRequest, "invalid settings", err.Error())
+// handleLoggingSettings reports and changes log levels. Changes apply at once
+// and last until the process restarts.
+func (s *Server) handleLoggingSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, logging.Levels())
+	case http.MethodPut:
+		s.requireWrite(http.HandlerFunc(s.handleLoggingSettingsUpdate)).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+func (s *Server) handleLoggingSettingsUpdate(w http.ResponseWriter, r *http.Request) {
+	var payload logging.LevelSettings
+	if err := decodeJSON(r, &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	if err := logging.SetLevels(payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid log levels", err.Error())
 		return
 	}
 
-	s.notify.Reload(context.Background())
+	levels := logging.Levels()
+	s.requestLogger(r).Info().
+		Str("default", levels.Default).
+		Interface("components", levels.Components).
+		Msg("Log levels changed")
+	writeJSON(w, http.StatusOK, levels)
+}
 
+// maskedSettings returns current settings with env-provided endpoints hidden.
+func (s *Server) maskedSettings() (notify.Settings, notify.Settings) {
 	settings := s.notify.Settings()
 	locked := s.notify.EnvLocked()
 	if locked.DiscordWebhook != "" {
@@ -67,7 +132,27 @@ func (s *Server) handleSettingsUpdate(w http.ResponseWriter, r *http.Request) {
 	if locked.SlackWebhook != "" {
 		settings.SlackWebhook = "ENV:configured"
 	}
-	writeJSON(w, http.StatusOK, settingsResponse{Notifications: settings, Locked: locked})
+	if locked.AppriseURL != "" {
+		settings.AppriseURL = "ENV:configured"
+		locked.AppriseURL = "ENV:configured"
+	}
+	if locked.AppriseURLs != "" {
+		settings.AppriseURLs = "ENV:configured"
+		locked.AppriseURLs = "ENV:configured"
+	}
+	if locked.MatrixAccessToken != "" {
+		settings.MatrixAccessToken = "ENV:configured"
+		locked.MatrixAccessToken = "ENV:configured"
+	}
+	if locked.TeamsWebhook != "" {
+		settings.TeamsWebhook = "ENV:configured"
+		locked.TeamsWebhook = "ENV:configured"
+	}
+	if locked.MQTTPassword != "" {
+		settings.MQTTPassword = "ENV:configured"
+		locked.MQTTPassword = "ENV:configured"
+	}
+	return settings, locked
 }
 
 func (s *Server) handleNotificationsTest(w http.ResponseWriter, r *http.Request) {
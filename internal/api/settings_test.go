@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/notify"
+)
+
+func TestHandleSettings_MasksEnvSecrets(t *testing.T) {
+	secrets := map[string]string{
+		"APPRISE_URL":       "http://apprise:8000/notify/apprise-key-1234",
+		"APPRISE_URLS":      "tgram://123456:telegram-token-5678/987",
+		"TEAMS_WEBHOOK_URL": "https://example.webhook.office.com/webhookb2/teams-signing-key-9012",
+	}
+	for key, value := range secrets {
+		t.Setenv(key, value)
+	}
+
+	s := testServer()
+	s.logger = logging.Default()
+	s.notify = notify.NewManager(notify.NewStore("", nil, s.logger), nil, s.logger)
+	t.Cleanup(s.notify.Stop)
+	if err := s.notify.Load(context.Background()); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	s.handleSettings(w, httptest.NewRequest(http.MethodGet, "/api/settings", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for key, value := range secrets {
+		if strings.Contains(body, value) {
+			t.Errorf("response contains the raw %s: %s", key, body)
+		}
+	}
+	if !strings.Contains(body, "ENV:configured") {
+		t.Errorf("expected env-provided values to be marked configured: %s", body)
+	}
+}
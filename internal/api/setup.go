@@ -0,0 +1,267 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/notify"
+)
+
+const setupSettingsKey = "setup"
+
+// minWebTokenLength is the shortest web token the setup wizard accepts.
+const minWebTokenLength = 16
+
+// setupRecord is what a completed setup saves. Its presence in the state
+// database is what marks setup as done.
+type setupRecord struct {
+	Root        string    `json:"root,omitempty"`
+	WebToken    string    `json:"web_token"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+type setupStatus struct {
+	// Required is true until a web token exists, from the environment or a
+	// completed setup, or a user account does.
+	Required    bool       `json:"required"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Root        string     `json:"root"`
+	// RootLocked is set when BULWARK_ROOT pins the root path.
+	RootLocked              bool            `json:"root_locked"`
+	RootCheck               dependencyCheck `json:"root_check"`
+	Docker                  dependencyCheck `json:"docker"`
+	StatePersistent         bool            `json:"state_persistent"`
+	NotificationsConfigured bool            `json:"notifications_configured"`
+}
+
+type setupRequest struct {
+	Root string `json:"root"`
+	// WebToken is generated when empty.
+	WebToken      string          `json:"web_token"`
+	Notifications notify.Settings `json:"notifications"`
+}
+
+type setupResponse struct {
+	Root        string    `json:"root"`
+	WebToken    string    `json:"web_token"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// webToken returns the token that guards writes: BULWARK_WEB_TOKEN_WRITE or
+// BULWARK_WEB_TOKEN, or the one chosen during setup.
+func (s *Server) webToken() string {
+	s.setupMu.RLock()
+	defer s.setupMu.RUnlock()
+	if s.cfg.WebToken != "" {
+		return s.cfg.WebToken
+	}
+	return s.setup.WebToken
+}
+
+// rootPath returns the compose discovery root.
+func (s *Server) rootPath() string {
+	s.setupMu.RLock()
+	defer s.setupMu.RUnlock()
+	if s.setup.Root != "" && !s.cfg.RootLocked {
+		return s.setup.Root
+	}
+	return s.cfg.Root
+}
+
+// setupRequired reports whether the public setup endpoint may still run. An
+// existing account, e.g. the admin from BULWARK_ADMIN_USERNAME, already
+// guards the server, so setup is over once one exists.
+func (s *Server) setupRequired() bool {
+	if s.hasUsers.Load() {
+		return false
+	}
+	s.setupMu.RLock()
+	defer s.setupMu.RUnlock()
+	return s.cfg.WebToken == "" && s.setup.CompletedAt.IsZero()
+}
+
+// loadSetup restores the result of an earlier setup.
+func (s *Server) loadSetup(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	raw, err := s.store.GetSetting(ctx, setupSettingsKey)
+	if err != nil {
+		return
+	}
+	var record setupRecord
+	if err := json.Unmarshal([]byte(raw), &record); err != nil {
+		s.logger.Warn().Err(err).Msg("Ignoring unreadable setup record")
+		return
+	}
+	s.setupMu.Lock()
+	s.setup = record
+	s.setupMu.Unlock()
+}
+
+func (s *Server) handleSetup(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.handleSetupStatus(w, r)
+	case http.MethodPost:
+		s.handleSetupComplete(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+// handleSetupStatus reports what setup still needs. ?root= checks a candidate
+// root path instead of the current one.
+func (s *Server) handleSetupStatus(w http.ResponseWriter, r *http.Request) {
+	status := setupStatus{
+		Required:        s.setupRequired(),
+		Root:            s.rootPath(),
+		RootLocked:      s.cfg.RootLocked,
+		StatePersistent: s.store != nil,
+	}
+	if !status.Required {
+		s.setupMu.RLock()
+		if completed := s.setup.CompletedAt; !completed.IsZero() {
+			status.CompletedAt = &completed
+		}
+		s.setupMu.RUnlock()
+		writeJSON(w, http.StatusOK, status)
+		return
+	}
+
+	if root := r.URL.Query().Get("root"); root != "" {
+		status.Root = root
+	}
+	status.RootCheck = dependencyCheck{Status: checkOK}
+	if err := checkRoot(status.Root); err != nil {
+		status.RootCheck = dependencyCheck{Status: checkFailed, Error: err.Error()}
+	}
+	status.Docker = timedCheck(r.Context(), s.checkDocker)
+	if s.notify != nil {
+		status.NotificationsConfigured = s.notify.Settings().AnyChannelEnabled()
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleSetupComplete validates the whole request before saving anything.
+// Notification settings are saved first and the setup record last, so setup
+// only counts as done once every part of it has been written.
+func (s *Server) handleSetupComplete(w http.ResponseWriter, r *http.Request) {
+	s.setupRun.Lock()
+	defer s.setupRun.Unlock()
+
+	if !s.setupRequired() {
+		writeError(w, http.StatusConflict, "setup already completed", "")
+		return
+	}
+	if s.writeLimiter != nil && !s.writeLimiter.Allow() {
+		writeError(w, http.StatusTooManyRequests, "rate limited", "Too many write requests")
+		return
+	}
+	if s.store == nil || s.notify == nil {
+		writeError(w, http.StatusServiceUnavailable, "setup unavailable", "Setup needs a state database; set BULWARK_STATE_DB")
+		return
+	}
+
+	var req setupRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	root := s.rootPath()
+	if req.Root != "" && req.Root != root {
+		if s.cfg.RootLocked {
+			writeError(w, http.StatusBadRequest, "invalid root", "The root path is set by BULWARK_ROOT")
+			return
+		}
+		root = req.Root
+	}
+	if err := checkRoot(root); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid root", err.Error())
+		return
+	}
+
+	token := req.WebToken
+	if token == "" {
+		var err error
+		if token, err = generateToken(); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to generate token", err.Error())
+			return
+		}
+	} else if len(token) < minWebTokenLength {
+		writeError(w, http.StatusBadRequest, "invalid token", fmt.Sprintf("The web token must be at least %d characters", minWebTokenLength))
+		return
+	}
+
+	notifications := req.Notifications.Normalize()
+	if err := notifications.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid notifications", err.Error())
+		return
+	}
+	if !notifications.AnyChannelEnabled() {
+		writeError(w, http.StatusBadRequest, "invalid notifications", "Enable at least one notification channel")
+		return
+	}
+
+	if docker := timedCheck(r.Context(), s.checkDocker); docker.Status == checkFailed {
+		writeError(w, http.StatusServiceUnavailable, "Docker unreachable", docker.Error)
+		return
+	}
+
+	if err := s.notify.Update(r.Context(), notifications); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save notifications", err.Error())
+		return
+	}
+	record := setupRecord{WebToken: token, CompletedAt: time.Now().UTC()}
+	if root != s.cfg.Root {
+		record.Root = root
+	}
+	encoded, err := json.Marshal(record)
+	if err == nil {
+		err = s.store.SetSetting(r.Context(), setupSettingsKey, string(encoded))
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save setup", err.Error())
+		return
+	}
+
+	s.setupMu.Lock()
+	s.setup = record
+	s.setupMu.Unlock()
+	s.notify.Reload(context.Background())
+	s.planCache.Invalidate()
+	s.requestLogger(r).Info().Str("root", root).Msg("First-run setup completed")
+
+	s.startSession(w, accessAdmin, "")
+	writeJSON(w, http.StatusOK, setupResponse{Root: root, WebToken: token, CompletedAt: record.CompletedAt})
+}
+
+func checkRoot(root string) error {
+	if !filepath.IsAbs(root) {
+		return fmt.Errorf("root %q is not an absolute path", root)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return fmt.Errorf("root %q is not readable: %w", root, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("root %q is not a directory", root)
+	}
+	return nil
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/notify"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func setupTestServer(t *testing.T) *Server {
+	t.Helper()
+	store, err := state.NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	if err := store.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	s := testServer()
+	s.cfg.Root = t.TempDir()
+	s.logger = logging.Default()
+	s.store = store
+	s.notify = notify.NewManager(notify.NewStore("", nil, s.logger), nil, s.logger)
+	t.Cleanup(s.notify.Stop)
+	s.dockerPing = func(context.Context) error { return nil }
+	return s
+}
+
+func postSetup(s *Server, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	s.handleSetup(w, httptest.NewRequest(http.MethodPost, "/api/setup", strings.NewReader(body)))
+	return w
+}
+
+const setupNotifications = `"notifications":{"discord_enabled":true,"discord_webhook":"https://discord.com/api/webhooks/1/abc"}`
+
+func TestHandleSetup_Status(t *testing.T) {
+	s := setupTestServer(t)
+	s.dockerPing = func(context.Context) error { return errors.New("connection refused") }
+
+	w := httptest.NewRecorder()
+	s.handleSetup(w, httptest.NewRequest(http.MethodGet, "/api/setup?root=relative/path", nil))
+	var status setupStatus
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !status.Required || !status.StatePersistent {
+		t.Errorf("expected setup required with persistent state, got %+v", status)
+	}
+	if status.RootCheck.Status != checkFailed || status.Docker.Status != checkFailed {
+		t.Errorf("expected failed root and docker checks, got %+v / %+v", status.RootCheck, status.Docker)
+	}
+
+	s.cfg.WebToken = "from-the-environment"
+	w = httptest.NewRecorder()
+	s.handleSetup(w, httptest.NewRequest(http.MethodGet, "/api/setup", nil))
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if status.Required {
+		t.Error("setup should not be required when BULWARK_WEB_TOKEN is set")
+	}
+}
+
+func TestHandleSetup_Validation(t *testing.T) {
+	s := setupTestServer(t)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"relative root", `{"root":"data",` + setupNotifications + `}`, http.StatusBadRequest},
+		{"short token", `{"web_token":"short",` + setupNotifications + `}`, http.StatusBadRequest},
+		{"no channel", `{"notifications":{}}`, http.StatusBadRequest},
+		{"incomplete channel", `{"notifications":{"slack_enabled":true}}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := postSetup(s, tt.body); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	s.dockerPing = func(context.Context) error { return errors.New("connection refused") }
+	if w := postSetup(s, `{`+setupNotifications+`}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 when Docker is unreachable, got %d", w.Code)
+	}
+	if !s.setupRequired() {
+		t.Error("a failed setup must not complete")
+	}
+}
+
+func TestHandleSetup_Complete(t *testing.T) {
+	s := setupTestServer(t)
+	root := t.TempDir()
+
+	w := postSetup(s, `{"root":"`+root+`",`+setupNotifications+`}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp setupResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.WebToken) != 64 {
+		t.Errorf("expected a generated token, got %q", resp.WebToken)
+	}
+	if s.webToken() != resp.WebToken || s.rootPath() != root {
+		t.Errorf("setup not applied: token %q root %q", s.webToken(), s.rootPath())
+	}
+	if !s.notify.Settings().DiscordEnabled {
+		t.Error("expected notification settings to be saved")
+	}
+	if len(w.Result().Cookies()) == 0 {
+		t.Error("expected a session cookie")
+	}
+
+	if w := postSetup(s, `{`+setupNotifications+`}`); w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a second setup, got %d", w.Code)
+	}
+
+	// A restarted server picks the saved setup up again.
+	restarted := testServer()
+	restarted.logger = s.logger
+	restarted.store = s.store
+	restarted.loadSetup(context.Background())
+	if restarted.setupRequired() || restarted.webToken() != resp.WebToken || restarted.rootPath() != root {
+		t.Errorf("setup not restored: token %q root %q", restarted.webToken(), restarted.rootPath())
+	}
+}
+
+func TestHandleSetup_AdminFromEnvironment(t *testing.T) {
+	s := setupTestServer(t)
+	s.cfg.AdminUsername = "admin"
+	s.cfg.AdminPassword = "admin-password-1"
+	s.loadUsers(context.Background())
+
+	if s.setupRequired() {
+		t.Error("setup should not be required once an admin account exists")
+	}
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/setup",
+		strings.NewReader(`{"web_token":"attacker-chosen-token",`+setupNotifications+`}`)))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for an anonymous setup, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("expected no session for an anonymous setup")
+	}
+	if s.webToken() != "" {
+		t.Errorf("expected no web token, got %q", s.webToken())
+	}
+}
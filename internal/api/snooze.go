@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type snoozeRequest struct {
+	// Duration is a Go duration such as "12h", or days such as "3d".
+	Duration string `json:"duration"`
+}
+
+// handleSnooze serves a service's snooze: GET returns it, POST snoozes the
+// service's updates for a duration, and DELETE ends the snooze.
+func (s *Server) handleSnooze(w http.ResponseWriter, r *http.Request, serviceID string) {
+	if s.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "snoozing unavailable", "state persistence is disabled")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		snoozes, err := s.store.ListSnoozes(r.Context(), time.Now())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list snoozes", err.Error())
+			return
+		}
+		for _, snooze := range snoozes {
+			if snooze.ServiceID == serviceID {
+				writeJSON(w, http.StatusOK, snooze)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "not snoozed", serviceID)
+	case http.MethodPost:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleSnoozeUpdate(w, r, serviceID)
+		})).ServeHTTP(w, r)
+	case http.MethodDelete:
+		s.requireWrite(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := s.store.DeleteSnooze(r.Context(), serviceID); err != nil {
+				writeError(w, statusForError(err), "failed to clear snooze", err.Error())
+				return
+			}
+			s.planCache.Invalidate()
+			s.requestLogger(r).Info().Str("service_id", serviceID).Msg("Snooze cleared")
+			w.WriteHeader(http.StatusNoContent)
+		})).ServeHTTP(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+func (s *Server) handleSnoozeUpdate(w http.ResponseWriter, r *http.Request, serviceID string) {
+	var req snoozeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	duration, err := state.ParseSnoozeDuration(req.Duration)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid duration", err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	service, err := s.store.GetService(ctx, serviceID)
+	if err != nil {
+		writeError(w, statusForError(err), "service not found", err.Error())
+		return
+	}
+	snooze := &state.Snooze{ServiceID: service.ID, Until: time.Now().UTC().Add(duration)}
+	if err := s.store.SaveSnooze(ctx, snooze); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to snooze", err.Error())
+		return
+	}
+	s.planCache.Invalidate()
+	s.requestLogger(r).Info().Str("service", service.Name).Time("until", snooze.Until).Msg("Updates snoozed")
+	writeJSON(w, http.StatusCreated, snooze)
+}
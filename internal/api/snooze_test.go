@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestSnoozeService(t *testing.T) {
+	do := serviceServer(t)
+
+	if w := do(http.MethodPost, "/api/services/service-1/snooze", `{"duration":"forever"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid duration, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/services/missing/snooze", `{"duration":"2d"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown service, got %d", w.Code)
+	}
+	w := do(http.MethodPost, "/api/services/service-1/snooze", `{"duration":"2d"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var snooze state.Snooze
+	if err := json.NewDecoder(do(http.MethodGet, "/api/services/service-1/snooze", "").Body).Decode(&snooze); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if left := time.Until(snooze.Until); left < 47*time.Hour || left > 48*time.Hour {
+		t.Errorf("expected the snooze to end in 2 days, got %s", left)
+	}
+
+	if w := do(http.MethodDelete, "/api/services/service-1/snooze", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/services/service-1/snooze", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 once cleared, got %d", w.Code)
+	}
+}
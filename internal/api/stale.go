@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+// planSettingsKey stores the latest full plan, so a restarted server can
+// answer from it instead of re-planning against every registry at once.
+const planSettingsKey = "plan_cache"
+
+// persistPlan saves plan to the state database.
+func (s *Server) persistPlan(ctx context.Context, plan *planner.Plan) {
+	if s.store == nil {
+		return
+	}
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to encode plan for persistence")
+		return
+	}
+	if err := s.store.SetSetting(context.WithoutCancel(ctx), planSettingsKey, string(raw)); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to persist plan")
+	}
+}
+
+// loadPersistedPlan restores the plan a previous process saved. It is served
+// flagged stale until the first fresh plan is built. Its items carry no
+// discovered targets or services, so apply runs never use it.
+func (s *Server) loadPersistedPlan(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	raw, err := s.store.GetSetting(ctx, planSettingsKey)
+	if err != nil {
+		return
+	}
+	var plan planner.Plan
+	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+		s.logger.Warn().Err(err).Msg("Ignoring unreadable persisted plan")
+		return
+	}
+	s.planCache.SetStale(&plan)
+	recordUpdatesAvailable(&plan)
+	s.logger.Info().Time("generated_at", plan.GeneratedAt).Msg("Restored persisted plan; refreshing in the background")
+}
+
+// refreshStalePlan builds a fresh plan in the background to replace the
+// persisted one. Only one refresh runs at a time; a failed refresh is retried
+// by the next request.
+func (s *Server) refreshStalePlan() {
+	if !s.staleRefresh.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer s.staleRefresh.Store(false)
+		if _, err := s.sharedPlan(s.baseContext()); err != nil {
+			s.logger.Warn().Err(err).Msg("Background plan refresh failed")
+		}
+	}()
+}
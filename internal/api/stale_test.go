@@ -0,0 +1,46 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+func TestPersistedPlanServedStale(t *testing.T) {
+	ctx := context.Background()
+	s := setupTestServer(t)
+	generatedAt := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	s.persistPlan(ctx, &planner.Plan{
+		GeneratedAt: generatedAt,
+		UpdateCount: 1,
+		Items:       []planner.PlanItem{{ServiceName: "web", RemoteDigest: "sha256:new", UpdateAvailable: true}},
+	})
+
+	// A restarted server starts from an empty cache.
+	s.planCache.Invalidate()
+	s.loadPersistedPlan(ctx)
+	// Keep the background refresh from reaching for Docker.
+	s.staleRefresh.Store(true)
+
+	plan, err := s.getPlan(ctx, planRequest{})
+	if err != nil {
+		t.Fatalf("getPlan failed: %v", err)
+	}
+	if !plan.GeneratedAt.Equal(generatedAt) || len(plan.Items) != 1 || plan.Items[0].RemoteDigest != "sha256:new" {
+		t.Fatalf("expected the persisted plan, got %+v", plan)
+	}
+	info, ok := s.planCache.Info(plan)
+	if !ok || !info.Stale {
+		t.Errorf("expected the plan to be flagged stale, got %+v", info)
+	}
+	if _, ok := s.cachedPlan(ctx); ok {
+		t.Error("expected apply runs not to see the stale plan")
+	}
+
+	s.planCache.Set(&planner.Plan{GeneratedAt: time.Now()})
+	if _, ok := s.planCache.Stale(); ok {
+		t.Error("expected a fresh plan to replace the stale one")
+	}
+}
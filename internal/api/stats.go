@@ -0,0 +1,179 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+	// maxStatsUpdates caps the history rows one stats request aggregates.
+	maxStatsUpdates = 10000
+	// maxSLABreaches is how many of the latest breaches a response lists.
+	maxSLABreaches = 20
+)
+
+// statsResponse aggregates the step timings of the updates completed since
+// Since. Skipped updates are left out.
+type statsResponse struct {
+	Since    time.Time  `json:"since"`
+	Updates  int        `json:"updates"`
+	Failed   int        `json:"failed"`
+	Total    stepStats  `json:"total"`
+	Pull     stepStats  `json:"pull"`
+	Recreate stepStats  `json:"recreate"`
+	Probe    stepStats  `json:"probe"`
+	Downtime stepStats  `json:"downtime"`
+	SLA      *slaReport `json:"sla,omitempty"`
+}
+
+// stepStats summarizes the durations of one step. Updates that did not run
+// the step are not counted.
+type stepStats struct {
+	Count int   `json:"count"`
+	AvgMs int64 `json:"avg_ms"`
+	P50Ms int64 `json:"p50_ms"`
+	P95Ms int64 `json:"p95_ms"`
+	MaxMs int64 `json:"max_ms"`
+}
+
+// slaReport compares each update's downtime with BULWARK_DOWNTIME_SLA.
+type slaReport struct {
+	DowntimeMs int64 `json:"downtime_ms"`
+	Met        int   `json:"met"`
+	Breached   int   `json:"breached"`
+	// Compliance is the percentage of updates that met the SLA.
+	Compliance float64     `json:"compliance"`
+	Breaches   []slaBreach `json:"breaches"`
+}
+
+type slaBreach struct {
+	ID          int64     `json:"id"`
+	TargetID    string    `json:"target_id"`
+	ServiceName string    `json:"service_name"`
+	DowntimeMs  int64     `json:"downtime_ms"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// handleStats serves statsResponse for the last ?days= days (30 by default),
+// optionally for one ?target_id=.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	days := parseIntQuery(r, "days", defaultStatsDays)
+	if days < 1 || days > maxStatsDays {
+		writeError(w, http.StatusBadRequest, "invalid days", "days must be between 1 and 365")
+		return
+	}
+	since := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+
+	var results []state.UpdateResult
+	if s.store != nil {
+		var err error
+		results, err = s.store.ListUpdateHistory(r.Context(), state.HistoryQuery{
+			TargetID: r.URL.Query().Get("target_id"),
+			Since:    since,
+			Limit:    maxStatsUpdates,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "stats failed", err.Error())
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, buildStats(results, since, s.cfg.DowntimeSLA))
+}
+
+// buildStats aggregates results, newest first as the store returns them.
+func buildStats(results []state.UpdateResult, since time.Time, sla time.Duration) statsResponse {
+	resp := statsResponse{Since: since}
+	var total, pull, recreate, probe, downtime []int64
+	if sla > 0 {
+		resp.SLA = &slaReport{DowntimeMs: sla.Milliseconds(), Compliance: 100, Breaches: []slaBreach{}}
+	}
+
+	for _, result := range results {
+		if result.ResultCode.IsSkip() {
+			continue
+		}
+		resp.Updates++
+		if !result.Success {
+			resp.Failed++
+		}
+		if result.CompletedAt.After(result.StartedAt) {
+			total = append(total, result.CompletedAt.Sub(result.StartedAt).Milliseconds())
+		}
+		t := result.Timings
+		pull = appendPositive(pull, t.PullMs)
+		recreate = appendPositive(recreate, t.RecreateMs)
+		probe = appendPositive(probe, t.ProbeMs)
+		downtime = appendPositive(downtime, t.DowntimeMs)
+
+		if resp.SLA == nil {
+			continue
+		}
+		if t.DowntimeMs <= resp.SLA.DowntimeMs {
+			resp.SLA.Met++
+			continue
+		}
+		resp.SLA.Breached++
+		if len(resp.SLA.Breaches) < maxSLABreaches {
+			resp.SLA.Breaches = append(resp.SLA.Breaches, slaBreach{
+				ID:          result.ID,
+				TargetID:    result.TargetID,
+				ServiceName: result.ServiceName,
+				DowntimeMs:  t.DowntimeMs,
+				CompletedAt: result.CompletedAt,
+			})
+		}
+	}
+
+	resp.Total = summarize(total)
+	resp.Pull = summarize(pull)
+	resp.Recreate = summarize(recreate)
+	resp.Probe = summarize(probe)
+	resp.Downtime = summarize(downtime)
+	if resp.SLA != nil && resp.Updates > 0 {
+		resp.SLA.Compliance = float64(resp.SLA.Met) * 100 / float64(resp.Updates)
+	}
+	return resp
+}
+
+func appendPositive(values []int64, v int64) []int64 {
+	if v > 0 {
+		return append(values, v)
+	}
+	return values
+}
+
+// summarize returns the mean, nearest-rank percentiles and maximum of values.
+func summarize(values []int64) stepStats {
+	if len(values) == 0 {
+		return stepStats{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return stepStats{
+		Count: len(values),
+		AvgMs: sum / int64(len(values)),
+		P50Ms: percentile(values, 50),
+		P95Ms: percentile(values, 95),
+		MaxMs: values[len(values)-1],
+	}
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestBuildStats(t *testing.T) {
+	now := time.Date(2026, 3, 18, 7, 0, 0, 0, time.UTC)
+	update := func(seconds int, timings state.UpdateTimings, code state.ResultCode) state.UpdateResult {
+		return state.UpdateResult{
+			ServiceName: "web",
+			Success:     code == state.ResultSuccess,
+			ResultCode:  code,
+			Timings:     timings,
+			StartedAt:   now,
+			CompletedAt: now.Add(time.Duration(seconds) * time.Second),
+		}
+	}
+	results := []state.UpdateResult{
+		update(10, state.UpdateTimings{PullMs: 6000, RecreateMs: 2000, ProbeMs: 2000, DowntimeMs: 4000}, state.ResultSuccess),
+		update(20, state.UpdateTimings{PullMs: 12000, RecreateMs: 3000, DowntimeMs: 8000}, state.ResultProbeFailed),
+		update(30, state.UpdateTimings{PullMs: 30000}, state.ResultSuccess),
+		update(1, state.UpdateTimings{}, state.ResultSkippedLocked),
+	}
+
+	stats := buildStats(results, now.Add(-time.Hour), 5*time.Second)
+
+	if stats.Updates != 3 || stats.Failed != 1 {
+		t.Fatalf("expected 3 updates and 1 failure, got %d and %d", stats.Updates, stats.Failed)
+	}
+	if stats.Total != (stepStats{Count: 3, AvgMs: 20000, P50Ms: 20000, P95Ms: 30000, MaxMs: 30000}) {
+		t.Errorf("unexpected total: %+v", stats.Total)
+	}
+	if stats.Probe.Count != 1 || stats.Downtime.Count != 2 || stats.Downtime.MaxMs != 8000 {
+		t.Errorf("expected steps that did not run to be left out, got probe %+v and downtime %+v", stats.Probe, stats.Downtime)
+	}
+	if stats.SLA == nil || stats.SLA.Met != 2 || stats.SLA.Breached != 1 || len(stats.SLA.Breaches) != 1 {
+		t.Fatalf("unexpected SLA report: %+v", stats.SLA)
+	}
+	if got := stats.SLA.Compliance; got < 66.6 || got > 66.7 {
+		t.Errorf("expected 66.7%% compliance, got %.2f", got)
+	}
+
+	if stats := buildStats(nil, now, 0); stats.SLA != nil || stats.Total.Count != 0 {
+		t.Errorf("expected empty stats without an SLA, got %+v", stats)
+	}
+}
+
+func TestHandleStats(t *testing.T) {
+	s := setupTestServer(t)
+	ctx := context.Background()
+	if err := s.store.SaveTarget(ctx, &state.Target{ID: "target-1", Type: state.TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	if err := s.store.SaveService(ctx, &state.Service{ID: "service-1", TargetID: "target-1", Name: "web", Image: "nginx:latest", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	for _, age := range []time.Duration{time.Hour, 40 * 24 * time.Hour} {
+		completed := time.Now().Add(-age)
+		if err := s.store.SaveUpdateResult(ctx, &state.UpdateResult{
+			TargetID:     "target-1",
+			ServiceID:    "service-1",
+			ServiceName:  "web",
+			Success:      true,
+			ResultCode:   state.ResultSuccess,
+			ProbeResults: []state.ProbeResult{},
+			Timings:      state.UpdateTimings{PullMs: 1500, DowntimeMs: 700},
+			StartedAt:    completed.Add(-3 * time.Second),
+			CompletedAt:  completed,
+		}); err != nil {
+			t.Fatalf("SaveUpdateResult failed: %v", err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	s.handleStats(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var stats statsResponse
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if stats.Updates != 1 || stats.Pull.AvgMs != 1500 || stats.Downtime.MaxMs != 700 {
+		t.Errorf("expected the update from the last 30 days only, got %+v", stats)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleStats(w, httptest.NewRequest(http.MethodGet, "/api/stats?days=90", nil))
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil || stats.Updates != 2 {
+		t.Errorf("expected both updates in 90 days, got %+v (%v)", stats, err)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleStats(w, httptest.NewRequest(http.MethodGet, "/api/stats?days=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for days=0, got %d", w.Code)
+	}
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"github.com/itsmrshow/bulwark/internal/telemetry"
+)
+
+const (
+	// telemetryIDKey stores the random installation ID reports carry.
+	telemetryIDKey = "telemetry_id"
+	// telemetryStartDelay is how long after startup the first report is
+	// sent, so a restart loop does not send one report per start.
+	telemetryStartDelay = 15 * time.Minute
+	// telemetryDays is how far back update counts reach.
+	telemetryDays = 30
+)
+
+type telemetryResponse struct {
+	Enabled    bool             `json:"enabled"`
+	Endpoint   string           `json:"endpoint,omitempty"`
+	Interval   string           `json:"interval"`
+	LastSentAt *time.Time       `json:"last_sent_at,omitempty"`
+	Report     telemetry.Report `json:"report"`
+}
+
+// handleTelemetry shows whether telemetry is on and the report it would
+// send now, byte for byte, whether or not it is on.
+func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	resp := telemetryResponse{
+		Enabled:  s.telemetryActive(),
+		Endpoint: s.cfg.TelemetryURL,
+		Interval: s.telemetryInterval().String(),
+		Report:   s.telemetryReport(r.Context()),
+	}
+	s.telemetryMu.Lock()
+	if !s.telemetrySentAt.IsZero() {
+		sentAt := s.telemetrySentAt
+		resp.LastSentAt = &sentAt
+	}
+	s.telemetryMu.Unlock()
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// telemetryActive reports whether reports are sent: only when the operator
+// opted in and named an endpoint.
+func (s *Server) telemetryActive() bool {
+	return s.cfg.TelemetryEnabled && s.cfg.TelemetryURL != ""
+}
+
+func (s *Server) telemetryInterval() time.Duration {
+	if s.cfg.TelemetryInterval > 0 {
+		return s.cfg.TelemetryInterval
+	}
+	return telemetry.DefaultInterval
+}
+
+// telemetryLoop sends a report shortly after startup and then every
+// interval until ctx ends.
+func (s *Server) telemetryLoop(ctx context.Context) {
+	client := telemetry.NewClient(s.cfg.TelemetryURL)
+	timer := time.NewTimer(telemetryStartDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		report := s.telemetryReport(ctx)
+		if err := client.Send(ctx, report); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to send telemetry report")
+		} else {
+			s.telemetryMu.Lock()
+			s.telemetrySentAt = time.Now().UTC()
+			s.telemetryMu.Unlock()
+		}
+		timer.Reset(s.telemetryInterval())
+	}
+}
+
+// telemetryReport assembles the report from the cached plan and the update
+// history. It never builds a plan of its own.
+func (s *Server) telemetryReport(ctx context.Context) telemetry.Report {
+	report := telemetry.Report{
+		InstallID: s.telemetryID(ctx),
+		Version:   s.cfg.Version,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Profile:   s.cfg.Profile,
+	}
+
+	features := s.configFeatures()
+	plan, ok := s.planCache.Get()
+	if !ok {
+		plan, ok = s.planCache.Stale()
+	}
+	if ok {
+		targets := make(map[string]bool)
+		for _, item := range plan.Items {
+			targets[item.TargetID] = true
+		}
+		report.Targets = telemetry.Bucket(len(targets))
+		report.Services = telemetry.Bucket(len(plan.Items))
+		features = append(features, labelFeatures(plan)...)
+	}
+
+	var updates, succeeded int
+	if s.store != nil {
+		results, err := s.store.ListUpdateHistory(ctx, state.HistoryQuery{
+			Since: time.Now().Add(-telemetryDays * 24 * time.Hour),
+			Limit: maxStatsUpdates,
+		})
+		if err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to count updates for telemetry")
+		}
+		for _, result := range results {
+			if result.ResultCode.IsSkip() {
+				continue
+			}
+			updates++
+			if result.Success {
+				succeeded++
+			}
+		}
+	}
+	report.Updates = telemetry.Bucket(updates)
+	report.SuccessRate = telemetry.SuccessRate(succeeded, updates)
+	report.Features = telemetry.Features(features...)
+	return report
+}
+
+// telemetryID returns the installation ID, generating it on first use. It
+// is kept in the state database; without one it lasts for the process.
+func (s *Server) telemetryID(ctx context.Context) string {
+	s.telemetryMu.Lock()
+	defer s.telemetryMu.Unlock()
+	if s.telemetryInstallID != "" {
+		return s.telemetryInstallID
+	}
+	if s.store != nil {
+		if id, err := s.store.GetSetting(ctx, telemetryIDKey); err == nil && id != "" {
+			s.telemetryInstallID = id
+			return id
+		}
+	}
+	s.telemetryInstallID = telemetry.NewInstallID()
+	if s.store != nil {
+		if err := s.store.SetSetting(ctx, telemetryIDKey, s.telemetryInstallID); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to persist telemetry ID")
+		}
+	}
+	return s.telemetryInstallID
+}
+
+// configFeatures names the optional server features that are switched on.
+func (s *Server) configFeatures() []string {
+	cfg := s.cfg
+	var features []string
+	add := func(on bool, name string) {
+		if on {
+			features = append(features, name)
+		}
+	}
+	add(cfg.StateDB != "", "state_db")
+	add(cfg.MetricsEnabled, "metrics")
+	add(cfg.SessionJWT, "session_jwt")
+	add(cfg.ReadToken != "", "read_token")
+	add(cfg.WidgetToken != "", "widget")
+	add(cfg.IncrementalPlan, "incremental_plan")
+	add(cfg.ResourceSnapshots, "resource_snapshots")
+	add(cfg.SBOMEnabled, "sbom")
+	add(cfg.CleanupPolicy == cleanupDangling, "cleanup")
+	add(cfg.DowntimeSLA > 0, "downtime_sla")
+	add(len(cfg.CORSOrigins) > 0, "cors")
+	add(len(cfg.Registries) > 0, "registries")
+
+	if s.notify != nil {
+		settings := s.notify.Settings()
+		add(settings.AutoUpdateEnabled, "auto_update")
+		add(settings.DigestEnabled, "digest")
+		add(settings.DiscordEnabled, "discord")
+		add(settings.SlackEnabled, "slack")
+		add(settings.AppriseEnabled, "apprise")
+		add(settings.MatrixEnabled, "matrix")
+		add(settings.TeamsEnabled, "teams")
+		add(settings.MQTTEnabled, "mqtt")
+		add(settings.HADiscoveryEnabled, "ha_discovery")
+	}
+	return features
+}
+
+// labelFeatures names the label features the planned services use.
+func labelFeatures(plan *planner.Plan) []string {
+	var features []string
+	for _, item := range plan.Items {
+		if item.Probe.Type != "" && item.Probe.Type != state.ProbeTypeNone {
+			features = append(features, "probe_"+string(item.Probe.Type))
+		}
+		if item.Policy != "" {
+			features = append(features, "policy_"+string(item.Policy))
+		}
+		if item.Group != "" {
+			features = append(features, "groups")
+		}
+		if item.Build {
+			features = append(features, "build")
+		}
+	}
+	return features
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestHandleTelemetry(t *testing.T) {
+	s := setupTestServer(t)
+	s.cfg.StateDB = "/data/bulwark.db"
+	s.cfg.MetricsEnabled = true
+	s.cfg.Version = "1.2.0"
+	ctx := context.Background()
+
+	s.planCache.Set(&planner.Plan{Items: []planner.PlanItem{
+		{TargetID: "t1", TargetName: "media", ServiceName: "plex", Probe: state.ProbeConfig{Type: state.ProbeTypeHTTP}, Policy: state.PolicySafe},
+		{TargetID: "t1", TargetName: "media", ServiceName: "sonarr", Group: "home"},
+		{TargetID: "t2", TargetName: "db", ServiceName: "postgres", Probe: state.ProbeConfig{Type: state.ProbeTypeNone}},
+	}})
+	if err := s.store.SaveTarget(ctx, &state.Target{ID: "t1", Type: state.TargetTypeCompose, Name: "media", Path: "/srv/media/compose.yml", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	if err := s.store.SaveService(ctx, &state.Service{ID: "s1", TargetID: "t1", Name: "plex", Image: "plex:latest", Labels: state.DefaultLabels()}); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	for _, code := range []state.ResultCode{state.ResultSuccess, state.ResultSuccess, state.ResultProbeFailed, state.ResultSkippedLocked} {
+		if err := s.store.SaveUpdateResult(ctx, &state.UpdateResult{
+			TargetID:     "t1",
+			ServiceID:    "s1",
+			ServiceName:  "plex",
+			Success:      code == state.ResultSuccess,
+			ResultCode:   code,
+			ProbeResults: []state.ProbeResult{},
+			StartedAt:    time.Now().Add(-time.Hour),
+			CompletedAt:  time.Now().Add(-time.Hour),
+		}); err != nil {
+			t.Fatalf("SaveUpdateResult failed: %v", err)
+		}
+	}
+
+	get := func() telemetryResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleTelemetry(w, httptest.NewRequest(http.MethodGet, "/api/telemetry", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), "plex") || strings.Contains(w.Body.String(), "media") {
+			t.Errorf("report leaks names: %s", w.Body.String())
+		}
+		var resp telemetryResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		return resp
+	}
+
+	resp := get()
+	if resp.Enabled {
+		t.Error("expected telemetry to be off by default")
+	}
+	report := resp.Report
+	if report.InstallID == "" || report.Version != "1.2.0" || report.Targets != "1-5" || report.Services != "1-5" || report.Updates != "1-5" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.SuccessRate == nil || *report.SuccessRate != 70 {
+		t.Errorf("expected a 70%% success rate, got %v", report.SuccessRate)
+	}
+	want := []string{"groups", "metrics", "policy_safe", "probe_http", "state_db"}
+	for _, feature := range want {
+		found := false
+		for _, got := range report.Features {
+			found = found || got == feature
+		}
+		if !found {
+			t.Errorf("expected feature %q in %v", feature, report.Features)
+		}
+	}
+
+	// The installation ID survives in the state database.
+	s.telemetryInstallID = ""
+	if again := get(); again.Report.InstallID != report.InstallID {
+		t.Errorf("expected install ID %q to persist, got %q", report.InstallID, again.Report.InstallID)
+	}
+
+	s.cfg.TelemetryEnabled = true
+	if get().Enabled {
+		t.Error("expected telemetry to stay off without an endpoint")
+	}
+	s.cfg.TelemetryURL = "https://telemetry.example.com/report"
+	if resp := get(); !resp.Enabled || resp.Endpoint != s.cfg.TelemetryURL {
+		t.Errorf("expected telemetry on, got %+v", resp)
+	}
+}
+
+func TestLabelFeatures(t *testing.T) {
+	plan := &planner.Plan{Items: []planner.PlanItem{
+		{Probe: state.ProbeConfig{Type: state.ProbeTypeNone}, Build: true},
+		{Policy: state.PolicyNotify},
+	}}
+	if got, want := labelFeatures(plan), []string{"build", "policy_notify"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("labelFeatures() = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Cleanup policies for what an apply run removes after updating a service.
+const (
+	cleanupNone     = "none"
+	cleanupDangling = "dangling" // Prune dangling images left behind by pulls
+)
+
+const serverSettingsKey = "server_settings"
+
+// maxCheckConcurrency keeps a plan build from opening more registry
+// connections at once than any registry's rate limits tolerate.
+const maxCheckConcurrency = 64
+
+// ServerSettings are the server tunables /api/settings changes at runtime.
+// Durations are Go duration strings such as "5m". In an update, empty and
+// zero fields keep their current value.
+type ServerSettings struct {
+	PlanCacheTTL     string `json:"plan_cache_ttl,omitempty"`
+	DigestCacheTTL   string `json:"digest_cache_ttl,omitempty"`
+	LockTimeout      string `json:"lock_timeout,omitempty"`
+	CheckConcurrency int    `json:"check_concurrency,omitempty"`
+	CleanupPolicy    string `json:"cleanup_policy,omitempty"`
+}
+
+// settingEnv maps each ServerSettings field to the variable that pins it.
+var settingEnv = map[string]string{
+	"plan_cache_ttl":    "BULWARK_PLAN_CACHE_TTL",
+	"digest_cache_ttl":  "BULWARK_DIGEST_CACHE_TTL",
+	"lock_timeout":      "BULWARK_LOCK_TIMEOUT",
+	"check_concurrency": "BULWARK_CHECK_CONCURRENCY",
+	"cleanup_policy":    "BULWARK_CLEANUP_POLICY",
+}
+
+func envLockedSettings() []string {
+	var locked []string
+	for name, env := range settingEnv {
+		if os.Getenv(env) != "" {
+			locked = append(locked, name)
+		}
+	}
+	slices.Sort(locked)
+	return locked
+}
+
+// tunables is ServerSettings in parsed form.
+type tunables struct {
+	planCacheTTL     time.Duration
+	digestCacheTTL   time.Duration
+	lockTimeout      time.Duration
+	checkConcurrency int
+	cleanupPolicy    string
+}
+
+func tunablesFromConfig(cfg Config) tunables {
+	return tunables{
+		planCacheTTL:     cfg.PlanCacheTTL,
+		digestCacheTTL:   cfg.DigestCacheTTL,
+		lockTimeout:      cfg.LockTimeout,
+		checkConcurrency: cfg.CheckConcurrency,
+		cleanupPolicy:    cfg.CleanupPolicy,
+	}
+}
+
+func (t tunables) settings() ServerSettings {
+	return ServerSettings{
+		PlanCacheTTL:     t.planCacheTTL.String(),
+		DigestCacheTTL:   t.digestCacheTTL.String(),
+		LockTimeout:      t.lockTimeout.String(),
+		CheckConcurrency: t.checkConcurrency,
+		CleanupPolicy:    t.cleanupPolicy,
+	}
+}
+
+// merge returns t with the fields set in update applied. Fields named in
+// locked may only be sent unchanged.
+func (t tunables) merge(update ServerSettings, locked []string) (tunables, error) {
+	next := t
+	current := t.settings()
+	checkLocked := func(name, value, was string) error {
+		if value != was && slices.Contains(locked, name) {
+			return fmt.Errorf("%s is set by %s", name, settingEnv[name])
+		}
+		return nil
+	}
+
+	durations := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+		min   time.Duration
+	}{
+		{"plan_cache_ttl", update.PlanCacheTTL, &next.planCacheTTL, time.Second},
+		{"digest_cache_ttl", update.DigestCacheTTL, &next.digestCacheTTL, time.Second},
+		{"lock_timeout", update.LockTimeout, &next.lockTimeout, time.Second},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return t, fmt.Errorf("%s: %w", d.name, err)
+		}
+		if parsed < d.min {
+			return t, fmt.Errorf("%s must be at least %s", d.name, d.min)
+		}
+		if err := checkLocked(d.name, parsed.String(), (*d.dst).String()); err != nil {
+			return t, err
+		}
+		*d.dst = parsed
+	}
+
+	if update.CheckConcurrency != 0 {
+		if update.CheckConcurrency < 1 || update.CheckConcurrency > maxCheckConcurrency {
+			return t, fmt.Errorf("check_concurrency must be between 1 and %d", maxCheckConcurrency)
+		}
+		if err := checkLocked("check_concurrency", fmt.Sprint(update.CheckConcurrency), fmt.Sprint(current.CheckConcurrency)); err != nil {
+			return t, err
+		}
+		next.checkConcurrency = update.CheckConcurrency
+	}
+
+	if update.CleanupPolicy != "" {
+		switch update.CleanupPolicy {
+		case cleanupNone, cleanupDangling:
+		default:
+			return t, fmt.Errorf("cleanup_policy must be %q or %q", cleanupNone, cleanupDangling)
+		}
+		if err := checkLocked("cleanup_policy", update.CleanupPolicy, current.CleanupPolicy); err != nil {
+			return t, err
+		}
+		next.cleanupPolicy = update.CleanupPolicy
+	}
+	return next, nil
+}
+
+// serverTunables returns the active tunables.
+func (s *Server) serverTunables() tunables {
+	s.tunablesMu.RLock()
+	defer s.tunablesMu.RUnlock()
+	return s.tunables
+}
+
+// setTunables makes t active, pushing the values that live in other
+// components to them.
+func (s *Server) setTunables(t tunables) {
+	s.tunablesMu.Lock()
+	s.tunables = t
+	s.tunablesMu.Unlock()
+
+	if s.planCache != nil {
+		s.planCache.SetTTL(t.planCacheTTL)
+	}
+	if s.registry != nil {
+		s.registry.WithDigestTTL(t.digestCacheTTL)
+	}
+}
+
+// loadTunables applies settings saved through the API on top of the
+// configuration. Variables in the environment still win.
+func (s *Server) loadTunables(ctx context.Context) {
+	t := tunablesFromConfig(s.cfg)
+	if s.store != nil {
+		if raw, err := s.store.GetSetting(ctx, serverSettingsKey); err == nil {
+			var saved ServerSettings
+			if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+				s.logger.Warn().Err(err).Msg("Ignoring unreadable saved server settings")
+			} else if merged, err := t.merge(withoutLocked(saved, s.cfg.LockedSettings), nil); err != nil {
+				s.logger.Warn().Err(err).Msg("Ignoring invalid saved server settings")
+			} else {
+				t = merged
+			}
+		}
+	}
+	s.setTunables(t)
+}
+
+// updateTunables validates update, applies it and saves the result.
+func (s *Server) updateTunables(ctx context.Context, update ServerSettings) error {
+	next, err := s.serverTunables().merge(update, s.cfg.LockedSettings)
+	if err != nil {
+		return err
+	}
+	if s.store != nil {
+		encoded, err := json.Marshal(next.settings())
+		if err != nil {
+			return err
+		}
+		if err := s.store.SetSetting(ctx, serverSettingsKey, string(encoded)); err != nil {
+			return err
+		}
+	}
+	s.setTunables(next)
+	return nil
+}
+
+// withoutLocked clears the fields of settings pinned by the environment.
+func withoutLocked(settings ServerSettings, locked []string) ServerSettings {
+	for _, name := range locked {
+		switch name {
+		case "plan_cache_ttl":
+			settings.PlanCacheTTL = ""
+		case "digest_cache_ttl":
+			settings.DigestCacheTTL = ""
+		case "lock_timeout":
+			settings.LockTimeout = ""
+		case "check_concurrency":
+			settings.CheckConcurrency = 0
+		case "cleanup_policy":
+			settings.CleanupPolicy = ""
+		}
+	}
+	return settings
+}
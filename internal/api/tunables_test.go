@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+)
+
+func TestTunablesMerge(t *testing.T) {
+	current := tunables{
+		planCacheTTL:     5 * time.Minute,
+		digestCacheTTL:   10 * time.Minute,
+		lockTimeout:      5 * time.Minute,
+		checkConcurrency: 10,
+		cleanupPolicy:    cleanupNone,
+	}
+
+	next, err := current.merge(ServerSettings{LockTimeout: "90s", CheckConcurrency: 4, CleanupPolicy: cleanupDangling}, nil)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	want := current
+	want.lockTimeout = 90 * time.Second
+	want.checkConcurrency = 4
+	want.cleanupPolicy = cleanupDangling
+	if next != want {
+		t.Errorf("merge() = %+v, want %+v", next, want)
+	}
+
+	invalid := []ServerSettings{
+		{PlanCacheTTL: "soon"},
+		{DigestCacheTTL: "500ms"},
+		{CheckConcurrency: maxCheckConcurrency + 1},
+		{CheckConcurrency: -1},
+		{CleanupPolicy: "everything"},
+	}
+	for _, update := range invalid {
+		if _, err := current.merge(update, nil); err == nil {
+			t.Errorf("merge(%+v): expected error", update)
+		}
+	}
+}
+
+func TestTunablesMerge_Locked(t *testing.T) {
+	current := tunables{lockTimeout: 5 * time.Minute, checkConcurrency: 10}
+	locked := []string{"lock_timeout"}
+
+	if _, err := current.merge(ServerSettings{LockTimeout: "1m"}, locked); err == nil || !strings.Contains(err.Error(), "BULWARK_LOCK_TIMEOUT") {
+		t.Fatalf("expected locked error, got %v", err)
+	}
+	// Sending the current value back, as a form does, is not a change.
+	if _, err := current.merge(ServerSettings{LockTimeout: "300s", CheckConcurrency: 2}, locked); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHandleSettings_UpdatesServerSettings(t *testing.T) {
+	s := testServer()
+	s.cfg.ReadOnly = false
+	s.logger = logging.Default()
+	s.setTunables(tunablesFromConfig(Config{}.WithDefaults()))
+
+	put := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.handleSettings(w, httptest.NewRequest(http.MethodPut, "/api/settings", strings.NewReader(body)))
+		return w
+	}
+
+	if w := put(`{"server":{"plan_cache_ttl":"-1m"}}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	w := put(`{"server":{"plan_cache_ttl":"2m","check_concurrency":3}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp settingsResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Server.PlanCacheTTL != "2m0s" || resp.Server.CheckConcurrency != 3 {
+		t.Errorf("unexpected server settings %+v", resp.Server)
+	}
+	if s.planCache.ttl != 2*time.Minute {
+		t.Errorf("plan cache TTL not applied: %s", s.planCache.ttl)
+	}
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// User roles. Viewers read, operators also apply and roll back, admins also
+// manage accounts.
+const (
+	roleViewer   = "viewer"
+	roleOperator = "operator"
+	roleAdmin    = "admin"
+)
+
+var roleAccess = map[string]accessLevel{
+	roleViewer:   accessRead,
+	roleOperator: accessWrite,
+	roleAdmin:    accessAdmin,
+}
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]{0,63}$`)
+
+type createUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// updateUserRequest changes a user's role, resets their password, or both.
+type updateUserRequest struct {
+	Role     string `json:"role"`
+	Password string `json:"password"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// loadUsers notes whether any account exists and creates the first admin
+// from BULWARK_ADMIN_USERNAME and BULWARK_ADMIN_PASSWORD when none does.
+func (s *Server) loadUsers(ctx context.Context) {
+	if s.store == nil {
+		return
+	}
+	users, err := s.store.ListUsers(ctx)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to load user accounts")
+		return
+	}
+	if len(users) > 0 || s.cfg.AdminUsername == "" {
+		s.hasUsers.Store(len(users) > 0)
+		return
+	}
+
+	user, err := newUser(s.cfg.AdminUsername, s.cfg.AdminPassword, roleAdmin)
+	if err == nil {
+		err = s.store.SaveUser(ctx, user)
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to create the admin account from BULWARK_ADMIN_USERNAME")
+		return
+	}
+	s.hasUsers.Store(true)
+	s.logger.Info().Str("username", user.Username).Msg("Created admin account")
+}
+
+// userSessionAccess re-checks a user's session against the account, so role
+// changes, password resets and deletions apply to sessions already issued.
+func (s *Server) userSessionAccess(ctx context.Context, sess session) accessLevel {
+	if s.store == nil {
+		return accessNone
+	}
+	user, err := s.store.GetUser(ctx, sess.username)
+	if err != nil {
+		return accessNone
+	}
+	if sess.issued.Before(user.PasswordChangedAt) {
+		return accessNone
+	}
+	return roleAccess[user.Role]
+}
+
+// authenticate checks a username and password.
+func (s *Server) authenticate(ctx context.Context, username, password string) (*state.User, error) {
+	if s.store == nil {
+		return nil, errors.New("user accounts need a state database")
+	}
+	user, err := s.store.GetUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyPassword(password, user.PasswordHash); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		users, err := s.store.ListUsers(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to list users", err.Error())
+			return
+		}
+		if users == nil {
+			users = []state.User{}
+		}
+		writeJSON(w, http.StatusOK, users)
+	case http.MethodPost:
+		s.handleCreateUser(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+func (s *Server) handleCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	user, err := newUser(req.Username, req.Password, req.Role)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user", err.Error())
+		return
+	}
+	if _, err := s.store.GetUser(r.Context(), user.Username); err == nil {
+		writeError(w, http.StatusConflict, "user exists", user.Username)
+		return
+	}
+	if err := s.store.SaveUser(r.Context(), user); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save user", err.Error())
+		return
+	}
+	s.hasUsers.Store(true)
+	s.requestLogger(r).Info().Str("username", user.Username).Str("role", user.Role).Msg("User created")
+	writeJSON(w, http.StatusCreated, user)
+}
+
+// handleUser updates or deletes the user named in /api/users/{username}.
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	username := strings.TrimPrefix(r.URL.Path, "/api/users/")
+	user, err := s.store.GetUser(r.Context(), username)
+	if err != nil {
+		writeError(w, statusForError(err), "user not found", err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var req updateUserRequest
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+			return
+		}
+		if req.Role == "" && req.Password == "" {
+			writeError(w, http.StatusBadRequest, "invalid request", "Set role, password or both")
+			return
+		}
+		if req.Role != "" {
+			if _, ok := roleAccess[req.Role]; !ok {
+				writeError(w, http.StatusBadRequest, "invalid role", "Role must be viewer, operator or admin")
+				return
+			}
+			if user.Role == roleAdmin && req.Role != roleAdmin && s.lastAdmin(r.Context()) {
+				writeError(w, http.StatusConflict, "last admin", "Make another user an admin first")
+				return
+			}
+			user.Role = req.Role
+		}
+		if req.Password != "" {
+			if err := setPassword(user, req.Password); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid password", err.Error())
+				return
+			}
+		}
+		if err := s.store.SaveUser(r.Context(), user); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to save user", err.Error())
+			return
+		}
+		s.requestLogger(r).Info().Str("username", user.Username).Str("role", user.Role).Bool("reset", req.Password != "").Msg("User updated")
+		writeJSON(w, http.StatusOK, user)
+	case http.MethodDelete:
+		if user.Role == roleAdmin && s.lastAdmin(r.Context()) {
+			writeError(w, http.StatusConflict, "last admin", "Make another user an admin first")
+			return
+		}
+		if err := s.store.DeleteUser(r.Context(), username); err != nil {
+			writeError(w, statusForError(err), "failed to delete user", err.Error())
+			return
+		}
+		if users, err := s.store.ListUsers(r.Context()); err == nil {
+			s.hasUsers.Store(len(users) > 0)
+		}
+		s.requestLogger(r).Info().Str("username", username).Msg("User deleted")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+	}
+}
+
+// handleChangePassword lets a logged-in user change their own password.
+// Their other sessions end; this one is replaced by a fresh session.
+func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	c := s.credentials(r)
+	if c.username == "" || c.access == accessNone {
+		writeError(w, http.StatusUnauthorized, "login required", "Login with a username and password")
+		return
+	}
+	var req changePasswordRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	user, err := s.authenticate(r.Context(), c.username, req.CurrentPassword)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid credentials", "The current password is wrong")
+		return
+	}
+	if err := setPassword(user, req.NewPassword); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid password", err.Error())
+		return
+	}
+	if err := s.store.SaveUser(r.Context(), user); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save user", err.Error())
+		return
+	}
+	s.requestLogger(r).Info().Str("username", user.Username).Msg("Password changed")
+
+	s.startSession(w, roleAccess[user.Role], user.Username)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Password changed",
+	})
+}
+
+// lastAdmin reports whether at most one admin account exists.
+func (s *Server) lastAdmin(ctx context.Context) bool {
+	users, err := s.store.ListUsers(ctx)
+	if err != nil {
+		return true
+	}
+	admins := 0
+	for _, user := range users {
+		if user.Role == roleAdmin {
+			admins++
+		}
+	}
+	return admins <= 1
+}
+
+func newUser(username, password, role string) (*state.User, error) {
+	if !usernamePattern.MatchString(username) {
+		return nil, errors.New("usernames are 1-64 letters, digits, '.', '_', '@' or '-'")
+	}
+	if _, ok := roleAccess[role]; !ok {
+		return nil, errors.New("role must be viewer, operator or admin")
+	}
+	user := &state.User{Username: username, Role: role}
+	if err := setPassword(user, password); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// setPassword hashes password into user and ends the user's sessions.
+func setPassword(user *state.User, password string) error {
+	if err := checkPassword(password); err != nil {
+		return err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	user.PasswordChangedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPasswordHash(t *testing.T) {
+	hash, err := hashPassword("correct horse battery")
+	if err != nil {
+		t.Fatalf("hashPassword failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$") {
+		t.Fatalf("unexpected hash format: %s", hash)
+	}
+	if err := verifyPassword("correct horse battery", hash); err != nil {
+		t.Errorf("expected the password to verify: %v", err)
+	}
+	if err := verifyPassword("wrong horse battery", hash); err != errPasswordMismatch {
+		t.Errorf("expected a mismatch, got %v", err)
+	}
+	if err := verifyPassword("correct horse battery", "$2a$10$bcrypt"); err == nil {
+		t.Error("expected an unsupported hash to fail")
+	}
+}
+
+// accountsServer returns a server with a state database whose Handler is
+// ready to serve, and a helper that sends requests to it.
+func accountsServer(t *testing.T) (*Server, func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder) {
+	t.Helper()
+	s := setupTestServer(t)
+	s.cfg.ReadOnly = false
+	s.cfg.WebToken = "write-token-secret"
+	s.cfg.AdminUsername = "admin"
+	s.cfg.AdminPassword = "admin-password-1"
+	s.loadUsers(context.Background())
+	h := s.Handler()
+
+	do := func(method, path, body string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	return s, do
+}
+
+func login(t *testing.T, do func(string, string, string, *http.Cookie) *httptest.ResponseRecorder, username, password string) *http.Cookie {
+	t.Helper()
+	w := do(http.MethodPost, "/api/login", `{"username":"`+username+`","password":"`+password+`"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("login %s: expected 200, got %d: %s", username, w.Code, w.Body.String())
+	}
+	return w.Result().Cookies()[0]
+}
+
+func TestUserAccounts(t *testing.T) {
+	s, do := accountsServer(t)
+	if !s.hasUsers.Load() {
+		t.Fatal("expected the admin account from the environment")
+	}
+
+	if w := do(http.MethodPost, "/api/login", `{"username":"admin","password":"wrong-password-1"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong password, got %d", w.Code)
+	}
+	admin := login(t, do, "admin", "admin-password-1")
+
+	if w := do(http.MethodGet, "/api/users", "", nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/users", `{"username":"kid","password":"short","role":"viewer"}`, admin); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a short password, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/users", `{"username":"kid","password":"viewer-password","role":"viewer"}`, admin); w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/api/users", `{"username":"kid","password":"viewer-password","role":"viewer"}`, admin); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a duplicate, got %d", w.Code)
+	}
+
+	w := do(http.MethodGet, "/api/users", "", admin)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "argon2id") {
+		t.Fatalf("expected users without password hashes, got %d: %s", w.Code, w.Body.String())
+	}
+
+	viewer := login(t, do, "kid", "viewer-password")
+	if w := do(http.MethodPost, "/api/rollback", `{}`, viewer); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a viewer to be refused writes, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/api/users", "", viewer); w.Code != http.StatusForbidden {
+		t.Fatalf("expected a viewer to be refused user management, got %d", w.Code)
+	}
+	healthOf := func(cookie *http.Cookie) healthResponse {
+		var resp healthResponse
+		_ = json.NewDecoder(do(http.MethodGet, "/api/health", "", cookie).Body).Decode(&resp)
+		return resp
+	}
+	if health := healthOf(viewer); health.User != "kid" || health.Access != "read" || !health.Accounts {
+		t.Errorf("unexpected health for a viewer: %+v", health)
+	}
+
+	// A role change applies to the session already issued.
+	if w := do(http.MethodPut, "/api/users/kid", `{"role":"operator"}`, admin); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if got := healthOf(viewer).Access; got != "write" {
+		t.Errorf("expected the promoted session to write, got %s", got)
+	}
+
+	// A password reset ends the user's sessions.
+	if w := do(http.MethodPut, "/api/users/kid", `{"password":"reset-password-1"}`, admin); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if health := healthOf(viewer); health.User != "" || health.Access != "read" {
+		t.Errorf("expected the reset to end the session, got %+v", health)
+	}
+
+	if w := do(http.MethodDelete, "/api/users/admin", "", admin); w.Code != http.StatusConflict {
+		t.Fatalf("expected the last admin to be kept, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/users/kid", "", admin); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/users/kid", "", admin); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	_, do := accountsServer(t)
+	old := login(t, do, "admin", "admin-password-1")
+
+	if w := do(http.MethodPost, "/api/account/password", `{"current_password":"nope","new_password":"new-password-12"}`, old); w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong current password, got %d", w.Code)
+	}
+	w := do(http.MethodPost, "/api/account/password", `{"current_password":"admin-password-1","new_password":"new-password-12"}`, old)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	fresh := w.Result().Cookies()[0]
+
+	if w := do(http.MethodGet, "/api/users", "", fresh); w.Code != http.StatusOK {
+		t.Errorf("expected the new session to work, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/login", `{"username":"admin","password":"admin-password-1"}`, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the old password to be refused, got %d", w.Code)
+	}
+	login(t, do, "admin", "new-password-12")
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type tagChangeRequest struct {
+	Tag string `json:"tag"`
+}
+
+type tagChangeResponse struct {
+	Result *state.UpdateResult `json:"result"`
+}
+
+// handleServiceTag switches a compose service to another tag of its image:
+// POST /api/services/{id}/tag with {"tag": "1.27"}. The change runs like an
+// update, probes and rollback included, and answers with its history entry.
+func (s *Server) handleServiceTag(w http.ResponseWriter, r *http.Request, serviceID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	var req tagChangeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+	req.Tag = strings.TrimSpace(req.Tag)
+	if !tagPattern.MatchString(req.Tag) {
+		writeError(w, http.StatusBadRequest, "invalid tag", req.Tag)
+		return
+	}
+
+	ctx := r.Context()
+	targets, err := s.discoverTargets(ctx, "")
+	if err != nil {
+		writeError(w, statusForError(err), "discovery failed", err.Error())
+		return
+	}
+	target, service := findDiscoveredService(targets, serviceID)
+	if service == nil {
+		writeError(w, http.StatusNotFound, "service not found", serviceID)
+		return
+	}
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create Docker client", err.Error())
+		return
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	ctx, cancel := withTimeout(ctx, s.cfg.ServiceUpdateTimeout)
+	defer cancel()
+	exec := executor.NewExecutor(dockerClient, policy.NewEngine(s.logger), s.store, s.logger, false).
+		WithLockManager(s.locks).
+		WithLockTimeout(s.serverTunables().lockTimeout).
+		WithDiskSpaceCheck(s.diskSpaceChecker(ctx, dockerClient, s.logger)).
+		WithPullScheduler(s.pulls).
+		WithWatchdog(s.watchdog).
+		WithTrigger(state.TriggerManualUI, s.actor(r))
+	exec = s.withResourceSnapshots(exec)
+	result, err := exec.ChangeTag(ctx, target, service, req.Tag)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "version change not possible", err.Error())
+		return
+	}
+
+	s.planCache.Invalidate()
+	if s.digestMemory != nil {
+		s.digestMemory.Forget(service.Image)
+	}
+	s.requestLogger(r).Info().
+		Str("service", service.Name).
+		Str("tag", req.Tag).
+		Bool("success", result.Success).
+		Msg("Version change finished")
+	writeJSON(w, http.StatusOK, tagChangeResponse{Result: result})
+}
+
+// findDiscoveredService returns the service with id and its target.
+func findDiscoveredService(targets []state.Target, id string) (*state.Target, *state.Service) {
+	for i := range targets {
+		for j := range targets[i].Services {
+			if targets[i].Services[j].ID == id {
+				return &targets[i], &targets[i].Services[j]
+			}
+		}
+	}
+	return nil, nil
+}
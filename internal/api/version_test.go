@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestServiceTagValidation(t *testing.T) {
+	do := serviceServer(t)
+
+	if w := do(http.MethodGet, "/api/services/service-1/tag", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/services/service-1/tag", `{"tag":"bad tag"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid tag, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/api/services/service-1/tag", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a tag, got %d", w.Code)
+	}
+}
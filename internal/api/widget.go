@@ -0,0 +1,59 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+)
+
+// widgetResponse is a flat status summary for dashboard widgets such as
+// Homepage's customapi widget, which map top-level fields by name.
+type widgetResponse struct {
+	Updates       int        `json:"updates"`
+	Allowed       int        `json:"allowed"`
+	Services      int        `json:"services"`
+	Failures      int        `json:"failures"`
+	Rollbacks     int        `json:"rollbacks"`
+	LastRunStatus string     `json:"last_run_status"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+}
+
+// handleWidget serves widgetResponse. It is open like the other read
+// endpoints unless BULWARK_WIDGET_TOKEN is set, in which case the token must
+// be sent as a Bearer token or a token query parameter.
+func (s *Server) handleWidget(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed", "")
+		return
+	}
+
+	if s.cfg.WidgetToken != "" {
+		token := bearerToken(r.Header.Get("Authorization"))
+		if token == "" {
+			token = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.WidgetToken)) != 1 {
+			writeError(w, http.StatusUnauthorized, "invalid token", "Provide the widget token as a Bearer token or ?token=")
+			return
+		}
+	}
+
+	overview := s.buildOverview(r.Context())
+	resp := widgetResponse{
+		Updates:       overview.UpdatesAvailable,
+		Services:      overview.ManagedServices,
+		Failures:      overview.Failures,
+		Rollbacks:     overview.Rollbacks,
+		LastRunStatus: "none",
+	}
+	if plan, ok := s.planCache.Get(); ok {
+		resp.Allowed = plan.AllowedCount
+	}
+	if overview.LastRun != nil {
+		resp.LastRunStatus = overview.LastRun.Status
+		completedAt := overview.LastRun.CompletedAt
+		resp.LastRunAt = &completedAt
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+func TestHandleWidget(t *testing.T) {
+	s := testServer()
+	s.planCache.Set(&planner.Plan{GeneratedAt: time.Now(), UpdateCount: 3, AllowedCount: 2, ServiceCount: 7})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/widget", nil)
+	w := httptest.NewRecorder()
+	s.handleWidget(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var resp widgetResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Updates != 3 || resp.Allowed != 2 || resp.Services != 7 {
+		t.Errorf("unexpected counts: %+v", resp)
+	}
+	if resp.LastRunStatus != "none" || resp.LastRunAt != nil {
+		t.Errorf("expected no last run without history, got %+v", resp)
+	}
+}
+
+func TestHandleWidget_Token(t *testing.T) {
+	s := testServer()
+	s.cfg.WidgetToken = "secret"
+	s.planCache.Set(&planner.Plan{GeneratedAt: time.Now()})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/widget", nil)
+	w := httptest.NewRecorder()
+	s.handleWidget(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/widget?token=secret", nil)
+	w = httptest.NewRecorder()
+	s.handleWidget(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with query token, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/widget", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	s.handleWidget(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with bearer token, got %d", w.Code)
+	}
+}
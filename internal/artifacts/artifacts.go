@@ -0,0 +1,285 @@
+// Package artifacts keeps the files a run produces while it applies
+// updates, so a failed update can be looked into afterwards: the compose
+// output of each service, its probe transcript and the override file a
+// rollback recreated it from.
+//
+// Each run has a directory under the store's root holding its artifacts and
+// an index.jsonl with an entry per artifact.
+package artifacts
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of artifacts.
+const (
+	KindComposeOutput    = "compose_output"
+	KindProbeTranscript  = "probe_transcript"
+	KindRollbackOverride = "rollback_override"
+)
+
+// MaxSize bounds an artifact. Larger content keeps its end, where the
+// error that failed an update usually is.
+const MaxSize = 1 << 20
+
+const indexFile = "index.jsonl"
+
+// ErrNotFound marks an artifact that does not exist.
+var ErrNotFound = errors.New("artifact not found")
+
+// Artifact describes one file kept for a run.
+type Artifact struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Target    string    `json:"target,omitempty"`
+	Service   string    `json:"service,omitempty"`
+	Size      int       `json:"size"`
+	Truncated bool      `json:"truncated,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store keeps artifacts in a directory per run under Dir.
+type Store struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewStore returns a store keeping artifacts under dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// unsafeName matches what may not appear in a run ID or artifact name.
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Save keeps content as an artifact of run. The artifact's name is made
+// safe for a file name and unique within the run; the saved artifact is
+// returned.
+func (s *Store) Save(runID string, artifact Artifact, content []byte) (*Artifact, error) {
+	dir, err := s.runDir(runID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	existing, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, a := range existing {
+		taken[a.Name] = true
+	}
+
+	name := strings.Trim(unsafeName.ReplaceAllString(artifact.Name, "_"), "._")
+	if name == "" {
+		name = artifact.Kind
+	}
+	base, ext := strings.TrimSuffix(name, filepath.Ext(name)), filepath.Ext(name)
+	for n := 2; taken[name] || name == indexFile; n++ {
+		name = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+	artifact.Name = name
+
+	if len(content) > MaxSize {
+		content = content[len(content)-MaxSize:]
+		artifact.Truncated = true
+	}
+	artifact.Size = len(content)
+	artifact.CreatedAt = time.Now().UTC()
+	if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write artifact: %w", err)
+	}
+	if err := appendIndex(dir, &artifact); err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+// List returns the artifacts of run in the order they were saved. A run
+// without artifacts has none.
+func (s *Store) List(runID string) ([]Artifact, error) {
+	dir, err := s.runDir(runID)
+	if err != nil {
+		return nil, err
+	}
+	artifacts, err := readIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	if artifacts == nil {
+		artifacts = []Artifact{}
+	}
+	return artifacts, nil
+}
+
+// Read returns the artifact name of run and its content.
+func (s *Store) Read(runID, name string) (*Artifact, []byte, error) {
+	artifacts, err := s.List(runID)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range artifacts {
+		if artifacts[i].Name != name {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(s.Dir, runID, name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read artifact: %w", err)
+		}
+		return &artifacts[i], content, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Prune removes the artifacts of all but the keep most recent runs and
+// returns how many runs it removed.
+func (s *Store) Prune(keep int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list artifact directory: %w", err)
+	}
+	type run struct {
+		name     string
+		modified time.Time
+	}
+	var runs []run
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		runs = append(runs, run{name: entry.Name(), modified: info.ModTime()})
+	}
+	if len(runs) <= keep {
+		return 0, nil
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].modified.After(runs[j].modified) })
+	removed := 0
+	for _, r := range runs[max(keep, 0):] {
+		if err := os.RemoveAll(filepath.Join(s.Dir, r.name)); err != nil {
+			return removed, fmt.Errorf("failed to remove artifacts of run %s: %w", r.name, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *Store) runDir(runID string) (string, error) {
+	if runID == "" || unsafeName.MatchString(runID) || strings.Trim(runID, ".") == "" {
+		return "", fmt.Errorf("invalid run id %q", runID)
+	}
+	return filepath.Join(s.Dir, runID), nil
+}
+
+func readIndex(dir string) ([]Artifact, error) {
+	file, err := os.Open(filepath.Join(dir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifact index: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var artifacts []Artifact
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var artifact Artifact
+		if err := json.Unmarshal([]byte(line), &artifact); err != nil {
+			// A torn line from a crash must not hide the other artifacts.
+			continue
+		}
+		artifacts = append(artifacts, artifact)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read artifact index: %w", err)
+	}
+	return artifacts, nil
+}
+
+func appendIndex(dir string, artifact *Artifact) error {
+	line, err := json.Marshal(artifact)
+	if err != nil {
+		return fmt.Errorf("failed to encode artifact: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(dir, indexFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open artifact index: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write artifact index: %w", err)
+	}
+	return nil
+}
+
+// Recorder saves the artifacts of one service of a run.
+type Recorder struct {
+	store   *Store
+	runID   string
+	target  string
+	service string
+}
+
+// Recorder returns a recorder saving artifacts of service in target for run.
+func (s *Store) Recorder(runID, target, service string) *Recorder {
+	return &Recorder{store: s, runID: runID, target: target, service: service}
+}
+
+// Record saves content as an artifact of kind. name is prefixed with the
+// target and service, e.g. app-web-update.log.
+func (r *Recorder) Record(kind, name string, content []byte) error {
+	_, err := r.store.Save(r.runID, Artifact{
+		Name:    fmt.Sprintf("%s-%s-%s", r.target, r.service, name),
+		Kind:    kind,
+		Target:  r.target,
+		Service: r.service,
+	}, content)
+	return err
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose steps save their artifacts with r.
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// Record saves content with the context's recorder, if it has one.
+func Record(ctx context.Context, kind, name string, content []byte) error {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	if r == nil {
+		return nil
+	}
+	return r.Record(kind, name, content)
+}
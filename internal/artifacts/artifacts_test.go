@@ -0,0 +1,118 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	list, err := store.List("run-1")
+	if err != nil || list == nil || len(list) != 0 {
+		t.Fatalf("expected an empty list for a run without artifacts, got %v (%v)", list, err)
+	}
+
+	rec := store.Recorder("run-1", "app", "web")
+	if err := rec.Record(KindComposeOutput, "update.log", []byte("pulling\n")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := rec.Record(KindComposeOutput, "update.log", []byte("again\n")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := Record(WithRecorder(context.Background(), rec), KindRollbackOverride, "../override yml", []byte("services: {}\n")); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	if err := Record(context.Background(), KindProbeTranscript, "probes.txt", []byte("ignored")); err != nil {
+		t.Errorf("expected recording without a recorder to be a no-op, got %v", err)
+	}
+
+	list, err = store.List("run-1")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	var names []string
+	for _, a := range list {
+		names = append(names, a.Name)
+	}
+	want := []string{"app-web-update.log", "app-web-update-2.log", "app-web-.._override_yml"}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+	if list[0].Target != "app" || list[0].Service != "web" || list[0].Size != 8 {
+		t.Errorf("unexpected artifact %+v", list[0])
+	}
+
+	artifact, content, err := store.Read("run-1", "app-web-update-2.log")
+	if err != nil || string(content) != "again\n" || artifact.Kind != KindComposeOutput {
+		t.Errorf("expected the second log, got %+v %q (%v)", artifact, content, err)
+	}
+	if _, _, err := store.Read("run-1", "index.jsonl"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the index not to be served as an artifact, got %v", err)
+	}
+	if _, err := store.List("../etc"); err == nil {
+		t.Error("expected a run ID escaping the store to be rejected")
+	}
+}
+
+func TestStoreTruncatesAndPrunes(t *testing.T) {
+	store := NewStore(t.TempDir())
+	big := []byte(strings.Repeat("x", MaxSize) + "the error")
+	saved, err := store.Save("run-1", Artifact{Name: "big.log", Kind: KindComposeOutput}, big)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !saved.Truncated || saved.Size != MaxSize {
+		t.Errorf("expected a truncated artifact of MaxSize, got %+v", saved)
+	}
+	if _, content, _ := store.Read("run-1", "big.log"); !strings.HasSuffix(string(content), "the error") {
+		t.Error("expected truncation to keep the end of the content")
+	}
+
+	for i, run := range []string{"run-2", "run-3"} {
+		if _, err := store.Save(run, Artifact{Name: "a.log"}, []byte("a")); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+		modified := time.Now().Add(time.Duration(i+1) * time.Minute)
+		if err := os.Chtimes(filepath.Join(store.Dir, run), modified, modified); err != nil {
+			t.Fatalf("Chtimes failed: %v", err)
+		}
+	}
+	removed, err := store.Prune(2)
+	if err != nil || removed != 1 {
+		t.Fatalf("expected one run pruned, got %d (%v)", removed, err)
+	}
+	if list, _ := store.List("run-1"); len(list) != 0 {
+		t.Errorf("expected the oldest run to be pruned, got %v", list)
+	}
+	if list, _ := store.List("run-3"); len(list) != 1 {
+		t.Errorf("expected the newest run to be kept, got %v", list)
+	}
+}
+
+func TestLog(t *testing.T) {
+	var log Log
+	if log.Bytes() != nil {
+		t.Error("expected no bytes before anything was written")
+	}
+	log.now = func() time.Time { return time.Date(2026, 3, 1, 3, 4, 5, 0, time.UTC) }
+	log.Write("stderr", "Pulling web")
+	if got := string(log.Bytes()); got != "03:04:05 stderr Pulling web\n" {
+		t.Errorf("unexpected log %q", got)
+	}
+
+	transcript := string(ProbeTranscript([]state.ProbeResult{
+		{Type: "http", Success: true, Duration: 120 * time.Millisecond, Message: "200 OK"},
+		{Type: "tcp", Success: false, Duration: 5 * time.Second, Message: "connection refused"},
+	}))
+	if !strings.Contains(transcript, "ok   http") || !strings.Contains(transcript, "FAIL tcp") || !strings.Contains(transcript, "connection refused") {
+		t.Errorf("unexpected transcript %q", transcript)
+	}
+}
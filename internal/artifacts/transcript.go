@@ -0,0 +1,65 @@
+package artifacts
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// Log collects command output lines for an artifact, each with the time it
+// was written. Past twice MaxSize the oldest half is dropped, so a long pull
+// does not grow it without bound.
+type Log struct {
+	mu    sync.Mutex
+	b     strings.Builder
+	lines int
+	now   func() time.Time
+}
+
+// Write is a docker.OutputFunc.
+func (l *Log) Write(stream, line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now
+	if l.now != nil {
+		now = l.now
+	}
+	fmt.Fprintf(&l.b, "%s %s %s\n", now().UTC().Format("15:04:05"), stream, line)
+	l.lines++
+	if l.b.Len() > 2*MaxSize {
+		rest := l.b.String()[l.b.Len()-MaxSize:]
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[i+1:]
+		}
+		l.b.Reset()
+		l.b.WriteString(rest)
+	}
+}
+
+// Bytes returns the collected output, or nil when nothing was written.
+func (l *Log) Bytes() []byte {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.lines == 0 {
+		return nil
+	}
+	return []byte(l.b.String())
+}
+
+// ProbeTranscript renders the probe results of an update as text, one probe
+// per line with its outcome, duration and message.
+func ProbeTranscript(results []state.ProbeResult) []byte {
+	var b strings.Builder
+	for _, result := range results {
+		outcome := "ok"
+		if !result.Success {
+			outcome = "FAIL"
+		}
+		fmt.Fprintf(&b, "%-4s %-9s %8s  %s\n", outcome, result.Type, result.Duration.Round(time.Millisecond), result.Message)
+	}
+	return []byte(b.String())
+}
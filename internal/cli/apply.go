@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/itsmrshow/bulwark/internal/api"
 	"github.com/itsmrshow/bulwark/internal/discovery"
 	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/executor"
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
 	"github.com/itsmrshow/bulwark/internal/policy"
 	"github.com/itsmrshow/bulwark/internal/registry"
 	"github.com/itsmrshow/bulwark/internal/state"
@@ -35,23 +38,45 @@ runs health probes, and rolls back on failure.`,
 	cmd.Flags().String("state", "", "Path to state database (SQLite) for persistence")
 	cmd.Flags().String("db", "", "Alias for --state (SQLite path)")
 	cmd.Flags().String("target", "", "Update specific target only")
+	cmd.Flags().String("group", "", "Update the targets of one group (bulwark.group) only")
+	cmd.Flags().StringSlice("services", nil, "Update only these services (comma-separated names or globs, e.g. web,api)")
+	cmd.Flags().StringSlice("exclude", nil, "Never update these services (comma-separated names or globs)")
+	cmd.Flags().String("risk", "all", "Update only services of these risk classes: safe, notify, stateful, probe_missing, or all")
+	cmd.Flags().Int("max", 0, "Update at most this many services; 0 means no limit")
 	cmd.Flags().Bool("dry-run", false, "Dry-run mode (same as plan)")
 	cmd.Flags().Bool("force", false, "Override policy restrictions (use with caution)")
 	cmd.Flags().Bool("json", false, "Output as JSON")
+	cmd.Flags().String("summary-file", "", "Write a JSON summary of the outcome of each update to this file")
 
 	return cmd
 }
 
-func runApply(cmd *cobra.Command, args []string) error {
+func runApply(cmd *cobra.Command, args []string) (err error) {
+	summaryFile, _ := cmd.Flags().GetString("summary-file")
+	summary := newSummary("apply")
+	defer func() { err = summary.finish(cmd, summaryFile, err) }()
+
 	root, _ := cmd.Flags().GetString("root")
 	stateFile, _ := cmd.Flags().GetString("state")
 	dbFile, _ := cmd.Flags().GetString("db")
 	targetFilter, _ := cmd.Flags().GetString("target")
+	groupFilter, _ := cmd.Flags().GetString("group")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	force, _ := cmd.Flags().GetBool("force")
+	maxUpdates, _ := cmd.Flags().GetInt("max")
 	if stateFile == "" {
 		stateFile = dbFile
 	}
+	selector, err := applySelector(cmd)
+	if err != nil {
+		return withExitCode(ExitUsage, err)
+	}
+	if maxUpdates < 0 {
+		return withExitCode(ExitUsage, fmt.Errorf("--max must not be negative"))
+	}
+	if !dryRun && api.ResolveProfile(os.Getenv("BULWARK_PROFILE")) == api.ProfileObserver {
+		return fmt.Errorf("apply is disabled in the observer profile; use --dry-run to preview")
+	}
 
 	// Initialize logger
 	logger := logging.Default()
@@ -72,6 +97,16 @@ func runApply(cmd *cobra.Command, args []string) error {
 	}
 	defer func() { _ = dockerClient.Close() }()
 
+	// Fail early when a socket proxy blocks the calls an update needs
+	if !dryRun {
+		preflightCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		report := dockerClient.Preflight(preflightCtx)
+		cancel()
+		if report.Reachable && !report.CanWrite() {
+			return fmt.Errorf("docker endpoint does not allow updates (missing %s); run 'bulwark preflight' for details", missingNames(report))
+		}
+	}
+
 	// Create state store if path provided
 	var store state.Store
 	if stateFile != "" {
@@ -90,16 +125,28 @@ func runApply(cmd *cobra.Command, args []string) error {
 	}
 
 	// Create components
-	registryClient := registry.NewClient(logger)
+	registryClient := registry.NewClient(logger).WithHTTPOptions(registry.HTTPOptionsFromEnv())
 	policyEngine := policy.NewEngine(logger)
 	discoverer := discovery.NewDiscoverer(logger, dockerClient)
 	if store != nil {
 		discoverer = discoverer.WithStore(store)
 	}
-	exec := executor.NewExecutor(dockerClient, policyEngine, store, logger, dryRun)
+	exec := executor.NewExecutor(dockerClient, policyEngine, store, logger, dryRun).
+		WithTrigger(state.TriggerManualCLI, cliActor())
+	if store != nil {
+		exec = exec.WithResourceSnapshots(executor.DefaultMemoryJumpPercent)
+	}
 
 	// Run discovery
 	ctx := context.Background()
+
+	dataRoot := os.Getenv("BULWARK_DOCKER_DATA_ROOT")
+	if dataRoot == "" {
+		dataRoot, _ = dockerClient.DataRoot(ctx)
+	}
+	if dataRoot != "" {
+		exec = exec.WithDiskSpaceCheck(executor.NewDiskSpaceChecker(registryClient, dataRoot, logger))
+	}
 	var targets []state.Target
 
 	if targetFilter != "" {
@@ -123,13 +170,36 @@ func runApply(cmd *cobra.Command, args []string) error {
 	// Check for updates and apply
 	fmt.Printf("\n🔍 Checking for updates...\n\n")
 
-	updatesApplied := 0
-	updatesSkipped := 0
-	updatesFailed := 0
+	updatesStarted := 0
+
+	snoozed := make(map[string]time.Time)
+	if store != nil {
+		snoozes, err := store.ListSnoozes(ctx, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to load snoozes: %w", err)
+		}
+		for _, snooze := range snoozes {
+			snoozed[snooze.ServiceID] = snooze.Until
+		}
+	}
+	paused := make(map[string]bool)
+	if store != nil {
+		pauses, err := store.ListPausedGroups(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load paused groups: %w", err)
+		}
+		for _, pause := range pauses {
+			paused[pause.Group] = true
+		}
+	}
 
 	for _, target := range targets {
+		group := target.Group()
+		if groupFilter != "" && group != groupFilter {
+			continue
+		}
 		for _, service := range target.Services {
-			if !service.Labels.Enabled {
+			if !service.Labels.Enabled || !selector.MatchService(&target, &service) {
 				continue
 			}
 
@@ -139,15 +209,29 @@ func runApply(cmd *cobra.Command, args []string) error {
 				Str("image", service.Image).
 				Msg("Fetching remote digest")
 
+			item := SummaryItem{
+				Target:        target.Name,
+				Service:       service.Name,
+				Image:         service.Image,
+				CurrentDigest: service.CurrentDigest,
+			}
+			skip := func(reason string) {
+				fmt.Printf("⏭️  Skipping %s/%s: %s\n", target.Name, service.Name, reason)
+				item.Outcome, item.Reason = OutcomeSkipped, reason
+				summary.add(item)
+			}
+
 			remoteDigest, err := registryClient.FetchDigest(ctx, service.Image)
 			if err != nil {
 				logger.Warn().
 					Err(err).
 					Str("service", service.Name).
 					Msg("Failed to fetch remote digest")
-				updatesSkipped++
+				item.Outcome, item.Reason = OutcomeLookupFailed, fmt.Sprintf("Failed to fetch digest: %v", err)
+				summary.add(item)
 				continue
 			}
+			item.RemoteDigest = remoteDigest
 
 			// Compare digests
 			updateNeeded := registry.CompareDigests(service.CurrentDigest, remoteDigest)
@@ -155,6 +239,8 @@ func runApply(cmd *cobra.Command, args []string) error {
 				logger.Debug().
 					Str("service", service.Name).
 					Msg("Service is up to date")
+				item.Outcome = OutcomeUpToDate
+				summary.add(item)
 				continue
 			}
 
@@ -162,42 +248,85 @@ func runApply(cmd *cobra.Command, args []string) error {
 			decision := policyEngine.Evaluate(ctx, &target, &service, updateNeeded)
 
 			if !decision.Allowed && !force {
-				fmt.Printf("⏭️  Skipping %s/%s: %s\n", target.Name, service.Name, decision.Reason)
-				updatesSkipped++
+				skip(decision.Reason)
+				continue
+			}
+			if until, ok := snoozed[service.ID]; ok && !force {
+				skip("snoozed until " + until.Local().Format(time.DateTime))
+				continue
+			}
+			if paused[group] && !force {
+				skip(fmt.Sprintf("group %s is paused", group))
 				continue
 			}
 
+			if maxUpdates > 0 && updatesStarted >= maxUpdates {
+				skip(fmt.Sprintf("--max %d reached", maxUpdates))
+				continue
+			}
+			updatesStarted++
+
 			// Apply update
 			fmt.Printf("🔄 Updating %s/%s (%s)...\n", target.Name, service.Name, service.Image)
 
 			result := exec.ExecuteUpdate(ctx, &target, &service, remoteDigest)
 
-			if result.Success {
+			switch {
+			case result.Success:
 				fmt.Printf("✅ Updated %s/%s successfully\n", target.Name, service.Name)
-				updatesApplied++
-			} else if executor.IsSkipError(result.Error) {
-				fmt.Printf("⏭️  Skipped %s/%s: %s\n", target.Name, service.Name, executor.SkipReason(result.Error))
-				updatesSkipped++
-			} else {
-				fmt.Printf("❌ Failed to update %s/%s: %v\n", target.Name, service.Name, result.Error)
-				updatesFailed++
+				item.Outcome = OutcomeApplied
+				if dryRun {
+					// A dry run reports what an apply would update, as plan does
+					item.Outcome, item.Reason = OutcomeUpdateAvailable, "Dry run"
+				}
+			case result.ResultCode.IsSkip():
+				fmt.Printf("⏭️  Skipped %s/%s: %s\n", target.Name, service.Name, result.ErrorMessage)
+				item.Outcome, item.Reason = OutcomeSkipped, result.ErrorMessage
+			default:
+				fmt.Printf("❌ Failed to update %s/%s: %s\n", target.Name, service.Name, result.ErrorMessage)
+				item.Outcome, item.Reason = OutcomeFailed, result.ErrorMessage
 			}
+			summary.add(item)
 		}
 	}
 
 	// Summary
 	fmt.Print("\n" + strings.Repeat("=", 60) + "\n")
 	fmt.Printf("Summary:\n")
-	fmt.Printf("  ✅ Updates Applied: %d\n", updatesApplied)
-	fmt.Printf("  ⏭️  Updates Skipped: %d\n", updatesSkipped)
-	if updatesFailed > 0 {
-		fmt.Printf("  ❌ Updates Failed: %d\n", updatesFailed)
+	fmt.Printf("  ✅ Updates Applied: %d\n", summary.Counts.Applied)
+	fmt.Printf("  ⏭️  Updates Skipped: %d\n", summary.Counts.Skipped+summary.Counts.LookupFailed)
+	if summary.Counts.Failed > 0 {
+		fmt.Printf("  ❌ Updates Failed: %d\n", summary.Counts.Failed)
 	}
 	fmt.Print(strings.Repeat("=", 60) + "\n")
 
-	if updatesFailed > 0 {
-		return fmt.Errorf("%d updates failed", updatesFailed)
+	return summary.result()
+}
+
+// applySelector builds the selection of the apply command's --services,
+// --exclude and --risk flags, the same selection the API's apply takes.
+func applySelector(cmd *cobra.Command) (*planner.Selector, error) {
+	services, _ := cmd.Flags().GetStringSlice("services")
+	exclude, _ := cmd.Flags().GetStringSlice("exclude")
+	risk, _ := cmd.Flags().GetString("risk")
+
+	selector := &planner.Selector{Services: services, Exclude: exclude}
+	if risk = strings.TrimSpace(risk); risk != "" && risk != "all" {
+		for _, class := range strings.Split(risk, ",") {
+			selector.Risks = append(selector.Risks, strings.TrimSpace(class))
+		}
+	}
+	if err := selector.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid selection: %w", err)
 	}
+	return selector, nil
+}
 
-	return nil
+// cliActor names who runs a CLI command in history and the compose journal:
+// the login user, or "cli" when it is unknown.
+func cliActor() string {
+	if name := strings.TrimSpace(os.Getenv("USER")); name != "" {
+		return name
+	}
+	return "cli"
 }
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestApplySelector(t *testing.T) {
+	cmd := NewApplyCommand()
+	if err := cmd.ParseFlags([]string{"--services", "web,api*", "--exclude", "api-legacy", "--risk", "safe"}); err != nil {
+		t.Fatalf("ParseFlags failed: %v", err)
+	}
+	selector, err := applySelector(cmd)
+	if err != nil {
+		t.Fatalf("applySelector failed: %v", err)
+	}
+	target := &state.Target{Name: "app"}
+	safe := state.Labels{Enabled: true, Policy: state.PolicySafe, Tier: state.TierStateless, Probe: state.ProbeConfig{Type: state.ProbeTypeHTTP}}
+	for name, want := range map[string]bool{"web": true, "api-v2": true, "api-legacy": false, "worker": false} {
+		if got := selector.MatchService(target, &state.Service{Name: name, Labels: safe}); got != want {
+			t.Errorf("service %s selected = %v, want %v", name, got, want)
+		}
+	}
+	if selector.MatchService(target, &state.Service{Name: "web", Labels: state.Labels{Policy: state.PolicyNotify}}) {
+		t.Error("expected --risk safe to leave out a notify-only service")
+	}
+
+	cmd = NewApplyCommand()
+	if err := cmd.ParseFlags([]string{"--risk", "all"}); err != nil {
+		t.Fatalf("ParseFlags failed: %v", err)
+	}
+	if selector, err := applySelector(cmd); err != nil || !selector.Empty() {
+		t.Errorf("expected --risk all to select everything, got %+v (%v)", selector, err)
+	}
+
+	cmd = NewApplyCommand()
+	if err := cmd.ParseFlags([]string{"--risk", "low"}); err != nil {
+		t.Fatalf("ParseFlags failed: %v", err)
+	}
+	if _, err := applySelector(cmd); err == nil {
+		t.Error("expected an unknown risk class to be rejected")
+	}
+}
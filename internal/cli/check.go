@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -28,17 +29,41 @@ with remote registry digests. Respects policy configuration.`,
 
 	cmd.Flags().String("root", "/docker_data", "Root directory to scan for compose projects")
 	cmd.Flags().String("target", "", "Check specific target only")
-	cmd.Flags().Bool("json", false, "Output as JSON")
+	cmd.Flags().String("service", "", "Check specific service only (name or ID)")
+	cmd.Flags().String("image", "", "Check services running this image only")
+	cmd.Flags().Bool("json", false, "Output as JSON (same as --output json)")
+	cmd.Flags().String("output", outputTable, "Output format: table, json, sarif or junit")
+	cmd.Flags().String("output-file", "", "Write json, sarif or junit output to this file instead of stdout")
 	cmd.Flags().Bool("show-all", false, "Show all services, even without updates")
+	cmd.Flags().String("summary-file", "", "Write a JSON summary of the results to this file")
 
 	return cmd
 }
 
-func runCheck(cmd *cobra.Command, args []string) error {
+func runCheck(cmd *cobra.Command, args []string) (err error) {
+	summaryFile, _ := cmd.Flags().GetString("summary-file")
+	summary := newSummary("check")
+	defer func() { err = summary.finish(cmd, summaryFile, err) }()
+
 	root, _ := cmd.Flags().GetString("root")
 	targetFilter, _ := cmd.Flags().GetString("target")
+	serviceFilter, _ := cmd.Flags().GetString("service")
+	imageFilter, _ := cmd.Flags().GetString("image")
 	jsonOutput, _ := cmd.Flags().GetBool("json")
+	output, _ := cmd.Flags().GetString("output")
+	outputFile, _ := cmd.Flags().GetString("output-file")
 	showAll, _ := cmd.Flags().GetBool("show-all")
+	if jsonOutput {
+		output = outputJSON
+	}
+	switch output {
+	case outputTable, outputJSON, outputSARIF, outputJUnit:
+	default:
+		return withExitCode(ExitUsage, fmt.Errorf("unknown output %q: use table, json, sarif or junit", output))
+	}
+	if outputFile != "" && output == outputTable {
+		return withExitCode(ExitUsage, fmt.Errorf("--output-file needs --output json, sarif or junit"))
+	}
 
 	// Initialize logger
 	logger := logging.Default()
@@ -50,7 +75,7 @@ func runCheck(cmd *cobra.Command, args []string) error {
 	}
 	defer func() { _ = dockerClient.Close() }()
 
-	registryClient := registry.NewClient(logger)
+	registryClient := registry.NewClient(logger).WithHTTPOptions(registry.HTTPOptionsFromEnv())
 	policyEngine := policy.NewEngine(logger)
 	discoverer := discovery.NewDiscoverer(logger, dockerClient)
 
@@ -71,7 +96,7 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if len(targets) == 0 {
+	if len(targets) == 0 && (output == outputTable || output == outputJSON) {
 		fmt.Println("No targets discovered. Enable Bulwark on your services with bulwark.enabled=true")
 		return nil
 	}
@@ -84,6 +109,12 @@ func runCheck(cmd *cobra.Command, args []string) error {
 			if !service.Labels.Enabled {
 				continue
 			}
+			if serviceFilter != "" && service.Name != serviceFilter && service.ID != serviceFilter {
+				continue
+			}
+			if imageFilter != "" && !registry.MatchesImage(imageFilter, service.Image) {
+				continue
+			}
 
 			check := state.UpdateCheck{
 				Target:       &target,
@@ -135,23 +166,78 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	if len(checks) == 0 && (serviceFilter != "" || imageFilter != "") {
+		return fmt.Errorf("no enabled service matches the given filter")
+	}
+
+	for _, check := range checks {
+		summary.add(checkSummaryItem(check))
+	}
+
 	// Output results
-	if jsonOutput {
-		return outputCheckJSON(checks)
+	if output == outputTable {
+		outputCheckTable(checks, showAll)
+		return summary.result()
 	}
+	out := os.Stdout
+	if outputFile != "" {
+		out, err = os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() { _ = out.Close() }()
+	}
+	switch output {
+	case outputJSON:
+		err = outputCheckJSON(out, checks)
+	case outputSARIF:
+		var version string
+		if fields := strings.Fields(cmd.Root().Version); len(fields) > 0 {
+			version = fields[0]
+		}
+		err = writeCheckSARIF(out, checks, root, version)
+	case outputJUnit:
+		err = writeCheckJUnit(out, checks)
+	}
+	if err == nil && outputFile != "" {
+		err = out.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s output: %w", output, err)
+	}
+	return summary.result()
+}
 
-	return outputCheckTable(checks, showAll)
+func checkSummaryItem(check state.UpdateCheck) SummaryItem {
+	item := SummaryItem{
+		Target:        check.Target.Name,
+		Service:       check.Service.Name,
+		Image:         check.Service.Image,
+		CurrentDigest: check.Service.CurrentDigest,
+		RemoteDigest:  check.RemoteDigest,
+		Outcome:       OutcomeUpToDate,
+		Reason:        check.Reason,
+	}
+	switch {
+	case check.RemoteDigest == "":
+		item.Outcome = OutcomeLookupFailed
+	case check.UpdateNeeded && check.PolicyAllows:
+		item.Outcome = OutcomeUpdateAvailable
+	case check.UpdateNeeded:
+		item.Outcome = OutcomeBlocked
+	}
+	return item
 }
 
-func outputCheckJSON(checks []state.UpdateCheck) error {
-	encoder := json.NewEncoder(os.Stdout)
+func outputCheckJSON(w io.Writer, checks []state.UpdateCheck) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(map[string]interface{}{
 		"checks": checks,
 	})
 }
 
-func outputCheckTable(checks []state.UpdateCheck, showAll bool) error {
+func outputCheckTable(checks []state.UpdateCheck, showAll bool) {
 	fmt.Printf("\nUpdate Check Results:\n\n")
 	fmt.Printf("%-20s %-20s %-15s %-12s %-12s %s\n",
 		"TARGET", "SERVICE", "POLICY", "UPDATE?", "ALLOWED?", "REASON")
@@ -221,11 +307,4 @@ func outputCheckTable(checks []state.UpdateCheck, showAll bool) error {
 		fmt.Printf(" (use --show-all to see)")
 	}
 	fmt.Println()
-
-	// Return non-zero exit code if updates are available
-	if updatesAvailable > 0 {
-		return fmt.Errorf("updates available")
-	}
-
-	return nil
 }
@@ -0,0 +1,291 @@
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// Output formats of the check command.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputSARIF = "sarif"
+	outputJUnit = "junit"
+)
+
+// SARIF rule IDs of check findings.
+const (
+	ruleUpdateAvailable = "bulwark/update-available"
+	ruleLookupFailed    = "bulwark/digest-lookup-failed"
+)
+
+// checkSeverity maps the risk of updating a service to a SARIF level. An
+// update Bulwark can apply on its own is a note; one it holds for a human,
+// or would apply without a probe to catch a failure, is a warning; one of a
+// stateful service is an error.
+func checkSeverity(risk string) string {
+	switch risk {
+	case planner.RiskStateful:
+		return "error"
+	case planner.RiskNotifyOnly, planner.RiskProbeMissing:
+		return "warning"
+	}
+	return "note"
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                    `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLink `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult                `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	Name                 string       `json:"name"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	FullDescription      sarifMessage `json:"fullDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifactLink struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation sarifArtifactLink `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+type sarifResult struct {
+	RuleID              string                 `json:"ruleId"`
+	Level               string                 `json:"level"`
+	Message             sarifMessage           `json:"message"`
+	Locations           []sarifLocation        `json:"locations,omitempty"`
+	PartialFingerprints map[string]string      `json:"partialFingerprints"`
+	Properties          map[string]interface{} `json:"properties"`
+}
+
+func newSARIFRule(id, name, short, full, level string) sarifRule {
+	rule := sarifRule{
+		ID:               id,
+		Name:             name,
+		ShortDescription: sarifMessage{Text: short},
+		FullDescription:  sarifMessage{Text: full},
+	}
+	rule.DefaultConfiguration.Level = level
+	return rule
+}
+
+// writeCheckSARIF writes the outdated services and failed lookups of checks
+// as a SARIF 2.1.0 log. Compose file locations are relative to root, so
+// code scanning can attach findings to files of a repository checked out
+// there. Services that are up to date are not findings.
+func writeCheckSARIF(w io.Writer, checks []state.UpdateCheck, root, version string) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "bulwark",
+			Version:        version,
+			InformationURI: "https://github.com/itsmrshow/bulwark",
+			Rules: []sarifRule{
+				newSARIFRule(ruleUpdateAvailable, "UpdateAvailable",
+					"A newer image is available",
+					"The registry has a different digest for the image this service runs. The level of a finding follows the risk of updating the service: stateful services are errors, notify-only or unprobed ones warnings, and services Bulwark updates on its own notes.",
+					"warning"),
+				newSARIFRule(ruleLookupFailed, "DigestLookupFailed",
+					"The registry could not be asked for the image's digest",
+					"Bulwark could not fetch the remote digest of the image this service runs, so it cannot tell whether the service is outdated.",
+					"warning"),
+			},
+		}},
+		Results: []sarifResult{},
+	}
+	absRoot := ""
+	if root != "" {
+		if abs, err := filepath.Abs(root); err == nil {
+			absRoot = abs
+			run.OriginalURIBaseIDs = map[string]sarifArtifactLink{
+				"ROOT": {URI: strings.TrimSuffix(fileURI(absRoot), "/") + "/"},
+			}
+		}
+	}
+
+	for _, check := range checks {
+		lookupFailed := check.RemoteDigest == ""
+		if !check.UpdateNeeded && !lookupFailed {
+			continue
+		}
+		name := check.Target.Name + "/" + check.Service.Name
+		risk := planner.RiskFromLabels(check.Service.Labels)
+		result := sarifResult{
+			RuleID:              ruleUpdateAvailable,
+			Level:               checkSeverity(risk),
+			Message:             sarifMessage{Text: fmt.Sprintf("%s runs an outdated image %s (risk: %s). %s", name, check.Service.Image, risk, check.Reason)},
+			PartialFingerprints: map[string]string{"bulwarkService/v1": name},
+			Properties: map[string]interface{}{
+				"target":         check.Target.Name,
+				"service":        check.Service.Name,
+				"image":          check.Service.Image,
+				"current_digest": check.Service.CurrentDigest,
+				"remote_digest":  check.RemoteDigest,
+				"risk":           risk,
+				"policy_allows":  check.PolicyAllows,
+			},
+		}
+		if lookupFailed {
+			result.RuleID = ruleLookupFailed
+			result.Level = "warning"
+			result.Message.Text = fmt.Sprintf("%s: %s", name, check.Reason)
+		}
+		if location, ok := sarifComposeLocation(check.Target, absRoot); ok {
+			result.Locations = []sarifLocation{location}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+// sarifComposeLocation locates a finding in the compose file of target,
+// relative to absRoot when the file is below it. Targets of labelled
+// containers have no file and no location.
+func sarifComposeLocation(target *state.Target, absRoot string) (sarifLocation, bool) {
+	var location sarifLocation
+	if target.Path == "" {
+		return location, false
+	}
+	path, err := filepath.Abs(target.Path)
+	if err != nil {
+		return location, false
+	}
+	link := &location.PhysicalLocation.ArtifactLocation
+	if rel, err := filepath.Rel(absRoot, path); absRoot != "" && err == nil && !strings.HasPrefix(rel, "..") {
+		link.URI, link.URIBaseID = filepath.ToSlash(rel), "ROOT"
+	} else {
+		link.URI = fileURI(path)
+	}
+	return location, true
+}
+
+func fileURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeCheckJUnit writes checks as a JUnit XML report with a test suite per
+// target and a test case per service: an outdated service fails with its
+// risk as the failure type, and a failed digest lookup is an error.
+func writeCheckJUnit(w io.Writer, checks []state.UpdateCheck) error {
+	report := junitTestSuites{Name: "bulwark check"}
+	suites := make(map[string]int)
+	for _, check := range checks {
+		i, ok := suites[check.Target.Name]
+		if !ok {
+			i = len(report.Suites)
+			suites[check.Target.Name] = i
+			report.Suites = append(report.Suites, junitTestSuite{Name: check.Target.Name})
+		}
+		suite := &report.Suites[i]
+
+		testCase := junitTestCase{Name: check.Service.Name, ClassName: check.Target.Name}
+		switch {
+		case check.RemoteDigest == "":
+			testCase.Error = &junitProblem{Message: check.Reason, Type: OutcomeLookupFailed, Text: check.Service.Image}
+			suite.Errors++
+		case check.UpdateNeeded:
+			risk := planner.RiskFromLabels(check.Service.Labels)
+			testCase.Failure = &junitProblem{
+				Message: check.Reason,
+				Type:    risk,
+				Text: fmt.Sprintf("image: %s\ncurrent digest: %s\nremote digest: %s\nrisk: %s\nallowed by policy: %t",
+					check.Service.Image, check.Service.CurrentDigest, check.RemoteDigest, risk, check.PolicyAllows),
+			}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+	}
+	for _, suite := range report.Suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
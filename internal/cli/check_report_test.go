@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func testChecks() []state.UpdateCheck {
+	target := &state.Target{Name: "app", Path: "/docker_data/app/compose.yml"}
+	probed := state.ProbeConfig{Type: state.ProbeTypeHTTP}
+	return []state.UpdateCheck{
+		{
+			Target:       target,
+			Service:      &state.Service{Name: "db", Image: "postgres:16", CurrentDigest: "sha256:aaa", Labels: state.Labels{Policy: state.PolicySafe, Tier: state.TierStateful, Probe: probed}},
+			RemoteDigest: "sha256:bbb",
+			UpdateNeeded: true,
+			Reason:       "Stateful service requires aggressive policy",
+		},
+		{
+			Target:       target,
+			Service:      &state.Service{Name: "web", Image: "nginx:latest", CurrentDigest: "sha256:ccc", Labels: state.Labels{Policy: state.PolicySafe, Tier: state.TierStateless, Probe: probed}},
+			RemoteDigest: "sha256:ddd",
+			UpdateNeeded: true,
+			PolicyAllows: true,
+			Reason:       "Safe to update",
+		},
+		{
+			Target:       target,
+			Service:      &state.Service{Name: "cache", Image: "redis:7", CurrentDigest: "sha256:eee"},
+			RemoteDigest: "sha256:eee",
+			Reason:       "Digests match - up to date",
+		},
+		{
+			Target:  &state.Target{Name: "tools"},
+			Service: &state.Service{Name: "backup", Image: "ghcr.io/acme/backup:1"},
+			Reason:  "Failed to fetch digest: unauthorized",
+		},
+	}
+}
+
+func TestWriteCheckSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCheckSARIF(&buf, testChecks(), "/docker_data", "1.2.0"); err != nil {
+		t.Fatalf("writeCheckSARIF failed: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.0" || run.OriginalURIBaseIDs["ROOT"].URI != "file:///docker_data/" {
+		t.Errorf("unexpected run metadata %+v", run)
+	}
+
+	results := run.Results
+	if len(results) != 3 {
+		t.Fatalf("expected the two outdated services and the failed lookup, got %d results", len(results))
+	}
+	if results[0].RuleID != ruleUpdateAvailable || results[0].Level != "error" {
+		t.Errorf("stateful service: got rule %s level %s, want %s error", results[0].RuleID, results[0].Level, ruleUpdateAvailable)
+	}
+	if results[1].Level != "note" {
+		t.Errorf("safe service: got level %s, want note", results[1].Level)
+	}
+	location := results[0].Locations[0].PhysicalLocation.ArtifactLocation
+	if location.URI != "app/compose.yml" || location.URIBaseID != "ROOT" {
+		t.Errorf("unexpected location %+v", location)
+	}
+	if results[2].RuleID != ruleLookupFailed || len(results[2].Locations) != 0 {
+		t.Errorf("unexpected lookup failure %+v", results[2])
+	}
+}
+
+func TestWriteCheckJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeCheckJUnit(&buf, testChecks()); err != nil {
+		t.Fatalf("writeCheckJUnit failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "<?xml") {
+		t.Error("expected an XML header")
+	}
+	var report junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("output is not XML: %v", err)
+	}
+	if report.Tests != 4 || report.Failures != 2 || report.Errors != 1 || len(report.Suites) != 2 {
+		t.Fatalf("unexpected totals %+v", report)
+	}
+	app := report.Suites[0]
+	if app.Name != "app" || app.Cases[0].Failure == nil || app.Cases[0].Failure.Type != "stateful" {
+		t.Errorf("unexpected suite %+v", app)
+	}
+	if app.Cases[2].Failure != nil || app.Cases[2].Error != nil {
+		t.Error("expected an up-to-date service to pass")
+	}
+	if report.Suites[1].Cases[0].Error == nil {
+		t.Error("expected a failed lookup to be an error")
+	}
+}
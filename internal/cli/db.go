@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// NewDBCommand creates the db command
+func NewDBCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "db",
+		Short: "Maintain the state database",
+	}
+	cmd.PersistentFlags().String("state", os.Getenv("BULWARK_STATE_DB"), "Path to state database (SQLite)")
+
+	relink := &cobra.Command{
+		Use:   "relink <old-target> <new-target>",
+		Short: "Merge a target's history into another target",
+		Long: `Moves the update history, ignored updates and snoozes of a target that was
+renamed or moved to the target it became, then removes the old target. Targets
+are given by ID or name. Services match by name; map renamed services with
+--service old=new. The old IDs stay valid as aliases of the new ones.
+
+  bulwark db relink media media-stack --service plex=plex-server`,
+		Args: cobra.ExactArgs(2),
+		RunE: runRelink,
+	}
+	relink.Flags().StringArray("service", nil, "Map a renamed service, old=new (repeatable)")
+
+	aliases := &cobra.Command{
+		Use:   "aliases",
+		Short: "List the IDs relinked to other IDs",
+		Args:  cobra.NoArgs,
+		RunE:  runAliases,
+	}
+
+	cmd.AddCommand(relink, aliases)
+	return cmd
+}
+
+func openStateDB(cmd *cobra.Command) (*state.SQLiteStore, error) {
+	stateFile, _ := cmd.Flags().GetString("state")
+	if stateFile == "" {
+		return nil, fmt.Errorf("set --state or BULWARK_STATE_DB")
+	}
+	store, err := state.NewSQLiteStore(stateFile, logging.Default())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create state store: %w", err)
+	}
+	if err := store.Initialize(context.Background()); err != nil {
+		_ = store.Close()
+		return nil, fmt.Errorf("failed to initialize state store: %w", err)
+	}
+	return store, nil
+}
+
+func runRelink(cmd *cobra.Command, args []string) error {
+	mappings, _ := cmd.Flags().GetStringArray("service")
+	renames := make(map[string]string, len(mappings))
+	for _, mapping := range mappings {
+		oldName, newName, ok := strings.Cut(mapping, "=")
+		if !ok || strings.TrimSpace(oldName) == "" || strings.TrimSpace(newName) == "" {
+			return fmt.Errorf("expected --service old=new, got %q", mapping)
+		}
+		renames[strings.TrimSpace(oldName)] = strings.TrimSpace(newName)
+	}
+
+	store, err := openStateDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	oldTarget, err := findTarget(ctx, store, args[0])
+	if err != nil {
+		return err
+	}
+	newTarget, err := findTarget(ctx, store, args[1])
+	if err != nil {
+		return err
+	}
+	moved, err := store.RelinkTarget(ctx, oldTarget.ID, newTarget.ID, renames)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Relinked %s (%s) to %s (%s): %d history entries moved\n", oldTarget.Name, oldTarget.ID, newTarget.Name, newTarget.ID, moved)
+	return nil
+}
+
+// findTarget looks a target up by ID, then by name.
+func findTarget(ctx context.Context, store state.TargetStore, ref string) (*state.Target, error) {
+	if target, err := store.GetTarget(ctx, ref); err == nil {
+		return target, nil
+	}
+	target, err := store.GetTargetByName(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("no target with ID or name %q", ref)
+	}
+	return target, nil
+}
+
+func runAliases(cmd *cobra.Command, args []string) error {
+	store, err := openStateDB(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	aliases, err := store.ListAliases(context.Background())
+	if err != nil {
+		return err
+	}
+	if len(aliases) == 0 {
+		fmt.Println("No aliases")
+		return nil
+	}
+	for _, alias := range aliases {
+		fmt.Printf("%-8s %s -> %s  (%s)\n", alias.Kind, alias.OldID, alias.NewID, alias.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
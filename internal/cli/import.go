@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/itsmrshow/bulwark/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+// NewImportCommand creates the import command
+func NewImportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Translate another updater's configuration to Bulwark labels",
+	}
+	cmd.PersistentFlags().StringP("output", "o", "", "Write the override file here instead of stdout")
+
+	watchtower := &cobra.Command{
+		Use:   "watchtower <compose-file>",
+		Short: "Import Watchtower labels and settings",
+		Long: `Reads the com.centurylinklabs.watchtower.* labels of a compose file's
+services, and the environment and flags of the Watchtower container in it,
+and prints a compose override file with the matching bulwark.* labels. The
+Bulwark settings for Watchtower's schedule, cleanup and notifications are
+listed in its header, along with what did not translate.
+
+  bulwark import watchtower compose.yml -o compose.bulwark.yml
+  docker compose -f compose.yml -f compose.bulwark.yml up -d`,
+		Args: cobra.ExactArgs(1),
+		RunE: runImportWatchtower,
+	}
+	watchtower.Flags().Bool("label-enable", false, "Watchtower runs elsewhere with --label-enable")
+
+	diun := &cobra.Command{
+		Use:   "diun [compose-file]",
+		Short: "Import Diun labels and settings",
+		Long: `Reads the diun.* labels of a compose file's services, and Diun's settings
+from --config or from the Diun container in the compose file, and prints a
+compose override file with the matching bulwark.* labels. Diun only
+notifies, so the services it watched get bulwark.policy=notify.
+
+  bulwark import diun compose.yml --config diun.yml -o compose.bulwark.yml`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runImportDiun,
+	}
+	diun.Flags().String("config", "", "Diun's config file (diun.yml)")
+
+	cmd.AddCommand(watchtower, diun)
+	return cmd
+}
+
+func runImportWatchtower(cmd *cobra.Command, args []string) error {
+	labelEnable, _ := cmd.Flags().GetBool("label-enable")
+	compose, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read compose file: %w", err)
+	}
+	result, err := importer.Watchtower(args[0], compose, importer.WatchtowerOptions{LabelEnable: labelEnable})
+	if err != nil {
+		return err
+	}
+	return writeImport(cmd, result)
+}
+
+func runImportDiun(cmd *cobra.Command, args []string) error {
+	configPath, _ := cmd.Flags().GetString("config")
+	if len(args) == 0 && configPath == "" {
+		return fmt.Errorf("give a compose file, --config, or both")
+	}
+
+	var compose, config []byte
+	source := configPath
+	if len(args) == 1 {
+		source = args[0]
+		var err error
+		if compose, err = os.ReadFile(args[0]); err != nil {
+			return fmt.Errorf("failed to read compose file: %w", err)
+		}
+	}
+	if configPath != "" {
+		var err error
+		if config, err = os.ReadFile(configPath); err != nil {
+			return fmt.Errorf("failed to read Diun config: %w", err)
+		}
+	}
+	result, err := importer.Diun(source, compose, config)
+	if err != nil {
+		return err
+	}
+	return writeImport(cmd, result)
+}
+
+func writeImport(cmd *cobra.Command, result *importer.Result) error {
+	output, _ := cmd.Flags().GetString("output")
+	override, err := result.Override()
+	if err != nil {
+		return fmt.Errorf("failed to render override file: %w", err)
+	}
+	if output == "" {
+		_, err = cmd.OutOrStdout().Write(override)
+		return err
+	}
+	if err := os.WriteFile(output, override, 0o644); err != nil {
+		return fmt.Errorf("failed to write override file: %w", err)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Wrote labels for %d services to %s; review the notes at its top\n", len(result.Labels), output)
+	return nil
+}
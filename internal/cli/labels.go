@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/itsmrshow/bulwark/internal/api"
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/journal"
+	"github.com/spf13/cobra"
+)
+
+// NewLabelsCommand creates the labels command
+func NewLabelsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "labels <compose-file> <service>",
+		Short: "Generate a service's Bulwark labels and write them to its compose file",
+		Long: `Generates the bulwark.* labels of a compose service from its policy, tier
+and probe settings. Settings not given on the command line keep the value
+the service's labels have now.
+
+Without --write the label block and the change to the compose file are
+printed. With --write the labels are written to the compose file, keeping
+its other content, comments and formatting, and the change is journaled
+beside it in .bulwark/.
+
+  bulwark labels compose.yml web --policy safe --probe-type http --probe-url http://localhost/health`,
+		Args: cobra.ExactArgs(2),
+		RunE: runLabels,
+	}
+
+	cmd.Flags().String("policy", "", "Update policy: notify, safe or aggressive")
+	cmd.Flags().String("tier", "", "Service tier: stateless or stateful")
+	cmd.Flags().String("probe-type", "", "Probe: docker, http, tcp, log, stability or none")
+	cmd.Flags().String("probe-url", "", "URL of an http probe")
+	cmd.Flags().Int("probe-status", 0, "Status an http probe expects")
+	cmd.Flags().String("probe-tcp-host", "", "Host of a tcp probe")
+	cmd.Flags().Int("probe-tcp-port", 0, "Port of a tcp probe")
+	cmd.Flags().String("probe-log-pattern", "", "Pattern a log probe waits for")
+	cmd.Flags().Int("probe-window", 0, "Seconds a log probe waits")
+	cmd.Flags().Bool("write", false, "Write the labels to the compose file")
+
+	return cmd
+}
+
+func runLabels(cmd *cobra.Command, args []string) error {
+	path, service := args[0], args[1]
+	write, _ := cmd.Flags().GetBool("write")
+	if write && api.ResolveProfile(os.Getenv("BULWARK_PROFILE")) == api.ProfileObserver {
+		return fmt.Errorf("label changes are disabled in the observer profile")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read compose file: %w", err)
+	}
+	current, err := discovery.ComposeLabels(content, service)
+	if err != nil {
+		return err
+	}
+	settings := discovery.SettingsFromLabels(current)
+	flags := cmd.Flags()
+	for name, field := range map[string]*string{
+		"policy":            &settings.Policy,
+		"tier":              &settings.Tier,
+		"probe-type":        &settings.Probe.Type,
+		"probe-url":         &settings.Probe.URL,
+		"probe-tcp-host":    &settings.Probe.TCPHost,
+		"probe-log-pattern": &settings.Probe.LogPattern,
+	} {
+		if flags.Changed(name) {
+			*field, _ = flags.GetString(name)
+		}
+	}
+	for name, field := range map[string]*int{
+		"probe-status":   &settings.Probe.ExpectStatus,
+		"probe-tcp-port": &settings.Probe.TCPPort,
+		"probe-window":   &settings.Probe.WindowSec,
+	} {
+		if flags.Changed(name) {
+			*field, _ = flags.GetInt(name)
+		}
+	}
+
+	labels, err := settings.Labels()
+	if err != nil {
+		return err
+	}
+	updated, err := discovery.SetComposeLabels(content, service, labels)
+	if err != nil {
+		return err
+	}
+	if string(updated) == string(content) {
+		fmt.Printf("%s already has these labels\n", service)
+		return nil
+	}
+	if !write {
+		fmt.Print(discovery.LabelBlock(labels))
+		fmt.Printf("\nChange to %s:\n%s\n\nRun again with --write to apply it.\n", path, journal.Diff(string(content), string(updated)))
+		return nil
+	}
+
+	if _, err := journal.Write(path, updated, cliActor(), fmt.Sprintf("labels of %s set", service)); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Labels of %s written to %s\n", service, path)
+	return nil
+}
@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// NewMaintenanceCommand creates the maintenance command
+func NewMaintenanceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintenance [on|off]",
+		Short: "Pause scheduled applies during host maintenance",
+		Long: `Starts or ends a maintenance window. While it lasts, auto-updates and
+scheduled runs are skipped, and the web UI shows the message as a banner.
+Manual applies still run. Without an argument, shows the current window.
+
+  bulwark maintenance on --message "Host maintenance" --until 18:00
+  bulwark maintenance off`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"on", "off"},
+		RunE:      runMaintenance,
+	}
+
+	cmd.Flags().String("state", os.Getenv("BULWARK_STATE_DB"), "Path to state database (SQLite)")
+	cmd.Flags().String("message", "", "Message shown in the web UI")
+	cmd.Flags().String("until", "", "End of the window: a time such as 18:00, an RFC 3339 time, or a length such as 2h")
+
+	return cmd
+}
+
+func runMaintenance(cmd *cobra.Command, args []string) error {
+	stateFile, _ := cmd.Flags().GetString("state")
+	message, _ := cmd.Flags().GetString("message")
+	untilFlag, _ := cmd.Flags().GetString("until")
+	if stateFile == "" {
+		return fmt.Errorf("the maintenance window is kept in the state database; set --state or BULWARK_STATE_DB")
+	}
+	action := ""
+	if len(args) == 1 {
+		action = args[0]
+	}
+	if action != "on" && (message != "" || untilFlag != "") {
+		return fmt.Errorf("--message and --until apply to 'maintenance on'")
+	}
+
+	store, err := state.NewSQLiteStore(stateFile, logging.Default())
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+	if err := store.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize state store: %w", err)
+	}
+
+	switch action {
+	case "on":
+		now := time.Now()
+		maintenance := &state.Maintenance{
+			Message:   strings.TrimSpace(message),
+			StartedBy: cliActor(),
+			StartedAt: now.UTC(),
+		}
+		if untilFlag != "" {
+			until, err := state.ParseMaintenanceUntil(untilFlag, now)
+			if err != nil {
+				return err
+			}
+			until = until.UTC()
+			maintenance.Until = &until
+		}
+		if current := state.LoadMaintenance(ctx, store); current.Active(now) {
+			maintenance.StartedAt = current.StartedAt
+		}
+		if err := state.SaveMaintenance(ctx, store, maintenance); err != nil {
+			return err
+		}
+		fmt.Println("Maintenance started: " + describeMaintenance(maintenance))
+	case "off":
+		if err := state.SaveMaintenance(ctx, store, nil); err != nil {
+			return err
+		}
+		fmt.Println("Maintenance ended; scheduled applies resume")
+	case "":
+		maintenance := state.LoadMaintenance(ctx, store)
+		if !maintenance.Active(time.Now()) {
+			fmt.Println("No maintenance window")
+			return nil
+		}
+		fmt.Println("Maintenance: " + describeMaintenance(maintenance))
+	default:
+		return fmt.Errorf("expected on or off, got %q", action)
+	}
+	return nil
+}
+
+// describeMaintenance summarizes a window, e.g. "Host maintenance until
+// 18:00 — auto-updates paused".
+func describeMaintenance(maintenance *state.Maintenance) string {
+	text := maintenance.Message
+	if text == "" {
+		text = "maintenance"
+	}
+	if maintenance.Until != nil {
+		text += " until " + maintenance.Until.Local().Format(time.DateTime)
+	}
+	return text + " — auto-updates paused"
+}
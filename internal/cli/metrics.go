@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/itsmrshow/bulwark/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+// NewMetricsCommand creates the metrics command
+func NewMetricsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Work with Bulwark's Prometheus metrics",
+	}
+
+	dashboard := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Print a Grafana dashboard of Bulwark's metrics",
+		Long: `Prints a Grafana dashboard for the metrics served on /metrics with
+BULWARK_METRICS_ENABLED=true: available updates, updates and rollbacks,
+probe failures and update durations, filterable by target. The dashboard
+is generated from the metric definitions of this build, so it always
+matches their names and labels. Import it in Grafana and pick the
+Prometheus data source that scrapes Bulwark.
+
+  bulwark metrics dashboard > bulwark-dashboard.json`,
+		Args: cobra.NoArgs,
+		RunE: runMetricsDashboard,
+	}
+	dashboard.Flags().String("title", metrics.DefaultDashboardTitle, "Dashboard title")
+	dashboard.Flags().StringP("output", "o", "", "Write the dashboard to a file instead of stdout")
+
+	cmd.AddCommand(dashboard)
+	return cmd
+}
+
+func runMetricsDashboard(cmd *cobra.Command, args []string) error {
+	title, _ := cmd.Flags().GetString("title")
+	output, _ := cmd.Flags().GetString("output")
+
+	raw, err := metrics.Dashboard(title)
+	if err != nil {
+		return fmt.Errorf("failed to generate dashboard: %w", err)
+	}
+	raw = append(raw, '\n')
+	if output == "" {
+		_, err = cmd.OutOrStdout().Write(raw)
+		return err
+	}
+	if err := os.WriteFile(output, raw, 0o644); err != nil {
+		return fmt.Errorf("failed to write dashboard: %w", err)
+	}
+	return nil
+}
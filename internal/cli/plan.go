@@ -30,15 +30,22 @@ including policy decisions and probe configurations.`,
 	cmd.Flags().String("root", "/docker_data", "Root directory to scan for compose projects")
 	cmd.Flags().String("state", "", "Path to state database (SQLite) for persistence")
 	cmd.Flags().String("target", "", "Plan for specific target only")
+	cmd.Flags().String("group", "", "Plan for the targets of one group (bulwark.group) only")
 	cmd.Flags().Bool("json", false, "Output as JSON")
+	cmd.Flags().String("summary-file", "", "Write a JSON summary of the plan to this file")
 
 	return cmd
 }
 
-func runPlan(cmd *cobra.Command, args []string) error {
+func runPlan(cmd *cobra.Command, args []string) (err error) {
+	summaryFile, _ := cmd.Flags().GetString("summary-file")
+	summary := newSummary("plan")
+	defer func() { err = summary.finish(cmd, summaryFile, err) }()
+
 	root, _ := cmd.Flags().GetString("root")
 	stateFile, _ := cmd.Flags().GetString("state")
 	target, _ := cmd.Flags().GetString("target")
+	group, _ := cmd.Flags().GetString("group")
 	jsonOutput, _ := cmd.Flags().GetBool("json")
 
 	logger := logging.Default()
@@ -68,27 +75,45 @@ func runPlan(cmd *cobra.Command, args []string) error {
 		discoverer = discoverer.WithStore(store)
 	}
 
-	registryClient := registry.NewClient(logger)
+	registryClient := registry.NewClient(logger).WithHTTPOptions(registry.HTTPOptionsFromEnv())
 	policyEngine := policy.NewEngine(logger)
-	plannerSvc := planner.NewPlanner(logger, discoverer, registryClient, policyEngine)
+	plannerSvc := planner.NewPlanner(logger, discoverer, registryClient, policyEngine).
+		WithRollbackCheck(dockerClient, registryClient).
+		WithTagSuggestions(registryClient)
+	if store != nil {
+		plannerSvc.WithConfigDrift(docker.NewComposeRunner(), store).
+			WithIgnoredUpdates(store).
+			WithSnoozes(store).
+			WithPausedGroups(store)
+	}
 
 	plan, err := plannerSvc.BuildPlan(context.Background(), planner.PlanOptions{
 		Root:            root,
 		TargetFilter:    target,
 		IncludeDisabled: false,
+		Group:           group,
 	})
 	if err != nil {
 		return fmt.Errorf("plan failed: %w", err)
 	}
 
+	for _, item := range plan.Items {
+		summary.add(planSummaryItem(item))
+	}
+
 	if jsonOutput {
 		encoder := json.NewEncoder(os.Stdout)
 		encoder.SetIndent("", "  ")
-		return encoder.Encode(plan)
+		if err := encoder.Encode(plan); err != nil {
+			return err
+		}
+		return summary.result()
 	}
 
 	if target != "" {
 		fmt.Printf("Planning updates for target: %s\n\n", target)
+	} else if group != "" {
+		fmt.Printf("Planning updates for group: %s\n\n", group)
 	} else {
 		fmt.Println("Planning updates for all targets...")
 		fmt.Println()
@@ -135,10 +160,70 @@ func runPlan(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Services: %d\n", plan.ServiceCount)
 	fmt.Printf("  Updates Available: %d\n", plan.UpdateCount)
 	fmt.Printf("  Updates Allowed: %d\n", plan.AllowedCount)
+	if plan.BaseUpdateCount > 0 {
+		fmt.Printf("  Base Image Updates (rebuild recommended): %d\n", plan.BaseUpdateCount)
+	}
+	if plan.IgnoredCount > 0 {
+		fmt.Printf("  Updates Ignored: %d\n", plan.IgnoredCount)
+	}
 
-	if plan.UpdateCount > 0 {
-		return fmt.Errorf("updates available")
+	var drifted []string
+	seen := make(map[string]bool)
+	for _, item := range plan.Items {
+		if item.ConfigDrift && !seen[item.TargetID] {
+			seen[item.TargetID] = true
+			drifted = append(drifted, item.TargetName)
+		}
+	}
+	if len(drifted) > 0 {
+		fmt.Printf("\n⚠️  Compose config changed since last update: %s\n", strings.Join(drifted, ", "))
 	}
 
-	return nil
+	var degraded []string
+	for _, item := range plan.Items {
+		if item.RollbackDegraded {
+			degraded = append(degraded, item.TargetName+"/"+item.ServiceName)
+		}
+	}
+	if len(degraded) > 0 {
+		fmt.Printf("\n⚠️  Rollback not possible (current digest gone locally and from the registry): %s\n", strings.Join(degraded, ", "))
+	}
+
+	var mutable []string
+	for _, item := range plan.Items {
+		if item.MutableTag == "" {
+			continue
+		}
+		entry := fmt.Sprintf("%s/%s (:%s", item.TargetName, item.ServiceName, item.MutableTag)
+		if item.SuggestedTag != "" {
+			entry += ", pin " + item.SuggestedTag
+		}
+		mutable = append(mutable, entry+")")
+	}
+	if len(mutable) > 0 {
+		fmt.Printf("\n⚠️  Tracking mutable tags: %s\n", strings.Join(mutable, ", "))
+	}
+
+	return summary.result()
+}
+
+func planSummaryItem(item planner.PlanItem) SummaryItem {
+	summary := SummaryItem{
+		Target:        item.TargetName,
+		Service:       item.ServiceName,
+		Image:         item.Image,
+		CurrentDigest: item.CurrentDigest,
+		RemoteDigest:  item.RemoteDigest,
+		Outcome:       OutcomeUpToDate,
+		Reason:        item.Reason,
+	}
+	switch {
+	case item.FetchErr != nil:
+		summary.Outcome = OutcomeLookupFailed
+	case item.UpdateAvailable && item.Allowed:
+		summary.Outcome = OutcomeUpdateAvailable
+	case item.UpdateAvailable:
+		summary.Outcome = OutcomeBlocked
+	}
+	return summary
 }
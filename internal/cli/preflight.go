@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/spf13/cobra"
+)
+
+// NewPreflightCommand creates the preflight command
+func NewPreflightCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Check which Docker API calls Bulwark is allowed to make",
+		Long: `Checks every Docker API call Bulwark needs against the configured
+endpoint (DOCKER_HOST), including restricted endpoints such as
+docker-socket-proxy. Write checks target objects that do not exist, so
+nothing is modified.`,
+		RunE: runPreflight,
+	}
+
+	cmd.Flags().Bool("json", false, "Output as JSON")
+
+	return cmd
+}
+
+func runPreflight(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	report := dockerClient.Preflight(ctx)
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("\n%-20s %-32s %-8s %s\n", "CAPABILITY", "ENDPOINT", "STATUS", "NEEDED FOR")
+	fmt.Println(strings.Repeat("-", 90))
+	for _, c := range report.Capabilities {
+		status := "✓ ok"
+		if !c.Allowed {
+			status = "✗ denied"
+		}
+		fmt.Printf("%-20s %-32s %-8s %s\n", c.Name, c.Endpoint, status, c.Purpose)
+		if c.Error != "" {
+			fmt.Printf("%-20s   %s\n", "", c.Error)
+		}
+	}
+	fmt.Println()
+
+	switch {
+	case !report.Reachable:
+		return fmt.Errorf("docker endpoint unreachable")
+	case !report.CanRead():
+		return fmt.Errorf("discovery is not possible (missing %s)", missingNames(report))
+	case !report.CanWrite():
+		fmt.Printf("Read-only: discovery and planning work, updates cannot be applied (missing %s)\n", missingNames(report))
+	default:
+		fmt.Println("All capabilities available")
+	}
+	return nil
+}
+
+// missingNames lists the names of denied capabilities.
+func missingNames(report docker.CapabilityReport) string {
+	var names []string
+	for _, c := range report.Missing() {
+		names = append(names, c.Name)
+	}
+	return strings.Join(names, ", ")
+}
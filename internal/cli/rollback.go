@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/api"
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// NewRollbackCommand creates the rollback command
+func NewRollbackCommand() *cobra.Command {
+	rootDefault := os.Getenv("BULWARK_ROOT")
+	if rootDefault == "" {
+		rootDefault = "/docker_data"
+	}
+
+	cmd := &cobra.Command{
+		Use:   "rollback <target>/<service>",
+		Short: "Roll a service back past its last update",
+		Long: `Recreates a service with the digest its last update replaced, as recorded in
+history. The compose file is left unchanged.
+
+With --dry-run nothing changes: the command checks that history records the
+previous digest, that the image is available locally or from the registry,
+that the compose file is present and that no update holds the service's
+lock, then prints what the rollback would do. It exits non-zero when a
+check fails.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRollback,
+	}
+
+	cmd.Flags().String("root", rootDefault, "Root directory to scan for compose projects")
+	cmd.Flags().String("state", os.Getenv("BULWARK_STATE_DB"), "Path to state database (SQLite) holding the update history")
+	cmd.Flags().Bool("dry-run", false, "Verify the rollback and show what it would do without making it")
+
+	return cmd
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	root, _ := cmd.Flags().GetString("root")
+	stateFile, _ := cmd.Flags().GetString("state")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	targetName, serviceName, ok := strings.Cut(args[0], "/")
+	if !ok || targetName == "" || serviceName == "" {
+		return fmt.Errorf("expected <target>/<service>, got %q", args[0])
+	}
+	if stateFile == "" {
+		return fmt.Errorf("rollback needs the update history; pass --state or set BULWARK_STATE_DB")
+	}
+	if !dryRun && api.ResolveProfile(os.Getenv("BULWARK_PROFILE")) == api.ProfileObserver {
+		return fmt.Errorf("rollbacks are disabled in the observer profile")
+	}
+
+	logger := logging.Default()
+	ctx := context.Background()
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	store, err := state.NewSQLiteStore(stateFile, logger)
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize state store: %w", err)
+	}
+
+	target, err := discovery.NewDiscoverer(logger, dockerClient).WithStore(store).DiscoverTarget(ctx, root, targetName)
+	if err != nil {
+		return fmt.Errorf("failed to discover target: %w", err)
+	}
+	var service *state.Service
+	for i := range target.Services {
+		if target.Services[i].Name == serviceName {
+			service = &target.Services[i]
+			break
+		}
+	}
+	if service == nil {
+		return fmt.Errorf("service %s not found in target %s", serviceName, targetName)
+	}
+
+	last, err := executor.LastUpdate(ctx, store, service.ID)
+	if err != nil {
+		return fmt.Errorf("failed to read update history: %w", err)
+	}
+	exec := executor.NewExecutor(dockerClient, policy.NewEngine(logger), store, logger, dryRun).
+		WithTrigger(state.TriggerManualCLI, cliActor())
+
+	if dryRun {
+		registryClient := registry.NewClient(logger).WithHTTPOptions(registry.HTTPOptionsFromEnv())
+		report := exec.VerifyRollback(ctx, target, service, last, registryClient)
+		printRollbackReport(report)
+		if !report.Ready {
+			return fmt.Errorf("rollback of %s is not ready", args[0])
+		}
+		return nil
+	}
+
+	if last == nil || last.OldDigest == "" {
+		return fmt.Errorf("no update of %s is recorded, so there is nothing to roll back to", args[0])
+	}
+	result := &state.UpdateResult{
+		TargetID:    target.ID,
+		ServiceID:   service.ID,
+		ServiceName: service.Name,
+		OldDigest:   last.OldDigest,
+		NewDigest:   service.CurrentDigest,
+	}
+	if err := exec.ExecuteRollback(ctx, target, service, result); err != nil {
+		return fmt.Errorf("rollback of %s failed: %w", args[0], err)
+	}
+	fmt.Printf("✓ %s rolled back to %s\n", args[0], last.OldDigest)
+	return nil
+}
+
+func printRollbackReport(report *executor.RollbackReport) {
+	fmt.Printf("Rollback of %s/%s\n", report.Target, report.Service)
+	for _, check := range report.Checks {
+		mark := "✓"
+		if !check.OK {
+			mark = "✗"
+		}
+		fmt.Printf("  %s %-12s %s\n", mark, check.Name, check.Message)
+	}
+	if !report.Ready {
+		return
+	}
+	fmt.Println("\nWould:")
+	for i, step := range report.Steps {
+		fmt.Printf("  %d. %s\n", i+1, step)
+	}
+}
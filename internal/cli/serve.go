@@ -2,17 +2,73 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/itsmrshow/bulwark/internal/api"
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/mock"
 	"github.com/spf13/cobra"
 )
 
+// Kinds of environment-backed serve flags.
+const (
+	flagString   = "string"
+	flagBool     = "bool"
+	flagInt      = "int"
+	flagDuration = "duration"
+	// flagFile reads the variable's value from a file, so secrets stay out
+	// of the process list; it suits systemd's LoadCredential=.
+	flagFile = "file"
+)
+
+// envFlag is a serve flag standing in for an environment variable. A flag
+// that is set overrides the variable, so settings it pins stay locked in
+// the web console as they would be with the variable.
+type envFlag struct {
+	name  string
+	env   string
+	kind  string
+	usage string
+}
+
+var serveEnvFlags = []envFlag{
+	{"data-dir", "BULWARK_DATA_DIR", flagString, "Directory for settings and SBOMs (default /data)"},
+	{"config-path", "BULWARK_CONFIG_PATH", flagString, "Settings file (default <data-dir>/bulwark.json)"},
+	{"profile", "BULWARK_PROFILE", flagString, "Profile: full or observer"},
+	{"web-token-file", "BULWARK_WEB_TOKEN_WRITE", flagFile, "File holding the write token"},
+	{"read-token-file", "BULWARK_WEB_TOKEN_READ", flagFile, "File holding the read token"},
+	{"widget-token-file", "BULWARK_WIDGET_TOKEN", flagFile, "File holding the /api/widget token"},
+	{"timezone", "BULWARK_TZ", flagString, "IANA time zone for cron schedules"},
+	{"check-cron", "BULWARK_NOTIFY_CHECK_CRON", flagString, "Cron schedule of the update check"},
+	{"digest-cron", "BULWARK_NOTIFY_DIGEST_CRON", flagString, "Cron schedule of the digest notification"},
+	{"auto-update", "BULWARK_AUTO_UPDATE_ENABLED", flagBool, "Apply updates on the auto-update schedule"},
+	{"auto-update-cron", "BULWARK_AUTO_UPDATE_CRON", flagString, "Cron schedule of auto-updates"},
+	{"auto-update-unsafe", "BULWARK_AUTO_UPDATE_UNSAFE", flagBool, "Let auto-updates apply non-safe updates too"},
+	{"catch-up", "BULWARK_CATCHUP_ENABLED", flagBool, "Run missed scheduled jobs on startup"},
+	{"plan-cache-ttl", "BULWARK_PLAN_CACHE_TTL", flagDuration, "How long a plan is served from cache (default 5m)"},
+	{"digest-cache-ttl", "BULWARK_DIGEST_CACHE_TTL", flagDuration, "How long remote digests are cached"},
+	{"lock-timeout", "BULWARK_LOCK_TIMEOUT", flagDuration, "Wait for another update on the same target (default 5m)"},
+	{"plan-timeout", "BULWARK_PLAN_TIMEOUT", flagDuration, "Limit on one plan build (default 2m)"},
+	{"discovery-timeout", "BULWARK_DISCOVERY_TIMEOUT", flagDuration, "Limit on one discovery pass (default 30s)"},
+	{"service-update-timeout", "BULWARK_SERVICE_UPDATE_TIMEOUT", flagDuration, "Limit on updating one service (default 15m)"},
+	{"check-concurrency", "BULWARK_CHECK_CONCURRENCY", flagInt, "Digest lookups a plan build runs at once"},
+	{"max-concurrent-runs", "BULWARK_MAX_CONCURRENT_RUNS", flagInt, "Apply runs executing at once (default 1)"},
+	{"cleanup-policy", "BULWARK_CLEANUP_POLICY", flagString, "Image cleanup after updates: none or dangling"},
+	{"downtime-sla", "BULWARK_DOWNTIME_SLA", flagDuration, "Longest downtime an update may cause"},
+	{"cors-origins", "BULWARK_CORS_ORIGINS", flagString, "Comma-separated browser origins allowed to call the API"},
+	{"metrics", "BULWARK_METRICS_ENABLED", flagBool, "Serve Prometheus metrics on /metrics"},
+	{"mock", "BULWARK_MOCK", flagBool, "Run against a simulated Docker engine and registry, for demos and development"},
+}
+
 // NewServeCommand creates the serve command
 func NewServeCommand() *cobra.Command {
 	cfg := api.LoadConfig()
@@ -22,22 +78,97 @@ func NewServeCommand() *cobra.Command {
 		Short: "Run Bulwark Web Console (API + UI)",
 		Long: `Runs Bulwark as a daemon process with:
 - Web Console (API + UI)
-- Scheduler and webhook support (planned)`,
+- Scheduled checks, notifications and auto-updates
+
+Every flag stands in for an environment variable, named in its description;
+a flag that is set takes precedence. Under systemd with Type=notify, Bulwark
+reports readiness once it listens and pings the watchdog when WatchdogSec= is
+set.`,
 		RunE: runServe,
 	}
 
-	cmd.Flags().String("addr", cfg.Addr, "Web UI/API listen address")
-	cmd.Flags().String("root", cfg.Root, "Root directory to scan for compose projects")
-	cmd.Flags().String("state", cfg.StateDB, "Path to state database (SQLite)")
-	cmd.Flags().String("ui-dist", cfg.DistDir, "Path to built UI assets")
-	cmd.Flags().Bool("ui-enabled", cfg.UIEnabled, "Enable the web UI")
-	cmd.Flags().Bool("ui-readonly", cfg.ReadOnly, "Run UI in read-only mode")
+	cmd.Flags().String("addr", cfg.Addr, "Web UI/API listen address ($BULWARK_UI_ADDR)")
+	cmd.Flags().String("root", cfg.Root, "Root directory to scan for compose projects ($BULWARK_ROOT)")
+	cmd.Flags().String("state", cfg.StateDB, "Path to state database (SQLite) ($BULWARK_STATE_DB)")
+	cmd.Flags().String("ui-dist", cfg.DistDir, "Path to built UI assets ($BULWARK_UI_DIST)")
+	cmd.Flags().Bool("ui-enabled", cfg.UIEnabled, "Enable the web UI ($BULWARK_UI_ENABLED)")
+	cmd.Flags().Bool("no-ui", false, "Serve the API only, without the web UI")
+	cmd.Flags().Bool("ui-readonly", cfg.ReadOnly, "Run UI in read-only mode ($BULWARK_UI_READONLY)")
+	for _, f := range serveEnvFlags {
+		usage := fmt.Sprintf("%s ($%s)", f.usage, f.env)
+		if f.kind == flagBool {
+			cmd.Flags().Bool(f.name, false, usage)
+		} else {
+			cmd.Flags().String(f.name, "", usage)
+		}
+	}
 
 	return cmd
 }
 
+// startMock starts the default mock scenario in a temporary directory and
+// points cfg at it: its compose projects become the root, and settings are
+// kept there too unless BULWARK_DATA_DIR says otherwise.
+func startMock(cfg *api.Config) (*mock.Environment, error) {
+	dir, err := os.MkdirTemp("", "bulwark-mock-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the mock environment: %w", err)
+	}
+	env, err := mock.Start(dir, mock.DefaultScenario())
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to start the mock environment: %w", err)
+	}
+	cfg.Root = env.Root
+	cfg.RootLocked = true
+	if os.Getenv("BULWARK_DATA_DIR") == "" {
+		cfg.DataDir = dir
+	}
+	return env, nil
+}
+
+// applyServeEnvFlags sets the environment variable of every env flag given
+// on the command line.
+func applyServeEnvFlags(cmd *cobra.Command) error {
+	for _, f := range serveEnvFlags {
+		if !cmd.Flags().Changed(f.name) {
+			continue
+		}
+		var value string
+		if f.kind == flagBool {
+			enabled, _ := cmd.Flags().GetBool(f.name)
+			value = strconv.FormatBool(enabled)
+		} else {
+			value, _ = cmd.Flags().GetString(f.name)
+		}
+		switch f.kind {
+		case flagInt:
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid --%s %q: expected a number", f.name, value)
+			}
+		case flagDuration:
+			if _, err := time.ParseDuration(value); err != nil {
+				return fmt.Errorf("invalid --%s %q: expected a duration such as 5m", f.name, value)
+			}
+		case flagFile:
+			data, err := os.ReadFile(value)
+			if err != nil {
+				return fmt.Errorf("failed to read --%s: %w", f.name, err)
+			}
+			value = strings.TrimSpace(string(data))
+		}
+		if err := os.Setenv(f.env, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func runServe(cmd *cobra.Command, args []string) error {
 	logger := logging.Default()
+	if err := applyServeEnvFlags(cmd); err != nil {
+		return err
+	}
 	cfg := api.LoadConfig()
 
 	addr, _ := cmd.Flags().GetString("addr")
@@ -45,14 +176,31 @@ func runServe(cmd *cobra.Command, args []string) error {
 	stateFile, _ := cmd.Flags().GetString("state")
 	distDir, _ := cmd.Flags().GetString("ui-dist")
 	uiEnabled, _ := cmd.Flags().GetBool("ui-enabled")
+	noUI, _ := cmd.Flags().GetBool("no-ui")
 	uiReadonly, _ := cmd.Flags().GetBool("ui-readonly")
 
 	cfg.Addr = addr
 	cfg.Root = root
+	cfg.RootLocked = cfg.RootLocked || cmd.Flags().Changed("root")
 	cfg.StateDB = stateFile
 	cfg.DistDir = distDir
-	cfg.UIEnabled = uiEnabled
+	cfg.UIEnabled = uiEnabled && !noUI
 	cfg.ReadOnly = uiReadonly
+	if version := strings.Fields(cmd.Root().Version); len(version) > 0 {
+		cfg.Version = version[0]
+	}
+
+	if cfg.Mock {
+		env, err := startMock(&cfg)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			env.Stop()
+			_ = os.RemoveAll(filepath.Dir(env.Root))
+		}()
+		logger.Warn().Str("root", cfg.Root).Msg("Mock mode: running against a simulated Docker engine and registry")
+	}
 
 	server, err := api.NewServer(cfg, logger)
 	if err != nil {
@@ -60,9 +208,15 @@ func runServe(cmd *cobra.Command, args []string) error {
 	}
 	defer func() { _ = server.Close() }()
 
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", cfg.Addr, err)
+	}
+
 	httpServer := &http.Server{
 		Addr:              cfg.Addr,
 		Handler:           server.Handler(),
+		BaseContext:       server.BaseContext,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      30 * time.Second,
@@ -71,16 +225,40 @@ func runServe(cmd *cobra.Command, args []string) error {
 
 	go func() {
 		logger.Info().Str("addr", cfg.Addr).Msg("Bulwark Web Console listening")
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
 			logger.Error().Err(err).Msg("server error")
 			os.Exit(1)
 		}
 	}()
 
+	if err := sdNotify("READY=1"); err != nil {
+		logger.Warn().Err(err).Msg("Failed to notify systemd of readiness")
+	}
+	watchdogDone := make(chan struct{})
+	defer close(watchdogDone)
+	if interval := sdWatchdogInterval(); interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					_ = sdNotify("WATCHDOG=1")
+				case <-watchdogDone:
+					return
+				}
+			}
+		}()
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
+	_ = sdNotify("STOPPING=1")
 
+	// End running plans and applies first, so the drain below does not wait
+	// out the full timeout on them.
+	server.StopOperations()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	return httpServer.Shutdown(ctx)
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestApplyServeEnvFlags(t *testing.T) {
+	t.Setenv("BULWARK_AUTO_UPDATE_CRON", "")
+	t.Setenv("BULWARK_WEB_TOKEN_WRITE", "")
+	t.Setenv("BULWARK_METRICS_ENABLED", "")
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := NewServeCommand()
+	if err := cmd.ParseFlags([]string{"--auto-update-cron", "0 2 * * *", "--web-token-file", tokenFile, "--metrics"}); err != nil {
+		t.Fatalf("ParseFlags failed: %v", err)
+	}
+	if err := applyServeEnvFlags(cmd); err != nil {
+		t.Fatalf("applyServeEnvFlags failed: %v", err)
+	}
+	for env, want := range map[string]string{
+		"BULWARK_AUTO_UPDATE_CRON": "0 2 * * *",
+		"BULWARK_WEB_TOKEN_WRITE":  "s3cret",
+		"BULWARK_METRICS_ENABLED":  "true",
+	} {
+		if got := os.Getenv(env); got != want {
+			t.Errorf("%s = %q, want %q", env, got, want)
+		}
+	}
+
+	cmd = NewServeCommand()
+	if err := cmd.ParseFlags([]string{"--plan-timeout", "soon"}); err != nil {
+		t.Fatalf("ParseFlags failed: %v", err)
+	}
+	if err := applyServeEnvFlags(cmd); err == nil {
+		t.Error("expected an invalid duration to be rejected")
+	}
+}
+
+func TestSdNotify(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	t.Setenv("NOTIFY_SOCKET", socket)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("sdNotify failed: %v", err)
+	}
+	buf := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != "READY=1" {
+		t.Fatalf("expected READY=1, got %q (%v)", buf[:n], err)
+	}
+
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("READY=1"); err != nil {
+		t.Errorf("expected no-op without NOTIFY_SOCKET, got %v", err)
+	}
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if got := sdWatchdogInterval(); got != 15*time.Second {
+		t.Errorf("expected 15s, got %s", got)
+	}
+	t.Setenv("WATCHDOG_PID", "1")
+	if got := sdWatchdogInterval(); got != 0 {
+		t.Errorf("expected the watchdog of another process to be ignored, got %s", got)
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// NewSnoozeCommand creates the snooze command
+func NewSnoozeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snooze <target>/<service> [duration]",
+		Short: "Defer a service's updates for a while",
+		Long: `Defers a service's updates for a duration such as 12h or 3d. Plans still
+list the updates, but apply runs skip them until the snooze expires. Without a
+duration, shows the service's snooze.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runSnooze,
+	}
+
+	cmd.Flags().String("state", os.Getenv("BULWARK_STATE_DB"), "Path to state database (SQLite)")
+	cmd.Flags().Bool("clear", false, "End the snooze")
+
+	return cmd
+}
+
+func runSnooze(cmd *cobra.Command, args []string) error {
+	stateFile, _ := cmd.Flags().GetString("state")
+	clearSnooze, _ := cmd.Flags().GetBool("clear")
+	if stateFile == "" {
+		return fmt.Errorf("snoozes are kept in the state database; set --state or BULWARK_STATE_DB")
+	}
+	targetName, serviceName, ok := strings.Cut(args[0], "/")
+	if !ok || targetName == "" || serviceName == "" {
+		return fmt.Errorf("expected <target>/<service>, got %q", args[0])
+	}
+
+	var duration time.Duration
+	if len(args) == 2 {
+		if clearSnooze {
+			return fmt.Errorf("--clear takes no duration")
+		}
+		var err error
+		if duration, err = state.ParseSnoozeDuration(args[1]); err != nil {
+			return err
+		}
+	}
+
+	store, err := state.NewSQLiteStore(stateFile, logging.Default())
+	if err != nil {
+		return fmt.Errorf("failed to create state store: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+	if err := store.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize state store: %w", err)
+	}
+
+	service, err := findService(ctx, store, targetName, serviceName)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case clearSnooze:
+		if err := store.DeleteSnooze(ctx, service.ID); err != nil {
+			if errors.Is(err, state.ErrNotFound) {
+				fmt.Printf("%s is not snoozed\n", args[0])
+				return nil
+			}
+			return err
+		}
+		fmt.Printf("Snooze of %s cleared\n", args[0])
+	case duration > 0:
+		snooze := &state.Snooze{ServiceID: service.ID, Until: time.Now().Add(duration)}
+		if err := store.SaveSnooze(ctx, snooze); err != nil {
+			return err
+		}
+		fmt.Printf("%s snoozed until %s\n", args[0], snooze.Until.Format(time.DateTime))
+	default:
+		snoozes, err := store.ListSnoozes(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, snooze := range snoozes {
+			if snooze.ServiceID == service.ID {
+				fmt.Printf("%s snoozed until %s\n", args[0], snooze.Until.Local().Format(time.DateTime))
+				return nil
+			}
+		}
+		fmt.Printf("%s is not snoozed\n", args[0])
+	}
+	return nil
+}
+
+// findService looks up a service by its target and service names among the
+// targets discovery has saved.
+func findService(ctx context.Context, store state.Store, targetName, serviceName string) (*state.Service, error) {
+	target, err := store.GetTargetByName(ctx, targetName)
+	if err != nil {
+		return nil, fmt.Errorf("target %s not found; run discover with --state first", targetName)
+	}
+	services, err := store.GetServicesByTarget(ctx, target.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range services {
+		if services[i].Name == serviceName {
+			return &services[i], nil
+		}
+	}
+	return nil, fmt.Errorf("service %s not found in target %s", serviceName, targetName)
+}
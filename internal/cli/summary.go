@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of check, plan and apply. They are part of the CLI's contract,
+// so wrappers in CI or cron can tell "nothing to do" from "something to do"
+// from "something broke" without parsing output.
+const (
+	ExitOK               = 0 // Nothing to do, or every update applied
+	ExitError            = 1 // The command could not run, e.g. Docker is unreachable
+	ExitUsage            = 2 // Invalid flags
+	ExitUpdatesAvailable = 3 // check or plan found updates
+	ExitFailures         = 4 // An update failed, or a registry lookup did
+)
+
+// exitError carries the exit code of a command's error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// withExitCode returns err exiting with code.
+func withExitCode(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for a command's error: ExitOK for
+// nil, the code the command chose, or ExitError for any other failure.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exit *exitError
+	if errors.As(err, &exit) {
+		return exit.code
+	}
+	return ExitError
+}
+
+// UsageError marks a flag parsing error, for cobra's SetFlagErrorFunc.
+func UsageError(_ *cobra.Command, err error) error {
+	return withExitCode(ExitUsage, err)
+}
+
+// Outcomes of a summary item.
+const (
+	OutcomeUpToDate        = "up_to_date"
+	OutcomeUpdateAvailable = "update_available"
+	OutcomeBlocked         = "blocked" // An update policy holds back
+	OutcomeApplied         = "applied"
+	OutcomeSkipped         = "skipped"
+	OutcomeFailed          = "failed"
+	OutcomeLookupFailed    = "lookup_failed" // The remote digest could not be fetched
+)
+
+// summaryVersion is bumped when a field of Summary changes meaning.
+const summaryVersion = 1
+
+// Summary is the machine-readable result --summary-file writes.
+type Summary struct {
+	Version    int           `json:"version"`
+	Command    string        `json:"command"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	ExitCode   int           `json:"exit_code"`
+	Error      string        `json:"error,omitempty"`
+	Counts     SummaryCounts `json:"counts"`
+	Items      []SummaryItem `json:"items"`
+}
+
+// SummaryCounts counts the items of a summary by outcome.
+type SummaryCounts struct {
+	Services         int `json:"services"`
+	UpToDate         int `json:"up_to_date"`
+	UpdatesAvailable int `json:"updates_available"`
+	Blocked          int `json:"blocked"`
+	Applied          int `json:"applied"`
+	Skipped          int `json:"skipped"`
+	Failed           int `json:"failed"`
+	LookupFailed     int `json:"lookup_failed"`
+}
+
+// SummaryItem is the outcome for one service.
+type SummaryItem struct {
+	Target        string `json:"target"`
+	Service       string `json:"service"`
+	Image         string `json:"image"`
+	CurrentDigest string `json:"current_digest,omitempty"`
+	RemoteDigest  string `json:"remote_digest,omitempty"`
+	Outcome       string `json:"outcome"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+func newSummary(command string) *Summary {
+	return &Summary{Version: summaryVersion, Command: command, StartedAt: time.Now().UTC(), Items: []SummaryItem{}}
+}
+
+// add records item and counts its outcome.
+func (s *Summary) add(item SummaryItem) {
+	s.Items = append(s.Items, item)
+	s.Counts.Services++
+	switch item.Outcome {
+	case OutcomeUpToDate:
+		s.Counts.UpToDate++
+	case OutcomeUpdateAvailable:
+		s.Counts.UpdatesAvailable++
+	case OutcomeBlocked:
+		s.Counts.UpdatesAvailable++
+		s.Counts.Blocked++
+	case OutcomeApplied:
+		s.Counts.Applied++
+	case OutcomeSkipped:
+		s.Counts.Skipped++
+	case OutcomeFailed:
+		s.Counts.Failed++
+	case OutcomeLookupFailed:
+		s.Counts.LookupFailed++
+	}
+}
+
+// result returns the error a command with this summary exits with: a
+// failure takes precedence over available updates.
+func (s *Summary) result() error {
+	switch {
+	case s.Counts.Failed > 0:
+		return withExitCode(ExitFailures, fmt.Errorf("%d updates failed", s.Counts.Failed))
+	case s.Counts.LookupFailed > 0:
+		return withExitCode(ExitFailures, fmt.Errorf("%d registry lookups failed", s.Counts.LookupFailed))
+	case s.Counts.UpdatesAvailable > 0:
+		return withExitCode(ExitUpdatesAvailable, fmt.Errorf("updates available"))
+	}
+	return nil
+}
+
+// finish completes the summary with the command's result and writes it to
+// path, if one is given. It returns err, or the write's failure when the
+// command itself succeeded. Available updates are a result rather than an
+// error, so cobra neither reports them nor prints cmd's usage for them.
+func (s *Summary) finish(cmd *cobra.Command, path string, err error) error {
+	switch ExitCode(err) {
+	case ExitUpdatesAvailable:
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	case ExitFailures:
+		cmd.SilenceUsage = true
+	}
+	if path == "" {
+		return err
+	}
+	s.FinishedAt = time.Now().UTC()
+	s.ExitCode = ExitCode(err)
+	if err != nil && s.ExitCode != ExitUpdatesAvailable {
+		s.Error = err.Error()
+	}
+	if writeErr := writeSummary(path, s); writeErr != nil {
+		if err == nil {
+			return writeErr
+		}
+		fmt.Fprintf(os.Stderr, "failed to write summary file: %v\n", writeErr)
+	}
+	return err
+}
+
+// writeSummary writes summary to path through a temporary file, so a
+// wrapper never reads half a summary.
+func writeSummary(path string, summary *Summary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".bulwark-summary-*")
+	if err != nil {
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write summary file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, ExitOK},
+		{errors.New("no docker"), ExitError},
+		{UsageError(nil, errors.New("unknown flag")), ExitUsage},
+		{fmt.Errorf("apply: %w", withExitCode(ExitFailures, errors.New("1 updates failed"))), ExitFailures},
+	}
+	for _, c := range cases {
+		if got := ExitCode(c.err); got != c.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", c.err, got, c.want)
+		}
+	}
+}
+
+func TestSummaryResult(t *testing.T) {
+	summary := newSummary("check")
+	summary.add(SummaryItem{Service: "db", Outcome: OutcomeUpToDate})
+	if err := summary.result(); err != nil {
+		t.Fatalf("expected no error when everything is up to date, got %v", err)
+	}
+
+	summary.add(SummaryItem{Service: "web", Outcome: OutcomeBlocked})
+	if got := ExitCode(summary.result()); got != ExitUpdatesAvailable {
+		t.Errorf("exit code with an update held back = %d, want %d", got, ExitUpdatesAvailable)
+	}
+	if summary.Counts.UpdatesAvailable != 1 || summary.Counts.Blocked != 1 {
+		t.Errorf("unexpected counts %+v", summary.Counts)
+	}
+
+	summary.add(SummaryItem{Service: "api", Outcome: OutcomeLookupFailed})
+	if got := ExitCode(summary.result()); got != ExitFailures {
+		t.Errorf("exit code with a failed lookup = %d, want %d", got, ExitFailures)
+	}
+	if summary.Counts.Services != 3 {
+		t.Errorf("services = %d, want 3", summary.Counts.Services)
+	}
+}
+
+func TestSummaryFinishWritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.json")
+	summary := newSummary("plan")
+	summary.add(SummaryItem{Target: "app", Service: "web", Image: "nginx:latest", Outcome: OutcomeUpdateAvailable})
+
+	err := summary.finish(NewPlanCommand(), path, summary.result())
+	if ExitCode(err) != ExitUpdatesAvailable {
+		t.Fatalf("expected finish to keep the command's result, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read summary: %v", err)
+	}
+	var written Summary
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatalf("summary is not JSON: %v", err)
+	}
+	if written.Command != "plan" || written.ExitCode != ExitUpdatesAvailable || written.Error != "" {
+		t.Errorf("unexpected summary %+v", written)
+	}
+	if len(written.Items) != 1 || written.Items[0].Outcome != OutcomeUpdateAvailable {
+		t.Errorf("unexpected items %+v", written.Items)
+	}
+	if written.FinishedAt.IsZero() {
+		t.Error("expected finished_at to be set")
+	}
+
+	if err := newSummary("plan").finish(NewPlanCommand(), filepath.Join(t.TempDir(), "missing", "summary.json"), nil); err == nil {
+		t.Error("expected a summary that cannot be written to fail an otherwise successful command")
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/api"
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"github.com/spf13/cobra"
+)
+
+// NewTagCommand creates the tag command
+func NewTagCommand() *cobra.Command {
+	rootDefault := os.Getenv("BULWARK_ROOT")
+	if rootDefault == "" {
+		rootDefault = "/docker_data"
+	}
+
+	cmd := &cobra.Command{
+		Use:   "tag <target>/<service> <tag>",
+		Short: "Switch a compose service to another image tag",
+		Long: `Rewrites the image tag of a compose service in its compose file, then pulls,
+recreates and probes the service. When the change fails the compose file is
+restored and the previous version keeps running. The change is recorded in
+history as a version change.`,
+		Args: cobra.ExactArgs(2),
+		RunE: runTag,
+	}
+
+	cmd.Flags().String("root", rootDefault, "Root directory to scan for compose projects")
+	cmd.Flags().String("state", os.Getenv("BULWARK_STATE_DB"), "Path to state database (SQLite) for history")
+	cmd.Flags().Bool("dry-run", false, "Show the change without making it")
+
+	return cmd
+}
+
+func runTag(cmd *cobra.Command, args []string) error {
+	root, _ := cmd.Flags().GetString("root")
+	stateFile, _ := cmd.Flags().GetString("state")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	targetName, serviceName, ok := strings.Cut(args[0], "/")
+	if !ok || targetName == "" || serviceName == "" {
+		return fmt.Errorf("expected <target>/<service>, got %q", args[0])
+	}
+	if !dryRun && api.ResolveProfile(os.Getenv("BULWARK_PROFILE")) == api.ProfileObserver {
+		return fmt.Errorf("tag changes are disabled in the observer profile")
+	}
+
+	logger := logging.Default()
+	ctx := context.Background()
+
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		return fmt.Errorf("failed to create Docker client: %w", err)
+	}
+	defer func() { _ = dockerClient.Close() }()
+
+	var store state.Store
+	if stateFile != "" {
+		sqliteStore, err := state.NewSQLiteStore(stateFile, logger)
+		if err != nil {
+			return fmt.Errorf("failed to create state store: %w", err)
+		}
+		defer func() { _ = sqliteStore.Close() }()
+		if err := sqliteStore.Initialize(ctx); err != nil {
+			return fmt.Errorf("failed to initialize state store: %w", err)
+		}
+		store = sqliteStore
+	}
+
+	discoverer := discovery.NewDiscoverer(logger, dockerClient)
+	if store != nil {
+		discoverer = discoverer.WithStore(store)
+	}
+	target, err := discoverer.DiscoverTarget(ctx, root, targetName)
+	if err != nil {
+		return fmt.Errorf("failed to discover target: %w", err)
+	}
+	var service *state.Service
+	for i := range target.Services {
+		if target.Services[i].Name == serviceName {
+			service = &target.Services[i]
+			break
+		}
+	}
+	if service == nil {
+		return fmt.Errorf("service %s not found in target %s", serviceName, targetName)
+	}
+
+	exec := executor.NewExecutor(dockerClient, policy.NewEngine(logger), store, logger, dryRun).
+		WithTrigger(state.TriggerManualCLI, cliActor())
+	result, err := exec.ChangeTag(ctx, target, service, args[1])
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("version change of %s failed: %s", args[0], result.ErrorMessage)
+	}
+	if dryRun {
+		fmt.Printf("Would switch %s from %s to %s\n", args[0], service.Image, args[1])
+		return nil
+	}
+	fmt.Printf("✓ %s now runs %s\n", args[0], args[1])
+	return nil
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -19,6 +19,7 @@ type ComposeScanner struct {
 	logger        *logging.Logger
 	dockerClient  *docker.Client
 	composeRunner *docker.ComposeRunner
+	ids           state.IDStrategy
 }
 
 // NewComposeScanner creates a new compose scanner
@@ -27,6 +28,7 @@ func NewComposeScanner(logger *logging.Logger, dockerClient *docker.Client) *Com
 		logger:        logger.WithComponent("compose-scanner"),
 		dockerClient:  dockerClient,
 		composeRunner: docker.NewComposeRunner(),
+		ids:           state.IDStrategyFromEnv(),
 	}
 }
 
@@ -39,7 +41,12 @@ type ComposeFile struct {
 // ComposeService represents a service in docker-compose.yml
 type ComposeService struct {
 	Image       string             `yaml:"image"`
-	Labels      interface{}        `yaml:"labels"` // Can be map or array
+	Build       interface{}        `yaml:"build,omitempty"`      // Build context string or map
+	Labels      interface{}        `yaml:"labels"`               // Can be map or array
+	DependsOn   interface{}        `yaml:"depends_on,omitempty"` // List or map of service names
+	Platform    string             `yaml:"platform,omitempty"`
+	PullPolicy  string             `yaml:"pull_policy,omitempty"`
+	StopGrace   string             `yaml:"stop_grace_period,omitempty"`
 	HealthCheck *HealthCheckConfig `yaml:"healthcheck,omitempty"`
 }
 
@@ -112,9 +119,9 @@ func (s *ComposeScanner) parseComposeFile(ctx context.Context, composePath strin
 	}
 
 	// Create target
-	projectName := filepath.Base(filepath.Dir(composePath))
+	projectName := s.projectName(ctx, composePath)
 	target := &state.Target{
-		ID:        state.GenerateTargetID(state.TargetTypeCompose, projectName, composePath),
+		ID:        state.TargetIDFor(s.ids, state.TargetTypeCompose, projectName, composePath),
 		Type:      state.TargetTypeCompose,
 		Name:      projectName,
 		Path:      composePath,
@@ -126,36 +133,57 @@ func (s *ComposeScanner) parseComposeFile(ctx context.Context, composePath strin
 
 	// Parse services
 	for serviceName, composeService := range composeFile.Services {
-		if composeService.Image == "" {
-			// Skip services without an image (build-only services)
-			continue
-		}
-
 		// Convert labels to map[string]string (handles both map and array formats)
 		labelMap := convertLabelsToMap(composeService.Labels)
 
+		image := composeService.Image
+		built := composeService.Build != nil
+		if image == "" {
+			if !built {
+				continue
+			}
+			// Build-only services are rebuilt rather than pulled, which
+			// operators must opt in to.
+			if strings.ToLower(labelMap[LabelBuild]) != "true" {
+				s.logger.Debug().
+					Str("target", target.Name).
+					Str("service", serviceName).
+					Msg("Skipping build-only service (set bulwark.build=true to rebuild it)")
+				continue
+			}
+			image = builtImageName(target.Name, serviceName)
+		}
+
 		// Parse labels
-		labels := ParseLabels(labelMap, composeService.Image)
+		labels := ParseLabels(labelMap, image)
 
 		// Get current digest from Docker if container is running
-		digest := s.getCurrentDigest(ctx, target.Name, serviceName, composeService.Image)
+		digest, imageID := s.getCurrentDigest(ctx, target.Name, serviceName, image)
 
 		// Parse healthcheck
+		pullPolicy := strings.ToLower(strings.TrimSpace(composeService.PullPolicy))
+
 		var healthCheck *state.HealthCheck
 		if composeService.HealthCheck != nil {
 			healthCheck = parseHealthCheck(composeService.HealthCheck)
 		}
 
 		service := state.Service{
-			ID:            state.GenerateServiceID(target.ID, serviceName),
-			TargetID:      target.ID,
-			Name:          serviceName,
-			Image:         composeService.Image,
-			CurrentDigest: digest,
-			Labels:        labels,
-			HealthCheck:   healthCheck,
-			CreatedAt:     time.Now(),
-			UpdatedAt:     time.Now(),
+			ID:              state.GenerateServiceID(target.ID, serviceName),
+			TargetID:        target.ID,
+			Name:            serviceName,
+			Image:           image,
+			CurrentDigest:   digest,
+			Labels:          labels,
+			HealthCheck:     healthCheck,
+			BaseImage:       resolveBaseImage(ctx, s.dockerClient, imageID, nil),
+			Build:           built && (labels.Build || pullPolicy == state.PullPolicyBuild),
+			DependsOn:       parseDependsOn(composeService.DependsOn),
+			Platform:        strings.TrimSpace(composeService.Platform),
+			PullPolicy:      pullPolicy,
+			StopGracePeriod: parseComposeDuration(composeService.StopGrace),
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
 		}
 
 		target.Services = append(target.Services, service)
@@ -164,18 +192,43 @@ func (s *ComposeScanner) parseComposeFile(ctx context.Context, composePath strin
 	return target, nil
 }
 
-// getCurrentDigest gets the current digest of a running container
-func (s *ComposeScanner) getCurrentDigest(ctx context.Context, projectName, serviceName, imageName string) string {
+// builtImageName is the image name docker compose v2 gives a build-only
+// service, using compose's project name normalization.
+func builtImageName(projectName, serviceName string) string {
+	return docker.NormalizeProjectName(projectName) + "-" + serviceName
+}
+
+// projectName resolves the compose project name of composePath, which is
+// what its containers are labeled with, as docker.ResolveProjectName does.
+func (s *ComposeScanner) projectName(ctx context.Context, composePath string) string {
+	name := docker.ComposeProjectName(composePath)
+	containers, err := s.dockerClient.ListContainers(ctx, false)
+	if err != nil {
+		return name
+	}
+	if resolved := docker.ResolveProjectName(composePath, containers); resolved != name {
+		s.logger.Debug().
+			Str("path", composePath).
+			Str("config_name", name).
+			Str("project", resolved).
+			Msg("Using the project name of the file's running containers")
+		return resolved
+	}
+	return name
+}
+
+// getCurrentDigest gets the current digest and image ID of a running container
+func (s *ComposeScanner) getCurrentDigest(ctx context.Context, projectName, serviceName, imageName string) (string, string) {
 	// List containers with label filters
 	containers, err := s.dockerClient.ListContainers(ctx, false)
 	if err != nil {
-		return ""
+		return "", ""
 	}
 
 	// Find container for this service
 	for _, container := range containers {
 		// Check if it's part of the compose project
-		if container.Labels["com.docker.compose.project"] == projectName &&
+		if container.Labels[docker.ProjectLabel] == projectName &&
 			container.Labels["com.docker.compose.service"] == serviceName {
 
 			// Inspect to get image digest
@@ -184,11 +237,41 @@ func (s *ComposeScanner) getCurrentDigest(ctx context.Context, projectName, serv
 				continue
 			}
 
-			return resolveRepoDigest(ctx, s.dockerClient, imageName, inspect.Image)
+			return resolveRepoDigest(ctx, s.dockerClient, imageName, inspect.Image), inspect.Image
 		}
 	}
 
-	return ""
+	return "", ""
+}
+
+// parseComposeDuration parses a compose duration such as "1m30s". Invalid or
+// empty values yield zero.
+func parseComposeDuration(value string) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// parseDependsOn returns the service names from a compose depends_on entry,
+// which is either a list of names or a map keyed by name.
+func parseDependsOn(dependsOn interface{}) []string {
+	var names []string
+	switch v := dependsOn.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	case map[string]interface{}:
+		for name := range v {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
 }
 
 // convertLabelsToMap converts labels from interface{} (map or array) to map[string]string
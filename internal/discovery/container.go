@@ -11,12 +11,14 @@ import (
 	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/state"
+	"gopkg.in/yaml.v3"
 )
 
 // ContainerScanner scans for loose containers with Bulwark labels
 type ContainerScanner struct {
 	logger       *logging.Logger
 	dockerClient *docker.Client
+	ids          state.IDStrategy
 }
 
 // NewContainerScanner creates a new container scanner
@@ -24,6 +26,7 @@ func NewContainerScanner(logger *logging.Logger, dockerClient *docker.Client) *C
 	return &ContainerScanner{
 		logger:       logger.WithComponent("container-scanner"),
 		dockerClient: dockerClient,
+		ids:          state.IDStrategyFromEnv(),
 	}
 }
 
@@ -31,6 +34,7 @@ func NewContainerScanner(logger *logging.Logger, dockerClient *docker.Client) *C
 func (s *ContainerScanner) ScanContainers(ctx context.Context) ([]state.Target, error) {
 	s.logger.Info().Msg("Scanning running containers for Bulwark labels")
 	digestCache := make(map[string]string)
+	baseCache := make(map[string]*state.BaseImage)
 
 	// List all running containers
 	containers, err := s.dockerClient.ListContainers(ctx, false)
@@ -63,7 +67,7 @@ func (s *ContainerScanner) ScanContainers(ctx context.Context) ([]state.Target,
 
 	// Process compose projects
 	for projectName, projectContainers := range composeProjects {
-		target := s.createComposeTarget(ctx, projectName, projectContainers, digestCache)
+		target := s.createComposeTarget(ctx, projectName, projectContainers, digestCache, baseCache)
 		if target != nil {
 			targets = append(targets, *target)
 		}
@@ -92,7 +96,7 @@ func (s *ContainerScanner) ScanContainers(ctx context.Context) ([]state.Target,
 		// Create target for this loose container
 		containerName := getContainerName(container.Names)
 		target := state.Target{
-			ID:        state.GenerateTargetID(state.TargetTypeContainer, containerName, container.ID),
+			ID:        state.TargetIDFor(s.ids, state.TargetTypeContainer, containerName, container.ID),
 			Type:      state.TargetTypeContainer,
 			Name:      containerName,
 			Path:      container.ID, // Store container ID as path for loose containers
@@ -114,6 +118,7 @@ func (s *ContainerScanner) ScanContainers(ctx context.Context) ([]state.Target,
 			CurrentDigest: digest,
 			Labels:        labels,
 			HealthCheck:   parseContainerHealthCheck(inspect.State.Health),
+			BaseImage:     resolveBaseImage(ctx, s.dockerClient, inspect.Image, baseCache),
 			CreatedAt:     time.Now(),
 			UpdatedAt:     time.Now(),
 		}
@@ -133,7 +138,7 @@ func (s *ContainerScanner) ScanContainers(ctx context.Context) ([]state.Target,
 }
 
 // createComposeTarget creates a target from a group of compose containers
-func (s *ContainerScanner) createComposeTarget(ctx context.Context, projectName string, containers []docker.Container, digestCache map[string]string) *state.Target {
+func (s *ContainerScanner) createComposeTarget(ctx context.Context, projectName string, containers []docker.Container, digestCache map[string]string, baseCache map[string]*state.BaseImage) *state.Target {
 	if len(containers) == 0 {
 		return nil
 	}
@@ -142,7 +147,7 @@ func (s *ContainerScanner) createComposeTarget(ctx context.Context, projectName
 
 	// Create target
 	target := state.Target{
-		ID:        state.GenerateTargetID(state.TargetTypeCompose, projectName, composePath),
+		ID:        state.TargetIDFor(s.ids, state.TargetTypeCompose, projectName, composePath),
 		Type:      state.TargetTypeCompose,
 		Name:      projectName,
 		Path:      composePath,
@@ -152,6 +157,10 @@ func (s *ContainerScanner) createComposeTarget(ctx context.Context, projectName
 		UpdatedAt: time.Now(),
 	}
 
+	// Only services the compose file builds may be rebuilt, whatever their
+	// labels say.
+	built := buildServices(composePath)
+
 	// Add each container as a service
 	for _, container := range containers {
 		serviceName := container.Labels["com.docker.compose.service"]
@@ -183,6 +192,8 @@ func (s *ContainerScanner) createComposeTarget(ctx context.Context, projectName
 			CurrentDigest: digest,
 			Labels:        labels,
 			HealthCheck:   parseContainerHealthCheck(inspect.State.Health),
+			BaseImage:     resolveBaseImage(ctx, s.dockerClient, inspect.Image, baseCache),
+			Build:         labels.Build && built[serviceName],
 			CreatedAt:     time.Now(),
 			UpdatedAt:     time.Now(),
 		}
@@ -204,6 +215,29 @@ func (s *ContainerScanner) createComposeTarget(ctx context.Context, projectName
 	return &target
 }
 
+// buildServices lists the services with a build: section in the compose file
+// at composePath. An unreadable file has none.
+func buildServices(composePath string) map[string]bool {
+	built := make(map[string]bool)
+	if composePath == "" {
+		return built
+	}
+	data, err := os.ReadFile(composePath)
+	if err != nil {
+		return built
+	}
+	var composeFile ComposeFile
+	if err := yaml.Unmarshal(data, &composeFile); err != nil {
+		return built
+	}
+	for name, service := range composeFile.Services {
+		if service.Build != nil {
+			built[name] = true
+		}
+	}
+	return built
+}
+
 func resolveComposePath(labels map[string]string) string {
 	if labels == nil {
 		return ""
@@ -7,8 +7,51 @@ import (
 
 	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
 )
 
+// OCI image labels recording the base a locally built image was built from.
+// BuildKit can set these automatically; they can also be set in the Dockerfile.
+const (
+	LabelOCIBaseName   = "org.opencontainers.image.base.name"
+	LabelOCIBaseDigest = "org.opencontainers.image.base.digest"
+)
+
+// resolveBaseImage returns the recorded base of a locally built image.
+// Images pulled from a registry (those with repo digests) return nil: their
+// publisher is responsible for rebuilding on base updates.
+func resolveBaseImage(ctx context.Context, dockerClient *docker.Client, imageID string, cache map[string]*state.BaseImage) *state.BaseImage {
+	if imageID == "" {
+		return nil
+	}
+	if cache != nil {
+		if base, ok := cache[imageID]; ok {
+			return base
+		}
+	}
+
+	var base *state.BaseImage
+	if inspect, err := dockerClient.ImageInspect(ctx, imageID); err == nil {
+		base = baseImageFromLabels(inspect.RepoDigests, inspect.Labels)
+	}
+	if cache != nil {
+		cache[imageID] = base
+	}
+	return base
+}
+
+func baseImageFromLabels(repoDigests []string, labels map[string]string) *state.BaseImage {
+	if len(repoDigests) > 0 {
+		return nil
+	}
+	name := strings.TrimSpace(labels[LabelOCIBaseName])
+	digest := strings.TrimSpace(labels[LabelOCIBaseDigest])
+	if name == "" || digest == "" {
+		return nil
+	}
+	return &state.BaseImage{Name: name, Digest: digest}
+}
+
 // resolveRepoDigest returns a repo digest that matches the image reference.
 // Falls back to the image ID if no repo digest is available.
 func resolveRepoDigest(ctx context.Context, dockerClient *docker.Client, imageName, imageID string) string {
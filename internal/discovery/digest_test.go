@@ -0,0 +1,23 @@
+package discovery
+
+import "testing"
+
+func TestBaseImageFromLabels(t *testing.T) {
+	labels := map[string]string{
+		LabelOCIBaseName:   "docker.io/library/alpine:3.20",
+		LabelOCIBaseDigest: "sha256:abc",
+	}
+
+	base := baseImageFromLabels(nil, labels)
+	if base == nil || base.Name != "docker.io/library/alpine:3.20" || base.Digest != "sha256:abc" {
+		t.Fatalf("unexpected base image: %+v", base)
+	}
+
+	if base := baseImageFromLabels([]string{"nginx@sha256:def"}, labels); base != nil {
+		t.Errorf("expected registry images to be ignored, got %+v", base)
+	}
+
+	if base := baseImageFromLabels(nil, map[string]string{LabelOCIBaseName: "alpine"}); base != nil {
+		t.Errorf("expected nil without a base digest, got %+v", base)
+	}
+}
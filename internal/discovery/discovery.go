@@ -3,6 +3,7 @@ package discovery
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/itsmrshow/bulwark/internal/docker"
@@ -17,7 +18,7 @@ type Discoverer struct {
 	dockerClient     *docker.Client
 	composeScanner   *ComposeScanner
 	containerScanner *ContainerScanner
-	store            state.Store // Optional state persistence
+	store            state.TargetStore // Optional state persistence
 }
 
 // NewDiscoverer creates a new discoverer
@@ -32,7 +33,7 @@ func NewDiscoverer(logger *logging.Logger, dockerClient *docker.Client) *Discove
 }
 
 // WithStore sets the state store for persistence
-func (d *Discoverer) WithStore(store state.Store) *Discoverer {
+func (d *Discoverer) WithStore(store state.TargetStore) *Discoverer {
 	d.store = store
 	return d
 }
@@ -70,6 +71,7 @@ func (d *Discoverer) Discover(ctx context.Context, basePath string) ([]state.Tar
 
 	// Deduplicate targets
 	allTargets = d.deduplicateTargets(allTargets)
+	d.reportLabelIssues(allTargets)
 
 	// Persist to store if configured
 	if d.store != nil {
@@ -93,6 +95,54 @@ func (d *Discoverer) Discover(ctx context.Context, basePath string) ([]state.Tar
 	return allTargets, nil
 }
 
+// relinkDirectoryTarget carries over the history of a compose target stored
+// under the ID it had when compose targets were named after their directory.
+// A project whose name: or COMPOSE_PROJECT_NAME differs from the directory
+// name gets a new ID; the old one is relinked to it and kept as an alias.
+func (d *Discoverer) relinkDirectoryTarget(ctx context.Context, target *state.Target) {
+	if target.Type != state.TargetTypeCompose || target.Path == "" {
+		return
+	}
+	oldID := state.TargetIDFor(d.containerScanner.ids, state.TargetTypeCompose, filepath.Base(filepath.Dir(target.Path)), target.Path)
+	if oldID == target.ID {
+		return
+	}
+	old, err := d.store.GetTarget(ctx, oldID)
+	if err != nil || old.Path != target.Path {
+		return
+	}
+	moved, err := d.store.RelinkTarget(ctx, oldID, target.ID, nil)
+	if err != nil {
+		d.logger.Warn().
+			Err(err).
+			Str("target", target.Name).
+			Str("old_id", oldID).
+			Msg("Failed to relink the target's directory-named ID; run bulwark db relink")
+		return
+	}
+	d.logger.Info().
+		Str("target", target.Name).
+		Str("old_id", oldID).
+		Int("history_moved", moved).
+		Msg("Relinked the target's directory-named ID to its project name")
+}
+
+// reportLabelIssues logs the labels of managed services that Bulwark does
+// not understand, so typos do not go unnoticed.
+func (d *Discoverer) reportLabelIssues(targets []state.Target) {
+	for _, target := range targets {
+		for _, service := range target.Services {
+			for _, issue := range service.Labels.Issues {
+				d.logger.Warn().
+					Str("target", target.Name).
+					Str("service", service.Name).
+					Str("label", issue.Label).
+					Msg(issue.Message)
+			}
+		}
+	}
+}
+
 // deduplicateTargets removes duplicate targets based on ID
 func (d *Discoverer) deduplicateTargets(targets []state.Target) []state.Target {
 	seen := make(map[string]bool)
@@ -121,7 +171,7 @@ func (d *Discoverer) DiscoverTarget(ctx context.Context, basePath, targetID stri
 		}
 	}
 
-	return nil, fmt.Errorf("target not found: %s", targetID)
+	return nil, fmt.Errorf("target %w: %s", state.ErrNotFound, targetID)
 }
 
 // CountServicesByPolicy returns statistics on services by policy
@@ -188,6 +238,10 @@ func (d *Discoverer) persistTargets(ctx context.Context, targets []state.Target)
 	for i := range targets {
 		target := &targets[i]
 		originalTargetID := target.ID
+		// A target relinked with bulwark db relink carries on under its new ID.
+		if newID, err := d.store.ResolveAlias(ctx, target.ID); err == nil {
+			target.ID = newID
+		}
 
 		// Save target
 		if err := d.store.SaveTarget(ctx, target); err != nil {
@@ -198,7 +252,7 @@ func (d *Discoverer) persistTargets(ctx context.Context, targets []state.Target)
 			continue
 		}
 
-		// If target ID was normalized/reused by the store, update service foreign keys.
+		// If target ID was aliased or reused by the store, update service foreign keys.
 		if target.ID != originalTargetID {
 			for j := range target.Services {
 				target.Services[j].TargetID = target.ID
@@ -216,6 +270,8 @@ func (d *Discoverer) persistTargets(ctx context.Context, targets []state.Target)
 					Msg("Failed to save service")
 			}
 		}
+
+		d.relinkDirectoryTarget(ctx, target)
 	}
 
 	d.logger.Debug().
@@ -1,8 +1,13 @@
 package discovery
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
 
@@ -110,3 +115,91 @@ func TestGetContainerName(t *testing.T) {
 		}
 	}
 }
+
+func TestPersistTargets_RelinksDirectoryNamedTarget(t *testing.T) {
+	ctx := context.Background()
+	store := state.NewMemoryStore()
+	d := &Discoverer{
+		logger:           logging.Default(),
+		containerScanner: &ContainerScanner{ids: state.IDStrategyPath},
+		store:            store,
+	}
+	path := "/srv/media/compose.yaml"
+
+	// Stored before project names were resolved: named after the directory.
+	old := &state.Target{ID: state.GenerateTargetID(state.TargetTypeCompose, "media", path), Type: state.TargetTypeCompose, Name: "media", Path: path}
+	if err := store.SaveTarget(ctx, old); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	oldWeb := &state.Service{ID: state.GenerateServiceID(old.ID, "web"), TargetID: old.ID, Name: "web", Labels: state.DefaultLabels()}
+	if err := store.SaveService(ctx, oldWeb); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	now := time.Now()
+	if err := store.SaveUpdateResult(ctx, &state.UpdateResult{TargetID: old.ID, ServiceID: oldWeb.ID, ServiceName: "web", Success: true, StartedAt: now, CompletedAt: now}); err != nil {
+		t.Fatalf("SaveUpdateResult failed: %v", err)
+	}
+
+	target := state.Target{ID: state.GenerateTargetID(state.TargetTypeCompose, "media-stack", path), Type: state.TargetTypeCompose, Name: "media-stack", Path: path}
+	target.Services = []state.Service{{ID: state.GenerateServiceID(target.ID, "web"), TargetID: target.ID, Name: "web", Labels: state.DefaultLabels()}}
+	if err := d.persistTargets(ctx, []state.Target{target}); err != nil {
+		t.Fatalf("persistTargets failed: %v", err)
+	}
+
+	if id, err := store.ResolveAlias(ctx, old.ID); err != nil || id != target.ID {
+		t.Errorf("ResolveAlias(old target) = %q, %v; want %q", id, err, target.ID)
+	}
+	history, err := store.GetUpdateHistoryByService(ctx, target.Services[0].ID, 10)
+	if err != nil || len(history) != 1 {
+		t.Errorf("expected the history under the new service, got %+v (%v)", history, err)
+	}
+}
+
+func TestBuildServices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compose.yaml")
+	compose := "services:\n  app:\n    build: .\n  cache:\n    image: redis:7\n"
+	if err := os.WriteFile(path, []byte(compose), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	built := buildServices(path)
+	if !built["app"] || built["cache"] {
+		t.Errorf("expected only app to be built, got %v", built)
+	}
+	if built := buildServices(filepath.Join(t.TempDir(), "missing.yaml")); len(built) != 0 {
+		t.Errorf("expected no build services for a missing file, got %v", built)
+	}
+}
+
+func TestParseDependsOn(t *testing.T) {
+	list := parseDependsOn([]interface{}{"redis", "db"})
+	if len(list) != 2 || list[0] != "db" || list[1] != "redis" {
+		t.Errorf("unexpected list form result: %v", list)
+	}
+
+	long := parseDependsOn(map[string]interface{}{
+		"db": map[string]interface{}{"condition": "service_healthy"},
+	})
+	if len(long) != 1 || long[0] != "db" {
+		t.Errorf("unexpected map form result: %v", long)
+	}
+
+	if names := parseDependsOn(nil); len(names) != 0 {
+		t.Errorf("expected no dependencies, got %v", names)
+	}
+}
+
+func TestParseComposeDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"1m30s": 90 * time.Second,
+		" 10s ": 10 * time.Second,
+		"":      0,
+		"soon":  0,
+		"-5s":   0,
+	}
+	for value, want := range tests {
+		if got := parseComposeDuration(value); got != want {
+			t.Errorf("parseComposeDuration(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
@@ -60,3 +60,9 @@ func TestResolveImageRef(t *testing.T) {
 		})
 	}
 }
+
+func TestBuiltImageName(t *testing.T) {
+	if got := builtImageName("My.App", "web"); got != "myapp-web" {
+		t.Errorf("builtImageName() = %q, want %q", got, "myapp-web")
+	}
+}
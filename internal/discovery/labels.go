@@ -2,8 +2,11 @@ package discovery
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/itsmrshow/bulwark/internal/state"
 )
@@ -14,6 +17,14 @@ const (
 	LabelPolicy          = "bulwark.policy"
 	LabelTier            = "bulwark.tier"
 	LabelDefinition      = "bulwark.definition"
+	LabelBuild           = "bulwark.build"
+	LabelStrategy        = "bulwark.strategy"
+	LabelParallel        = "bulwark.project.parallel"
+	LabelComposeUpFlags  = "bulwark.compose.up_flags"
+	LabelComposeForce    = "bulwark.compose.force_recreate"
+	LabelComposeDeps     = "bulwark.compose.deps"
+	LabelDependsOn       = "bulwark.depends_on_target"
+	LabelDependentAction = "bulwark.depends_on_target.action"
 	LabelProbeType       = "bulwark.probe.type"
 	LabelProbeURL        = "bulwark.probe.url"
 	LabelProbeStatus     = "bulwark.probe.expect_status"
@@ -21,9 +32,55 @@ const (
 	LabelProbeTCPPort    = "bulwark.probe.tcp_port"
 	LabelProbeLogPattern = "bulwark.probe.log_pattern"
 	LabelProbeWindowSec  = "bulwark.probe.window_sec"
+	LabelProbeLogStream  = "bulwark.probe.log_stream"
+	LabelProbeLogCase    = "bulwark.probe.log_ignore_case"
+	LabelProbeLogLines   = "bulwark.probe.log_max_lines"
+	LabelProbeLogFail    = "bulwark.probe.log_fail_pattern"
+	LabelProbeFail       = "bulwark.probe.fail_pattern"
+	LabelProbeFailWatch  = "bulwark.probe.fail_watch"
 	LabelProbeStability  = "bulwark.probe.stability_sec"
+	LabelProbeRestarts   = "bulwark.probe.max_restarts"
+	LabelRetryMax        = "bulwark.retry.max"
+	LabelRetryBackoff    = "bulwark.retry.backoff"
+	LabelLockMode        = "bulwark.lock.mode"
+	LabelLockTimeout     = "bulwark.lock.timeout"
+	LabelDrainURL        = "bulwark.drain.url"
+	LabelDrainBackend    = "bulwark.drain.backend"
+	LabelDrainTimeout    = "bulwark.drain.timeout"
+	LabelGroup           = "bulwark.group"
+	LabelCheckTTL        = "bulwark.check.ttl"
+	LabelAllowMutableTag = "bulwark.allow_mutable_tag"
+	LabelSchema          = "bulwark.schema"
 )
 
+// knownLabels are the label keys ParseLabels reads.
+var knownLabels = map[string]bool{
+	LabelEnabled: true, LabelPolicy: true, LabelTier: true, LabelDefinition: true,
+	LabelBuild: true, LabelStrategy: true, LabelParallel: true, LabelComposeUpFlags: true,
+	LabelComposeForce: true, LabelComposeDeps: true,
+	LabelDependsOn: true, LabelDependentAction: true, LabelProbeType: true, LabelProbeURL: true,
+	LabelProbeStatus: true, LabelProbeTCPHost: true, LabelProbeTCPPort: true,
+	LabelProbeLogPattern: true, LabelProbeWindowSec: true, LabelProbeLogStream: true,
+	LabelProbeLogCase: true, LabelProbeLogLines: true, LabelProbeLogFail: true, LabelProbeStability: true,
+	LabelProbeFail: true, LabelProbeFailWatch: true,
+	LabelProbeRestarts: true, LabelRetryMax: true, LabelRetryBackoff: true, LabelLockMode: true,
+	LabelLockTimeout: true, LabelDrainURL: true, LabelDrainBackend: true, LabelDrainTimeout: true,
+	LabelGroup: true, LabelCheckTTL: true, LabelAllowMutableTag: true, LabelSchema: true,
+}
+
+// UnknownLabels returns the bulwark.* keys of labels that Bulwark does not
+// read, sorted, e.g. a misspelled bulwark.polcy.
+func UnknownLabels(labels map[string]string) []string {
+	var unknown []string
+	for key := range labels {
+		if strings.HasPrefix(key, "bulwark.") && !knownLabels[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
 // Known database images that should default to stateful tier
 var knownDatabases = []string{
 	"postgres", "postgresql",
@@ -50,6 +107,55 @@ func ParseLabels(labels map[string]string, imageName string) state.Labels {
 		result.Enabled = strings.ToLower(enabled) == "true"
 	}
 
+	if build, ok := labels[LabelBuild]; ok {
+		result.Build = strings.ToLower(build) == "true"
+	}
+
+	if parallel, ok := labels[LabelParallel]; ok {
+		result.Parallel = strings.ToLower(parallel) == "true"
+	}
+
+	if flags, ok := labels[LabelComposeUpFlags]; ok {
+		result.ComposeUpFlags = strings.FieldsFunc(flags, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	}
+
+	if force, ok := labels[LabelComposeForce]; ok {
+		result.ComposeNoForceRecreate = strings.ToLower(strings.TrimSpace(force)) == "false"
+	}
+
+	if deps, ok := labels[LabelComposeDeps]; ok {
+		result.ComposeWithDeps = strings.ToLower(strings.TrimSpace(deps)) == "true"
+	}
+
+	if strings.ToLower(strings.TrimSpace(labels[LabelStrategy])) == string(state.StrategyBlueGreen) {
+		result.Strategy = state.StrategyBlueGreen
+	}
+
+	if dependsOn, ok := labels[LabelDependsOn]; ok {
+		for _, name := range strings.Split(dependsOn, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				result.DependsOn = append(result.DependsOn, name)
+			}
+		}
+		result.DependentAction = state.DependentActionProbe
+		if strings.ToLower(strings.TrimSpace(labels[LabelDependentAction])) == state.DependentActionRestart {
+			result.DependentAction = state.DependentActionRestart
+		}
+	}
+
+	result.Group = strings.TrimSpace(labels[LabelGroup])
+	if ttl, ok := labels[LabelCheckTTL]; ok {
+		if d, ok := parseDurationLabel(ttl); ok {
+			result.CheckTTL = d
+		}
+	}
+
+	if allow, ok := labels[LabelAllowMutableTag]; ok {
+		result.AllowMutableTag = strings.ToLower(allow) == "true"
+	}
+
 	// Parse policy
 	if policy, ok := labels[LabelPolicy]; ok {
 		switch strings.ToLower(policy) {
@@ -91,9 +197,81 @@ func ParseLabels(labels map[string]string, imageName string) state.Labels {
 	// Parse probe configuration
 	result.Probe = parseProbeConfig(labels)
 
+	// Parse retry policy
+	result.Retry = parseRetryConfig(labels, result.Retry)
+
+	// Parse target lock behavior
+	result.Lock = parseLockConfig(labels, result.Lock)
+
+	// Parse proxy drain settings
+	result.Drain = parseDrainConfig(labels, result.Drain)
+
+	result.Schema, result.Issues = LintLabels(labels)
+
 	return result
 }
 
+// defaultFailWatch is how long bulwark.probe.fail_pattern is watched for
+// after an update when bulwark.probe.fail_watch is not set.
+const defaultFailWatch = 10 * time.Minute
+
+// parseDurationLabel parses a non-negative Go duration ("30s") or a plain
+// number of seconds.
+func parseDurationLabel(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return d, true
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	return 0, false
+}
+
+// parseRetryConfig parses retry policy from labels.
+func parseRetryConfig(labels map[string]string, config state.RetryConfig) state.RetryConfig {
+	if value, ok := labels[LabelRetryMax]; ok {
+		if n, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && n >= 0 {
+			config.Max = n
+		}
+	}
+
+	if backoff, ok := labels[LabelRetryBackoff]; ok {
+		if d, ok := parseDurationLabel(backoff); ok {
+			config.Backoff = d
+		}
+	}
+
+	return config
+}
+
+// parseLockConfig parses the target lock mode and timeout from labels.
+func parseLockConfig(labels map[string]string, config state.LockConfig) state.LockConfig {
+	if strings.ToLower(strings.TrimSpace(labels[LabelLockMode])) == string(state.LockModeSkip) {
+		config.Mode = state.LockModeSkip
+	}
+	if timeout, ok := labels[LabelLockTimeout]; ok {
+		if d, ok := parseDurationLabel(timeout); ok {
+			config.Timeout = d
+		}
+	}
+	return config
+}
+
+// parseDrainConfig parses reverse proxy drain settings from labels.
+func parseDrainConfig(labels map[string]string, config state.DrainConfig) state.DrainConfig {
+	config.URL = strings.TrimSpace(labels[LabelDrainURL])
+	config.Backend = strings.TrimSpace(labels[LabelDrainBackend])
+
+	if timeout, ok := labels[LabelDrainTimeout]; ok {
+		if d, ok := parseDurationLabel(timeout); ok {
+			config.Timeout = d
+		}
+	}
+
+	return config
+}
+
 // parseProbeConfig parses probe configuration from labels
 func parseProbeConfig(labels map[string]string) state.ProbeConfig {
 	config := state.ProbeConfig{
@@ -148,6 +326,38 @@ func parseProbeConfig(labels map[string]string) state.ProbeConfig {
 			config.WindowSec = windowInt
 		}
 	}
+	if stream, ok := labels[LabelProbeLogStream]; ok {
+		switch strings.ToLower(strings.TrimSpace(stream)) {
+		case "stdout":
+			config.LogStream = "stdout"
+		case "stderr":
+			config.LogStream = "stderr"
+		}
+	}
+	if ignoreCase, ok := labels[LabelProbeLogCase]; ok {
+		config.LogIgnoreCase = strings.ToLower(ignoreCase) == "true"
+	}
+	if lines, ok := labels[LabelProbeLogLines]; ok {
+		if linesInt, err := strconv.Atoi(lines); err == nil && linesInt > 0 {
+			config.LogMaxLines = linesInt
+		}
+	}
+	if pattern, ok := labels[LabelProbeLogFail]; ok {
+		config.LogFailPattern = pattern
+	}
+
+	// Parse the post-update watch
+	if pattern, ok := labels[LabelProbeFail]; ok {
+		config.FailPattern = pattern
+	}
+	if config.FailPattern != "" {
+		config.FailWatch = defaultFailWatch
+		if watch, ok := labels[LabelProbeFailWatch]; ok {
+			if d, ok := parseDurationLabel(watch); ok {
+				config.FailWatch = d
+			}
+		}
+	}
 
 	// Parse stability window
 	if stability, ok := labels[LabelProbeStability]; ok {
@@ -155,6 +365,11 @@ func parseProbeConfig(labels map[string]string) state.ProbeConfig {
 			config.StabilitySec = stabilityInt
 		}
 	}
+	if restarts, ok := labels[LabelProbeRestarts]; ok {
+		if restartsInt, err := strconv.Atoi(restarts); err == nil && restartsInt >= 0 {
+			config.MaxRestarts = restartsInt
+		}
+	}
 
 	return config
 }
@@ -244,10 +459,15 @@ func ValidateLabels(labels state.Labels) []string {
 			warnings = append(warnings, "TCP probe configured but host or port missing")
 		}
 	case state.ProbeTypeLog:
-		if labels.Probe.LogPattern == "" {
+		if labels.Probe.LogPattern == "" && labels.Probe.LogFailPattern == "" {
 			warnings = append(warnings, "Log probe configured but no pattern provided")
 		}
 	}
+	if labels.Probe.FailPattern != "" {
+		if _, err := regexp.Compile(labels.Probe.FailPattern); err != nil {
+			warnings = append(warnings, fmt.Sprintf("invalid fail pattern: %v", err))
+		}
+	}
 
 	// Check policy and tier combination
 	if labels.Tier == state.TierStateful && labels.Policy == state.PolicyAggressive {
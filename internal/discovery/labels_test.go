@@ -1,7 +1,9 @@
 package discovery
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/itsmrshow/bulwark/internal/state"
 )
@@ -31,6 +33,61 @@ func TestParseLabels_Enabled(t *testing.T) {
 	}
 }
 
+func TestParseLabels_Build(t *testing.T) {
+	if ParseLabels(map[string]string{}, "app").Build {
+		t.Error("expected build=false by default")
+	}
+	if !ParseLabels(map[string]string{"bulwark.build": "true"}, "app").Build {
+		t.Error("expected build=true")
+	}
+}
+
+func TestParseLabels_DependsOnTarget(t *testing.T) {
+	labels := ParseLabels(map[string]string{
+		"bulwark.depends_on_target":        "postgres, redis",
+		"bulwark.depends_on_target.action": "restart",
+	}, "app")
+	if len(labels.DependsOn) != 2 || labels.DependsOn[0] != "postgres" || labels.DependsOn[1] != "redis" {
+		t.Errorf("unexpected depends_on_target: %v", labels.DependsOn)
+	}
+	if labels.DependentAction != state.DependentActionRestart {
+		t.Errorf("expected restart action, got %q", labels.DependentAction)
+	}
+
+	labels = ParseLabels(map[string]string{"bulwark.depends_on_target": "postgres"}, "app")
+	if labels.DependentAction != state.DependentActionProbe {
+		t.Errorf("expected default probe action, got %q", labels.DependentAction)
+	}
+}
+
+func TestParseLabels_Strategy(t *testing.T) {
+	if got := ParseLabels(map[string]string{}, "nginx").Strategy; got != state.StrategyRecreate {
+		t.Errorf("expected default recreate strategy, got %q", got)
+	}
+	if got := ParseLabels(map[string]string{"bulwark.strategy": "Blue-Green"}, "nginx").Strategy; got != state.StrategyBlueGreen {
+		t.Errorf("expected blue-green strategy, got %q", got)
+	}
+}
+
+func TestParseLabels_Drain(t *testing.T) {
+	labels := ParseLabels(map[string]string{
+		"bulwark.drain.url":     "http://proxy:8080/drain",
+		"bulwark.drain.backend": "web@docker",
+		"bulwark.drain.timeout": "30s",
+	}, "nginx")
+	if labels.Drain.URL != "http://proxy:8080/drain" || labels.Drain.Backend != "web@docker" {
+		t.Errorf("unexpected drain config: %+v", labels.Drain)
+	}
+	if labels.Drain.Timeout != 30*time.Second {
+		t.Errorf("expected 30s timeout, got %v", labels.Drain.Timeout)
+	}
+
+	labels = ParseLabels(map[string]string{"bulwark.drain.url": "http://proxy/drain"}, "nginx")
+	if labels.Drain.Timeout != 10*time.Second {
+		t.Errorf("expected default 10s timeout, got %v", labels.Drain.Timeout)
+	}
+}
+
 func TestParseLabels_AllPolicies(t *testing.T) {
 	tests := []struct {
 		value    string
@@ -117,11 +174,48 @@ func TestParseLabels_ProbeLog(t *testing.T) {
 	}
 }
 
+func TestParseLabels_ProbeLogFilters(t *testing.T) {
+	labels := ParseLabels(map[string]string{
+		"bulwark.probe.type":             "log",
+		"bulwark.probe.log_fail_pattern": "FATAL|panic:",
+		"bulwark.probe.log_stream":       "STDERR",
+		"bulwark.probe.log_ignore_case":  "true",
+		"bulwark.probe.log_max_lines":    "200",
+	}, "app")
+	want := state.ProbeConfig{Type: state.ProbeTypeLog, HTTPStatus: 200, LogFailPattern: "FATAL|panic:", LogStream: "stderr", LogIgnoreCase: true, LogMaxLines: 200}
+	if labels.Probe != want {
+		t.Errorf("got %+v, want %+v", labels.Probe, want)
+	}
+	if unknown := UnknownLabels(map[string]string{"bulwark.probe.log_stream": "stdout"}); len(unknown) != 0 {
+		t.Errorf("expected the log filter labels to be known, got %v", unknown)
+	}
+
+	labels = ParseLabels(map[string]string{"bulwark.probe.log_stream": "both", "bulwark.probe.log_max_lines": "-1"}, "app")
+	if labels.Probe.LogStream != "" || labels.Probe.LogMaxLines != 0 {
+		t.Errorf("expected both streams and the default line limit, got %+v", labels.Probe)
+	}
+}
+
+func TestParseLabels_FailPattern(t *testing.T) {
+	labels := ParseLabels(map[string]string{"bulwark.probe.fail_pattern": "panic:|FATAL"}, "app")
+	if labels.Probe.FailPattern != "panic:|FATAL" || labels.Probe.FailWatch != 10*time.Minute {
+		t.Errorf("expected the pattern with the default watch, got %q for %v", labels.Probe.FailPattern, labels.Probe.FailWatch)
+	}
+	labels = ParseLabels(map[string]string{"bulwark.probe.fail_pattern": "panic:", "bulwark.probe.fail_watch": "30m"}, "app")
+	if labels.Probe.FailWatch != 30*time.Minute {
+		t.Errorf("expected a 30m watch, got %v", labels.Probe.FailWatch)
+	}
+	if labels := ParseLabels(map[string]string{"bulwark.probe.fail_watch": "30m"}, "app"); labels.Probe.FailWatch != 0 {
+		t.Errorf("expected no watch without a pattern, got %v", labels.Probe.FailWatch)
+	}
+}
+
 func TestParseLabels_ProbeStability(t *testing.T) {
 	labels := ParseLabels(map[string]string{
 		"bulwark.enabled":             "true",
 		"bulwark.probe.type":          "stability",
 		"bulwark.probe.stability_sec": "30",
+		"bulwark.probe.max_restarts":  "2",
 	}, "app")
 	if labels.Probe.Type != state.ProbeTypeStability {
 		t.Errorf("expected probe type=stability, got %s", labels.Probe.Type)
@@ -129,6 +223,9 @@ func TestParseLabels_ProbeStability(t *testing.T) {
 	if labels.Probe.StabilitySec != 30 {
 		t.Errorf("expected stability_sec=30, got %d", labels.Probe.StabilitySec)
 	}
+	if labels.Probe.MaxRestarts != 2 {
+		t.Errorf("expected max_restarts=2, got %d", labels.Probe.MaxRestarts)
+	}
 }
 
 func TestParseLabels_DatabaseAutoStateful(t *testing.T) {
@@ -150,6 +247,106 @@ func TestParseLabels_ExplicitTierOverridesDatabase(t *testing.T) {
 	}
 }
 
+func TestParseLabels_Retry(t *testing.T) {
+	labels := ParseLabels(map[string]string{}, "nginx:latest")
+	if labels.Retry.Max != 0 || labels.Retry.Backoff != 10*time.Second {
+		t.Errorf("unexpected retry defaults: %+v", labels.Retry)
+	}
+
+	labels = ParseLabels(map[string]string{
+		"bulwark.retry.max":     "3",
+		"bulwark.retry.backoff": "30s",
+	}, "nginx:latest")
+	if labels.Retry.Max != 3 || labels.Retry.Backoff != 30*time.Second {
+		t.Errorf("unexpected retry config: %+v", labels.Retry)
+	}
+
+	labels = ParseLabels(map[string]string{
+		"bulwark.retry.max":     "-1",
+		"bulwark.retry.backoff": "5",
+	}, "nginx:latest")
+	if labels.Retry.Max != 0 || labels.Retry.Backoff != 5*time.Second {
+		t.Errorf("expected invalid max ignored and bare seconds accepted, got %+v", labels.Retry)
+	}
+}
+
+func TestParseLabels_Parallel(t *testing.T) {
+	if ParseLabels(map[string]string{}, "nginx:latest").Parallel {
+		t.Error("expected parallel updates off by default")
+	}
+	if !ParseLabels(map[string]string{"bulwark.project.parallel": "TRUE"}, "nginx:latest").Parallel {
+		t.Error("expected bulwark.project.parallel=TRUE to enable parallel updates")
+	}
+}
+
+func TestParseLabels_ComposeUpOptions(t *testing.T) {
+	labels := ParseLabels(map[string]string{}, "nginx:latest")
+	if labels.ComposeNoForceRecreate || labels.ComposeWithDeps {
+		t.Errorf("expected --force-recreate and --no-deps by default, got %+v", labels)
+	}
+	labels = ParseLabels(map[string]string{"bulwark.compose.force_recreate": "false", "bulwark.compose.deps": "true"}, "nginx:latest")
+	if !labels.ComposeNoForceRecreate || !labels.ComposeWithDeps {
+		t.Errorf("expected both flags turned off, got %+v", labels)
+	}
+}
+
+func TestParseLabels_Group(t *testing.T) {
+	if group := ParseLabels(map[string]string{}, "nginx:latest").Group; group != "" {
+		t.Errorf("expected no group by default, got %q", group)
+	}
+	if group := ParseLabels(map[string]string{"bulwark.group": " media "}, "nginx:latest").Group; group != "media" {
+		t.Errorf("expected group media, got %q", group)
+	}
+}
+
+func TestParseLabels_CheckTTL(t *testing.T) {
+	if ttl := ParseLabels(map[string]string{"bulwark.check.ttl": "6h"}, "nginx:latest").CheckTTL; ttl != 6*time.Hour {
+		t.Errorf("expected 6h, got %v", ttl)
+	}
+	if ttl := ParseLabels(map[string]string{"bulwark.check.ttl": "soon"}, "nginx:latest").CheckTTL; ttl != 0 {
+		t.Errorf("expected an invalid TTL to be ignored, got %v", ttl)
+	}
+}
+
+func TestParseLabels_ComposeUpFlags(t *testing.T) {
+	labels := ParseLabels(map[string]string{
+		"bulwark.compose.up_flags": "--renew-anon-volumes, --remove-orphans --pull=always",
+	}, "nginx:latest")
+
+	want := []string{"--renew-anon-volumes", "--remove-orphans", "--pull=always"}
+	if len(labels.ComposeUpFlags) != len(want) {
+		t.Fatalf("unexpected flags: %v", labels.ComposeUpFlags)
+	}
+	for i, flag := range want {
+		if labels.ComposeUpFlags[i] != flag {
+			t.Fatalf("unexpected flags: %v", labels.ComposeUpFlags)
+		}
+	}
+}
+
+func TestParseLabels_Lock(t *testing.T) {
+	labels := ParseLabels(map[string]string{}, "nginx:latest")
+	if labels.Lock.Mode != state.LockModeWait || labels.Lock.Timeout != 0 {
+		t.Errorf("unexpected lock defaults: %+v", labels.Lock)
+	}
+
+	labels = ParseLabels(map[string]string{
+		"bulwark.lock.mode":    "skip",
+		"bulwark.lock.timeout": "90",
+	}, "nginx:latest")
+	if labels.Lock.Mode != state.LockModeSkip || labels.Lock.Timeout != 90*time.Second {
+		t.Errorf("unexpected lock config: %+v", labels.Lock)
+	}
+
+	labels = ParseLabels(map[string]string{
+		"bulwark.lock.mode":    "sometimes",
+		"bulwark.lock.timeout": "soon",
+	}, "nginx:latest")
+	if labels.Lock.Mode != state.LockModeWait || labels.Lock.Timeout != 0 {
+		t.Errorf("expected invalid lock labels ignored, got %+v", labels.Lock)
+	}
+}
+
 func TestIsKnownDatabase(t *testing.T) {
 	tests := []struct {
 		image    string
@@ -265,3 +462,25 @@ func TestParseDefinition(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLabels_AllowMutableTag(t *testing.T) {
+	if !ParseLabels(map[string]string{"bulwark.allow_mutable_tag": "true"}, "nginx:latest").AllowMutableTag {
+		t.Error("expected the mutable tag to be allowed")
+	}
+	if ParseLabels(map[string]string{}, "nginx:latest").AllowMutableTag {
+		t.Error("expected mutable tags not to be allowed by default")
+	}
+}
+
+func TestUnknownLabels(t *testing.T) {
+	labels := map[string]string{
+		"bulwark.enabled":    "true",
+		"bulwark.polcy":      "safe",
+		"bulwark.probe.type": "http",
+		"bulwark.probe.urll": "http://localhost",
+		"traefik.enable":     "true",
+	}
+	if got := UnknownLabels(labels); !reflect.DeepEqual(got, []string{"bulwark.polcy", "bulwark.probe.urll"}) {
+		t.Errorf("UnknownLabels() = %v", got)
+	}
+}
@@ -0,0 +1,174 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// SchemaVersion is the version of the label schema this Bulwark reads.
+// Services declare the version their labels were written for with
+// bulwark.schema; labels without one are read as the current version.
+const SchemaVersion = 1
+
+// labelValues are the values accepted by labels that take one of a few.
+var labelValues = map[string][]string{
+	LabelPolicy:          {"notify", "safe", "aggressive"},
+	LabelTier:            {"stateless", "stateful"},
+	LabelStrategy:        {"recreate", "blue-green"},
+	LabelProbeType:       {"docker", "http", "tcp", "log", "stability", "none"},
+	LabelProbeLogStream:  {"stdout", "stderr", "both"},
+	LabelLockMode:        {"wait", "skip"},
+	LabelDependentAction: {"probe", "restart"},
+}
+
+// boolLabels take "true" or "false".
+var boolLabels = []string{LabelEnabled, LabelBuild, LabelParallel, LabelAllowMutableTag, LabelProbeLogCase, LabelComposeForce, LabelComposeDeps}
+
+// LintLabels returns the label schema version a service declares, zero
+// when it declares none, and the bulwark.* labels that are not understood:
+// unknown keys, with the known key a typo most likely meant, values
+// outside a label's choices, and a schema newer than SchemaVersion.
+func LintLabels(labels map[string]string) (int, []state.LabelIssue) {
+	var schema int
+	var issues []state.LabelIssue
+
+	if value, ok := labels[LabelSchema]; ok {
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		switch {
+		case err != nil || n < 1:
+			issues = append(issues, state.LabelIssue{
+				Label:   LabelSchema,
+				Message: fmt.Sprintf("%s=%q is not a schema version; labels are read as schema %d", LabelSchema, value, SchemaVersion),
+			})
+		case n > SchemaVersion:
+			schema = n
+			issues = append(issues, state.LabelIssue{
+				Label:   LabelSchema,
+				Message: fmt.Sprintf("Labels are written for schema %d, but this Bulwark reads schema %d; upgrade Bulwark", n, SchemaVersion),
+			})
+		default:
+			schema = n
+		}
+	}
+
+	for _, key := range UnknownLabels(labels) {
+		issue := state.LabelIssue{Label: key, Message: fmt.Sprintf("Unknown label %s is ignored", key)}
+		if suggestion := closestLabel(key); suggestion != "" {
+			issue.Suggestion = suggestion
+			issue.Message += fmt.Sprintf("; did you mean %s?", suggestion)
+		}
+		issues = append(issues, issue)
+	}
+
+	for key, allowed := range labelValues {
+		value, ok := labels[key]
+		if !ok || containsFold(allowed, strings.TrimSpace(value)) {
+			continue
+		}
+		issues = append(issues, state.LabelIssue{
+			Label:   key,
+			Message: fmt.Sprintf("%s=%q is not one of %s; the default is used", key, value, strings.Join(allowed, ", ")),
+		})
+	}
+	for _, key := range boolLabels {
+		value, ok := labels[key]
+		if !ok || strings.EqualFold(value, "true") || strings.EqualFold(value, "false") {
+			continue
+		}
+		issues = append(issues, state.LabelIssue{
+			Label:   key,
+			Message: fmt.Sprintf("%s=%q is not true or false; it is read as false", key, value),
+		})
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Label < issues[j].Label })
+	return schema, issues
+}
+
+// LabelReport lists the label issues of one running container.
+type LabelReport struct {
+	Container string             `json:"container"`
+	Project   string             `json:"project,omitempty"`
+	Service   string             `json:"service,omitempty"`
+	Enabled   bool               `json:"enabled"`
+	Schema    int                `json:"schema,omitempty"`
+	Issues    []state.LabelIssue `json:"issues"`
+}
+
+// Lint checks the bulwark.* labels of every running container, including
+// the ones a misspelled bulwark.enabled keeps out of discovery, and reports
+// those with issues, ordered by container name.
+func (d *Discoverer) Lint(ctx context.Context) ([]LabelReport, error) {
+	containers, err := d.dockerClient.ListContainers(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	reports := []LabelReport{}
+	for _, container := range containers {
+		labels := ParseLabels(container.Labels, container.Image)
+		if len(labels.Issues) == 0 {
+			continue
+		}
+		reports = append(reports, LabelReport{
+			Container: getContainerName(container.Names),
+			Project:   container.Labels["com.docker.compose.project"],
+			Service:   container.Labels["com.docker.compose.service"],
+			Enabled:   labels.Enabled,
+			Schema:    labels.Schema,
+			Issues:    labels.Issues,
+		})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Container < reports[j].Container })
+	return reports, nil
+}
+
+// closestLabel returns the known label key nearest to key, or "" when none
+// is close enough to be a likely typo.
+func closestLabel(key string) string {
+	best, bestDistance := "", 4
+	for known := range knownLabels {
+		if distance := editDistance(key, known); distance < bestDistance || (distance == bestDistance && known < best) {
+			best, bestDistance = known, distance
+		}
+	}
+	if best == "" || bestDistance > len(best)/4 {
+		return ""
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestLintLabels(t *testing.T) {
+	schema, issues := LintLabels(map[string]string{
+		"bulwark.enabled":   "yes",
+		"bulwark.probe.ur":  "http://localhost",
+		"bulwark.polcy":     "safe",
+		"bulwark.tier":      "stateles",
+		"bulwark.strategy":  "Blue-Green",
+		"bulwark.something": "x",
+		"bulwark.schema":    "1",
+		"traefik.enable":    "true",
+	})
+	if schema != 1 {
+		t.Errorf("schema = %d; want 1", schema)
+	}
+
+	want := []state.LabelIssue{
+		{Label: "bulwark.enabled", Message: `bulwark.enabled="yes" is not true or false; it is read as false`},
+		{Label: "bulwark.polcy", Message: "Unknown label bulwark.polcy is ignored; did you mean bulwark.policy?", Suggestion: "bulwark.policy"},
+		{Label: "bulwark.probe.ur", Message: "Unknown label bulwark.probe.ur is ignored; did you mean bulwark.probe.url?", Suggestion: "bulwark.probe.url"},
+		{Label: "bulwark.something", Message: "Unknown label bulwark.something is ignored"},
+		{Label: "bulwark.tier", Message: `bulwark.tier="stateles" is not one of stateless, stateful; the default is used`},
+	}
+	if !reflect.DeepEqual(issues, want) {
+		t.Errorf("issues =\n%+v\nwant\n%+v", issues, want)
+	}
+}
+
+func TestLintLabels_Schema(t *testing.T) {
+	if schema, issues := LintLabels(map[string]string{"bulwark.enabled": "true"}); schema != 0 || len(issues) != 0 {
+		t.Errorf("without a schema: %d, %v", schema, issues)
+	}
+
+	schema, issues := LintLabels(map[string]string{"bulwark.schema": "2"})
+	if schema != 2 || len(issues) != 1 || !strings.Contains(issues[0].Message, "upgrade Bulwark") {
+		t.Errorf("newer schema: %d, %v", schema, issues)
+	}
+
+	schema, issues = LintLabels(map[string]string{"bulwark.schema": "v1"})
+	if schema != 0 || len(issues) != 1 || issues[0].Label != LabelSchema {
+		t.Errorf("invalid schema: %d, %v", schema, issues)
+	}
+}
+
+func TestParseLabels_Issues(t *testing.T) {
+	labels := ParseLabels(map[string]string{
+		"bulwark.enabled":    "true",
+		"bulwark.schema":     "1",
+		"bulwark.probe.type": "htpp",
+	}, "nginx")
+	if labels.Schema != 1 || len(labels.Issues) != 1 || labels.Issues[0].Label != LabelProbeType {
+		t.Errorf("ParseLabels() schema %d, issues %v", labels.Schema, labels.Issues)
+	}
+}
+
+func TestClosestLabel(t *testing.T) {
+	for key, want := range map[string]string{
+		"bulwark.enable":           "bulwark.enabled",
+		"bulwark.probe.tcp-port":   "bulwark.probe.tcp_port",
+		"bulwark.probe.log_patern": "bulwark.probe.log_pattern",
+		"bulwark.notify.discord":   "",
+	} {
+		if got := closestLabel(key); got != want {
+			t.Errorf("closestLabel(%q) = %q; want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package discovery
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LabelSettings are the service settings a label block is generated from.
+// Empty fields leave their label out, so the service falls back to the
+// default.
+type LabelSettings struct {
+	Policy string        `json:"policy,omitempty"`
+	Tier   string        `json:"tier,omitempty"`
+	Probe  ProbeSettings `json:"probe"`
+}
+
+// ProbeSettings are the probe part of LabelSettings.
+type ProbeSettings struct {
+	Type         string `json:"type,omitempty"`
+	URL          string `json:"url,omitempty"`
+	ExpectStatus int    `json:"expect_status,omitempty"`
+	TCPHost      string `json:"tcp_host,omitempty"`
+	TCPPort      int    `json:"tcp_port,omitempty"`
+	LogPattern   string `json:"log_pattern,omitempty"`
+	WindowSec    int    `json:"window_sec,omitempty"`
+}
+
+// settingsLabels are the labels LabelSettings cover, in the order they are
+// written.
+var settingsLabels = []string{
+	LabelEnabled, LabelPolicy, LabelTier, LabelProbeType, LabelProbeURL, LabelProbeStatus,
+	LabelProbeTCPHost, LabelProbeTCPPort, LabelProbeLogPattern, LabelProbeWindowSec,
+}
+
+// SettingsFromLabels returns the LabelSettings expressed by labels, e.g.
+// to change one setting of a service and keep the others.
+func SettingsFromLabels(labels map[string]string) LabelSettings {
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(strings.TrimSpace(labels[key]))
+		return n
+	}
+	return LabelSettings{
+		Policy: labels[LabelPolicy],
+		Tier:   labels[LabelTier],
+		Probe: ProbeSettings{
+			Type:         labels[LabelProbeType],
+			URL:          labels[LabelProbeURL],
+			ExpectStatus: atoi(LabelProbeStatus),
+			TCPHost:      labels[LabelProbeTCPHost],
+			TCPPort:      atoi(LabelProbeTCPPort),
+			LogPattern:   labels[LabelProbeLogPattern],
+			WindowSec:    atoi(LabelProbeWindowSec),
+		},
+	}
+}
+
+// Labels returns the labels enabling a service with these settings. The
+// error reports a value outside a label's choices or a probe missing what
+// its type needs.
+func (s LabelSettings) Labels() (map[string]string, error) {
+	labels := map[string]string{LabelEnabled: "true"}
+	set := func(key, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			labels[key] = value
+		}
+	}
+	setInt := func(key string, n int) {
+		if n != 0 {
+			labels[key] = strconv.Itoa(n)
+		}
+	}
+	set(LabelPolicy, s.Policy)
+	set(LabelTier, s.Tier)
+	set(LabelProbeType, s.Probe.Type)
+	set(LabelProbeURL, s.Probe.URL)
+	setInt(LabelProbeStatus, s.Probe.ExpectStatus)
+	set(LabelProbeTCPHost, s.Probe.TCPHost)
+	setInt(LabelProbeTCPPort, s.Probe.TCPPort)
+	set(LabelProbeLogPattern, s.Probe.LogPattern)
+	setInt(LabelProbeWindowSec, s.Probe.WindowSec)
+
+	for _, key := range []string{LabelPolicy, LabelTier, LabelProbeType} {
+		if value, ok := labels[key]; ok && !slices.Contains(labelValues[key], value) {
+			return nil, fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(labelValues[key], ", "), value)
+		}
+	}
+	if s.Probe.ExpectStatus != 0 && (s.Probe.ExpectStatus < 100 || s.Probe.ExpectStatus > 599) {
+		return nil, fmt.Errorf("%s must be an HTTP status, got %d", LabelProbeStatus, s.Probe.ExpectStatus)
+	}
+	if s.Probe.TCPPort < 0 || s.Probe.TCPPort > 65535 {
+		return nil, fmt.Errorf("%s must be a port, got %d", LabelProbeTCPPort, s.Probe.TCPPort)
+	}
+	if s.Probe.WindowSec < 0 {
+		return nil, fmt.Errorf("%s must not be negative", LabelProbeWindowSec)
+	}
+	switch labels[LabelProbeType] {
+	case "http":
+		if labels[LabelProbeURL] == "" {
+			return nil, fmt.Errorf("an http probe needs a URL")
+		}
+	case "tcp":
+		if labels[LabelProbeTCPPort] == "" {
+			return nil, fmt.Errorf("a tcp probe needs a port")
+		}
+	case "log":
+		if labels[LabelProbeLogPattern] == "" {
+			return nil, fmt.Errorf("a log probe needs a pattern")
+		}
+	}
+	return labels, nil
+}
+
+// LabelBlock renders labels as the labels: block of a compose service.
+func LabelBlock(labels map[string]string) string {
+	var b strings.Builder
+	b.WriteString("labels:\n")
+	for _, key := range orderedLabels(labels) {
+		fmt.Fprintf(&b, "  %s: %s\n", key, yamlScalar(labels[key]))
+	}
+	return b.String()
+}
+
+// ComposeLabels returns the labels service sets in the compose file data.
+func ComposeLabels(data []byte, service string) (map[string]string, error) {
+	var file ComposeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	svc, ok := file.Services[service]
+	if !ok {
+		return nil, fmt.Errorf("service %s not found in compose file", service)
+	}
+	return convertLabelsToMap(svc.Labels), nil
+}
+
+// SetComposeLabels returns the compose file data with the labels of service
+// that LabelSettings cover replaced by labels. Other labels, comments and
+// formatting are kept: labels already set to their value stay untouched,
+// changed ones are rewritten in place, dropped ones are removed and new ones
+// are appended in the style, map or list, the service already uses. A
+// service without labels gets a labels: block.
+func SetComposeLabels(data []byte, service string, labels map[string]string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	var root *yaml.Node
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		root = doc.Content[0]
+	}
+	_, services := mappingEntry(root, "services")
+	serviceKey, serviceNode := mappingEntry(services, service)
+	if serviceNode == nil {
+		return nil, fmt.Errorf("service %s not found in compose file", service)
+	}
+	if serviceNode.Kind != yaml.MappingNode || serviceNode.Style&yaml.FlowStyle != 0 || len(serviceNode.Content) == 0 {
+		return nil, fmt.Errorf("service %s is not a block mapping; edit its labels by hand", service)
+	}
+
+	text := string(data)
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	e := &labelEdit{lines: strings.SplitAfter(text, "\n"), labels: labels, remove: map[int]bool{}}
+	e.lines = e.lines[:len(e.lines)-1]
+
+	serviceIndent := serviceKey.Column - 1
+	childIndent := serviceNode.Content[0].Column - 1
+	entryIndent := childIndent + (childIndent - serviceIndent)
+
+	labelsKey, labelsNode := mappingEntry(serviceNode, "labels")
+	var err error
+	switch {
+	case labelsNode == nil:
+		if key, _ := mappingEntry(serviceNode, "<<"); key != nil {
+			return nil, fmt.Errorf("service %s merges an anchor that may carry labels; edit them by hand", service)
+		}
+		e.insertAt = e.blockEnd(serviceKey.Line-1, serviceIndent)
+		e.insert = append([]string{strings.Repeat(" ", childIndent) + "labels:\n"},
+			e.mapEntries(strings.Repeat(" ", entryIndent), nil)...)
+	case labelsNode.Kind == yaml.AliasNode:
+		return nil, fmt.Errorf("labels of service %s come from an anchor; edit them by hand", service)
+	case labelsNode.Style&yaml.FlowStyle != 0 || labelsNode.Tag == "!!null":
+		err = e.expandFlow(labelsKey, labelsNode, childIndent, entryIndent)
+	case labelsNode.Kind == yaml.MappingNode:
+		err = e.editMapping(labelsNode)
+	case labelsNode.Kind == yaml.SequenceNode:
+		err = e.editSequence(labelsNode)
+	default:
+		return nil, fmt.Errorf("labels of service %s are neither a map nor a list", service)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("labels of service %s: %w", service, err)
+	}
+	return []byte(e.String()), nil
+}
+
+// labelEdit collects the line changes SetComposeLabels makes.
+type labelEdit struct {
+	lines  []string
+	labels map[string]string
+
+	remove   map[int]bool
+	insertAt int
+	insert   []string
+}
+
+func (e *labelEdit) String() string {
+	var b strings.Builder
+	for i, line := range e.lines {
+		if i == e.insertAt {
+			b.WriteString(strings.Join(e.insert, ""))
+		}
+		if !e.remove[i] {
+			b.WriteString(line)
+		}
+	}
+	if e.insertAt >= len(e.lines) {
+		b.WriteString(strings.Join(e.insert, ""))
+	}
+	return b.String()
+}
+
+// editMapping updates labels written as key: value entries.
+func (e *labelEdit) editMapping(node *yaml.Node) error {
+	present := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if !slices.Contains(settingsLabels, key.Value) {
+			continue
+		}
+		if err := e.replace(key, value, key.Value, value.Value, present, func(prefix string) string {
+			return fmt.Sprintf("%s%s: %s\n", prefix, key.Value, yamlScalar(e.labels[key.Value]))
+		}); err != nil {
+			return err
+		}
+	}
+	first := node.Content[0]
+	last := node.Content[len(node.Content)-2]
+	e.insertAt = e.blockEnd(last.Line-1, first.Column-1)
+	e.insert = e.mapEntries(e.lines[first.Line-1][:first.Column-1], present)
+	return nil
+}
+
+// editSequence updates labels written as "key=value" list items.
+func (e *labelEdit) editSequence(node *yaml.Node) error {
+	present := map[string]bool{}
+	for _, item := range node.Content {
+		key, value, _ := strings.Cut(item.Value, "=")
+		if !slices.Contains(settingsLabels, key) {
+			continue
+		}
+		quoted := item.Style&yaml.DoubleQuotedStyle != 0
+		if err := e.replace(item, item, key, value, present, func(prefix string) string {
+			return prefix + itemScalar(key+"="+e.labels[key], quoted) + "\n"
+		}); err != nil {
+			return err
+		}
+	}
+	first := node.Content[0]
+	last := node.Content[len(node.Content)-1]
+	prefix := e.lines[first.Line-1][:first.Column-1]
+	indent := len(prefix) - len(strings.TrimLeft(prefix, " "))
+	quoted := first.Style&yaml.DoubleQuotedStyle != 0
+	e.insertAt = e.blockEnd(last.Line-1, indent)
+	for _, key := range orderedLabels(e.labels) {
+		if !present[key] {
+			e.insert = append(e.insert, prefix+itemScalar(key+"="+e.labels[key], quoted)+"\n")
+		}
+	}
+	return nil
+}
+
+// replace keeps, rewrites or removes the single-line label entry starting at
+// start whose value node is end, recording the key as present when it stays.
+func (e *labelEdit) replace(start, end *yaml.Node, key, value string, present map[string]bool, render func(prefix string) string) error {
+	if end.Line != start.Line || end.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return fmt.Errorf("label %s spans several lines; edit it by hand", key)
+	}
+	line := start.Line - 1
+	want, keep := e.labels[key]
+	switch {
+	case !keep || present[key]:
+		e.remove[line] = true
+	case want != value:
+		e.lines[line] = render(e.lines[line][:start.Column-1])
+	}
+	if keep {
+		present[key] = true
+	}
+	return nil
+}
+
+// expandFlow rewrites labels written in flow style, e.g. labels: {} or
+// labels: ["a=b"], or left empty as a block carrying the existing and the
+// new labels.
+func (e *labelEdit) expandFlow(key, node *yaml.Node, childIndent, entryIndent int) error {
+	end := key.Line
+	for _, child := range node.Content {
+		end = max(end, child.Line)
+	}
+	if node.Line != key.Line || end != key.Line {
+		return fmt.Errorf("labels span several lines in flow style; edit them by hand")
+	}
+	prefix := strings.Repeat(" ", entryIndent)
+	block := []string{strings.Repeat(" ", childIndent) + "labels:\n"}
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			if k, _, _ := strings.Cut(item.Value, "="); !slices.Contains(settingsLabels, k) {
+				block = append(block, prefix+"- "+yamlScalar(item.Value)+"\n")
+			}
+		}
+		for _, k := range orderedLabels(e.labels) {
+			block = append(block, prefix+"- "+yamlScalar(k+"="+e.labels[k])+"\n")
+		}
+	} else {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if k := node.Content[i].Value; !slices.Contains(settingsLabels, k) {
+				block = append(block, fmt.Sprintf("%s%s: %s\n", prefix, k, yamlScalar(node.Content[i+1].Value)))
+			}
+		}
+		block = append(block, e.mapEntries(prefix, nil)...)
+	}
+	e.remove[key.Line-1] = true
+	e.insertAt = key.Line - 1
+	e.insert = block
+	return nil
+}
+
+// mapEntries renders the labels not yet present as key: value lines.
+func (e *labelEdit) mapEntries(prefix string, present map[string]bool) []string {
+	var entries []string
+	for _, key := range orderedLabels(e.labels) {
+		if !present[key] {
+			entries = append(entries, fmt.Sprintf("%s%s: %s\n", prefix, key, yamlScalar(e.labels[key])))
+		}
+	}
+	return entries
+}
+
+// blockEnd returns the index of the line after the block that starts at
+// line start and holds every following line indented deeper than indent.
+// Trailing blank lines are not part of the block.
+func (e *labelEdit) blockEnd(start, indent int) int {
+	end := start + 1
+	for i := start + 1; i < len(e.lines); i++ {
+		trimmed := strings.TrimLeft(e.lines[i], " ")
+		if strings.TrimSpace(trimmed) == "" {
+			continue
+		}
+		if len(e.lines[i])-len(trimmed) <= indent {
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// mappingEntry returns the key and value nodes of key in the mapping node.
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// orderedLabels returns the keys of labels, those LabelSettings cover first
+// in their usual order.
+func orderedLabels(labels map[string]string) []string {
+	var keys, rest []string
+	for _, key := range settingsLabels {
+		if _, ok := labels[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+	for key := range labels {
+		if !slices.Contains(settingsLabels, key) {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	return append(keys, rest...)
+}
+
+// yamlScalar renders value as a YAML string, quoted when it would otherwise
+// read as another type or break the line, e.g. "true" or "200".
+func yamlScalar(value string) string {
+	out, err := yaml.Marshal(value)
+	if err != nil || strings.ContainsAny(value, "\r\n") {
+		return strconv.Quote(value)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+func itemScalar(value string, quoted bool) string {
+	if quoted {
+		return strconv.Quote(value)
+	}
+	return yamlScalar(value)
+}
@@ -0,0 +1,178 @@
+package discovery
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLabelSettings_Labels(t *testing.T) {
+	labels, err := LabelSettings{
+		Policy: "safe",
+		Probe:  ProbeSettings{Type: "http", URL: "http://localhost:8080/health", ExpectStatus: 200},
+	}.Labels()
+	if err != nil {
+		t.Fatalf("Labels: %v", err)
+	}
+	want := "labels:\n" +
+		"  bulwark.enabled: \"true\"\n" +
+		"  bulwark.policy: safe\n" +
+		"  bulwark.probe.type: http\n" +
+		"  bulwark.probe.url: http://localhost:8080/health\n" +
+		"  bulwark.probe.expect_status: \"200\"\n"
+	if got := LabelBlock(labels); got != want {
+		t.Errorf("block =\n%s\nwant\n%s", got, want)
+	}
+
+	for _, settings := range []LabelSettings{
+		{Policy: "careful"},
+		{Tier: "Stateful"},
+		{Probe: ProbeSettings{Type: "http"}},
+		{Probe: ProbeSettings{Type: "tcp", TCPHost: "db"}},
+		{Probe: ProbeSettings{ExpectStatus: 42}},
+	} {
+		if _, err := settings.Labels(); err == nil {
+			t.Errorf("%+v: expected an error", settings)
+		}
+	}
+}
+
+func TestSetComposeLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		compose string
+		want    string
+	}{
+		{
+			name: "map",
+			compose: `services:
+  web:
+    image: nginx:1.27 # pinned
+    labels:
+      traefik.enable: "true"
+      bulwark.enabled: "true"
+      bulwark.policy: notify  # for now
+      bulwark.tier: stateful
+  db:
+    image: postgres:16
+`,
+			want: `services:
+  web:
+    image: nginx:1.27 # pinned
+    labels:
+      traefik.enable: "true"
+      bulwark.enabled: "true"
+      bulwark.policy: safe
+      bulwark.probe.type: tcp
+      bulwark.probe.tcp_port: "80"
+  db:
+    image: postgres:16
+`,
+		},
+		{
+			name: "list",
+			compose: `services:
+    web:
+        image: nginx
+        labels:
+            - "traefik.enable=true"
+            - "bulwark.policy=notify"
+        ports: ["80:80"]
+`,
+			want: `services:
+    web:
+        image: nginx
+        labels:
+            - "traefik.enable=true"
+            - "bulwark.policy=safe"
+            - "bulwark.enabled=true"
+            - "bulwark.probe.type=tcp"
+            - "bulwark.probe.tcp_port=80"
+        ports: ["80:80"]
+`,
+		},
+		{
+			name: "no labels",
+			compose: `services:
+  web:
+    image: nginx
+    environment:
+      A: b
+
+  db:
+    image: postgres
+`,
+			want: `services:
+  web:
+    image: nginx
+    environment:
+      A: b
+    labels:
+      bulwark.enabled: "true"
+      bulwark.policy: safe
+      bulwark.probe.type: tcp
+      bulwark.probe.tcp_port: "80"
+
+  db:
+    image: postgres
+`,
+		},
+		{
+			name: "flow",
+			compose: `services:
+  web:
+    image: nginx
+    labels: {traefik.enable: "true"}`,
+			want: `services:
+  web:
+    image: nginx
+    labels:
+      traefik.enable: "true"
+      bulwark.enabled: "true"
+      bulwark.policy: safe
+      bulwark.probe.type: tcp
+      bulwark.probe.tcp_port: "80"
+`,
+		},
+	}
+
+	labels, err := LabelSettings{Policy: "safe", Probe: ProbeSettings{Type: "tcp", TCPPort: 80}}.Labels()
+	if err != nil {
+		t.Fatalf("Labels: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SetComposeLabels([]byte(tt.compose), "web", labels)
+			if err != nil {
+				t.Fatalf("SetComposeLabels: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+			read, err := ComposeLabels(got, "web")
+			if err != nil {
+				t.Fatalf("ComposeLabels: %v", err)
+			}
+			for key, value := range labels {
+				if read[key] != value {
+					t.Errorf("%s = %q; want %q", key, read[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestSetComposeLabels_Refuses(t *testing.T) {
+	labels := map[string]string{LabelEnabled: "true"}
+	for name, compose := range map[string]string{
+		"missing service": "services:\n  db:\n    image: postgres\n",
+		"anchor":          "x-labels: &labels\n  a: b\nservices:\n  web:\n    image: nginx\n    labels: *labels\n",
+		"merge":           "x-base: &base\n  labels:\n    a: b\nservices:\n  web:\n    <<: *base\n    image: nginx\n",
+		"multi-line":      "services:\n  web:\n    image: nginx\n    labels:\n      bulwark.policy: >-\n        safe\n",
+	} {
+		if _, err := SetComposeLabels([]byte(compose), "web", labels); err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if !strings.Contains(err.Error(), "web") {
+			t.Errorf("%s: error %q does not name the service", name, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package docker
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+)
+
+// preflightRef names a container and image that do not exist. Write checks
+// target it so an allowed call fails with 404 instead of changing anything.
+const preflightRef = "bulwark-preflight-nonexistent"
+
+// Capability is one Docker API call Bulwark relies on and whether the daemon,
+// or a socket proxy in front of it, allows it.
+type Capability struct {
+	Name     string `json:"name"`
+	Endpoint string `json:"endpoint"`
+	Purpose  string `json:"purpose"`
+	Write    bool   `json:"write"`
+	Allowed  bool   `json:"allowed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// CapabilityReport is the result of Preflight.
+type CapabilityReport struct {
+	Reachable    bool         `json:"reachable"`
+	Capabilities []Capability `json:"capabilities"`
+}
+
+// CanRead reports whether discovery and planning can run.
+func (r CapabilityReport) CanRead() bool {
+	return r.Reachable && r.allAllowed(false)
+}
+
+// CanWrite reports whether updates can be applied.
+func (r CapabilityReport) CanWrite() bool {
+	return r.Reachable && r.allAllowed(true)
+}
+
+// Missing returns the capabilities that were denied or could not be checked.
+func (r CapabilityReport) Missing() []Capability {
+	var missing []Capability
+	for _, c := range r.Capabilities {
+		if !c.Allowed {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+func (r CapabilityReport) allAllowed(write bool) bool {
+	for _, c := range r.Capabilities {
+		if c.Write == write && !c.Allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// Preflight checks each Docker API call Bulwark needs. It works through
+// restricted endpoints such as docker-socket-proxy, which answer 403 for
+// blocked sections. Write calls are aimed at objects that do not exist, so a
+// 404 means the call is allowed and nothing is modified.
+func (c *Client) Preflight(ctx context.Context) CapabilityReport {
+	report := CapabilityReport{}
+	if err := c.Ping(ctx); err != nil {
+		report.Capabilities = append(report.Capabilities, Capability{
+			Name: "ping", Endpoint: "GET /_ping", Purpose: "connect to the daemon", Error: err.Error(),
+		})
+		return report
+	}
+	report.Reachable = true
+
+	checks := []struct {
+		capability Capability
+		call       func(ctx context.Context) error
+	}{
+		{Capability{Name: "ping", Endpoint: "GET /_ping", Purpose: "connect to the daemon"},
+			func(ctx context.Context) error { return nil }},
+		{Capability{Name: "containers.list", Endpoint: "GET /containers/json", Purpose: "discover running services"},
+			func(ctx context.Context) error {
+				_, err := c.cli.ContainerList(ctx, container.ListOptions{Limit: 1})
+				return err
+			}},
+		{Capability{Name: "containers.inspect", Endpoint: "GET /containers/{id}/json", Purpose: "read labels, health and restart counts"},
+			func(ctx context.Context) error {
+				_, err := c.cli.ContainerInspect(ctx, preflightRef)
+				return err
+			}},
+		{Capability{Name: "containers.logs", Endpoint: "GET /containers/{id}/logs", Purpose: "log probes"},
+			func(ctx context.Context) error {
+				logs, err := c.cli.ContainerLogs(ctx, preflightRef, container.LogsOptions{ShowStdout: true, Tail: "1"})
+				if err == nil {
+					_ = logs.Close()
+				}
+				return err
+			}},
+		{Capability{Name: "images.list", Endpoint: "GET /images/json", Purpose: "read local digests"},
+			func(ctx context.Context) error {
+				_, err := c.cli.ImageList(ctx, types.ImageListOptions{})
+				return err
+			}},
+		{Capability{Name: "images.inspect", Endpoint: "GET /images/{id}/json", Purpose: "read image digests and base image labels"},
+			func(ctx context.Context) error {
+				_, _, err := c.cli.ImageInspectWithRaw(ctx, preflightRef)
+				return err
+			}},
+		{Capability{Name: "networks.list", Endpoint: "GET /networks", Purpose: "docker compose up", Write: true},
+			func(ctx context.Context) error {
+				_, err := c.cli.NetworkList(ctx, types.NetworkListOptions{})
+				return err
+			}},
+		{Capability{Name: "volumes.list", Endpoint: "GET /volumes", Purpose: "docker compose up", Write: true},
+			func(ctx context.Context) error {
+				_, err := c.cli.VolumeList(ctx, volume.ListOptions{})
+				return err
+			}},
+		{Capability{Name: "containers.write", Endpoint: "POST /containers/{id}/restart", Purpose: "recreate, restart and remove containers", Write: true},
+			func(ctx context.Context) error {
+				return c.cli.ContainerRestart(ctx, preflightRef, container.StopOptions{})
+			}},
+		{Capability{Name: "images.write", Endpoint: "POST /images/{name}/tag", Purpose: "pull and tag images", Write: true},
+			func(ctx context.Context) error {
+				return c.cli.ImageTag(ctx, preflightRef, preflightRef+":bulwark")
+			}},
+	}
+
+	for _, check := range checks {
+		capability := check.capability
+		capability.Allowed, capability.Error = classifyPreflight(check.call(ctx))
+		report.Capabilities = append(report.Capabilities, capability)
+	}
+	return report
+}
+
+// classifyPreflight treats success and "not found" as allowed; anything else,
+// typically 403 from a socket proxy, as denied.
+func classifyPreflight(err error) (bool, string) {
+	if err == nil || errdefs.IsNotFound(err) {
+		return true, ""
+	}
+	if errdefs.IsForbidden(err) || errdefs.IsUnauthorized(err) {
+		return false, "blocked: " + err.Error()
+	}
+	return false, err.Error()
+}
@@ -0,0 +1,53 @@
+package docker
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+)
+
+func TestClassifyPreflight(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		allowed bool
+	}{
+		{"success", nil, true},
+		{"not found", errdefs.NotFound(errors.New("no such container")), true},
+		{"forbidden", errdefs.Forbidden(errors.New("403 Forbidden")), false},
+		{"other", errors.New("connection reset"), false},
+	}
+	for _, tt := range tests {
+		allowed, reason := classifyPreflight(tt.err)
+		if allowed != tt.allowed {
+			t.Errorf("%s: allowed = %v, want %v", tt.name, allowed, tt.allowed)
+		}
+		if !allowed && reason == "" {
+			t.Errorf("%s: expected a reason for a denied call", tt.name)
+		}
+	}
+}
+
+func TestCapabilityReport_ReadOnlyProxy(t *testing.T) {
+	report := CapabilityReport{
+		Reachable: true,
+		Capabilities: []Capability{
+			{Name: "containers.list", Allowed: true},
+			{Name: "containers.write", Write: true},
+		},
+	}
+
+	if !report.CanRead() {
+		t.Error("expected reads to be possible")
+	}
+	if report.CanWrite() {
+		t.Error("expected writes to be blocked")
+	}
+	if missing := report.Missing(); len(missing) != 1 || missing[0].Name != "containers.write" {
+		t.Errorf("unexpected missing capabilities %+v", missing)
+	}
+	if (CapabilityReport{}).CanRead() {
+		t.Error("expected an unreachable daemon to be unreadable")
+	}
+}
@@ -2,8 +2,11 @@ package docker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -11,6 +14,7 @@ import (
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
 )
 
 // Container represents a Docker container
@@ -30,9 +34,20 @@ type ContainerJSON struct {
 	ID              string
 	Name            string
 	Image           string
+	RestartCount    int
 	State           ContainerState
 	Config          *ContainerConfig
 	NetworkSettings *NetworkSettings
+	Mounts          []Mount
+}
+
+// Mount represents a volume, bind or tmpfs mounted into a container
+type Mount struct {
+	Type        string
+	Name        string // Volume name, empty for binds
+	Source      string
+	Destination string
+	RW          bool
 }
 
 // ContainerState represents container state
@@ -41,6 +56,9 @@ type ContainerState struct {
 	Running    bool
 	Paused     bool
 	Restarting bool
+	OOMKilled  bool
+	ExitCode   int
+	StartedAt  time.Time
 	Health     *Health
 }
 
@@ -54,6 +72,9 @@ type Health struct {
 type ContainerConfig struct {
 	Image       string
 	Labels      map[string]string
+	Env         []string
+	Entrypoint  []string
+	Cmd         []string
 	Healthcheck *Healthcheck
 }
 
@@ -85,16 +106,40 @@ type ImageInspect struct {
 	RepoDigests []string
 	Created     string
 	Size        int64
+	Labels      map[string]string
+	Platform    string // os/arch[/variant]
 }
 
 // Client wraps the Docker API client
 type Client struct {
-	cli *client.Client
+	cli client.APIClient
 }
 
-// NewClient creates a new Docker client
+// engine stands in for the Docker engine in every client NewClient creates,
+// once UseEngine set it.
+var engine client.APIClient
+
+// UseEngine makes NewClient return clients of api instead of connecting to
+// a Docker engine, so Bulwark can run against a simulated one. Call it at
+// startup, before any client is created.
+func UseEngine(api client.APIClient) {
+	engine = api
+}
+
+// NewClient creates a new Docker client. Without DOCKER_HOST it connects to
+// the platform's default engine, falling back to Docker Desktop's per-user
+// socket where the system socket does not exist.
 func NewClient() (*Client, error) {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if engine != nil {
+		return &Client{cli: engine}, nil
+	}
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+	if os.Getenv("DOCKER_HOST") == "" {
+		if host := defaultHost(); host != "" {
+			opts = append(opts, client.WithHost(host))
+		}
+	}
+	cli, err := client.NewClientWithOpts(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
@@ -149,16 +194,22 @@ func (c *Client) InspectContainer(ctx context.Context, containerID string) (Cont
 	}
 
 	result := ContainerJSON{
-		ID:    inspect.ID,
-		Name:  inspect.Name,
-		Image: inspect.Image,
+		ID:           inspect.ID,
+		Name:         inspect.Name,
+		Image:        inspect.Image,
+		RestartCount: inspect.RestartCount,
 		State: ContainerState{
 			Status:     inspect.State.Status,
 			Running:    inspect.State.Running,
 			Paused:     inspect.State.Paused,
 			Restarting: inspect.State.Restarting,
+			OOMKilled:  inspect.State.OOMKilled,
+			ExitCode:   inspect.State.ExitCode,
 		},
 	}
+	if startedAt, err := time.Parse(time.RFC3339Nano, inspect.State.StartedAt); err == nil {
+		result.State.StartedAt = startedAt
+	}
 
 	if inspect.State.Health != nil {
 		result.State.Health = &Health{
@@ -169,8 +220,11 @@ func (c *Client) InspectContainer(ctx context.Context, containerID string) (Cont
 
 	if inspect.Config != nil {
 		result.Config = &ContainerConfig{
-			Image:  inspect.Config.Image,
-			Labels: inspect.Config.Labels,
+			Image:      inspect.Config.Image,
+			Labels:     inspect.Config.Labels,
+			Env:        inspect.Config.Env,
+			Entrypoint: inspect.Config.Entrypoint,
+			Cmd:        inspect.Config.Cmd,
 		}
 		if inspect.Config.Healthcheck != nil {
 			result.Config.Healthcheck = &Healthcheck{
@@ -183,12 +237,37 @@ func (c *Client) InspectContainer(ctx context.Context, containerID string) (Cont
 		}
 	}
 
+	if inspect.NetworkSettings != nil {
+		result.NetworkSettings = &NetworkSettings{
+			IPAddress: inspect.NetworkSettings.IPAddress,
+			Ports:     make(map[string][]PortBinding, len(inspect.NetworkSettings.Ports)),
+		}
+		for port, bindings := range inspect.NetworkSettings.Ports {
+			converted := make([]PortBinding, 0, len(bindings))
+			for _, binding := range bindings {
+				converted = append(converted, PortBinding{HostIP: binding.HostIP, HostPort: binding.HostPort})
+			}
+			result.NetworkSettings.Ports[string(port)] = converted
+		}
+	}
+
+	for _, mount := range inspect.Mounts {
+		result.Mounts = append(result.Mounts, Mount{
+			Type:        string(mount.Type),
+			Name:        mount.Name,
+			Source:      mount.Source,
+			Destination: mount.Destination,
+			RW:          mount.RW,
+		})
+	}
+
 	return result, nil
 }
 
-// ImagePull pulls an image from a registry
-func (c *Client) ImagePull(ctx context.Context, ref string) error {
-	out, err := c.cli.ImagePull(ctx, ref, types.ImagePullOptions{})
+// ImagePull pulls an image from a registry. An empty platform uses the
+// daemon's default.
+func (c *Client) ImagePull(ctx context.Context, ref, platform string) error {
+	out, err := c.cli.ImagePull(ctx, ref, types.ImagePullOptions{Platform: platform})
 	if err != nil {
 		return fmt.Errorf("failed to pull image %s: %w", ref, err)
 	}
@@ -219,15 +298,45 @@ func (c *Client) ImageInspect(ctx context.Context, imageID string) (ImageInspect
 		return ImageInspect{}, fmt.Errorf("failed to inspect image %s: %w", imageID, err)
 	}
 
+	var labels map[string]string
+	if inspect.Config != nil {
+		labels = inspect.Config.Labels
+	}
+
 	return ImageInspect{
 		ID:          inspect.ID,
 		RepoTags:    inspect.RepoTags,
 		RepoDigests: inspect.RepoDigests,
+		Labels:      labels,
 		Created:     inspect.Created,
 		Size:        inspect.Size,
+		Platform:    imagePlatform(inspect.Os, inspect.Architecture, inspect.Variant),
 	}, nil
 }
 
+// HasImage reports whether the daemon has the image ref, e.g.
+// nginx@sha256:..., without pulling it.
+func (c *Client) HasImage(ctx context.Context, ref string) (bool, error) {
+	_, _, err := c.cli.ImageInspectWithRaw(ctx, ref)
+	if errdefs.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+	return true, nil
+}
+
+func imagePlatform(os, arch, variant string) string {
+	if os == "" || arch == "" {
+		return ""
+	}
+	if variant != "" {
+		return os + "/" + arch + "/" + variant
+	}
+	return os + "/" + arch
+}
+
 // ContainerRestart restarts a container
 func (c *Client) ContainerRestart(ctx context.Context, containerID string) error {
 	timeout := int(10) // seconds
@@ -240,6 +349,22 @@ func (c *Client) ContainerRestart(ctx context.Context, containerID string) error
 	return nil
 }
 
+// ContainerStopAndRemove stops a container, giving it stopTimeout (10 seconds
+// if zero) to exit, and removes it
+func (c *Client) ContainerStopAndRemove(ctx context.Context, containerID string, stopTimeout time.Duration) error {
+	timeout := int(10) // seconds
+	if stopTimeout > 0 {
+		timeout = int(stopTimeout.Round(time.Second) / time.Second)
+	}
+	if err := c.cli.ContainerStop(ctx, containerID, container.StopOptions{Timeout: &timeout}); err != nil {
+		return fmt.Errorf("failed to stop container %s: %w", containerID, err)
+	}
+	if err := c.cli.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err != nil {
+		return fmt.Errorf("failed to remove container %s: %w", containerID, err)
+	}
+	return nil
+}
+
 // ContainerLogs gets container logs
 func (c *Client) ContainerLogs(ctx context.Context, containerID string, tail string) (io.ReadCloser, error) {
 	options := container.LogsOptions{
@@ -256,11 +381,13 @@ func (c *Client) ContainerLogs(ctx context.Context, containerID string, tail str
 	return logs, nil
 }
 
-// ContainerLogsSince gets container logs since a given time
-func (c *Client) ContainerLogsSince(ctx context.Context, containerID string, since time.Time, tail string) (io.ReadCloser, error) {
+// ContainerLogsSince gets container logs since a given time, from stdout,
+// stderr or both. Without a TTY, Docker multiplexes the two streams with a
+// frame header in front of each chunk.
+func (c *Client) ContainerLogsSince(ctx context.Context, containerID string, since time.Time, tail string, stdout, stderr bool) (io.ReadCloser, error) {
 	options := container.LogsOptions{
-		ShowStdout: true,
-		ShowStderr: true,
+		ShowStdout: stdout,
+		ShowStderr: stderr,
 		Since:      since.Format(time.RFC3339),
 		Tail:       tail,
 	}
@@ -296,11 +423,103 @@ func (c *Client) RemoveImage(ctx context.Context, imageID string, force bool) er
 	return nil
 }
 
-// PruneImages prunes unused images
-func (c *Client) PruneImages(ctx context.Context) error {
-	_, err := c.cli.ImagesPrune(ctx, filters.Args{})
+// PruneImages removes dangling images and returns how many it removed.
+// Images whose ID or repo digest is in protected are kept, as are images a
+// container still uses.
+func (c *Client) PruneImages(ctx context.Context, protected []string) (int, error) {
+	if len(protected) == 0 {
+		report, err := c.cli.ImagesPrune(ctx, filters.Args{})
+		if err != nil {
+			return 0, fmt.Errorf("failed to prune images: %w", err)
+		}
+		return len(report.ImagesDeleted), nil
+	}
+
+	images, err := c.cli.ImageList(ctx, types.ImageListOptions{Filters: filters.NewArgs(filters.Arg("dangling", "true"))})
 	if err != nil {
-		return fmt.Errorf("failed to prune images: %w", err)
+		return 0, fmt.Errorf("failed to list dangling images: %w", err)
 	}
-	return nil
+	keep := make(map[string]bool, len(protected))
+	for _, digest := range protected {
+		keep[digest] = true
+	}
+	removed := 0
+	for _, img := range images {
+		if isProtectedImage(img, keep) {
+			continue
+		}
+		_, err := c.cli.ImageRemove(ctx, img.ID, types.ImageRemoveOptions{PruneChildren: true})
+		if errdefs.IsConflict(err) || errdefs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove image %s: %w", img.ID, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// isProtectedImage reports whether img's ID or one of its repo digests is
+// in protected.
+func isProtectedImage(img image.Summary, protected map[string]bool) bool {
+	if protected[img.ID] {
+		return true
+	}
+	for _, ref := range img.RepoDigests {
+		if i := strings.LastIndex(ref, "@"); i >= 0 && protected[ref[i+1:]] {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerStats is a container's CPU and memory use, as docker stats shows it.
+type ContainerStats struct {
+	CPUPercent  float64 // Of one CPU, so a busy container on four CPUs reaches 400
+	MemoryBytes uint64  // Usage without the reclaimable page cache
+	MemoryLimit uint64
+}
+
+// ContainerStats samples a container's resource use once. The engine takes
+// two readings about a second apart to compute the CPU share.
+func (c *Client) ContainerStats(ctx context.Context, containerID string) (ContainerStats, error) {
+	resp, err := c.cli.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return ContainerStats{}, fmt.Errorf("failed to get stats for container %s: %w", containerID, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var stats types.StatsJSON
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return ContainerStats{}, fmt.Errorf("failed to decode stats for container %s: %w", containerID, err)
+	}
+	return statsFromJSON(stats), nil
+}
+
+// statsFromJSON computes CPU and memory use the way the docker CLI does.
+func statsFromJSON(stats types.StatsJSON) ContainerStats {
+	result := ContainerStats{MemoryLimit: stats.MemoryStats.Limit}
+
+	memory := stats.MemoryStats.Usage
+	// cgroup v1 reports total_inactive_file, v2 inactive_file.
+	inactive, ok := stats.MemoryStats.Stats["total_inactive_file"]
+	if !ok {
+		inactive = stats.MemoryStats.Stats["inactive_file"]
+	}
+	if inactive < memory {
+		memory -= inactive
+	}
+	result.MemoryBytes = memory
+
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	cpus := float64(stats.CPUStats.OnlineCPUs)
+	if cpus == 0 {
+		cpus = float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		result.CPUPercent = cpuDelta / systemDelta * cpus * 100
+	}
+	return result
 }
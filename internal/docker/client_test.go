@@ -0,0 +1,75 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/image"
+)
+
+func TestIsProtectedImage(t *testing.T) {
+	protected := map[string]bool{"sha256:aaa": true, "sha256:bbb": true}
+	tests := []struct {
+		name string
+		img  image.Summary
+		want bool
+	}{
+		{"repo digest", image.Summary{ID: "sha256:111", RepoDigests: []string{"nginx@sha256:aaa"}}, true},
+		{"registry with port", image.Summary{ID: "sha256:222", RepoDigests: []string{"registry:5000/app@sha256:bbb"}}, true},
+		{"image ID", image.Summary{ID: "sha256:bbb"}, true},
+		{"other digest", image.Summary{ID: "sha256:333", RepoDigests: []string{"nginx@sha256:ccc"}}, false},
+		{"no digests", image.Summary{ID: "sha256:444"}, false},
+	}
+	for _, tt := range tests {
+		if got := isProtectedImage(tt.img, protected); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDesktopHost(t *testing.T) {
+	home := t.TempDir()
+	if got := desktopHost(home); got != "" {
+		t.Fatalf("expected no host without a Desktop socket, got %q", got)
+	}
+	socket := filepath.Join(home, ".docker", "run", "docker.sock")
+	if err := os.MkdirAll(filepath.Dir(socket), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(socket, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := desktopHost(home), "unix://"+socket; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := desktopHost(""); got != "" {
+		t.Errorf("expected no host without a home directory, got %q", got)
+	}
+}
+
+func TestStatsFromJSON(t *testing.T) {
+	var stats types.StatsJSON
+	stats.MemoryStats = types.MemoryStats{Usage: 300 << 20, Limit: 1 << 30, Stats: map[string]uint64{"inactive_file": 100 << 20}}
+	stats.CPUStats = types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 3_000}, SystemUsage: 20_000, OnlineCPUs: 4}
+	stats.PreCPUStats = types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 1_000}, SystemUsage: 10_000}
+
+	got := statsFromJSON(stats)
+	if got.MemoryBytes != 200<<20 || got.MemoryLimit != 1<<30 {
+		t.Errorf("memory = %d of %d, want %d of %d", got.MemoryBytes, got.MemoryLimit, 200<<20, 1<<30)
+	}
+	if got.CPUPercent != 80 {
+		t.Errorf("cpu = %v, want 80", got.CPUPercent)
+	}
+
+	// cgroup v1 names the page cache total_inactive_file; without a previous
+	// reading there is no CPU share.
+	stats.MemoryStats.Stats = map[string]uint64{"total_inactive_file": 50 << 20}
+	stats.PreCPUStats = types.CPUStats{}
+	stats.CPUStats = types.CPUStats{}
+	got = statsFromJSON(stats)
+	if got.MemoryBytes != 250<<20 || got.CPUPercent != 0 {
+		t.Errorf("got %+v", got)
+	}
+}
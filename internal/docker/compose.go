@@ -3,16 +3,67 @@ package docker
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // ComposeRunner executes docker compose commands
 type ComposeRunner struct {
 	composeBinary string
+	project       string // Passed as -p when set
+}
+
+// ComposeFunc runs a compose command in-process: files are the compose
+// files it was given with -f and args the rest of its arguments, e.g.
+// ["up", "-d", "--no-deps", "web"]. It returns the command's output.
+type ComposeFunc func(ctx context.Context, files, args []string) (string, error)
+
+// composeFunc runs compose commands in place of the compose binary, once
+// UseCompose set it.
+var composeFunc ComposeFunc
+
+// UseCompose makes compose runners hand their commands to fn instead of
+// running the compose binary, as a simulated engine needs. Call it at
+// startup, before any command runs.
+func UseCompose(fn ComposeFunc) {
+	composeFunc = fn
+}
+
+// runCommand runs a compose command built by buildCommandWithFiles, or hands
+// it to the function set with UseCompose. Output goes to the command's
+// Stdout either way. A -p project name is not handed on, since a simulated
+// engine names projects after their files.
+func runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	if composeFunc == nil {
+		return cmd.Run()
+	}
+	var files []string
+	rest := cmd.Args[1:]
+	if len(rest) > 0 && rest[0] == "compose" {
+		rest = rest[1:]
+	}
+	for len(rest) > 1 && (rest[0] == "-f" || rest[0] == "-p") {
+		if rest[0] == "-f" {
+			files = append(files, rest[1])
+		}
+		rest = rest[2:]
+	}
+	out, err := composeFunc(ctx, files, rest)
+	if cmd.Stdout != nil {
+		_, _ = io.WriteString(cmd.Stdout, out)
+	}
+	if err != nil && cmd.Stderr != nil {
+		_, _ = io.WriteString(cmd.Stderr, err.Error()+"\n")
+	}
+	return err
 }
 
 // NewComposeRunner creates a new compose runner
@@ -28,6 +79,16 @@ func NewComposeRunner() *ComposeRunner {
 	}
 }
 
+// ForProject returns a runner whose commands name the compose project with
+// -p, so they act on the running stack even when it was started under a name
+// compose would not derive from the file, e.g. with -p. An empty name leaves
+// the project to compose.
+func (r *ComposeRunner) ForProject(name string) *ComposeRunner {
+	runner := *r
+	runner.project = name
+	return &runner
+}
+
 // buildCommand builds a docker compose command
 func (r *ComposeRunner) buildCommand(ctx context.Context, composePath string, args ...string) *exec.Cmd {
 	return r.buildCommandWithFiles(ctx, []string{composePath}, args...)
@@ -47,14 +108,16 @@ func (r *ComposeRunner) buildCommandWithFiles(ctx context.Context, composePaths
 		for _, composePath := range composePaths {
 			cmdArgs = append(cmdArgs, "-f", composePath)
 		}
-		cmdArgs = append(cmdArgs, args...)
 	} else {
 		// Legacy docker-compose
 		for _, composePath := range composePaths {
 			cmdArgs = append(cmdArgs, "-f", composePath)
 		}
-		cmdArgs = append(cmdArgs, args...)
 	}
+	if r.project != "" {
+		cmdArgs = append(cmdArgs, "-p", r.project)
+	}
+	cmdArgs = append(cmdArgs, args...)
 
 	cmd := exec.CommandContext(ctx, r.composeBinary, cmdArgs...)
 	cmd.Dir = filepath.Dir(composePaths[0])
@@ -62,59 +125,121 @@ func (r *ComposeRunner) buildCommandWithFiles(ctx context.Context, composePaths
 	return cmd
 }
 
-// Pull pulls images for a service
-func (r *ComposeRunner) Pull(ctx context.Context, composePath, service string) error {
+// Pull pulls images for a service. A non-empty platform (e.g. linux/arm64)
+// is passed as DOCKER_DEFAULT_PLATFORM, since compose pull has no --platform
+// flag.
+func (r *ComposeRunner) Pull(ctx context.Context, composePath, service, platform string) error {
 	args := []string{"pull"}
 	if service != "" {
 		args = append(args, service)
 	}
 
 	cmd := r.buildCommand(ctx, composePath, args...)
+	if platform != "" {
+		cmd.Env = append(os.Environ(), "DOCKER_DEFAULT_PLATFORM="+platform)
+	}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	return runStreaming(ctx, cmd, "pull")
+}
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to pull: %w\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
+// Build builds images for a service. With pull set, newer versions of base
+// images are pulled first.
+func (r *ComposeRunner) Build(ctx context.Context, composePath, service string, pull bool) error {
+	args := []string{"build"}
+	if pull {
+		args = append(args, "--pull")
+	}
+	if service != "" {
+		args = append(args, service)
 	}
 
-	return nil
+	cmd := r.buildCommand(ctx, composePath, args...)
+
+	return runStreaming(ctx, cmd, "build")
+}
+
+// UpOptions controls how `compose up` recreates a service.
+type UpOptions struct {
+	ForceRecreate bool
+	WithDeps      bool          // Leave out --no-deps, so compose also starts or recreates the service's dependencies
+	StopTimeout   time.Duration // Passed as --timeout when set
+	ExtraFlags    []string      // Only flags accepted by AllowedUpFlag are used
+}
+
+// allowedUpFlags are the extra `compose up` flags users may opt in to. Flags
+// that change which services start or detach behavior are left out.
+var allowedUpFlags = map[string]bool{
+	"--renew-anon-volumes": true,
+	"--remove-orphans":     true,
+	"--quiet-pull":         true,
+	"--no-build":           true,
+	"--build":              true,
+	"--wait":               true,
+}
+
+// AllowedUpFlag reports whether flag may be added to a `compose up` run.
+func AllowedUpFlag(flag string) bool {
+	return allowedUpFlags[flag]
 }
 
 // Up starts services
-func (r *ComposeRunner) Up(ctx context.Context, composePath, service string, forceRecreate bool) error {
-	return r.upWithFiles(ctx, []string{composePath}, service, forceRecreate)
+func (r *ComposeRunner) Up(ctx context.Context, composePath, service string, opts UpOptions) error {
+	return r.upWithFiles(ctx, []string{composePath}, service, opts)
 }
 
 // UpWithOverride starts services using a base compose file plus a one-off override file.
-func (r *ComposeRunner) UpWithOverride(ctx context.Context, composePath, overridePath, service string, forceRecreate bool) error {
+func (r *ComposeRunner) UpWithOverride(ctx context.Context, composePath, overridePath, service string, opts UpOptions) error {
 	files := []string{composePath}
 	if strings.TrimSpace(overridePath) != "" {
 		files = append(files, overridePath)
 	}
-	return r.upWithFiles(ctx, files, service, forceRecreate)
+	return r.upWithFiles(ctx, files, service, opts)
+}
+
+func (r *ComposeRunner) upWithFiles(ctx context.Context, composeFiles []string, service string, opts UpOptions) error {
+	cmd := r.buildCommandWithFiles(ctx, composeFiles, upArgs(service, opts)...)
+
+	return runStreaming(ctx, cmd, "up")
 }
 
-func (r *ComposeRunner) upWithFiles(ctx context.Context, composeFiles []string, service string, forceRecreate bool) error {
+func upArgs(service string, opts UpOptions) []string {
 	args := []string{"up", "-d"}
 
-	if forceRecreate {
+	if opts.ForceRecreate {
 		args = append(args, "--force-recreate")
 	}
+	if opts.StopTimeout > 0 {
+		args = append(args, "--timeout", strconv.Itoa(int(opts.StopTimeout.Round(time.Second)/time.Second)))
+	}
+	for _, flag := range opts.ExtraFlags {
+		if AllowedUpFlag(flag) {
+			args = append(args, flag)
+		}
+	}
 
 	if service != "" {
-		args = append(args, "--no-deps", service)
+		if !opts.WithDeps {
+			args = append(args, "--no-deps")
+		}
+		args = append(args, service)
 	}
 
-	cmd := r.buildCommandWithFiles(ctx, composeFiles, args...)
+	return args
+}
+
+// Scale starts the service with the given number of containers without
+// recreating the ones already running, so new containers use the current image.
+func (r *ComposeRunner) Scale(ctx context.Context, composePath, service string, replicas int) error {
+	args := []string{"up", "-d", "--no-deps", "--no-recreate", "--scale", fmt.Sprintf("%s=%d", service, replicas), service}
+
+	cmd := r.buildCommand(ctx, composePath, args...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to up: %w\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
+	if err := runCommand(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to scale: %w\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
 	}
 
 	return nil
@@ -128,7 +253,7 @@ func (r *ComposeRunner) Down(ctx context.Context, composePath string) error {
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	if err := runCommand(ctx, cmd); err != nil {
 		return fmt.Errorf("failed to down: %w\nstdout: %s\nstderr: %s", err, stdout.String(), stderr.String())
 	}
 
@@ -143,7 +268,7 @@ func (r *ComposeRunner) Ps(ctx context.Context, composePath string) ([]string, e
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	if err := runCommand(ctx, cmd); err != nil {
 		return nil, fmt.Errorf("failed to ps: %w\nstderr: %s", err, stderr.String())
 	}
 
@@ -153,19 +278,44 @@ func (r *ComposeRunner) Ps(ctx context.Context, composePath string) ([]string, e
 
 // Config validates and parses compose file
 func (r *ComposeRunner) Config(ctx context.Context, composePath string) (string, error) {
-	cmd := r.buildCommand(ctx, composePath, "config")
+	return r.configOutput(ctx, composePath)
+}
+
+func (r *ComposeRunner) configOutput(ctx context.Context, composePath string, flags ...string) (string, error) {
+	cmd := r.buildCommand(ctx, composePath, append([]string{"config"}, flags...)...)
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
-	if err := cmd.Run(); err != nil {
+	if err := runCommand(ctx, cmd); err != nil {
 		return "", fmt.Errorf("failed to config: %w\nstderr: %s", err, stderr.String())
 	}
 
 	return stdout.String(), nil
 }
 
+// Services lists the services defined in the compose file. It fails when the
+// file is missing or compose rejects it.
+func (r *ComposeRunner) Services(ctx context.Context, composePath string) ([]string, error) {
+	config, err := r.configOutput(ctx, composePath, "--services")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(config), nil
+}
+
+// ConfigHash returns a SHA-256 of the fully resolved compose config, so edits
+// to the compose file or its interpolated environment change the hash.
+func (r *ComposeRunner) ConfigHash(ctx context.Context, composePath string) (string, error) {
+	config, err := r.Config(ctx, composePath)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // FindComposeFiles finds all docker-compose files in a directory tree
 func FindComposeFiles(root string) ([]string, error) {
 	var composeFiles []string
@@ -181,6 +331,10 @@ func FindComposeFiles(root string) ([]string, error) {
 		}
 
 		if info.IsDir() {
+			// Skip the compose journal's backups
+			if info.Name() == ".bulwark" && path != root {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -0,0 +1,130 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpArgs(t *testing.T) {
+	got := upArgs("web", UpOptions{ForceRecreate: true})
+	want := []string{"up", "-d", "--force-recreate", "--no-deps", "web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("default args = %v, want %v", got, want)
+	}
+
+	got = upArgs("web", UpOptions{
+		ForceRecreate: true,
+		StopTimeout:   90 * time.Second,
+		ExtraFlags:    []string{"--renew-anon-volumes", "--detach=false", "--remove-orphans"},
+	})
+	want = []string{"up", "-d", "--force-recreate", "--timeout", "90", "--renew-anon-volumes", "--remove-orphans", "--no-deps", "web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("custom args = %v, want %v", got, want)
+	}
+
+	got = upArgs("web", UpOptions{WithDeps: true})
+	want = []string{"up", "-d", "web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("args without --force-recreate and --no-deps = %v, want %v", got, want)
+	}
+}
+
+func TestComposeProjectName(t *testing.T) {
+	write := func(t *testing.T, path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name    string
+		compose string
+		dotEnv  string
+		want    string
+	}{
+		{name: "directory name", compose: "services: {}\n", want: "myapp"},
+		{name: "top-level name", compose: "name: media-stack\nservices: {}\n", want: "media-stack"},
+		{name: "interpolated name", compose: "name: ${STACK}\nservices: {}\n", want: "myapp"},
+		{name: ".env wins over name", compose: "name: media-stack\n", dotEnv: "# project\nexport COMPOSE_PROJECT_NAME=\"Media\"\n", want: "media"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "My.App")
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, "compose.yaml")
+			write(t, path, tt.compose)
+			if tt.dotEnv != "" {
+				write(t, filepath.Join(dir, ".env"), tt.dotEnv)
+			}
+			if got := ComposeProjectName(path); got != tt.want {
+				t.Errorf("ComposeProjectName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartedFrom(t *testing.T) {
+	labels := map[string]string{
+		"com.docker.compose.project.config_files": "/srv/media/compose.yaml, /srv/media/compose.override.yaml",
+	}
+	if !startedFrom(labels, "/srv/media/compose.yaml") {
+		t.Error("expected the first config file to match")
+	}
+	if !startedFrom(labels, "/srv/media/./compose.override.yaml") {
+		t.Error("expected the override file to match")
+	}
+	if startedFrom(labels, "/srv/other/compose.yaml") {
+		t.Error("expected another project's file not to match")
+	}
+	if startedFrom(nil, "/srv/media/compose.yaml") {
+		t.Error("expected a container without labels not to match")
+	}
+}
+
+func TestResolveProjectName(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "media")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "compose.yaml")
+	if err := os.WriteFile(path, []byte("services: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	started := func(project, files string) Container {
+		return Container{Labels: map[string]string{ProjectLabel: project, "com.docker.compose.project.config_files": files}}
+	}
+
+	if got := ResolveProjectName(path, nil); got != "media" {
+		t.Errorf("without containers: got %q, want media", got)
+	}
+	if got := ResolveProjectName(path, []Container{started("stack", path)}); got != "stack" {
+		t.Errorf("started with -p: got %q, want stack", got)
+	}
+	if got := ResolveProjectName(path, []Container{started("stack", path), started("media", path)}); got != "media" {
+		t.Errorf("with a container of the configured name: got %q, want media", got)
+	}
+	if got := ResolveProjectName(path, []Container{started("other", "/srv/other/compose.yaml")}); got != "media" {
+		t.Errorf("with another file's containers: got %q, want media", got)
+	}
+}
+
+func TestBuildCommandProject(t *testing.T) {
+	r := &ComposeRunner{composeBinary: "docker"}
+	cmd := r.ForProject("stack").buildCommand(context.Background(), "/srv/media/compose.yaml", "pull", "web")
+	want := []string{"docker", "compose", "-f", "/srv/media/compose.yaml", "-p", "stack", "pull", "web"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("args = %v, want %v", cmd.Args, want)
+	}
+
+	cmd = r.buildCommand(context.Background(), "/srv/media/compose.yaml", "pull", "web")
+	want = []string{"docker", "compose", "-f", "/srv/media/compose.yaml", "pull", "web"}
+	if !reflect.DeepEqual(cmd.Args, want) {
+		t.Errorf("args without a project = %v, want %v", cmd.Args, want)
+	}
+}
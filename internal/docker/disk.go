@@ -0,0 +1,25 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+)
+
+// DataRoot returns the daemon's data root, e.g. /var/lib/docker. The path is
+// on the Docker host and may not exist inside Bulwark's own container.
+func (c *Client) DataRoot(ctx context.Context) (string, error) {
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get docker info: %w", err)
+	}
+	return info.DockerRootDir, nil
+}
+
+// HostName returns the name of the Docker host, as the daemon reports it.
+func (c *Client) HostName(ctx context.Context) (string, error) {
+	info, err := c.cli.Info(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get docker info: %w", err)
+	}
+	return info.Name, nil
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package docker
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+// FreeSpace returns the bytes available to the current user on the volume
+// holding path.
+func FreeSpace(path string) (uint64, error) {
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, fmt.Errorf("invalid path %s: %w", path, err)
+	}
+	var available, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(name, &available, &total, &free); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem at %s: %w", path, err)
+	}
+	return available, nil
+}
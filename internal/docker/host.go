@@ -0,0 +1,28 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// desktopSockets are where Docker Desktop puts the per-user engine socket,
+// relative to the home directory: macOS, then Linux.
+var desktopSockets = []string{
+	filepath.Join(".docker", "run", "docker.sock"),
+	filepath.Join(".docker", "desktop", "docker.sock"),
+}
+
+// desktopHost returns the unix:// address of the first Docker Desktop
+// socket that exists under home, or "".
+func desktopHost(home string) string {
+	if home == "" {
+		return ""
+	}
+	for _, socket := range desktopSockets {
+		path := filepath.Join(home, socket)
+		if _, err := os.Stat(path); err == nil {
+			return "unix://" + path
+		}
+	}
+	return ""
+}
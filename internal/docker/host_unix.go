@@ -0,0 +1,21 @@
+//go:build !windows
+
+package docker
+
+import (
+	"os"
+)
+
+// defaultSocket is the Docker client's default engine socket.
+const defaultSocket = "/var/run/docker.sock"
+
+// defaultHost returns the engine address to use when DOCKER_HOST is unset:
+// "" keeps the client's default socket, and a Docker Desktop install without
+// that socket gets its per-user socket instead.
+func defaultHost() string {
+	if _, err := os.Stat(defaultSocket); err == nil {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+	return desktopHost(home)
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package docker
+
+// defaultHost returns "" on Windows, where the client's default named pipe,
+// npipe:////./pipe/docker_engine, is the one Docker Desktop serves.
+func defaultHost() string {
+	return ""
+}
@@ -0,0 +1,157 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OutputFunc receives one line of compose output. stream is "stdout" or
+// "stderr"; compose writes its pull and recreate progress to stderr.
+type OutputFunc func(stream, line string)
+
+type outputKey struct{}
+
+// WithOutput returns a context whose compose pulls, builds and ups report each
+// output line to fn as it is written. fn may be called from two goroutines at
+// once.
+func WithOutput(ctx context.Context, fn OutputFunc) context.Context {
+	return context.WithValue(ctx, outputKey{}, fn)
+}
+
+func outputFromContext(ctx context.Context) OutputFunc {
+	fn, _ := ctx.Value(outputKey{}).(OutputFunc)
+	return fn
+}
+
+// runStreaming runs cmd, keeping its output for the error message and
+// streaming it line by line to the context's OutputFunc, if any.
+func runStreaming(ctx context.Context, cmd *exec.Cmd, action string) error {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	var writers []*lineWriter
+	if fn := outputFromContext(ctx); fn != nil {
+		outLines := &lineWriter{stream: "stdout", fn: fn}
+		errLines := &lineWriter{stream: "stderr", fn: fn}
+		cmd.Stdout = io.MultiWriter(&stdout, outLines)
+		cmd.Stderr = io.MultiWriter(&stderr, errLines)
+		writers = append(writers, outLines, errLines)
+	}
+
+	err := runCommand(ctx, cmd)
+	for _, w := range writers {
+		w.flush()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w\nstdout: %s\nstderr: %s", action, err, stdout.String(), stderr.String())
+	}
+	return nil
+}
+
+// ansiEscape matches the cursor and color sequences compose emits even when
+// its output is not a terminal.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// lineWriter splits a byte stream into lines. Progress bars redraw with a
+// carriage return, so '\r' ends a line as well.
+type lineWriter struct {
+	stream string
+	fn     OutputFunc
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexAny(w.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		w.emit(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (w *lineWriter) flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.emit(w.buf)
+	w.buf = nil
+}
+
+func (w *lineWriter) emit(raw []byte) {
+	line := strings.TrimSpace(ansiEscape.ReplaceAllString(string(raw), ""))
+	if line != "" {
+		w.fn(w.stream, line)
+	}
+}
+
+// LayerProgress is how far the pull of one image layer has got, as reported
+// by a line of compose pull output.
+type LayerProgress struct {
+	Layer   string
+	Status  string // e.g. "Downloading", "Extracting", "Pull complete"
+	Current int64  // Bytes done; zero when the line has no byte counts
+	Total   int64
+}
+
+// Percent is the layer's progress from 0 to 100. The download is most of a
+// pull's time on a slow uplink, so a layer counts as done once downloaded.
+func (p LayerProgress) Percent() int {
+	switch p.Status {
+	case "Downloading":
+		if p.Total <= 0 {
+			return 0
+		}
+		return int(min(p.Current*100/p.Total, 100))
+	case "Waiting", "Pulling fs layer":
+		return 0
+	default: // Verifying Checksum, Download complete, Extracting, Pull complete, Already exists
+		return 100
+	}
+}
+
+var (
+	layerLine = regexp.MustCompile(`^([0-9a-f]{12}) (Pulling fs layer|Waiting|Downloading|Verifying Checksum|Download complete|Extracting|Pull complete|Already exists)\b(.*)$`)
+	byteCount = regexp.MustCompile(`([0-9.]+)\s*([kMGT]?B)/([0-9.]+)\s*([kMGT]?B)`)
+)
+
+// ParsePullProgress parses a line of compose pull output such as
+// "a1b2c3d4e5f6 Downloading [==>   ]  12.5MB/45.1MB". Lines about anything
+// but a layer are not progress.
+func ParsePullProgress(line string) (LayerProgress, bool) {
+	m := layerLine.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return LayerProgress{}, false
+	}
+	progress := LayerProgress{Layer: m[1], Status: m[2]}
+	if counts := byteCount.FindStringSubmatch(m[3]); counts != nil {
+		progress.Current = parseSize(counts[1], counts[2])
+		progress.Total = parseSize(counts[3], counts[4])
+	}
+	return progress, true
+}
+
+// parseSize reads a size as Docker prints it, in decimal units.
+func parseSize(number, unit string) int64 {
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0
+	}
+	scale := map[string]float64{"B": 1, "kB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12}[unit]
+	return int64(value * scale)
+}
@@ -0,0 +1,87 @@
+package docker
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := &lineWriter{stream: "stderr", fn: func(_, line string) { lines = append(lines, line) }}
+
+	_, _ = w.Write([]byte("abc Pulling fs layer\n abc Downloading [=>   ] 1MB/9MB\r"))
+	_, _ = w.Write([]byte("\x1b[2K abc Downloading [====>] 9MB/9MB\r\n\nweb Pul"))
+	_, _ = w.Write([]byte("led"))
+	w.flush()
+
+	want := []string{
+		"abc Pulling fs layer",
+		"abc Downloading [=>   ] 1MB/9MB",
+		"abc Downloading [====>] 9MB/9MB",
+		"web Pulled",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestRunStreaming(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+
+	var mu sync.Mutex
+	var lines []string
+	ctx := WithOutput(context.Background(), func(stream, line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, stream+": "+line)
+	})
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", "echo pulling; echo progress >&2; exit 3")
+	err := runStreaming(ctx, cmd, "pull")
+	if err == nil || !strings.Contains(err.Error(), "failed to pull") || !strings.Contains(err.Error(), "stderr: progress") {
+		t.Fatalf("expected the output in the error, got %v", err)
+	}
+
+	sort.Strings(lines)
+	want := []string{"stderr: progress", "stdout: pulling"}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestParsePullProgress(t *testing.T) {
+	tests := []struct {
+		line    string
+		ok      bool
+		status  string
+		percent int
+	}{
+		{line: "a1b2c3d4e5f6 Downloading [=====>     ]  12.5MB/50MB", ok: true, status: "Downloading", percent: 25},
+		{line: " a1b2c3d4e5f6 Downloading [>  ]  512B/2kB", ok: true, status: "Downloading", percent: 25},
+		{line: "a1b2c3d4e5f6 Pulling fs layer", ok: true, status: "Pulling fs layer", percent: 0},
+		{line: "a1b2c3d4e5f6 Extracting [=>  ]  1MB/50MB", ok: true, status: "Extracting", percent: 100},
+		{line: "a1b2c3d4e5f6 Pull complete", ok: true, status: "Pull complete", percent: 100},
+		{line: "web Pulling", ok: false},
+		{line: "web Pulled", ok: false},
+	}
+	for _, tt := range tests {
+		progress, ok := ParsePullProgress(tt.line)
+		if ok != tt.ok {
+			t.Errorf("ParsePullProgress(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if progress.Layer != "a1b2c3d4e5f6" || progress.Status != tt.status || progress.Percent() != tt.percent {
+			t.Errorf("ParsePullProgress(%q) = %+v (%d%%), want %s at %d%%", tt.line, progress, progress.Percent(), tt.status, tt.percent)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectLabel is the container label docker compose stores the project
+// name in.
+const ProjectLabel = "com.docker.compose.project"
+
+// ComposeProjectName is the project name docker compose gives the file at
+// composePath: COMPOSE_PROJECT_NAME from the .env file next to it, else the
+// file's top-level name:, else the directory name. A name: that needs
+// variable interpolation is skipped, as is anything unreadable.
+func ComposeProjectName(composePath string) string {
+	dir := filepath.Dir(composePath)
+	if name := dotEnvValue(filepath.Join(dir, ".env"), "COMPOSE_PROJECT_NAME"); name != "" {
+		return NormalizeProjectName(name)
+	}
+	if data, err := os.ReadFile(composePath); err == nil {
+		var file struct {
+			Name string `yaml:"name"`
+		}
+		if yaml.Unmarshal(data, &file) == nil && file.Name != "" && !strings.Contains(file.Name, "$") {
+			return NormalizeProjectName(file.Name)
+		}
+	}
+	return NormalizeProjectName(filepath.Base(dir))
+}
+
+// ResolveProjectName is the project name composePath's containers run under.
+// It is ComposeProjectName unless no container in containers carries that
+// name and some were started from composePath under another one, e.g. with
+// -p or an interpolated name:, in which case their project label wins.
+func ResolveProjectName(composePath string, containers []Container) string {
+	name := ComposeProjectName(composePath)
+	var fromFile string
+	for _, container := range containers {
+		project := container.Labels[ProjectLabel]
+		if project == name {
+			return name
+		}
+		if fromFile == "" && project != "" && startedFrom(container.Labels, composePath) {
+			fromFile = project
+		}
+	}
+	if fromFile != "" {
+		return fromFile
+	}
+	return name
+}
+
+// startedFrom reports whether a compose container's labels name composePath
+// as one of its config files.
+func startedFrom(labels map[string]string, composePath string) bool {
+	want := filepath.Clean(composePath)
+	for _, file := range strings.Split(labels["com.docker.compose.project.config_files"], ",") {
+		if file = strings.TrimSpace(file); file != "" && filepath.Clean(file) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeProjectName applies compose's project name rules: lower case,
+// keeping only letters, digits, dashes and underscores.
+func NormalizeProjectName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// dotEnvValue reads key from a .env file, or "" when the file or key is
+// missing.
+func dotEnvValue(path, key string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		if !ok || strings.TrimSpace(name) != key {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		return value
+	}
+	return ""
+}
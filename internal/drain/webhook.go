@@ -0,0 +1,160 @@
+// Package drain takes services out of a reverse proxy's rotation around an
+// update and puts them back once the new version is healthy.
+package drain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// Webhook actions sent to the drain endpoint.
+const (
+	ActionDrain  = "drain"
+	ActionStatus = "status"
+	ActionEnable = "enable"
+)
+
+// Request is the JSON body posted to a drain webhook.
+type Request struct {
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Service string `json:"service"`
+	Backend string `json:"backend"`
+}
+
+// Response is the optional JSON body returned by a drain webhook. When
+// ActiveConnections is present the drainer polls with ActionStatus until it
+// reaches zero; otherwise it waits out the full drain timeout.
+type Response struct {
+	ActiveConnections *int `json:"active_connections,omitempty"`
+}
+
+// WebhookDrainer drives a proxy through an HTTP webhook. The webhook is the
+// integration point for nginx upstreams, Traefik file-provider sidecars or
+// any proxy with an admin API.
+type WebhookDrainer struct {
+	Client       *http.Client
+	PollInterval time.Duration
+	logger       *logging.Logger
+}
+
+// NewWebhookDrainer creates a webhook drainer
+func NewWebhookDrainer(logger *logging.Logger) *WebhookDrainer {
+	return &WebhookDrainer{
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		PollInterval: time.Second,
+		logger:       logger.WithComponent("drain"),
+	}
+}
+
+// Drain marks the service as draining and waits up to config.Timeout for its
+// connections to finish.
+func (d *WebhookDrainer) Drain(ctx context.Context, target *state.Target, service *state.Service) error {
+	config := service.Labels.Drain
+	resp, err := d.post(ctx, config.URL, request(ActionDrain, target, service))
+	if err != nil {
+		return fmt.Errorf("drain %s: %w", backend(service), err)
+	}
+
+	timer := time.NewTimer(config.Timeout)
+	defer timer.Stop()
+
+	if resp.ActiveConnections == nil {
+		select {
+		case <-timer.C:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	active := *resp.ActiveConnections
+	for active > 0 {
+		select {
+		case <-ticker.C:
+			status, err := d.post(ctx, config.URL, request(ActionStatus, target, service))
+			if err != nil {
+				d.logger.Debug().Err(err).Str("backend", backend(service)).Msg("Drain status check failed, will retry")
+				continue
+			}
+			if status.ActiveConnections == nil {
+				return nil
+			}
+			active = *status.ActiveConnections
+		case <-timer.C:
+			d.logger.Warn().
+				Str("backend", backend(service)).
+				Int("active_connections", active).
+				Dur("timeout", config.Timeout).
+				Msg("Drain timed out with connections still open")
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Enable puts the service back into rotation.
+func (d *WebhookDrainer) Enable(ctx context.Context, target *state.Target, service *state.Service) error {
+	if _, err := d.post(ctx, service.Labels.Drain.URL, request(ActionEnable, target, service)); err != nil {
+		return fmt.Errorf("enable %s: %w", backend(service), err)
+	}
+	return nil
+}
+
+func (d *WebhookDrainer) post(ctx context.Context, url string, payload Request) (Response, error) {
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return Response{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return Response{}, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	var out Response
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err == nil && len(bytes.TrimSpace(data)) > 0 {
+		// A body that is not JSON simply means the webhook does not report connections.
+		_ = json.Unmarshal(data, &out)
+	}
+	return out, nil
+}
+
+func request(action string, target *state.Target, service *state.Service) Request {
+	return Request{
+		Action:  action,
+		Target:  target.Name,
+		Service: service.Name,
+		Backend: backend(service),
+	}
+}
+
+// backend is the name the proxy knows the service by.
+func backend(service *state.Service) string {
+	if service.Labels.Drain.Backend != "" {
+		return service.Labels.Drain.Backend
+	}
+	return service.Name
+}
@@ -0,0 +1,84 @@
+package drain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func drainService(url string, timeout time.Duration) (*state.Target, *state.Service) {
+	service := &state.Service{Name: "web", Labels: state.DefaultLabels()}
+	service.Labels.Drain = state.DrainConfig{URL: url, Backend: "web@docker", Timeout: timeout}
+	return &state.Target{Name: "app"}, service
+}
+
+func TestWebhookDrainerPollsUntilConnectionsClose(t *testing.T) {
+	var actions []string
+	active := 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if req.Backend != "web@docker" || req.Target != "app" || req.Service != "web" {
+			t.Errorf("unexpected request: %+v", req)
+		}
+		actions = append(actions, req.Action)
+		if req.Action == ActionStatus {
+			active--
+		}
+		fmt.Fprintf(w, `{"active_connections": %d}`, active)
+	}))
+	defer server.Close()
+
+	drainer := NewWebhookDrainer(logging.Default())
+	drainer.PollInterval = time.Millisecond
+	target, service := drainService(server.URL, time.Minute)
+
+	if err := drainer.Drain(context.Background(), target, service); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if err := drainer.Enable(context.Background(), target, service); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+
+	want := []string{ActionDrain, ActionStatus, ActionStatus, ActionEnable}
+	if fmt.Sprint(actions) != fmt.Sprint(want) {
+		t.Fatalf("actions = %v, want %v", actions, want)
+	}
+}
+
+func TestWebhookDrainerWaitsTimeoutWithoutConnectionCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	target, service := drainService(server.URL, 20*time.Millisecond)
+	start := time.Now()
+	if err := NewWebhookDrainer(logging.Default()).Drain(context.Background(), target, service); err != nil {
+		t.Fatalf("drain: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected drain to wait the timeout, returned after %v", elapsed)
+	}
+}
+
+func TestWebhookDrainerReportsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	target, service := drainService(server.URL, time.Second)
+	if err := NewWebhookDrainer(logging.Default()).Drain(context.Background(), target, service); err == nil {
+		t.Fatal("expected error for non-2xx webhook response")
+	}
+}
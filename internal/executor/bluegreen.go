@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/metrics"
+	"github.com/itsmrshow/bulwark/internal/probe"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// StartParallel pulls the new image and starts a second container for the
+// service next to the running one. Compose names it <project>-<service>-N
+// and, as long as the service publishes no fixed host port, gives it its own
+// ports. It returns the IDs of the old container and of the new one.
+func (e *ComposeExecutor) StartParallel(ctx context.Context, target *state.Target, service *state.Service) ([]string, string, error) {
+	if e.shouldSkipSelfUpdate(ctx, target, service) {
+		return nil, "", NewCodedSkipError(state.ResultSkippedSelfUpdate, "self-update skipped: update Bulwark externally with 'docker compose pull bulwark && docker compose up -d bulwark'")
+	}
+
+	oldIDs, err := e.serviceContainers(ctx, target, service)
+	if err != nil {
+		return nil, "", newStepError(state.ResultRecreateFailed, err)
+	}
+	if len(oldIDs) != 1 {
+		return nil, "", newStepError(state.ResultRecreateFailed,
+			fmt.Errorf("blue-green update needs exactly one running container, found %d", len(oldIDs)))
+	}
+
+	if err := e.checkDefinition(ctx, target, service); err != nil {
+		return nil, "", err
+	}
+	if err := e.prepareImage(ctx, target, service); err != nil {
+		return nil, "", err
+	}
+
+	e.logger.Info().
+		Str("service", service.Name).
+		Msg("Starting parallel container")
+
+	scaleStart := time.Now()
+	if err := e.compose(target).Scale(ctx, target.Path, service.Name, 2); err != nil {
+		return nil, "", newStepError(state.ResultRecreateFailed, fmt.Errorf("failed to start parallel container: %w", err))
+	}
+	timerFromContext(ctx).addRecreate(time.Since(scaleStart))
+
+	current, err := e.serviceContainers(ctx, target, service)
+	if err != nil {
+		return nil, "", newStepError(state.ResultRecreateFailed, err)
+	}
+	for _, id := range current {
+		if id != oldIDs[0] {
+			return oldIDs, id, nil
+		}
+	}
+	return nil, "", newStepError(state.ResultRecreateFailed, fmt.Errorf("parallel container for %s did not start", service.Name))
+}
+
+// Retire stops and removes the given containers of a service, giving each
+// stopTimeout to exit.
+func (e *ComposeExecutor) Retire(ctx context.Context, containerIDs []string, stopTimeout time.Duration) error {
+	for _, id := range containerIDs {
+		if err := e.dockerClient.ContainerStopAndRemove(ctx, id, stopTimeout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serviceContainers lists the running containers of a compose service.
+func (e *ComposeExecutor) serviceContainers(ctx context.Context, target *state.Target, service *state.Service) ([]string, error) {
+	if e.dockerClient == nil {
+		return nil, fmt.Errorf("docker client unavailable")
+	}
+	containers, err := e.dockerClient.ListContainers(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	var ids []string
+	for _, c := range containers {
+		if c.Labels["com.docker.compose.project"] == target.Name &&
+			c.Labels["com.docker.compose.service"] == service.Name {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+// retireTimeout bounds removing a new container that failed its probes, on
+// top of the service's stop grace period.
+const retireTimeout = time.Minute
+
+// retireNewContainer removes a new container that failed its probes. It runs
+// even when the update's context was cancelled or timed out, which is often
+// why the probes failed, so the old and new containers are not both left
+// running.
+func (e *Executor) retireNewContainer(ctx context.Context, service *state.Service, newID string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), retireTimeout+service.StopGracePeriod)
+	defer cancel()
+	return e.blueGreen.Retire(ctx, []string{newID}, service.StopGracePeriod)
+}
+
+// blueGreenUpdate starts the new version next to the old one and probes it.
+// Only once the probes pass is the old container removed; otherwise the new
+// container is removed and the old one keeps serving, so no rollback is needed.
+// Starting the new container is retried per the service's retry policy.
+func (e *Executor) blueGreenUpdate(ctx context.Context, target *state.Target, service *state.Service, result *state.UpdateResult) error {
+	var oldIDs []string
+	var newID string
+	err := e.retry(ctx, service, result, func() (err error) {
+		oldIDs, newID, err = e.blueGreen.StartParallel(ctx, target, service)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if service.Labels.Probe.Type != state.ProbeTypeNone {
+		e.logger.Info().
+			Str("service", service.Name).
+			Str("container", shortID(newID)).
+			Msg("Probing parallel container")
+
+		probeStart := time.Now()
+		result.ProbeResults = e.probeEngine.ExecuteProbes(ctx, target, service, newID)
+		timerFromContext(ctx).addProbe(time.Since(probeStart))
+		if !probe.AllProbesPassed(result.ProbeResults) {
+			metrics.UpdatesTotal.WithLabelValues(target.Name, service.Name, "rolled_back").Inc()
+			if err := e.retireNewContainer(ctx, service, newID); err != nil {
+				return newStepError(state.ResultRollbackFailed,
+					fmt.Errorf("%w on the new container and it could not be removed: %w", ErrProbeFailed, err))
+			}
+			result.RollbackPerformed = true
+			result.RollbackDigest = result.OldDigest
+			return fmt.Errorf("%w on the new container, kept the previous container", ErrProbeFailed)
+		}
+	}
+
+	// Traffic moves to the new container; let the proxy drain the old one
+	// before it is removed.
+	if e.drainer != nil && service.Labels.Drain.URL != "" {
+		e.drainService(ctx, target, service)
+		defer e.enableService(ctx, target, service, result)
+	}
+
+	e.logger.Info().
+		Str("service", service.Name).
+		Str("container", shortID(oldIDs[0])).
+		Msg("Removing previous container")
+
+	retireStart := time.Now()
+	if err := e.blueGreen.Retire(ctx, oldIDs, service.StopGracePeriod); err != nil {
+		return newStepError(state.ResultRecreateFailed, fmt.Errorf("failed to remove previous container: %w", err))
+	}
+	timerFromContext(ctx).addRecreate(time.Since(retireStart))
+	return nil
+}
+
+func shortID(id string) string {
+	return id[:min(12, len(id))]
+}
+
+// useBlueGreen reports whether the update should run side by side. Loose
+// containers are always recreated.
+func (e *Executor) useBlueGreen(target *state.Target, service *state.Service) bool {
+	return e.blueGreen != nil &&
+		target.Type == state.TargetTypeCompose &&
+		service.Labels.Strategy == state.StrategyBlueGreen
+}
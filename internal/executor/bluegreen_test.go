@@ -0,0 +1,149 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/probe"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type fakeBlueGreen struct {
+	started   int
+	failFirst int // StartParallel fails this many times first
+	retired   []string
+	cancel    context.CancelFunc // Called once the parallel container started
+}
+
+func (f *fakeBlueGreen) StartParallel(ctx context.Context, target *state.Target, service *state.Service) ([]string, string, error) {
+	f.started++
+	if f.started <= f.failFirst {
+		return nil, "", errors.New("pull timeout")
+	}
+	if f.cancel != nil {
+		f.cancel()
+	}
+	return []string{"old"}, "new", nil
+}
+
+func (f *fakeBlueGreen) Retire(ctx context.Context, containerIDs []string, stopTimeout time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.retired = append(f.retired, containerIDs...)
+	return nil
+}
+
+func blueGreenExecutor(bg *fakeBlueGreen, compose *fakeComposeUpdater) *Executor {
+	return &Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		blueGreen:     bg,
+		lockManager:   &fakeLockManager{},
+		probeEngine:   probe.NewEngine(nil, logging.Default()),
+		logger:        logging.Default(),
+	}
+}
+
+func TestExecutorBlueGreenRetiresOldContainer(t *testing.T) {
+	bg := &fakeBlueGreen{}
+	compose := &fakeComposeUpdater{}
+	exec := blueGreenExecutor(bg, compose)
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Strategy = state.StrategyBlueGreen
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if !result.Success {
+		t.Fatalf("expected success, got error %s", result.ErrorMessage)
+	}
+	if compose.updateCalled != 0 {
+		t.Fatalf("expected recreate path not to run, got %d calls", compose.updateCalled)
+	}
+	if bg.started != 1 || len(bg.retired) != 1 || bg.retired[0] != "old" {
+		t.Fatalf("expected old container retired, got started=%d retired=%v", bg.started, bg.retired)
+	}
+}
+
+func TestExecutorBlueGreenKeepsOldContainerWhenProbesFail(t *testing.T) {
+	bg := &fakeBlueGreen{}
+	exec := blueGreenExecutor(bg, &fakeComposeUpdater{})
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Strategy = state.StrategyBlueGreen
+	service.Labels.Probe.Type = state.ProbeTypeHTTP // no URL, so the probe fails validation
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if result.ResultCode != state.ResultProbeFailed || !result.RollbackPerformed {
+		t.Fatalf("expected probe_failed with previous container kept, got code=%s rollback=%v", result.ResultCode, result.RollbackPerformed)
+	}
+	if len(bg.retired) != 1 || bg.retired[0] != "new" {
+		t.Fatalf("expected only the new container retired, got %v", bg.retired)
+	}
+}
+
+func TestExecutorBlueGreenRetiresNewContainerAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bg := &fakeBlueGreen{cancel: cancel}
+	exec := blueGreenExecutor(bg, &fakeComposeUpdater{})
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Strategy = state.StrategyBlueGreen
+	service.Labels.Probe.Type = state.ProbeTypeHTTP
+
+	result := exec.ExecuteUpdate(ctx, target, service, "sha256:new")
+
+	if result.ResultCode == state.ResultRollbackFailed || !result.RollbackPerformed {
+		t.Fatalf("expected the new container removed despite the cancelled update, got code=%s rollback=%v", result.ResultCode, result.RollbackPerformed)
+	}
+	if len(bg.retired) != 1 || bg.retired[0] != "new" {
+		t.Fatalf("expected the new container retired, got %v", bg.retired)
+	}
+}
+
+func TestExecutorBlueGreenIgnoredForContainerTargets(t *testing.T) {
+	bg := &fakeBlueGreen{}
+	exec := blueGreenExecutor(bg, &fakeComposeUpdater{})
+
+	target := &state.Target{ID: "container-1", Type: state.TargetTypeContainer, Name: "loose", Path: "abc"}
+	service := &state.Service{ID: "svc-1", Name: "loose", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Strategy = state.StrategyBlueGreen
+
+	if result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new"); !result.Success {
+		t.Fatalf("expected success, got error %s", result.ErrorMessage)
+	}
+	if bg.started != 0 {
+		t.Fatalf("expected loose container to be recreated, got %d parallel starts", bg.started)
+	}
+}
+
+func TestExecutorBlueGreenRetriesStart(t *testing.T) {
+	bg := &fakeBlueGreen{failFirst: 1}
+	exec := blueGreenExecutor(bg, &fakeComposeUpdater{})
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Strategy = state.StrategyBlueGreen
+	service.Labels.Retry = state.RetryConfig{Max: 1, Backoff: time.Millisecond}
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if !result.Success {
+		t.Fatalf("expected success after a retry, got error %s", result.ErrorMessage)
+	}
+	if result.Attempts != 2 || bg.started != 2 {
+		t.Fatalf("expected 2 attempts, got attempts=%d starts=%d", result.Attempts, bg.started)
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/artifacts"
 	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/state"
@@ -17,6 +20,9 @@ type ComposeExecutor struct {
 	runner       *docker.ComposeRunner
 	dockerClient *docker.Client
 	logger       *logging.Logger
+	prepulled    sync.Map // "<compose path>#<service>" pulled by PullImage, not yet recreated -> pull time
+	diskCheck    *DiskSpaceChecker
+	pulls        *PullScheduler
 }
 
 // NewComposeExecutor creates a new compose executor
@@ -28,6 +34,26 @@ func NewComposeExecutor(dockerClient *docker.Client, logger *logging.Logger) *Co
 	}
 }
 
+// compose returns the runner for target's compose project. Discovery names
+// compose targets after the project their containers run under, so commands
+// act on that stack even when it was started with -p.
+func (e *ComposeExecutor) compose(target *state.Target) *docker.ComposeRunner {
+	return e.runner.ForProject(target.Name)
+}
+
+// ProjectName resolves the compose project name of composePath as discovery
+// does, from the running containers when the Docker client is available.
+func (e *ComposeExecutor) ProjectName(ctx context.Context, composePath string) string {
+	if e.dockerClient == nil {
+		return docker.ComposeProjectName(composePath)
+	}
+	containers, err := e.dockerClient.ListContainers(ctx, false)
+	if err != nil {
+		return docker.ComposeProjectName(composePath)
+	}
+	return docker.ResolveProjectName(composePath, containers)
+}
+
 // UpdateService updates a service in a compose project
 func (e *ComposeExecutor) UpdateService(ctx context.Context, target *state.Target, service *state.Service) error {
 	if e.shouldSkipSelfUpdate(ctx, target, service) {
@@ -35,7 +61,7 @@ func (e *ComposeExecutor) UpdateService(ctx context.Context, target *state.Targe
 			Str("target", target.Name).
 			Str("service", service.Name).
 			Msg("Skipping self-update for Bulwark service")
-		return NewSkipError("self-update skipped: update Bulwark externally with 'docker compose pull bulwark && docker compose up -d bulwark'")
+		return NewCodedSkipError(state.ResultSkippedSelfUpdate, "self-update skipped: update Bulwark externally with 'docker compose pull bulwark && docker compose up -d bulwark'")
 	}
 
 	e.logger.Info().
@@ -44,41 +70,178 @@ func (e *ComposeExecutor) UpdateService(ctx context.Context, target *state.Targe
 		Str("image", service.Image).
 		Msg("Updating compose service")
 
-	// Step 1: Pull the latest image
+	if err := e.checkDefinition(ctx, target, service); err != nil {
+		return err
+	}
+
+	// Step 1: Pull the latest image, or rebuild it for build: services
+	if err := e.prepareImage(ctx, target, service); err != nil {
+		return err
+	}
+
+	// Step 2: Recreate service with new image (force recreate to pick up new digest)
+	e.logger.Info().
+		Str("service", service.Name).
+		Msg("Recreating service")
+
+	upStart := time.Now()
+	timer := timerFromContext(ctx)
+	timer.down(upStart)
+	err := e.compose(target).Up(ctx, target.Path, service.Name, upOptions(service))
+	upDuration := time.Since(upStart)
+	timer.addRecreate(upDuration)
+	if err != nil {
+		return newStepError(state.ResultRecreateFailed, fmt.Errorf("failed to recreate service: %w", err))
+	}
+
+	e.logger.Info().
+		Str("service", service.Name).
+		Dur("duration", upDuration).
+		Msg("Service recreated successfully")
+
+	return nil
+}
+
+// checkDefinition confirms compose accepts the target's compose file and that
+// it still defines the service, so an update of a broken definition fails
+// with ErrInvalidCompose rather than being retried.
+func (e *ComposeExecutor) checkDefinition(ctx context.Context, target *state.Target, service *state.Service) error {
+	services, err := e.compose(target).Services(ctx, target.Path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidCompose, err)
+	}
+	if !slices.Contains(services, service.Name) {
+		return fmt.Errorf("%w: %s does not define service %s", ErrInvalidCompose, target.Path, service.Name)
+	}
+	return nil
+}
+
+// upOptions builds the recreate options from the service's compose settings
+// and labels. Parallel services never touch their dependencies, which may be
+// updating at the same time.
+func upOptions(service *state.Service) docker.UpOptions {
+	return docker.UpOptions{
+		ForceRecreate: !service.Labels.ComposeNoForceRecreate,
+		WithDeps:      service.Labels.ComposeWithDeps && !service.Labels.Parallel,
+		StopTimeout:   service.StopGracePeriod,
+		ExtraFlags:    service.Labels.ComposeUpFlags,
+	}
+}
+
+// prepareImage pulls the service's image, or rebuilds it for build: services.
+func (e *ComposeExecutor) prepareImage(ctx context.Context, target *state.Target, service *state.Service) error {
+	if service.Build {
+		return e.rebuild(ctx, target, service)
+	}
+	if service.PullPolicy == state.PullPolicyNever {
+		return NewCodedSkipError(state.ResultSkippedPullPolicy, "pull_policy is never; pull the image outside Bulwark")
+	}
+	if pulled, ok := e.prepulled.LoadAndDelete(prepullKey(target, service)); ok {
+		timerFromContext(ctx).addPull(pulled.(time.Duration))
+		e.logger.Info().
+			Str("service", service.Name).
+			Msg("Using pre-pulled image")
+		return nil
+	}
+
+	if e.diskCheck != nil {
+		if err := e.diskCheck.Check(ctx, service); err != nil {
+			return err
+		}
+	}
+
 	e.logger.Info().
 		Str("service", service.Name).
+		Str("platform", service.Platform).
 		Msg("Pulling latest image")
 
+	release, err := e.pulls.acquire(ctx, service)
+	if err != nil {
+		return newStepError(state.ResultPullFailed, fmt.Errorf("failed to pull image: %w", err))
+	}
 	pullStart := time.Now()
-	if err := e.runner.Pull(ctx, target.Path, service.Name); err != nil {
-		return fmt.Errorf("failed to pull image: %w", err)
+	err = e.compose(target).Pull(ctx, target.Path, service.Name, service.Platform)
+	release()
+	if err != nil {
+		return newStepError(state.ResultPullFailed, fmt.Errorf("failed to pull image: %w", err))
 	}
 	pullDuration := time.Since(pullStart)
+	timerFromContext(ctx).addPull(pullDuration)
 
 	e.logger.Info().
 		Str("service", service.Name).
 		Dur("duration", pullDuration).
 		Msg("Image pull completed")
 
-	// Step 2: Recreate service with new image (force recreate to pick up new digest)
+	return nil
+}
+
+// PullImage pulls the service's image ahead of its update, so the update
+// itself only recreates the container. Build services are rebuilt during the
+// update instead.
+func (e *ComposeExecutor) PullImage(ctx context.Context, target *state.Target, service *state.Service) error {
+	if service.Build {
+		return nil
+	}
+	timer := &stepTimer{}
+	if err := e.prepareImage(withStepTimer(ctx, timer), target, service); err != nil {
+		return err
+	}
+	e.prepulled.Store(prepullKey(target, service), timer.pull)
+	return nil
+}
+
+func prepullKey(target *state.Target, service *state.Service) string {
+	return target.Path + "#" + service.Name
+}
+
+// rebuild runs `docker compose build --pull` for a build: service. The
+// current image is tagged first so a failed update can be rolled back to it.
+func (e *ComposeExecutor) rebuild(ctx context.Context, target *state.Target, service *state.Service) error {
+	if service.CurrentDigest != "" && e.dockerClient != nil {
+		if err := e.dockerClient.ImageTag(ctx, service.CurrentDigest, rollbackImageRef(service.Image)); err != nil {
+			e.logger.Warn().
+				Err(err).
+				Str("service", service.Name).
+				Msg("Failed to tag current image for rollback")
+		}
+	}
+
 	e.logger.Info().
 		Str("service", service.Name).
-		Msg("Recreating service")
+		Msg("Rebuilding image")
 
-	upStart := time.Now()
-	if err := e.runner.Up(ctx, target.Path, service.Name, true); err != nil {
-		return fmt.Errorf("failed to recreate service: %w", err)
+	buildStart := time.Now()
+	if err := e.compose(target).Build(ctx, target.Path, service.Name, true); err != nil {
+		return newStepError(state.ResultBuildFailed, fmt.Errorf("failed to build image: %w", err))
 	}
-	upDuration := time.Since(upStart)
+
+	buildDuration := time.Since(buildStart)
+	timerFromContext(ctx).addPull(buildDuration)
 
 	e.logger.Info().
 		Str("service", service.Name).
-		Dur("duration", upDuration).
-		Msg("Service recreated successfully")
+		Dur("duration", buildDuration).
+		Msg("Image build completed")
 
 	return nil
 }
 
+// rollbackImageRef is the tag holding the pre-build image of a build: service.
+func rollbackImageRef(image string) string {
+	repo := image
+	if at := strings.Index(repo, "@"); at >= 0 {
+		repo = repo[:at]
+	}
+	if colon := strings.LastIndex(repo, ":"); colon > strings.LastIndex(repo, "/") {
+		repo = repo[:colon]
+	}
+	return repo + ":bulwark-rollback"
+}
+
 func (e *ComposeExecutor) shouldSkipSelfUpdate(ctx context.Context, target *state.Target, service *state.Service) bool {
 	if target == nil || service == nil {
 		return false
@@ -126,36 +289,28 @@ func allowSelfUpdate(value string) bool {
 }
 
 // getSelfContainerID determines the container ID of the running Bulwark instance.
-// It tries multiple strategies in order of reliability:
-// 1. BULWARK_CONTAINER_ID env var (explicit, most reliable)
-// 2. /proc/1/cpuset (works in most Docker environments)
-// 3. HOSTNAME env var (fallback, current behavior)
+// BULWARK_CONTAINER_ID takes precedence; otherwise the ID is detected the
+// way the platform allows (see hostContainerID).
 func getSelfContainerID() string {
-	// Strategy 1: Explicit env var
 	if id := strings.TrimSpace(os.Getenv("BULWARK_CONTAINER_ID")); id != "" {
 		return id
 	}
+	return hostContainerID()
+}
 
-	// Strategy 2: Read from /proc/1/cpuset
-	if data, err := os.ReadFile("/proc/1/cpuset"); err == nil {
-		cpuset := strings.TrimSpace(string(data))
-		// cpuset looks like /docker/<container_id> or /system.slice/docker-<id>.scope
-		if parts := strings.Split(cpuset, "/"); len(parts) > 1 {
-			last := parts[len(parts)-1]
-			// Docker format: the last segment is the container ID
-			if len(last) >= 12 && last != "" {
-				// Handle systemd format: docker-<id>.scope
-				last = strings.TrimPrefix(last, "docker-")
-				last = strings.TrimSuffix(last, ".scope")
-				if len(last) >= 12 {
-					return last
-				}
-			}
-		}
+// containerIDFromCpuset extracts the container ID from the content of
+// /proc/1/cpuset, which looks like /docker/<id> or
+// /system.slice/docker-<id>.scope. It returns "" for other content.
+func containerIDFromCpuset(cpuset string) string {
+	parts := strings.Split(strings.TrimSpace(cpuset), "/")
+	if len(parts) < 2 {
+		return ""
 	}
-
-	// Strategy 3: HOSTNAME (traditional fallback)
-	return strings.TrimSpace(os.Getenv("HOSTNAME"))
+	last := strings.TrimSuffix(strings.TrimPrefix(parts[len(parts)-1], "docker-"), ".scope")
+	if len(last) < 12 {
+		return ""
+	}
+	return last
 }
 
 // GetNewDigest gets the digest of the currently running container after update
@@ -198,19 +353,33 @@ func (e *ComposeExecutor) Rollback(ctx context.Context, target *state.Target, se
 		Str("digest", digest).
 		Msg("Rolling back service to previous digest")
 
-	baseImage := service.Image
-	if strings.Contains(baseImage, "@") {
-		parts := strings.Split(service.Image, "@")
-		baseImage = parts[0]
-	}
-	imageWithDigest := fmt.Sprintf("%s@%s", baseImage, digest)
-
-	e.logger.Info().
-		Str("image", imageWithDigest).
-		Msg("Pulling previous digest")
-
-	if err := e.dockerClient.ImagePull(ctx, imageWithDigest); err != nil {
-		return fmt.Errorf("failed to pull previous digest: %w", err)
+	var imageWithDigest string
+	if service.Build {
+		// Locally built images cannot be pulled; use the tag saved before the rebuild.
+		imageWithDigest = rollbackImageRef(service.Image)
+	} else {
+		baseImage := service.Image
+		if strings.Contains(baseImage, "@") {
+			parts := strings.Split(service.Image, "@")
+			baseImage = parts[0]
+		}
+		imageWithDigest = fmt.Sprintf("%s@%s", baseImage, digest)
+
+		// The previous image is usually still around; pulling it again would
+		// fail once the registry garbage-collected the digest.
+		if local, err := e.dockerClient.HasImage(ctx, imageWithDigest); err == nil && local {
+			e.logger.Info().
+				Str("image", imageWithDigest).
+				Msg("Previous digest available locally")
+		} else {
+			e.logger.Info().
+				Str("image", imageWithDigest).
+				Msg("Pulling previous digest")
+
+			if err := e.dockerClient.ImagePull(ctx, imageWithDigest, service.Platform); err != nil {
+				return fmt.Errorf("failed to pull previous digest: %w", err)
+			}
+		}
 	}
 
 	// Step 2: Pin rollback image via temporary compose override to guarantee digest recreation.
@@ -228,13 +397,16 @@ func (e *ComposeExecutor) Rollback(ctx context.Context, target *state.Target, se
 	if _, err := overrideFile.WriteString(overrideContent); err != nil {
 		return fmt.Errorf("failed to write rollback override file: %w", err)
 	}
+	if err := artifacts.Record(ctx, artifacts.KindRollbackOverride, "rollback-override.yml", []byte(overrideContent)); err != nil {
+		e.logger.Warn().Err(err).Msg("Failed to keep rollback override file")
+	}
 
 	// Step 3: Recreate service with rolled-back image
 	e.logger.Info().
 		Str("service", service.Name).
 		Msg("Recreating service with previous version")
 
-	if err := e.runner.UpWithOverride(ctx, target.Path, overridePath, service.Name, true); err != nil {
+	if err := e.compose(target).UpWithOverride(ctx, target.Path, overridePath, service.Name, upOptions(service)); err != nil {
 		return fmt.Errorf("failed to recreate service during rollback: %w", err)
 	}
 
@@ -1,6 +1,15 @@
 package executor
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
 
 func TestIsSameComposeService(t *testing.T) {
 	tests := []struct {
@@ -58,3 +67,95 @@ func TestAllowSelfUpdate(t *testing.T) {
 		t.Fatal("expected false to disable self-update")
 	}
 }
+
+func TestRollbackImageRef(t *testing.T) {
+	tests := map[string]string{
+		"myapp-web":                    "myapp-web:bulwark-rollback",
+		"myapp-web:latest":             "myapp-web:bulwark-rollback",
+		"registry:5000/team/api:1.2":   "registry:5000/team/api:bulwark-rollback",
+		"registry:5000/team/api":       "registry:5000/team/api:bulwark-rollback",
+		"team/api@sha256:0123456789ab": "team/api:bulwark-rollback",
+	}
+	for image, want := range tests {
+		if got := rollbackImageRef(image); got != want {
+			t.Errorf("rollbackImageRef(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestPrepareImageRespectsPullPolicyNever(t *testing.T) {
+	e := NewComposeExecutor(nil, logging.Default())
+	target := &state.Target{Name: "app", Path: "/nonexistent/compose.yml"}
+	service := &state.Service{Name: "web", Image: "nginx:latest", PullPolicy: state.PullPolicyNever}
+
+	err := e.prepareImage(context.Background(), target, service)
+	if ResultCodeFor(err) != state.ResultSkippedPullPolicy {
+		t.Fatalf("expected pull_policy skip, got %v", err)
+	}
+}
+
+func TestPrepareImageUsesPrePulledImageOnce(t *testing.T) {
+	e := NewComposeExecutor(nil, logging.Default())
+	target := &state.Target{Name: "app", Path: "/nonexistent/compose.yml"}
+	service := &state.Service{Name: "web", Image: "nginx:latest"}
+
+	e.prepulled.Store(prepullKey(target, service), 3*time.Second)
+	timer := &stepTimer{}
+	if err := e.prepareImage(withStepTimer(context.Background(), timer), target, service); err != nil {
+		t.Fatalf("expected pre-pulled image to be used, got %v", err)
+	}
+	if timer.pull != 3*time.Second {
+		t.Errorf("expected the pre-pull time to count as pull time, got %s", timer.pull)
+	}
+	if err := e.prepareImage(context.Background(), target, service); err == nil {
+		t.Fatal("expected a second update to pull again")
+	}
+}
+
+func TestCheckDefinition(t *testing.T) {
+	docker.UseCompose(func(ctx context.Context, files, args []string) (string, error) {
+		if files[0] == "/stacks/broken/compose.yaml" {
+			return "", errors.New("validating compose.yaml: services.web additional properties 'imag' not allowed")
+		}
+		return "db\nweb\n", nil
+	})
+	t.Cleanup(func() { docker.UseCompose(nil) })
+	e := NewComposeExecutor(nil, logging.Default())
+
+	tests := []struct {
+		path, service string
+		invalid       bool
+	}{
+		{"/stacks/app/compose.yaml", "web", false},
+		{"/stacks/app/compose.yaml", "cache", true},
+		{"/stacks/broken/compose.yaml", "web", true},
+	}
+	for _, tt := range tests {
+		err := e.checkDefinition(context.Background(), &state.Target{Name: "app", Path: tt.path}, &state.Service{Name: tt.service})
+		if got := errors.Is(err, ErrInvalidCompose); got != tt.invalid {
+			t.Errorf("checkDefinition(%s, %s) = %v, want invalid %v", tt.path, tt.service, err, tt.invalid)
+		}
+		if tt.invalid && isTransient(err) {
+			t.Errorf("expected %v not to be retried", err)
+		}
+	}
+}
+
+func TestContainerIDFromCpuset(t *testing.T) {
+	id := "4f3c2b1a0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4a3f2e1d0c9b8a7f6e5d4c3b"
+	tests := []struct {
+		cpuset string
+		want   string
+	}{
+		{"/docker/" + id + "\n", id},
+		{"/system.slice/docker-" + id + ".scope", id},
+		{"/\n", ""},
+		{"", ""},
+		{"/user.slice", ""},
+	}
+	for _, tt := range tests {
+		if got := containerIDFromCpuset(tt.cpuset); got != tt.want {
+			t.Errorf("containerIDFromCpuset(%q) = %q, want %q", tt.cpuset, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// containerSnapshot inspects the container a compose service runs in, so its
+// configuration can be compared across the recreate. It returns nil when the
+// container cannot be inspected; loose containers are not compared because
+// the recreate replaces the container ID their target points at.
+func (e *Executor) containerSnapshot(ctx context.Context, target *state.Target, service *state.Service) *docker.ContainerJSON {
+	if e.dockerClient == nil || target.Type != state.TargetTypeCompose {
+		return nil
+	}
+	containerID, err := e.findContainerID(ctx, target, service)
+	if err != nil {
+		e.logger.Debug().Err(err).Str("service", service.Name).Msg("No container to snapshot")
+		return nil
+	}
+	inspect, err := e.dockerClient.InspectContainer(ctx, containerID)
+	if err != nil {
+		e.logger.Debug().Err(err).Str("service", service.Name).Msg("Failed to snapshot container config")
+		return nil
+	}
+	return &inspect
+}
+
+// recordConfigChanges attaches to result how the recreated container's
+// configuration differs from before, so changed image defaults such as a
+// dropped volume show up even when the probes pass.
+func (e *Executor) recordConfigChanges(ctx context.Context, target *state.Target, service *state.Service, before *docker.ContainerJSON, result *state.UpdateResult) {
+	if before == nil {
+		return
+	}
+	after := e.containerSnapshot(ctx, target, service)
+	if after == nil {
+		return
+	}
+	result.ConfigChanges = diffContainerConfig(before, after)
+	if len(result.ConfigChanges) > 0 {
+		e.logger.Info().
+			Str("service", service.Name).
+			Int("changes", len(result.ConfigChanges)).
+			Msg("Container configuration changed")
+	}
+}
+
+// diffContainerConfig lists how the environment, mounts, ports, labels,
+// entrypoint and command of after differ from before, ordered by field and key.
+func diffContainerConfig(before, after *docker.ContainerJSON) []state.ConfigChange {
+	var changes []state.ConfigChange
+	sections := []struct {
+		field string
+		read  func(*docker.ContainerJSON) map[string]string
+	}{
+		{"env", envValues},
+		{"mount", mountValues},
+		{"port", portValues},
+		{"label", labelValues},
+		{"entrypoint", func(c *docker.ContainerJSON) map[string]string {
+			if c.Config == nil {
+				return nil
+			}
+			return commandValue(c.Config.Entrypoint)
+		}},
+		{"cmd", func(c *docker.ContainerJSON) map[string]string {
+			if c.Config == nil {
+				return nil
+			}
+			return commandValue(c.Config.Cmd)
+		}},
+	}
+	for _, section := range sections {
+		changes = append(changes, diffValues(section.field, section.read(before), section.read(after))...)
+	}
+	return changes
+}
+
+func diffValues(field string, before, after map[string]string) []state.ConfigChange {
+	keys := make(map[string]bool, len(before)+len(after))
+	for key := range before {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var changes []state.ConfigChange
+	for _, key := range sorted {
+		if before[key] != after[key] {
+			changes = append(changes, state.ConfigChange{Field: field, Key: key, Before: before[key], After: after[key]})
+		}
+	}
+	return changes
+}
+
+func envValues(c *docker.ContainerJSON) map[string]string {
+	if c.Config == nil {
+		return nil
+	}
+	values := make(map[string]string, len(c.Config.Env))
+	for _, entry := range c.Config.Env {
+		key, value, _ := strings.Cut(entry, "=")
+		values[key] = value
+	}
+	return values
+}
+
+func mountValues(c *docker.ContainerJSON) map[string]string {
+	values := make(map[string]string, len(c.Mounts))
+	for _, mount := range c.Mounts {
+		source := mount.Source
+		if mount.Name != "" {
+			source = mount.Name
+		}
+		value := fmt.Sprintf("%s %s", mount.Type, source)
+		if !mount.RW {
+			value += " (ro)"
+		}
+		values[mount.Destination] = value
+	}
+	return values
+}
+
+func portValues(c *docker.ContainerJSON) map[string]string {
+	if c.NetworkSettings == nil {
+		return nil
+	}
+	values := make(map[string]string, len(c.NetworkSettings.Ports))
+	for port, bindings := range c.NetworkSettings.Ports {
+		hosts := make([]string, 0, len(bindings))
+		for _, binding := range bindings {
+			hosts = append(hosts, binding.HostIP+":"+binding.HostPort)
+		}
+		sort.Strings(hosts)
+		values[port] = strings.Join(hosts, ", ")
+		if values[port] == "" {
+			values[port] = "exposed"
+		}
+	}
+	return values
+}
+
+// labelValues leaves out compose's bookkeeping labels and the OCI image
+// annotations, which change with every recreate or build.
+func labelValues(c *docker.ContainerJSON) map[string]string {
+	if c.Config == nil {
+		return nil
+	}
+	values := make(map[string]string, len(c.Config.Labels))
+	for key, value := range c.Config.Labels {
+		if strings.HasPrefix(key, "com.docker.compose.") || strings.HasPrefix(key, "org.opencontainers.image.") {
+			continue
+		}
+		values[key] = value
+	}
+	return values
+}
+
+func commandValue(args []string) map[string]string {
+	if len(args) == 0 {
+		return nil
+	}
+	return map[string]string{"": strings.Join(args, " ")}
+}
@@ -0,0 +1,54 @@
+package executor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestDiffContainerConfig(t *testing.T) {
+	before := &docker.ContainerJSON{
+		Config: &docker.ContainerConfig{
+			Env:        []string{"PATH=/usr/bin", "APP_VERSION=1.25", "MODE=prod"},
+			Labels:     map[string]string{"com.docker.compose.config-hash": "aaa", "team": "web"},
+			Entrypoint: []string{"/docker-entrypoint.sh"},
+			Cmd:        []string{"nginx", "-g", "daemon off;"},
+		},
+		NetworkSettings: &docker.NetworkSettings{Ports: map[string][]docker.PortBinding{
+			"80/tcp": {{HostIP: "0.0.0.0", HostPort: "8080"}},
+		}},
+		Mounts: []docker.Mount{
+			{Type: "volume", Name: "app_data", Source: "/var/lib/docker/volumes/app_data/_data", Destination: "/data", RW: true},
+			{Type: "bind", Source: "/srv/app/conf", Destination: "/etc/nginx/conf.d"},
+		},
+	}
+	after := &docker.ContainerJSON{
+		Config: &docker.ContainerConfig{
+			Env:        []string{"PATH=/usr/bin", "APP_VERSION=1.27", "MODE=prod"},
+			Labels:     map[string]string{"com.docker.compose.config-hash": "bbb", "team": "web"},
+			Entrypoint: []string{"/docker-entrypoint.sh"},
+			Cmd:        []string{"nginx", "-g", "daemon off;"},
+		},
+		NetworkSettings: &docker.NetworkSettings{Ports: map[string][]docker.PortBinding{
+			"80/tcp":  {{HostIP: "0.0.0.0", HostPort: "8080"}},
+			"443/tcp": nil,
+		}},
+		Mounts: []docker.Mount{
+			{Type: "bind", Source: "/srv/app/conf", Destination: "/etc/nginx/conf.d"},
+		},
+	}
+
+	want := []state.ConfigChange{
+		{Field: "env", Key: "APP_VERSION", Before: "1.25", After: "1.27"},
+		{Field: "mount", Key: "/data", Before: "volume app_data"},
+		{Field: "port", Key: "443/tcp", After: "exposed"},
+	}
+	if got := diffContainerConfig(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffContainerConfig() = %+v, want %+v", got, want)
+	}
+	if got := diffContainerConfig(before, before); len(got) != 0 {
+		t.Errorf("expected no changes for the same config, got %+v", got)
+	}
+}
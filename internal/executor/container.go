@@ -3,8 +3,8 @@ package executor
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 
+	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
@@ -30,23 +30,14 @@ func (e *ContainerExecutor) UpdateService(ctx context.Context, target *state.Tar
 	}
 
 	if !service.Labels.Enabled {
-		return NewSkipError("bulwark.enabled is not true")
+		return NewCodedSkipError(state.ResultSkippedDisabled, "bulwark.enabled is not true")
 	}
 
-	definition, err := ParseDefinition(service.Labels.Definition)
+	composeTarget, composeService, definition, err := resolveDefinition(service)
 	if err != nil {
-		return NewSkipError(fmt.Sprintf("invalid definition: %v", err))
-	}
-
-	composeTarget := &state.Target{
-		Type: state.TargetTypeCompose,
-		Name: composeProjectName(definition.ComposePath),
-		Path: definition.ComposePath,
-	}
-	composeService := &state.Service{
-		Name:  definition.Service,
-		Image: service.Image,
+		return err
 	}
+	e.resolveProject(ctx, composeTarget)
 
 	e.logger.Info().
 		Str("container", service.Name).
@@ -64,12 +55,45 @@ func (e *ContainerExecutor) Rollback(ctx context.Context, target *state.Target,
 	}
 
 	if !service.Labels.Enabled {
-		return NewSkipError("bulwark.enabled is not true")
+		return NewCodedSkipError(state.ResultSkippedDisabled, "bulwark.enabled is not true")
+	}
+
+	composeTarget, composeService, definition, err := resolveDefinition(service)
+	if err != nil {
+		return err
+	}
+	e.resolveProject(ctx, composeTarget)
+
+	e.logger.Warn().
+		Str("container", service.Name).
+		Str("compose_path", definition.ComposePath).
+		Str("compose_service", definition.Service).
+		Msg("Delegating rollback to compose executor")
+
+	return e.composeExec.Rollback(ctx, composeTarget, composeService, digest)
+}
+
+// PullImage pre-pulls a loose container's image through its compose definition.
+func (e *ContainerExecutor) PullImage(ctx context.Context, target *state.Target, service *state.Service) error {
+	puller, ok := e.composeExec.(imagePuller)
+	if !ok || service == nil || !service.Labels.Enabled {
+		return nil
 	}
 
+	composeTarget, composeService, _, err := resolveDefinition(service)
+	if err != nil {
+		return err
+	}
+	e.resolveProject(ctx, composeTarget)
+	return puller.PullImage(ctx, composeTarget, composeService)
+}
+
+// resolveDefinition maps a loose container to the compose target and service
+// its definition label points at.
+func resolveDefinition(service *state.Service) (*state.Target, *state.Service, Definition, error) {
 	definition, err := ParseDefinition(service.Labels.Definition)
 	if err != nil {
-		return NewSkipError(fmt.Sprintf("invalid definition: %v", err))
+		return nil, nil, Definition{}, NewCodedSkipError(state.ResultInvalidDefinition, fmt.Sprintf("invalid definition: %v", err))
 	}
 
 	composeTarget := &state.Target{
@@ -81,19 +105,20 @@ func (e *ContainerExecutor) Rollback(ctx context.Context, target *state.Target,
 		Name:  definition.Service,
 		Image: service.Image,
 	}
+	return composeTarget, composeService, definition, nil
+}
 
-	e.logger.Warn().
-		Str("container", service.Name).
-		Str("compose_path", definition.ComposePath).
-		Str("compose_service", definition.Service).
-		Msg("Delegating rollback to compose executor")
-
-	return e.composeExec.Rollback(ctx, composeTarget, composeService, digest)
+// resolveProject names composeTarget after the project its containers run
+// under, as discovery does, when the compose executor can tell.
+func (e *ContainerExecutor) resolveProject(ctx context.Context, composeTarget *state.Target) {
+	if resolver, ok := e.composeExec.(projectResolver); ok && composeTarget.Path != "" {
+		composeTarget.Name = resolver.ProjectName(ctx, composeTarget.Path)
+	}
 }
 
 func composeProjectName(composePath string) string {
 	if composePath == "" {
 		return "unknown"
 	}
-	return filepath.Base(filepath.Dir(composePath))
+	return docker.ComposeProjectName(composePath)
 }
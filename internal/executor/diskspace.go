@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// diskSpaceFactor is how many times an image's compressed size must be free
+// before pulling: the download is stored and then extracted.
+const diskSpaceFactor = 2
+
+type imageSizer interface {
+	FetchImageSize(ctx context.Context, image, platform string) (int64, error)
+}
+
+// DiskSpaceChecker refuses pulls that would not fit on the Docker data root.
+type DiskSpaceChecker struct {
+	sizer     imageSizer
+	dataRoot  string
+	freeSpace func(path string) (uint64, error)
+	logger    *logging.Logger
+}
+
+// NewDiskSpaceChecker creates a checker for the filesystem holding dataRoot,
+// which must be visible to Bulwark (the host path, or a mount of it).
+func NewDiskSpaceChecker(sizer imageSizer, dataRoot string, logger *logging.Logger) *DiskSpaceChecker {
+	return &DiskSpaceChecker{
+		sizer:     sizer,
+		dataRoot:  dataRoot,
+		freeSpace: docker.FreeSpace,
+		logger:    logger.WithComponent("disk-check"),
+	}
+}
+
+// Check returns a skip error when the service's new image needs more space
+// than is free. If either number cannot be determined the pull goes ahead.
+func (c *DiskSpaceChecker) Check(ctx context.Context, service *state.Service) error {
+	free, err := c.freeSpace(c.dataRoot)
+	if err != nil {
+		c.logger.Debug().Err(err).Msg("Disk space unknown; skipping check")
+		return nil
+	}
+
+	size, err := c.sizer.FetchImageSize(ctx, service.Image, service.Platform)
+	if err != nil {
+		c.logger.Debug().Err(err).Str("image", service.Image).Msg("Image size unknown; skipping disk check")
+		return nil
+	}
+
+	required := uint64(size) * diskSpaceFactor
+	if free < required {
+		return NewCodedSkipError(state.ResultInsufficientDisk, fmt.Sprintf(
+			"insufficient disk space: %s needs about %s, %s free on %s",
+			service.Image, formatBytes(required), formatBytes(free), c.dataRoot))
+	}
+	return nil
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type fakeSizer struct {
+	size int64
+	err  error
+}
+
+func (f fakeSizer) FetchImageSize(ctx context.Context, image, platform string) (int64, error) {
+	return f.size, f.err
+}
+
+func TestDiskSpaceChecker(t *testing.T) {
+	service := &state.Service{Name: "web", Image: "nginx:latest"}
+	const gib = 1 << 30
+
+	tests := []struct {
+		name     string
+		size     int64
+		sizeErr  error
+		free     uint64
+		freeErr  error
+		wantSkip bool
+	}{
+		{name: "enough space", size: gib, free: 3 * gib},
+		{name: "not enough space", size: gib, free: gib + gib/2, wantSkip: true},
+		{name: "unknown image size", sizeErr: errors.New("registry down"), free: 1},
+		{name: "unknown free space", size: gib, freeErr: errors.New("no such path")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := NewDiskSpaceChecker(fakeSizer{size: tt.size, err: tt.sizeErr}, "/var/lib/docker", logging.Default())
+			checker.freeSpace = func(string) (uint64, error) { return tt.free, tt.freeErr }
+
+			err := checker.Check(context.Background(), service)
+			if tt.wantSkip {
+				if ResultCodeFor(err) != state.ResultInsufficientDisk {
+					t.Fatalf("expected insufficient_disk, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected pull to go ahead, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[uint64]string{
+		512:           "512 B",
+		1536:          "1.5 KiB",
+		3 * (1 << 30): "3.0 GiB",
+		5 * (1 << 20): "5.0 MiB",
+	}
+	for n, want := range tests {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
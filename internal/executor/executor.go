@@ -6,10 +6,13 @@ import (
 	"time"
 
 	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/drain"
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/metrics"
 	"github.com/itsmrshow/bulwark/internal/policy"
 	"github.com/itsmrshow/bulwark/internal/probe"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/sbom"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
 
@@ -17,7 +20,11 @@ import (
 type Executor struct {
 	composeExec   composeUpdater
 	containerExec containerUpdater
+	blueGreen     blueGreenUpdater
 	lockManager   lockManager
+	configHasher  configHasher
+	drainer       proxyDrainer
+	sbom          sbomGenerator
 	policyEngine  *policy.Engine
 	probeEngine   *probe.Engine
 	store         state.Store
@@ -25,6 +32,18 @@ type Executor struct {
 	logger        *logging.Logger
 	dryRun        bool
 	lockTimeout   time.Duration
+
+	// resourceSnapshots samples CPU and memory use around each update;
+	// memoryJumpPercent is the memory increase that gets flagged.
+	resourceSnapshots bool
+	memoryJumpPercent float64
+
+	watchdog *Watchdog
+
+	// actor is recorded in the compose journal for files the executor edits
+	// and, with trigger, in the history of every update it runs.
+	actor   string
+	trigger state.UpdateTrigger
 }
 
 // NewExecutor creates a new executor
@@ -33,7 +52,10 @@ func NewExecutor(dockerClient *docker.Client, policyEngine *policy.Engine, store
 	return &Executor{
 		composeExec:   composeExec,
 		containerExec: NewContainerExecutor(composeExec, logger),
+		blueGreen:     composeExec,
 		lockManager:   NewLockManager(logger),
+		configHasher:  composeExec.runner,
+		drainer:       drain.NewWebhookDrainer(logger),
 		policyEngine:  policyEngine,
 		probeEngine:   probe.NewEngine(dockerClient, logger),
 		store:         store,
@@ -50,6 +72,88 @@ func (e *Executor) WithLockTimeout(d time.Duration) *Executor {
 	return e
 }
 
+// WithTrigger records what started the executor's updates and who in their
+// history, and credits actor in the compose journal for files it edits.
+func (e *Executor) WithTrigger(trigger state.UpdateTrigger, actor string) *Executor {
+	e.trigger = trigger
+	e.actor = actor
+	return e
+}
+
+// WithDiskSpaceCheck makes compose pulls check free space on the Docker data
+// root first and skip the update when the image would not fit.
+func (e *Executor) WithDiskSpaceCheck(checker *DiskSpaceChecker) *Executor {
+	if compose, ok := e.composeExec.(*ComposeExecutor); ok {
+		compose.diskCheck = checker
+	}
+	return e
+}
+
+// WithLockManager makes the executor take target locks from locks, which is
+// shared with the process's other executors so their updates exclude each
+// other.
+func (e *Executor) WithLockManager(locks *LockManager) *Executor {
+	if locks != nil {
+		e.lockManager = locks
+	}
+	return e
+}
+
+// WithPullScheduler makes compose pulls wait for their turn in scheduler,
+// which is shared with the process's other executors.
+func (e *Executor) WithPullScheduler(scheduler *PullScheduler) *Executor {
+	if compose, ok := e.composeExec.(*ComposeExecutor); ok {
+		compose.pulls = scheduler
+	}
+	return e
+}
+
+// WithResourceSnapshots samples each service's CPU and memory use before
+// its update and once the new version's probes pass, flagging a memory
+// increase of more than memoryJumpPercent (never, when zero).
+func (e *Executor) WithResourceSnapshots(memoryJumpPercent float64) *Executor {
+	e.resourceSnapshots = true
+	e.memoryJumpPercent = memoryJumpPercent
+	return e
+}
+
+// WithWatchdog hands services with a fail pattern to watchdog once their
+// update succeeded.
+func (e *Executor) WithWatchdog(watchdog *Watchdog) *Executor {
+	e.watchdog = watchdog
+	return e
+}
+
+// WithSBOM captures an SBOM for each digest a successful update applies.
+func (e *Executor) WithSBOM(generator *sbom.SyftGenerator) *Executor {
+	if generator != nil {
+		e.sbom = generator
+	}
+	return e
+}
+
+// PrePull fetches the image for an update ahead of time, so ExecuteUpdate
+// only has to recreate the service. Targets whose updater cannot pull
+// separately are left for ExecuteUpdate.
+func (e *Executor) PrePull(ctx context.Context, target *state.Target, service *state.Service) error {
+	if e.dryRun {
+		return nil
+	}
+
+	var updater interface{}
+	switch target.Type {
+	case state.TargetTypeCompose:
+		updater = e.composeExec
+	case state.TargetTypeContainer:
+		updater = e.containerExec
+	}
+	puller, ok := updater.(imagePuller)
+	if !ok {
+		return nil
+	}
+	return puller.PullImage(ctx, target, service)
+}
+
 // ExecuteUpdate performs an update for a service
 func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, service *state.Service, newDigest string) *state.UpdateResult {
 	result := &state.UpdateResult{
@@ -62,7 +166,12 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 		RollbackPerformed: false,
 		ProbeResults:      []state.ProbeResult{},
 		StartedAt:         time.Now(),
+		Trigger:           e.trigger,
+		Actor:             e.actor,
 	}
+	DescribeImage(result, service.Image, service.Platform)
+	timer := &stepTimer{}
+	ctx = withStepTimer(ctx, timer)
 
 	e.logger.Info().
 		Str("target", target.Name).
@@ -75,36 +184,56 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 	if e.dryRun {
 		e.logger.Info().Msg("DRY RUN: Would update service")
 		result.Success = true
+		result.ResultCode = state.ResultSuccess
 		result.CompletedAt = time.Now()
 		return result
 	}
 
 	// Acquire lock
-	if err := e.lockManager.Lock(ctx, target.ID, e.lockTimeout); err != nil {
-		result.Error = fmt.Errorf("failed to acquire lock: %w", err)
-		result.CompletedAt = time.Now()
+	if err := e.acquireLock(ctx, target, service); err != nil {
+		recordError(result, err)
+		if IsSkipError(err) {
+			result.NewDigest = result.OldDigest
+		}
+		timer.finish(result)
 		return result
 	}
-	defer e.lockManager.Unlock(target.ID)
+	defer e.unlockService(target, service)
+
+	return e.update(ctx, target, service, newDigest, result, timer)
+}
+
+// update recreates service with its new image, probes it and rolls it back
+// when the probes fail. The caller holds the service's lock.
+func (e *Executor) update(ctx context.Context, target *state.Target, service *state.Service, newDigest string, result *state.UpdateResult, timer *stepTimer) *state.UpdateResult {
+	// Blue-green updates probe the new container before the old one is
+	// removed, and drain the proxy only for that switch.
+	blueGreen := e.useBlueGreen(target, service)
+	// A watch of the service's previous update ends with its container.
+	e.watchdog.Stop(service.ID)
+	before := e.containerSnapshot(ctx, target, service)
+	usageBefore := e.resourceUsage(ctx, target, service)
+
+	if !blueGreen && e.drainer != nil && service.Labels.Drain.URL != "" {
+		e.drainService(ctx, target, service)
+		defer e.enableService(ctx, target, service, result)
+	}
 
-	// Perform update based on target type
 	var updateErr error
-	switch target.Type {
-	case state.TargetTypeCompose:
-		updateErr = e.composeExec.UpdateService(ctx, target, service)
-	case state.TargetTypeContainer:
-		updateErr = e.containerExec.UpdateService(ctx, target, service)
-	default:
-		updateErr = fmt.Errorf("unknown target type: %s", target.Type)
+	if blueGreen {
+		updateErr = e.blueGreenUpdate(ctx, target, service, result)
+	} else {
+		// Perform update, retrying transient failures per the service's retry policy
+		updateErr = e.updateWithRetry(ctx, target, service, result)
 	}
 
 	if updateErr != nil {
-		result.Error = updateErr
+		recordError(result, updateErr)
 		result.Success = false
 		if IsSkipError(updateErr) {
 			result.NewDigest = result.OldDigest
 		}
-		result.CompletedAt = time.Now()
+		timer.finish(result)
 
 		if !IsSkipError(updateErr) {
 			metrics.UpdatesTotal.WithLabelValues(target.Name, service.Name, "failed").Inc()
@@ -122,6 +251,13 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 				Msg("Update failed")
 		}
 
+		// Results with probe outcomes are persisted here, as on the probe failure path below
+		if len(result.ProbeResults) > 0 && e.store != nil {
+			if err := e.store.SaveUpdateResult(ctx, result); err != nil {
+				e.logger.Warn().Err(err).Msg("Failed to save update result to store")
+			}
+		}
+
 		return result
 	}
 
@@ -142,9 +278,11 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 		actualNewDigest = newDigest
 	}
 	result.NewDigest = actualNewDigest
+	e.recordConfigChanges(ctx, target, service, before, result)
 
-	// Run health probes if configured (skip for dry-run and if probe type is none)
-	if service.Labels.Probe.Type != state.ProbeTypeNone {
+	// Run health probes if configured (skip for dry-run, if probe type is none,
+	// and for blue-green updates, which probed the new container already)
+	if service.Labels.Probe.Type != state.ProbeTypeNone && !blueGreen {
 		e.logger.Info().
 			Str("service", service.Name).
 			Str("probe_type", string(service.Labels.Probe.Type)).
@@ -156,7 +294,9 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 			e.logger.Warn().Err(err).Msg("Failed to find container ID for probes, skipping")
 		} else {
 			// Execute probes
+			probeStart := time.Now()
 			probeResults := e.probeEngine.ExecuteProbes(ctx, target, service, containerID)
+			timer.addProbe(time.Since(probeStart))
 			result.ProbeResults = probeResults
 
 			// Check if all probes passed
@@ -170,13 +310,14 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 				// Perform rollback
 				rollbackErr := e.ExecuteRollback(ctx, target, service, result)
 				if rollbackErr != nil {
-					result.Error = fmt.Errorf("update succeeded but probes failed, rollback also failed: %w", rollbackErr)
+					recordError(result, newStepError(state.ResultRollbackFailed,
+						fmt.Errorf("update succeeded but %w, rollback also failed: %w", ErrProbeFailed, rollbackErr)))
 				} else {
-					result.Error = fmt.Errorf("update succeeded but health probes failed, rolled back to previous version")
+					recordError(result, fmt.Errorf("update succeeded but %w, rolled back to previous version", ErrProbeFailed))
 				}
 
 				result.Success = false
-				result.CompletedAt = time.Now()
+				timer.finish(result)
 
 				// Save failed result
 				if e.store != nil {
@@ -197,10 +338,18 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 
 	// Update successful
 	result.Success = true
-	result.CompletedAt = time.Now()
+	result.ResultCode = state.ResultSuccess
+	timer.finish(result)
+	if result.Platform == "" {
+		result.Platform = e.imagePlatform(ctx, service)
+	}
 
 	metrics.UpdatesTotal.WithLabelValues(target.Name, service.Name, "success").Inc()
 
+	e.recordConfigHash(ctx, target)
+	e.recordResources(ctx, target, service, usageBefore, result)
+	e.captureSBOM(ctx, result, service, newDigest)
+
 	e.logger.Info().
 		Str("service", service.Name).
 		Dur("duration", result.CompletedAt.Sub(result.StartedAt)).
@@ -212,10 +361,234 @@ func (e *Executor) ExecuteUpdate(ctx context.Context, target *state.Target, serv
 			e.logger.Warn().Err(err).Msg("Failed to save update result to store")
 		}
 	}
+	e.watchdog.Watch(target, service, result)
 
 	return result
 }
 
+// DescribeImage records which image an update result is about: its
+// repository, tag and registry, and the platform it was pinned to if any.
+func DescribeImage(result *state.UpdateResult, image, platform string) {
+	result.Platform = platform
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		result.Image = image
+		return
+	}
+	result.Image = ref.Repository
+	result.Tag = ref.Tag
+	result.Registry = ref.Registry
+}
+
+// captureSBOM attaches an SBOM for the applied registry digest to the result.
+// Failures are logged only; they never fail an update that already succeeded.
+func (e *Executor) captureSBOM(ctx context.Context, result *state.UpdateResult, service *state.Service, digest string) {
+	if e.sbom == nil || digest == "" {
+		return
+	}
+	captured, err := e.sbom.Generate(ctx, service.Image, digest, service.Platform)
+	if err != nil {
+		e.logger.Warn().Err(err).Str("service", service.Name).Msg("Failed to capture SBOM")
+		return
+	}
+	result.SBOM = captured
+}
+
+// imagePlatform reports the platform of the image the service now runs, for
+// services that do not pin one.
+func (e *Executor) imagePlatform(ctx context.Context, service *state.Service) string {
+	if e.dockerClient == nil || service.Image == "" {
+		return ""
+	}
+	inspect, err := e.dockerClient.ImageInspect(ctx, service.Image)
+	if err != nil {
+		return ""
+	}
+	return inspect.Platform
+}
+
+// recordConfigHash stores the compose config hash the update was validated
+// against, so later plans can flag edits made outside Bulwark.
+func (e *Executor) recordConfigHash(ctx context.Context, target *state.Target) {
+	if e.configHasher == nil || e.store == nil || target.Type != state.TargetTypeCompose {
+		return
+	}
+	hash, err := e.configHasher.ConfigHash(ctx, target.Path)
+	if err != nil {
+		e.logger.Warn().Err(err).Str("target", target.Name).Msg("Failed to hash compose config")
+		return
+	}
+	if err := e.store.SetSetting(ctx, state.ConfigHashSettingKey(target.ID), hash); err != nil {
+		e.logger.Warn().Err(err).Str("target", target.Name).Msg("Failed to record compose config hash")
+	}
+}
+
+// acquireLock takes the target lock according to the service's lock mode:
+// waiting up to its timeout (or the executor default), or skipping the update
+// right away when another update holds the target.
+func (e *Executor) acquireLock(ctx context.Context, target *state.Target, service *state.Service) error {
+	lock := service.Labels.Lock
+	if lock.Mode == state.LockModeSkip {
+		if !e.tryLockService(target, service) {
+			return NewCodedSkipError(state.ResultSkippedLocked, fmt.Sprintf("target %s is locked by another update", target.Name))
+		}
+		return nil
+	}
+
+	timeout := e.lockTimeout
+	if lock.Timeout > 0 {
+		timeout = lock.Timeout
+	}
+	if err := e.lockService(ctx, target, service, timeout); err != nil {
+		return newStepError(state.ResultLockTimeout, fmt.Errorf("failed to acquire lock: %w", err))
+	}
+	return nil
+}
+
+// parallelService reports whether service updates alongside its siblings:
+// a compose service with bulwark.project.parallel.
+func parallelService(target *state.Target, service *state.Service) bool {
+	return target.Type == state.TargetTypeCompose && service.Labels.Parallel
+}
+
+// serviceLockKey is the lock a parallel service holds of its own.
+func serviceLockKey(target *state.Target, service *state.Service) string {
+	return target.ID + "/" + service.Name
+}
+
+// lockService takes the locks an update of service holds. Most updates lock
+// their whole target. A parallel service shares the target's lock with its
+// parallel siblings and holds a lock of its own, so siblings update side by
+// side while anything that locks the whole target waits for all of them.
+func (e *Executor) lockService(ctx context.Context, target *state.Target, service *state.Service, timeout time.Duration) error {
+	if !parallelService(target, service) {
+		return e.lockManager.Lock(ctx, target.ID, timeout)
+	}
+	if err := e.lockManager.RLock(ctx, target.ID, timeout); err != nil {
+		return err
+	}
+	if err := e.lockManager.Lock(ctx, serviceLockKey(target, service), timeout); err != nil {
+		e.lockManager.RUnlock(target.ID)
+		return err
+	}
+	return nil
+}
+
+// tryLockService takes the locks of lockService only if they are free.
+func (e *Executor) tryLockService(target *state.Target, service *state.Service) bool {
+	if !parallelService(target, service) {
+		return e.lockManager.TryLock(target.ID)
+	}
+	if !e.lockManager.TryRLock(target.ID) {
+		return false
+	}
+	if !e.lockManager.TryLock(serviceLockKey(target, service)) {
+		e.lockManager.RUnlock(target.ID)
+		return false
+	}
+	return true
+}
+
+// unlockService releases the locks taken by lockService.
+func (e *Executor) unlockService(target *state.Target, service *state.Service) {
+	if !parallelService(target, service) {
+		e.lockManager.Unlock(target.ID)
+		return
+	}
+	e.lockManager.Unlock(serviceLockKey(target, service))
+	e.lockManager.RUnlock(target.ID)
+}
+
+// drainService takes the service out of proxy rotation before it is recreated.
+// Failures are logged but do not block the update.
+func (e *Executor) drainService(ctx context.Context, target *state.Target, service *state.Service) {
+	e.logger.Info().Str("service", service.Name).Msg("Draining service in reverse proxy")
+	if err := e.drainer.Drain(ctx, target, service); err != nil {
+		e.logger.Warn().Err(err).Str("service", service.Name).Msg("Failed to drain service, continuing with update")
+	}
+}
+
+// enableTimeout bounds putting a service back into proxy rotation.
+const enableTimeout = 30 * time.Second
+
+// enableService puts the service back into proxy rotation once the update has
+// settled. A service whose rollback failed is left drained so the proxy keeps
+// routing around it. It runs even when the update's context was cancelled, so
+// a cancelled or timed out update does not leave the service drained.
+func (e *Executor) enableService(ctx context.Context, target *state.Target, service *state.Service, result *state.UpdateResult) {
+	if result.ResultCode == state.ResultRollbackFailed {
+		e.logger.Warn().Str("service", service.Name).Msg("Leaving service drained after failed rollback")
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), enableTimeout)
+	defer cancel()
+	if err := e.drainer.Enable(ctx, target, service); err != nil {
+		e.logger.Error().Err(err).Str("service", service.Name).Msg("Failed to re-enable service in reverse proxy")
+	}
+}
+
+// updateWithRetry runs the update step, retrying transient failures per the
+// service's retry policy. A service that still fails keeps its update
+// pending, so the next run tries it again.
+func (e *Executor) updateWithRetry(ctx context.Context, target *state.Target, service *state.Service, result *state.UpdateResult) error {
+	return e.retry(ctx, service, result, func() error {
+		switch target.Type {
+		case state.TargetTypeCompose:
+			return e.composeExec.UpdateService(ctx, target, service)
+		case state.TargetTypeContainer:
+			return e.containerExec.UpdateService(ctx, target, service)
+		default:
+			return fmt.Errorf("unknown target type: %s", target.Type)
+		}
+	})
+}
+
+// retry runs step, retrying transient failures up to
+// service.Labels.Retry.Max times with exponential backoff, both bounded by
+// boundRetry. The number of attempts is recorded on result.
+func (e *Executor) retry(ctx context.Context, service *state.Service, result *state.UpdateResult, step func() error) error {
+	retry := boundRetry(service.Labels.Retry)
+	backoff := retry.Backoff
+
+	for {
+		result.Attempts++
+
+		err := step()
+		if !isTransient(err) || result.Attempts > retry.Max {
+			return err
+		}
+
+		e.logger.Warn().
+			Err(err).
+			Str("service", service.Name).
+			Int("attempt", result.Attempts).
+			Int("max_retries", retry.Max).
+			Dur("backoff", backoff).
+			Msg("Update attempt failed, retrying")
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w (retry aborted: %v)", err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+}
+
+// RollbackService rolls service back as ExecuteRollback does, holding the
+// target lock an update of the service would hold, so a rollback asked for
+// on its own does not overlap a running update.
+func (e *Executor) RollbackService(ctx context.Context, target *state.Target, service *state.Service, result *state.UpdateResult) error {
+	if err := e.lockService(ctx, target, service, e.lockTimeout); err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer e.unlockService(target, service)
+	return e.ExecuteRollback(ctx, target, service, result)
+}
+
 // ExecuteRollback rolls back a failed update
 func (e *Executor) ExecuteRollback(ctx context.Context, target *state.Target, service *state.Service, result *state.UpdateResult) error {
 	e.logger.Warn().
@@ -260,6 +633,44 @@ func (e *Executor) ExecuteRollback(ctx context.Context, target *state.Target, se
 	return nil
 }
 
+// RefreshDependent reacts to an update of a target the service depends on.
+// With restart set the container is restarted first; the service's probes
+// then run if any are configured. It returns the probe results, which are
+// empty when the service has no probes. The service's lock is held
+// throughout, so an update of the service cannot recreate the container
+// meanwhile.
+func (e *Executor) RefreshDependent(ctx context.Context, target *state.Target, service *state.Service, restart bool) ([]state.ProbeResult, error) {
+	if e.dryRun {
+		e.logger.Info().Str("service", service.Name).Bool("restart", restart).Msg("DRY RUN: Would refresh dependent service")
+		return nil, nil
+	}
+
+	if err := e.lockService(ctx, target, service, e.lockTimeout); err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer e.unlockService(target, service)
+
+	containerID, err := e.findContainerID(ctx, target, service)
+	if err != nil {
+		return nil, err
+	}
+
+	if restart {
+		e.logger.Info().
+			Str("target", target.Name).
+			Str("service", service.Name).
+			Msg("Restarting dependent service")
+		if err := e.dockerClient.ContainerRestart(ctx, containerID); err != nil {
+			return nil, err
+		}
+	}
+
+	if service.Labels.Probe.Type == state.ProbeTypeNone {
+		return nil, nil
+	}
+	return e.probeEngine.ExecuteProbes(ctx, target, service, containerID), nil
+}
+
 // findContainerID finds the container ID for a service
 func (e *Executor) findContainerID(ctx context.Context, target *state.Target, service *state.Service) (string, error) {
 	containers, err := e.dockerClient.ListContainers(ctx, false)
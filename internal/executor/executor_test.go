@@ -2,6 +2,8 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -13,12 +15,22 @@ type fakeComposeUpdater struct {
 	updateCalled    int
 	getDigestCalled int
 	rollbackCalled  int
+	pullCalled      int
 	updateErr       error
+	failFirst       int // when set, only the first failFirst calls return updateErr
 	digest          string
 }
 
+func (f *fakeComposeUpdater) PullImage(ctx context.Context, target *state.Target, service *state.Service) error {
+	f.pullCalled++
+	return nil
+}
+
 func (f *fakeComposeUpdater) UpdateService(ctx context.Context, target *state.Target, service *state.Service) error {
 	f.updateCalled++
+	if f.failFirst > 0 && f.updateCalled > f.failFirst {
+		return nil
+	}
 	return f.updateErr
 }
 
@@ -64,10 +76,44 @@ func (f *fakeLockManager) Lock(ctx context.Context, targetID string, timeout tim
 	return f.lockErr
 }
 
+func (f *fakeLockManager) TryLock(targetID string) bool {
+	f.lockCalled++
+	f.lastTargetID = targetID
+	return f.lockErr == nil
+}
+
 func (f *fakeLockManager) Unlock(targetID string) {
 	f.unlockCalled++
 }
 
+func (f *fakeLockManager) RLock(ctx context.Context, targetID string, timeout time.Duration) error {
+	return f.Lock(ctx, targetID, timeout)
+}
+
+func (f *fakeLockManager) TryRLock(targetID string) bool {
+	return f.TryLock(targetID)
+}
+
+func (f *fakeLockManager) RUnlock(targetID string) {
+	f.Unlock(targetID)
+}
+
+type fakeDrainer struct {
+	calls     []string
+	enableCtx error // ctx.Err() when Enable was called
+}
+
+func (f *fakeDrainer) Drain(ctx context.Context, target *state.Target, service *state.Service) error {
+	f.calls = append(f.calls, "drain")
+	return nil
+}
+
+func (f *fakeDrainer) Enable(ctx context.Context, target *state.Target, service *state.Service) error {
+	f.calls = append(f.calls, "enable")
+	f.enableCtx = ctx.Err()
+	return nil
+}
+
 func TestExecutorUsesComposeUpdaterForComposeTargets(t *testing.T) {
 	compose := &fakeComposeUpdater{}
 	container := &fakeContainerUpdater{}
@@ -98,7 +144,7 @@ func TestExecutorUsesComposeUpdaterForComposeTargets(t *testing.T) {
 	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
 
 	if !result.Success {
-		t.Fatalf("expected success, got error %v", result.Error)
+		t.Fatalf("expected success, got error %s", result.ErrorMessage)
 	}
 	if compose.updateCalled != 1 {
 		t.Fatalf("expected compose updater called once, got %d", compose.updateCalled)
@@ -144,7 +190,7 @@ func TestExecutorUsesContainerUpdaterForContainerTargets(t *testing.T) {
 	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
 
 	if !result.Success {
-		t.Fatalf("expected success, got error %v", result.Error)
+		t.Fatalf("expected success, got error %s", result.ErrorMessage)
 	}
 	if container.updateCalled != 1 {
 		t.Fatalf("expected container updater called once, got %d", container.updateCalled)
@@ -159,3 +205,337 @@ func TestExecutorUsesContainerUpdaterForContainerTargets(t *testing.T) {
 		t.Fatalf("expected GetNewDigest not called, got %d", compose.getDigestCalled)
 	}
 }
+
+func TestExecutorRetriesFailedUpdate(t *testing.T) {
+	compose := &fakeComposeUpdater{updateErr: errors.New("pull timeout"), failFirst: 2}
+	exec := &Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		lockManager:   &fakeLockManager{},
+		logger:        logging.Default(),
+	}
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Retry = state.RetryConfig{Max: 2, Backoff: time.Millisecond}
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if !result.Success {
+		t.Fatalf("expected success after retries, got error %s", result.ErrorMessage)
+	}
+	if result.Attempts != 3 || compose.updateCalled != 3 {
+		t.Fatalf("expected 3 attempts, got attempts=%d calls=%d", result.Attempts, compose.updateCalled)
+	}
+}
+
+func TestExecutorStopsAfterRetryLimit(t *testing.T) {
+	compose := &fakeComposeUpdater{updateErr: errors.New("pull timeout")}
+	exec := &Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		lockManager:   &fakeLockManager{},
+		logger:        logging.Default(),
+	}
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Retry = state.RetryConfig{Max: 1, Backoff: time.Millisecond}
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if result.Attempts != 2 || compose.updateCalled != 2 {
+		t.Fatalf("expected 2 attempts, got attempts=%d calls=%d", result.Attempts, compose.updateCalled)
+	}
+}
+
+func TestExecutorDoesNotRetryPermanentFailure(t *testing.T) {
+	compose := &fakeComposeUpdater{updateErr: fmt.Errorf("%w: open /stacks/app/compose.yaml: no such file or directory", ErrInvalidCompose)}
+	exec := &Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		lockManager:   &fakeLockManager{},
+		logger:        logging.Default(),
+	}
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Retry = state.RetryConfig{Max: 3, Backoff: time.Millisecond}
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if result.Success {
+		t.Fatal("expected failure")
+	}
+	if result.Attempts != 1 || compose.updateCalled != 1 {
+		t.Fatalf("expected a single attempt, got attempts=%d calls=%d", result.Attempts, compose.updateCalled)
+	}
+}
+
+func TestBoundRetry(t *testing.T) {
+	got := boundRetry(state.RetryConfig{Max: 1000, Backoff: 24 * time.Hour})
+	if got.Max != maxRetries || got.Backoff != maxRetryBackoff {
+		t.Errorf("expected retries bounded to %d and %v, got %+v", maxRetries, maxRetryBackoff, got)
+	}
+	if got := boundRetry(state.RetryConfig{Max: 2, Backoff: time.Second}); got.Max != 2 || got.Backoff != time.Second {
+		t.Errorf("expected a modest policy to be kept, got %+v", got)
+	}
+}
+
+func TestExecutorPrePull(t *testing.T) {
+	compose := &fakeComposeUpdater{}
+	exec := &Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		lockManager:   &fakeLockManager{},
+		logger:        logging.Default(),
+	}
+	service := &state.Service{ID: "svc-1", Name: "web", Labels: state.DefaultLabels()}
+
+	if err := exec.PrePull(context.Background(), &state.Target{Type: state.TargetTypeCompose}, service); err != nil {
+		t.Fatalf("compose pre-pull: %v", err)
+	}
+	if compose.pullCalled != 1 {
+		t.Fatalf("expected compose image pulled once, got %d", compose.pullCalled)
+	}
+
+	// The fake container updater cannot pull ahead, so the update pulls instead.
+	if err := exec.PrePull(context.Background(), &state.Target{Type: state.TargetTypeContainer}, service); err != nil {
+		t.Fatalf("container pre-pull: %v", err)
+	}
+	if compose.pullCalled != 1 {
+		t.Fatalf("expected no extra pull, got %d", compose.pullCalled)
+	}
+}
+
+func TestExecutorSkipsLockedTargetInSkipMode(t *testing.T) {
+	compose := &fakeComposeUpdater{}
+	locks := &fakeLockManager{lockErr: errors.New("busy")}
+	exec := &Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		lockManager:   locks,
+		logger:        logging.Default(),
+	}
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Lock.Mode = state.LockModeSkip
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if result.ResultCode != state.ResultSkippedLocked || result.ErrorCode != state.ResultSkippedLocked {
+		t.Fatalf("expected skipped_locked, got %s (%s)", result.ResultCode, result.ErrorMessage)
+	}
+	if compose.updateCalled != 0 || locks.unlockCalled != 0 {
+		t.Fatalf("expected no update or unlock, got update=%d unlock=%d", compose.updateCalled, locks.unlockCalled)
+	}
+}
+
+func TestLockManagerReleasesAbandonedWait(t *testing.T) {
+	locks := NewLockManager(logging.Default())
+	ctx := context.Background()
+
+	if err := locks.Lock(ctx, "t1", time.Second); err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+	if locks.TryLock("t1") {
+		t.Fatal("expected TryLock to fail while held")
+	}
+	if err := locks.Lock(ctx, "t1", 10*time.Millisecond); err == nil {
+		t.Fatal("expected lock timeout")
+	}
+
+	locks.Unlock("t1")
+	// The timed-out waiter acquires and releases the lock in the background.
+	if err := locks.Lock(ctx, "t1", time.Second); err != nil {
+		t.Fatalf("expected lock after abandoned wait released, got %v", err)
+	}
+	locks.Unlock("t1")
+}
+
+// blockingComposeUpdater holds an update open until release is closed.
+type blockingComposeUpdater struct {
+	fakeComposeUpdater
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *blockingComposeUpdater) UpdateService(ctx context.Context, target *state.Target, service *state.Service) error {
+	close(f.started)
+	<-f.release
+	return f.fakeComposeUpdater.UpdateService(ctx, target, service)
+}
+
+func TestExecutorsShareLockManager(t *testing.T) {
+	locks := NewLockManager(logging.Default())
+	first := &blockingComposeUpdater{started: make(chan struct{}), release: make(chan struct{})}
+	second := &fakeComposeUpdater{}
+	newExec := func(compose composeUpdater) *Executor {
+		exec := &Executor{
+			composeExec:   compose,
+			containerExec: &fakeContainerUpdater{},
+			lockManager:   NewLockManager(logging.Default()),
+			logger:        logging.Default(),
+			lockTimeout:   time.Second,
+		}
+		return exec.WithLockManager(locks)
+	}
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+
+	done := make(chan *state.UpdateResult)
+	go func() { done <- newExec(first).ExecuteUpdate(context.Background(), target, service, "sha256:new") }()
+	<-first.started
+
+	skipping := *service
+	skipping.Labels.Lock.Mode = state.LockModeSkip
+	result := newExec(second).ExecuteUpdate(context.Background(), target, &skipping, "sha256:new")
+	if result.ResultCode != state.ResultSkippedLocked {
+		t.Fatalf("expected second executor to find the target locked, got %s (%s)", result.ResultCode, result.ErrorMessage)
+	}
+	if second.updateCalled != 0 {
+		t.Fatalf("expected no update by the second executor, got %d", second.updateCalled)
+	}
+
+	close(first.release)
+	if result := <-done; !result.Success {
+		t.Fatalf("expected first update to succeed, got %s (%s)", result.ResultCode, result.ErrorMessage)
+	}
+	if !locks.TryLock(target.ID) {
+		t.Fatal("expected the shared lock to be released after the update")
+	}
+	locks.Unlock(target.ID)
+}
+
+func TestRollbackServiceWaitsForLock(t *testing.T) {
+	locks := NewLockManager(logging.Default())
+	compose := &fakeComposeUpdater{}
+	exec := (&Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		logger:        logging.Default(),
+		lockTimeout:   10 * time.Millisecond,
+	}).WithLockManager(locks)
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", Labels: state.DefaultLabels()}
+	result := &state.UpdateResult{OldDigest: "sha256:old"}
+
+	if err := locks.Lock(context.Background(), target.ID, time.Second); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if err := exec.RollbackService(context.Background(), target, service, result); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected lock timeout while an update holds the target, got %v", err)
+	}
+	if compose.rollbackCalled != 0 {
+		t.Fatalf("expected no rollback while locked, got %d", compose.rollbackCalled)
+	}
+
+	locks.Unlock(target.ID)
+	exec.lockTimeout = time.Second
+	if err := exec.RollbackService(context.Background(), target, service, result); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if compose.rollbackCalled != 1 || !result.RollbackPerformed {
+		t.Fatalf("expected one rollback, got %d (performed %v)", compose.rollbackCalled, result.RollbackPerformed)
+	}
+	if !locks.TryLock(target.ID) {
+		t.Fatal("expected the lock to be released after the rollback")
+	}
+	locks.Unlock(target.ID)
+}
+
+func TestParallelServicesExcludeWholeTarget(t *testing.T) {
+	exec := &Executor{lockManager: NewLockManager(logging.Default()), logger: logging.Default()}
+	ctx := context.Background()
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	web := &state.Service{Name: "web", Labels: state.DefaultLabels()}
+	worker := &state.Service{Name: "worker", Labels: state.DefaultLabels()}
+	web.Labels.Parallel, worker.Labels.Parallel = true, true
+	db := &state.Service{Name: "db", Labels: state.DefaultLabels()}
+
+	if err := exec.lockService(ctx, target, web, time.Second); err != nil {
+		t.Fatalf("lock web: %v", err)
+	}
+	if !exec.tryLockService(target, worker) {
+		t.Fatal("expected parallel siblings to lock side by side")
+	}
+	if exec.tryLockService(target, web) {
+		t.Fatal("expected a second update of web to wait")
+	}
+	if exec.tryLockService(target, db) {
+		t.Fatal("expected a whole-target update to wait for parallel services")
+	}
+
+	exec.unlockService(target, web)
+	exec.unlockService(target, worker)
+	if !exec.tryLockService(target, db) {
+		t.Fatal("expected the whole-target lock once parallel services finished")
+	}
+	if exec.tryLockService(target, web) {
+		t.Fatal("expected a parallel service to wait for a whole-target update")
+	}
+	exec.unlockService(target, db)
+}
+
+func TestExecutorDrainsAroundUpdate(t *testing.T) {
+	drainer := &fakeDrainer{}
+	exec := &Executor{
+		composeExec:   &fakeComposeUpdater{},
+		containerExec: &fakeContainerUpdater{},
+		lockManager:   &fakeLockManager{},
+		drainer:       drainer,
+		logger:        logging.Default(),
+	}
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+
+	exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+	if len(drainer.calls) != 0 {
+		t.Fatalf("expected no drain without a drain url, got %v", drainer.calls)
+	}
+
+	service.Labels.Drain.URL = "http://proxy/drain"
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+	if !result.Success {
+		t.Fatalf("expected success, got error %s", result.ErrorMessage)
+	}
+	if len(drainer.calls) != 2 || drainer.calls[0] != "drain" || drainer.calls[1] != "enable" {
+		t.Fatalf("expected drain then enable, got %v", drainer.calls)
+	}
+}
+
+func TestEnableServiceOutlivesCancelledUpdate(t *testing.T) {
+	drainer := &fakeDrainer{}
+	exec := &Executor{drainer: drainer, logger: logging.Default()}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	service := &state.Service{ID: "svc-1", Name: "web", Labels: state.DefaultLabels()}
+	exec.enableService(ctx, &state.Target{Name: "app"}, service, &state.UpdateResult{})
+	if len(drainer.calls) != 1 || drainer.enableCtx != nil {
+		t.Fatalf("expected the service re-enabled with a live context, got calls=%v err=%v", drainer.calls, drainer.enableCtx)
+	}
+}
+
+func TestRefreshDependentNeedsLock(t *testing.T) {
+	locks := &fakeLockManager{lockErr: ErrLockTimeout}
+	exec := &Executor{lockManager: locks, logger: logging.Default()}
+
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "worker", Labels: state.DefaultLabels()}
+
+	if _, err := exec.RefreshDependent(context.Background(), target, service, true); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected the lock timeout, got %v", err)
+	}
+	if locks.lastTargetID != "compose-1" || locks.unlockCalled != 0 {
+		t.Errorf("expected only a lock attempt on the target, got %+v", locks)
+	}
+}
@@ -18,7 +18,37 @@ type containerUpdater interface {
 	Rollback(ctx context.Context, target *state.Target, service *state.Service, digest string) error
 }
 
+type imagePuller interface {
+	PullImage(ctx context.Context, target *state.Target, service *state.Service) error
+}
+
+type projectResolver interface {
+	ProjectName(ctx context.Context, composePath string) string
+}
+
+type blueGreenUpdater interface {
+	StartParallel(ctx context.Context, target *state.Target, service *state.Service) ([]string, string, error)
+	Retire(ctx context.Context, containerIDs []string, stopTimeout time.Duration) error
+}
+
 type lockManager interface {
 	Lock(ctx context.Context, targetID string, timeout time.Duration) error
+	RLock(ctx context.Context, targetID string, timeout time.Duration) error
+	TryLock(targetID string) bool
+	TryRLock(targetID string) bool
 	Unlock(targetID string)
+	RUnlock(targetID string)
+}
+
+type configHasher interface {
+	ConfigHash(ctx context.Context, composePath string) (string, error)
+}
+
+type sbomGenerator interface {
+	Generate(ctx context.Context, image, digest, platform string) (*state.SBOM, error)
+}
+
+type proxyDrainer interface {
+	Drain(ctx context.Context, target *state.Target, service *state.Service) error
+	Enable(ctx context.Context, target *state.Target, service *state.Service) error
 }
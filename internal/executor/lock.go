@@ -2,6 +2,7 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -9,9 +10,15 @@ import (
 	"github.com/itsmrshow/bulwark/internal/logging"
 )
 
-// LockManager manages per-target locks to prevent concurrent updates
+// ErrLockTimeout is returned when another update holds a target's lock for
+// longer than the caller is willing to wait.
+var ErrLockTimeout = errors.New("timeout waiting for lock")
+
+// LockManager manages per-target locks to prevent concurrent updates. A lock
+// is held either exclusively, or shared by updates that may run side by side
+// but must not overlap an exclusive holder.
 type LockManager struct {
-	locks  sync.Map // map[string]*sync.Mutex
+	locks  sync.Map // map[string]*sync.RWMutex
 	logger *logging.Logger
 }
 
@@ -22,21 +29,35 @@ func NewLockManager(logger *logging.Logger) *LockManager {
 	}
 }
 
-// Lock acquires a lock for the given target ID with timeout
+func (lm *LockManager) mutex(targetID string) *sync.RWMutex {
+	mutexInterface, _ := lm.locks.LoadOrStore(targetID, &sync.RWMutex{})
+	return mutexInterface.(*sync.RWMutex)
+}
+
+// Lock acquires the exclusive lock for the given target ID with timeout
 func (lm *LockManager) Lock(ctx context.Context, targetID string, timeout time.Duration) error {
+	mutex := lm.mutex(targetID)
+	return lm.wait(ctx, targetID, timeout, mutex.Lock, mutex.Unlock)
+}
+
+// RLock acquires a shared hold of the lock for the given target ID with
+// timeout. It waits for an exclusive holder, and keeps Lock waiting until it
+// is released with RUnlock.
+func (lm *LockManager) RLock(ctx context.Context, targetID string, timeout time.Duration) error {
+	mutex := lm.mutex(targetID)
+	return lm.wait(ctx, targetID, timeout, mutex.RLock, mutex.RUnlock)
+}
+
+func (lm *LockManager) wait(ctx context.Context, targetID string, timeout time.Duration, lock, unlock func()) error {
 	lm.logger.Debug().
 		Str("target_id", targetID).
 		Dur("timeout", timeout).
 		Msg("Attempting to acquire lock")
 
-	// Get or create mutex for this target
-	mutexInterface, _ := lm.locks.LoadOrStore(targetID, &sync.Mutex{})
-	mutex := mutexInterface.(*sync.Mutex)
-
 	// Try to acquire lock with timeout
 	lockAcquired := make(chan struct{})
 	go func() {
-		mutex.Lock()
+		lock()
 		close(lockAcquired)
 	}()
 
@@ -48,31 +69,72 @@ func (lm *LockManager) Lock(ctx context.Context, targetID string, timeout time.D
 			Msg("Lock acquired")
 		return nil
 	case <-ctx.Done():
+		releaseWhenAcquired(unlock, lockAcquired)
 		return fmt.Errorf("context canceled while waiting for lock: %w", ctx.Err())
 	case <-time.After(timeout):
-		return fmt.Errorf("timeout waiting for lock on target %s", targetID)
+		releaseWhenAcquired(unlock, lockAcquired)
+		return fmt.Errorf("%w on target %s", ErrLockTimeout, targetID)
 	}
 }
 
-// Unlock releases the lock for the given target ID
+// releaseWhenAcquired releases the lock once an abandoned Lock attempt gets
+// it, so a caller that gave up does not hold the target forever.
+func releaseWhenAcquired(unlock func(), acquired <-chan struct{}) {
+	go func() {
+		<-acquired
+		unlock()
+	}()
+}
+
+// TryLock acquires the exclusive lock for the given target ID only if it is free.
+func (lm *LockManager) TryLock(targetID string) bool {
+	return lm.try(targetID, lm.mutex(targetID).TryLock)
+}
+
+// TryRLock acquires a shared hold of the lock for the given target ID only if
+// no one holds it exclusively.
+func (lm *LockManager) TryRLock(targetID string) bool {
+	return lm.try(targetID, lm.mutex(targetID).TryRLock)
+}
+
+func (lm *LockManager) try(targetID string, tryLock func() bool) bool {
+	if !tryLock() {
+		lm.logger.Debug().
+			Str("target_id", targetID).
+			Msg("Lock busy")
+		return false
+	}
+	return true
+}
+
+// Unlock releases the exclusive lock for the given target ID
 func (lm *LockManager) Unlock(targetID string) {
+	if mutex, ok := lm.existing(targetID); ok {
+		mutex.Unlock()
+		lm.logger.Debug().Str("target_id", targetID).Msg("Lock released")
+	}
+}
+
+// RUnlock releases a shared hold of the lock for the given target ID
+func (lm *LockManager) RUnlock(targetID string) {
+	if mutex, ok := lm.existing(targetID); ok {
+		mutex.RUnlock()
+		lm.logger.Debug().Str("target_id", targetID).Msg("Shared lock released")
+	}
+}
+
+func (lm *LockManager) existing(targetID string) (*sync.RWMutex, bool) {
 	mutexInterface, ok := lm.locks.Load(targetID)
 	if !ok {
 		lm.logger.Warn().
 			Str("target_id", targetID).
 			Msg("Attempted to unlock non-existent lock")
-		return
+		return nil, false
 	}
-
-	mutex := mutexInterface.(*sync.Mutex)
-	mutex.Unlock()
-
-	lm.logger.Debug().
-		Str("target_id", targetID).
-		Msg("Lock released")
+	return mutexInterface.(*sync.RWMutex), true
 }
 
-// WithLock executes a function while holding the lock for a target
+// WithLock executes a function while holding the exclusive lock for a target
 func (lm *LockManager) WithLock(ctx context.Context, targetID string, timeout time.Duration, fn func() error) error {
 	if err := lm.Lock(ctx, targetID, timeout); err != nil {
 		return err
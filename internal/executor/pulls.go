@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// PullScheduler spaces out image pulls so updates don't saturate a small
+// uplink. It caps how many pulls run at once, holds each pull back until a
+// delay has passed since the previous one finished, and runs big images one
+// at a time. One scheduler is shared by every executor of a process.
+type PullScheduler struct {
+	slots   chan struct{} // nil when the number of pulls is not capped
+	big     chan struct{}
+	delay   time.Duration
+	bigSize int64
+	sizer   imageSizer
+
+	// gate holds the end of the last pull; taking it serializes the delay
+	// check, so two waiting pulls don't both start when it expires.
+	gate chan time.Time
+}
+
+// NewPullScheduler allows maxConcurrent pulls at once (unlimited below 1) and
+// waits delay after each pull before starting the next. When sizer is set,
+// images whose compressed size is at least bigSize bytes pull one at a time.
+func NewPullScheduler(maxConcurrent int, delay time.Duration, sizer imageSizer, bigSize int64) *PullScheduler {
+	p := &PullScheduler{
+		big:     make(chan struct{}, 1),
+		delay:   delay,
+		bigSize: bigSize,
+		sizer:   sizer,
+		gate:    make(chan time.Time, 1),
+	}
+	if maxConcurrent > 0 {
+		p.slots = make(chan struct{}, maxConcurrent)
+	}
+	p.gate <- time.Time{}
+	return p
+}
+
+// acquire waits until the service's image may be pulled. The returned release
+// must be called once the pull has finished.
+func (p *PullScheduler) acquire(ctx context.Context, service *state.Service) (func(), error) {
+	if p == nil {
+		return func() {}, nil
+	}
+
+	var held []chan struct{}
+	releaseHeld := func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			<-held[i]
+		}
+	}
+	take := func(ch chan struct{}) error {
+		select {
+		case ch <- struct{}{}:
+			held = append(held, ch)
+			return nil
+		case <-ctx.Done():
+			releaseHeld()
+			return ctx.Err()
+		}
+	}
+
+	if p.isBig(ctx, service) {
+		if err := take(p.big); err != nil {
+			return nil, err
+		}
+	}
+	if p.slots != nil {
+		if err := take(p.slots); err != nil {
+			return nil, err
+		}
+	}
+
+	var lastEnd time.Time
+	select {
+	case lastEnd = <-p.gate:
+	case <-ctx.Done():
+		releaseHeld()
+		return nil, ctx.Err()
+	}
+	if wait := time.Until(lastEnd.Add(p.delay)); p.delay > 0 && wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			p.gate <- lastEnd
+			releaseHeld()
+			return nil, ctx.Err()
+		}
+	}
+	p.gate <- lastEnd
+
+	return func() {
+		end := <-p.gate
+		if now := time.Now(); now.After(end) {
+			end = now
+		}
+		p.gate <- end
+		releaseHeld()
+	}, nil
+}
+
+// isBig reports whether the service's image is large enough to pull on its
+// own. An image whose size the registry does not report is not.
+func (p *PullScheduler) isBig(ctx context.Context, service *state.Service) bool {
+	if p.sizer == nil || p.bigSize <= 0 {
+		return false
+	}
+	size, err := p.sizer.FetchImageSize(ctx, service.Image, service.Platform)
+	return err == nil && size >= p.bigSize
+}
@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// runPulls pulls each service at once through p and returns the most pulls
+// that were running at the same time.
+func runPulls(t *testing.T, p *PullScheduler, services ...*state.Service) int32 {
+	t.Helper()
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for _, service := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := p.acquire(context.Background(), service)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			n := running.Add(1)
+			for {
+				old := peak.Load()
+				if n <= old || peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			running.Add(-1)
+			release()
+		}()
+	}
+	wg.Wait()
+	return peak.Load()
+}
+
+func TestPullScheduler_CapsConcurrency(t *testing.T) {
+	p := NewPullScheduler(2, 0, nil, 0)
+	services := []*state.Service{{Image: "a"}, {Image: "b"}, {Image: "c"}, {Image: "d"}}
+	if peak := runPulls(t, p, services...); peak > 2 {
+		t.Errorf("%d pulls ran at once, want at most 2", peak)
+	}
+}
+
+func TestPullScheduler_BigImagesPullAlone(t *testing.T) {
+	p := NewPullScheduler(0, 0, fakeSizer{size: 2 << 30}, 1<<30)
+	services := []*state.Service{{Image: "a"}, {Image: "b"}, {Image: "c"}}
+	if peak := runPulls(t, p, services...); peak != 1 {
+		t.Errorf("%d big pulls ran at once, want 1", peak)
+	}
+}
+
+func TestPullScheduler_WaitsBetweenPulls(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	p := NewPullScheduler(1, delay, nil, 0)
+	service := &state.Service{Image: "a"}
+
+	release, err := p.acquire(context.Background(), service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	finished := time.Now()
+	release, err = p.acquire(context.Background(), service)
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if waited := time.Since(finished); waited < delay {
+		t.Errorf("next pull started after %v, want at least %v", waited, delay)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.acquire(ctx, service); err == nil {
+		t.Error("acquire with a cancelled context succeeded")
+	}
+}
+
+func TestPullScheduler_Nil(t *testing.T) {
+	var p *PullScheduler
+	release, err := p.acquire(context.Background(), &state.Service{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+}
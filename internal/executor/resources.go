@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// DefaultMemoryJumpPercent is how much more memory a new version may use
+// once its probes pass before the update is flagged.
+const DefaultMemoryJumpPercent = 50
+
+// resourceUsage samples the CPU and memory use of the container a service
+// runs in, or returns nil when it cannot be sampled.
+func (e *Executor) resourceUsage(ctx context.Context, target *state.Target, service *state.Service) *state.ResourceUsage {
+	if !e.resourceSnapshots || e.dockerClient == nil {
+		return nil
+	}
+	containerID, err := e.findContainerID(ctx, target, service)
+	if err != nil {
+		e.logger.Debug().Err(err).Str("service", service.Name).Msg("No container to sample resource usage of")
+		return nil
+	}
+	stats, err := e.dockerClient.ContainerStats(ctx, containerID)
+	if err != nil {
+		e.logger.Debug().Err(err).Str("service", service.Name).Msg("Failed to sample resource usage")
+		return nil
+	}
+	return &state.ResourceUsage{
+		CPUPercent:  stats.CPUPercent,
+		MemoryBytes: stats.MemoryBytes,
+		MemoryLimit: stats.MemoryLimit,
+	}
+}
+
+// recordResources samples the updated service's resource use and attaches
+// it to result with the use before the update, warning when the new version
+// needs noticeably more memory: a regression its probes would not catch.
+func (e *Executor) recordResources(ctx context.Context, target *state.Target, service *state.Service, before *state.ResourceUsage, result *state.UpdateResult) {
+	if before == nil {
+		return
+	}
+	after := e.resourceUsage(ctx, target, service)
+	if after == nil {
+		return
+	}
+	result.Resources = compareResources(before, after, e.memoryJumpPercent)
+	if result.Resources.MemoryWarning {
+		e.logger.Warn().
+			Str("service", service.Name).
+			Uint64("memory_before", before.MemoryBytes).
+			Uint64("memory_after", after.MemoryBytes).
+			Float64("change_percent", result.Resources.MemoryChangePercent).
+			Msg("New version uses noticeably more memory")
+	}
+}
+
+// compareResources pairs two samples and flags a memory increase of more
+// than jumpPercent; zero or less never flags.
+func compareResources(before, after *state.ResourceUsage, jumpPercent float64) *state.ResourceSnapshot {
+	snapshot := &state.ResourceSnapshot{Before: before, After: after}
+	if before.MemoryBytes == 0 {
+		return snapshot
+	}
+	snapshot.MemoryChangePercent = (float64(after.MemoryBytes) - float64(before.MemoryBytes)) / float64(before.MemoryBytes) * 100
+	snapshot.MemoryWarning = jumpPercent > 0 && snapshot.MemoryChangePercent > jumpPercent
+	return snapshot
+}
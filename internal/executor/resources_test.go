@@ -0,0 +1,37 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestCompareResources(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      uint64
+		after       uint64
+		threshold   float64
+		wantChange  float64
+		wantWarning bool
+	}{
+		{"jump beyond threshold", 100 << 20, 180 << 20, 50, 80, true},
+		{"jump within threshold", 100 << 20, 140 << 20, 50, 40, false},
+		{"less memory", 200 << 20, 100 << 20, 50, -50, false},
+		{"threshold off", 100 << 20, 400 << 20, 0, 300, false},
+		{"no baseline", 0, 100 << 20, 50, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := &state.ResourceUsage{MemoryBytes: tt.before}
+			after := &state.ResourceUsage{MemoryBytes: tt.after}
+			got := compareResources(before, after, tt.threshold)
+			if got.Before != before || got.After != after {
+				t.Errorf("expected both samples to be kept, got %+v", got)
+			}
+			if got.MemoryChangePercent != tt.wantChange || got.MemoryWarning != tt.wantWarning {
+				t.Errorf("got change %v warning %v, want %v %v", got.MemoryChangePercent, got.MemoryWarning, tt.wantChange, tt.wantWarning)
+			}
+		})
+	}
+}
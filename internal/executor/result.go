@@ -0,0 +1,72 @@
+package executor
+
+import (
+	"errors"
+
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// ErrProbeFailed is wrapped by update errors caused by failing health probes,
+// whether or not the rollback that followed succeeded.
+var ErrProbeFailed = errors.New("health probes failed")
+
+// ErrInvalidCompose is wrapped by update errors caused by a compose file that
+// is missing, that compose rejects or that no longer defines the service. A
+// retry cannot fix it.
+var ErrInvalidCompose = errors.New("invalid compose definition")
+
+// StepError tags an update failure with the result code of the step that failed.
+type StepError struct {
+	Code state.ResultCode
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+func newStepError(code state.ResultCode, err error) error {
+	return &StepError{Code: code, Err: err}
+}
+
+// recordError stores err on result and classifies the result by it.
+func recordError(result *state.UpdateResult, err error) {
+	result.ResultCode = ResultCodeFor(err)
+	result.SetError(err, result.ResultCode)
+}
+
+// ResultCodeFor classifies an update error. A nil error is a success and
+// unclassified errors map to ResultUpdateFailed.
+func ResultCodeFor(err error) state.ResultCode {
+	if err == nil {
+		return state.ResultSuccess
+	}
+
+	var skip *SkipError
+	if errors.As(err, &skip) {
+		if skip.Code == "" {
+			return state.ResultSkipped
+		}
+		return skip.Code
+	}
+
+	var step *StepError
+	if errors.As(err, &step) {
+		return step.Code
+	}
+
+	switch {
+	case errors.Is(err, ErrLockTimeout):
+		return state.ResultLockTimeout
+	case errors.Is(err, ErrProbeFailed):
+		return state.ResultProbeFailed
+	case errors.Is(err, policy.ErrPolicyBlocked):
+		return state.ResultPolicyBlocked
+	}
+	return state.ResultUpdateFailed
+}
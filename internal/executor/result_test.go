@@ -0,0 +1,56 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestResultCodeFor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want state.ResultCode
+	}{
+		{"nil", nil, state.ResultSuccess},
+		{"generic skip", NewSkipError("missing target"), state.ResultSkipped},
+		{"self update", NewCodedSkipError(state.ResultSkippedSelfUpdate, "self"), state.ResultSkippedSelfUpdate},
+		{"pull", newStepError(state.ResultPullFailed, errors.New("timeout")), state.ResultPullFailed},
+		{"wrapped step", fmt.Errorf("retry aborted: %w", newStepError(state.ResultRecreateFailed, errors.New("boom"))), state.ResultRecreateFailed},
+		{"lock timeout", fmt.Errorf("%w on target web", ErrLockTimeout), state.ResultLockTimeout},
+		{"probe failed", fmt.Errorf("update succeeded but %w", ErrProbeFailed), state.ResultProbeFailed},
+		{"probe and rollback failed", newStepError(state.ResultRollbackFailed, fmt.Errorf("%w: %w", ErrProbeFailed, errors.New("boom"))), state.ResultRollbackFailed},
+		{"policy blocked", policy.Blocked("Policy is 'notify'"), state.ResultPolicyBlocked},
+		{"unknown", errors.New("boom"), state.ResultUpdateFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResultCodeFor(tt.err); got != tt.want {
+				t.Fatalf("ResultCodeFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDescribeImage(t *testing.T) {
+	tests := []struct {
+		image, platform             string
+		wantImage, wantTag, wantReg string
+	}{
+		{"nginx:1.27", "", "library/nginx", "1.27", "docker.io"},
+		{"ghcr.io/acme/api@sha256:abc", "linux/arm64", "acme/api", "", "ghcr.io"},
+		{"sha256:abc", "", "sha256:abc", "", ""},
+	}
+
+	for _, tt := range tests {
+		result := &state.UpdateResult{}
+		DescribeImage(result, tt.image, tt.platform)
+		if result.Image != tt.wantImage || result.Tag != tt.wantTag || result.Registry != tt.wantReg || result.Platform != tt.platform {
+			t.Fatalf("DescribeImage(%q) = %+v", tt.image, result)
+		}
+	}
+}
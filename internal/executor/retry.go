@@ -0,0 +1,47 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// Bounds on a service's retry policy, whatever its labels ask for, so a
+// failing service cannot hold its run for hours.
+const (
+	maxRetries      = 10
+	maxRetryBackoff = 5 * time.Minute
+)
+
+// boundRetry clamps retry to maxRetries and maxRetryBackoff.
+func boundRetry(retry state.RetryConfig) state.RetryConfig {
+	if retry.Max > maxRetries {
+		retry.Max = maxRetries
+	}
+	if retry.Max < 0 {
+		retry.Max = 0
+	}
+	if retry.Backoff > maxRetryBackoff {
+		retry.Backoff = maxRetryBackoff
+	}
+	return retry
+}
+
+// isTransient reports whether retrying the update step that failed with err
+// may succeed. Skips, cancelled runs, an invalid compose definition and
+// failures a retry does not change, such as failed probes, are not retried.
+func isTransient(err error) bool {
+	if err == nil || IsSkipError(err) {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrInvalidCompose) {
+		return false
+	}
+	switch ResultCodeFor(err) {
+	case state.ResultLockTimeout, state.ResultProbeFailed, state.ResultRollbackFailed:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// Where a rollback takes its image from.
+const (
+	RollbackSourceLocal    = "local"
+	RollbackSourceRegistry = "registry"
+)
+
+// ManifestChecker tells whether a registry still serves a digest, e.g. a
+// registry.Client.
+type ManifestChecker interface {
+	ManifestExists(ctx context.Context, image, digest string) (bool, error)
+}
+
+type imageChecker interface {
+	HasImage(ctx context.Context, ref string) (bool, error)
+}
+
+// RollbackCheck is one precondition of a rollback.
+type RollbackCheck struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message"`
+}
+
+// RollbackReport is what VerifyRollback found: whether a rollback of the
+// service can go ahead and the steps it would take.
+type RollbackReport struct {
+	Target         string          `json:"target"`
+	Service        string          `json:"service"`
+	CurrentDigest  string          `json:"current_digest,omitempty"`
+	RollbackDigest string          `json:"rollback_digest,omitempty"`
+	Image          string          `json:"image,omitempty"`
+	Source         string          `json:"source,omitempty"`
+	Ready          bool            `json:"ready"`
+	Checks         []RollbackCheck `json:"checks"`
+	Steps          []string        `json:"steps,omitempty"`
+}
+
+// Check records a precondition; the rollback is ready only while every
+// check passes.
+func (r *RollbackReport) Check(name string, ok bool, message string) {
+	r.Checks = append(r.Checks, RollbackCheck{Name: name, OK: ok, Message: message})
+	r.Ready = r.Ready && ok
+}
+
+// LastUpdate returns the update a rollback of the service undoes: its most
+// recent update that was not skipped, as skipped runs never changed the
+// container. It returns nil when the service has no such update.
+func LastUpdate(ctx context.Context, store state.Store, serviceID string) (*state.UpdateResult, error) {
+	history, err := store.GetUpdateHistoryByService(ctx, serviceID, 20)
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		if !history[i].ResultCode.IsSkip() {
+			return &history[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// VerifyRollback checks, without changing anything, that the service can be
+// rolled back past last, its most recent update: that last recorded the
+// digest it replaced, that the image with that digest is available locally
+// or from the registry through manifests, that the compose file the
+// rollback recreates the service from is there, and that no update holds
+// the service's lock. The report lists the steps a rollback would take.
+func (e *Executor) VerifyRollback(ctx context.Context, target *state.Target, service *state.Service, last *state.UpdateResult, manifests ManifestChecker) *RollbackReport {
+	var images imageChecker
+	if e.dockerClient != nil {
+		images = e.dockerClient
+	}
+	return e.verifyRollback(ctx, images, manifests, target, service, last)
+}
+
+func (e *Executor) verifyRollback(ctx context.Context, images imageChecker, manifests ManifestChecker, target *state.Target, service *state.Service, last *state.UpdateResult) *RollbackReport {
+	report := &RollbackReport{
+		Target:        target.Name,
+		Service:       service.Name,
+		CurrentDigest: service.CurrentDigest,
+		Ready:         true,
+	}
+
+	switch {
+	case last == nil:
+		report.Check("history", false, "No update of the service is recorded, so there is nothing to roll back to")
+	case last.OldDigest == "":
+		report.Check("history", false, fmt.Sprintf("The last update, started %s, recorded no previous digest", last.StartedAt.Format("2006-01-02 15:04")))
+	case last.OldDigest == service.CurrentDigest:
+		report.Check("history", false, fmt.Sprintf("The service already runs %s, the digest its last update replaced", shortID(strings.TrimPrefix(last.OldDigest, "sha256:"))))
+	default:
+		report.RollbackDigest = last.OldDigest
+		report.Check("history", true, fmt.Sprintf("The last update, started %s, replaced %s", last.StartedAt.Format("2006-01-02 15:04"), shortID(strings.TrimPrefix(last.OldDigest, "sha256:"))))
+	}
+
+	composeTarget, composeService := target, service
+	switch target.Type {
+	case state.TargetTypeCompose:
+	case state.TargetTypeContainer:
+		var err error
+		if composeTarget, composeService, _, err = resolveDefinition(service); err != nil {
+			report.Check("compose_file", false, fmt.Sprintf("The container cannot be recreated: %v", err))
+			composeTarget = nil
+		}
+	default:
+		report.Check("compose_file", false, fmt.Sprintf("Unknown target type %s", target.Type))
+		composeTarget = nil
+	}
+	if composeTarget != nil {
+		if info, err := os.Stat(composeTarget.Path); err != nil || info.IsDir() {
+			report.Check("compose_file", false, fmt.Sprintf("Compose file %s is missing", composeTarget.Path))
+		} else {
+			report.Check("compose_file", true, fmt.Sprintf("Compose file %s is present", composeTarget.Path))
+		}
+	}
+
+	if report.RollbackDigest != "" {
+		e.checkRollbackImage(ctx, report, images, manifests, service)
+	}
+
+	if e.tryLockService(target, service) {
+		e.unlockService(target, service)
+		report.Check("lock", true, "No update holds the service's lock")
+	} else {
+		report.Check("lock", false, fmt.Sprintf("An update of %s is in progress", target.Name))
+	}
+
+	if report.Image != "" && composeTarget != nil {
+		if report.Source == RollbackSourceRegistry {
+			report.Steps = append(report.Steps, fmt.Sprintf("Pull %s", report.Image))
+		}
+		report.Steps = append(report.Steps,
+			fmt.Sprintf("Recreate service %s with %s through a temporary compose override", composeService.Name, report.Image),
+			fmt.Sprintf("Leave %s unchanged, so the next update moves the service forward again", composeTarget.Path))
+	}
+	return report
+}
+
+// checkRollbackImage records where the rollback's image comes from.
+func (e *Executor) checkRollbackImage(ctx context.Context, report *RollbackReport, images imageChecker, manifests ManifestChecker, service *state.Service) {
+	if service.Build {
+		// Locally built images cannot be pulled; the rollback uses the tag
+		// saved before the rebuild.
+		report.Image = rollbackImageRef(service.Image)
+		if images != nil {
+			if ok, err := images.HasImage(ctx, report.Image); err == nil && ok {
+				report.Source = RollbackSourceLocal
+				report.Check("image", true, fmt.Sprintf("The pre-build image %s is available locally", report.Image))
+				return
+			}
+		}
+		report.Check("image", false, fmt.Sprintf("The pre-build image %s is gone and cannot be pulled", report.Image))
+		return
+	}
+
+	base, _, _ := strings.Cut(service.Image, "@")
+	report.Image = base + "@" + report.RollbackDigest
+	if images != nil {
+		if ok, err := images.HasImage(ctx, report.Image); err == nil && ok {
+			report.Source = RollbackSourceLocal
+			report.Check("image", true, fmt.Sprintf("%s is available locally", report.Image))
+			return
+		}
+	}
+	if manifests == nil {
+		report.Check("image", false, fmt.Sprintf("%s is not available locally", report.Image))
+		return
+	}
+	ok, err := manifests.ManifestExists(ctx, service.Image, report.RollbackDigest)
+	switch {
+	case err != nil:
+		report.Check("image", false, fmt.Sprintf("%s is not available locally and the registry could not be asked: %v", report.Image, err))
+	case !ok:
+		report.Check("image", false, fmt.Sprintf("%s is not available locally and the registry no longer serves it", report.Image))
+	default:
+		report.Source = RollbackSourceRegistry
+		report.Check("image", true, fmt.Sprintf("%s can be pulled from the registry", report.Image))
+	}
+}
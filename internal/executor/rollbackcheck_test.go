@@ -0,0 +1,90 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type fakeImages map[string]bool
+
+func (f fakeImages) HasImage(ctx context.Context, ref string) (bool, error) {
+	return f[ref], nil
+}
+
+type fakeManifests struct {
+	exists bool
+	err    error
+}
+
+func (f fakeManifests) ManifestExists(ctx context.Context, image, digest string) (bool, error) {
+	return f.exists, f.err
+}
+
+func TestVerifyRollback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	if err := os.WriteFile(path, []byte("services:\n  web:\n    image: nginx:1.27\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	target := &state.Target{ID: "t1", Type: state.TargetTypeCompose, Name: "app", Path: path}
+	service := &state.Service{ID: "s1", Name: "web", Image: "nginx:1.27", CurrentDigest: "sha256:new"}
+	last := &state.UpdateResult{OldDigest: "sha256:old", StartedAt: time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)}
+
+	verify := func(images fakeImages, manifests ManifestChecker, locks *fakeLockManager, last *state.UpdateResult) *RollbackReport {
+		exec := &Executor{lockManager: locks, logger: logging.Default()}
+		return exec.verifyRollback(context.Background(), images, manifests, target, service, last)
+	}
+	failed := func(report *RollbackReport) []string {
+		var names []string
+		for _, check := range report.Checks {
+			if !check.OK {
+				names = append(names, check.Name)
+			}
+		}
+		return names
+	}
+
+	report := verify(fakeImages{"nginx:1.27@sha256:old": true}, fakeManifests{}, &fakeLockManager{}, last)
+	if !report.Ready || report.Source != RollbackSourceLocal || report.Image != "nginx:1.27@sha256:old" {
+		t.Fatalf("expected a ready rollback from the local image, got %+v", report)
+	}
+	if len(report.Steps) != 2 {
+		t.Errorf("expected recreate and leave-file steps without a pull, got %v", report.Steps)
+	}
+
+	report = verify(fakeImages{}, fakeManifests{exists: true}, &fakeLockManager{}, last)
+	if !report.Ready || report.Source != RollbackSourceRegistry || len(report.Steps) != 3 {
+		t.Errorf("expected a ready rollback pulling from the registry, got %+v", report)
+	}
+
+	report = verify(fakeImages{}, fakeManifests{}, &fakeLockManager{}, last)
+	if report.Ready || len(failed(report)) != 1 || failed(report)[0] != "image" {
+		t.Errorf("expected the garbage-collected digest to fail, got %+v", report)
+	}
+	report = verify(fakeImages{}, fakeManifests{err: errors.New("timeout")}, &fakeLockManager{}, last)
+	if report.Ready {
+		t.Errorf("expected an unreachable registry to fail the image check, got %+v", report)
+	}
+
+	report = verify(fakeImages{}, fakeManifests{exists: true}, &fakeLockManager{lockErr: ErrLockTimeout}, nil)
+	if got := failed(report); report.Ready || len(got) != 2 || got[0] != "history" || got[1] != "lock" {
+		t.Errorf("expected history and lock to fail, got %v", got)
+	}
+	if report.Steps != nil {
+		t.Errorf("expected no steps without a rollback digest, got %v", report.Steps)
+	}
+
+	missing := *target
+	missing.Path = filepath.Join(t.TempDir(), "gone.yml")
+	exec := &Executor{lockManager: &fakeLockManager{}, logger: logging.Default()}
+	report = exec.verifyRollback(context.Background(), fakeImages{}, fakeManifests{exists: true}, &missing, service, last)
+	if got := failed(report); len(got) != 1 || got[0] != "compose_file" {
+		t.Errorf("expected the missing compose file to fail, got %v", got)
+	}
+}
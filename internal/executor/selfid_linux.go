@@ -0,0 +1,17 @@
+package executor
+
+import (
+	"os"
+	"strings"
+)
+
+// hostContainerID detects the container Bulwark runs in from /proc/1/cpuset,
+// falling back to HOSTNAME, which Docker sets to the short container ID.
+func hostContainerID() string {
+	if data, err := os.ReadFile("/proc/1/cpuset"); err == nil {
+		if id := containerIDFromCpuset(string(data)); id != "" {
+			return id
+		}
+	}
+	return strings.TrimSpace(os.Getenv("HOSTNAME"))
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package executor
+
+// hostContainerID returns "": containers run Linux, so outside Linux, e.g.
+// on a Docker Desktop laptop, Bulwark itself is never one of the services
+// it updates.
+func hostContainerID() string {
+	return ""
+}
@@ -1,9 +1,14 @@
 package executor
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
 
 // SkipError indicates a safe, intentional skip.
 type SkipError struct {
+	Code   state.ResultCode
 	Reason string
 }
 
@@ -11,9 +16,14 @@ func (e *SkipError) Error() string {
 	return e.Reason
 }
 
-// NewSkipError creates a SkipError.
+// NewSkipError creates a SkipError with the generic skipped result code.
 func NewSkipError(reason string) error {
-	return &SkipError{Reason: reason}
+	return &SkipError{Code: state.ResultSkipped, Reason: reason}
+}
+
+// NewCodedSkipError creates a SkipError recorded under a specific result code.
+func NewCodedSkipError(code state.ResultCode, reason string) error {
+	return &SkipError{Code: code, Reason: reason}
 }
 
 // IsSkipError checks whether the error is a SkipError.
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/metrics"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// stepTimer collects how long the steps of one update take. It travels in
+// the update's context, so the compose executor can report its pull and
+// recreate times without changing the updater interfaces. A nil timer
+// records nothing.
+type stepTimer struct {
+	pull     time.Duration
+	recreate time.Duration
+	probe    time.Duration
+	downFrom time.Time // when the old container was first stopped
+}
+
+type timerKey struct{}
+
+func withStepTimer(ctx context.Context, t *stepTimer) context.Context {
+	return context.WithValue(ctx, timerKey{}, t)
+}
+
+func timerFromContext(ctx context.Context) *stepTimer {
+	t, _ := ctx.Value(timerKey{}).(*stepTimer)
+	return t
+}
+
+func (t *stepTimer) addPull(d time.Duration) {
+	if t != nil {
+		t.pull += d
+	}
+}
+
+func (t *stepTimer) addRecreate(d time.Duration) {
+	if t != nil {
+		t.recreate += d
+	}
+}
+
+func (t *stepTimer) addProbe(d time.Duration) {
+	if t != nil {
+		t.probe += d
+	}
+}
+
+// down marks the old container as stopped at the given time. Retries keep
+// the first mark, as the service stays down between attempts.
+func (t *stepTimer) down(at time.Time) {
+	if t != nil && t.downFrom.IsZero() {
+		t.downFrom = at
+	}
+}
+
+// finish completes result, records its timings and observes them in the
+// update histograms. Skipped updates are not observed.
+func (t *stepTimer) finish(result *state.UpdateResult) {
+	result.CompletedAt = time.Now()
+	result.Timings = state.UpdateTimings{
+		PullMs:     t.pull.Milliseconds(),
+		RecreateMs: t.recreate.Milliseconds(),
+		ProbeMs:    t.probe.Milliseconds(),
+	}
+	var downtime time.Duration
+	if !t.downFrom.IsZero() {
+		downtime = result.CompletedAt.Sub(t.downFrom)
+		result.Timings.DowntimeMs = downtime.Milliseconds()
+	}
+
+	if result.ResultCode.IsSkip() {
+		return
+	}
+	for step, d := range map[string]time.Duration{"pull": t.pull, "recreate": t.recreate, "probe": t.probe} {
+		if d > 0 {
+			metrics.UpdateStepDuration.WithLabelValues(step).Observe(d.Seconds())
+		}
+	}
+	metrics.UpdateDuration.Observe(result.CompletedAt.Sub(result.StartedAt).Seconds())
+	if !t.downFrom.IsZero() {
+		metrics.UpdateDowntime.Observe(downtime.Seconds())
+	}
+}
@@ -0,0 +1,62 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// timedComposeUpdater reports step times the way ComposeExecutor does, each
+// attempt pulling for 2s and recreating for 1s.
+type timedComposeUpdater struct {
+	fakeComposeUpdater
+}
+
+func (f *timedComposeUpdater) UpdateService(ctx context.Context, target *state.Target, service *state.Service) error {
+	timer := timerFromContext(ctx)
+	timer.addPull(2 * time.Second)
+	timer.down(time.Now().Add(-time.Second))
+	timer.addRecreate(time.Second)
+	return f.fakeComposeUpdater.UpdateService(ctx, target, service)
+}
+
+func TestExecutorRecordsStepTimings(t *testing.T) {
+	compose := &timedComposeUpdater{fakeComposeUpdater{updateErr: errors.New("transient"), failFirst: 1}}
+	exec := &Executor{
+		composeExec:   compose,
+		containerExec: &fakeContainerUpdater{},
+		lockManager:   &fakeLockManager{},
+		logger:        logging.Default(),
+	}
+	target := &state.Target{ID: "compose-1", Type: state.TargetTypeCompose, Name: "app"}
+	service := &state.Service{ID: "svc-1", Name: "web", CurrentDigest: "sha256:old", Labels: state.DefaultLabels()}
+	service.Labels.Probe.Type = state.ProbeTypeNone
+	service.Labels.Retry = state.RetryConfig{Max: 1, Backoff: time.Millisecond}
+
+	result := exec.ExecuteUpdate(context.Background(), target, service, "sha256:new")
+
+	if !result.Success {
+		t.Fatalf("expected success, got error %s", result.ErrorMessage)
+	}
+	if result.Timings.PullMs != 4000 || result.Timings.RecreateMs != 2000 {
+		t.Errorf("expected both attempts to be timed, got %+v", result.Timings)
+	}
+	// The service went down in the first attempt and stayed down through
+	// the retry.
+	if result.Timings.DowntimeMs < 1000 || result.Timings.DowntimeMs >= 2000 {
+		t.Errorf("expected downtime from the first attempt, got %dms", result.Timings.DowntimeMs)
+	}
+}
+
+func TestStepTimerWithoutContext(t *testing.T) {
+	timer := timerFromContext(context.Background())
+	timer.addPull(time.Second)
+	timer.down(time.Now())
+	if timer != nil {
+		t.Fatal("expected no timer in a plain context")
+	}
+}
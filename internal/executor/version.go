@@ -0,0 +1,175 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/journal"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeTag switches a compose service to another tag of its image. The
+// image in the compose file is rewritten, then the service is pulled,
+// recreated and probed like any update. When the change fails the compose
+// file is restored, and a service that could not be recreated runs its
+// previous digest again. The result is recorded as a version change.
+//
+// The returned error reports a change that cannot be attempted at all, e.g.
+// for a loose container or an image set through variable interpolation.
+func (e *Executor) ChangeTag(ctx context.Context, target *state.Target, service *state.Service, tag string) (*state.UpdateResult, error) {
+	if target.Type != state.TargetTypeCompose {
+		return nil, fmt.Errorf("version changes need a compose target; %s is a %s target", target.Name, target.Type)
+	}
+	if service.Build {
+		return nil, fmt.Errorf("service %s builds its image locally", service.Name)
+	}
+	newImage, err := registry.Retag(service.Image, tag)
+	if err != nil {
+		return nil, err
+	}
+	previousTag := ""
+	if ref, err := registry.ParseImageReference(service.Image); err == nil {
+		previousTag = ref.Tag
+	}
+	if previousTag == tag {
+		return nil, fmt.Errorf("service %s already runs tag %s", service.Name, tag)
+	}
+
+	changed := *service
+	changed.Image = newImage
+	result := &state.UpdateResult{
+		TargetID:     target.ID,
+		ServiceID:    service.ID,
+		ServiceName:  service.Name,
+		OldDigest:    service.CurrentDigest,
+		ProbeResults: []state.ProbeResult{},
+		StartedAt:    time.Now(),
+		Kind:         state.UpdateKindVersionChange,
+		PreviousTag:  previousTag,
+		Trigger:      e.trigger,
+		Actor:        e.actor,
+	}
+	DescribeImage(result, newImage, service.Platform)
+	timer := &stepTimer{}
+	ctx = withStepTimer(ctx, timer)
+
+	e.logger.Info().
+		Str("target", target.Name).
+		Str("service", service.Name).
+		Str("from", service.Image).
+		Str("to", newImage).
+		Msg("Changing image tag")
+
+	if e.dryRun {
+		e.logger.Info().Msg("DRY RUN: Would change image tag")
+		result.Success = true
+		result.ResultCode = state.ResultSuccess
+		result.CompletedAt = time.Now()
+		return result, nil
+	}
+
+	if err := e.acquireLock(ctx, target, service); err != nil {
+		recordError(result, err)
+		timer.finish(result)
+		e.saveResult(ctx, result)
+		return result, nil
+	}
+	defer e.unlockService(target, service)
+
+	reason := fmt.Sprintf("tag change of %s from %s to %s", service.Name, previousTag, tag)
+	original, err := setComposeImage(target.Path, service.Name, newImage, e.actor, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	e.update(ctx, target, &changed, "", result, timer)
+	if !result.Success {
+		reason := fmt.Sprintf("restore after failed tag change of %s", service.Name)
+		if _, err := journal.Write(target.Path, original, e.actor, reason); err != nil {
+			e.logger.Error().Err(err).Str("path", target.Path).Msg("Failed to restore compose file")
+		}
+		if result.ResultCode == state.ResultRecreateFailed && !result.RollbackPerformed {
+			if err := e.ExecuteRollback(ctx, target, service, result); err != nil {
+				recordError(result, newStepError(state.ResultRollbackFailed,
+					fmt.Errorf("%s, rollback also failed: %w", result.ErrorMessage, err)))
+			}
+		}
+	}
+	e.saveResult(ctx, result)
+	return result, nil
+}
+
+// saveResult records result in history unless it was saved already.
+func (e *Executor) saveResult(ctx context.Context, result *state.UpdateResult) {
+	if e.store == nil || result.ID != 0 {
+		return
+	}
+	if err := e.store.SaveUpdateResult(context.WithoutCancel(ctx), result); err != nil {
+		e.logger.Warn().Err(err).Msg("Failed to save update result to store")
+	}
+}
+
+// setComposeImage points service at image in the compose file at path and
+// returns the file's previous content. Only the image value is rewritten, so
+// comments and formatting survive. The write is journaled under actor with
+// reason.
+func setComposeImage(path, service, image, actor, reason string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	node := mappingValue(mappingValue(mappingValue(documentRoot(&doc), "services"), service), "image")
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("service %s sets no image in %s", service, path)
+	}
+	if strings.Contains(node.Value, "$") {
+		return nil, fmt.Errorf("image %q of service %s uses variable interpolation; change the variable instead", node.Value, service)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if node.Line < 1 || node.Line > len(lines) {
+		return nil, fmt.Errorf("image of service %s not found in %s", service, path)
+	}
+	line := lines[node.Line-1]
+	start := min(max(node.Column-1, 0), len(line))
+	offset := strings.Index(line[start:], node.Value)
+	if offset < 0 {
+		return nil, fmt.Errorf("image of service %s not found in %s", service, path)
+	}
+	offset += start
+	lines[node.Line-1] = line[:offset] + image + line[offset+len(node.Value):]
+
+	if _, err := journal.Write(path, []byte(strings.Join(lines, "")), actor, reason); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in the mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/journal"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+const versionCompose = `services:
+  web:
+    # pinned for the plugin API
+    image: "nginx:1.25" # keep quoted
+    ports:
+      - "80:80"
+  db:
+    image: postgres:16
+`
+
+func versionTarget(t *testing.T) *state.Target {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	if err := os.WriteFile(path, []byte(versionCompose), 0o640); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	return &state.Target{ID: "app", Type: state.TargetTypeCompose, Name: "app", Path: path}
+}
+
+func readCompose(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	return string(data)
+}
+
+func TestSetComposeImage(t *testing.T) {
+	target := versionTarget(t)
+	original, err := setComposeImage(target.Path, "web", "nginx:1.27", "test", "tag change")
+	if err != nil {
+		t.Fatalf("setComposeImage failed: %v", err)
+	}
+	if string(original) != versionCompose {
+		t.Errorf("expected the original content back, got %q", original)
+	}
+	want := `services:
+  web:
+    # pinned for the plugin API
+    image: "nginx:1.27" # keep quoted
+    ports:
+      - "80:80"
+  db:
+    image: postgres:16
+`
+	if got := readCompose(t, target.Path); got != want {
+		t.Errorf("unexpected compose file:\n%s", got)
+	}
+	if info, err := os.Stat(target.Path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("expected permissions to be kept, got %v (%v)", info.Mode(), err)
+	}
+
+	if _, err := setComposeImage(target.Path, "cache", "redis:7", "test", "tag change"); err == nil {
+		t.Error("expected an error for a service without image")
+	}
+}
+
+func TestChangeTag(t *testing.T) {
+	target := versionTarget(t)
+	compose := &fakeComposeUpdater{}
+	exec := &Executor{composeExec: compose, lockManager: &fakeLockManager{}, logger: logging.Default()}
+	service := &state.Service{ID: "web", Name: "web", Image: "nginx:1.25", CurrentDigest: "sha256:old"}
+	service.Labels.Probe.Type = state.ProbeTypeNone
+
+	result, err := exec.ChangeTag(context.Background(), target, service, "1.27")
+	if err != nil {
+		t.Fatalf("ChangeTag failed: %v", err)
+	}
+	if !result.Success || result.Kind != state.UpdateKindVersionChange || result.PreviousTag != "1.25" || result.Tag != "1.27" {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if compose.updateCalled != 1 {
+		t.Errorf("expected one update, got %d", compose.updateCalled)
+	}
+
+	if _, err := exec.ChangeTag(context.Background(), target, service, "1.25"); err == nil {
+		t.Error("expected an error changing to the current tag")
+	}
+}
+
+func TestChangeTag_RestoresComposeOnFailure(t *testing.T) {
+	target := versionTarget(t)
+	compose := &fakeComposeUpdater{updateErr: newStepError(state.ResultRecreateFailed, errors.New("port taken"))}
+	exec := &Executor{composeExec: compose, lockManager: &fakeLockManager{}, logger: logging.Default()}
+	service := &state.Service{ID: "web", Name: "web", Image: "nginx:1.25", CurrentDigest: "sha256:old"}
+	service.Labels.Probe.Type = state.ProbeTypeNone
+
+	result, err := exec.ChangeTag(context.Background(), target, service, "1.27")
+	if err != nil {
+		t.Fatalf("ChangeTag failed: %v", err)
+	}
+	if result.Success || result.ResultCode != state.ResultRecreateFailed || !result.RollbackPerformed {
+		t.Errorf("expected a rolled back recreate failure, got %+v", result)
+	}
+	if compose.rollbackCalled != 1 {
+		t.Errorf("expected one rollback, got %d", compose.rollbackCalled)
+	}
+	if got := readCompose(t, target.Path); got != versionCompose {
+		t.Errorf("expected the compose file to be restored, got:\n%s", got)
+	}
+	entries, err := journal.List(target.Path)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected the change and the restore to be journaled, got %+v", entries)
+	}
+}
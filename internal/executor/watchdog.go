@@ -0,0 +1,274 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/probe"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// defaultWatchInterval is how often a watched service's logs are checked.
+const defaultWatchInterval = 15 * time.Second
+
+// Watchdog keeps watching services after a successful update and rolls one
+// back when a line of its logs matches its bulwark.probe.fail_pattern, or it
+// is OOM killed, before the watch ends: failures that only show up once the
+// probes have passed. One watchdog is shared by every executor of a process
+// and outlives their runs, so it opens Docker clients of its own.
+type Watchdog struct {
+	ctx      context.Context
+	store    state.Store
+	logger   *logging.Logger
+	interval time.Duration
+	connect  func() (*docker.Client, error)
+	// locks is shared with the process's other executors; nil gives every
+	// watch a lock manager of its own.
+	locks *LockManager
+
+	// onRollback is told about each rollback the watchdog performed or
+	// attempted, after its history entry was saved.
+	onRollback func(target *state.Target, service *state.Service, result *state.UpdateResult)
+
+	mu      sync.Mutex
+	watches map[string]*watch // By service ID
+}
+
+type watch struct {
+	cancel context.CancelFunc
+}
+
+// NewWatchdog creates a watchdog whose watches end with ctx.
+func NewWatchdog(ctx context.Context, store state.Store, logger *logging.Logger) *Watchdog {
+	return &Watchdog{
+		ctx:      ctx,
+		store:    store,
+		logger:   logger.WithComponent("watchdog"),
+		interval: defaultWatchInterval,
+		connect:  docker.NewClient,
+		watches:  make(map[string]*watch),
+	}
+}
+
+// WithLockManager makes the watchdog's rollbacks take target locks from
+// locks, so they wait for other updates of the target.
+func (w *Watchdog) WithLockManager(locks *LockManager) *Watchdog {
+	w.locks = locks
+	return w
+}
+
+// OnRollback sets a function called with the history entry of every
+// rollback the watchdog starts.
+func (w *Watchdog) OnRollback(fn func(target *state.Target, service *state.Service, result *state.UpdateResult)) *Watchdog {
+	w.onRollback = fn
+	return w
+}
+
+// Watch starts watching a service that result updated, replacing an earlier
+// watch of it. Services without a fail pattern are not watched.
+func (w *Watchdog) Watch(target *state.Target, service *state.Service, result *state.UpdateResult) {
+	if w == nil || service.Labels.Probe.FailPattern == "" || service.Labels.Probe.FailWatch <= 0 {
+		return
+	}
+	pattern, err := regexp.Compile(service.Labels.Probe.FailPattern)
+	if err != nil {
+		w.logger.Warn().Err(err).Str("service", service.Name).Msg("Invalid fail pattern, not watching")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, service.Labels.Probe.FailWatch)
+	current := &watch{cancel: cancel}
+	w.mu.Lock()
+	if previous, ok := w.watches[service.ID]; ok {
+		previous.cancel()
+	}
+	w.watches[service.ID] = current
+	w.mu.Unlock()
+
+	targetCopy, serviceCopy, resultCopy := *target, *service, *result
+	go func() {
+		defer w.forget(service.ID, current)
+		w.run(ctx, &targetCopy, &serviceCopy, &resultCopy, pattern)
+	}()
+}
+
+// Stop ends the watch of a service, e.g. because it is being updated again.
+func (w *Watchdog) Stop(serviceID string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if current, ok := w.watches[serviceID]; ok {
+		current.cancel()
+		delete(w.watches, serviceID)
+	}
+}
+
+// Watching reports whether a service is being watched.
+func (w *Watchdog) Watching(serviceID string) bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, ok := w.watches[serviceID]
+	return ok
+}
+
+// forget removes a finished watch, unless a newer one replaced it.
+func (w *Watchdog) forget(serviceID string, finished *watch) {
+	finished.cancel()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.watches[serviceID] == finished {
+		delete(w.watches, serviceID)
+	}
+}
+
+func (w *Watchdog) run(ctx context.Context, target *state.Target, service *state.Service, result *state.UpdateResult, pattern *regexp.Regexp) {
+	client, err := w.connect()
+	if err != nil {
+		w.logger.Warn().Err(err).Str("service", service.Name).Msg("Cannot watch service")
+		return
+	}
+	defer func() { _ = client.Close() }()
+	exec := NewExecutor(client, policy.NewEngine(w.logger), w.store, w.logger, false).
+		WithLockManager(w.locks).
+		WithTrigger(result.Trigger, result.Actor)
+
+	w.logger.Info().
+		Str("service", service.Name).
+		Str("fail_pattern", pattern.String()).
+		Dur("watch", service.Labels.Probe.FailWatch).
+		Msg("Watching updated service")
+
+	check := func(ctx context.Context, since time.Time) (string, error) {
+		return exec.watchCheck(ctx, target, service, pattern, since)
+	}
+	rollback := func(reason string) {
+		w.rollback(exec, target, service, result, reason)
+	}
+	w.loop(ctx, result.CompletedAt, check, rollback)
+}
+
+// loop checks a service every interval from since until ctx ends, and calls
+// rollback with the reason of the first failure it finds.
+func (w *Watchdog) loop(ctx context.Context, since time.Time, check func(context.Context, time.Time) (string, error), rollback func(string)) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		checkedAt := time.Now()
+		reason, err := check(ctx, since)
+		if err != nil {
+			if ctx.Err() == nil {
+				w.logger.Debug().Err(err).Msg("Watch check failed, will retry")
+			}
+			continue
+		}
+		if reason != "" {
+			rollback(reason)
+			return
+		}
+		since = checkedAt
+	}
+}
+
+// watchCheck looks for a line matching pattern in what the service logged
+// since the given time, and for an OOM kill. It returns why the update
+// failed, or "" while all is well.
+func (e *Executor) watchCheck(ctx context.Context, target *state.Target, service *state.Service, pattern *regexp.Regexp, since time.Time) (string, error) {
+	containerID, err := e.findContainerID(ctx, target, service)
+	if err != nil {
+		return "", err
+	}
+	inspect, err := e.dockerClient.InspectContainer(ctx, containerID)
+	if err != nil {
+		return "", err
+	}
+	if inspect.State.OOMKilled {
+		return "container was OOM killed", nil
+	}
+
+	logs, err := e.dockerClient.ContainerLogsSince(ctx, containerID, since, "all", true, true)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = logs.Close() }()
+	line, err := probe.FirstMatch(logs, pattern)
+	if err != nil {
+		return "", err
+	}
+	if line != "" {
+		return fmt.Sprintf("fail pattern %q matched: %s", pattern.String(), line), nil
+	}
+	return "", nil
+}
+
+// rollback rolls back the update result recorded and saves the outcome as a
+// history entry of its own.
+func (w *Watchdog) rollback(exec *Executor, target *state.Target, service *state.Service, result *state.UpdateResult, reason string) {
+	// The watch has ended, but the rollback must not be cut short with it.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(w.ctx), 15*time.Minute)
+	defer cancel()
+
+	after := time.Since(result.CompletedAt).Round(time.Second)
+	w.logger.Error().
+		Str("service", service.Name).
+		Str("reason", reason).
+		Dur("after", after).
+		Msg("Updated service failed after its probes passed, rolling back")
+
+	tripped := &state.UpdateResult{
+		TargetID:    result.TargetID,
+		ServiceID:   result.ServiceID,
+		ServiceName: result.ServiceName,
+		Image:       result.Image,
+		Tag:         result.Tag,
+		Registry:    result.Registry,
+		Platform:    result.Platform,
+		OldDigest:   result.OldDigest,
+		NewDigest:   result.NewDigest,
+		Kind:        result.Kind,
+		PreviousTag: result.PreviousTag,
+		Trigger:     result.Trigger,
+		Actor:       result.Actor,
+		Attempts:    1,
+		StartedAt:   time.Now().UTC(),
+	}
+
+	var err error
+	if lockErr := exec.lockService(ctx, target, service, exec.lockTimeout); lockErr != nil {
+		err = newStepError(state.ResultRollbackFailed, fmt.Errorf("%w %v after the update (%s), rollback could not lock the target: %w", ErrProbeFailed, after, reason, lockErr))
+	} else {
+		rollbackErr := exec.ExecuteRollback(ctx, target, service, tripped)
+		exec.unlockService(target, service)
+		if rollbackErr != nil {
+			err = newStepError(state.ResultRollbackFailed, fmt.Errorf("%w %v after the update (%s), rollback also failed: %w", ErrProbeFailed, after, reason, rollbackErr))
+		} else {
+			err = fmt.Errorf("%w %v after the update (%s), rolled back to previous version", ErrProbeFailed, after, reason)
+		}
+	}
+	recordError(tripped, err)
+	tripped.CompletedAt = time.Now().UTC()
+
+	if w.store != nil {
+		if err := w.store.SaveUpdateResult(ctx, tripped); err != nil {
+			w.logger.Warn().Err(err).Msg("Failed to save watchdog rollback to store")
+		}
+	}
+	if w.onRollback != nil {
+		w.onRollback(target, service, tripped)
+	}
+}
@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestWatchdogLoop(t *testing.T) {
+	w := NewWatchdog(context.Background(), nil, logging.Default())
+	w.interval = 5 * time.Millisecond
+
+	start := time.Now().Add(-time.Minute)
+	var sinces []time.Time
+	check := func(ctx context.Context, since time.Time) (string, error) {
+		sinces = append(sinces, since)
+		switch len(sinces) {
+		case 1:
+			return "", nil
+		case 2:
+			return "", errors.New("container not found")
+		default:
+			return "fail pattern matched: panic: nil map", nil
+		}
+	}
+	var reason string
+	w.loop(context.Background(), start, check, func(r string) { reason = r })
+
+	if reason != "fail pattern matched: panic: nil map" {
+		t.Errorf("expected a rollback for the match, got %q", reason)
+	}
+	if len(sinces) != 3 || !sinces[0].Equal(start) || !sinces[1].After(start) || !sinces[2].Equal(sinces[1]) {
+		t.Errorf("expected each check to start where the last clean one ended, got %v", sinces)
+	}
+}
+
+func TestWatchdogLoop_EndsWithWatch(t *testing.T) {
+	w := NewWatchdog(context.Background(), nil, logging.Default())
+	w.interval = 5 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	rolledBack := false
+	w.loop(ctx, time.Now(), func(context.Context, time.Time) (string, error) { return "", nil }, func(string) { rolledBack = true })
+	if rolledBack {
+		t.Error("expected no rollback for a clean service")
+	}
+}
+
+func TestWatchdogWatch(t *testing.T) {
+	connected := make(chan struct{}, 1)
+	w := NewWatchdog(context.Background(), nil, logging.Default())
+	w.connect = func() (*docker.Client, error) {
+		connected <- struct{}{}
+		return nil, errors.New("no docker")
+	}
+	target := &state.Target{ID: "t1", Name: "app"}
+	result := &state.UpdateResult{CompletedAt: time.Now()}
+
+	w.Watch(target, &state.Service{ID: "s1", Name: "web"}, result)
+	if w.Watching("s1") {
+		t.Fatal("expected no watch without a fail pattern")
+	}
+
+	service := &state.Service{ID: "s1", Name: "web", Labels: state.Labels{Probe: state.ProbeConfig{FailPattern: "panic", FailWatch: time.Minute}}}
+	w.Watch(target, service, result)
+	<-connected
+	deadline := time.Now().Add(time.Second)
+	for w.Watching("s1") && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if w.Watching("s1") {
+		t.Error("expected a watch that could not connect to end")
+	}
+
+	var nilWatchdog *Watchdog
+	nilWatchdog.Watch(target, service, result)
+	nilWatchdog.Stop("s1")
+}
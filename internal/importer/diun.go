@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// diunLabel prefixes Diun's container labels.
+const diunLabel = "diun."
+
+// diunConfig is the part of Diun's configuration, from its diun.yml or
+// the environment of its container, that has a counterpart in Bulwark.
+type diunConfig struct {
+	Watch struct {
+		Schedule string `yaml:"schedule"`
+	} `yaml:"watch"`
+	Providers struct {
+		Docker *diunDockerProvider `yaml:"docker"`
+		File   interface{}         `yaml:"file"`
+	} `yaml:"providers"`
+	Notif map[string]map[string]interface{} `yaml:"notif"`
+}
+
+type diunDockerProvider struct {
+	WatchByDefault bool `yaml:"watchByDefault"`
+}
+
+// Diun translates the Diun labels of a compose file's services, and Diun's
+// settings from its config file or its container in the compose file, to
+// Bulwark labels and environment. Either input may be empty. Diun only
+// notifies, so every service it watched gets bulwark.policy=notify.
+func Diun(source string, compose, config []byte) (*Result, error) {
+	var cfg diunConfig
+	if len(config) > 0 {
+		if err := yaml.Unmarshal(config, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse Diun config: %w", err)
+		}
+	}
+	services := map[string]composeService{}
+	if len(compose) > 0 {
+		var err error
+		if services, err = parseCompose(compose); err != nil {
+			return nil, err
+		}
+	}
+	result := newResult(source)
+
+	var diuns []string
+	for _, name := range sortedNames(services) {
+		if isImage(services[name].Image, "crazymax/diun", "diun") {
+			diuns = append(diuns, name)
+			cfg.readEnv(stringMap(services[name].Environment))
+		}
+	}
+	if len(diuns) > 0 {
+		result.note("Remove the Diun service %s once Bulwark runs, or both will notify about the same updates", strings.Join(diuns, ", "))
+	}
+	if cfg.Providers.File != nil {
+		result.note("Diun's file provider watched images without containers; Bulwark only checks the images of running services")
+	}
+
+	watchByDefault := cfg.Providers.Docker != nil && cfg.Providers.Docker.WatchByDefault
+	for _, name := range sortedNames(services) {
+		if containsString(diuns, name) {
+			continue
+		}
+		translateDiun(result, name, stringMap(services[name].Labels), watchByDefault)
+	}
+	cfg.environment(result)
+	return result, nil
+}
+
+// readEnv reads the DIUN_* variables that override diun.yml.
+func (c *diunConfig) readEnv(env map[string]string) {
+	if schedule := env["DIUN_WATCH_SCHEDULE"]; schedule != "" {
+		c.Watch.Schedule = schedule
+	}
+	if value := env["DIUN_PROVIDERS_DOCKER_WATCHBYDEFAULT"]; value != "" {
+		c.Providers.Docker = &diunDockerProvider{WatchByDefault: isTrue(value)}
+	}
+	for key, value := range env {
+		rest, ok := strings.CutPrefix(key, "DIUN_NOTIF_")
+		if !ok {
+			continue
+		}
+		notifier, field, ok := strings.Cut(rest, "_")
+		if !ok {
+			continue
+		}
+		notifier = strings.ToLower(notifier)
+		if c.Notif == nil {
+			c.Notif = make(map[string]map[string]interface{})
+		}
+		if c.Notif[notifier] == nil {
+			c.Notif[notifier] = make(map[string]interface{})
+		}
+		c.Notif[notifier][strings.ToLower(field)] = value
+	}
+}
+
+// translateDiun adds the labels of one service.
+func translateDiun(result *Result, name string, labels map[string]string, watchByDefault bool) {
+	enable, labelled := labels[diunLabel+"enable"]
+	watched := watchByDefault
+	if labelled {
+		watched = isTrue(enable)
+	}
+	if !watched {
+		if labelled {
+			result.label(name, "bulwark.enabled", "false")
+		}
+		return
+	}
+
+	result.label(name, "bulwark.enabled", "true")
+	result.label(name, "bulwark.policy", "notify")
+
+	var tagOptions []string
+	for _, option := range []string{"watch_repo", "include_tags", "exclude_tags", "max_tags", "sort_tags"} {
+		if _, ok := labels[diunLabel+option]; ok {
+			tagOptions = append(tagOptions, option)
+		}
+	}
+	if len(tagOptions) > 0 {
+		result.note("%s: %s have no equivalent; Bulwark follows the digest of the running tag, and suggests a release tag for mutable tags such as latest", name, strings.Join(tagOptions, ", "))
+	}
+	if platform := labels[diunLabel+"platform"]; platform != "" {
+		result.note("%s: set platform: %s on the compose service, which Bulwark checks digests for", name, platform)
+	}
+	if labels[diunLabel+"regopt"] != "" {
+		result.note("%s: registry options (regopt) are not imported; give Bulwark registry credentials through Docker's config.json", name)
+	}
+}
+
+// environment adds the Bulwark settings matching Diun's schedule and
+// notifiers.
+func (c *diunConfig) environment(result *Result) {
+	if c.Watch.Schedule != "" {
+		result.Env["BULWARK_NOTIFY_CHECK_CRON"] = standardCron(c.Watch.Schedule)
+		result.Env["BULWARK_NOTIFY_ON_FIND"] = "true"
+	}
+
+	webhooks := map[string]string{
+		"discord": "DISCORD_WEBHOOK_URL",
+		"slack":   "SLACK_WEBHOOK_URL",
+		"teams":   "TEAMS_WEBHOOK_URL",
+	}
+	var others []string
+	for notifier, settings := range c.Notif {
+		env, ok := webhooks[notifier]
+		if !ok {
+			others = append(others, notifier)
+			continue
+		}
+		for field, value := range settings {
+			if strings.EqualFold(field, "webhookURL") {
+				result.Env[env] = fmt.Sprint(value)
+			}
+		}
+	}
+	if len(others) > 0 {
+		sort.Strings(others)
+		result.note("Diun notifiers %s are not imported; set up Bulwark's Matrix, MQTT or Apprise notifications for them", strings.Join(others, ", "))
+	}
+}
@@ -0,0 +1,216 @@
+// Package importer translates the configuration of other container updaters
+// into Bulwark labels and settings, for users switching to Bulwark.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Result is what an import produced: the labels to add to each compose
+// service, the environment to give Bulwark, and notes on what did not
+// translate.
+type Result struct {
+	Source string
+	Labels map[string]map[string]string // By service name
+	Env    map[string]string
+	Notes  []string
+}
+
+func newResult(source string) *Result {
+	return &Result{
+		Source: source,
+		Labels: make(map[string]map[string]string),
+		Env:    make(map[string]string),
+	}
+}
+
+func (r *Result) label(service, key, value string) {
+	if r.Labels[service] == nil {
+		r.Labels[service] = make(map[string]string)
+	}
+	r.Labels[service][key] = value
+}
+
+func (r *Result) note(format string, args ...interface{}) {
+	r.Notes = append(r.Notes, fmt.Sprintf(format, args...))
+}
+
+// Override renders the result as a compose override file that adds the
+// labels to the services when passed to docker compose after the original
+// file. Notes and the environment are written as comments at the top.
+func (r *Result) Override() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Bulwark labels imported from %s.\n", r.Source)
+	buf.WriteString("# Use it with: docker compose -f compose.yml -f <this file> up -d\n")
+	if len(r.Env) > 0 {
+		buf.WriteString("#\n# Environment for the Bulwark container:\n")
+		keys := make([]string, 0, len(r.Env))
+		for key := range r.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(&buf, "#   %s=%s\n", key, r.Env[key])
+		}
+	}
+	if len(r.Notes) > 0 {
+		buf.WriteString("#\n# Review before use:\n")
+		for _, note := range r.Notes {
+			fmt.Fprintf(&buf, "#   - %s\n", note)
+		}
+	}
+
+	type service struct {
+		Labels map[string]string `yaml:"labels"`
+	}
+	services := make(map[string]service, len(r.Labels))
+	for name, labels := range r.Labels {
+		services[name] = service{Labels: labels}
+	}
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(struct {
+		Services map[string]service `yaml:"services"`
+	}{services}); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// composeService holds the parts of a compose service an import reads.
+type composeService struct {
+	Image       string      `yaml:"image"`
+	Labels      interface{} `yaml:"labels"`
+	Environment interface{} `yaml:"environment"`
+	Command     interface{} `yaml:"command"`
+}
+
+func parseCompose(data []byte) (map[string]composeService, error) {
+	var file struct {
+		Services map[string]composeService `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse compose file: %w", err)
+	}
+	return file.Services, nil
+}
+
+// sortedNames returns the service names in order, for stable output.
+func sortedNames(services map[string]composeService) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// stringMap reads compose labels or environment in either of their forms,
+// a map or a list of KEY=value.
+func stringMap(v interface{}) map[string]string {
+	result := make(map[string]string)
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if val != nil {
+				result[key] = fmt.Sprint(val)
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				if key, val, ok := strings.Cut(str, "="); ok {
+					result[key] = val
+				}
+			}
+		}
+	}
+	return result
+}
+
+// commandArgs reads a compose command in either of its forms. A string is
+// split like a shell would, keeping quoted arguments together.
+func commandArgs(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return splitCommand(v)
+	case []interface{}:
+		args := make([]string, 0, len(v))
+		for _, item := range v {
+			args = append(args, fmt.Sprint(item))
+		}
+		return args
+	}
+	return nil
+}
+
+func splitCommand(command string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+// isImage reports whether image is one of the repositories given, with or
+// without a registry, tag or digest.
+func isImage(image string, repositories ...string) bool {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	for _, repository := range repositories {
+		if image == repository || strings.HasSuffix(image, "/"+repository) {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(value string) bool {
+	enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+	return err == nil && enabled
+}
+
+// standardCron turns a cron expression with a leading seconds field, as
+// Watchtower and Diun accept, into the five-field form Bulwark uses.
+func standardCron(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if fields := strings.Fields(expr); len(fields) == 6 && !strings.HasPrefix(expr, "@") && !strings.HasPrefix(expr, "CRON_TZ=") && !strings.HasPrefix(expr, "TZ=") {
+		return strings.Join(fields[1:], " ")
+	}
+	return expr
+}
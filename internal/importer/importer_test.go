@@ -0,0 +1,223 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const watchtowerCompose = `
+services:
+  watchtower:
+    image: containrrr/watchtower:1.7.1
+    environment:
+      - WATCHTOWER_CLEANUP=true
+      - WATCHTOWER_NOTIFICATION_URL=discord://secret@12345 gotify://gotify.lan/token
+    command: --label-enable --schedule "0 0 4 * * *"
+  web:
+    image: nginx:1.27
+    labels:
+      com.centurylinklabs.watchtower.enable: "true"
+      com.centurylinklabs.watchtower.lifecycle.pre-update: /backup.sh
+  db:
+    image: postgres:16
+    labels:
+      - com.centurylinklabs.watchtower.enable=false
+  cache:
+    image: redis:7
+  status:
+    image: louislam/uptime-kuma:1
+    labels:
+      com.centurylinklabs.watchtower.enable: "true"
+      com.centurylinklabs.watchtower.monitor-only: "true"
+`
+
+func TestWatchtower(t *testing.T) {
+	result, err := Watchtower("compose.yml", []byte(watchtowerCompose), WatchtowerOptions{})
+	if err != nil {
+		t.Fatalf("Watchtower() error = %v", err)
+	}
+
+	wantLabels := map[string]map[string]string{
+		"web":    {"bulwark.enabled": "true"},
+		"db":     {"bulwark.enabled": "false"},
+		"status": {"bulwark.enabled": "true", "bulwark.policy": "notify"},
+	}
+	if !reflect.DeepEqual(result.Labels, wantLabels) {
+		t.Errorf("labels = %v; want %v", result.Labels, wantLabels)
+	}
+
+	wantEnv := map[string]string{
+		"BULWARK_AUTO_UPDATE_ENABLED": "true",
+		"BULWARK_AUTO_UPDATE_CRON":    "0 4 * * *",
+		"BULWARK_CLEANUP_POLICY":      "dangling",
+		"DISCORD_WEBHOOK_URL":         "https://discord.com/api/webhooks/12345/secret",
+		"APPRISE_URLS":                "gotify://gotify.lan/token",
+	}
+	if !reflect.DeepEqual(result.Env, wantEnv) {
+		t.Errorf("env = %v; want %v", result.Env, wantEnv)
+	}
+
+	notes := strings.Join(result.Notes, "\n")
+	for _, want := range []string{"Remove the Watchtower service watchtower", "web: lifecycle hooks (pre-update)", "APPRISE_URL"} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("notes miss %q:\n%s", want, notes)
+		}
+	}
+}
+
+func TestWatchtower_Defaults(t *testing.T) {
+	compose := []byte(`
+services:
+  web:
+    image: nginx
+  app:
+    image: ghcr.io/acme/app:2
+    labels:
+      com.centurylinklabs.watchtower.scope: prod
+`)
+	result, err := Watchtower("compose.yml", compose, WatchtowerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without --label-enable, Watchtower updates every container daily.
+	if result.Labels["web"]["bulwark.enabled"] != "true" || result.Labels["app"]["bulwark.group"] != "prod" {
+		t.Errorf("labels = %v", result.Labels)
+	}
+	if result.Env["BULWARK_AUTO_UPDATE_CRON"] != "@every 24h" {
+		t.Errorf("env = %v", result.Env)
+	}
+
+	result, err = Watchtower("compose.yml", compose, WatchtowerOptions{LabelEnable: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Labels) != 0 || len(result.Env) != 0 {
+		t.Errorf("expected nothing to import with --label-enable, got %v and %v", result.Labels, result.Env)
+	}
+}
+
+func TestWatchtower_MonitorOnlyInterval(t *testing.T) {
+	compose := []byte(`
+services:
+  watchtower:
+    image: docker.io/containrrr/watchtower
+    environment:
+      WATCHTOWER_MONITOR_ONLY: "true"
+      WATCHTOWER_POLL_INTERVAL: 21600
+  web:
+    image: nginx
+`)
+	result, err := Watchtower("compose.yml", compose, WatchtowerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Labels["web"]["bulwark.policy"] != "notify" {
+		t.Errorf("labels = %v", result.Labels)
+	}
+	if result.Env["BULWARK_NOTIFY_CHECK_CRON"] != "@every 6h" || result.Env["BULWARK_AUTO_UPDATE_ENABLED"] != "" {
+		t.Errorf("env = %v", result.Env)
+	}
+}
+
+func TestDiun(t *testing.T) {
+	compose := []byte(`
+services:
+  diun:
+    image: crazymax/diun:4
+    environment:
+      - DIUN_WATCH_SCHEDULE=0 */6 * * *
+      - DIUN_NOTIF_DISCORD_WEBHOOKURL=https://discord.com/api/webhooks/1/abc
+  web:
+    image: nginx
+    labels:
+      diun.enable: "true"
+      diun.include_tags: ^\d+\.\d+$
+  db:
+    image: postgres:16
+`)
+	config := []byte(`
+providers:
+  docker:
+    watchByDefault: true
+notif:
+  gotify:
+    endpoint: http://gotify
+`)
+	result, err := Diun("compose.yml", compose, config)
+	if err != nil {
+		t.Fatalf("Diun() error = %v", err)
+	}
+
+	notify := map[string]string{"bulwark.enabled": "true", "bulwark.policy": "notify"}
+	if want := map[string]map[string]string{"web": notify, "db": notify}; !reflect.DeepEqual(result.Labels, want) {
+		t.Errorf("labels = %v; want %v", result.Labels, want)
+	}
+	wantEnv := map[string]string{
+		"BULWARK_NOTIFY_CHECK_CRON": "0 */6 * * *",
+		"BULWARK_NOTIFY_ON_FIND":    "true",
+		"DISCORD_WEBHOOK_URL":       "https://discord.com/api/webhooks/1/abc",
+	}
+	if !reflect.DeepEqual(result.Env, wantEnv) {
+		t.Errorf("env = %v; want %v", result.Env, wantEnv)
+	}
+	notes := strings.Join(result.Notes, "\n")
+	for _, want := range []string{"web: include_tags", "notifiers gotify", "Remove the Diun service diun"} {
+		if !strings.Contains(notes, want) {
+			t.Errorf("notes miss %q:\n%s", want, notes)
+		}
+	}
+}
+
+func TestOverride(t *testing.T) {
+	result := newResult("compose.yml")
+	result.label("web", "bulwark.enabled", "true")
+	result.Env["BULWARK_AUTO_UPDATE_ENABLED"] = "true"
+	result.note("check web")
+
+	out, err := result.Override()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(out)
+	for _, want := range []string{"#   BULWARK_AUTO_UPDATE_ENABLED=true", "#   - check web"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("override misses %q:\n%s", want, text)
+		}
+	}
+
+	var parsed struct {
+		Services map[string]struct {
+			Labels map[string]string `yaml:"labels"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(out, &parsed); err != nil {
+		t.Fatalf("override is not valid YAML: %v", err)
+	}
+	if parsed.Services["web"].Labels["bulwark.enabled"] != "true" {
+		t.Errorf("parsed override = %+v", parsed)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	got := splitCommand(`--schedule "0 0 4 * * *"  --scope='prod env' web`)
+	want := []string{"--schedule", "0 0 4 * * *", "--scope=prod env", "web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitCommand() = %q; want %q", got, want)
+	}
+}
+
+func TestStandardCron(t *testing.T) {
+	for expr, want := range map[string]string{
+		"0 0 4 * * *":  "0 4 * * *",
+		"0 4 * * *":    "0 4 * * *",
+		"@daily":       "@daily",
+		"@every 1h30m": "@every 1h30m",
+	} {
+		if got := standardCron(expr); got != want {
+			t.Errorf("standardCron(%q) = %q; want %q", expr, got, want)
+		}
+	}
+}
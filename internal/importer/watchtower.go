@@ -0,0 +1,265 @@
+package importer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watchtowerLabel prefixes Watchtower's container labels.
+const watchtowerLabel = "com.centurylinklabs.watchtower."
+
+// WatchtowerOptions tunes a Watchtower import.
+type WatchtowerOptions struct {
+	// LabelEnable assumes Watchtower only updates containers labelled
+	// enable=true, as with --label-enable, when the file has no Watchtower
+	// container to read its settings from.
+	LabelEnable bool
+}
+
+// watchtowerConfig is the part of Watchtower's configuration that has a
+// counterpart in Bulwark.
+type watchtowerConfig struct {
+	labelEnable   bool
+	monitorOnly   bool
+	cleanup       bool
+	schedule      string
+	interval      string // Seconds
+	scope         string
+	notifications []string
+	slackHook     string
+	teamsHook     string
+	containers    []string
+}
+
+// Watchtower translates the Watchtower labels of a compose file's services,
+// and the settings of the Watchtower container in it, to Bulwark labels and
+// environment.
+func Watchtower(source string, compose []byte, options WatchtowerOptions) (*Result, error) {
+	services, err := parseCompose(compose)
+	if err != nil {
+		return nil, err
+	}
+	result := newResult(source)
+
+	cfg := watchtowerConfig{labelEnable: options.LabelEnable}
+	var watchtowers []string
+	for _, name := range sortedNames(services) {
+		if isImage(services[name].Image, "containrrr/watchtower", "watchtower") {
+			watchtowers = append(watchtowers, name)
+			cfg.read(services[name])
+		}
+	}
+	if len(watchtowers) == 0 {
+		if options.LabelEnable {
+			result.note("No Watchtower container in the file; assumed it only updates containers labelled %senable=true", watchtowerLabel)
+		} else {
+			result.note("No Watchtower container in the file; assumed its defaults: every container, checked every 24h")
+		}
+	} else {
+		result.note("Remove the Watchtower service %s once Bulwark runs, so the two do not update the same containers", strings.Join(watchtowers, ", "))
+	}
+	if len(cfg.containers) > 0 {
+		result.note("Watchtower only watched the containers %s; compose services of other names were left out", strings.Join(cfg.containers, ", "))
+	}
+
+	for _, name := range sortedNames(services) {
+		if containsString(watchtowers, name) {
+			continue
+		}
+		cfg.translate(result, name, stringMap(services[name].Labels))
+	}
+	cfg.environment(result, len(watchtowers) > 0)
+	return result, nil
+}
+
+// read collects the settings of a Watchtower container from its
+// environment and command line.
+func (c *watchtowerConfig) read(service composeService) {
+	env := stringMap(service.Environment)
+	c.labelEnable = c.labelEnable || isTrue(env["WATCHTOWER_LABEL_ENABLE"])
+	c.monitorOnly = c.monitorOnly || isTrue(env["WATCHTOWER_MONITOR_ONLY"])
+	c.cleanup = c.cleanup || isTrue(env["WATCHTOWER_CLEANUP"])
+	setIfEmpty(&c.schedule, env["WATCHTOWER_SCHEDULE"])
+	setIfEmpty(&c.interval, env["WATCHTOWER_POLL_INTERVAL"])
+	setIfEmpty(&c.scope, env["WATCHTOWER_SCOPE"])
+	setIfEmpty(&c.slackHook, env["WATCHTOWER_NOTIFICATION_SLACK_HOOK_URL"])
+	setIfEmpty(&c.teamsHook, env["WATCHTOWER_NOTIFICATION_MSTEAMS_HOOK_URL"])
+	c.notifications = append(c.notifications, strings.Fields(env["WATCHTOWER_NOTIFICATION_URL"])...)
+
+	args := commandArgs(service.Command)
+	for i := 0; i < len(args); i++ {
+		flag, value, hasValue := strings.Cut(args[i], "=")
+		next := func() string {
+			if hasValue {
+				return value
+			}
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+		switch flag {
+		case "--label-enable":
+			c.labelEnable = !hasValue || isTrue(value)
+		case "--monitor-only", "-m":
+			c.monitorOnly = !hasValue || isTrue(value)
+		case "--cleanup", "-c":
+			c.cleanup = !hasValue || isTrue(value)
+		case "--schedule", "-s":
+			c.schedule = next()
+		case "--interval", "-i":
+			c.interval = next()
+		case "--scope":
+			c.scope = next()
+		case "--notification-url":
+			c.notifications = append(c.notifications, next())
+		default:
+			if !strings.HasPrefix(flag, "-") {
+				c.containers = append(c.containers, flag)
+			}
+		}
+	}
+}
+
+// translate adds the labels of one service.
+func (c *watchtowerConfig) translate(result *Result, name string, labels map[string]string) {
+	// Bulwark leaves services without bulwark.enabled alone, so those
+	// Watchtower skipped only get a label when one disabled them.
+	if c.scope != "" && labels[watchtowerLabel+"scope"] != c.scope {
+		return
+	}
+
+	enable, labelled := labels[watchtowerLabel+"enable"]
+	watched := !c.labelEnable
+	if labelled {
+		watched = isTrue(enable)
+	}
+	if len(c.containers) > 0 && !containsString(c.containers, name) {
+		watched = false
+	}
+	if !watched {
+		if labelled {
+			result.label(name, "bulwark.enabled", "false")
+		}
+		return
+	}
+
+	result.label(name, "bulwark.enabled", "true")
+	if c.monitorOnly || isTrue(labels[watchtowerLabel+"monitor-only"]) {
+		result.label(name, "bulwark.policy", "notify")
+	}
+	if scope := labels[watchtowerLabel+"scope"]; scope != "" && c.scope == "" {
+		result.label(name, "bulwark.group", scope)
+	}
+	if isTrue(labels[watchtowerLabel+"no-pull"]) {
+		result.note("%s: no-pull has no equivalent; Bulwark always pulls, or rebuilds build: services with bulwark.build=true", name)
+	}
+	if dependsOn := labels[watchtowerLabel+"depends-on"]; dependsOn != "" {
+		result.note("%s: depends-on %s names containers; bulwark.depends_on_target takes target (compose project) names", name, dependsOn)
+	}
+	var hooks []string
+	for key := range labels {
+		if strings.HasPrefix(key, watchtowerLabel+"lifecycle.") {
+			hooks = append(hooks, strings.TrimPrefix(key, watchtowerLabel+"lifecycle."))
+		}
+	}
+	if len(hooks) > 0 {
+		sort.Strings(hooks)
+		result.note("%s: lifecycle hooks (%s) are not supported; check the service with bulwark.probe.* labels instead", name, strings.Join(hooks, ", "))
+	}
+}
+
+// environment adds the Bulwark settings matching Watchtower's schedule,
+// cleanup and notifications.
+func (c *watchtowerConfig) environment(result *Result, found bool) {
+	schedule := ""
+	switch {
+	case c.schedule != "":
+		schedule = standardCron(c.schedule)
+	case c.interval != "":
+		seconds, err := strconv.Atoi(c.interval)
+		if err != nil || seconds <= 0 {
+			result.note("Ignored the poll interval %q: not a number of seconds", c.interval)
+			break
+		}
+		schedule = "@every " + shortDuration(time.Duration(seconds)*time.Second)
+	case found || !c.labelEnable:
+		schedule = "@every 24h"
+	}
+	if schedule != "" {
+		if c.monitorOnly {
+			result.Env["BULWARK_NOTIFY_CHECK_CRON"] = schedule
+			result.Env["BULWARK_NOTIFY_ON_FIND"] = "true"
+		} else {
+			result.Env["BULWARK_AUTO_UPDATE_ENABLED"] = "true"
+			result.Env["BULWARK_AUTO_UPDATE_CRON"] = schedule
+		}
+	}
+	if c.cleanup {
+		result.Env["BULWARK_CLEANUP_POLICY"] = "dangling"
+	}
+	if c.slackHook != "" {
+		result.Env["SLACK_WEBHOOK_URL"] = c.slackHook
+	}
+	if c.teamsHook != "" {
+		result.Env["TEAMS_WEBHOOK_URL"] = c.teamsHook
+	}
+
+	var apprise []string
+	for _, url := range c.notifications {
+		if webhook, ok := discordWebhook(url); ok {
+			result.Env["DISCORD_WEBHOOK_URL"] = webhook
+			continue
+		}
+		apprise = append(apprise, url)
+	}
+	if len(apprise) > 0 {
+		result.Env["APPRISE_URLS"] = strings.Join(apprise, ",")
+		result.note("Notification URLs other than Discord go to APPRISE_URLS, which also needs APPRISE_URL; check that Apprise accepts each scheme")
+	}
+}
+
+// discordWebhook turns a shoutrrr discord://token@id URL into the webhook
+// URL Bulwark takes.
+func discordWebhook(url string) (string, bool) {
+	rest, ok := strings.CutPrefix(url, "discord://")
+	if !ok {
+		return "", false
+	}
+	rest, _, _ = strings.Cut(rest, "?")
+	token, id, ok := strings.Cut(rest, "@")
+	if !ok || token == "" || id == "" {
+		return "", false
+	}
+	return fmt.Sprintf("https://discord.com/api/webhooks/%s/%s", id, token), true
+}
+
+// shortDuration formats d without trailing zero units, e.g. 24h or 90m.
+func shortDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+func setIfEmpty(field *string, value string) {
+	if *field == "" {
+		*field = strings.TrimSpace(value)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
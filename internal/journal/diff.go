@@ -0,0 +1,97 @@
+package journal
+
+import "strings"
+
+// maxDiffLines bounds the LCS table; larger files are diffed as a whole
+// replacement rather than line by line.
+const maxDiffLines = 5000
+
+// Diff returns a line diff of before and after, with removed lines prefixed
+// by "-", added lines by "+" and unchanged lines by a space. Unchanged lines
+// more than three lines away from a change are left out.
+func Diff(before, after string) string {
+	a, b := splitLines(before), splitLines(after)
+	if len(a)+len(b) > maxDiffLines {
+		return wholeDiff(a, b)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	return trimContext(lines, 3)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func wholeDiff(a, b []string) string {
+	lines := make([]string, 0, len(a)+len(b))
+	for _, line := range a {
+		lines = append(lines, "-"+line)
+	}
+	for _, line := range b {
+		lines = append(lines, "+"+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// trimContext keeps changed lines and up to context unchanged lines around
+// them, marking skipped stretches with "...".
+func trimContext(lines []string, context int) string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if line[0] == ' ' {
+			continue
+		}
+		for k := max(0, i-context); k <= min(len(lines)-1, i+context); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out []string
+	skipped := false
+	for i, line := range lines {
+		if !keep[i] {
+			skipped = true
+			continue
+		}
+		if skipped && len(out) > 0 {
+			out = append(out, "...")
+		}
+		skipped = false
+		out = append(out, line)
+	}
+	return strings.Join(out, "\n")
+}
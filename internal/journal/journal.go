@@ -0,0 +1,187 @@
+// Package journal keeps versioned backups of the compose files Bulwark
+// writes, along with a journal of who changed which file, when and how.
+//
+// Everything lives in a .bulwark directory next to the compose file: one
+// backup of the previous content per change and a journal.jsonl with an
+// entry per change.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dir is the directory beside a compose file holding its backups and journal.
+const Dir = ".bulwark"
+
+const journalFile = "journal.jsonl"
+
+// ErrNotFound marks a journal entry that does not exist.
+var ErrNotFound = errors.New("journal entry not found")
+
+// Entry records one write to a compose file.
+type Entry struct {
+	ID   int    `json:"id"`
+	File string `json:"file"`
+	// Backup names the file in Dir holding the content before the change.
+	Backup    string    `json:"backup"`
+	Actor     string    `json:"actor,omitempty"`
+	Reason    string    `json:"reason"`
+	Diff      string    `json:"diff"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// mu serializes journal writes within the process.
+var mu sync.Mutex
+
+// Write replaces the content of the compose file at path, keeping its
+// permissions. The previous content is backed up and the change journaled
+// under actor with reason.
+func Write(path string, content []byte, actor, reason string) (*Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat compose file: %w", err)
+	}
+	previous, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compose file: %w", err)
+	}
+
+	dir := filepath.Join(filepath.Dir(path), Dir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	entries, err := readEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+	id := 1
+	for _, entry := range entries {
+		id = max(id, entry.ID+1)
+	}
+
+	entry := &Entry{
+		ID:        id,
+		File:      filepath.Base(path),
+		Backup:    fmt.Sprintf("%s.%d.bak", filepath.Base(path), id),
+		Actor:     actor,
+		Reason:    reason,
+		Diff:      Diff(string(previous), string(content)),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := os.WriteFile(filepath.Join(dir, entry.Backup), previous, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to back up compose file: %w", err)
+	}
+	if err := os.WriteFile(path, content, info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write compose file: %w", err)
+	}
+	if err := appendEntry(dir, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// List returns the journal entries of the compose file at path, newest first.
+func List(path string) ([]Entry, error) {
+	entries, err := readEntries(filepath.Join(filepath.Dir(path), Dir))
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Base(path)
+	matched := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.File == base {
+			matched = append(matched, entry)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+	return matched, nil
+}
+
+// Backup returns the entry id of the compose file at path and the content
+// the file had before that change.
+func Backup(path string, id int) (*Entry, []byte, error) {
+	entries, err := List(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range entries {
+		if entries[i].ID != id {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(filepath.Dir(path), Dir, entries[i].Backup))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		return &entries[i], content, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %d", ErrNotFound, id)
+}
+
+// Restore puts back the content the compose file at path had before change
+// id. The restore is journaled like any other write.
+func Restore(path string, id int, actor string) (*Entry, error) {
+	_, content, err := Backup(path, id)
+	if err != nil {
+		return nil, err
+	}
+	return Write(path, content, actor, fmt.Sprintf("restore the version before change %d", id))
+}
+
+func readEntries(dir string) ([]Entry, error) {
+	file, err := os.Open(filepath.Join(dir, journalFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			// A torn line from a crash must not hide the rest of the journal.
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	return entries, nil
+}
+
+func appendEntry(dir string, entry *Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode journal entry: %w", err)
+	}
+	file, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteListRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "compose.yml")
+	v1 := "services:\n  web:\n    image: nginx:1.25\n"
+	v2 := "services:\n  web:\n    image: nginx:1.27\n"
+	if err := os.WriteFile(path, []byte(v1), 0o640); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	entry, err := Write(path, []byte(v2), "alice", "tag change")
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if entry.ID != 1 || entry.Actor != "alice" || entry.Backup != "compose.yml.1.bak" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if !strings.Contains(entry.Diff, "-    image: nginx:1.25\n+    image: nginx:1.27") {
+		t.Errorf("unexpected diff:\n%s", entry.Diff)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("expected permissions to be kept, got %v (%v)", info, err)
+	}
+
+	if _, err := Restore(path, 1, "bob"); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != v1 {
+		t.Errorf("expected the first version back, got %q", data)
+	}
+
+	entries, err := List(path)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].ID != 2 || entries[0].Actor != "bob" {
+		t.Fatalf("expected the restore first, got %+v", entries)
+	}
+	_, content, err := Backup(path, 2)
+	if err != nil || string(content) != v2 {
+		t.Errorf("expected the backup of change 2 to hold v2, got %q (%v)", content, err)
+	}
+	if _, _, err := Backup(path, 9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestListSeparatesFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "compose.yml"), filepath.Join(dir, "docker-compose.override.yml")
+	for _, path := range []string{a, b} {
+		if err := os.WriteFile(path, []byte("services: {}\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	if _, err := Write(a, []byte("services:\n  web: {}\n"), "", "edit"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := Write(b, []byte("services:\n  db: {}\n"), "", "edit"); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	entries, err := List(b)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != 2 {
+		t.Errorf("expected only the override's entry, got %+v", entries)
+	}
+}
+
+func TestDiffContext(t *testing.T) {
+	before := "a\nb\nc\nd\ne\nf\ng\nh\ni\n"
+	after := "a\nb\nc\nd\ne\nf\ng\nh\nI\n"
+	want := " f\n g\n h\n-i\n+I"
+	if got := Diff(before, after); got != want {
+		t.Errorf("Diff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := Diff("x\n", "x\n"); got != "" {
+		t.Errorf("expected no diff for equal input, got %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// LevelSettings is the default log level and the per-component overrides
+// that take precedence over it.
+type LevelSettings struct {
+	Default    string            `json:"default"`
+	Components map[string]string `json:"components"`
+}
+
+// levels holds the active settings. zerolog's global level is kept at the
+// most verbose of them so that component loggers can opt into debug output;
+// componentLevel then drops what a component's own level excludes.
+var levels = struct {
+	sync.RWMutex
+	def        zerolog.Level
+	components map[string]zerolog.Level
+}{def: zerolog.InfoLevel}
+
+// ParseLevels parses overrides written as "registry=debug,executor=info".
+func ParseLevels(spec string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		component, level, ok := strings.Cut(entry, "=")
+		component = strings.TrimSpace(component)
+		if !ok || component == "" {
+			return nil, fmt.Errorf("invalid log level override %q: expected component=level", entry)
+		}
+		overrides[component] = strings.TrimSpace(level)
+	}
+	if _, err := parseComponents(overrides); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// SetLevels replaces the active levels. An empty Default keeps the current
+// default level; Components replaces every existing override.
+func SetLevels(settings LevelSettings) error {
+	components, err := parseComponents(settings.Components)
+	if err != nil {
+		return err
+	}
+
+	levels.Lock()
+	defer levels.Unlock()
+	def := levels.def
+	if settings.Default != "" {
+		if def, err = parseLevel(settings.Default); err != nil {
+			return err
+		}
+	}
+	applyLevels(def, components)
+	return nil
+}
+
+// Levels returns the active levels.
+func Levels() LevelSettings {
+	levels.RLock()
+	defer levels.RUnlock()
+	settings := LevelSettings{
+		Default:    levels.def.String(),
+		Components: make(map[string]string, len(levels.components)),
+	}
+	for component, level := range levels.components {
+		settings.Components[component] = level.String()
+	}
+	return settings
+}
+
+// applyLevels must be called with levels locked.
+func applyLevels(def zerolog.Level, components map[string]zerolog.Level) {
+	levels.def = def
+	levels.components = components
+
+	global := def
+	for _, level := range components {
+		if level < global {
+			global = level
+		}
+	}
+	zerolog.SetGlobalLevel(global)
+}
+
+func levelFor(component string) zerolog.Level {
+	levels.RLock()
+	defer levels.RUnlock()
+	if level, ok := levels.components[component]; ok {
+		return level
+	}
+	return levels.def
+}
+
+func parseComponents(overrides map[string]string) (map[string]zerolog.Level, error) {
+	components := make(map[string]zerolog.Level, len(overrides))
+	for component, value := range overrides {
+		level, err := parseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", component, err)
+		}
+		components[strings.ToLower(component)] = level
+	}
+	return components, nil
+}
+
+func parseLevel(value string) (zerolog.Level, error) {
+	level, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(value)))
+	if err != nil || value == "" {
+		return zerolog.NoLevel, fmt.Errorf("unknown log level %q", value)
+	}
+	return level, nil
+}
+
+// componentLevel is a hook that discards events below the level configured
+// for its component.
+type componentLevel string
+
+func (c componentLevel) Run(e *zerolog.Event, level zerolog.Level, _ string) {
+	if level != zerolog.NoLevel && level < levelFor(string(c)) {
+		e.Discard()
+	}
+}
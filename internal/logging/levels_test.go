@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestParseLevels(t *testing.T) {
+	got, err := ParseLevels(" registry=debug, executor = info ,")
+	if err != nil {
+		t.Fatalf("ParseLevels: %v", err)
+	}
+	if len(got) != 2 || got["registry"] != "debug" || got["executor"] != "info" {
+		t.Errorf("unexpected overrides %v", got)
+	}
+
+	for _, spec := range []string{"registry", "=debug", "registry=loud"} {
+		if _, err := ParseLevels(spec); err == nil {
+			t.Errorf("ParseLevels(%q): expected error", spec)
+		}
+	}
+}
+
+func TestComponentLevels(t *testing.T) {
+	t.Cleanup(func() { _ = SetLevels(LevelSettings{Default: "info"}) })
+
+	var buf bytes.Buffer
+	root := wrap(zerolog.New(&buf), "")
+	registry := root.WithComponent("registry")
+	executor := root.WithComponent("executor").WithTarget("t1", "web")
+
+	if err := SetLevels(LevelSettings{Default: "info", Components: map[string]string{"registry": "debug", "executor": "warn"}}); err != nil {
+		t.Fatalf("SetLevels: %v", err)
+	}
+	root.Debug().Msg("root-debug")
+	registry.Debug().Msg("registry-debug")
+	executor.Info().Msg("executor-info")
+	executor.Warn().Msg("executor-warn")
+
+	out := buf.String()
+	for _, want := range []string{"registry-debug", "executor-warn"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"root-debug", "executor-info"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("did not expect %q in output:\n%s", unwanted, out)
+		}
+	}
+
+	// Dropping the override returns the component to the default level.
+	buf.Reset()
+	if err := SetLevels(LevelSettings{}); err != nil {
+		t.Fatalf("SetLevels: %v", err)
+	}
+	registry.Debug().Msg("registry-debug")
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %s", buf.String())
+	}
+	if got := Levels(); got.Default != "info" || len(got.Components) != 0 {
+		t.Errorf("unexpected levels %+v", got)
+	}
+}
+
+func TestSetLevels_InvalidKeepsCurrent(t *testing.T) {
+	t.Cleanup(func() { _ = SetLevels(LevelSettings{Default: "info"}) })
+
+	if err := SetLevels(LevelSettings{Default: "warn", Components: map[string]string{"api": "debug"}}); err != nil {
+		t.Fatalf("SetLevels: %v", err)
+	}
+	if err := SetLevels(LevelSettings{Default: "chatty"}); err == nil {
+		t.Fatal("expected error for unknown level")
+	}
+	if got := Levels(); got.Default != "warn" || got.Components["api"] != "debug" {
+		t.Errorf("levels changed after failed update: %+v", got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/redact"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -13,30 +14,48 @@ import (
 // Logger wraps zerolog.Logger with Bulwark-specific configuration
 type Logger struct {
 	*zerolog.Logger
+	// base is Logger without the component level hook, so derived loggers
+	// can install their own instead of stacking on the parent's.
+	base      zerolog.Logger
+	component string
 }
 
 // Config holds logger configuration
 type Config struct {
 	Level         string
-	Format        string // "json" or "console"
+	Format        string            // "json" or "console"
+	Components    map[string]string // Per-component level overrides, see ParseLevels
 	RedactSecrets bool
 }
 
-// New creates a new configured logger
+// New creates a new configured logger and makes cfg's levels the active ones.
 func New(cfg Config) *Logger {
 	// Parse log level
 	level, err := zerolog.ParseLevel(strings.ToLower(cfg.Level))
 	if err != nil {
 		level = zerolog.InfoLevel
 	}
+	components, err := parseComponents(cfg.Components)
+	if err != nil {
+		components = nil
+	}
+	levels.Lock()
+	applyLevels(level, components)
+	levels.Unlock()
 
-	zerolog.SetGlobalLevel(level)
+	return newLogger(cfg)
+}
 
+func newLogger(cfg Config) *Logger {
 	// Configure output
-	var output io.Writer = os.Stdout
+	var out io.Writer = os.Stdout
+	if cfg.RedactSecrets {
+		out = redactingWriter{out: out}
+	}
+	output := out
 	if cfg.Format == "console" {
 		output = zerolog.ConsoleWriter{
-			Out:        os.Stdout,
+			Out:        out,
 			TimeFormat: time.RFC3339,
 		}
 	}
@@ -48,40 +67,66 @@ func New(cfg Config) *Logger {
 		Caller().
 		Logger()
 
-	return &Logger{Logger: &logger}
+	return wrap(logger, "")
 }
 
-// Default returns a logger with default configuration
+// wrap returns base with the level hook for component installed.
+func wrap(base zerolog.Logger, component string) *Logger {
+	logger := base.Hook(componentLevel(component))
+	return &Logger{Logger: &logger, base: base, component: component}
+}
+
+// redactingWriter masks secrets in each log line before it is written.
+type redactingWriter struct {
+	out io.Writer
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.out, redact.String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Default returns a logger with default configuration. It leaves the active
+// levels as they are.
 func Default() *Logger {
-	return New(Config{
-		Level:         "info",
+	return newLogger(Config{
 		Format:        "console",
 		RedactSecrets: true,
 	})
 }
 
-// WithComponent returns a new logger with a component field
+// WithComponent returns a new logger with a component field. Its events are
+// filtered by the component's level override, if one is set.
 func (l *Logger) WithComponent(component string) *Logger {
-	logger := l.Logger.With().Str("component", component).Logger()
-	return &Logger{Logger: &logger}
+	logger := l.base.With().Str("component", component).Logger()
+	return wrap(logger, component)
 }
 
 // WithTarget returns a new logger with target context
 func (l *Logger) WithTarget(targetID, targetName string) *Logger {
-	logger := l.Logger.With().
+	logger := l.base.With().
 		Str("target_id", targetID).
 		Str("target_name", targetName).
 		Logger()
-	return &Logger{Logger: &logger}
+	return wrap(logger, l.component)
 }
 
 // WithService returns a new logger with service context
 func (l *Logger) WithService(serviceName, image string) *Logger {
-	logger := l.Logger.With().
+	logger := l.base.With().
 		Str("service", serviceName).
 		Str("image", image).
 		Logger()
-	return &Logger{Logger: &logger}
+	return wrap(logger, l.component)
+}
+
+// WithRequestID returns a new logger with the ID of the HTTP request it
+// logs for
+func (l *Logger) WithRequestID(requestID string) *Logger {
+	logger := l.base.With().Str("request_id", requestID).Logger()
+	return wrap(logger, l.component)
 }
 
 // Init initializes the global logger
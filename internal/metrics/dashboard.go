@@ -0,0 +1,245 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultDashboardTitle is the title of the generated Grafana dashboard.
+const DefaultDashboardTitle = "Bulwark"
+
+// dashboardQuery is one PromQL expression of a panel. Expr holds %[1]s
+// where the metric's name goes; Labels lists every label it selects or
+// groups by, so the dashboard fails to generate rather than silently
+// showing nothing once a metric loses one.
+type dashboardQuery struct {
+	Metric prometheus.Collector
+	Expr   string
+	Labels []string
+	Legend string
+}
+
+type dashboardPanel struct {
+	Title   string
+	Type    string // timeseries or stat
+	Unit    string
+	Width   int
+	Queries []dashboardQuery
+}
+
+// dashboardPanels lays out the dashboard. Queries reference the collectors
+// themselves, so names always follow the code.
+var dashboardPanels = []dashboardPanel{
+	{
+		Title: "Updates available", Type: "stat", Unit: "short", Width: 8,
+		Queries: []dashboardQuery{{
+			Metric: UpdatesAvailable,
+			Expr:   `sum(%[1]s{target=~"$target"})`,
+			Labels: []string{"target"},
+		}},
+	},
+	{
+		Title: "Updates available by target", Type: "timeseries", Unit: "short", Width: 16,
+		Queries: []dashboardQuery{{
+			Metric: UpdatesAvailable,
+			Expr:   `sum by (target) (%[1]s{target=~"$target"})`,
+			Labels: []string{"target"},
+			Legend: "{{target}}",
+		}},
+	},
+	{
+		Title: "Updates by result", Type: "timeseries", Unit: "short", Width: 12,
+		Queries: []dashboardQuery{{
+			Metric: UpdatesTotal,
+			Expr:   `sum by (target, result) (increase(%[1]s{target=~"$target"}[$__interval]))`,
+			Labels: []string{"target", "result"},
+			Legend: "{{target}} {{result}}",
+		}},
+	},
+	{
+		Title: "Rollbacks", Type: "timeseries", Unit: "short", Width: 12,
+		Queries: []dashboardQuery{{
+			Metric: RollbacksTotal,
+			Expr:   `sum by (target, service) (increase(%[1]s{target=~"$target"}[$__interval]))`,
+			Labels: []string{"target", "service"},
+			Legend: "{{target}}/{{service}}",
+		}},
+	},
+	{
+		Title: "Probe failures", Type: "timeseries", Unit: "short", Width: 12,
+		Queries: []dashboardQuery{{
+			Metric: ProbesTotal,
+			Expr:   `sum by (target, service, type) (increase(%[1]s{result="failure", target=~"$target"}[$__interval]))`,
+			Labels: []string{"target", "service", "type", "result"},
+			Legend: "{{target}}/{{service}} {{type}}",
+		}},
+	},
+	{
+		Title: "Probe duration (p95)", Type: "timeseries", Unit: "s", Width: 12,
+		Queries: []dashboardQuery{{
+			Metric: ProbeDuration,
+			Expr:   `histogram_quantile(0.95, sum by (le, type) (rate(%[1]s_bucket[$__rate_interval])))`,
+			Labels: []string{"type"},
+			Legend: "{{type}}",
+		}},
+	},
+	{
+		Title: "Update duration", Type: "timeseries", Unit: "s", Width: 12,
+		Queries: []dashboardQuery{
+			{
+				Metric: UpdateDuration,
+				Expr:   `histogram_quantile(0.5, sum by (le) (rate(%[1]s_bucket[$__rate_interval])))`,
+				Legend: "p50",
+			},
+			{
+				Metric: UpdateDuration,
+				Expr:   `histogram_quantile(0.95, sum by (le) (rate(%[1]s_bucket[$__rate_interval])))`,
+				Legend: "p95",
+			},
+		},
+	},
+	{
+		Title: "Update step duration (p95)", Type: "timeseries", Unit: "s", Width: 12,
+		Queries: []dashboardQuery{{
+			Metric: UpdateStepDuration,
+			Expr:   `histogram_quantile(0.95, sum by (le, step) (rate(%[1]s_bucket[$__rate_interval])))`,
+			Labels: []string{"step"},
+			Legend: "{{step}}",
+		}},
+	},
+	{
+		Title: "Update downtime (p95)", Type: "timeseries", Unit: "s", Width: 12,
+		Queries: []dashboardQuery{{
+			Metric: UpdateDowntime,
+			Expr:   `histogram_quantile(0.95, sum by (le) (rate(%[1]s_bucket[$__rate_interval])))`,
+			Legend: "p95",
+		}},
+	},
+	{
+		Title: "Managed services", Type: "timeseries", Unit: "short", Width: 12,
+		Queries: []dashboardQuery{
+			{Metric: ManagedTargets, Expr: `%[1]s`, Legend: "targets"},
+			{Metric: ManagedServices, Expr: `%[1]s`, Legend: "services"},
+		},
+	},
+}
+
+// descPattern pulls the name and variable labels out of a metric
+// description's string form.
+var descPattern = regexp.MustCompile(`fqName: "([^"]+)".*variableLabels: \{([^}]*)\}`)
+
+// describeMetric returns the name and labels of the metric c collects.
+func describeMetric(c prometheus.Collector) (string, map[string]bool, error) {
+	ch := make(chan *prometheus.Desc, 1)
+	c.Describe(ch)
+	close(ch)
+	desc, ok := <-ch
+	if !ok {
+		return "", nil, fmt.Errorf("collector describes no metric")
+	}
+	m := descPattern.FindStringSubmatch(desc.String())
+	if m == nil {
+		return "", nil, fmt.Errorf("unreadable metric description %s", desc)
+	}
+	labels := make(map[string]bool)
+	for _, label := range strings.Split(m[2], ",") {
+		if label != "" {
+			labels[label] = true
+		}
+	}
+	return m[1], labels, nil
+}
+
+// Dashboard returns a Grafana dashboard of Bulwark's metrics as JSON. It
+// has a Prometheus data source variable and a target variable that every
+// per-target panel filters on.
+func Dashboard(title string) ([]byte, error) {
+	if title == "" {
+		title = DefaultDashboardTitle
+	}
+	targetMetric, _, err := describeMetric(UpdatesAvailable)
+	if err != nil {
+		return nil, err
+	}
+	datasource := map[string]string{"type": "prometheus", "uid": "${datasource}"}
+
+	panels := make([]map[string]interface{}, 0, len(dashboardPanels))
+	x, y, rowHeight := 0, 0, 8
+	for i, p := range dashboardPanels {
+		targets := make([]map[string]string, 0, len(p.Queries))
+		for j, q := range p.Queries {
+			name, labels, err := describeMetric(q.Metric)
+			if err != nil {
+				return nil, fmt.Errorf("panel %q: %w", p.Title, err)
+			}
+			for _, label := range q.Labels {
+				if !labels[label] {
+					return nil, fmt.Errorf("panel %q: metric %s has no label %q", p.Title, name, label)
+				}
+			}
+			target := map[string]string{
+				"refId": string(rune('A' + j)),
+				"expr":  fmt.Sprintf(q.Expr, name),
+			}
+			if q.Legend != "" {
+				target["legendFormat"] = q.Legend
+			}
+			targets = append(targets, target)
+		}
+
+		if x+p.Width > 24 {
+			x, y = 0, y+rowHeight
+		}
+		panels = append(panels, map[string]interface{}{
+			"id":         i + 1,
+			"type":       p.Type,
+			"title":      p.Title,
+			"datasource": datasource,
+			"gridPos":    map[string]int{"x": x, "y": y, "w": p.Width, "h": rowHeight},
+			"fieldConfig": map[string]interface{}{
+				"defaults":  map[string]string{"unit": p.Unit},
+				"overrides": []interface{}{},
+			},
+			"targets": targets,
+		})
+		x += p.Width
+	}
+
+	dashboard := map[string]interface{}{
+		"title":         title,
+		"uid":           "bulwark",
+		"tags":          []string{"bulwark"},
+		"schemaVersion": 39,
+		"editable":      true,
+		"refresh":       "1m",
+		"time":          map[string]string{"from": "now-7d", "to": "now"},
+		"templating": map[string]interface{}{
+			"list": []map[string]interface{}{
+				{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+				{
+					"name":       "target",
+					"label":      "Target",
+					"type":       "query",
+					"datasource": datasource,
+					"query":      fmt.Sprintf("label_values(%s, target)", targetMetric),
+					"refresh":    2,
+					"multi":      true,
+					"includeAll": true,
+					"allValue":   ".*",
+					"current":    map[string]interface{}{"text": "All", "value": "$__all"},
+				},
+			},
+		},
+		"panels": panels,
+	}
+	return json.MarshalIndent(dashboard, "", "  ")
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDashboard(t *testing.T) {
+	raw, err := Dashboard("")
+	if err != nil {
+		t.Fatalf("Dashboard() error = %v", err)
+	}
+	var dashboard struct {
+		Title  string `json:"title"`
+		Panels []struct {
+			Title   string `json:"title"`
+			GridPos struct {
+				X, W int
+			} `json:"gridPos"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal(raw, &dashboard); err != nil {
+		t.Fatalf("dashboard is not valid JSON: %v", err)
+	}
+	if dashboard.Title != DefaultDashboardTitle {
+		t.Errorf("title = %q; want %q", dashboard.Title, DefaultDashboardTitle)
+	}
+	if len(dashboard.Panels) != len(dashboardPanels) {
+		t.Fatalf("panels = %d; want %d", len(dashboard.Panels), len(dashboardPanels))
+	}
+
+	var exprs []string
+	for _, p := range dashboard.Panels {
+		if p.GridPos.X+p.GridPos.W > 24 {
+			t.Errorf("panel %q overflows the grid", p.Title)
+		}
+		for _, target := range p.Targets {
+			exprs = append(exprs, target.Expr)
+		}
+	}
+	all := strings.Join(exprs, "\n")
+	for _, want := range []string{
+		`sum by (target) (bulwark_updates_available{target=~"$target"})`,
+		`increase(bulwark_rollbacks_total{target=~"$target"}`,
+		`bulwark_probes_total{result="failure", target=~"$target"}`,
+		`rate(bulwark_update_duration_seconds_bucket[$__rate_interval])`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("dashboard has no query with %s", want)
+		}
+	}
+}
+
+func TestDashboard_UnknownLabel(t *testing.T) {
+	saved := dashboardPanels
+	t.Cleanup(func() { dashboardPanels = saved })
+	dashboardPanels = []dashboardPanel{{
+		Title: "Rollbacks", Type: "timeseries", Width: 12,
+		Queries: []dashboardQuery{{
+			Metric: RollbacksTotal,
+			Expr:   `sum by (result) (%[1]s)`,
+			Labels: []string{"result"},
+		}},
+	}}
+
+	if _, err := Dashboard(""); err == nil || !strings.Contains(err.Error(), `no label "result"`) {
+		t.Errorf("Dashboard() error = %v; want missing label error", err)
+	}
+}
+
+func TestDescribeMetric(t *testing.T) {
+	name, labels, err := describeMetric(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "test_total",
+		Help: "Test",
+	}, []string{"a", "b"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "test_total" || !labels["a"] || !labels["b"] || len(labels) != 2 {
+		t.Errorf("describeMetric() = %q, %v", name, labels)
+	}
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import "sync"
+
+// DefaultMaxServiceLabels caps how many services get series of their own in
+// the per-service metrics.
+const DefaultMaxServiceLabels = 500
+
+// overflowLabel stands in for the target and service of every service past
+// the cap.
+const overflowLabel = "other"
+
+var serviceLabels = struct {
+	sync.Mutex
+	enabled bool
+	max     int
+	seen    map[string]struct{}
+}{
+	enabled: true,
+	max:     DefaultMaxServiceLabels,
+	seen:    make(map[string]struct{}),
+}
+
+// ConfigureServiceLabels sets whether per-service metrics carry target and
+// service labels and how many distinct services they label before the rest
+// are counted as "other". A max of zero or less lifts the cap.
+func ConfigureServiceLabels(enabled bool, max int) {
+	serviceLabels.Lock()
+	defer serviceLabels.Unlock()
+	serviceLabels.enabled = enabled
+	serviceLabels.max = max
+	serviceLabels.seen = make(map[string]struct{})
+}
+
+// ServiceLabels returns the target and service label values to record a
+// service's metrics under. They are empty when service labels are disabled
+// and "other" once the cap of distinct services is reached, so a large
+// fleet cannot blow up the number of series.
+func ServiceLabels(target, service string) (string, string) {
+	serviceLabels.Lock()
+	defer serviceLabels.Unlock()
+	if !serviceLabels.enabled {
+		return "", ""
+	}
+	key := target + "/" + service
+	if _, ok := serviceLabels.seen[key]; ok {
+		return target, service
+	}
+	if serviceLabels.max > 0 && len(serviceLabels.seen) >= serviceLabels.max {
+		return overflowLabel, overflowLabel
+	}
+	serviceLabels.seen[key] = struct{}{}
+	return target, service
+}
+
+// SetUpdatesAvailable replaces the updates-available gauges with the counts
+// of a new plan, so targets that left the plan stop being exported.
+func SetUpdatesAvailable(counts map[string]int) {
+	UpdatesAvailable.Reset()
+	for target, count := range counts {
+		UpdatesAvailable.WithLabelValues(target).Set(float64(count))
+	}
+}
@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestServiceLabels(t *testing.T) {
+	t.Cleanup(func() { ConfigureServiceLabels(true, DefaultMaxServiceLabels) })
+
+	ConfigureServiceLabels(true, 2)
+	for _, tc := range []struct {
+		target, service         string
+		wantTarget, wantService string
+	}{
+		{"app", "web", "app", "web"},
+		{"app", "db", "app", "db"},
+		{"media", "plex", "other", "other"}, // Over the cap
+		{"app", "web", "app", "web"},        // Seen before the cap was reached
+	} {
+		target, service := ServiceLabels(tc.target, tc.service)
+		if target != tc.wantTarget || service != tc.wantService {
+			t.Errorf("ServiceLabels(%q, %q) = %q, %q; want %q, %q",
+				tc.target, tc.service, target, service, tc.wantTarget, tc.wantService)
+		}
+	}
+
+	ConfigureServiceLabels(false, 2)
+	if target, service := ServiceLabels("app", "web"); target != "" || service != "" {
+		t.Errorf("disabled labels = %q, %q; want empty", target, service)
+	}
+
+	ConfigureServiceLabels(true, 0)
+	for i := 0; i < 10; i++ {
+		if target, _ := ServiceLabels("app", string(rune('a'+i))); target != "app" {
+			t.Fatalf("uncapped label = %q; want app", target)
+		}
+	}
+}
+
+func TestSetUpdatesAvailable(t *testing.T) {
+	SetUpdatesAvailable(map[string]int{"app": 2, "media": 0})
+	if got := updatesAvailable(t); len(got) != 2 || got["app"] != 2 || got["media"] != 0 {
+		t.Errorf("updates available = %v; want app 2, media 0", got)
+	}
+
+	// A target missing from the next plan is no longer exported.
+	SetUpdatesAvailable(map[string]int{"media": 1})
+	if got := updatesAvailable(t); len(got) != 1 || got["media"] != 1 {
+		t.Errorf("updates available = %v; want media 1", got)
+	}
+}
+
+// updatesAvailable gathers the exported updates-available gauges by target.
+func updatesAvailable(t *testing.T) map[string]float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string]float64)
+	for _, family := range families {
+		if family.GetName() != "bulwark_updates_available" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			values[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+		}
+	}
+	return values
+}
@@ -5,6 +5,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// updateBuckets span from half a second to about half an hour, as pulls of
+// large images over slow links take minutes.
+var updateBuckets = prometheus.ExponentialBuckets(0.5, 2, 12)
+
 var (
 	// UpdatesTotal counts completed updates by target, service, and result.
 	UpdatesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
@@ -18,11 +22,12 @@ var (
 		Help: "Total number of rollbacks performed",
 	}, []string{"target", "service"})
 
-	// ProbesTotal counts probe executions by type and result.
+	// ProbesTotal counts probe executions by type, result, target and
+	// service. Label the latter two through ServiceLabels.
 	ProbesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "bulwark_probes_total",
 		Help: "Total number of probe executions",
-	}, []string{"type", "result"})
+	}, []string{"type", "result", "target", "service"})
 
 	// ProbeDuration observes probe execution durations.
 	ProbeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
@@ -31,6 +36,29 @@ var (
 		Buckets: prometheus.DefBuckets,
 	}, []string{"type"})
 
+	// UpdateDuration observes how long whole service updates take.
+	UpdateDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "bulwark_update_duration_seconds",
+		Help:    "Time to update a service, probes included",
+		Buckets: updateBuckets,
+	})
+
+	// UpdateStepDuration observes the pull, recreate and probe steps of
+	// service updates.
+	UpdateStepDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bulwark_update_step_duration_seconds",
+		Help:    "Time spent in one step of a service update",
+		Buckets: updateBuckets,
+	}, []string{"step"})
+
+	// UpdateDowntime observes how long services were down while updated in
+	// place.
+	UpdateDowntime = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "bulwark_update_downtime_seconds",
+		Help:    "Time from stopping the old container until the update settled",
+		Buckets: updateBuckets,
+	})
+
 	// DigestFetchDuration observes registry digest fetch times.
 	DigestFetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "bulwark_digest_fetch_duration_seconds",
@@ -45,6 +73,13 @@ var (
 		Buckets: prometheus.DefBuckets,
 	})
 
+	// UpdatesAvailable tracks the pending updates per target in the latest
+	// full plan.
+	UpdatesAvailable = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "bulwark_updates_available",
+		Help: "Updates available per target in the latest plan",
+	}, []string{"target"})
+
 	// ManagedTargets tracks the current number of managed targets.
 	ManagedTargets = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "bulwark_managed_targets",
@@ -0,0 +1,272 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"gopkg.in/yaml.v3"
+)
+
+// composeService is the part of a compose service the engine simulates.
+type composeService struct {
+	Image   string      `yaml:"image"`
+	Labels  interface{} `yaml:"labels"`
+	Volumes []string    `yaml:"volumes"`
+}
+
+type composeProject struct {
+	name    string
+	dir     string
+	files   []string
+	content string
+	// services by name, with later files overriding earlier ones as
+	// compose merges them.
+	services map[string]*composeService
+	labels   map[string]map[string]string
+}
+
+func loadProject(files []string) (*composeProject, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no compose file given")
+	}
+	project := &composeProject{
+		name:     docker.ComposeProjectName(files[0]),
+		dir:      filepath.Dir(files[0]),
+		services: make(map[string]*composeService),
+		labels:   make(map[string]map[string]string),
+	}
+	var content strings.Builder
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, err
+		}
+		project.files = append(project.files, abs)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		content.Write(data)
+		var parsed struct {
+			Services map[string]composeService `yaml:"services"`
+		}
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for name, svc := range parsed.Services {
+			merged, ok := project.services[name]
+			if !ok {
+				merged = &composeService{}
+				project.services[name] = merged
+				project.labels[name] = make(map[string]string)
+			}
+			if svc.Image != "" {
+				merged.Image = svc.Image
+			}
+			if len(svc.Volumes) > 0 {
+				merged.Volumes = svc.Volumes
+			}
+			for key, value := range labelMap(svc.Labels) {
+				project.labels[name][key] = value
+			}
+		}
+	}
+	project.content = content.String()
+	return project, nil
+}
+
+// labelMap reads compose labels in either their map or their list form.
+func labelMap(labels interface{}) map[string]string {
+	out := make(map[string]string)
+	switch v := labels.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			out[key] = fmt.Sprint(value)
+		}
+	case []interface{}:
+		for _, item := range v {
+			key, value, _ := strings.Cut(fmt.Sprint(item), "=")
+			out[key] = value
+		}
+	}
+	return out
+}
+
+// containerLabels are the labels compose gives a container of service.
+func (p *composeProject) containerLabels(service string, number int) map[string]string {
+	labels := copyLabels(p.labels[service])
+	labels[docker.ProjectLabel] = p.name
+	labels["com.docker.compose.service"] = service
+	labels["com.docker.compose.project.config_files"] = strings.Join(p.files, ",")
+	labels["com.docker.compose.project.working_dir"] = p.dir
+	labels["com.docker.compose.container-number"] = strconv.Itoa(number)
+	return labels
+}
+
+// Compose runs a compose command against the engine. It is a
+// docker.ComposeFunc and understands the commands Bulwark runs: pull, up
+// (with --scale), down, ps and config. Builds are not simulated.
+func (e *Engine) Compose(ctx context.Context, files, args []string) (string, error) {
+	project, err := loadProject(files)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("no compose command given")
+	}
+	verb, args := args[0], args[1:]
+	services, scale := composeOperands(args)
+	if len(services) == 0 {
+		for name := range project.services {
+			services = append(services, name)
+		}
+		sort.Strings(services)
+	}
+	for _, name := range services {
+		if _, ok := project.services[name]; !ok && verb != "ps" {
+			return "", fmt.Errorf("no such service: %s", name)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out strings.Builder
+	switch verb {
+	case "pull":
+		for _, name := range services {
+			img, err := e.pull(project.services[name].Image)
+			if err != nil {
+				return out.String(), err
+			}
+			fmt.Fprintf(&out, " %s Pulled %s\n", name, img.digest)
+		}
+	case "up":
+		for _, name := range services {
+			replicas := 1
+			if n, ok := scale[name]; ok {
+				replicas = n
+			}
+			if err := e.up(project, name, replicas, &out); err != nil {
+				return out.String(), err
+			}
+		}
+	case "down":
+		for _, c := range e.projectContainers(project.name, "") {
+			delete(e.containers, c.id)
+			fmt.Fprintf(&out, " Container %s Removed\n", c.name)
+		}
+	case "ps":
+		running := make(map[string]bool)
+		for _, c := range e.projectContainers(project.name, "") {
+			if c.running {
+				running[c.labels["com.docker.compose.service"]] = true
+			}
+		}
+		names := make([]string, 0, len(running))
+		for name := range running {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(&out, name)
+		}
+	case "config":
+		if slices.Contains(args, "--services") {
+			names := make([]string, 0, len(project.services))
+			for name := range project.services {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Fprintln(&out, strings.Join(names, "\n"))
+			break
+		}
+		out.WriteString(project.content)
+	default:
+		return "", fmt.Errorf("compose %s is not simulated", verb)
+	}
+	return out.String(), nil
+}
+
+// composeOperands splits command arguments into service names and the
+// replica counts of --scale, skipping other flags and their values.
+func composeOperands(args []string) ([]string, map[string]int) {
+	var services []string
+	scale := make(map[string]int)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--scale" && i+1 < len(args):
+			i++
+			name, n, _ := strings.Cut(args[i], "=")
+			scale[name], _ = strconv.Atoi(n)
+		case arg == "--timeout" || arg == "-t" || arg == "--filter" || arg == "--platform" || arg == "--pull":
+			i++
+		case strings.HasPrefix(arg, "-"):
+		default:
+			services = append(services, arg)
+		}
+	}
+	return services, scale
+}
+
+// projectContainers lists the containers of a project, or of one of its
+// services, by container number. The engine's lock must be held.
+func (e *Engine) projectContainers(project, service string) []*mockContainer {
+	var list []*mockContainer
+	for _, c := range e.containers {
+		if c.labels[docker.ProjectLabel] != project {
+			continue
+		}
+		if service != "" && c.labels["com.docker.compose.service"] != service {
+			continue
+		}
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
+
+// up brings service to replicas containers of its current image: existing
+// containers of another image are recreated, surplus ones removed. The
+// engine's lock must be held.
+func (e *Engine) up(project *composeProject, service string, replicas int, out *strings.Builder) error {
+	ref := project.services[service].Image
+	img := e.findImage(ref)
+	if img == nil {
+		var err error
+		if img, err = e.pull(ref); err != nil {
+			return err
+		}
+	}
+	existing := make(map[string]*mockContainer)
+	for _, c := range e.projectContainers(project.name, service) {
+		existing[c.name] = c
+	}
+	for n := 1; n <= replicas; n++ {
+		name := fmt.Sprintf("%s-%s-%d", project.name, service, n)
+		if c, ok := existing[name]; ok {
+			delete(existing, name)
+			if c.imageID == img.id && c.image == ref && c.running {
+				fmt.Fprintf(out, " Container %s Running\n", name)
+				continue
+			}
+			delete(e.containers, c.id)
+		}
+		if _, err := e.run(name, ref, project.containerLabels(service, n), project.services[service].Volumes); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, " Container %s Started\n", name)
+	}
+	for _, c := range existing {
+		delete(e.containers, c.id)
+		fmt.Fprintf(out, " Container %s Removed\n", c.name)
+	}
+	return nil
+}
@@ -0,0 +1,581 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/system"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/itsmrshow/bulwark/internal/registry"
+)
+
+// Engine simulates a Docker engine in memory. It implements the calls of
+// client.APIClient that Bulwark makes; the embedded interface is nil, so
+// any other call panics, which points at the method to add here.
+type Engine struct {
+	client.APIClient
+
+	registry *Registry
+	dataRoot string
+
+	mu         sync.Mutex
+	containers map[string]*mockContainer // ID -> container
+	images     map[string]*mockImage     // ID -> image
+	tags       map[string]string         // "registry/repository:tag" -> image ID
+	seq        int
+}
+
+type mockContainer struct {
+	id       string
+	name     string
+	image    string // reference the container was started from
+	imageID  string
+	labels   map[string]string
+	volumes  []string
+	running  bool
+	healthy  bool
+	created  time.Time
+	started  time.Time
+	restarts int
+}
+
+type mockImage struct {
+	id         string
+	repository string // registry/repository, for repo digests
+	digest     string
+	tags       []string
+	created    time.Time
+}
+
+// NewEngine returns an engine without containers. Images are pulled from
+// reg, and dataRoot is reported as the engine's data root, for disk space
+// checks.
+func NewEngine(reg *Registry, dataRoot string) *Engine {
+	return &Engine{
+		registry:   reg,
+		dataRoot:   dataRoot,
+		containers: make(map[string]*mockContainer),
+		images:     make(map[string]*mockImage),
+		tags:       make(map[string]string),
+	}
+}
+
+func notFound(format string, args ...interface{}) error {
+	return errdefs.NotFound(fmt.Errorf(format, args...))
+}
+
+// newID returns a 64-character hex ID, unique within the engine.
+func (e *Engine) newID(seed string) string {
+	e.seq++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", seed, e.seq)))
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureImage returns the local image of ref resolved to digest, creating
+// it, and tags it with ref unless ref is pinned to a digest. The engine's
+// lock must be held.
+func (e *Engine) ensureImage(ref, digest string) (*mockImage, error) {
+	parsed, err := registry.ParseImageReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference format: %w", err)
+	}
+	repository := parsed.Registry + "/" + parsed.Repository
+	sum := sha256.Sum256([]byte(digest))
+	id := "sha256:" + hex.EncodeToString(sum[:])
+	img, ok := e.images[id]
+	if !ok {
+		img = &mockImage{id: id, repository: repository, digest: digest, created: time.Now().UTC()}
+		e.images[id] = img
+	}
+	if parsed.Digest == "" {
+		key := repository + ":" + parsed.Tag
+		if previous, ok := e.tags[key]; ok && previous != id {
+			if old := e.images[previous]; old != nil {
+				old.tags = removeString(old.tags, ref)
+			}
+		}
+		e.tags[key] = id
+		if !containsString(img.tags, ref) {
+			img.tags = append(img.tags, ref)
+		}
+	}
+	return img, nil
+}
+
+// pull resolves ref with the registry and keeps its image. The engine's
+// lock must be held.
+func (e *Engine) pull(ref string) (*mockImage, error) {
+	parsed, err := registry.ParseImageReference(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference format: %w", err)
+	}
+	digest := parsed.Digest
+	if digest == "" {
+		latest, ok := e.registry.Latest(ref)
+		if !ok {
+			return nil, notFound("pull access denied for %s, repository does not exist", ref)
+		}
+		digest = latest
+	}
+	return e.ensureImage(ref, digest)
+}
+
+// findImage looks ref up by ID, by repo digest or by tag. The engine's
+// lock must be held.
+func (e *Engine) findImage(ref string) *mockImage {
+	if img, ok := e.images[ref]; ok {
+		return img
+	}
+	if img, ok := e.images["sha256:"+ref]; ok {
+		return img
+	}
+	parsed, err := registry.ParseImageReference(ref)
+	if err != nil {
+		return nil
+	}
+	repository := parsed.Registry + "/" + parsed.Repository
+	if parsed.Digest != "" {
+		for _, img := range e.images {
+			if img.repository == repository && img.digest == parsed.Digest {
+				return img
+			}
+		}
+		return nil
+	}
+	return e.images[e.tags[repository+":"+parsed.Tag]]
+}
+
+// findContainer looks a container up by ID, ID prefix or name. The
+// engine's lock must be held.
+func (e *Engine) findContainer(ref string) *mockContainer {
+	ref = strings.TrimPrefix(ref, "/")
+	if c, ok := e.containers[ref]; ok {
+		return c
+	}
+	for _, c := range e.containers {
+		if c.name == ref || (len(ref) >= 12 && strings.HasPrefix(c.id, ref)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// run starts a container from ref, pulling the image when it is not
+// local. The engine's lock must be held.
+func (e *Engine) run(name, ref string, labels map[string]string, volumes []string) (*mockContainer, error) {
+	img := e.findImage(ref)
+	if img == nil {
+		var err error
+		if img, err = e.pull(ref); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now().UTC()
+	c := &mockContainer{
+		id:      e.newID(name),
+		name:    name,
+		image:   ref,
+		imageID: img.id,
+		labels:  labels,
+		volumes: volumes,
+		running: true,
+		healthy: !e.registry.Unhealthy(img.digest),
+		created: now,
+		started: now,
+	}
+	e.containers[c.id] = c
+	return c, nil
+}
+
+// repoDigest is the repo digest docker reports for img, with Docker Hub's
+// registry left out as docker does.
+func (img *mockImage) repoDigest() string {
+	repository := strings.TrimPrefix(img.repository, "docker.io/")
+	return repository + "@" + img.digest
+}
+
+// Ping reports the engine as reachable.
+func (e *Engine) Ping(ctx context.Context) (types.Ping, error) {
+	return types.Ping{APIVersion: "1.44", OSType: "linux"}, nil
+}
+
+// Close does nothing: the engine lives as long as the process.
+func (e *Engine) Close() error {
+	return nil
+}
+
+// Info describes the simulated engine.
+func (e *Engine) Info(ctx context.Context) (system.Info, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	running := 0
+	for _, c := range e.containers {
+		if c.running {
+			running++
+		}
+	}
+	return system.Info{
+		ID:                "bulwark-mock",
+		Name:              "bulwark-mock",
+		ServerVersion:     "mock",
+		OperatingSystem:   "Simulated engine",
+		OSType:            "linux",
+		Architecture:      "x86_64",
+		DockerRootDir:     e.dataRoot,
+		Containers:        len(e.containers),
+		ContainersRunning: running,
+		Images:            len(e.images),
+		NCPU:              4,
+		MemTotal:          8 << 30,
+	}, nil
+}
+
+// ContainerList lists the running containers, or all with options.All.
+func (e *Engine) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	list := make([]types.Container, 0, len(e.containers))
+	for _, c := range e.containers {
+		if !c.running && !options.All {
+			continue
+		}
+		state, status := "running", "Up "+time.Since(c.started).Round(time.Second).String()
+		if !c.running {
+			state, status = "exited", "Exited (0)"
+		}
+		list = append(list, types.Container{
+			ID:      c.id,
+			Names:   []string{"/" + c.name},
+			Image:   c.image,
+			ImageID: c.imageID,
+			Labels:  copyLabels(c.labels),
+			State:   state,
+			Status:  status,
+			Created: c.created.Unix(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Names[0] < list[j].Names[0] })
+	if options.Limit > 0 && len(list) > options.Limit {
+		list = list[:options.Limit]
+	}
+	return list, nil
+}
+
+// ContainerInspect describes a container. Containers of an unhealthy
+// release report an unhealthy health check.
+func (e *Engine) ContainerInspect(ctx context.Context, ref string) (types.ContainerJSON, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.findContainer(ref)
+	if c == nil {
+		return types.ContainerJSON{}, notFound("No such container: %s", ref)
+	}
+	status, health := "running", "healthy"
+	if !c.running {
+		status = "exited"
+	}
+	if !c.healthy {
+		health = "unhealthy"
+	}
+	failing := 0
+	if !c.healthy {
+		failing = 3
+	}
+	inspect := types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:           c.id,
+			Created:      c.created.Format(time.RFC3339Nano),
+			Image:        c.imageID,
+			Name:         "/" + c.name,
+			RestartCount: c.restarts,
+			State: &types.ContainerState{
+				Status:    status,
+				Running:   c.running,
+				StartedAt: c.started.Format(time.RFC3339Nano),
+				Health:    &types.Health{Status: health, FailingStreak: failing},
+			},
+		},
+		Config: &container.Config{
+			Image:       c.image,
+			Labels:      copyLabels(c.labels),
+			Healthcheck: &container.HealthConfig{Test: []string{"CMD", "true"}, Interval: 30 * time.Second, Retries: 3},
+		},
+		NetworkSettings: &types.NetworkSettings{
+			DefaultNetworkSettings: types.DefaultNetworkSettings{IPAddress: "172.18.0.2"},
+		},
+	}
+	for _, v := range c.volumes {
+		name, destination, _ := strings.Cut(v, ":")
+		inspect.Mounts = append(inspect.Mounts, types.MountPoint{
+			Type:        mount.TypeVolume,
+			Name:        name,
+			Source:      e.dataRoot + "/volumes/" + name + "/_data",
+			Destination: destination,
+			RW:          true,
+		})
+	}
+	return inspect, nil
+}
+
+// ContainerLogs returns a few lines a container would log: containers of
+// an unhealthy release log an error instead of becoming ready.
+func (e *Engine) ContainerLogs(ctx context.Context, ref string, options container.LogsOptions) (io.ReadCloser, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.findContainer(ref)
+	if c == nil {
+		return nil, notFound("No such container: %s", ref)
+	}
+	// Containers without a TTY multiplex their streams, as compose starts
+	// them.
+	var logs bytes.Buffer
+	stdout := stdcopy.NewStdWriter(&logs, stdcopy.Stdout)
+	stderr := stdcopy.NewStdWriter(&logs, stdcopy.Stderr)
+	started := c.started.Format(time.RFC3339)
+	if options.ShowStdout {
+		fmt.Fprintf(stdout, "%s starting %s\n", started, c.image)
+		if c.healthy {
+			fmt.Fprintf(stdout, "%s ready to accept connections\n", started)
+		}
+	}
+	if options.ShowStderr && !c.healthy {
+		fmt.Fprintf(stderr, "%s ERROR failed to load configuration\n", started)
+	}
+	return io.NopCloser(&logs), nil
+}
+
+// ContainerRestart restarts a container.
+func (e *Engine) ContainerRestart(ctx context.Context, ref string, options container.StopOptions) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.findContainer(ref)
+	if c == nil {
+		return notFound("No such container: %s", ref)
+	}
+	c.running = true
+	c.started = time.Now().UTC()
+	return nil
+}
+
+// ContainerStop stops a container.
+func (e *Engine) ContainerStop(ctx context.Context, ref string, options container.StopOptions) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.findContainer(ref)
+	if c == nil {
+		return notFound("No such container: %s", ref)
+	}
+	c.running = false
+	return nil
+}
+
+// ContainerRemove removes a container.
+func (e *Engine) ContainerRemove(ctx context.Context, ref string, options container.RemoveOptions) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.findContainer(ref)
+	if c == nil {
+		return notFound("No such container: %s", ref)
+	}
+	if c.running && !options.Force {
+		return errdefs.Conflict(fmt.Errorf("cannot remove running container %s", c.name))
+	}
+	delete(e.containers, c.id)
+	return nil
+}
+
+// ContainerStats samples a container's resource use. Usage is steady,
+// derived from the container's ID.
+func (e *Engine) ContainerStats(ctx context.Context, ref string, stream bool) (types.ContainerStats, error) {
+	e.mu.Lock()
+	c := e.findContainer(ref)
+	e.mu.Unlock()
+	if c == nil {
+		return types.ContainerStats{}, notFound("No such container: %s", ref)
+	}
+	var stats types.StatsJSON
+	stats.ID, stats.Name = c.id, "/"+c.name
+	stats.MemoryStats = types.MemoryStats{Usage: uint64(64+int(c.id[0])%128) << 20, Limit: 8 << 30}
+	stats.CPUStats = types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 2_000_000}, SystemUsage: 200_000_000, OnlineCPUs: 4}
+	stats.PreCPUStats = types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 1_000_000}, SystemUsage: 100_000_000}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return types.ContainerStats{}, err
+	}
+	return types.ContainerStats{Body: io.NopCloser(bytes.NewReader(data)), OSType: "linux"}, nil
+}
+
+// ImagePull pulls ref from the simulated registry.
+func (e *Engine) ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	img, err := e.pull(ref)
+	if err != nil {
+		return nil, err
+	}
+	status := fmt.Sprintf("{\"status\":\"Digest: %s\"}\n{\"status\":\"Status: Downloaded newer image for %s\"}\n", img.digest, ref)
+	return io.NopCloser(strings.NewReader(status)), nil
+}
+
+// ImageTag tags a local image.
+func (e *Engine) ImageTag(ctx context.Context, source, target string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	img := e.findImage(source)
+	if img == nil {
+		return notFound("No such image: %s", source)
+	}
+	_, err := e.ensureImage(target, img.digest)
+	return err
+}
+
+// ImageInspectWithRaw describes a local image.
+func (e *Engine) ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	img := e.findImage(ref)
+	if img == nil {
+		return types.ImageInspect{}, nil, notFound("No such image: %s", ref)
+	}
+	inspect := types.ImageInspect{
+		ID:           img.id,
+		RepoTags:     append([]string(nil), img.tags...),
+		RepoDigests:  []string{img.repoDigest()},
+		Created:      img.created.Format(time.RFC3339Nano),
+		Size:         img.size(),
+		Os:           "linux",
+		Architecture: "amd64",
+		Config:       &container.Config{},
+	}
+	raw, err := json.Marshal(inspect)
+	return inspect, raw, err
+}
+
+func (img *mockImage) size() int64 {
+	return 50_000_000 + int64(img.digest[len(img.digest)-1])*100_000
+}
+
+// ImageList lists the local images.
+func (e *Engine) ImageList(ctx context.Context, options types.ImageListOptions) ([]image.Summary, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	list := make([]image.Summary, 0, len(e.images))
+	for _, img := range e.images {
+		list = append(list, image.Summary{
+			ID:          img.id,
+			RepoTags:    append([]string(nil), img.tags...),
+			RepoDigests: []string{img.repoDigest()},
+			Created:     img.created.Unix(),
+			Size:        img.size(),
+			Containers:  int64(e.usedBy(img.id)),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+// usedBy counts the containers of image ID. The engine's lock must be held.
+func (e *Engine) usedBy(id string) int {
+	n := 0
+	for _, c := range e.containers {
+		if c.imageID == id {
+			n++
+		}
+	}
+	return n
+}
+
+// ImageRemove removes a local image no container uses.
+func (e *Engine) ImageRemove(ctx context.Context, ref string, options types.ImageRemoveOptions) ([]image.DeleteResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	img := e.findImage(ref)
+	if img == nil {
+		return nil, notFound("No such image: %s", ref)
+	}
+	if e.usedBy(img.id) > 0 && !options.Force {
+		return nil, errdefs.Conflict(fmt.Errorf("image %s is being used by a container", ref))
+	}
+	e.removeImage(img)
+	return []image.DeleteResponse{{Deleted: img.id}}, nil
+}
+
+// removeImage drops img and its tags. The engine's lock must be held.
+func (e *Engine) removeImage(img *mockImage) {
+	for key, id := range e.tags {
+		if id == img.id {
+			delete(e.tags, key)
+		}
+	}
+	delete(e.images, img.id)
+}
+
+// ImagesPrune removes the images no container uses and no tag names.
+func (e *Engine) ImagesPrune(ctx context.Context, pruneFilter filters.Args) (types.ImagesPruneReport, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var report types.ImagesPruneReport
+	for _, img := range e.images {
+		if len(img.tags) > 0 || e.usedBy(img.id) > 0 {
+			continue
+		}
+		report.ImagesDeleted = append(report.ImagesDeleted, image.DeleteResponse{Deleted: img.id})
+		report.SpaceReclaimed += uint64(img.size())
+		e.removeImage(img)
+	}
+	return report, nil
+}
+
+// NetworkList lists no networks; compose networks are not simulated.
+func (e *Engine) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	return []types.NetworkResource{}, nil
+}
+
+// VolumeList lists no volumes; volumes only appear as container mounts.
+func (e *Engine) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	return volume.ListResponse{}, nil
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	copied := make(map[string]string, len(labels))
+	for key, value := range labels {
+		copied[key] = value
+	}
+	return copied
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	kept := list[:0]
+	for _, item := range list {
+		if item != s {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,185 @@
+// Package mock simulates a Docker host for development, demos and
+// end-to-end tests: an in-memory engine behind Bulwark's Docker client, a
+// compose implementation running against it, and a registry answering
+// Bulwark's digest lookups. Start seeds all three from a Scenario and
+// points the docker and registry packages at them.
+package mock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is the set of compose projects a simulated host runs.
+type Scenario struct {
+	Targets []Target
+}
+
+// Target is a compose project, written to <root>/<name>/compose.yaml.
+type Target struct {
+	Name     string
+	Services []Service
+}
+
+// Service is a compose service and the state of its image.
+type Service struct {
+	Name    string
+	Image   string
+	Labels  map[string]string
+	Volumes []string
+
+	// Running is the release the service's container runs. Latest, when
+	// set, is a newer release the registry has, so the service is
+	// outdated; UnhealthyLatest makes its containers fail their health
+	// check.
+	Running         string
+	Latest          string
+	UnhealthyLatest bool
+
+	// LookupStatus makes digest lookups of the image fail with this HTTP
+	// status, e.g. 429 for a rate limit.
+	LookupStatus int
+}
+
+// Environment is a started simulation.
+type Environment struct {
+	// Root is the directory holding the scenario's compose projects, for
+	// discovery to scan.
+	Root     string
+	Engine   *Engine
+	Registry *Registry
+}
+
+func enabled(labels map[string]string) map[string]string {
+	labels["bulwark.enabled"] = "true"
+	return labels
+}
+
+// DefaultScenario is a small home lab: media, web and monitoring stacks
+// with services that are up to date, outdated at every risk level, one
+// whose update fails its health check and is rolled back, and one whose
+// registry is rate limited.
+func DefaultScenario() Scenario {
+	dockerProbe := func(labels map[string]string) map[string]string {
+		labels["bulwark.probe.type"] = "docker"
+		return enabled(labels)
+	}
+	return Scenario{Targets: []Target{
+		{Name: "media", Services: []Service{
+			{Name: "sonarr", Image: "lscr.io/linuxserver/sonarr:latest", Running: "4.0.9", Latest: "4.0.10",
+				Labels: dockerProbe(map[string]string{"bulwark.policy": "safe"})},
+			{Name: "radarr", Image: "lscr.io/linuxserver/radarr:latest", Running: "5.11.0",
+				Labels: dockerProbe(map[string]string{"bulwark.policy": "safe"})},
+			{Name: "jellyfin", Image: "jellyfin/jellyfin:10.9", Running: "10.9.10", Latest: "10.9.11",
+				Labels: dockerProbe(map[string]string{"bulwark.policy": "notify"})},
+		}},
+		{Name: "web", Services: []Service{
+			{Name: "proxy", Image: "nginx:1.27-alpine", Running: "1.27.1", Latest: "1.27.2", UnhealthyLatest: true,
+				Labels: enabled(map[string]string{
+					"bulwark.policy":                 "safe",
+					"bulwark.probe.type":             "log",
+					"bulwark.probe.log_pattern":      "ready to accept connections",
+					"bulwark.probe.log_fail_pattern": "ERROR",
+				})},
+			{Name: "app", Image: "ghcr.io/acme/app:stable", Running: "2.3.0",
+				Labels: dockerProbe(map[string]string{"bulwark.policy": "safe"})},
+			{Name: "db", Image: "postgres:16", Running: "16.3", Latest: "16.4", Volumes: []string{"pgdata:/var/lib/postgresql/data"},
+				Labels: dockerProbe(map[string]string{"bulwark.policy": "safe", "bulwark.tier": "stateful"})},
+		}},
+		{Name: "monitoring", Services: []Service{
+			{Name: "grafana", Image: "grafana/grafana:11.2.0", Running: "11.2.0",
+				Labels: dockerProbe(map[string]string{"bulwark.policy": "safe"})},
+			{Name: "prometheus", Image: "prom/prometheus:latest", Running: "2.54.1", Latest: "2.55.0", LookupStatus: 429,
+				Labels: dockerProbe(map[string]string{"bulwark.policy": "safe"})},
+			{Name: "uptime-kuma", Image: "louislam/uptime-kuma:1", Running: "1.23.13", Latest: "1.23.14",
+				Labels: enabled(map[string]string{"bulwark.policy": "safe"})},
+		}},
+	}}
+}
+
+// Start writes the compose projects of scenario below dir, starts their
+// containers on a new engine, publishes their releases to a new registry
+// and makes the docker and registry packages use both. Call Stop to
+// restore the real ones.
+func Start(dir string, scenario Scenario) (*Environment, error) {
+	env := &Environment{
+		Root:     filepath.Join(dir, "stacks"),
+		Registry: NewRegistry(),
+	}
+	dataRoot := filepath.Join(dir, "engine")
+	if err := os.MkdirAll(dataRoot, 0o755); err != nil {
+		return nil, err
+	}
+	env.Engine = NewEngine(env.Registry, dataRoot)
+
+	ctx := context.Background()
+	for _, target := range scenario.Targets {
+		path, err := writeComposeFile(env.Root, target)
+		if err != nil {
+			return nil, err
+		}
+		for _, svc := range target.Services {
+			if _, err := env.Registry.Publish(svc.Image, svc.Running, false); err != nil {
+				return nil, fmt.Errorf("%s/%s: %w", target.Name, svc.Name, err)
+			}
+		}
+		if _, err := env.Engine.Compose(ctx, []string{path}, []string{"up", "-d"}); err != nil {
+			return nil, fmt.Errorf("failed to start %s: %w", target.Name, err)
+		}
+		for _, svc := range target.Services {
+			if svc.Latest != "" {
+				if _, err := env.Registry.Publish(svc.Image, svc.Latest, svc.UnhealthyLatest); err != nil {
+					return nil, err
+				}
+			}
+			if svc.LookupStatus != 0 {
+				if err := env.Registry.Fail(svc.Image, svc.LookupStatus); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	docker.UseEngine(env.Engine)
+	docker.UseCompose(env.Engine.Compose)
+	registry.UseTransport(env.Registry)
+	return env, nil
+}
+
+// Stop makes the docker and registry packages use the real Docker engine
+// and registries again.
+func (env *Environment) Stop() {
+	docker.UseEngine(nil)
+	docker.UseCompose(nil)
+	registry.UseTransport(nil)
+}
+
+func writeComposeFile(root string, target Target) (string, error) {
+	services := make(map[string]interface{}, len(target.Services))
+	for _, svc := range target.Services {
+		service := map[string]interface{}{"image": svc.Image}
+		if len(svc.Labels) > 0 {
+			service["labels"] = svc.Labels
+		}
+		if len(svc.Volumes) > 0 {
+			service["volumes"] = svc.Volumes
+		}
+		services[svc.Name] = service
+	}
+	data, err := yaml.Marshal(map[string]interface{}{"services": services})
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(root, target.Name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "compose.yaml")
+	return path, os.WriteFile(path, data, 0o644)
+}
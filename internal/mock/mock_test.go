@@ -0,0 +1,114 @@
+package mock
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/docker"
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func startDefault(t *testing.T) (*Environment, *docker.Client, []state.Target) {
+	t.Helper()
+	env, err := Start(t.TempDir(), DefaultScenario())
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(env.Stop)
+
+	client, err := docker.NewClient()
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	return env, client, discover(t, client, env.Root)
+}
+
+func discover(t *testing.T, client *docker.Client, root string) []state.Target {
+	t.Helper()
+	logger := logging.New(logging.Config{Level: "error"})
+	targets, err := discovery.NewDiscoverer(logger, client).Discover(context.Background(), root)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+	return targets
+}
+
+func findService(t *testing.T, targets []state.Target, target, service string) (*state.Target, *state.Service) {
+	t.Helper()
+	for i := range targets {
+		if targets[i].Name != target {
+			continue
+		}
+		for j := range targets[i].Services {
+			if targets[i].Services[j].Name == service {
+				return &targets[i], &targets[i].Services[j]
+			}
+		}
+	}
+	t.Fatalf("service %s/%s not discovered", target, service)
+	return nil, nil
+}
+
+func TestStartDiscoversScenario(t *testing.T) {
+	env, _, targets := startDefault(t)
+	if len(targets) != 3 {
+		t.Fatalf("expected 3 targets, got %d", len(targets))
+	}
+
+	logger := logging.New(logging.Config{Level: "error"})
+	reg := registry.NewClient(logger)
+	ctx := context.Background()
+
+	_, radarr := findService(t, targets, "media", "radarr")
+	remote, err := reg.FetchDigest(ctx, radarr.Image)
+	if err != nil {
+		t.Fatalf("FetchDigest failed: %v", err)
+	}
+	if radarr.CurrentDigest == "" || remote != radarr.CurrentDigest {
+		t.Errorf("radarr should be up to date: running %q, registry %q", radarr.CurrentDigest, remote)
+	}
+
+	_, sonarr := findService(t, targets, "media", "sonarr")
+	latest, _ := env.Registry.Latest(sonarr.Image)
+	if sonarr.CurrentDigest == latest {
+		t.Error("sonarr should be outdated")
+	}
+
+	_, prometheus := findService(t, targets, "monitoring", "prometheus")
+	if _, err := reg.FetchDigest(ctx, prometheus.Image); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Errorf("expected the scripted rate limit, got %v", err)
+	}
+}
+
+func TestUpdateAndRollback(t *testing.T) {
+	env, client, targets := startDefault(t)
+	logger := logging.New(logging.Config{Level: "error"})
+	exec := executor.NewExecutor(client, policy.NewEngine(logger), state.NewMemoryStore(), logger, false)
+	ctx := context.Background()
+
+	target, sonarr := findService(t, targets, "media", "sonarr")
+	latest, _ := env.Registry.Latest(sonarr.Image)
+	if result := exec.ExecuteUpdate(ctx, target, sonarr, latest); !result.Success {
+		t.Fatalf("sonarr update failed: %s", result.ErrorMessage)
+	}
+	if _, after := findService(t, discover(t, client, env.Root), "media", "sonarr"); after.CurrentDigest != latest {
+		t.Errorf("sonarr runs %s after the update, want %s", after.CurrentDigest, latest)
+	}
+
+	target, proxy := findService(t, targets, "web", "proxy")
+	previous := proxy.CurrentDigest
+	latest, _ = env.Registry.Latest(proxy.Image)
+	result := exec.ExecuteUpdate(ctx, target, proxy, latest)
+	if result.Success || !result.RollbackPerformed {
+		t.Fatalf("expected the unhealthy proxy release to be rolled back, got %+v", result)
+	}
+	if _, after := findService(t, discover(t, client, env.Root), "web", "proxy"); after.CurrentDigest != previous {
+		t.Errorf("proxy runs %s after the rollback, want %s", after.CurrentDigest, previous)
+	}
+}
@@ -0,0 +1,257 @@
+package mock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/itsmrshow/bulwark/internal/registry"
+)
+
+const mediaTypeManifest = "application/vnd.docker.distribution.manifest.v2+json"
+
+// Registry simulates the registries of a scenario's images. It is an
+// http.RoundTripper answering the requests of Bulwark's registry client
+// in-process: each tag resolves to the latest release published for it,
+// unless a failure is scripted for the image.
+type Registry struct {
+	mu        sync.Mutex
+	images    map[string]*remoteImage // "registry/repository:tag" -> image
+	unhealthy map[string]bool         // digest -> release fails its health check
+}
+
+type remoteImage struct {
+	repository string // registry/repository
+	tag        string
+	digests    []string // every release, oldest first
+	status     int      // non-zero: lookups fail with this HTTP status
+}
+
+// NewRegistry returns a registry without images.
+func NewRegistry() *Registry {
+	return &Registry{
+		images:    make(map[string]*remoteImage),
+		unhealthy: make(map[string]bool),
+	}
+}
+
+// imageKey normalizes image to "registry/repository:tag", so "nginx" and
+// "docker.io/library/nginx:latest" are the same image.
+func imageKey(image string) (key, repository, tag string, err error) {
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return "", "", "", err
+	}
+	repository = ref.Registry + "/" + ref.Repository
+	return repository + ":" + ref.Tag, repository, ref.Tag, nil
+}
+
+// releaseDigest is the digest of the release named release of image. It is
+// derived from both, so a scenario's digests are the same on every start.
+func releaseDigest(image, release string) string {
+	sum := sha256.Sum256([]byte(image + "@" + release))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Publish makes release the latest release of image and returns its digest.
+// An unhealthy release starts containers that fail their health check, so
+// an update to it is rolled back.
+func (r *Registry) Publish(image, release string, unhealthy bool) (string, error) {
+	key, repository, tag, err := imageKey(image)
+	if err != nil {
+		return "", err
+	}
+	digest := releaseDigest(key, release)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	img, ok := r.images[key]
+	if !ok {
+		img = &remoteImage{repository: repository, tag: tag}
+		r.images[key] = img
+	}
+	img.digests = append(img.digests, digest)
+	if unhealthy {
+		r.unhealthy[digest] = true
+	}
+	return digest, nil
+}
+
+// Fail makes lookups of image fail with the HTTP status, e.g. 429 for a
+// rate limit; 0 lets them succeed again.
+func (r *Registry) Fail(image string, status int) error {
+	key, repository, tag, err := imageKey(image)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	img, ok := r.images[key]
+	if !ok {
+		img = &remoteImage{repository: repository, tag: tag}
+		r.images[key] = img
+	}
+	img.status = status
+	return nil
+}
+
+// Latest returns the digest image's tag resolves to, if any release of it
+// was published.
+func (r *Registry) Latest(image string) (string, bool) {
+	key, _, _, err := imageKey(image)
+	if err != nil {
+		return "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	img, ok := r.images[key]
+	if !ok || len(img.digests) == 0 {
+		return "", false
+	}
+	return img.digests[len(img.digests)-1], true
+}
+
+// Unhealthy reports whether digest is a release that fails its health check.
+func (r *Registry) Unhealthy(digest string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.unhealthy[digest]
+}
+
+// RoundTrip answers a registry request: token requests, manifests by tag
+// or digest, and tag lists. Anything else is not found.
+func (r *Registry) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	host := req.URL.Host
+	if host == "registry-1.docker.io" {
+		host = "docker.io"
+	}
+	path := req.URL.Path
+
+	switch {
+	case host == "auth.docker.io" || strings.HasSuffix(path, "/token"):
+		return jsonResponse(req, http.StatusOK, map[string]interface{}{"token": "mock", "expires_in": 300})
+	case path == "/v2/" || path == "/v2":
+		return jsonResponse(req, http.StatusOK, map[string]interface{}{})
+	case strings.HasPrefix(path, "/v2/") && strings.Contains(path, "/manifests/"):
+		repository, reference, _ := strings.Cut(strings.TrimPrefix(path, "/v2/"), "/manifests/")
+		return r.manifest(req, host+"/"+repository, reference)
+	case strings.HasPrefix(path, "/v2/") && strings.HasSuffix(path, "/tags/list"):
+		repository := strings.TrimSuffix(strings.TrimPrefix(path, "/v2/"), "/tags/list")
+		return r.tags(req, host, repository)
+	}
+	return errorResponse(req, http.StatusNotFound, "NAME_UNKNOWN", "repository name not known to registry")
+}
+
+func (r *Registry) manifest(req *http.Request, repository, reference string) (*http.Response, error) {
+	r.mu.Lock()
+	var digest string
+	status := 0
+	for _, img := range r.images {
+		if img.repository != repository {
+			continue
+		}
+		if img.tag == reference {
+			status = img.status
+			if len(img.digests) > 0 {
+				digest = img.digests[len(img.digests)-1]
+			}
+			break
+		}
+		for _, d := range img.digests {
+			if d == reference {
+				digest = d
+			}
+		}
+	}
+	r.mu.Unlock()
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return errorResponse(req, status, "UNAUTHORIZED", "authentication required")
+	case status == http.StatusTooManyRequests:
+		return errorResponse(req, status, "TOOMANYREQUESTS", "simulated rate limit")
+	case status != 0:
+		return errorResponse(req, status, "UNKNOWN", "simulated registry failure")
+	case digest == "":
+		return errorResponse(req, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown")
+	}
+
+	// Sizes are derived from the digest, so releases differ in size the
+	// way real ones do.
+	seed := int64(digest[len(digest)-1]) + int64(digest[len(digest)-2])<<8
+	manifest := map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     mediaTypeManifest,
+		"config": map[string]interface{}{
+			"mediaType": "application/vnd.docker.container.image.v1+json",
+			"digest":    releaseDigest(digest, "config"),
+			"size":      1469,
+		},
+		"layers": []map[string]interface{}{
+			{"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "digest": releaseDigest(digest, "base"), "size": 3_400_000},
+			{"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "digest": releaseDigest(digest, "app"), "size": 20_000_000 + seed*1_000},
+		},
+	}
+	resp, err := jsonResponse(req, http.StatusOK, manifest)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Set("Content-Type", mediaTypeManifest)
+	resp.Header.Set("Docker-Content-Digest", digest)
+	return resp, nil
+}
+
+func (r *Registry) tags(req *http.Request, host, repository string) (*http.Response, error) {
+	r.mu.Lock()
+	var tags []string
+	for _, img := range r.images {
+		if img.repository == host+"/"+repository && len(img.digests) > 0 {
+			tags = append(tags, img.tag)
+		}
+	}
+	r.mu.Unlock()
+	if len(tags) == 0 {
+		return errorResponse(req, http.StatusNotFound, "NAME_UNKNOWN", "repository name not known to registry")
+	}
+	sort.Strings(tags)
+	return jsonResponse(req, http.StatusOK, map[string]interface{}{"name": repository, "tags": tags})
+}
+
+func jsonResponse(req *http.Request, status int, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	if req.Method == http.MethodHead {
+		data = nil
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: int64(len(data)),
+		Request:       req,
+	}, nil
+}
+
+func errorResponse(req *http.Request, status int, code, message string) (*http.Response, error) {
+	return jsonResponse(req, status, map[string]interface{}{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
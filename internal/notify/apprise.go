@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// apprisePayload is the JSON body accepted by the Apprise API /notify endpoints.
+type apprisePayload struct {
+	URLs   string `json:"urls,omitempty"`
+	Tag    string `json:"tag,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Body   string `json:"body"`
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// AppriseNotifier sends messages to an Apprise API server.
+//
+// URL is the full notify endpoint, e.g. http://apprise:8000/notify/bulwark for a
+// stored configuration key, or http://apprise:8000/notify for stateless mode
+// combined with URLs.
+type AppriseNotifier struct {
+	URL    string
+	Tag    string
+	URLs   string
+	Client *http.Client
+}
+
+// Send sends a plain-text message to Apprise with retry.
+func (a *AppriseNotifier) Send(ctx context.Context, message string) error {
+	return a.SendTitled(ctx, "", message, "info")
+}
+
+// SendTitled sends a message with a title and Apprise notification type
+// (info, success, warning, failure) with retry.
+func (a *AppriseNotifier) SendTitled(ctx context.Context, title, body, notifyType string) error {
+	if a.URL == "" {
+		return fmt.Errorf("apprise url missing")
+	}
+
+	payload := apprisePayload{
+		URLs:   a.URLs,
+		Tag:    a.Tag,
+		Title:  title,
+		Body:   body,
+		Type:   notifyType,
+		Format: "text",
+	}
+	return sendWithRetry(ctx, "apprise", func(ctx context.Context) (int, error) {
+		return a.doSend(ctx, payload)
+	})
+}
+
+func (a *AppriseNotifier) doSend(ctx context.Context, payload apprisePayload) (int, error) {
+	client := a.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("apprise returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// embedSeverity maps an embed color to a severity: success, warning, failure, or info.
+// The names match Apprise notification types.
+func embedSeverity(embed discordEmbed) string {
+	switch embed.Color {
+	case 0x57F287:
+		return "success"
+	case 0xFEE75C, 0xF4A22C:
+		return "warning"
+	case 0xED4245:
+		return "failure"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAppriseNotifier_Success(t *testing.T) {
+	var got apprisePayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		if r.URL.Path != "/notify/bulwark" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := &AppriseNotifier{
+		URL:    server.URL + "/notify/bulwark",
+		Tag:    "ops",
+		Client: server.Client(),
+	}
+
+	if err := notifier.SendTitled(context.Background(), "Updated", "nginx updated", "success"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Title != "Updated" || got.Body != "nginx updated" || got.Type != "success" || got.Tag != "ops" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+	if got.URLs != "" {
+		t.Errorf("expected urls to be omitted, got %q", got.URLs)
+	}
+}
+
+func TestAppriseNotifier_ClientErrorNoRetry(t *testing.T) {
+	attempt := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier := &AppriseNotifier{URL: server.URL, Client: server.Client()}
+	if err := notifier.Send(context.Background(), "test"); err == nil {
+		t.Error("expected error for bad request")
+	}
+	if attempt != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempt)
+	}
+}
+
+func TestAppriseNotifier_MissingURL(t *testing.T) {
+	notifier := &AppriseNotifier{}
+	if err := notifier.Send(context.Background(), "test"); err == nil {
+		t.Error("expected error for missing apprise URL")
+	}
+}
@@ -0,0 +1,412 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+const (
+	defaultHADiscoveryPrefix = "homeassistant"
+	haKeepAlive              = 60 // seconds
+	haRefreshInterval        = 5 * time.Minute
+	haReconnectDelay         = 30 * time.Second
+	haPayloadPress           = "PRESS"
+	haPayloadInstall         = "INSTALL"
+)
+
+// CommandFunc starts an apply requested from Home Assistant. An empty
+// serviceID applies all safe updates.
+type CommandFunc func(ctx context.Context, serviceID string) error
+
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+}
+
+// haEntityConfig is the discovery payload for the sensor, button and update
+// entities Bulwark exposes.
+type haEntityConfig struct {
+	Name                string   `json:"name"`
+	UniqueID            string   `json:"unique_id"`
+	ObjectID            string   `json:"object_id,omitempty"`
+	StateTopic          string   `json:"state_topic,omitempty"`
+	ValueTemplate       string   `json:"value_template,omitempty"`
+	JSONAttributesTopic string   `json:"json_attributes_topic,omitempty"`
+	CommandTopic        string   `json:"command_topic,omitempty"`
+	PayloadPress        string   `json:"payload_press,omitempty"`
+	PayloadInstall      string   `json:"payload_install,omitempty"`
+	AvailabilityTopic   string   `json:"availability_topic"`
+	Icon                string   `json:"icon,omitempty"`
+	StateClass          string   `json:"state_class,omitempty"`
+	Device              haDevice `json:"device"`
+}
+
+// haUpdateState is the JSON state of an update entity.
+type haUpdateState struct {
+	InstalledVersion string `json:"installed_version"`
+	LatestVersion    string `json:"latest_version"`
+	Title            string `json:"title"`
+	ReleaseSummary   string `json:"release_summary,omitempty"`
+}
+
+type haUpdatesState struct {
+	Count    int      `json:"count"`
+	Services []string `json:"services"`
+}
+
+type haRunState struct {
+	Status      string    `json:"status"`
+	RunID       string    `json:"run_id"`
+	Mode        string    `json:"mode"`
+	Applied     int       `json:"applied"`
+	Skipped     int       `json:"skipped"`
+	Failed      int       `json:"failed"`
+	Rollbacks   int       `json:"rollbacks"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// homeAssistant keeps a broker session open to publish Home Assistant
+// discovery configs and entity state, and to receive apply commands.
+type homeAssistant struct {
+	publisher *MQTTPublisher
+	prefix    string
+	discovery string
+	planFn    PlanFunc
+	commandFn CommandFunc
+	logger    *logging.Logger
+
+	mu       sync.Mutex
+	session  *mqttSession
+	services map[string]bool // service IDs with a published update entity
+	lastRun  []byte
+
+	refreshCh chan struct{}
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func newHomeAssistant(settings Settings, planFn PlanFunc, commandFn CommandFunc, logger *logging.Logger) *homeAssistant {
+	publisher := mqttPublisher(settings)
+	prefix := strings.Trim(strings.TrimSpace(settings.MQTTTopicPrefix), "/")
+	if prefix == "" {
+		prefix = defaultMQTTTopicPrefix
+	}
+	discovery := strings.Trim(strings.TrimSpace(settings.HADiscoveryPrefix), "/")
+	if discovery == "" {
+		discovery = defaultHADiscoveryPrefix
+	}
+	return &homeAssistant{
+		publisher: publisher,
+		prefix:    prefix,
+		discovery: discovery,
+		planFn:    planFn,
+		commandFn: commandFn,
+		logger:    logger.WithComponent("homeassistant"),
+		services:  make(map[string]bool),
+		refreshCh: make(chan struct{}, 1),
+	}
+}
+
+func (h *homeAssistant) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.done = make(chan struct{})
+	go func() {
+		defer close(h.done)
+		h.run(ctx)
+	}()
+}
+
+func (h *homeAssistant) stop() {
+	if h.cancel == nil {
+		return
+	}
+	h.cancel()
+	<-h.done
+}
+
+// run keeps a session open, reconnecting after failures.
+func (h *homeAssistant) run(ctx context.Context) {
+	for {
+		err := h.serve(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		h.logger.Warn().Err(err).Dur("retry_in", haReconnectDelay).Msg("Home Assistant MQTT session ended")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(haReconnectDelay):
+		}
+	}
+}
+
+func (h *homeAssistant) serve(ctx context.Context) error {
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	session, _, err := h.publisher.connect(connectCtx, &mqttWill{Topic: h.topic("status"), Payload: "offline", Retain: true}, haKeepAlive)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer session.close()
+
+	if err := h.publishDiscovery(session); err != nil {
+		return err
+	}
+	if h.commandFn != nil {
+		if err := session.subscribe(h.topic("command/#")); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	h.session = session
+	h.services = make(map[string]bool)
+	lastRun := h.lastRun
+	h.mu.Unlock()
+	defer func() {
+		h.mu.Lock()
+		h.session = nil
+		h.mu.Unlock()
+	}()
+	if lastRun != nil {
+		_ = session.publish(h.topic("state/last_run"), lastRun, true)
+	}
+
+	readErr := make(chan error, 1)
+	go func() { readErr <- session.readMessages(h.handleMessage) }()
+
+	h.logger.Info().Str("discovery_prefix", h.discovery).Msg("Connected to MQTT broker for Home Assistant")
+	h.refresh(ctx, session)
+
+	ping := time.NewTicker(haKeepAlive * time.Second / 2)
+	defer ping.Stop()
+	refresh := time.NewTicker(haRefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = session.publish(h.topic("status"), []byte("offline"), true)
+			return nil
+		case err := <-readErr:
+			return err
+		case <-ping.C:
+			if err := session.ping(); err != nil {
+				return err
+			}
+		case <-refresh.C:
+			h.refresh(ctx, session)
+		case <-h.refreshCh:
+			h.refresh(ctx, session)
+		}
+	}
+}
+
+// publishDiscovery announces the Bulwark-wide entities and marks Bulwark online.
+func (h *homeAssistant) publishDiscovery(session *mqttSession) error {
+	configs := map[string]haEntityConfig{
+		"sensor/updates_available": {
+			Name:                "Updates available",
+			StateTopic:          h.topic("state/updates"),
+			ValueTemplate:       "{{ value_json.count }}",
+			JSONAttributesTopic: h.topic("state/updates"),
+			Icon:                "mdi:package-up",
+			StateClass:          "measurement",
+		},
+		"sensor/last_run": {
+			Name:                "Last run status",
+			StateTopic:          h.topic("state/last_run"),
+			ValueTemplate:       "{{ value_json.status }}",
+			JSONAttributesTopic: h.topic("state/last_run"),
+			Icon:                "mdi:history",
+		},
+	}
+	if h.commandFn != nil {
+		configs["button/apply_safe"] = haEntityConfig{
+			Name:         "Apply safe updates",
+			CommandTopic: h.topic("command/apply"),
+			PayloadPress: haPayloadPress,
+			Icon:         "mdi:update",
+		}
+	}
+
+	for key, config := range configs {
+		component, object, _ := strings.Cut(key, "/")
+		if err := h.publishConfig(session, component, object, config); err != nil {
+			return err
+		}
+	}
+	return session.publish(h.topic("status"), []byte("online"), true)
+}
+
+// refresh publishes the update count and one update entity per service in
+// the current plan, and removes entities for services that disappeared.
+func (h *homeAssistant) refresh(ctx context.Context, session *mqttSession) {
+	if h.planFn == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+	plan, err := h.planFn(ctx)
+	if err != nil {
+		h.logger.Warn().Err(err).Msg("Home Assistant refresh failed to build plan")
+		return
+	}
+
+	updates := haUpdatesState{Services: []string{}}
+	seen := make(map[string]bool)
+	for _, item := range plan.Items {
+		if item.ServiceID == "" {
+			continue
+		}
+		if item.UpdateAvailable {
+			updates.Count++
+			updates.Services = append(updates.Services, item.TargetName+"/"+item.ServiceName)
+		}
+		seen[item.ServiceID] = true
+		if err := h.publishService(session, item); err != nil {
+			h.logger.Warn().Err(err).Str("service", item.ServiceName).Msg("Failed to publish Home Assistant update entity")
+			return
+		}
+	}
+
+	h.mu.Lock()
+	var removed []string
+	for id := range h.services {
+		if !seen[id] {
+			removed = append(removed, id)
+		}
+	}
+	h.services = seen
+	h.mu.Unlock()
+	for _, id := range removed {
+		// An empty retained config deletes the entity in Home Assistant.
+		_ = session.publish(h.configTopic("update", id), nil, true)
+	}
+
+	payload, _ := json.Marshal(updates)
+	_ = session.publish(h.topic("state/updates"), payload, true)
+}
+
+func (h *homeAssistant) publishService(session *mqttSession, item planner.PlanItem) error {
+	config := haEntityConfig{
+		Name:       item.TargetName + "/" + item.ServiceName,
+		StateTopic: h.topic("state/service/" + item.ServiceID),
+		Icon:       "mdi:docker",
+	}
+	if h.commandFn != nil {
+		config.CommandTopic = h.topic("command/apply/" + item.ServiceID)
+		config.PayloadInstall = haPayloadInstall
+	}
+	if err := h.publishConfig(session, "update", item.ServiceID, config); err != nil {
+		return err
+	}
+
+	current := shortDigest(item.CurrentDigest)
+	latest := current
+	if item.UpdateAvailable {
+		latest = shortDigest(item.RemoteDigest)
+	}
+	payload, _ := json.Marshal(haUpdateState{
+		InstalledVersion: current,
+		LatestVersion:    latest,
+		Title:            item.Image,
+		ReleaseSummary:   truncateNotificationText(item.Reason, 255),
+	})
+	return session.publish(config.StateTopic, payload, true)
+}
+
+func (h *homeAssistant) publishConfig(session *mqttSession, component, object string, config haEntityConfig) error {
+	node := h.nodeID()
+	config.UniqueID = node + "_" + object
+	config.ObjectID = node + "_" + object
+	config.AvailabilityTopic = h.topic("status")
+	config.Device = haDevice{
+		Identifiers:  []string{node},
+		Name:         "Bulwark",
+		Manufacturer: "Bulwark",
+		Model:        "Container updater",
+	}
+	payload, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err := session.publish(h.configTopic(component, object), payload, true); err != nil {
+		return fmt.Errorf("publish %s discovery: %w", component, err)
+	}
+	return nil
+}
+
+// handleMessage dispatches commands published by Home Assistant.
+func (h *homeAssistant) handleMessage(topic string, payload []byte) {
+	if h.commandFn == nil {
+		return
+	}
+	var serviceID string
+	switch {
+	case topic == h.topic("command/apply"):
+	case strings.HasPrefix(topic, h.topic("command/apply/")):
+		serviceID = strings.TrimPrefix(topic, h.topic("command/apply/"))
+	default:
+		return
+	}
+
+	h.logger.Info().Str("service_id", serviceID).Str("payload", string(payload)).Msg("Apply requested from Home Assistant")
+	go func() {
+		if err := h.commandFn(context.Background(), serviceID); err != nil {
+			h.logger.Warn().Err(err).Str("service_id", serviceID).Msg("Home Assistant apply command failed")
+		}
+	}()
+}
+
+// publishRunStatus records the outcome of an apply run and refreshes the
+// update entities, which the run may have changed.
+func (h *homeAssistant) publishRunStatus(report AutoUpdateRunReport) {
+	payload, _ := json.Marshal(haRunState{
+		Status:      report.Status,
+		RunID:       report.RunID,
+		Mode:        report.Mode,
+		Applied:     report.Summary.UpdatesApplied,
+		Skipped:     report.Summary.UpdatesSkipped,
+		Failed:      report.Summary.UpdatesFailed,
+		Rollbacks:   report.Summary.Rollbacks,
+		CompletedAt: report.CompletedAt,
+	})
+
+	h.mu.Lock()
+	h.lastRun = payload
+	session := h.session
+	h.mu.Unlock()
+	if session != nil {
+		_ = session.publish(h.topic("state/last_run"), payload, true)
+	}
+
+	select {
+	case h.refreshCh <- struct{}{}:
+	default:
+	}
+}
+
+func (h *homeAssistant) topic(suffix string) string {
+	return h.prefix + "/" + suffix
+}
+
+func (h *homeAssistant) configTopic(component, object string) string {
+	return h.discovery + "/" + component + "/" + h.nodeID() + "/" + object + "/config"
+}
+
+// nodeID derives the discovery node from the topic prefix, so two Bulwark
+// instances with different prefixes show up as separate devices.
+func (h *homeAssistant) nodeID() string {
+	return strings.NewReplacer("/", "_", "+", "_", "#", "_", " ", "_").Replace(h.prefix)
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+)
+
+// pipeSession returns a session whose packets can be read from the returned
+// reader, and a channel of the topics/payloads published on it.
+func pipeSession(t *testing.T) (*mqttSession, <-chan [2]string) {
+	t.Helper()
+	client, broker := net.Pipe()
+	t.Cleanup(func() { _ = client.Close(); _ = broker.Close() })
+
+	published := make(chan [2]string, 64)
+	go func() {
+		reader := bufio.NewReader(broker)
+		for {
+			header, body, err := readMQTTPacket(reader)
+			if err != nil {
+				close(published)
+				return
+			}
+			if header&0xF0 != mqttPublish {
+				continue
+			}
+			topic, payload := readFakeString(body)
+			published <- [2]string{topic, string(payload)}
+		}
+	}()
+	return &mqttSession{conn: client, reader: bufio.NewReader(client)}, published
+}
+
+func collect(ch <-chan [2]string, n int) map[string]string {
+	got := make(map[string]string)
+	for i := 0; i < n; i++ {
+		select {
+		case msg := <-ch:
+			got[msg[0]] = msg[1]
+		case <-time.After(time.Second):
+			return got
+		}
+	}
+	return got
+}
+
+func TestHomeAssistantPublishesDiscoveryAndState(t *testing.T) {
+	plan := &planner.Plan{Items: []planner.PlanItem{
+		{TargetName: "app", ServiceID: "svc1", ServiceName: "web", Image: "nginx:1.27", CurrentDigest: "sha256:aaaaaaaaaaaaaaaa", RemoteDigest: "sha256:bbbbbbbbbbbbbbbb", UpdateAvailable: true},
+		{TargetName: "app", ServiceID: "svc2", ServiceName: "db", Image: "postgres:16", CurrentDigest: "sha256:cccccccccccccccc"},
+	}}
+	settings := Settings{MQTTBroker: "mqtt://broker", MQTTTopicPrefix: "bulwark"}
+	planFn := func(context.Context) (*planner.Plan, error) { return plan, nil }
+	commandFn := func(context.Context, string) error { return nil }
+	ha := newHomeAssistant(settings, planFn, commandFn, logging.Default())
+
+	session, published := pipeSession(t)
+	go func() {
+		_ = ha.publishDiscovery(session)
+		ha.refresh(context.Background(), session)
+	}()
+	// 3 discovery configs + online, then 2 x (config + state) + the count.
+	got := collect(published, 9)
+
+	var button haEntityConfig
+	if err := json.Unmarshal([]byte(got["homeassistant/button/bulwark/apply_safe/config"]), &button); err != nil {
+		t.Fatalf("missing apply button config: %v (got %v)", err, got)
+	}
+	if button.CommandTopic != "bulwark/command/apply" || button.AvailabilityTopic != "bulwark/status" {
+		t.Errorf("unexpected button config %+v", button)
+	}
+	if got["bulwark/status"] != "online" {
+		t.Errorf("expected online status, got %q", got["bulwark/status"])
+	}
+
+	var update haEntityConfig
+	if err := json.Unmarshal([]byte(got["homeassistant/update/bulwark/svc1/config"]), &update); err != nil {
+		t.Fatalf("missing update entity config: %v", err)
+	}
+	if update.CommandTopic != "bulwark/command/apply/svc1" || update.PayloadInstall != haPayloadInstall {
+		t.Errorf("unexpected update config %+v", update)
+	}
+
+	var state haUpdateState
+	if err := json.Unmarshal([]byte(got["bulwark/state/service/svc1"]), &state); err != nil {
+		t.Fatalf("missing service state: %v", err)
+	}
+	if state.InstalledVersion != "aaaaaaaaaaaa" || state.LatestVersion != "bbbbbbbbbbbb" {
+		t.Errorf("unexpected update state %+v", state)
+	}
+	if !strings.Contains(got["bulwark/state/updates"], `"count":1`) {
+		t.Errorf("unexpected updates state %q", got["bulwark/state/updates"])
+	}
+}
+
+func TestHomeAssistantHandlesCommands(t *testing.T) {
+	requested := make(chan string, 2)
+	ha := newHomeAssistant(Settings{MQTTBroker: "mqtt://broker"}, nil, func(_ context.Context, serviceID string) error {
+		requested <- serviceID
+		return nil
+	}, logging.Default())
+
+	ha.handleMessage("bulwark/command/apply/svc1", []byte(haPayloadInstall))
+	ha.handleMessage("bulwark/command/apply", []byte(haPayloadPress))
+	ha.handleMessage("bulwark/state/updates", []byte("{}"))
+
+	got := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case id := <-requested:
+			got[id] = true
+		case <-time.After(time.Second):
+			t.Fatal("command not dispatched")
+		}
+	}
+	if !got["svc1"] || !got[""] {
+		t.Errorf("unexpected commands %v", got)
+	}
+	select {
+	case id := <-requested:
+		t.Errorf("unexpected extra command %q", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
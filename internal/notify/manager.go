@@ -10,6 +10,7 @@ import (
 
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/redact"
 	"github.com/itsmrshow/bulwark/internal/scheduler"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
@@ -39,24 +40,41 @@ type AutoUpdateRunItem struct {
 	Image       string
 	Result      string
 	CompletedAt time.Time
-	Details     string
+	// Duration is how long the update of the service took; zero when it
+	// was skipped before starting.
+	Duration time.Duration
+	Details  string
 }
 
-// ApplyFunc triggers an automatic update run. safe and unsafe correspond to
-// which risk tiers should be updated.
+// ApplyFunc runs an automatic update and blocks until it completes. safe and
+// unsafe correspond to which risk tiers should be updated.
 type ApplyFunc func(ctx context.Context, safe bool, unsafe bool)
 
 // Manager orchestrates notification settings and scheduled jobs.
 type Manager struct {
-	logger   *logging.Logger
-	store    Store
-	planFn   PlanFunc
-	applyFn  ApplyFunc
-	mu       sync.RWMutex
-	config   Settings
-	lastHash string
-	sched    *scheduler.Scheduler
-	envLock  Settings
+	logger     *logging.Logger
+	store      Store
+	planFn     PlanFunc
+	applyFn    ApplyFunc
+	commandFn  CommandFunc
+	ha         *homeAssistant
+	haSettings Settings
+	mu         sync.RWMutex
+	config     Settings
+	lastHash   string
+	sched      *scheduler.Scheduler
+	history    *scheduler.History
+	tuning     JobTuning
+	envLock    Settings
+}
+
+// JobTuning controls how scheduled jobs are run.
+type JobTuning struct {
+	Jitter            time.Duration
+	NotifyTimeout     time.Duration
+	AutoUpdateTimeout time.Duration
+	// Location is the default timezone for schedules without a CRON_TZ= prefix.
+	Location *time.Location
 }
 
 // NewManager creates a notification manager.
@@ -65,10 +83,11 @@ func NewManager(store Store, planFn PlanFunc, logger *logging.Logger) *Manager {
 		logger = logging.Default()
 	}
 	return &Manager{
-		logger: logger.WithComponent("notify"),
-		store:  store,
-		planFn: planFn,
-		config: Defaults(),
+		logger:  logger.WithComponent("notify"),
+		store:   store,
+		planFn:  planFn,
+		config:  Defaults(),
+		history: scheduler.NewHistory(context.Background(), nil),
 	}
 }
 
@@ -78,6 +97,25 @@ func (m *Manager) WithApplyFunc(fn ApplyFunc) *Manager {
 	return m
 }
 
+// WithCommandFunc lets Home Assistant trigger applies through MQTT. Without
+// it the discovery configs expose no buttons.
+func (m *Manager) WithCommandFunc(fn CommandFunc) *Manager {
+	m.commandFn = fn
+	return m
+}
+
+// WithJobHistory persists scheduled job history to store.
+func (m *Manager) WithJobHistory(store scheduler.SettingsStore) *Manager {
+	m.history = scheduler.NewHistory(context.Background(), store)
+	return m
+}
+
+// WithJobTuning sets scheduled job timeouts and jitter.
+func (m *Manager) WithJobTuning(tuning JobTuning) *Manager {
+	m.tuning = tuning
+	return m
+}
+
 // Load loads settings from the store if available.
 func (m *Manager) Load(ctx context.Context) error {
 	if m.store != nil {
@@ -102,6 +140,7 @@ func (m *Manager) Settings() Settings {
 func (m *Manager) Reload(ctx context.Context) {
 	m.applyEnvOverrides()
 	m.restartScheduler()
+	m.restartHomeAssistant()
 }
 
 // EnvLocked returns environment-provided webhook settings.
@@ -134,27 +173,115 @@ func (m *Manager) Update(ctx context.Context, settings Settings) error {
 		merged.SlackWebhook = m.envLock.SlackWebhook
 		merged.SlackEnabled = true
 	}
+	if m.envLock.AppriseURL != "" {
+		merged.AppriseURL = m.envLock.AppriseURL
+		merged.AppriseEnabled = true
+	}
+	if m.envLock.AppriseTag != "" {
+		merged.AppriseTag = m.envLock.AppriseTag
+	}
+	if m.envLock.AppriseURLs != "" {
+		merged.AppriseURLs = m.envLock.AppriseURLs
+	}
+	if m.envLock.MatrixAccessToken != "" {
+		merged.MatrixHomeserver = m.envLock.MatrixHomeserver
+		merged.MatrixAccessToken = m.envLock.MatrixAccessToken
+		merged.MatrixRoomID = m.envLock.MatrixRoomID
+		merged.MatrixEnabled = true
+	}
+	if m.envLock.TeamsWebhook != "" {
+		merged.TeamsWebhook = m.envLock.TeamsWebhook
+		merged.TeamsEnabled = true
+	}
+	if m.envLock.MQTTBroker != "" {
+		merged.MQTTBroker = m.envLock.MQTTBroker
+		merged.MQTTUsername = m.envLock.MQTTUsername
+		merged.MQTTPassword = m.envLock.MQTTPassword
+		merged.MQTTEnabled = true
+	}
+	if m.envLock.MQTTTopicPrefix != "" {
+		merged.MQTTTopicPrefix = m.envLock.MQTTTopicPrefix
+	}
+	if m.envLock.HADiscoveryPrefix != "" {
+		merged.HADiscoveryPrefix = m.envLock.HADiscoveryPrefix
+	}
 	m.config = merged
 	m.mu.Unlock()
 
 	m.applyEnvOverrides()
 	m.restartScheduler()
+	m.restartHomeAssistant()
 	return nil
 }
 
-// Start loads settings and starts scheduled jobs.
+// Start loads settings, starts scheduled jobs, and catches up on runs missed
+// while the process was down.
 func (m *Manager) Start(ctx context.Context) {
 	_ = m.Load(ctx)
 	m.restartScheduler()
+	m.restartHomeAssistant()
+	m.catchUp()
 }
 
-// Stop stops scheduled jobs.
+// Stop stops scheduled jobs and the Home Assistant session.
 func (m *Manager) Stop() {
+	m.stopScheduler()
+	m.stopHomeAssistant()
+}
+
+// stopScheduler stops the scheduler and waits for its running jobs. It
+// waits without holding m.mu, which the jobs take to read settings.
+func (m *Manager) stopScheduler() {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	if m.sched != nil {
-		m.sched.Stop()
-		m.sched = nil
+	sched := m.sched
+	m.sched = nil
+	m.mu.Unlock()
+	if sched != nil {
+		sched.Stop()
+	}
+}
+
+// restartHomeAssistant reconnects the Home Assistant session when the
+// settings changed, or leaves it stopped when discovery is off.
+func (m *Manager) restartHomeAssistant() {
+	settings := m.Settings()
+	m.mu.RLock()
+	unchanged := m.ha != nil && m.haSettings == settings
+	m.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	m.stopHomeAssistant()
+	if !settings.MQTTEnabled || !settings.HADiscoveryEnabled {
+		return
+	}
+	ha := newHomeAssistant(settings, m.planFn, m.commandFn, m.logger)
+	ha.start()
+
+	m.mu.Lock()
+	m.ha = ha
+	m.haSettings = settings
+	m.mu.Unlock()
+}
+
+func (m *Manager) stopHomeAssistant() {
+	m.mu.Lock()
+	ha := m.ha
+	m.ha = nil
+	m.mu.Unlock()
+	if ha != nil {
+		ha.stop()
+	}
+}
+
+// PublishRunStatus reports a finished apply run to Home Assistant.
+func (m *Manager) PublishRunStatus(report AutoUpdateRunReport) {
+	m.mu.RLock()
+	ha := m.ha
+	m.mu.RUnlock()
+	if ha != nil {
+		ha.publishRunStatus(report)
 	}
 }
 
@@ -165,8 +292,55 @@ func (m *Manager) Test(ctx context.Context) error {
 	return m.send(ctx, settings, message)
 }
 
+// Jobs returns the status of currently scheduled jobs.
+func (m *Manager) Jobs() []scheduler.JobStatus {
+	m.mu.RLock()
+	sched := m.sched
+	m.mu.RUnlock()
+	if sched == nil {
+		return []scheduler.JobStatus{}
+	}
+	return sched.Status()
+}
+
+// ScheduleHealth reports how many jobs the current settings call for and how
+// many are actually scheduled. Fewer scheduled than expected means a job
+// failed to register, for example because of an invalid cron expression.
+func (m *Manager) ScheduleHealth() (expected, scheduled int) {
+	settings := m.Settings()
+	if settings.NotifyOnFind {
+		expected++
+	}
+	if settings.DigestEnabled {
+		expected++
+	}
+	if settings.AutoUpdateEnabled && m.applyFn != nil {
+		expected++
+	}
+	return expected, len(m.Jobs())
+}
+
+// Location returns the default timezone used for schedules.
+func (m *Manager) Location() *time.Location {
+	if m.tuning.Location != nil {
+		return m.tuning.Location
+	}
+	return time.Local
+}
+
+// RunJob triggers a scheduled job immediately.
+func (m *Manager) RunJob(name string) error {
+	m.mu.RLock()
+	sched := m.sched
+	m.mu.RUnlock()
+	if sched == nil {
+		return fmt.Errorf("%w: %s", scheduler.ErrJobNotFound, name)
+	}
+	return sched.RunNow(name)
+}
+
 func (m *Manager) restartScheduler() {
-	m.Stop()
+	m.stopScheduler()
 
 	settings := m.Settings()
 	autoUpdateActive := settings.AutoUpdateEnabled && m.applyFn != nil
@@ -174,24 +348,27 @@ func (m *Manager) restartScheduler() {
 		return
 	}
 
-	sched := scheduler.NewScheduler(m.logger)
+	sched := scheduler.NewScheduler(m.logger).WithHistory(m.history).
+		WithJitter(m.tuning.Jitter).
+		WithLocation(m.tuning.Location)
+	notifyOpts := scheduler.JobOptions{Timeout: m.tuning.NotifyTimeout}
 
 	if settings.NotifyOnFind {
-		if err := sched.AddJob(settings.CheckCron, &notifyJob{manager: m, mode: "immediate"}); err != nil {
+		if err := sched.AddJobWithOptions(settings.CheckCron, &notifyJob{manager: m, mode: "immediate"}, notifyOpts); err != nil {
 			m.logger.Error().Err(err).Msg("failed to schedule immediate notifications")
 		}
 	}
 	if settings.DigestEnabled {
-		if err := sched.AddJob(settings.DigestCron, &notifyJob{manager: m, mode: "digest"}); err != nil {
+		if err := sched.AddJobWithOptions(settings.DigestCron, &notifyJob{manager: m, mode: "digest"}, notifyOpts); err != nil {
 			m.logger.Error().Err(err).Msg("failed to schedule digest notifications")
 		}
 	}
 	if autoUpdateActive {
-		if err := sched.AddJob(settings.AutoUpdateCron, &autoUpdateJob{
+		if err := sched.AddJobWithOptions(settings.AutoUpdateCron, &autoUpdateJob{
 			manager: m,
 			safe:    settings.AutoUpdateSafe,
 			unsafe:  settings.AutoUpdateUnsafe,
-		}); err != nil {
+		}, scheduler.JobOptions{Timeout: m.tuning.AutoUpdateTimeout}); err != nil {
 			m.logger.Error().Err(err).Msg("failed to schedule auto-update")
 		}
 	}
@@ -203,9 +380,86 @@ func (m *Manager) restartScheduler() {
 	m.mu.Unlock()
 }
 
+// catchUp triggers missed jobs once, recorded with the catch-up trigger. A
+// missed auto-update is caught up with a safe-only apply when CatchUpApply is
+// set; otherwise a missed check or auto-update runs a check. A missed digest
+// is sent on its own.
+func (m *Manager) catchUp() {
+	settings := m.Settings()
+	if !settings.CatchUpEnabled {
+		return
+	}
+	threshold, err := time.ParseDuration(settings.CatchUpThreshold)
+	if err != nil {
+		return
+	}
+
+	m.mu.RLock()
+	sched := m.sched
+	m.mu.RUnlock()
+	if sched == nil {
+		return
+	}
+
+	now := time.Now()
+	if settings.CatchUpApply && settings.AutoUpdateEnabled && m.applyFn != nil &&
+		m.missed("auto-update", settings.AutoUpdateCron, threshold, now) {
+		m.logger.Info().Msg("Missed scheduled auto-update, running safe catch-up apply")
+		if err := sched.RunAs(&autoUpdateJob{manager: m, safe: true}, scheduler.TriggerCatchUp); err != nil {
+			m.logger.Warn().Err(err).Msg("failed to start catch-up apply")
+		}
+		return
+	}
+
+	if settings.DigestEnabled && m.missed("notify-digest", settings.DigestCron, threshold, now) {
+		m.logger.Info().Msg("Missed scheduled digest, sending catch-up digest")
+		if err := sched.RunAs(&catchUpCheckJob{manager: m, mode: "digest"}, scheduler.TriggerCatchUp); err != nil {
+			m.logger.Warn().Err(err).Msg("failed to start catch-up digest")
+		}
+	}
+
+	missedCheck := (settings.NotifyOnFind && m.missed("notify-immediate", settings.CheckCron, threshold, now)) ||
+		(settings.AutoUpdateEnabled && m.missed("auto-update", settings.AutoUpdateCron, threshold, now))
+	if !missedCheck {
+		return
+	}
+	m.logger.Info().Msg("Missed scheduled run, running catch-up check")
+	if err := sched.RunAs(&catchUpCheckJob{manager: m, mode: "immediate"}, scheduler.TriggerCatchUp); err != nil {
+		m.logger.Warn().Err(err).Msg("failed to start catch-up check")
+	}
+}
+
+// missed reports whether the job's next slot after its last recorded run
+// passed more than threshold ago. Jobs that never ran are not considered missed.
+func (m *Manager) missed(name, schedule string, threshold time.Duration, now time.Time) bool {
+	last, ok := m.history.Last(name)
+	if !ok {
+		return false
+	}
+	next, err := scheduler.NextRun(schedule, m.Location(), last.StartedAt)
+	if err != nil {
+		return false
+	}
+	return now.Sub(next) > threshold
+}
+
 func (m *Manager) applyEnvOverrides() {
 	discord := strings.TrimSpace(os.Getenv("DISCORD_WEBHOOK_URL"))
 	slack := strings.TrimSpace(os.Getenv("SLACK_WEBHOOK_URL"))
+	apprise := strings.TrimSpace(os.Getenv("APPRISE_URL"))
+	appriseTag := strings.TrimSpace(os.Getenv("APPRISE_TAG"))
+	appriseURLs := strings.TrimSpace(os.Getenv("APPRISE_URLS"))
+	matrixHomeserver := strings.TrimSpace(os.Getenv("MATRIX_HOMESERVER_URL"))
+	matrixToken := strings.TrimSpace(os.Getenv("MATRIX_ACCESS_TOKEN"))
+	matrixRoom := strings.TrimSpace(os.Getenv("MATRIX_ROOM_ID"))
+	teams := strings.TrimSpace(os.Getenv("TEAMS_WEBHOOK_URL"))
+	mqttBroker := strings.TrimSpace(os.Getenv("MQTT_BROKER_URL"))
+	mqttUsername := strings.TrimSpace(os.Getenv("MQTT_USERNAME"))
+	mqttPassword := os.Getenv("MQTT_PASSWORD")
+	mqttTopicPrefix := strings.TrimSpace(os.Getenv("MQTT_TOPIC_PREFIX"))
+	mqttTLSInsecure, mqttTLSInsecureSet := readEnvBool("MQTT_TLS_INSECURE")
+	haDiscovery, haDiscoverySet := readEnvBool("MQTT_HA_DISCOVERY")
+	haDiscoveryPrefix := strings.TrimSpace(os.Getenv("MQTT_HA_DISCOVERY_PREFIX"))
 	notifyOnFind, notifyOnFindSet := readEnvBool("BULWARK_NOTIFY_ON_FIND")
 	digestEnabled, digestEnabledSet := readEnvBool("BULWARK_NOTIFY_DIGEST")
 	checkCron := strings.TrimSpace(os.Getenv("BULWARK_NOTIFY_CHECK_CRON"))
@@ -214,10 +468,17 @@ func (m *Manager) applyEnvOverrides() {
 	autoUpdateSafe, autoUpdateSafeSet := readEnvBool("BULWARK_AUTO_UPDATE_SAFE")
 	autoUpdateUnsafe, autoUpdateUnsafeSet := readEnvBool("BULWARK_AUTO_UPDATE_UNSAFE")
 	autoUpdateCron := strings.TrimSpace(os.Getenv("BULWARK_AUTO_UPDATE_CRON"))
-
-	if discord == "" && slack == "" &&
+	catchUpEnabled, catchUpEnabledSet := readEnvBool("BULWARK_CATCHUP_ENABLED")
+	catchUpApply, catchUpApplySet := readEnvBool("BULWARK_CATCHUP_APPLY")
+	catchUpThreshold := strings.TrimSpace(os.Getenv("BULWARK_CATCHUP_THRESHOLD"))
+
+	if discord == "" && slack == "" && apprise == "" && appriseTag == "" && appriseURLs == "" &&
+		matrixToken == "" && teams == "" &&
+		mqttBroker == "" && mqttTopicPrefix == "" && !mqttTLSInsecureSet &&
+		!haDiscoverySet && haDiscoveryPrefix == "" &&
 		!notifyOnFindSet && !digestEnabledSet && checkCron == "" && digestCron == "" &&
-		!autoUpdateEnabledSet && !autoUpdateSafeSet && !autoUpdateUnsafeSet && autoUpdateCron == "" {
+		!autoUpdateEnabledSet && !autoUpdateSafeSet && !autoUpdateUnsafeSet && autoUpdateCron == "" &&
+		!catchUpEnabledSet && !catchUpApplySet && catchUpThreshold == "" {
 		return
 	}
 	m.mu.Lock()
@@ -255,6 +516,18 @@ func (m *Manager) applyEnvOverrides() {
 		m.config.AutoUpdateCron = autoUpdateCron
 		m.envLock.AutoUpdateCron = autoUpdateCron
 	}
+	if catchUpEnabledSet {
+		m.config.CatchUpEnabled = catchUpEnabled
+		m.envLock.CatchUpEnabled = catchUpEnabled
+	}
+	if catchUpApplySet {
+		m.config.CatchUpApply = catchUpApply
+		m.envLock.CatchUpApply = catchUpApply
+	}
+	if catchUpThreshold != "" {
+		m.config.CatchUpThreshold = catchUpThreshold
+		m.envLock.CatchUpThreshold = catchUpThreshold
+	}
 
 	if discord != "" {
 		m.config.DiscordWebhook = discord
@@ -268,6 +541,62 @@ func (m *Manager) applyEnvOverrides() {
 		m.envLock.SlackWebhook = slack
 		m.envLock.SlackEnabled = true
 	}
+	if apprise != "" {
+		m.config.AppriseURL = apprise
+		m.config.AppriseEnabled = true
+		m.envLock.AppriseURL = apprise
+		m.envLock.AppriseEnabled = true
+	}
+	if appriseTag != "" {
+		m.config.AppriseTag = appriseTag
+		m.envLock.AppriseTag = appriseTag
+	}
+	if appriseURLs != "" {
+		m.config.AppriseURLs = appriseURLs
+		m.envLock.AppriseURLs = appriseURLs
+	}
+	if matrixToken != "" {
+		m.config.MatrixHomeserver = matrixHomeserver
+		m.config.MatrixAccessToken = matrixToken
+		m.config.MatrixRoomID = matrixRoom
+		m.config.MatrixEnabled = true
+		m.envLock.MatrixHomeserver = matrixHomeserver
+		m.envLock.MatrixAccessToken = matrixToken
+		m.envLock.MatrixRoomID = matrixRoom
+		m.envLock.MatrixEnabled = true
+	}
+	if teams != "" {
+		m.config.TeamsWebhook = teams
+		m.config.TeamsEnabled = true
+		m.envLock.TeamsWebhook = teams
+		m.envLock.TeamsEnabled = true
+	}
+	if mqttBroker != "" {
+		m.config.MQTTBroker = mqttBroker
+		m.config.MQTTUsername = mqttUsername
+		m.config.MQTTPassword = mqttPassword
+		m.config.MQTTEnabled = true
+		m.envLock.MQTTBroker = mqttBroker
+		m.envLock.MQTTUsername = mqttUsername
+		m.envLock.MQTTPassword = mqttPassword
+		m.envLock.MQTTEnabled = true
+	}
+	if mqttTopicPrefix != "" {
+		m.config.MQTTTopicPrefix = mqttTopicPrefix
+		m.envLock.MQTTTopicPrefix = mqttTopicPrefix
+	}
+	if mqttTLSInsecureSet {
+		m.config.MQTTTLSInsecure = mqttTLSInsecure
+		m.envLock.MQTTTLSInsecure = mqttTLSInsecure
+	}
+	if haDiscoverySet {
+		m.config.HADiscoveryEnabled = haDiscovery
+		m.envLock.HADiscoveryEnabled = haDiscovery
+	}
+	if haDiscoveryPrefix != "" {
+		m.config.HADiscoveryPrefix = haDiscoveryPrefix
+		m.envLock.HADiscoveryPrefix = haDiscoveryPrefix
+	}
 
 	m.config = m.config.Normalize()
 }
@@ -325,6 +654,10 @@ func (m *Manager) run(ctx context.Context, mode string) error {
 		}
 	}
 
+	if mode == "immediate" && settings.MQTTEnabled {
+		m.publishUpdatesAvailable(ctx, settings, updates)
+	}
+
 	embed := formatDiscoveryEmbed(mode, updates, plan)
 	return m.sendDiscordEmbed(ctx, settings, embed)
 }
@@ -346,13 +679,40 @@ func (m *Manager) send(ctx context.Context, settings Settings, message string) e
 		}
 	}
 
+	if settings.AppriseEnabled {
+		if err := appriseNotifier(settings).Send(ctx, message); err != nil {
+			errs = append(errs, fmt.Sprintf("apprise: %v", err))
+		}
+	}
+
+	if settings.MatrixEnabled {
+		if err := matrixNotifier(settings).Send(ctx, message); err != nil {
+			errs = append(errs, fmt.Sprintf("matrix: %v", err))
+		}
+	}
+
+	if settings.TeamsEnabled {
+		teams := TeamsNotifier{WebhookURL: settings.TeamsWebhook}
+		if err := teams.Send(ctx, message); err != nil {
+			errs = append(errs, fmt.Sprintf("teams: %v", err))
+		}
+	}
+
+	if settings.MQTTEnabled {
+		event := MQTTEvent{Event: MQTTEventTest, Message: message, Timestamp: time.Now().UTC()}
+		if err := mqttPublisher(settings).Publish(ctx, event.Event, event); err != nil {
+			errs = append(errs, fmt.Sprintf("mqtt: %v", err))
+		}
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("%s", strings.Join(errs, "; "))
 	}
 	return nil
 }
 
-// sendDiscordEmbed sends a rich embed via Discord and falls back to plain text for Slack.
+// sendDiscordEmbed sends a rich embed via Discord and renders it in each other
+// enabled channel's native format (plain text for Apprise).
 func (m *Manager) sendDiscordEmbed(ctx context.Context, settings Settings, embed discordEmbed) error {
 	var errs []string
 
@@ -370,6 +730,26 @@ func (m *Manager) sendDiscordEmbed(ctx context.Context, settings Settings, embed
 		}
 	}
 
+	if settings.AppriseEnabled {
+		body := embedToText(discordEmbed{Description: embed.Description, Fields: embed.Fields, Timestamp: embed.Timestamp})
+		if err := appriseNotifier(settings).SendTitled(ctx, embed.Title, body, embedSeverity(embed)); err != nil {
+			errs = append(errs, fmt.Sprintf("apprise: %v", err))
+		}
+	}
+
+	if settings.MatrixEnabled {
+		if err := matrixNotifier(settings).sendEmbed(ctx, embed); err != nil {
+			errs = append(errs, fmt.Sprintf("matrix: %v", err))
+		}
+	}
+
+	if settings.TeamsEnabled {
+		teams := TeamsNotifier{WebhookURL: settings.TeamsWebhook}
+		if err := teams.sendEmbed(ctx, embed); err != nil {
+			errs = append(errs, fmt.Sprintf("teams: %v", err))
+		}
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("%s", strings.Join(errs, "; "))
 	}
@@ -379,9 +759,15 @@ func (m *Manager) sendDiscordEmbed(ctx context.Context, settings Settings, embed
 // NotifyResult sends a per-update result notification after an update completes.
 func (m *Manager) NotifyResult(ctx context.Context, result *state.UpdateResult, image string) {
 	settings := m.Settings()
-	if !settings.DiscordEnabled && !settings.SlackEnabled {
+	if !settings.AnyChannelEnabled() {
 		return
 	}
+	if settings.MQTTEnabled {
+		event := resultEvent(result, image)
+		if err := mqttPublisher(settings).Publish(ctx, event.Event, event); err != nil {
+			m.logger.Warn().Err(err).Str("event", event.Event).Msg("failed to publish mqtt event")
+		}
+	}
 
 	var title string
 	var color int
@@ -429,10 +815,10 @@ func (m *Manager) NotifyResult(ctx context.Context, result *state.UpdateResult,
 			Value: fmt.Sprintf("Returned to `%s`", shortDigest(result.RollbackDigest)),
 		})
 	}
-	if result.Error != nil {
+	if result.ErrorMessage != "" {
 		embed.Fields = append(embed.Fields, discordEmbedField{
 			Name:  "Details",
-			Value: truncateNotificationText(result.Error.Error(), 300),
+			Value: truncateNotificationText(redact.String(result.ErrorMessage), 300),
 		})
 	}
 
@@ -544,13 +930,17 @@ func formatAutoUpdateRunEmbed(report AutoUpdateRunReport) discordEmbed {
 		if details == "" {
 			details = "No details"
 		}
+		completed := item.CompletedAt.UTC().Format(time.RFC3339)
+		if item.Duration > 0 {
+			completed = fmt.Sprintf("%s · took %s", completed, item.Duration.Round(100*time.Millisecond))
+		}
 		fields = append(fields, discordEmbedField{
 			Name: fmt.Sprintf("%s/%s", nonEmpty(item.Target, "unknown"), nonEmpty(item.Service, "unknown")),
 			Value: fmt.Sprintf(
 				"%s\n`%s`\n%s\n%s",
 				item.Image,
 				item.Result,
-				item.CompletedAt.UTC().Format(time.RFC3339),
+				completed,
 				truncateNotificationText(details, 140),
 			),
 		})
@@ -583,6 +973,55 @@ func formatAutoUpdateRunEmbed(report AutoUpdateRunReport) discordEmbed {
 	}
 }
 
+func appriseNotifier(settings Settings) *AppriseNotifier {
+	return &AppriseNotifier{
+		URL:  settings.AppriseURL,
+		Tag:  settings.AppriseTag,
+		URLs: settings.AppriseURLs,
+	}
+}
+
+// publishUpdatesAvailable publishes one update_available event per update.
+func (m *Manager) publishUpdatesAvailable(ctx context.Context, settings Settings, updates []planner.PlanItem) {
+	publisher := mqttPublisher(settings)
+	now := time.Now().UTC()
+	for _, item := range updates {
+		event := MQTTEvent{
+			Event:     MQTTEventUpdateAvailable,
+			Target:    item.TargetName,
+			Service:   item.ServiceName,
+			Image:     item.Image,
+			OldDigest: item.CurrentDigest,
+			NewDigest: item.RemoteDigest,
+			Risk:      item.Risk,
+			Allowed:   item.Allowed,
+			Timestamp: now,
+		}
+		if err := publisher.Publish(ctx, event.Event, event); err != nil {
+			m.logger.Warn().Err(err).Str("event", event.Event).Msg("failed to publish mqtt event")
+			return
+		}
+	}
+}
+
+func mqttPublisher(settings Settings) *MQTTPublisher {
+	return &MQTTPublisher{
+		Broker:      settings.MQTTBroker,
+		Username:    settings.MQTTUsername,
+		Password:    settings.MQTTPassword,
+		TopicPrefix: settings.MQTTTopicPrefix,
+		TLSInsecure: settings.MQTTTLSInsecure,
+	}
+}
+
+func matrixNotifier(settings Settings) *MatrixNotifier {
+	return &MatrixNotifier{
+		Homeserver:  settings.MatrixHomeserver,
+		AccessToken: settings.MatrixAccessToken,
+		RoomID:      settings.MatrixRoomID,
+	}
+}
+
 func shortDigest(digest string) string {
 	bare := digest
 	if strings.HasPrefix(bare, "sha256:") {
@@ -702,3 +1141,27 @@ func (j *autoUpdateJob) Execute(ctx context.Context) error {
 	j.manager.applyFn(ctx, j.safe, j.unsafe)
 	return nil
 }
+
+// catchUpCheckJob catches up a missed notification job. In digest mode it
+// sends the digest; otherwise it refreshes the plan and sends an immediate
+// notification if NotifyOnFind is set. It is recorded under the history of
+// the job it stands in for.
+type catchUpCheckJob struct {
+	manager *Manager
+	mode    string
+}
+
+func (j *catchUpCheckJob) Name() string {
+	if j.mode == "digest" {
+		return "notify-digest"
+	}
+	return "notify-immediate"
+}
+
+func (j *catchUpCheckJob) Execute(ctx context.Context) error {
+	if j.mode == "digest" || j.manager.Settings().NotifyOnFind {
+		return j.manager.run(ctx, j.mode)
+	}
+	_, err := j.manager.planFn(ctx)
+	return err
+}
@@ -2,8 +2,16 @@ package notify
 
 import (
 	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/scheduler"
 )
 
 func TestMemoryStore(t *testing.T) {
@@ -73,6 +81,24 @@ func TestSettingsValidate(t *testing.T) {
 		t.Error("expected error for slack without webhook")
 	}
 
+	// Apprise enabled without URL
+	s = Settings{AppriseEnabled: true}
+	if err := s.Validate(); err == nil {
+		t.Error("expected error for apprise without url")
+	}
+
+	// Matrix enabled without room
+	s = Settings{MatrixEnabled: true, MatrixHomeserver: "https://matrix.org", MatrixAccessToken: "token"}
+	if err := s.Validate(); err == nil {
+		t.Error("expected error for matrix without room id")
+	}
+
+	// Teams enabled without webhook
+	s = Settings{TeamsEnabled: true}
+	if err := s.Validate(); err == nil {
+		t.Error("expected error for teams without webhook")
+	}
+
 	// Valid config
 	s = Settings{
 		DiscordEnabled: true,
@@ -170,6 +196,7 @@ func TestFormatAutoUpdateRunEmbed(t *testing.T) {
 				Image:       "ghcr.io/homarr-labs/homarr:latest",
 				Result:      "updated",
 				CompletedAt: completedAt.Add(-time.Minute),
+				Duration:    42 * time.Second,
 				Details:     "Update applied successfully",
 			},
 			{
@@ -192,4 +219,101 @@ func TestFormatAutoUpdateRunEmbed(t *testing.T) {
 	if embed.Timestamp != completedAt.Format(time.RFC3339) {
 		t.Fatalf("unexpected timestamp: %s", embed.Timestamp)
 	}
+	if !strings.Contains(embed.Fields[7].Value, "took 42s") {
+		t.Errorf("expected the item duration, got %q", embed.Fields[7].Value)
+	}
+}
+
+func TestCatchUp_RunsSafeApplyForMissedAutoUpdate(t *testing.T) {
+	type call struct {
+		safe, unsafe bool
+		trigger      string
+	}
+	calls := make(chan call, 1)
+	m := NewManager(nil, nil, logging.New(logging.Config{Level: "error"})).
+		WithApplyFunc(func(ctx context.Context, safe bool, unsafe bool) {
+			calls <- call{safe: safe, unsafe: unsafe, trigger: scheduler.TriggerFromContext(ctx)}
+		})
+	m.config = Settings{
+		AutoUpdateEnabled: true,
+		AutoUpdateUnsafe:  true,
+		AutoUpdateCron:    "0 3 * * *",
+		CatchUpEnabled:    true,
+		CatchUpApply:      true,
+	}.Normalize()
+	m.history.Record(context.Background(), "auto-update", scheduler.JobRun{
+		StartedAt: time.Now().Add(-72 * time.Hour),
+		Result:    "success",
+	})
+
+	m.restartScheduler()
+	defer m.Stop()
+	m.catchUp()
+
+	select {
+	case got := <-calls:
+		if !got.safe || got.unsafe {
+			t.Errorf("expected safe-only catch-up apply, got %+v", got)
+		}
+		if got.trigger != scheduler.TriggerCatchUp {
+			t.Errorf("expected catch-up trigger, got %q", got.trigger)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected catch-up apply to run")
+	}
+}
+
+func TestCatchUp_SendsMissedDigest(t *testing.T) {
+	bodies := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	plan := &planner.Plan{UpdateCount: 1, Items: []planner.PlanItem{{UpdateAvailable: true, Allowed: true}}}
+	m := NewManager(nil, func(ctx context.Context) (*planner.Plan, error) { return plan, nil }, logging.New(logging.Config{Level: "error"}))
+	m.config = Settings{
+		DiscordEnabled: true,
+		DiscordWebhook: server.URL,
+		DigestEnabled:  true,
+		DigestCron:     "0 9 * * *",
+		CatchUpEnabled: true,
+	}.Normalize()
+	m.history.Record(context.Background(), "notify-digest", scheduler.JobRun{
+		StartedAt: time.Now().Add(-72 * time.Hour),
+		Result:    "success",
+	})
+
+	m.restartScheduler()
+	defer m.Stop()
+	m.catchUp()
+
+	select {
+	case body := <-bodies:
+		if !strings.Contains(body, "Scheduled Digest") {
+			t.Errorf("expected a digest, got %s", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected catch-up digest to be sent")
+	}
+	select {
+	case body := <-bodies:
+		t.Errorf("expected no immediate alert without NotifyOnFind, got %s", body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestCatchUp_SkipsWithoutHistory(t *testing.T) {
+	m := NewManager(nil, nil, logging.New(logging.Config{Level: "error"}))
+	now := time.Now()
+	if m.missed("auto-update", "0 3 * * *", time.Hour, now) {
+		t.Error("expected job without history not to be considered missed")
+	}
+
+	m.history.Record(context.Background(), "auto-update", scheduler.JobRun{StartedAt: now.Add(-10 * time.Minute)})
+	if m.missed("auto-update", "* * * * *", time.Hour, now) {
+		t.Error("expected recent run within threshold not to be missed")
+	}
 }
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// matrixMessage is an m.room.message event body.
+type matrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+var matrixTxnCounter atomic.Uint64
+
+// MatrixNotifier sends messages to a Matrix room via the client-server API.
+type MatrixNotifier struct {
+	Homeserver  string
+	AccessToken string
+	RoomID      string
+	Client      *http.Client
+}
+
+// Send sends a plain-text message to Matrix with retry.
+func (m *MatrixNotifier) Send(ctx context.Context, message string) error {
+	return m.sendMessage(ctx, matrixMessage{MsgType: "m.text", Body: message})
+}
+
+// sendEmbed renders an embed as HTML and sends it to Matrix with retry.
+func (m *MatrixNotifier) sendEmbed(ctx context.Context, embed discordEmbed) error {
+	return m.sendMessage(ctx, embedToMatrixMessage(embed))
+}
+
+func (m *MatrixNotifier) sendMessage(ctx context.Context, msg matrixMessage) error {
+	if m.Homeserver == "" || m.AccessToken == "" || m.RoomID == "" {
+		return fmt.Errorf("matrix homeserver, access token, and room id required")
+	}
+
+	// The transaction ID must stay stable across retries so the homeserver
+	// can deduplicate a message that was delivered but not acknowledged.
+	txnID := fmt.Sprintf("bulwark-%d-%d", time.Now().UnixNano(), matrixTxnCounter.Add(1))
+	return sendWithRetry(ctx, "matrix", func(ctx context.Context) (int, error) {
+		return m.doSend(ctx, txnID, msg)
+	})
+}
+
+func (m *MatrixNotifier) doSend(ctx context.Context, txnID string, msg matrixMessage) (int, error) {
+	client := m.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	body, _ := json.Marshal(msg)
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(m.Homeserver, "/"),
+		url.PathEscape(m.RoomID),
+		url.PathEscape(txnID),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.AccessToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("matrix homeserver returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func embedToMatrixMessage(embed discordEmbed) matrixMessage {
+	var b strings.Builder
+	if embed.Title != "" {
+		b.WriteString("<strong>" + html.EscapeString(embed.Title) + "</strong><br>")
+	}
+	if embed.Description != "" {
+		b.WriteString(html.EscapeString(embed.Description) + "<br>")
+	}
+	if len(embed.Fields) > 0 {
+		b.WriteString("<ul>")
+		for _, field := range embed.Fields {
+			value := strings.ReplaceAll(html.EscapeString(field.Value), "\n", "<br>")
+			b.WriteString(fmt.Sprintf("<li><strong>%s</strong>: %s</li>", html.EscapeString(field.Name), value))
+		}
+		b.WriteString("</ul>")
+	}
+	if embed.Footer != nil && embed.Footer.Text != "" {
+		b.WriteString("<em>" + html.EscapeString(embed.Footer.Text) + "</em>")
+	}
+
+	return matrixMessage{
+		MsgType:       "m.notice",
+		Body:          embedToText(embed),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: b.String(),
+	}
+}
@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMatrixNotifier_Success(t *testing.T) {
+	var got matrixMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", r.Method)
+		}
+		if !strings.HasPrefix(r.URL.Path, "/_matrix/client/v3/rooms/!room:example.org/send/m.room.message/") {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer secret" {
+			t.Errorf("unexpected authorization header %q", auth)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := &MatrixNotifier{
+		Homeserver:  server.URL + "/",
+		AccessToken: "secret",
+		RoomID:      "!room:example.org",
+		Client:      server.Client(),
+	}
+
+	embed := discordEmbed{Title: "Updated <nginx>", Fields: []discordEmbedField{{Name: "Service", Value: "web"}}}
+	if err := notifier.sendEmbed(context.Background(), embed); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got.Format != "org.matrix.custom.html" {
+		t.Errorf("expected html format, got %q", got.Format)
+	}
+	if !strings.Contains(got.FormattedBody, "Updated &lt;nginx&gt;") {
+		t.Errorf("expected escaped title in formatted body, got %q", got.FormattedBody)
+	}
+	if !strings.Contains(got.Body, "Service: web") {
+		t.Errorf("expected plain-text fallback, got %q", got.Body)
+	}
+}
+
+func TestMatrixNotifier_MissingConfig(t *testing.T) {
+	notifier := &MatrixNotifier{Homeserver: "https://matrix.org"}
+	if err := notifier.Send(context.Background(), "test"); err == nil {
+		t.Error("expected error for missing token and room")
+	}
+}
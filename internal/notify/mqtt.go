@@ -0,0 +1,444 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/redact"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// MQTT event names, published under <prefix>/event/<name>.
+const (
+	MQTTEventUpdateAvailable = "update_available"
+	MQTTEventUpdateApplied   = "update_applied"
+	MQTTEventUpdateFailed    = "update_failed"
+	MQTTEventRollback        = "rollback"
+	MQTTEventTest            = "test"
+)
+
+const defaultMQTTTopicPrefix = "bulwark"
+
+// MQTTEvent is the JSON payload of every published event.
+type MQTTEvent struct {
+	Event          string    `json:"event"`
+	Target         string    `json:"target,omitempty"`
+	Service        string    `json:"service,omitempty"`
+	Image          string    `json:"image,omitempty"`
+	OldDigest      string    `json:"old_digest,omitempty"`
+	NewDigest      string    `json:"new_digest,omitempty"`
+	RollbackDigest string    `json:"rollback_digest,omitempty"`
+	Risk           string    `json:"risk,omitempty"`
+	Allowed        bool      `json:"allowed,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// resultEvent describes a finished update as an applied, failed or rollback event.
+func resultEvent(result *state.UpdateResult, image string) MQTTEvent {
+	event := MQTTEvent{
+		Event:     MQTTEventUpdateApplied,
+		Target:    result.TargetID,
+		Service:   result.ServiceName,
+		Image:     image,
+		OldDigest: result.OldDigest,
+		NewDigest: result.NewDigest,
+		Timestamp: result.CompletedAt.UTC(),
+	}
+	switch {
+	case result.RollbackPerformed:
+		event.Event = MQTTEventRollback
+		event.RollbackDigest = result.RollbackDigest
+	case !result.Success:
+		event.Event = MQTTEventUpdateFailed
+	}
+	if result.ErrorMessage != "" {
+		event.Message = redact.String(result.ErrorMessage)
+	}
+	return event
+}
+
+// MQTT 3.1.1 control packet types (upper nibble of the fixed header).
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttPuback     = 0x40
+	mqttSubscribe  = 0x82 // includes the reserved flag bits
+	mqttPingreq    = 0xC0
+	mqttDisconnect = 0xE0
+)
+
+// MQTTPublisher publishes JSON events to an MQTT broker, for example the
+// Mosquitto add-on Home Assistant automations subscribe to.
+//
+// Broker is a URL such as mqtt://broker:1883 or mqtts://broker:8883
+// (tcp:// and ssl:// are accepted too). Each publish uses its own short
+// connection with QoS 1, which is plenty for a handful of events per run.
+type MQTTPublisher struct {
+	Broker      string
+	Username    string
+	Password    string
+	ClientID    string
+	TopicPrefix string
+	// TLSInsecure skips broker certificate verification, for self-signed
+	// certificates on a home network.
+	TLSInsecure bool
+	Timeout     time.Duration
+}
+
+// Publish sends payload as JSON to <prefix>/event/<event> with retry.
+func (p *MQTTPublisher) Publish(ctx context.Context, event string, payload any) error {
+	if p.Broker == "" {
+		return fmt.Errorf("mqtt broker missing")
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode mqtt payload: %w", err)
+	}
+	topic := p.topic(event)
+	return sendWithRetry(ctx, "mqtt", func(ctx context.Context) (int, error) {
+		return p.publish(ctx, topic, body)
+	})
+}
+
+func (p *MQTTPublisher) topic(event string) string {
+	prefix := strings.Trim(strings.TrimSpace(p.TopicPrefix), "/")
+	if prefix == "" {
+		prefix = defaultMQTTTopicPrefix
+	}
+	return prefix + "/event/" + event
+}
+
+// publish runs one connect/publish/disconnect exchange. The returned status
+// follows the HTTP convention sendWithRetry understands: 4xx for a refused
+// connection that retrying will not fix.
+func (p *MQTTPublisher) publish(ctx context.Context, topic string, body []byte) (int, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	session, status, err := p.connect(ctx, nil, 30)
+	if err != nil {
+		return status, err
+	}
+	defer session.close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = session.conn.SetDeadline(deadline)
+	}
+
+	const packetID = 1
+	if err := session.write(publishPacket(topic, body, packetID, 1, false)); err != nil {
+		return 0, fmt.Errorf("mqtt publish: %w", err)
+	}
+	header, data, err := readMQTTPacket(session.reader)
+	if err != nil {
+		return 0, fmt.Errorf("mqtt puback: %w", err)
+	}
+	if header&0xF0 != mqttPuback || len(data) < 2 || binary.BigEndian.Uint16(data) != packetID {
+		return 0, fmt.Errorf("mqtt: unexpected packet 0x%02x waiting for puback", header)
+	}
+	return 200, nil
+}
+
+// mqttWill is the last-will message the broker publishes if the session
+// drops without a DISCONNECT.
+type mqttWill struct {
+	Topic   string
+	Payload string
+	Retain  bool
+}
+
+// mqttSession is an established broker connection. Writes are serialized so a
+// reader goroutine and publishers can share it.
+type mqttSession struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mu     sync.Mutex
+	nextID uint16
+}
+
+// connect dials the broker and completes the CONNECT/CONNACK handshake.
+func (p *MQTTPublisher) connect(ctx context.Context, will *mqttWill, keepAlive uint16) (*mqttSession, int, error) {
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	session := &mqttSession{conn: conn, reader: bufio.NewReader(conn)}
+
+	if err := session.write(p.connectPacket(will, keepAlive)); err != nil {
+		_ = conn.Close()
+		return nil, 0, fmt.Errorf("mqtt connect: %w", err)
+	}
+	header, data, err := readMQTTPacket(session.reader)
+	if err != nil {
+		_ = conn.Close()
+		return nil, 0, fmt.Errorf("mqtt connack: %w", err)
+	}
+	if header&0xF0 != mqttConnack || len(data) < 2 {
+		_ = conn.Close()
+		return nil, 0, fmt.Errorf("mqtt: unexpected packet 0x%02x waiting for connack", header)
+	}
+	if code := data[1]; code != 0 {
+		_ = conn.Close()
+		status := 400
+		if code == 3 { // server unavailable
+			status = 503
+		}
+		return nil, status, fmt.Errorf("mqtt broker refused connection: %s", connackReason(code))
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+	return session, 200, nil
+}
+
+func (s *mqttSession) write(packet []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.conn.Write(packet)
+	return err
+}
+
+// publish sends a QoS 0 message.
+func (s *mqttSession) publish(topic string, payload []byte, retain bool) error {
+	return s.write(publishPacket(topic, payload, 0, 0, retain))
+}
+
+// subscribe requests QoS 0 delivery for filter. The SUBACK is left to the
+// session's reader.
+func (s *mqttSession) subscribe(filter string) error {
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.mu.Unlock()
+
+	var body []byte
+	body = binary.BigEndian.AppendUint16(body, id)
+	body = appendMQTTString(body, filter)
+	body = append(body, 0)
+	return s.write(appendMQTTPacket(nil, mqttSubscribe, body))
+}
+
+func (s *mqttSession) ping() error {
+	return s.write([]byte{mqttPingreq, 0})
+}
+
+// close disconnects cleanly, so the broker does not publish the will.
+func (s *mqttSession) close() {
+	_ = s.write([]byte{mqttDisconnect, 0})
+	_ = s.conn.Close()
+}
+
+// readMessages delivers incoming PUBLISH packets to handle until the
+// connection fails.
+func (s *mqttSession) readMessages(handle func(topic string, payload []byte)) error {
+	for {
+		header, body, err := readMQTTPacket(s.reader)
+		if err != nil {
+			return err
+		}
+		if header&0xF0 != mqttPublish {
+			continue
+		}
+		if len(body) < 2 {
+			continue
+		}
+		n := int(binary.BigEndian.Uint16(body))
+		if len(body) < 2+n {
+			continue
+		}
+		topic, payload := string(body[2:2+n]), body[2+n:]
+		if qos := (header >> 1) & 0x03; qos > 0 && len(payload) >= 2 {
+			packetID := payload[:2]
+			payload = payload[2:]
+			_ = s.write(append([]byte{mqttPuback, 2}, packetID...))
+		}
+		handle(topic, payload)
+	}
+}
+
+func (p *MQTTPublisher) dial(ctx context.Context) (net.Conn, error) {
+	u, err := url.Parse(p.Broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid mqtt broker url %q", p.Broker)
+	}
+
+	var useTLS bool
+	switch strings.ToLower(u.Scheme) {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl", "tls":
+		useTLS = true
+	default:
+		return nil, fmt.Errorf("unsupported mqtt broker scheme %q", u.Scheme)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	dialer := &net.Dialer{}
+	if !useTLS {
+		return dialer.DialContext(ctx, "tcp", host)
+	}
+	tlsDialer := &tls.Dialer{
+		NetDialer: dialer,
+		Config: &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: p.TLSInsecure,
+			MinVersion:         tls.VersionTLS12,
+		},
+	}
+	return tlsDialer.DialContext(ctx, "tcp", host)
+}
+
+// newClientID returns a random client ID, so Bulwark instances sharing a
+// broker do not take over each other's sessions. It stays within the 23
+// bytes every MQTT 3.1.1 broker accepts.
+func newClientID() string {
+	buf := make([]byte, 7)
+	_, _ = rand.Read(buf)
+	return "bulwark-" + hex.EncodeToString(buf)
+}
+
+func (p *MQTTPublisher) connectPacket(will *mqttWill, keepAlive uint16) []byte {
+	clientID := p.ClientID
+	if clientID == "" {
+		clientID = newClientID()
+	}
+
+	flags := byte(0x02) // clean session
+	if will != nil {
+		flags |= 0x04 // will flag, QoS 0
+		if will.Retain {
+			flags |= 0x20
+		}
+	}
+	if p.Username != "" {
+		flags |= 0x80
+		if p.Password != "" {
+			flags |= 0x40
+		}
+	}
+
+	var body []byte
+	body = appendMQTTString(body, "MQTT")
+	body = append(body, 4, flags) // protocol level 3.1.1
+	body = binary.BigEndian.AppendUint16(body, keepAlive)
+	body = appendMQTTString(body, clientID)
+	if will != nil {
+		body = appendMQTTString(body, will.Topic)
+		body = appendMQTTString(body, will.Payload)
+	}
+	if p.Username != "" {
+		body = appendMQTTString(body, p.Username)
+		if p.Password != "" {
+			body = appendMQTTString(body, p.Password)
+		}
+	}
+	return appendMQTTPacket(nil, mqttConnect, body)
+}
+
+func publishPacket(topic string, payload []byte, packetID uint16, qos byte, retain bool) []byte {
+	header := byte(mqttPublish) | qos<<1
+	if retain {
+		header |= 0x01
+	}
+	var body []byte
+	body = appendMQTTString(body, topic)
+	if qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, packetID)
+	}
+	body = append(body, payload...)
+	return appendMQTTPacket(nil, header, body)
+}
+
+func appendMQTTPacket(dst []byte, header byte, body []byte) []byte {
+	dst = append(dst, header)
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		dst = append(dst, digit)
+		if length == 0 {
+			break
+		}
+	}
+	return append(dst, body...)
+}
+
+func appendMQTTString(dst []byte, value string) []byte {
+	dst = binary.BigEndian.AppendUint16(dst, uint16(len(value)))
+	return append(dst, value...)
+}
+
+// readMQTTPacket reads one control packet and returns its fixed header byte
+// (type and flags) and body.
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, fmt.Errorf("malformed remaining length")
+		}
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7F) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header, body, nil
+}
+
+func connackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad username or password"
+	case 5:
+		return "not authorized"
+	default:
+		return fmt.Sprintf("code %d", code)
+	}
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type publishedMessage struct {
+	username string
+	password string
+	topic    string
+	payload  []byte
+}
+
+// fakeBroker accepts MQTT connections, answers CONNECT with returnCode and
+// acknowledges one PUBLISH per connection.
+func fakeBroker(t *testing.T, returnCode byte) (string, <-chan publishedMessage, *atomic.Int32) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	messages := make(chan publishedMessage, 4)
+	var connections atomic.Int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			connections.Add(1)
+			go serveFakeBroker(conn, returnCode, messages)
+		}
+	}()
+	return "mqtt://" + listener.Addr().String(), messages, &connections
+}
+
+func serveFakeBroker(conn net.Conn, returnCode byte, messages chan<- publishedMessage) {
+	defer func() { _ = conn.Close() }()
+	reader := bufio.NewReader(conn)
+
+	header, body, err := readMQTTPacket(reader)
+	if err != nil || header&0xF0 != mqttConnect {
+		return
+	}
+	var msg publishedMessage
+	rest := body[10:] // protocol name, level, flags, keepalive
+	flags := body[7]
+	_, rest = readFakeString(rest) // client id
+	if flags&0x80 != 0 {
+		msg.username, rest = readFakeString(rest)
+	}
+	if flags&0x40 != 0 {
+		msg.password, _ = readFakeString(rest)
+	}
+	_, _ = conn.Write([]byte{mqttConnack, 2, 0, returnCode})
+	if returnCode != 0 {
+		return
+	}
+
+	header, body, err = readMQTTPacket(reader)
+	if err != nil || header&0xF0 != mqttPublish {
+		return
+	}
+	msg.topic, rest = readFakeString(body)
+	packetID := rest[:2]
+	msg.payload = rest[2:]
+	_, _ = conn.Write(append([]byte{mqttPuback, 2}, packetID...))
+	messages <- msg
+}
+
+func readFakeString(b []byte) (string, []byte) {
+	n := int(binary.BigEndian.Uint16(b))
+	return string(b[2 : 2+n]), b[2+n:]
+}
+
+func TestMQTTPublisher_Publish(t *testing.T) {
+	broker, messages, _ := fakeBroker(t, 0)
+	publisher := &MQTTPublisher{Broker: broker, Username: "bulwark", Password: "secret", TopicPrefix: "home/bulwark/"}
+
+	event := MQTTEvent{Event: MQTTEventRollback, Service: "web", Timestamp: time.Now()}
+	if err := publisher.Publish(context.Background(), event.Event, event); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	msg := <-messages
+	if msg.topic != "home/bulwark/event/rollback" {
+		t.Errorf("unexpected topic %q", msg.topic)
+	}
+	if msg.username != "bulwark" || msg.password != "secret" {
+		t.Errorf("unexpected credentials %q/%q", msg.username, msg.password)
+	}
+	var got MQTTEvent
+	if err := json.Unmarshal(msg.payload, &got); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if got.Event != MQTTEventRollback || got.Service != "web" {
+		t.Errorf("unexpected payload %+v", got)
+	}
+}
+
+func TestMQTTPublisher_RefusedIsNotRetried(t *testing.T) {
+	broker, _, connections := fakeBroker(t, 4)
+	publisher := &MQTTPublisher{Broker: broker}
+
+	err := publisher.Publish(context.Background(), MQTTEventTest, MQTTEvent{Event: MQTTEventTest})
+	if err == nil || !strings.Contains(err.Error(), "bad username or password") {
+		t.Fatalf("expected refused connection, got %v", err)
+	}
+	if n := connections.Load(); n != 1 {
+		t.Errorf("expected 1 connection, got %d", n)
+	}
+}
+
+func TestMQTTPublisher_InvalidBroker(t *testing.T) {
+	publisher := &MQTTPublisher{Broker: "http://broker"}
+	if _, err := publisher.publish(context.Background(), "bulwark/event/test", nil); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
+
+func TestResultEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		result state.UpdateResult
+		want   string
+	}{
+		{"applied", state.UpdateResult{Success: true}, MQTTEventUpdateApplied},
+		{"failed", state.UpdateResult{ErrorMessage: "pull failed"}, MQTTEventUpdateFailed},
+		{"rollback", state.UpdateResult{RollbackPerformed: true, RollbackDigest: "sha256:old"}, MQTTEventRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resultEvent(&tt.result, "nginx:latest"); got.Event != tt.want {
+				t.Errorf("resultEvent() = %q, want %q", got.Event, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientID(t *testing.T) {
+	a, b := newClientID(), newClientID()
+	if a == b {
+		t.Errorf("expected distinct client IDs, got %q twice", a)
+	}
+	if !strings.HasPrefix(a, "bulwark-") || len(a) > 23 {
+		t.Errorf("expected a bulwark- ID of at most 23 bytes, got %q", a)
+	}
+}
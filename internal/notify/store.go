@@ -19,7 +19,7 @@ type Store interface {
 }
 
 // NewStore chooses a store based on available backends.
-func NewStore(cfgPath string, stateStore state.Store, logger *logging.Logger) Store {
+func NewStore(cfgPath string, stateStore state.SettingsStore, logger *logging.Logger) Store {
 	if cfgPath != "" {
 		return &fileStore{path: cfgPath, logger: logger}
 	}
@@ -45,7 +45,7 @@ func (m *memoryStore) Save(ctx context.Context, settings Settings) error {
 func (m *memoryStore) SetHash(ctx context.Context, hash string) {}
 
 type dbStore struct {
-	store state.Store
+	store state.SettingsStore
 	hash  string
 }
 
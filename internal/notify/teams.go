@@ -0,0 +1,166 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type teamsCardElement struct {
+	Type     string          `json:"type"`
+	Text     string          `json:"text,omitempty"`
+	Weight   string          `json:"weight,omitempty"`
+	Size     string          `json:"size,omitempty"`
+	Color    string          `json:"color,omitempty"`
+	Wrap     bool            `json:"wrap,omitempty"`
+	IsSubtle bool            `json:"isSubtle,omitempty"`
+	Facts    []teamsCardFact `json:"facts,omitempty"`
+}
+
+type teamsCardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teamsAdaptiveCard struct {
+	Schema  string             `json:"$schema"`
+	Type    string             `json:"type"`
+	Version string             `json:"version"`
+	Body    []teamsCardElement `json:"body"`
+}
+
+type teamsAttachment struct {
+	ContentType string            `json:"contentType"`
+	Content     teamsAdaptiveCard `json:"content"`
+}
+
+// teamsPayload is the incoming-webhook envelope for an adaptive card.
+type teamsPayload struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// TeamsNotifier sends adaptive cards to a Microsoft Teams incoming webhook.
+type TeamsNotifier struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// Send sends a plain-text message to Teams with retry.
+func (t *TeamsNotifier) Send(ctx context.Context, message string) error {
+	return t.sendCard(ctx, teamsAdaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    []teamsCardElement{{Type: "TextBlock", Text: message, Wrap: true}},
+	})
+}
+
+// sendEmbed renders an embed as an adaptive card and sends it with retry.
+func (t *TeamsNotifier) sendEmbed(ctx context.Context, embed discordEmbed) error {
+	return t.sendCard(ctx, embedToTeamsCard(embed))
+}
+
+func (t *TeamsNotifier) sendCard(ctx context.Context, card teamsAdaptiveCard) error {
+	if t.WebhookURL == "" {
+		return fmt.Errorf("teams webhook url missing")
+	}
+
+	payload := teamsPayload{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content:     card,
+		}},
+	}
+	return sendWithRetry(ctx, "teams", func(ctx context.Context) (int, error) {
+		return t.doSend(ctx, payload)
+	})
+}
+
+func (t *TeamsNotifier) doSend(ctx context.Context, payload teamsPayload) (int, error) {
+	client := t.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("teams webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func embedToTeamsCard(embed discordEmbed) teamsAdaptiveCard {
+	body := make([]teamsCardElement, 0, 4)
+	if embed.Title != "" {
+		body = append(body, teamsCardElement{
+			Type:   "TextBlock",
+			Text:   embed.Title,
+			Weight: "Bolder",
+			Size:   "Medium",
+			Color:  teamsColor(embed.Color),
+			Wrap:   true,
+		})
+	}
+	if embed.Description != "" {
+		body = append(body, teamsCardElement{Type: "TextBlock", Text: embed.Description, Wrap: true})
+	}
+	if len(embed.Fields) > 0 {
+		facts := make([]teamsCardFact, 0, len(embed.Fields))
+		for _, field := range embed.Fields {
+			facts = append(facts, teamsCardFact{Title: field.Name, Value: field.Value})
+		}
+		body = append(body, teamsCardElement{Type: "FactSet", Facts: facts})
+	}
+	footer := ""
+	if embed.Footer != nil {
+		footer = embed.Footer.Text
+	}
+	if footer != "" || embed.Timestamp != "" {
+		body = append(body, teamsCardElement{
+			Type:     "TextBlock",
+			Text:     strings.TrimSpace(footer + " " + embed.Timestamp),
+			IsSubtle: true,
+			Wrap:     true,
+		})
+	}
+
+	return teamsAdaptiveCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body:    body,
+	}
+}
+
+// teamsColor maps an embed color to an adaptive-card text color.
+func teamsColor(color int) string {
+	switch embedSeverity(discordEmbed{Color: color}) {
+	case "success":
+		return "Good"
+	case "warning":
+		return "Warning"
+	case "failure":
+		return "Attention"
+	default:
+		return "Default"
+	}
+}
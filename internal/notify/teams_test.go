@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTeamsNotifier_AdaptiveCard(t *testing.T) {
+	var got teamsPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode payload: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	notifier := &TeamsNotifier{WebhookURL: server.URL, Client: server.Client()}
+	embed := discordEmbed{
+		Title:  "❌ Update Failed",
+		Color:  0xED4245,
+		Fields: []discordEmbedField{{Name: "Service", Value: "web"}},
+	}
+	if err := notifier.sendEmbed(context.Background(), embed); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got.Type != "message" || len(got.Attachments) != 1 {
+		t.Fatalf("unexpected envelope: %+v", got)
+	}
+	card := got.Attachments[0]
+	if card.ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Errorf("unexpected content type %q", card.ContentType)
+	}
+	if len(card.Content.Body) != 2 {
+		t.Fatalf("expected title and fact set, got %d elements", len(card.Content.Body))
+	}
+	if card.Content.Body[0].Color != "Attention" {
+		t.Errorf("expected failure color, got %q", card.Content.Body[0].Color)
+	}
+	if facts := card.Content.Body[1].Facts; len(facts) != 1 || facts[0].Value != "web" {
+		t.Errorf("unexpected facts: %+v", facts)
+	}
+}
+
+func TestTeamsNotifier_MissingURL(t *testing.T) {
+	notifier := &TeamsNotifier{}
+	if err := notifier.Send(context.Background(), "test"); err == nil {
+		t.Error("expected error for missing webhook URL")
+	}
+}
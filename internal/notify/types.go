@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/itsmrshow/bulwark/internal/planner"
 	"github.com/robfig/cron/v3"
@@ -17,6 +18,7 @@ const (
 	defaultCheck          = "*/15 * * * *"
 	defaultDigest         = "0 9 * * *"
 	defaultAutoUpdateCron = "CRON_TZ=America/New_York 0 3 * * *"
+	defaultCatchUpWindow  = "1h"
 )
 
 // Settings controls notification behavior.
@@ -30,6 +32,33 @@ type Settings struct {
 	CheckCron      string `json:"check_cron"`
 	DigestCron     string `json:"digest_cron"`
 
+	// AppriseURL is the Apprise API notify endpoint (e.g. http://apprise:8000/notify/bulwark).
+	AppriseURL     string `json:"apprise_url"`
+	AppriseEnabled bool   `json:"apprise_enabled"`
+	// AppriseTag limits delivery to URLs with this tag in a stored Apprise config.
+	AppriseTag string `json:"apprise_tag,omitempty"`
+	// AppriseURLs is a comma/space separated list of Apprise URLs for stateless mode.
+	AppriseURLs string `json:"apprise_urls,omitempty"`
+
+	MatrixEnabled     bool   `json:"matrix_enabled"`
+	MatrixHomeserver  string `json:"matrix_homeserver"`
+	MatrixAccessToken string `json:"matrix_access_token"`
+	MatrixRoomID      string `json:"matrix_room_id"`
+
+	TeamsEnabled bool   `json:"teams_enabled"`
+	TeamsWebhook string `json:"teams_webhook"`
+
+	// MQTTBroker is the broker URL, e.g. mqtt://mosquitto:1883 or mqtts://broker:8883.
+	MQTTEnabled     bool   `json:"mqtt_enabled"`
+	MQTTBroker      string `json:"mqtt_broker"`
+	MQTTUsername    string `json:"mqtt_username,omitempty"`
+	MQTTPassword    string `json:"mqtt_password,omitempty"`
+	MQTTTopicPrefix string `json:"mqtt_topic_prefix,omitempty"`
+	MQTTTLSInsecure bool   `json:"mqtt_tls_insecure,omitempty"`
+	// HADiscoveryEnabled publishes Home Assistant MQTT discovery configs.
+	HADiscoveryEnabled bool   `json:"ha_discovery_enabled"`
+	HADiscoveryPrefix  string `json:"ha_discovery_prefix,omitempty"`
+
 	// AutoUpdateEnabled gates the entire auto-update scheduler.
 	AutoUpdateEnabled bool `json:"auto_update_enabled"`
 	// AutoUpdateSafe is kept for compatibility; enabled auto-updates always include safe updates.
@@ -37,6 +66,13 @@ type Settings struct {
 	// AutoUpdateUnsafe extends auto-updates to stateful, policy=notify, or probe-missing services.
 	AutoUpdateUnsafe bool   `json:"auto_update_unsafe"`
 	AutoUpdateCron   string `json:"auto_update_cron"`
+
+	// CatchUpEnabled runs a check on startup when a scheduled run was missed
+	// by more than CatchUpThreshold (e.g. the host was powered off overnight).
+	CatchUpEnabled bool `json:"catch_up_enabled"`
+	// CatchUpApply also runs a safe-only apply when a missed auto-update is caught up.
+	CatchUpApply     bool   `json:"catch_up_apply"`
+	CatchUpThreshold string `json:"catch_up_threshold"`
 }
 
 // Defaults returns default notification settings.
@@ -62,6 +98,9 @@ func (s Settings) Normalize() Settings {
 	if s.AutoUpdateCron == "" {
 		s.AutoUpdateCron = defaultAutoUpdateCron
 	}
+	if s.CatchUpThreshold == "" {
+		s.CatchUpThreshold = defaultCatchUpWindow
+	}
 	if s.AutoUpdateEnabled {
 		s.AutoUpdateSafe = true
 	}
@@ -76,6 +115,21 @@ func (s Settings) Validate() error {
 	if s.SlackEnabled && s.SlackWebhook == "" {
 		return fmt.Errorf("slack webhook required when enabled")
 	}
+	if s.AppriseEnabled && s.AppriseURL == "" {
+		return fmt.Errorf("apprise url required when enabled")
+	}
+	if s.MatrixEnabled && (s.MatrixHomeserver == "" || s.MatrixAccessToken == "" || s.MatrixRoomID == "") {
+		return fmt.Errorf("matrix homeserver, access token, and room id required when enabled")
+	}
+	if s.TeamsEnabled && s.TeamsWebhook == "" {
+		return fmt.Errorf("teams webhook required when enabled")
+	}
+	if s.MQTTEnabled && s.MQTTBroker == "" {
+		return fmt.Errorf("mqtt broker required when enabled")
+	}
+	if s.HADiscoveryEnabled && !s.MQTTEnabled {
+		return fmt.Errorf("home assistant discovery requires mqtt")
+	}
 	if s.NotifyOnFind {
 		if _, err := cron.ParseStandard(s.CheckCron); err != nil {
 			return fmt.Errorf("invalid check cron: %w", err)
@@ -91,9 +145,19 @@ func (s Settings) Validate() error {
 			return fmt.Errorf("invalid auto_update_cron: %w", err)
 		}
 	}
+	if s.CatchUpEnabled {
+		if _, err := time.ParseDuration(s.CatchUpThreshold); err != nil {
+			return fmt.Errorf("invalid catch_up_threshold: %w", err)
+		}
+	}
 	return nil
 }
 
+// AnyChannelEnabled reports whether at least one delivery channel is enabled.
+func (s Settings) AnyChannelEnabled() bool {
+	return s.DiscordEnabled || s.SlackEnabled || s.AppriseEnabled || s.MatrixEnabled || s.TeamsEnabled || s.MQTTEnabled
+}
+
 // Encode converts settings to JSON.
 func Encode(settings Settings) (string, error) {
 	normalized := settings.Normalize()
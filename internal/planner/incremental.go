@@ -0,0 +1,99 @@
+package planner
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DigestMemory remembers the remote digests resolved by recent plans, so an
+// incremental plan only looks up the images whose result expired or whose
+// local digest changed since. It is safe for concurrent use.
+type DigestMemory struct {
+	mu      sync.Mutex
+	entries map[string]digestMemo
+}
+
+type digestMemo struct {
+	digest    string
+	local     string // Local digests of the services using the image
+	checkedAt time.Time
+}
+
+// NewDigestMemory creates an empty digest memory.
+func NewDigestMemory() *DigestMemory {
+	return &DigestMemory{entries: make(map[string]digestMemo)}
+}
+
+// Forget drops what is remembered about image.
+func (m *DigestMemory) Forget(image string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, image)
+}
+
+// Reset drops everything remembered.
+func (m *DigestMemory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = make(map[string]digestMemo)
+}
+
+// recall returns the remembered digest of image if it was checked within ttl
+// and the local digests are still local. changed reports a remembered entry
+// whose local digests differ, e.g. after a manual pull.
+func (m *DigestMemory) recall(image, local string, ttl time.Duration, now time.Time) (digest string, ok, changed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	memo, found := m.entries[image]
+	if !found {
+		return "", false, false
+	}
+	if memo.local != local {
+		return "", false, true
+	}
+	if now.Sub(memo.checkedAt) >= ttl {
+		return "", false, false
+	}
+	return memo.digest, true, false
+}
+
+func (m *DigestMemory) remember(image, local, digest string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[image] = digestMemo{digest: digest, local: local, checkedAt: now}
+}
+
+// WithIncremental makes plans reuse the remote digests memory holds for up
+// to ttl, or a service's bulwark.check.ttl. Images whose local digest changed
+// are always looked up again, bypassing the registry's digest cache.
+func (p *Planner) WithIncremental(memory *DigestMemory, ttl time.Duration) *Planner {
+	p.memory = memory
+	p.memoryTTL = ttl
+	return p
+}
+
+// imageFreshness collects, per image, the local digests of the services
+// using it and how long its remote digest may be reused.
+type imageFreshness struct {
+	locals map[string]bool
+	ttl    time.Duration
+}
+
+func (f *imageFreshness) add(local string, ttl time.Duration) {
+	f.locals[local] = true
+	if ttl > 0 && (f.ttl == 0 || ttl < f.ttl) {
+		f.ttl = ttl
+	}
+}
+
+// localKey identifies the set of local digests.
+func (f *imageFreshness) localKey() string {
+	locals := make([]string, 0, len(f.locals))
+	for local := range f.locals {
+		locals = append(locals, local)
+	}
+	sort.Strings(locals)
+	return strings.Join(locals, ",")
+}
@@ -0,0 +1,73 @@
+package planner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// invalidatingRegistry counts lookups and records cache invalidations.
+type invalidatingRegistry struct {
+	countingRegistry
+	invalidated []string
+}
+
+func (r *invalidatingRegistry) InvalidateDigest(image string) {
+	r.invalidated = append(r.invalidated, image)
+}
+
+func TestPlannerIncremental(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+	volatile := labels
+	volatile.CheckTTL = time.Nanosecond
+
+	target := state.Target{ID: "t1", Type: state.TargetTypeCompose, Name: "app", Services: []state.Service{
+		{ID: "web", TargetID: "t1", Name: "web", Image: "nginx:latest", CurrentDigest: "sha256:old", Labels: labels},
+		{ID: "db", TargetID: "t1", Name: "db", Image: "postgres:16", CurrentDigest: "sha256:old", Labels: volatile},
+	}}
+	registry := &invalidatingRegistry{}
+	memory := NewDigestMemory()
+	build := func() *Plan {
+		t.Helper()
+		plan, err := NewPlanner(
+			logging.Default(),
+			stubDiscoverer{targets: []state.Target{target}},
+			registry,
+			policy.NewEngine(logging.Default()),
+		).WithIncremental(memory, time.Hour).BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return plan
+	}
+
+	if plan := build(); plan.DigestsReused != 0 || plan.UpdateCount != 2 {
+		t.Fatalf("expected a full first plan, got %d reused and %d updates", plan.DigestsReused, plan.UpdateCount)
+	}
+	plan := build()
+	if plan.DigestsReused != 1 || plan.UpdateCount != 2 {
+		t.Fatalf("expected nginx to be reused, got %d reused and %d updates", plan.DigestsReused, plan.UpdateCount)
+	}
+	if registry.calls["nginx:latest"] != 1 || registry.calls["postgres:16"] != 2 {
+		t.Fatalf("expected only the expired image to be looked up again, got %v", registry.calls)
+	}
+
+	// A manual pull changes the local digest.
+	target.Services[0].CurrentDigest = "sha256:pulled"
+	if plan := build(); plan.DigestsReused != 0 {
+		t.Fatalf("expected nothing reused after a local change, got %d", plan.DigestsReused)
+	}
+	if registry.calls["nginx:latest"] != 2 || len(registry.invalidated) != 1 || registry.invalidated[0] != "nginx:latest" {
+		t.Fatalf("expected nginx to be looked up past the registry cache, got %v and %v", registry.calls, registry.invalidated)
+	}
+
+	memory.Reset()
+	if plan := build(); plan.DigestsReused != 0 {
+		t.Fatalf("expected nothing reused after a reset, got %d", plan.DigestsReused)
+	}
+}
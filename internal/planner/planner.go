@@ -3,11 +3,14 @@ package planner
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/redact"
 	"github.com/itsmrshow/bulwark/internal/registry"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
@@ -24,16 +27,26 @@ type PlanOptions struct {
 	Root            string
 	TargetFilter    string
 	IncludeDisabled bool
+	// Group limits the plan to the targets of one target group.
+	Group string
 }
 
 // Plan represents a structured update plan.
 type Plan struct {
-	GeneratedAt  time.Time  `json:"generated_at"`
-	TargetCount  int        `json:"target_count"`
-	ServiceCount int        `json:"service_count"`
-	UpdateCount  int        `json:"update_count"`
-	AllowedCount int        `json:"allowed_count"`
-	Items        []PlanItem `json:"items"`
+	GeneratedAt     time.Time  `json:"generated_at"`
+	TargetCount     int        `json:"target_count"`
+	ServiceCount    int        `json:"service_count"`
+	UpdateCount     int        `json:"update_count"`
+	AllowedCount    int        `json:"allowed_count"`
+	BaseUpdateCount int        `json:"base_update_count"` // Informational; not included in UpdateCount
+	IgnoredCount    int        `json:"ignored_count"`     // Updates skipped by the user; not included in UpdateCount
+	Items           []PlanItem `json:"items"`
+	// DigestsReused counts the remote digests an incremental plan took from
+	// its digest memory instead of looking them up.
+	DigestsReused int `json:"digests_reused,omitempty"`
+	// MutableTagCount counts the items whose image tracks a mutable tag
+	// such as latest.
+	MutableTagCount int `json:"mutable_tag_count"`
 }
 
 // PlanItem represents one service decision.
@@ -52,20 +65,75 @@ type PlanItem struct {
 	Tier            state.Tier        `json:"tier"`
 	Probe           state.ProbeConfig `json:"probe"`
 	Reason          string            `json:"reason"`
+	ReasonCode      state.ReasonCode  `json:"reason_code,omitempty"`
 	Risk            string            `json:"risk"`
 	Warnings        []string          `json:"warnings,omitempty"`
+	ConfigDrift     bool              `json:"config_drift,omitempty"`
+	Ignored         bool              `json:"ignored,omitempty"` // The user chose to skip RemoteDigest
+	SnoozedUntil    *time.Time        `json:"snoozed_until,omitempty"`
+	Group           string            `json:"group,omitempty"`
+	Paused          bool              `json:"paused,omitempty"` // Held back because Group is paused
+	BaseImage       string            `json:"base_image,omitempty"`
+	BaseUpdate      bool              `json:"base_update_available,omitempty"`
+	Build           bool              `json:"build,omitempty"` // Updated by rebuilding rather than pulling
+	DependsOn       []string          `json:"depends_on_target,omitempty"`
 	Target          *state.Target     `json:"-"`
 	Service         *state.Service    `json:"-"`
+	// FetchErr is the digest lookup failure behind an unresolved item; match
+	// it against registry.ErrRegistryUnavailable to tell outages from
+	// rejected images.
+	FetchErr error `json:"-"`
+
+	// Rollback is where a rollback to CurrentDigest would come from: "local",
+	// "registry" or "unavailable". It is empty when it was not checked.
+	Rollback         string `json:"rollback,omitempty"`
+	RollbackDegraded bool   `json:"rollback_degraded,omitempty"`
+
+	// MutableTag is the tag the image tracks when it is a release channel or
+	// branch such as latest; SuggestedTag is the most recent release version
+	// in the registry to pin instead.
+	MutableTag   string `json:"mutable_tag,omitempty"`
+	SuggestedTag string `json:"suggested_tag,omitempty"`
 }
 
+// configDriftWarning is attached to plan items whose compose config changed
+// since Bulwark last applied an update to the target.
+const configDriftWarning = "Compose config changed since Bulwark's last update (compose file or env edited); running state may not match what was validated"
+
+// rollbackDegradedWarning is attached to plan items whose current digest is
+// neither present locally nor pullable, so a failed update cannot be rolled
+// back.
+const rollbackDegradedWarning = "Current digest is no longer available locally or in the registry; a failed update cannot be rolled back"
+
+// Sources of a rollback to the current digest.
+const (
+	rollbackLocal       = "local"
+	rollbackRegistry    = "registry"
+	rollbackUnavailable = "unavailable"
+)
+
 // Planner builds structured plans.
 type Planner struct {
 	logger       *logging.Logger
 	discoverer   discoverer
 	registry     digestFetcher
 	policyEngine *policy.Engine
+	configHasher configHasher
+	hashStore    settingsReader
+	concurrency  int
+	images       imageChecker
+	manifests    manifestChecker
+	ignores      ignoreLister
+	snoozes      snoozeLister
+	pauses       pauseLister
+	memory       *DigestMemory
+	memoryTTL    time.Duration
+	tags         tagSuggester
 }
 
+// DefaultConcurrency is how many digest lookups a plan build runs at once.
+const DefaultConcurrency = 10
+
 type discoverer interface {
 	Discover(ctx context.Context, basePath string) ([]state.Target, error)
 	DiscoverTarget(ctx context.Context, basePath, targetID string) (*state.Target, error)
@@ -75,6 +143,42 @@ type digestFetcher interface {
 	FetchDigest(ctx context.Context, image string) (string, error)
 }
 
+type configHasher interface {
+	ConfigHash(ctx context.Context, composePath string) (string, error)
+}
+
+type settingsReader interface {
+	GetSetting(ctx context.Context, key string) (string, error)
+}
+
+type ignoreLister interface {
+	ListIgnoredUpdates(ctx context.Context) ([]state.IgnoredUpdate, error)
+}
+
+type snoozeLister interface {
+	ListSnoozes(ctx context.Context, now time.Time) ([]state.Snooze, error)
+}
+
+type pauseLister interface {
+	ListPausedGroups(ctx context.Context) ([]state.GroupPause, error)
+}
+
+type digestInvalidator interface {
+	InvalidateDigest(image string)
+}
+
+type tagSuggester interface {
+	SuggestVersionTag(ctx context.Context, image string) (string, error)
+}
+
+type imageChecker interface {
+	HasImage(ctx context.Context, ref string) (bool, error)
+}
+
+type manifestChecker interface {
+	ManifestExists(ctx context.Context, image, digest string) (bool, error)
+}
+
 // NewPlanner creates a planner.
 func NewPlanner(logger *logging.Logger, discoverer discoverer, registry digestFetcher, policyEngine *policy.Engine) *Planner {
 	if logger == nil {
@@ -88,6 +192,59 @@ func NewPlanner(logger *logging.Logger, discoverer discoverer, registry digestFe
 	}
 }
 
+// WithConfigDrift enables compose config drift detection. Each compose
+// target's current config hash is compared with the one recorded at its last
+// successful update.
+func (p *Planner) WithConfigDrift(hasher configHasher, store settingsReader) *Planner {
+	p.configHasher = hasher
+	p.hashStore = store
+	return p
+}
+
+// WithConcurrency sets how many digest lookups run at once. Values below one
+// select DefaultConcurrency.
+func (p *Planner) WithConcurrency(n int) *Planner {
+	p.concurrency = n
+	return p
+}
+
+// WithRollbackCheck verifies that every item with an update available can be
+// rolled back: its current digest must be present locally, or else still
+// served by the registry. Items failing both are flagged rollback_degraded.
+func (p *Planner) WithRollbackCheck(images imageChecker, manifests manifestChecker) *Planner {
+	p.images = images
+	p.manifests = manifests
+	return p
+}
+
+// WithTagSuggestions looks up a release version to pin for every image that
+// tracks a mutable tag such as latest.
+func (p *Planner) WithTagSuggestions(tags tagSuggester) *Planner {
+	p.tags = tags
+	return p
+}
+
+// WithIgnoredUpdates stops offering the updates users chose to skip. An
+// update stays ignored while the registry serves the ignored digest.
+func (p *Planner) WithIgnoredUpdates(store ignoreLister) *Planner {
+	p.ignores = store
+	return p
+}
+
+// WithSnoozes holds back the updates of snoozed services: they stay in the
+// plan but are not allowed until the snooze expires.
+func (p *Planner) WithSnoozes(store snoozeLister) *Planner {
+	p.snoozes = store
+	return p
+}
+
+// WithPausedGroups holds back the updates of every target in a paused
+// target group until the group is resumed.
+func (p *Planner) WithPausedGroups(store pauseLister) *Planner {
+	p.pauses = store
+	return p
+}
+
 // BuildPlan creates a plan for targets.
 func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error) {
 	var targets []state.Target
@@ -106,6 +263,9 @@ func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error
 			return nil, err
 		}
 	}
+	if opts.Group != "" {
+		targets = filterGroup(targets, opts.Group)
+	}
 
 	plan := &Plan{
 		GeneratedAt: time.Now().UTC(),
@@ -119,6 +279,7 @@ func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error
 		service *state.Service
 	}
 	var refs []serviceRef
+	planned := make(map[string]bool, len(targets))
 	for i := range targets {
 		target := &targets[i]
 		for j := range target.Services {
@@ -127,30 +288,64 @@ func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error
 				continue
 			}
 			refs = append(refs, serviceRef{target: target, service: service})
+			planned[target.ID] = true
 		}
 	}
 	plan.ServiceCount = len(refs)
 
-	// Fetch all remote digests concurrently with a bounded pool (max 10).
+	// Fetch all remote digests concurrently with a bounded pool.
 	type digestResult struct {
 		digest string
 		err    error
 	}
 	uniqueImages := make(map[string]int, len(refs))
 	images := make([]string, 0, len(refs))
+	freshness := make([]*imageFreshness, 0, len(refs))
+	addImage := func(image, local string, ttl time.Duration) {
+		idx, ok := uniqueImages[image]
+		if !ok {
+			idx = len(images)
+			uniqueImages[image] = idx
+			images = append(images, image)
+			freshness = append(freshness, &imageFreshness{locals: make(map[string]bool)})
+		}
+		freshness[idx].add(local, ttl)
+	}
 	for _, ref := range refs {
-		if _, ok := uniqueImages[ref.service.Image]; ok {
-			continue
+		if !ref.service.Build {
+			addImage(ref.service.Image, ref.service.CurrentDigest, ref.service.Labels.CheckTTL)
+		}
+		if base := ref.service.BaseImage; base != nil {
+			addImage(base.Name, base.Digest, 0)
 		}
-		uniqueImages[ref.service.Image] = len(images)
-		images = append(images, ref.service.Image)
 	}
 	digests := make([]digestResult, len(images))
+	now := time.Now()
 
-	const maxConcurrent = 10
+	maxConcurrent := p.concurrency
+	if maxConcurrent < 1 {
+		maxConcurrent = DefaultConcurrency
+	}
 	sem := make(chan struct{}, maxConcurrent)
 	var wg sync.WaitGroup
 	for i, image := range images {
+		local := freshness[i].localKey()
+		if p.memory != nil {
+			ttl := freshness[i].ttl
+			if ttl == 0 {
+				ttl = p.memoryTTL
+			}
+			digest, ok, changed := p.memory.recall(image, local, ttl, now)
+			if ok {
+				digests[i] = digestResult{digest: digest}
+				plan.DigestsReused++
+				continue
+			}
+			if invalidator, isInvalidator := p.registry.(digestInvalidator); changed && isInvalidator {
+				invalidator.InvalidateDigest(image)
+			}
+		}
+
 		wg.Add(1)
 		sem <- struct{}{}
 		go func(idx int, image string) {
@@ -158,10 +353,26 @@ func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error
 			defer func() { <-sem }()
 			d, err := p.registry.FetchDigest(ctx, image)
 			digests[idx] = digestResult{digest: d, err: err}
+			if err == nil && p.memory != nil {
+				p.memory.remember(image, local, d, now)
+			}
 		}(i, image)
 	}
 	wg.Wait()
 
+	serviceImages := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if !ref.service.Build {
+			serviceImages = append(serviceImages, ref.service.Image)
+		}
+	}
+	suggested := p.suggestVersionTags(ctx, serviceImages)
+
+	drifted := p.detectConfigDrift(ctx, targets, planned)
+	ignored := p.ignoredUpdates(ctx)
+	snoozed := p.snoozedUntil(ctx)
+	paused := p.pausedGroups(ctx)
+
 	// Build plan items using fetched digests.
 	for _, ref := range refs {
 		target := ref.target
@@ -178,36 +389,70 @@ func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error
 			Policy:        service.Labels.Policy,
 			Tier:          service.Labels.Tier,
 			Probe:         service.Labels.Probe,
+			ConfigDrift:   drifted[target.ID],
+			Build:         service.Build,
+			DependsOn:     service.Labels.DependsOn,
+			Group:         target.Group(),
 			Target:        target,
 			Service:       service,
 		}
 
-		item.Risk = riskFromLabels(service.Labels)
-
-		digest := digests[uniqueImages[service.Image]]
-		if digest.err != nil {
-			item.UpdateAvailable = false
-			item.Allowed = false
-			item.Reason = fmt.Sprintf("Failed to fetch digest: %v", digest.err)
-			item.Warnings = p.policyEngine.ValidateProbeConfiguration(service.Labels)
-			plan.Items = append(plan.Items, item)
-			continue
+		item.Risk = RiskFromLabels(service.Labels)
+		if tag, mutable := registry.MutableTag(service.Image); mutable && !service.Build {
+			item.MutableTag = tag
+			item.SuggestedTag = suggested[service.Image]
+			plan.MutableTagCount++
 		}
 
-		remoteDigest := digest.digest
-		item.RemoteDigest = remoteDigest
+		if base := service.BaseImage; base != nil {
+			item.BaseImage = base.Name
+			baseDigest := digests[uniqueImages[base.Name]]
+			if baseDigest.err == nil && registry.CompareDigests(base.Digest, baseDigest.digest) {
+				item.BaseUpdate = true
+				if !service.Build {
+					plan.BaseUpdateCount++
+				}
+			}
+		}
 
 		updateAvailable := false
 		reason := ""
-		if service.CurrentDigest == "" {
-			updateAvailable = true
-			reason = "No current digest (container not running)"
-		} else if registry.CompareDigests(service.CurrentDigest, remoteDigest) {
-			updateAvailable = true
-			reason = "Digest mismatch - update available"
+		reasonCode := state.ReasonNoUpdate
+		if service.Build {
+			updateAvailable, reason = buildUpdateStatus(item)
 		} else {
-			updateAvailable = false
-			reason = "Digests match - up to date"
+			digest := digests[uniqueImages[service.Image]]
+			if digest.err != nil {
+				item.UpdateAvailable = false
+				item.Allowed = false
+				item.Reason = fmt.Sprintf("Failed to fetch digest: %v", digest.err)
+				item.ReasonCode = state.ReasonDigestFetchFailed
+				item.FetchErr = digest.err
+				item.Warnings = p.itemWarnings(item)
+				plan.Items = append(plan.Items, item)
+				continue
+			}
+
+			remoteDigest := digest.digest
+			item.RemoteDigest = remoteDigest
+
+			if service.CurrentDigest == "" {
+				updateAvailable = true
+				reason = "No current digest (container not running)"
+			} else if registry.CompareDigests(service.CurrentDigest, remoteDigest) {
+				if ignore, ok := ignored[service.ID]; ok && !registry.CompareDigests(ignore.Digest, remoteDigest) {
+					reason = "Update ignored until a newer digest appears"
+					reasonCode = state.ReasonIgnored
+					item.Ignored = true
+					plan.IgnoredCount++
+				} else {
+					updateAvailable = true
+					reason = "Digest mismatch - update available"
+				}
+			} else {
+				updateAvailable = false
+				reason = "Digests match - up to date"
+			}
 		}
 
 		decision := p.policyEngine.Evaluate(ctx, target, service, updateAvailable)
@@ -215,10 +460,24 @@ func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error
 		item.Allowed = decision.Allowed
 		if updateAvailable {
 			item.Reason = decision.Reason
+			item.ReasonCode = decision.Code
 		} else {
 			item.Reason = reason
+			item.ReasonCode = reasonCode
+		}
+		if until, ok := snoozed[service.ID]; ok && updateAvailable {
+			item.SnoozedUntil = &until
+			item.Allowed = false
+			item.Reason = fmt.Sprintf("Snoozed until %s", until.UTC().Format("2006-01-02 15:04 MST"))
+			item.ReasonCode = state.ReasonSnoozed
+		}
+		if paused[item.Group] && updateAvailable {
+			item.Paused = true
+			item.Allowed = false
+			item.Reason = fmt.Sprintf("Group %s is paused", item.Group)
+			item.ReasonCode = state.ReasonGroupPaused
 		}
-		item.Warnings = p.policyEngine.ValidateProbeConfiguration(service.Labels)
+		item.Warnings = p.itemWarnings(item)
 
 		if item.UpdateAvailable {
 			plan.UpdateCount++
@@ -230,10 +489,418 @@ func (p *Planner) BuildPlan(ctx context.Context, opts PlanOptions) (*Plan, error
 		plan.Items = append(plan.Items, item)
 	}
 
+	p.checkRollbacks(ctx, plan.Items)
+	plan.Items = p.orderByDependencies(plan.Items)
+
 	return plan, nil
 }
 
-func riskFromLabels(labels state.Labels) string {
+// orderByDependencies reorders items so every target comes after the targets
+// its services depend on, keeping the discovery order otherwise. Targets in a
+// dependency cycle keep their original relative order.
+func (p *Planner) orderByDependencies(items []PlanItem) []PlanItem {
+	var order []string
+	byTarget := make(map[string][]PlanItem)
+	deps := make(map[string]map[string]bool)
+	for _, item := range items {
+		if _, ok := byTarget[item.TargetName]; !ok {
+			order = append(order, item.TargetName)
+			deps[item.TargetName] = make(map[string]bool)
+		}
+		byTarget[item.TargetName] = append(byTarget[item.TargetName], item)
+		for _, dep := range item.DependsOn {
+			if dep != item.TargetName {
+				deps[item.TargetName][dep] = true
+			}
+		}
+	}
+
+	placed := make(map[string]bool, len(order))
+	sorted := make([]PlanItem, 0, len(items))
+	for len(placed) < len(order) {
+		progressed := false
+		for _, name := range order {
+			if placed[name] {
+				continue
+			}
+			ready := true
+			for dep := range deps[name] {
+				// Dependencies outside the plan don't constrain ordering.
+				if _, planned := byTarget[dep]; planned && !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				placed[name] = true
+				sorted = append(sorted, byTarget[name]...)
+				progressed = true
+			}
+		}
+		if !progressed {
+			for _, name := range order {
+				if !placed[name] {
+					p.logger.Warn().Str("target", name).Msg("Target dependency cycle detected; keeping discovery order")
+					placed[name] = true
+					sorted = append(sorted, byTarget[name]...)
+				}
+			}
+		}
+	}
+	return sorted
+}
+
+// DependentItems returns the items whose services depend on a target in
+// updatedTargets (keyed by target name), in plan order. Services in
+// recreated (keyed by service ID) are excluded: recreating them already
+// picked up the change.
+func DependentItems(items []PlanItem, updatedTargets, recreated map[string]bool) []PlanItem {
+	var dependents []PlanItem
+	for _, item := range items {
+		if recreated[item.ServiceID] {
+			continue
+		}
+		for _, dep := range item.DependsOn {
+			if updatedTargets[dep] {
+				dependents = append(dependents, item)
+				break
+			}
+		}
+	}
+	return dependents
+}
+
+// UpdateBatches splits items, in apply order, into batches that may run
+// concurrently. Consecutive services of one compose project that all set
+// bulwark.project.parallel are grouped, and compose depends_on between them
+// splits the group into waves so a service updates after what it depends on.
+// Every other item is a batch of its own.
+func UpdateBatches(items []PlanItem) [][]PlanItem {
+	var batches [][]PlanItem
+	for i := 0; i < len(items); {
+		j := i + 1
+		if parallelItem(items[i]) {
+			for j < len(items) && parallelItem(items[j]) && items[j].TargetID == items[i].TargetID {
+				j++
+			}
+		}
+		if j-i == 1 {
+			batches = append(batches, items[i:j])
+		} else {
+			batches = append(batches, projectWaves(items[i:j])...)
+		}
+		i = j
+	}
+	return batches
+}
+
+func parallelItem(item PlanItem) bool {
+	return item.Target != nil && item.Target.Type == state.TargetTypeCompose &&
+		item.Service != nil && item.Service.Labels.Parallel
+}
+
+// projectWaves orders one project's parallel items into dependency waves. A
+// dependency cycle falls back to updating the remaining items one at a time.
+func projectWaves(items []PlanItem) [][]PlanItem {
+	pending := make(map[string]bool, len(items))
+	for _, item := range items {
+		pending[item.ServiceName] = true
+	}
+
+	var waves [][]PlanItem
+	remaining := items
+	for len(remaining) > 0 {
+		var wave, rest []PlanItem
+		for _, item := range remaining {
+			ready := true
+			for _, dep := range item.Service.DependsOn {
+				if dep != item.ServiceName && pending[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				wave = append(wave, item)
+			} else {
+				rest = append(rest, item)
+			}
+		}
+		if len(wave) == 0 {
+			for _, item := range rest {
+				waves = append(waves, []PlanItem{item})
+			}
+			break
+		}
+		for _, item := range wave {
+			delete(pending, item.ServiceName)
+		}
+		waves = append(waves, wave)
+		remaining = rest
+	}
+	return waves
+}
+
+// buildUpdateStatus decides whether a build: service needs a rebuild. Without
+// a registry to compare against, a rebuild is only planned when the recorded
+// base image has moved or no container is running.
+func buildUpdateStatus(item PlanItem) (bool, string) {
+	switch {
+	case item.CurrentDigest == "":
+		return true, "No current image (container not running) - rebuild"
+	case item.BaseUpdate:
+		return true, fmt.Sprintf("Base image %s updated - rebuild available", item.BaseImage)
+	case item.BaseImage == "":
+		return false, "Locally built; no base image labels to compare"
+	default:
+		return false, "Locally built; base image up to date"
+	}
+}
+
+func (p *Planner) itemWarnings(item PlanItem) []string {
+	warnings := p.policyEngine.ValidateProbeConfiguration(item.Service.Labels)
+	for _, issue := range item.Service.Labels.Issues {
+		warnings = append(warnings, issue.Message)
+	}
+	if item.ConfigDrift {
+		warnings = append(warnings, configDriftWarning)
+	}
+	if item.BaseUpdate && !item.Build {
+		warnings = append(warnings, fmt.Sprintf("Base image %s has a newer digest; rebuild recommended", item.BaseImage))
+	}
+	if item.Service.PullPolicy == state.PullPolicyNever && !item.Build {
+		warnings = append(warnings, "pull_policy is never; Bulwark will skip this update")
+	}
+	for _, flag := range item.Service.Labels.ComposeUpFlags {
+		if !docker.AllowedUpFlag(flag) {
+			warnings = append(warnings, fmt.Sprintf("Compose up flag %s is not supported and will be ignored", flag))
+		}
+	}
+	if item.MutableTag != "" {
+		warning := fmt.Sprintf("Image tracks mutable tag :%s and may change without a version bump; pin a release version", item.MutableTag)
+		if item.SuggestedTag != "" {
+			warning += fmt.Sprintf(" such as %s", item.SuggestedTag)
+		}
+		warnings = append(warnings, warning)
+	}
+	return warnings
+}
+
+// suggestVersionTags looks up a release version to pin for each image that
+// tracks a mutable tag, keyed by image. Failed lookups leave no suggestion.
+func (p *Planner) suggestVersionTags(ctx context.Context, images []string) map[string]string {
+	suggested := make(map[string]string)
+	if p.tags == nil {
+		return suggested
+	}
+	maxConcurrent := p.concurrency
+	if maxConcurrent < 1 {
+		maxConcurrent = DefaultConcurrency
+	}
+	sem := make(chan struct{}, maxConcurrent)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]bool, len(images))
+	for _, image := range images {
+		if _, mutable := registry.MutableTag(image); !mutable || seen[image] {
+			continue
+		}
+		seen[image] = true
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			tag, err := p.tags.SuggestVersionTag(ctx, image)
+			if err != nil {
+				p.logger.Debug().Err(err).Str("image", image).Msg("Could not suggest a version tag")
+				return
+			}
+			if tag != "" {
+				mu.Lock()
+				suggested[image] = tag
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return suggested
+}
+
+// ignoredUpdates returns the ignored updates by service ID. Without them,
+// every update is offered.
+func (p *Planner) ignoredUpdates(ctx context.Context) map[string]state.IgnoredUpdate {
+	ignored := make(map[string]state.IgnoredUpdate)
+	if p.ignores == nil {
+		return ignored
+	}
+	ignores, err := p.ignores.ListIgnoredUpdates(ctx)
+	if err != nil {
+		p.logger.Warn().Err(err).Msg("Failed to load ignored updates")
+		return ignored
+	}
+	for _, ignore := range ignores {
+		ignored[ignore.ServiceID] = ignore
+	}
+	return ignored
+}
+
+// snoozedUntil returns when each snoozed service's snooze expires, by
+// service ID.
+func (p *Planner) snoozedUntil(ctx context.Context) map[string]time.Time {
+	snoozed := make(map[string]time.Time)
+	if p.snoozes == nil {
+		return snoozed
+	}
+	snoozes, err := p.snoozes.ListSnoozes(ctx, time.Now())
+	if err != nil {
+		p.logger.Warn().Err(err).Msg("Failed to load snoozes")
+		return snoozed
+	}
+	for _, snooze := range snoozes {
+		snoozed[snooze.ServiceID] = snooze.Until
+	}
+	return snoozed
+}
+
+// pausedGroups returns the names of the paused target groups.
+func (p *Planner) pausedGroups(ctx context.Context) map[string]bool {
+	paused := make(map[string]bool)
+	if p.pauses == nil {
+		return paused
+	}
+	pauses, err := p.pauses.ListPausedGroups(ctx)
+	if err != nil {
+		p.logger.Warn().Err(err).Msg("Failed to load paused groups")
+		return paused
+	}
+	for _, pause := range pauses {
+		paused[pause.Group] = true
+	}
+	return paused
+}
+
+// filterGroup returns the targets that belong to group.
+func filterGroup(targets []state.Target, group string) []state.Target {
+	var matched []state.Target
+	for _, target := range targets {
+		if target.Group() == group {
+			matched = append(matched, target)
+		}
+	}
+	return matched
+}
+
+// checkRollbacks sets Rollback on the items with an update available.
+func (p *Planner) checkRollbacks(ctx context.Context, items []PlanItem) {
+	if p.images == nil || p.manifests == nil {
+		return
+	}
+	maxConcurrent := p.concurrency
+	if maxConcurrent < 1 {
+		maxConcurrent = DefaultConcurrency
+	}
+	sem := make(chan struct{}, maxConcurrent)
+	var wg sync.WaitGroup
+	for i := range items {
+		item := &items[i]
+		if !item.UpdateAvailable || item.Build || item.CurrentDigest == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			item.Rollback = p.rollbackSource(ctx, item.Image, item.CurrentDigest)
+			if item.Rollback == rollbackUnavailable {
+				item.RollbackDegraded = true
+				item.Warnings = append(item.Warnings, rollbackDegradedWarning)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// rollbackSource returns where digest of image can be restored from, or ""
+// when the registry could not tell.
+func (p *Planner) rollbackSource(ctx context.Context, image, digest string) string {
+	// Discovery falls back to the image ID for images without a repo digest.
+	for _, ref := range []string{digestReference(image, digest), digest} {
+		if ok, err := p.images.HasImage(ctx, ref); err == nil && ok {
+			return rollbackLocal
+		}
+	}
+	ok, err := p.manifests.ManifestExists(ctx, image, digest)
+	if err != nil {
+		p.logger.Debug().Err(err).Str("image", image).Str("digest", digest).Msg("Could not check rollback digest")
+		return ""
+	}
+	if ok {
+		return rollbackRegistry
+	}
+	return rollbackUnavailable
+}
+
+// digestReference returns image pinned to digest, e.g. nginx@sha256:...
+func digestReference(image, digest string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image + "@" + digest
+}
+
+// detectConfigDrift returns the IDs of compose targets whose current config
+// hash differs from the recorded one. Targets without a recorded hash, or
+// whose config cannot be resolved, are not flagged.
+func (p *Planner) detectConfigDrift(ctx context.Context, targets []state.Target, planned map[string]bool) map[string]bool {
+	drifted := make(map[string]bool)
+	if p.configHasher == nil || p.hashStore == nil {
+		return drifted
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, 10)
+	for i := range targets {
+		target := &targets[i]
+		if target.Type != state.TargetTypeCompose || !planned[target.ID] {
+			continue
+		}
+
+		recorded, err := p.hashStore.GetSetting(ctx, state.ConfigHashSettingKey(target.ID))
+		if err != nil || recorded == "" {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			current, err := p.configHasher.ConfigHash(ctx, target.Path)
+			if err != nil {
+				p.logger.Debug().Err(err).Str("target", target.Name).Msg("Failed to hash compose config")
+				return
+			}
+			if current != recorded {
+				p.logger.Warn().Str("target", target.Name).Msg("Compose config drift detected")
+				mu.Lock()
+				drifted[target.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return drifted
+}
+
+// RiskFromLabels classifies how risky an automatic update of a service with
+// labels is.
+func RiskFromLabels(labels state.Labels) string {
 	if labels.Policy == state.PolicyNotify {
 		return RiskNotifyOnly
 	}
@@ -248,26 +915,61 @@ func riskFromLabels(labels state.Labels) string {
 
 // HistoryFilter filters history entries.
 type HistoryFilter struct {
-	TargetID  string
-	ServiceID string
-	Result    string
+	TargetID   string
+	ServiceID  string
+	Result     string
+	ResultCode string
+	ReasonCode string
+	Kind       string
+	Trigger    string
+	Actor      string
 }
 
 // HistoryItem represents a record for the history endpoint.
 type HistoryItem struct {
-	TargetID     string    `json:"target_id"`
-	ServiceID    string    `json:"service_id"`
-	ServiceName  string    `json:"service_name"`
-	OldDigest    string    `json:"old_digest"`
-	NewDigest    string    `json:"new_digest"`
-	Success      bool      `json:"success"`
-	RolledBack   bool      `json:"rolled_back"`
-	ErrorMessage string    `json:"error_message,omitempty"`
-	StartedAt    time.Time `json:"started_at"`
-	CompletedAt  time.Time `json:"completed_at"`
-	ProbesPassed int       `json:"probes_passed"`
-	ProbesFailed int       `json:"probes_failed"`
-	DurationSec  float64   `json:"duration_sec"`
+	ID           int64       `json:"id,omitempty"`
+	TargetID     string      `json:"target_id"`
+	ServiceID    string      `json:"service_id"`
+	ServiceName  string      `json:"service_name"`
+	Image        string      `json:"image,omitempty"`
+	Tag          string      `json:"tag,omitempty"`
+	Registry     string      `json:"registry,omitempty"`
+	Platform     string      `json:"platform,omitempty"`
+	OldDigest    string      `json:"old_digest"`
+	NewDigest    string      `json:"new_digest"`
+	Success      bool        `json:"success"`
+	RolledBack   bool        `json:"rolled_back"`
+	ErrorMessage string      `json:"error_message,omitempty"`
+	ErrorCode    string      `json:"error_code,omitempty"`
+	StartedAt    time.Time   `json:"started_at"`
+	CompletedAt  time.Time   `json:"completed_at"`
+	ProbesPassed int         `json:"probes_passed"`
+	ProbesFailed int         `json:"probes_failed"`
+	DurationSec  float64     `json:"duration_sec"`
+	Attempts     int         `json:"attempts"`
+	ResultCode   string      `json:"result_code,omitempty"`
+	ReasonCode   string      `json:"reason_code,omitempty"` // Plan decision behind a skip
+	Skipped      bool        `json:"skipped"`
+	SBOM         *state.SBOM `json:"sbom,omitempty"` // Document served by /api/history/{id}/sbom
+
+	// Timings breaks DurationSec down by step.
+	Timings state.UpdateTimings `json:"timings"`
+
+	// Kind is "version_change" for a switch from PreviousTag to Tag and
+	// empty for a digest refresh.
+	Kind        string `json:"kind,omitempty"`
+	PreviousTag string `json:"previous_tag,omitempty"`
+
+	// ConfigChanges lists how the container's configuration differed after
+	// the recreate.
+	ConfigChanges []state.ConfigChange `json:"config_changes,omitempty"`
+
+	// Resources compares CPU and memory use before and after the update.
+	Resources *state.ResourceSnapshot `json:"resources,omitempty"`
+
+	// Trigger is what started the update and Actor who.
+	Trigger string `json:"trigger,omitempty"`
+	Actor   string `json:"actor,omitempty"`
 }
 
 // MapHistory converts update results to history items.
@@ -275,8 +977,9 @@ func MapHistory(results []state.UpdateResult) []HistoryItem {
 	items := make([]HistoryItem, 0, len(results))
 	for _, result := range results {
 		message := ""
-		if result.Error != nil {
-			message = result.Error.Error()
+		if result.ErrorMessage != "" {
+			// Rows written before redaction existed may still hold secrets.
+			message = redact.String(result.ErrorMessage)
 		}
 
 		completedAt := result.CompletedAt
@@ -297,19 +1000,38 @@ func MapHistory(results []state.UpdateResult) []HistoryItem {
 			}
 		}
 		items = append(items, HistoryItem{
+			ID:           result.ID,
 			TargetID:     result.TargetID,
 			ServiceID:    result.ServiceID,
 			ServiceName:  result.ServiceName,
+			Image:        result.Image,
+			Tag:          result.Tag,
+			Registry:     result.Registry,
+			Platform:     result.Platform,
 			OldDigest:    result.OldDigest,
 			NewDigest:    result.NewDigest,
 			Success:      result.Success,
 			RolledBack:   result.RollbackPerformed,
 			ErrorMessage: message,
+			ErrorCode:    string(result.ErrorCode),
 			StartedAt:    result.StartedAt,
 			CompletedAt:  completedAt,
 			ProbesPassed: probesPassed,
 			ProbesFailed: probesFailed,
 			DurationSec:  durationSec,
+			Attempts:     result.Attempts,
+			ResultCode:   string(result.ResultCode),
+			ReasonCode:   string(result.ReasonCode),
+			Skipped:      result.ResultCode.IsSkip(),
+			SBOM:         result.SBOM,
+			Timings:      result.Timings,
+			Kind:         string(result.Kind),
+			PreviousTag:  result.PreviousTag,
+
+			ConfigChanges: result.ConfigChanges,
+			Resources:     result.Resources,
+			Trigger:       string(result.Trigger),
+			Actor:         result.Actor,
 		})
 	}
 	return items
@@ -332,7 +1054,11 @@ func FilterHistory(items []HistoryItem, filter HistoryFilter) []HistoryItem {
 					continue
 				}
 			case "failed":
-				if item.Success {
+				if item.Success || item.Skipped {
+					continue
+				}
+			case "skipped":
+				if !item.Skipped {
 					continue
 				}
 			case "rolled_back":
@@ -341,6 +1067,27 @@ func FilterHistory(items []HistoryItem, filter HistoryFilter) []HistoryItem {
 				}
 			}
 		}
+		if filter.ResultCode != "" && item.ResultCode != filter.ResultCode {
+			continue
+		}
+		if filter.ReasonCode != "" && item.ReasonCode != filter.ReasonCode {
+			continue
+		}
+		if filter.Trigger != "" && item.Trigger != filter.Trigger {
+			continue
+		}
+		if filter.Actor != "" && item.Actor != filter.Actor {
+			continue
+		}
+		if filter.Kind != "" {
+			kind := item.Kind
+			if kind == "" {
+				kind = "digest"
+			}
+			if kind != filter.Kind {
+				continue
+			}
+		}
 		result = append(result, item)
 	}
 	return result
@@ -2,6 +2,9 @@ package planner
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -31,6 +34,16 @@ func (s stubRegistry) FetchDigest(ctx context.Context, image string) (string, er
 	return s.digest, nil
 }
 
+type mapRegistry map[string]string
+
+func (m mapRegistry) FetchDigest(ctx context.Context, image string) (string, error) {
+	digest, ok := m[image]
+	if !ok {
+		return "", fmt.Errorf("manifest unknown: %s", image)
+	}
+	return digest, nil
+}
+
 type countingRegistry struct {
 	mu    sync.Mutex
 	calls map[string]int
@@ -47,6 +60,22 @@ func (c *countingRegistry) FetchDigest(ctx context.Context, image string) (strin
 	return "sha256:new", nil
 }
 
+type stubHasher map[string]string
+
+func (s stubHasher) ConfigHash(ctx context.Context, composePath string) (string, error) {
+	return s[composePath], nil
+}
+
+type stubSettings map[string]string
+
+func (s stubSettings) GetSetting(ctx context.Context, key string) (string, error) {
+	value, ok := s[key]
+	if !ok {
+		return "", fmt.Errorf("setting not found: %s", key)
+	}
+	return value, nil
+}
+
 func TestPlannerBuildPlan(t *testing.T) {
 	labels := state.DefaultLabels()
 	labels.Enabled = true
@@ -110,6 +139,46 @@ func TestPlannerBuildPlan(t *testing.T) {
 	}
 }
 
+type stubTags map[string]string
+
+func (s stubTags) SuggestVersionTag(ctx context.Context, image string) (string, error) {
+	return s[image], nil
+}
+
+func TestPlannerFlagsMutableTags(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+	target := state.Target{
+		ID:   "demo",
+		Type: state.TargetTypeCompose,
+		Name: "demo",
+		Services: []state.Service{
+			{ID: "web", Name: "web", Image: "nginx:latest", CurrentDigest: "sha256:old", Labels: labels},
+			{ID: "db", Name: "db", Image: "postgres:16", CurrentDigest: "sha256:old", Labels: labels},
+		},
+	}
+	plannerSvc := NewPlanner(logging.Default(), stubDiscoverer{targets: []state.Target{target}}, stubRegistry{digest: "sha256:old"}, policy.NewEngine(logging.Default())).
+		WithTagSuggestions(stubTags{"nginx:latest": "1.27.3"})
+
+	plan, err := plannerSvc.BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan.MutableTagCount != 1 {
+		t.Fatalf("expected one mutable tag, got %d", plan.MutableTagCount)
+	}
+	web, db := plan.Items[0], plan.Items[1]
+	if web.MutableTag != "latest" || web.SuggestedTag != "1.27.3" {
+		t.Errorf("unexpected web item %+v", web)
+	}
+	if len(web.Warnings) == 0 || !strings.Contains(web.Warnings[len(web.Warnings)-1], "such as 1.27.3") {
+		t.Errorf("expected a mutable tag warning with the suggestion, got %v", web.Warnings)
+	}
+	if db.MutableTag != "" || db.SuggestedTag != "" {
+		t.Errorf("expected no mutable tag for a version tag, got %+v", db)
+	}
+}
+
 func TestMapHistoryClampsInvalidCompletionTimes(t *testing.T) {
 	started := time.Date(2026, 2, 9, 20, 40, 49, 0, time.UTC)
 
@@ -193,3 +262,553 @@ func TestPlannerBuildPlanDeduplicatesDigestFetchesByImage(t *testing.T) {
 		t.Fatalf("expected one digest fetch for nginx:latest, got %d", registry.calls["nginx:latest"])
 	}
 }
+
+func TestFilterHistorySeparatesSkipsFromFailures(t *testing.T) {
+	items := MapHistory([]state.UpdateResult{
+		{ServiceID: "a", Success: true, ResultCode: state.ResultSuccess},
+		{ServiceID: "b", ResultCode: state.ResultPullFailed},
+		{ServiceID: "c", ResultCode: state.ResultPolicyBlocked},
+	})
+
+	failed := FilterHistory(items, HistoryFilter{Result: "failed"})
+	if len(failed) != 1 || failed[0].ServiceID != "b" {
+		t.Fatalf("expected only the pull failure, got %+v", failed)
+	}
+
+	skipped := FilterHistory(items, HistoryFilter{Result: "skipped"})
+	if len(skipped) != 1 || skipped[0].ServiceID != "c" || !skipped[0].Skipped {
+		t.Fatalf("expected only the policy skip, got %+v", skipped)
+	}
+
+	byCode := FilterHistory(items, HistoryFilter{ResultCode: "pull_failed"})
+	if len(byCode) != 1 || byCode[0].ServiceID != "b" {
+		t.Fatalf("expected result_code filter to match pull failure, got %+v", byCode)
+	}
+}
+
+func TestPlannerFlagsComposeConfigDrift(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+
+	newTarget := func(name string) state.Target {
+		path := "/docker_data/" + name + "/compose.yml"
+		id := state.GenerateTargetID(state.TargetTypeCompose, name, path)
+		return state.Target{
+			ID:   id,
+			Type: state.TargetTypeCompose,
+			Name: name,
+			Path: path,
+			Services: []state.Service{
+				{ID: state.GenerateServiceID(id, "web"), TargetID: id, Name: "web", Image: "nginx:latest", CurrentDigest: "sha256:old", Labels: labels},
+			},
+		}
+	}
+	edited := newTarget("edited")
+	unchanged := newTarget("unchanged")
+	fresh := newTarget("fresh")
+
+	hasher := stubHasher{edited.Path: "hash-2", unchanged.Path: "hash-1", fresh.Path: "hash-1"}
+	settings := stubSettings{
+		state.ConfigHashSettingKey(edited.ID):    "hash-1",
+		state.ConfigHashSettingKey(unchanged.ID): "hash-1",
+	}
+
+	plannerSvc := NewPlanner(
+		logging.Default(),
+		stubDiscoverer{targets: []state.Target{edited, unchanged, fresh}},
+		stubRegistry{digest: "sha256:new"},
+		policy.NewEngine(logging.Default()),
+	).WithConfigDrift(hasher, settings)
+
+	plan, err := plannerSvc.BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, item := range plan.Items {
+		wantDrift := item.TargetName == "edited"
+		if item.ConfigDrift != wantDrift {
+			t.Errorf("%s: expected config_drift=%v, got %v", item.TargetName, wantDrift, item.ConfigDrift)
+		}
+		hasWarning := false
+		for _, warning := range item.Warnings {
+			if warning == configDriftWarning {
+				hasWarning = true
+			}
+		}
+		if hasWarning != wantDrift {
+			t.Errorf("%s: expected drift warning=%v, got warnings %v", item.TargetName, wantDrift, item.Warnings)
+		}
+	}
+}
+
+func TestPlannerReportsBaseImageUpdates(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+
+	targetID := state.GenerateTargetID(state.TargetTypeCompose, "app", "/docker_data/app/compose.yml")
+	target := state.Target{
+		ID:   targetID,
+		Type: state.TargetTypeCompose,
+		Name: "app",
+		Path: "/docker_data/app/compose.yml",
+		Services: []state.Service{
+			{
+				ID: state.GenerateServiceID(targetID, "api"), TargetID: targetID, Name: "api",
+				Image: "local/api:latest", CurrentDigest: "sha256:local", Labels: labels,
+				BaseImage: &state.BaseImage{Name: "alpine:3.20", Digest: "sha256:base-old"},
+			},
+			{
+				ID: state.GenerateServiceID(targetID, "worker"), TargetID: targetID, Name: "worker",
+				Image: "local/worker:latest", CurrentDigest: "sha256:local2", Labels: labels,
+				BaseImage: &state.BaseImage{Name: "debian:12", Digest: "sha256:debian"},
+			},
+		},
+	}
+
+	plannerSvc := NewPlanner(
+		logging.Default(),
+		stubDiscoverer{targets: []state.Target{target}},
+		mapRegistry{"alpine:3.20": "sha256:base-new", "debian:12": "sha256:debian"},
+		policy.NewEngine(logging.Default()),
+	)
+
+	plan, err := plannerSvc.BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan.BaseUpdateCount != 1 {
+		t.Fatalf("expected 1 base update, got %d", plan.BaseUpdateCount)
+	}
+	if plan.UpdateCount != 0 {
+		t.Fatalf("expected base updates to be excluded from update count, got %d", plan.UpdateCount)
+	}
+	for _, item := range plan.Items {
+		if want := item.ServiceName == "api"; item.BaseUpdate != want {
+			t.Errorf("%s: expected base_update_available=%v", item.ServiceName, want)
+		}
+	}
+}
+
+func TestPlannerRebuildsBuildServicesOnBaseUpdate(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+	labels.Policy = state.PolicyAggressive
+	labels.Build = true
+
+	targetID := state.GenerateTargetID(state.TargetTypeCompose, "app", "/docker_data/app/compose.yml")
+	newService := func(name, baseDigest string) state.Service {
+		return state.Service{
+			ID: state.GenerateServiceID(targetID, name), TargetID: targetID, Name: name,
+			Image: "app-" + name, CurrentDigest: "sha256:" + name, Labels: labels, Build: true,
+			BaseImage: &state.BaseImage{Name: "alpine:3.20", Digest: baseDigest},
+		}
+	}
+	target := state.Target{
+		ID:       targetID,
+		Type:     state.TargetTypeCompose,
+		Name:     "app",
+		Path:     "/docker_data/app/compose.yml",
+		Services: []state.Service{newService("stale", "sha256:old"), newService("fresh", "sha256:new")},
+	}
+
+	// The registry only knows the base image; build services must not be looked up.
+	plannerSvc := NewPlanner(
+		logging.Default(),
+		stubDiscoverer{targets: []state.Target{target}},
+		mapRegistry{"alpine:3.20": "sha256:new"},
+		policy.NewEngine(logging.Default()),
+	)
+
+	plan, err := plannerSvc.BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan.UpdateCount != 1 || plan.BaseUpdateCount != 0 {
+		t.Fatalf("expected 1 rebuild and no informational base updates, got updates=%d base=%d", plan.UpdateCount, plan.BaseUpdateCount)
+	}
+	for _, item := range plan.Items {
+		if !item.Build {
+			t.Errorf("%s: expected build item", item.ServiceName)
+		}
+		if want := item.ServiceName == "stale"; item.UpdateAvailable != want {
+			t.Errorf("%s: expected update_available=%v (reason %q)", item.ServiceName, want, item.Reason)
+		}
+	}
+}
+
+type stubIgnores []state.IgnoredUpdate
+
+func (s stubIgnores) ListIgnoredUpdates(ctx context.Context) ([]state.IgnoredUpdate, error) {
+	return s, nil
+}
+
+func TestPlannerSkipsIgnoredUpdates(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+
+	targetID := state.GenerateTargetID(state.TargetTypeCompose, "app", "/docker_data/app/compose.yml")
+	target := state.Target{
+		ID:   targetID,
+		Type: state.TargetTypeCompose,
+		Name: "app",
+		Path: "/docker_data/app/compose.yml",
+		Services: []state.Service{
+			{ID: "web", TargetID: targetID, Name: "web", Image: "nginx:latest", CurrentDigest: "sha256:old", Labels: labels},
+			{ID: "db", TargetID: targetID, Name: "db", Image: "postgres:16", CurrentDigest: "sha256:old", Labels: labels},
+		},
+	}
+
+	plannerSvc := NewPlanner(
+		logging.Default(),
+		stubDiscoverer{targets: []state.Target{target}},
+		mapRegistry{"nginx:latest": "sha256:new", "postgres:16": "sha256:newer"},
+		policy.NewEngine(logging.Default()),
+	).WithIgnoredUpdates(stubIgnores{
+		{ServiceID: "web", Digest: "sha256:new"},
+		// A newer digest than the ignored one is offered again.
+		{ServiceID: "db", Digest: "sha256:new"},
+	})
+
+	plan, err := plannerSvc.BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan.UpdateCount != 1 || plan.IgnoredCount != 1 {
+		t.Fatalf("expected 1 update and 1 ignored, got %d and %d", plan.UpdateCount, plan.IgnoredCount)
+	}
+	for _, item := range plan.Items {
+		ignored := item.ServiceName == "web"
+		if item.Ignored != ignored || item.UpdateAvailable == ignored {
+			t.Errorf("%s: expected ignored=%v, got %+v", item.ServiceName, ignored, item)
+		}
+		if ignored && item.ReasonCode != state.ReasonIgnored {
+			t.Errorf("expected reason code %s, got %s", state.ReasonIgnored, item.ReasonCode)
+		}
+	}
+}
+
+type stubSnoozes []state.Snooze
+
+func (s stubSnoozes) ListSnoozes(ctx context.Context, now time.Time) ([]state.Snooze, error) {
+	return s, nil
+}
+
+func TestPlannerHoldsBackSnoozedServices(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+
+	targetID := state.GenerateTargetID(state.TargetTypeCompose, "app", "/docker_data/app/compose.yml")
+	target := state.Target{
+		ID:   targetID,
+		Type: state.TargetTypeCompose,
+		Name: "app",
+		Path: "/docker_data/app/compose.yml",
+		Services: []state.Service{
+			{ID: "web", TargetID: targetID, Name: "web", Image: "nginx:latest", CurrentDigest: "sha256:old", Labels: labels},
+			{ID: "db", TargetID: targetID, Name: "db", Image: "postgres:16", CurrentDigest: "sha256:old", Labels: labels},
+		},
+	}
+	until := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)
+
+	plannerSvc := NewPlanner(
+		logging.Default(),
+		stubDiscoverer{targets: []state.Target{target}},
+		stubRegistry{digest: "sha256:new"},
+		policy.NewEngine(logging.Default()),
+	).WithSnoozes(stubSnoozes{{ServiceID: "web", Until: until}})
+
+	plan, err := plannerSvc.BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan.UpdateCount != 2 || plan.AllowedCount != 1 {
+		t.Fatalf("expected 2 updates with 1 allowed, got %d and %d", plan.UpdateCount, plan.AllowedCount)
+	}
+	for _, item := range plan.Items {
+		if item.ServiceName != "web" {
+			if item.SnoozedUntil != nil || !item.Allowed {
+				t.Errorf("expected db to be unaffected, got %+v", item)
+			}
+			continue
+		}
+		if item.Allowed || item.SnoozedUntil == nil || !item.SnoozedUntil.Equal(until) {
+			t.Errorf("expected web to be held back, got %+v", item)
+		}
+		if item.Reason != "Snoozed until 2026-10-20 08:00 UTC" || item.ReasonCode != state.ReasonSnoozed {
+			t.Errorf("unexpected reason: %s (%s)", item.Reason, item.ReasonCode)
+		}
+	}
+}
+
+type stubPauses []state.GroupPause
+
+func (s stubPauses) ListPausedGroups(ctx context.Context) ([]state.GroupPause, error) {
+	return s, nil
+}
+
+func TestPlannerGroups(t *testing.T) {
+	grouped := state.DefaultLabels()
+	grouped.Enabled = true
+	grouped.Group = "media"
+	plain := state.DefaultLabels()
+	plain.Enabled = true
+
+	targets := []state.Target{
+		{ID: "t1", Type: state.TargetTypeCompose, Name: "sonarr", Services: []state.Service{
+			{ID: "s1", TargetID: "t1", Name: "app", Image: "sonarr:latest", CurrentDigest: "sha256:old", Labels: plain},
+			{ID: "s2", TargetID: "t1", Name: "worker", Image: "sonarr:latest", CurrentDigest: "sha256:old", Labels: grouped},
+		}},
+		{ID: "t2", Type: state.TargetTypeCompose, Name: "blog", Services: []state.Service{
+			{ID: "s3", TargetID: "t2", Name: "web", Image: "nginx:latest", CurrentDigest: "sha256:old", Labels: plain},
+		}},
+	}
+	newPlanner := func() *Planner {
+		return NewPlanner(
+			logging.Default(),
+			stubDiscoverer{targets: targets},
+			stubRegistry{digest: "sha256:new"},
+			policy.NewEngine(logging.Default()),
+		)
+	}
+
+	plan, err := newPlanner().BuildPlan(context.Background(), PlanOptions{Root: "/docker_data", Group: "media"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan.TargetCount != 1 || len(plan.Items) != 2 {
+		t.Fatalf("expected only the media target, got %d targets and %d items", plan.TargetCount, len(plan.Items))
+	}
+	for _, item := range plan.Items {
+		if item.Group != "media" {
+			t.Errorf("expected every service of the target in the group, got %+v", item)
+		}
+	}
+
+	plan, err = newPlanner().WithPausedGroups(stubPauses{{Group: "media"}}).
+		BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if plan.UpdateCount != 3 || plan.AllowedCount != 1 {
+		t.Fatalf("expected 3 updates with 1 allowed, got %d and %d", plan.UpdateCount, plan.AllowedCount)
+	}
+	for _, item := range plan.Items {
+		if item.TargetName == "blog" {
+			if item.Paused || !item.Allowed {
+				t.Errorf("expected blog to be unaffected, got %+v", item)
+			}
+			continue
+		}
+		if !item.Paused || item.Allowed || item.Reason != "Group media is paused" {
+			t.Errorf("expected %s to be held back, got %+v", item.ServiceName, item)
+		}
+	}
+}
+
+type stubImages map[string]bool
+
+func (s stubImages) HasImage(ctx context.Context, ref string) (bool, error) {
+	return s[ref], nil
+}
+
+type stubManifests map[string]bool
+
+func (s stubManifests) ManifestExists(ctx context.Context, image, digest string) (bool, error) {
+	exists, ok := s[digest]
+	if !ok {
+		return false, fmt.Errorf("registry unavailable")
+	}
+	return exists, nil
+}
+
+func TestPlannerChecksRollbackCandidates(t *testing.T) {
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+
+	targetID := state.GenerateTargetID(state.TargetTypeCompose, "app", "/docker_data/app/compose.yml")
+	service := func(name, image, digest string) state.Service {
+		return state.Service{ID: state.GenerateServiceID(targetID, name), TargetID: targetID, Name: name, Image: image, CurrentDigest: digest, Labels: labels}
+	}
+	target := state.Target{
+		ID:   targetID,
+		Type: state.TargetTypeCompose,
+		Name: "app",
+		Path: "/docker_data/app/compose.yml",
+		Services: []state.Service{
+			service("local", "nginx:1.27", "sha256:local"),
+			service("pullable", "redis:7", "sha256:pullable"),
+			service("collected", "registry.example.com:5000/api:2", "sha256:collected"),
+			service("unknown", "postgres:16", "sha256:unknown"),
+			service("current", "caddy:2", "sha256:new"),
+		},
+	}
+
+	plannerSvc := NewPlanner(
+		logging.Default(),
+		stubDiscoverer{targets: []state.Target{target}},
+		stubRegistry{digest: "sha256:new"},
+		policy.NewEngine(logging.Default()),
+	).WithRollbackCheck(
+		stubImages{"nginx@sha256:local": true},
+		stubManifests{"sha256:pullable": true, "sha256:collected": false},
+	)
+
+	plan, err := plannerSvc.BuildPlan(context.Background(), PlanOptions{Root: "/docker_data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := map[string]string{"local": "local", "pullable": "registry", "collected": "unavailable", "unknown": "", "current": ""}
+	for _, item := range plan.Items {
+		if item.Rollback != want[item.ServiceName] {
+			t.Errorf("%s: expected rollback %q, got %q", item.ServiceName, want[item.ServiceName], item.Rollback)
+		}
+		degraded := item.ServiceName == "collected"
+		if item.RollbackDegraded != degraded {
+			t.Errorf("%s: expected rollback_degraded=%v", item.ServiceName, degraded)
+		}
+		if hasWarning := strings.Contains(strings.Join(item.Warnings, "\n"), rollbackDegradedWarning); hasWarning != degraded {
+			t.Errorf("%s: expected the rollback warning only when degraded, got %v", item.ServiceName, item.Warnings)
+		}
+	}
+}
+
+func TestDigestReference(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                          "nginx@sha256:abc",
+		"nginx:1.27":                     "nginx@sha256:abc",
+		"registry:5000/app":              "registry:5000/app@sha256:abc",
+		"registry:5000/app:2@sha256:old": "registry:5000/app@sha256:abc",
+		"ghcr.io/org/app:latest":         "ghcr.io/org/app@sha256:abc",
+	}
+	for image, want := range tests {
+		if got := digestReference(image, "sha256:abc"); got != want {
+			t.Errorf("%s: got %s, want %s", image, got, want)
+		}
+	}
+}
+
+func TestOrderByDependenciesPlacesDependenciesFirst(t *testing.T) {
+	items := []PlanItem{
+		{TargetName: "app", ServiceName: "web", DependsOn: []string{"db"}},
+		{TargetName: "app", ServiceName: "worker"},
+		{TargetName: "metrics", ServiceName: "grafana", DependsOn: []string{"external"}},
+		{TargetName: "db", ServiceName: "postgres"},
+	}
+
+	p := NewPlanner(logging.Default(), nil, nil, nil)
+	ordered := p.orderByDependencies(items)
+
+	var got []string
+	for _, item := range ordered {
+		got = append(got, item.TargetName+"/"+item.ServiceName)
+	}
+	want := []string{"metrics/grafana", "db/postgres", "app/web", "app/worker"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("order = %v, want %v", got, want)
+	}
+}
+
+func TestOrderByDependenciesToleratesCycles(t *testing.T) {
+	items := []PlanItem{
+		{TargetName: "a", DependsOn: []string{"b"}},
+		{TargetName: "b", DependsOn: []string{"a"}},
+	}
+
+	ordered := NewPlanner(logging.Default(), nil, nil, nil).orderByDependencies(items)
+	if len(ordered) != 2 || ordered[0].TargetName != "a" || ordered[1].TargetName != "b" {
+		t.Fatalf("expected original order for a cycle, got %+v", ordered)
+	}
+}
+
+func TestDependentItems(t *testing.T) {
+	items := []PlanItem{
+		{TargetName: "db", ServiceID: "db-postgres"},
+		{TargetName: "app", ServiceID: "app-web", DependsOn: []string{"db"}},
+		{TargetName: "app", ServiceID: "app-worker", DependsOn: []string{"db"}},
+		{TargetName: "cache", ServiceID: "cache-redis", DependsOn: []string{"queue"}},
+	}
+
+	dependents := DependentItems(items, map[string]bool{"db": true}, map[string]bool{"db-postgres": true, "app-worker": true})
+	if len(dependents) != 1 || dependents[0].ServiceID != "app-web" {
+		t.Fatalf("expected only app-web to be refreshed, got %+v", dependents)
+	}
+}
+
+func TestUpdateBatchesGroupsParallelProjectServices(t *testing.T) {
+	project := &state.Target{ID: "t1", Type: state.TargetTypeCompose, Name: "app"}
+	parallel := func(name string, deps ...string) PlanItem {
+		labels := state.DefaultLabels()
+		labels.Parallel = true
+		return PlanItem{
+			TargetID:    project.ID,
+			ServiceName: name,
+			Target:      project,
+			Service:     &state.Service{Name: name, Labels: labels, DependsOn: deps},
+		}
+	}
+	serial := PlanItem{
+		TargetID:    "t2",
+		ServiceName: "db",
+		Target:      &state.Target{ID: "t2", Type: state.TargetTypeCompose},
+		Service:     &state.Service{Name: "db", Labels: state.DefaultLabels()},
+	}
+
+	batches := UpdateBatches([]PlanItem{
+		serial,
+		parallel("api", "cache"),
+		parallel("cache"),
+		parallel("worker"),
+		parallel("ui", "api", "postgres"),
+	})
+
+	var got [][]string
+	for _, batch := range batches {
+		var names []string
+		for _, item := range batch {
+			names = append(names, item.ServiceName)
+		}
+		got = append(got, names)
+	}
+	want := [][]string{{"db"}, {"cache", "worker"}, {"api"}, {"ui"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected batches: %v", got)
+	}
+}
+
+func TestUpdateBatchesSerializesDependencyCycles(t *testing.T) {
+	project := &state.Target{ID: "t1", Type: state.TargetTypeCompose}
+	labels := state.DefaultLabels()
+	labels.Parallel = true
+	items := []PlanItem{
+		{TargetID: "t1", ServiceName: "a", Target: project, Service: &state.Service{Name: "a", Labels: labels, DependsOn: []string{"b"}}},
+		{TargetID: "t1", ServiceName: "b", Target: project, Service: &state.Service{Name: "b", Labels: labels, DependsOn: []string{"a"}}},
+	}
+
+	batches := UpdateBatches(items)
+	if len(batches) != 2 || len(batches[0]) != 1 || len(batches[1]) != 1 {
+		t.Fatalf("expected cycle to update one at a time, got %d batches", len(batches))
+	}
+}
+
+func TestItemWarningsFlagsUnsupportedUpFlags(t *testing.T) {
+	p := NewPlanner(logging.Default(), nil, nil, policy.NewEngine(logging.Default()))
+	labels := state.DefaultLabels()
+	labels.Enabled = true
+	labels.ComposeUpFlags = []string{"--remove-orphans", "--pull=always"}
+
+	warnings := p.itemWarnings(PlanItem{Service: &state.Service{Name: "web", Labels: labels}})
+
+	found := 0
+	for _, warning := range warnings {
+		if strings.Contains(warning, "--pull=always") {
+			found++
+		}
+		if strings.Contains(warning, "--remove-orphans") {
+			t.Errorf("allowed flag reported: %s", warning)
+		}
+	}
+	if found != 1 {
+		t.Errorf("expected one warning for --pull=always, got %v", warnings)
+	}
+}
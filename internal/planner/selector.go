@@ -0,0 +1,227 @@
+package planner
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// Selector picks plan items by what they are rather than by ID, so
+// automation can say "everything from linuxserver" without resolving
+// service IDs first:
+//
+//	{"images": ["linuxserver/*"], "risks": ["safe"]}
+//
+// Every field that is set must match; within a field, one entry matching
+// is enough. An empty selector matches every item.
+type Selector struct {
+	// Targets and Services are globs on the target and service names.
+	Targets  []string `json:"targets,omitempty"`
+	Services []string `json:"services,omitempty"`
+	// Images are globs on the image, matched against the reference as
+	// written and its repository with and without the registry, so
+	// "linuxserver/*" matches lscr.io/linuxserver/sonarr:latest. A pattern
+	// ending in /* also matches everything below it, as "lscr.io/*" does.
+	Images []string `json:"images,omitempty"`
+	// Risks are risk classes: safe, notify, stateful or probe_missing.
+	Risks []string `json:"risks,omitempty"`
+	// Policies are update policies: notify, safe or aggressive.
+	Policies []string `json:"policies,omitempty"`
+	// Labels are key=value or key!=value selectors on the service's
+	// Bulwark labels, e.g. "tier=stateless" or "bulwark.group!=media".
+	Labels []string `json:"labels,omitempty"`
+	// Exclude are globs on the service name of items never selected, even
+	// when everything else matches.
+	Exclude []string `json:"exclude,omitempty"`
+}
+
+// selectorLabels are the label keys a selector may test, without the
+// bulwark. prefix.
+var selectorLabels = []string{"policy", "tier", "group", "strategy", "probe.type"}
+
+// Empty reports whether s selects by nothing and so matches every item.
+func (s *Selector) Empty() bool {
+	return s == nil || len(s.Targets)+len(s.Services)+len(s.Images)+len(s.Risks)+len(s.Policies)+len(s.Labels)+len(s.Exclude) == 0
+}
+
+// Validate reports the first malformed glob, unknown risk class or policy,
+// or unsupported label selector of s.
+func (s *Selector) Validate() error {
+	if s == nil {
+		return nil
+	}
+	for _, patterns := range [][]string{s.Targets, s.Services, s.Images, s.Exclude} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil || strings.TrimSpace(pattern) == "" {
+				return fmt.Errorf("invalid pattern %q", pattern)
+			}
+		}
+	}
+	for _, risk := range s.Risks {
+		switch risk {
+		case RiskSafe, RiskNotifyOnly, RiskStateful, RiskProbeMissing:
+		default:
+			return fmt.Errorf("unknown risk %q: use %s, %s, %s or %s", risk, RiskSafe, RiskNotifyOnly, RiskStateful, RiskProbeMissing)
+		}
+	}
+	for _, policy := range s.Policies {
+		switch state.Policy(policy) {
+		case state.PolicyNotify, state.PolicySafe, state.PolicyAggressive:
+		default:
+			return fmt.Errorf("unknown policy %q: use %s, %s or %s", policy, state.PolicyNotify, state.PolicySafe, state.PolicyAggressive)
+		}
+	}
+	for _, selector := range s.Labels {
+		key, _, _, err := parseLabelSelector(selector)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(selectorLabels, key) {
+			return fmt.Errorf("label %q cannot be selected on: use one of bulwark.%s", key, strings.Join(selectorLabels, ", bulwark."))
+		}
+	}
+	return nil
+}
+
+// Match reports whether item is selected by s.
+func (s *Selector) Match(item PlanItem) bool {
+	if s.Empty() {
+		return true
+	}
+	if matchAny(s.Exclude, item.ServiceName) {
+		return false
+	}
+	if len(s.Targets) > 0 && !matchAny(s.Targets, item.TargetName) {
+		return false
+	}
+	if len(s.Services) > 0 && !matchAny(s.Services, item.ServiceName) {
+		return false
+	}
+	if len(s.Images) > 0 && !matchImage(s.Images, item.Image) {
+		return false
+	}
+	if len(s.Risks) > 0 && !slices.Contains(s.Risks, item.Risk) {
+		return false
+	}
+	if len(s.Policies) > 0 && !slices.Contains(s.Policies, string(item.Policy)) {
+		return false
+	}
+	if len(s.Labels) > 0 {
+		labels := itemLabels(item)
+		for _, selector := range s.Labels {
+			key, value, negate, err := parseLabelSelector(selector)
+			if err != nil || (labels[key] == value) == negate {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// MatchService reports whether service of target is selected by s, judged
+// by its labels alone, e.g. before its remote digest is looked up.
+func (s *Selector) MatchService(target *state.Target, service *state.Service) bool {
+	return s.Match(PlanItem{
+		TargetName:  target.Name,
+		ServiceName: service.Name,
+		Image:       service.Image,
+		Policy:      service.Labels.Policy,
+		Tier:        service.Labels.Tier,
+		Probe:       service.Labels.Probe,
+		Group:       target.Group(),
+		Risk:        RiskFromLabels(service.Labels),
+		Service:     service,
+	})
+}
+
+// Select returns the items of items selected by s.
+func (s *Selector) Select(items []PlanItem) []PlanItem {
+	selected := make([]PlanItem, 0, len(items))
+	for _, item := range items {
+		if s.Match(item) {
+			selected = append(selected, item)
+		}
+	}
+	return selected
+}
+
+// parseLabelSelector splits "key=value" or "key!=value", dropping the
+// bulwark. prefix from key.
+func parseLabelSelector(selector string) (key, value string, negate bool, err error) {
+	key, value, ok := strings.Cut(selector, "=")
+	if !ok {
+		return "", "", false, fmt.Errorf("invalid label selector %q: use key=value or key!=value", selector)
+	}
+	if strings.HasSuffix(key, "!") {
+		key, negate = strings.TrimSuffix(key, "!"), true
+	}
+	key = strings.TrimPrefix(strings.TrimSpace(key), "bulwark.")
+	if key == "" {
+		return "", "", false, fmt.Errorf("invalid label selector %q: the key is empty", selector)
+	}
+	return key, strings.TrimSpace(value), negate, nil
+}
+
+// itemLabels returns the values of the selectable labels of item, as they
+// apply after defaults.
+func itemLabels(item PlanItem) map[string]string {
+	strategy := state.StrategyRecreate
+	if item.Service != nil && item.Service.Labels.Strategy != "" {
+		strategy = item.Service.Labels.Strategy
+	}
+	return map[string]string{
+		"policy":     string(item.Policy),
+		"tier":       string(item.Tier),
+		"group":      item.Group,
+		"strategy":   string(strategy),
+		"probe.type": string(item.Probe.Type),
+	}
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchImage matches image against glob patterns, trying the reference as
+// written and its repository with and without the registry, and falls
+// back to registry.MatchesImage so "nginx" matches docker.io/library/nginx.
+// A pattern ending in /* matches everything below it, so "lscr.io/*"
+// matches lscr.io/linuxserver/sonarr.
+func matchImage(patterns []string, image string) bool {
+	candidates := []string{image}
+	if ref, err := registry.ParseImageReference(image); err == nil {
+		candidates = append(candidates,
+			ref.Repository,
+			strings.TrimPrefix(ref.Repository, "library/"),
+			ref.Registry+"/"+ref.Repository,
+		)
+	}
+	for _, pattern := range patterns {
+		for _, candidate := range candidates {
+			if ok, _ := path.Match(pattern, candidate); ok {
+				return true
+			}
+			if !strings.HasSuffix(pattern, "/*") {
+				continue
+			}
+			for i := strings.LastIndex(candidate, "/"); i > 0; i = strings.LastIndex(candidate[:i], "/") {
+				if ok, _ := path.Match(pattern, candidate[:i]); ok {
+					return true
+				}
+			}
+		}
+		if registry.MatchesImage(pattern, image) {
+			return true
+		}
+	}
+	return false
+}
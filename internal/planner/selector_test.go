@@ -0,0 +1,88 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestSelector(t *testing.T) {
+	items := []PlanItem{
+		{TargetName: "media", ServiceName: "sonarr", Image: "lscr.io/linuxserver/sonarr:latest", Risk: RiskSafe, Policy: state.PolicySafe, Tier: state.TierStateless, Group: "arr"},
+		{TargetName: "media", ServiceName: "radarr", Image: "linuxserver/radarr", Risk: RiskSafe, Policy: state.PolicySafe, Tier: state.TierStateless, Group: "arr"},
+		{TargetName: "db", ServiceName: "postgres", Image: "postgres:16", Risk: RiskStateful, Policy: state.PolicyNotify, Tier: state.TierStateful,
+			Service: &state.Service{Labels: state.Labels{Strategy: state.StrategyBlueGreen}}},
+		{TargetName: "web", ServiceName: "nginx", Image: "nginx:1.27", Risk: RiskProbeMissing, Policy: state.PolicyAggressive, Tier: state.TierStateless},
+	}
+	names := func(s *Selector) []string {
+		var out []string
+		for _, item := range s.Select(items) {
+			out = append(out, item.ServiceName)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name     string
+		selector *Selector
+		want     []string
+	}{
+		{"nil selects all", nil, []string{"sonarr", "radarr", "postgres", "nginx"}},
+		{"image glob across registries", &Selector{Images: []string{"linuxserver/*"}}, []string{"sonarr", "radarr"}},
+		{"image with registry", &Selector{Images: []string{"lscr.io/*"}}, []string{"sonarr"}},
+		{"plain image name", &Selector{Images: []string{"nginx"}}, []string{"nginx"}},
+		{"target glob", &Selector{Targets: []string{"d*"}}, []string{"postgres"}},
+		{"service glob", &Selector{Services: []string{"*arr"}}, []string{"sonarr", "radarr"}},
+		{"risk", &Selector{Risks: []string{RiskStateful, RiskProbeMissing}}, []string{"postgres", "nginx"}},
+		{"policy", &Selector{Policies: []string{"notify"}}, []string{"postgres"}},
+		{"label", &Selector{Labels: []string{"bulwark.group=arr"}}, []string{"sonarr", "radarr"}},
+		{"negated label", &Selector{Labels: []string{"tier!=stateless"}}, []string{"postgres"}},
+		{"default strategy", &Selector{Labels: []string{"strategy=recreate"}}, []string{"sonarr", "radarr", "nginx"}},
+		{"fields combine", &Selector{Targets: []string{"media"}, Services: []string{"s*"}}, []string{"sonarr"}},
+		{"exclude", &Selector{Exclude: []string{"postgres", "so*"}}, []string{"radarr", "nginx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.selector.Validate(); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+			got := names(tt.selector)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("expected %v, got %v", tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectorMatchService(t *testing.T) {
+	target := &state.Target{Name: "db"}
+	service := &state.Service{Name: "postgres", Image: "postgres:16", Labels: state.Labels{Policy: state.PolicySafe, Tier: state.TierStateful}}
+	if (&Selector{Risks: []string{RiskSafe}}).MatchService(target, service) {
+		t.Error("expected a stateful service not to match the safe risk class")
+	}
+	if !(&Selector{Risks: []string{RiskStateful}, Targets: []string{"db"}}).MatchService(target, service) {
+		t.Error("expected the stateful service of db to match")
+	}
+}
+
+func TestSelectorValidate(t *testing.T) {
+	for _, s := range []*Selector{
+		{Images: []string{"linuxserver/["}},
+		{Targets: []string{" "}},
+		{Risks: []string{"risky"}},
+		{Policies: []string{"yolo"}},
+		{Labels: []string{"tier"}},
+		{Labels: []string{"bulwark.probe.url=http://x"}},
+		{Labels: []string{"=x"}},
+		{Exclude: []string{"db["}},
+	} {
+		if err := s.Validate(); err == nil {
+			t.Errorf("expected %+v to be rejected", s)
+		}
+	}
+}
@@ -2,32 +2,91 @@ package policy
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/registry"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
 
 // Engine evaluates update policies
 type Engine struct {
 	logger *logging.Logger
+	// blockMutableTags refuses updates of images tracking tags such as
+	// latest unless the service sets bulwark.allow_mutable_tag=true.
+	blockMutableTags bool
+	// images restricts which registries and images may update automatically.
+	images ImageRules
 }
 
-// NewEngine creates a new policy engine
+// NewEngine creates a new policy engine. BULWARK_BLOCK_MUTABLE_TAGS=true
+// blocks updates of images tracking mutable tags, and the image rules come
+// from ImageRulesFromEnv.
 func NewEngine(logger *logging.Logger) *Engine {
 	return &Engine{
-		logger: logger.WithComponent("policy"),
+		logger:           logger.WithComponent("policy"),
+		blockMutableTags: strings.EqualFold(strings.TrimSpace(os.Getenv("BULWARK_BLOCK_MUTABLE_TAGS")), "true"),
+		images:           ImageRulesFromEnv(),
 	}
 }
 
+// WithImageRules replaces the registry and image allow/deny lists.
+func (e *Engine) WithImageRules(rules ImageRules) *Engine {
+	e.images = rules
+	return e
+}
+
+// WithBlockMutableTags overrides whether updates of images tracking mutable
+// tags are blocked.
+func (e *Engine) WithBlockMutableTags(block bool) *Engine {
+	e.blockMutableTags = block
+	return e
+}
+
 // Decision represents a policy decision
 type Decision struct {
 	Allowed bool
 	Reason  string
+	Code    state.ReasonCode // Identifies Reason for clients
 	Policy  state.Policy
 	Tier    state.Tier
 }
 
+// ErrPolicyBlocked is matched by the errors of updates the policy did not allow.
+var ErrPolicyBlocked = errors.New("blocked by policy")
+
+// BlockedError carries the reason a policy refused an update. Its message is
+// the reason alone, so history entries read the same as plan items.
+type BlockedError struct {
+	Reason string
+}
+
+func (e *BlockedError) Error() string {
+	return e.Reason
+}
+
+// Is makes errors.Is(err, ErrPolicyBlocked) match.
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrPolicyBlocked
+}
+
+// Blocked returns the error recorded for an update refused for reason.
+func Blocked(reason string) error {
+	return &BlockedError{Reason: reason}
+}
+
+// Err returns nil when the decision allows the update and a BlockedError
+// otherwise.
+func (d Decision) Err() error {
+	if d.Allowed {
+		return nil
+	}
+	return Blocked(d.Reason)
+}
+
 // Evaluate evaluates whether an update is allowed
 func (e *Engine) Evaluate(ctx context.Context, target *state.Target, service *state.Service, updateAvailable bool) Decision {
 	labels := service.Labels
@@ -37,6 +96,7 @@ func (e *Engine) Evaluate(ctx context.Context, target *state.Target, service *st
 		return Decision{
 			Allowed: false,
 			Reason:  "Bulwark not enabled (bulwark.enabled=true required)",
+			Code:    state.ReasonNotEnabled,
 			Policy:  labels.Policy,
 			Tier:    labels.Tier,
 		}
@@ -47,17 +107,43 @@ func (e *Engine) Evaluate(ctx context.Context, target *state.Target, service *st
 		return Decision{
 			Allowed: false,
 			Reason:  "No update available",
+			Code:    state.ReasonNoUpdate,
 			Policy:  labels.Policy,
 			Tier:    labels.Tier,
 		}
 	}
 
+	if !service.Build {
+		if reason, code := e.images.Check(service.Image); reason != "" {
+			return Decision{
+				Allowed: false,
+				Reason:  reason,
+				Code:    code,
+				Policy:  labels.Policy,
+				Tier:    labels.Tier,
+			}
+		}
+	}
+
+	if e.blockMutableTags && !labels.AllowMutableTag && !service.Build {
+		if tag, mutable := registry.MutableTag(service.Image); mutable {
+			return Decision{
+				Allowed: false,
+				Reason:  fmt.Sprintf("Image tracks mutable tag :%s; pin a version or set bulwark.allow_mutable_tag=true", tag),
+				Code:    state.ReasonMutableTag,
+				Policy:  labels.Policy,
+				Tier:    labels.Tier,
+			}
+		}
+	}
+
 	// Evaluate based on policy
 	switch labels.Policy {
 	case state.PolicyNotify:
 		return Decision{
 			Allowed: false,
 			Reason:  "Policy is 'notify' - manual updates only",
+			Code:    state.ReasonNotifyPolicy,
 			Policy:  labels.Policy,
 			Tier:    labels.Tier,
 		}
@@ -68,6 +154,7 @@ func (e *Engine) Evaluate(ctx context.Context, target *state.Target, service *st
 			return Decision{
 				Allowed: false,
 				Reason:  "Safe policy blocks stateful service updates (use aggressive to override)",
+				Code:    state.ReasonStatefulBlocked,
 				Policy:  labels.Policy,
 				Tier:    labels.Tier,
 			}
@@ -83,6 +170,7 @@ func (e *Engine) Evaluate(ctx context.Context, target *state.Target, service *st
 		return Decision{
 			Allowed: true,
 			Reason:  "Safe policy allows stateless updates with probes",
+			Code:    state.ReasonSafePolicy,
 			Policy:  labels.Policy,
 			Tier:    labels.Tier,
 		}
@@ -99,6 +187,7 @@ func (e *Engine) Evaluate(ctx context.Context, target *state.Target, service *st
 		return Decision{
 			Allowed: true,
 			Reason:  "Aggressive policy allows all updates",
+			Code:    state.ReasonAggressivePolicy,
 			Policy:  labels.Policy,
 			Tier:    labels.Tier,
 		}
@@ -107,6 +196,7 @@ func (e *Engine) Evaluate(ctx context.Context, target *state.Target, service *st
 		return Decision{
 			Allowed: false,
 			Reason:  fmt.Sprintf("Unknown policy: %s", labels.Policy),
+			Code:    state.ReasonUnknownPolicy,
 			Policy:  labels.Policy,
 			Tier:    labels.Tier,
 		}
@@ -177,7 +267,7 @@ func (e *Engine) ValidateProbeConfiguration(labels state.Labels) []string {
 		}
 
 	case state.ProbeTypeLog:
-		if labels.Probe.LogPattern == "" {
+		if labels.Probe.LogPattern == "" && labels.Probe.LogFailPattern == "" {
 			warnings = append(warnings, "Log probe configured but no pattern provided")
 		}
 
@@ -2,6 +2,7 @@ package policy
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/itsmrshow/bulwark/internal/logging"
@@ -33,3 +34,64 @@ func TestShouldRollbackReturnsTrueForFailedUpdateWithoutRollback(t *testing.T) {
 		t.Fatalf("expected true for failed update without rollback")
 	}
 }
+
+func TestDecisionErr(t *testing.T) {
+	engine := NewEngine(logging.Default())
+	service := &state.Service{Labels: state.Labels{Enabled: true, Policy: state.PolicyNotify}}
+
+	decision := engine.Evaluate(context.Background(), &state.Target{}, service, true)
+	err := decision.Err()
+	if !errors.Is(err, ErrPolicyBlocked) {
+		t.Fatalf("expected ErrPolicyBlocked, got %v", err)
+	}
+	if err.Error() != decision.Reason {
+		t.Fatalf("expected message %q, got %q", decision.Reason, err.Error())
+	}
+
+	if err := (Decision{Allowed: true}).Err(); err != nil {
+		t.Fatalf("expected nil error for an allowed decision, got %v", err)
+	}
+}
+
+func TestEvaluateBlocksMutableTags(t *testing.T) {
+	engine := NewEngine(logging.Default()).WithBlockMutableTags(true)
+	service := &state.Service{Image: "nginx:latest", Labels: state.Labels{Enabled: true, Policy: state.PolicyAggressive}}
+
+	if decision := engine.Evaluate(context.Background(), &state.Target{}, service, true); decision.Allowed {
+		t.Fatalf("expected :latest to be blocked, got %+v", decision)
+	}
+
+	service.Labels.AllowMutableTag = true
+	if decision := engine.Evaluate(context.Background(), &state.Target{}, service, true); !decision.Allowed {
+		t.Fatalf("expected the label to allow :latest, got %+v", decision)
+	}
+
+	service.Labels.AllowMutableTag = false
+	service.Image = "nginx:1.27"
+	if decision := engine.Evaluate(context.Background(), &state.Target{}, service, true); !decision.Allowed {
+		t.Fatalf("expected a version tag to be allowed, got %+v", decision)
+	}
+}
+
+func TestEvaluateReasonCodes(t *testing.T) {
+	engine := NewEngine(logging.Default()).WithBlockMutableTags(false).WithImageRules(ImageRules{})
+	tests := []struct {
+		labels          state.Labels
+		updateAvailable bool
+		want            state.ReasonCode
+	}{
+		{state.Labels{Enabled: false}, true, state.ReasonNotEnabled},
+		{state.Labels{Enabled: true, Policy: state.PolicySafe}, false, state.ReasonNoUpdate},
+		{state.Labels{Enabled: true, Policy: state.PolicyNotify}, true, state.ReasonNotifyPolicy},
+		{state.Labels{Enabled: true, Policy: state.PolicySafe, Tier: state.TierStateful}, true, state.ReasonStatefulBlocked},
+		{state.Labels{Enabled: true, Policy: state.PolicySafe, Tier: state.TierStateless}, true, state.ReasonSafePolicy},
+		{state.Labels{Enabled: true, Policy: state.PolicyAggressive, Tier: state.TierStateful}, true, state.ReasonAggressivePolicy},
+		{state.Labels{Enabled: true, Policy: "yolo"}, true, state.ReasonUnknownPolicy},
+	}
+	for _, tt := range tests {
+		service := &state.Service{Image: "nginx:1.27", Labels: tt.labels}
+		if decision := engine.Evaluate(context.Background(), &state.Target{}, service, tt.updateAvailable); decision.Code != tt.want {
+			t.Errorf("labels %+v: expected code %s, got %s (%s)", tt.labels, tt.want, decision.Code, decision.Reason)
+		}
+	}
+}
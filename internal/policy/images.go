@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// ImageRules restrict which images may update automatically. Patterns name
+// images in full registry form, e.g. ghcr.io/myorg/* or
+// docker.io/library/nginx. A "*" matches any run of characters, slashes
+// included, and a pattern without one also covers everything below it, so
+// ghcr.io/myorg covers ghcr.io/myorg/app.
+type ImageRules struct {
+	// Allowed lists the registries or repositories whose images may update
+	// automatically. Empty allows every image.
+	Allowed []string `json:"allowed,omitempty"`
+	// Denied lists images that are notify-only, whatever Allowed says.
+	Denied []string `json:"denied,omitempty"`
+}
+
+// ImageRulesFromEnv reads BULWARK_ALLOWED_REGISTRIES and
+// BULWARK_DENIED_IMAGES, both comma-separated pattern lists.
+func ImageRulesFromEnv() ImageRules {
+	return ImageRules{
+		Allowed: splitPatterns(os.Getenv("BULWARK_ALLOWED_REGISTRIES")),
+		Denied:  splitPatterns(os.Getenv("BULWARK_DENIED_IMAGES")),
+	}
+}
+
+// Check returns why image may not update automatically and the matching
+// reason code, or "" when the rules allow it.
+func (r ImageRules) Check(image string) (string, state.ReasonCode) {
+	if len(r.Allowed) == 0 && len(r.Denied) == 0 {
+		return "", ""
+	}
+	ref, err := registry.ParseImageReference(image)
+	if err != nil {
+		return fmt.Sprintf("Image %s cannot be checked against the image rules: %v", image, err), state.ReasonInvalidImage
+	}
+	name := ref.Registry + "/" + ref.Repository
+	for _, pattern := range r.Denied {
+		if matchImage(pattern, name) {
+			return fmt.Sprintf("Image %s matches denied pattern %s - notify only", name, pattern), state.ReasonImageDenied
+		}
+	}
+	if len(r.Allowed) == 0 {
+		return "", ""
+	}
+	for _, pattern := range r.Allowed {
+		if matchImage(pattern, name) {
+			return "", ""
+		}
+	}
+	return fmt.Sprintf("Image %s is not from an allowed registry (%s) - notify only", name, strings.Join(r.Allowed, ", ")), state.ReasonRegistryNotAllowed
+}
+
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		if pattern = strings.TrimSuffix(strings.TrimSpace(pattern), "/"); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// matchImage reports whether name, in registry/repository form, matches
+// pattern.
+func matchImage(pattern, name string) bool {
+	if !strings.Contains(pattern, "*") {
+		return name == pattern || strings.HasPrefix(name, pattern+"/")
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(name, parts[0]) {
+		return false
+	}
+	rest := name[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 {
+			return strings.HasSuffix(rest, part)
+		}
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+	return true
+}
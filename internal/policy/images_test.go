@@ -0,0 +1,62 @@
+package policy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+func TestImageRulesCheck(t *testing.T) {
+	rules := ImageRules{
+		Allowed: []string{"ghcr.io/myorg/*", "lscr.io", "docker.io/library"},
+		Denied:  []string{"ghcr.io/myorg/legacy-*"},
+	}
+	tests := []struct {
+		image   string
+		allowed bool
+	}{
+		{"ghcr.io/myorg/app:1.0", true},
+		{"ghcr.io/myorg/team/app:1.0", true},
+		{"lscr.io/linuxserver/radarr:latest", true},
+		{"nginx:1.27", true},
+		{"ghcr.io/myorg/legacy-api:2", false},
+		{"ghcr.io/other/app:1.0", false},
+		{"random/app:latest", false},
+		{"lscr.io.evil.com/app", false},
+	}
+	for _, tt := range tests {
+		if reason, _ := rules.Check(tt.image); (reason == "") != tt.allowed {
+			t.Errorf("Check(%q) = %q, want allowed=%v", tt.image, reason, tt.allowed)
+		}
+	}
+	if reason, _ := (ImageRules{}).Check("random/app"); reason != "" {
+		t.Errorf("expected empty rules to allow everything, got %q", reason)
+	}
+}
+
+func TestImageRulesFromEnv(t *testing.T) {
+	t.Setenv("BULWARK_ALLOWED_REGISTRIES", " ghcr.io/myorg/ ,, lscr.io")
+	t.Setenv("BULWARK_DENIED_IMAGES", "docker.io/random/*")
+	rules := ImageRulesFromEnv()
+	if strings.Join(rules.Allowed, ",") != "ghcr.io/myorg,lscr.io" || strings.Join(rules.Denied, ",") != "docker.io/random/*" {
+		t.Errorf("unexpected rules %+v", rules)
+	}
+}
+
+func TestEvaluateEnforcesImageRules(t *testing.T) {
+	engine := NewEngine(logging.Default()).WithImageRules(ImageRules{Denied: []string{"docker.io/random/*"}})
+	service := &state.Service{Image: "random/app:1.0", Labels: state.Labels{Enabled: true, Policy: state.PolicyAggressive}}
+
+	decision := engine.Evaluate(context.Background(), &state.Target{}, service, true)
+	if decision.Allowed || decision.Code != state.ReasonImageDenied || !strings.Contains(decision.Reason, "docker.io/random/*") {
+		t.Fatalf("expected the denied pattern as the reason, got %+v", decision)
+	}
+
+	service.Image = "ghcr.io/myorg/app:1.0"
+	if decision := engine.Evaluate(context.Background(), &state.Target{}, service, true); !decision.Allowed {
+		t.Fatalf("expected other images to update, got %+v", decision)
+	}
+}
@@ -40,12 +40,12 @@ func (e *Engine) ExecuteProbes(ctx context.Context, target *state.Target, servic
 		Msg("Executing health probes")
 
 	// Collect probes and any immediate error results
-	probes, errorResults := e.collectProbes(probeConfig, containerID)
+	probes, errorResults := e.collectProbes(probeConfig, service.HealthCheck, containerID)
 
 	// If we only have error results (validation failures), return them
 	if len(probes) == 0 {
 		for _, result := range errorResults {
-			e.logProbeResult(service.Name, result)
+			e.logProbeResult(target, service.Name, result)
 		}
 		return errorResults
 	}
@@ -80,7 +80,7 @@ func (e *Engine) ExecuteProbes(ctx context.Context, target *state.Target, servic
 
 	// Log detail for each result
 	for _, result := range allResults {
-		e.logProbeResult(service.Name, result)
+		e.logProbeResult(target, service.Name, result)
 	}
 
 	// Log summary
@@ -101,7 +101,8 @@ func (e *Engine) ExecuteProbes(ctx context.Context, target *state.Target, servic
 }
 
 // collectProbes builds probe instances and returns immediate error results for misconfigured probes.
-func (e *Engine) collectProbes(probeConfig state.ProbeConfig, containerID string) ([]Probe, []state.ProbeResult) {
+// healthCheck is the service's parsed healthcheck, which the stability probe waits on.
+func (e *Engine) collectProbes(probeConfig state.ProbeConfig, healthCheck *state.HealthCheck, containerID string) ([]Probe, []state.ProbeResult) {
 	var probes []Probe
 	var errorResults []state.ProbeResult
 
@@ -138,10 +139,15 @@ func (e *Engine) collectProbes(probeConfig state.ProbeConfig, containerID string
 		if stabilityWindow == 0 {
 			stabilityWindow = 10
 		}
-		probes = append(probes, NewStabilityProbe(stabilityWindow, e.config, e.logger))
+		stability := NewStabilityProbe(stabilityWindow, e.config, e.logger)
+		if e.dockerClient != nil && containerID != "" {
+			stability.WithRestartCheck(e.dockerClient, containerID, probeConfig.MaxRestarts).
+				WithHealthCheck(healthCheck)
+		}
+		probes = append(probes, stability)
 
 	case state.ProbeTypeLog:
-		if probeConfig.LogPattern == "" {
+		if probeConfig.LogPattern == "" && probeConfig.LogFailPattern == "" {
 			e.logger.Warn().Msg("Log probe configured but no pattern provided, skipping")
 			errorResults = append(errorResults, state.ProbeResult{
 				Type:    state.ProbeTypeLog,
@@ -153,7 +159,13 @@ func (e *Engine) collectProbes(probeConfig state.ProbeConfig, containerID string
 			if windowSec == 0 {
 				windowSec = 30
 			}
-			probes = append(probes, NewLogProbe(e.dockerClient, containerID, probeConfig.LogPattern, windowSec, e.config, e.logger))
+			probes = append(probes, NewLogProbe(e.dockerClient, containerID, probeConfig.LogPattern, windowSec, e.config, e.logger).
+				WithOptions(LogOptions{
+					Stream:      probeConfig.LogStream,
+					IgnoreCase:  probeConfig.LogIgnoreCase,
+					MaxLines:    probeConfig.LogMaxLines,
+					FailPattern: probeConfig.LogFailPattern,
+				}))
 		}
 
 	case state.ProbeTypeNone:
@@ -174,12 +186,17 @@ func (e *Engine) collectProbes(probeConfig state.ProbeConfig, containerID string
 }
 
 // logProbeResult logs details of a single probe result and records metrics
-func (e *Engine) logProbeResult(serviceName string, result state.ProbeResult) {
+func (e *Engine) logProbeResult(target *state.Target, serviceName string, result state.ProbeResult) {
 	resultLabel := "success"
 	if !result.Success {
 		resultLabel = "failure"
 	}
-	metrics.ProbesTotal.WithLabelValues(string(result.Type), resultLabel).Inc()
+	var targetName string
+	if target != nil {
+		targetName = target.Name
+	}
+	targetLabel, serviceLabel := metrics.ServiceLabels(targetName, serviceName)
+	metrics.ProbesTotal.WithLabelValues(string(result.Type), resultLabel, targetLabel, serviceLabel).Inc()
 	if result.Duration > 0 {
 		metrics.ProbeDuration.WithLabelValues(string(result.Type)).Observe(result.Duration.Seconds())
 	}
@@ -43,7 +43,7 @@ func TestAllProbesPassed_OneFails(t *testing.T) {
 
 func TestCollectProbes_None(t *testing.T) {
 	engine := testEngine()
-	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeNone}, "")
+	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeNone}, nil, "")
 	if len(probes) != 0 {
 		t.Errorf("expected 0 probes for none type, got %d", len(probes))
 	}
@@ -54,7 +54,7 @@ func TestCollectProbes_None(t *testing.T) {
 
 func TestCollectProbes_UnknownType(t *testing.T) {
 	engine := testEngine()
-	probes, errors := engine.collectProbes(state.ProbeConfig{Type: "unknown"}, "")
+	probes, errors := engine.collectProbes(state.ProbeConfig{Type: "unknown"}, nil, "")
 	if len(probes) != 0 {
 		t.Errorf("expected 0 probes for unknown type, got %d", len(probes))
 	}
@@ -65,7 +65,7 @@ func TestCollectProbes_UnknownType(t *testing.T) {
 
 func TestCollectProbes_HTTPMissingURL(t *testing.T) {
 	engine := testEngine()
-	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeHTTP}, "")
+	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeHTTP}, nil, "")
 	if len(probes) != 0 {
 		t.Errorf("expected 0 probes, got %d", len(probes))
 	}
@@ -76,7 +76,7 @@ func TestCollectProbes_HTTPMissingURL(t *testing.T) {
 
 func TestCollectProbes_TCPMissingConfig(t *testing.T) {
 	engine := testEngine()
-	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeTCP}, "")
+	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeTCP}, nil, "")
 	if len(probes) != 0 {
 		t.Errorf("expected 0 probes, got %d", len(probes))
 	}
@@ -87,7 +87,7 @@ func TestCollectProbes_TCPMissingConfig(t *testing.T) {
 
 func TestCollectProbes_LogMissingPattern(t *testing.T) {
 	engine := testEngine()
-	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeLog}, "")
+	probes, errors := engine.collectProbes(state.ProbeConfig{Type: state.ProbeTypeLog}, nil, "")
 	if len(probes) != 0 {
 		t.Errorf("expected 0 probes, got %d", len(probes))
 	}
@@ -102,7 +102,7 @@ func TestCollectProbes_HTTP(t *testing.T) {
 		Type:       state.ProbeTypeHTTP,
 		HTTPUrl:    "http://localhost/health",
 		HTTPStatus: 200,
-	}, "")
+	}, nil, "")
 	if len(probes) != 1 {
 		t.Errorf("expected 1 probe, got %d", len(probes))
 	}
@@ -117,7 +117,7 @@ func TestCollectProbes_TCP(t *testing.T) {
 		Type:    state.ProbeTypeTCP,
 		TCPHost: "localhost",
 		TCPPort: 5432,
-	}, "")
+	}, nil, "")
 	if len(probes) != 1 {
 		t.Errorf("expected 1 probe, got %d", len(probes))
 	}
@@ -131,7 +131,7 @@ func TestCollectProbes_Stability(t *testing.T) {
 	probes, errors := engine.collectProbes(state.ProbeConfig{
 		Type:         state.ProbeTypeStability,
 		StabilitySec: 10,
-	}, "")
+	}, nil, "")
 	if len(probes) != 1 {
 		t.Errorf("expected 1 probe, got %d", len(probes))
 	}
@@ -3,8 +3,11 @@ package probe
 import (
 	"bufio"
 	"context"
+	"encoding/binary"
 	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/itsmrshow/bulwark/internal/docker"
@@ -12,11 +15,39 @@ import (
 	"github.com/itsmrshow/bulwark/internal/state"
 )
 
+const (
+	// defaultLogLines is how many recent lines a log probe reads by default.
+	defaultLogLines = 500
+	// maxLogLineBytes caps how much of one log line is matched; the rest of
+	// a longer line is skipped.
+	maxLogLineBytes = 64 * 1024
+	// logLineTimeout bounds the time matching one line may take. Go's RE2
+	// engine runs in linear time, so this only trips on huge patterns.
+	logLineTimeout = 100 * time.Millisecond
+)
+
+// logReader is the subset of the Docker client the log probe reads with.
+type logReader interface {
+	ContainerLogsSince(ctx context.Context, containerID string, since time.Time, tail string, stdout, stderr bool) (io.ReadCloser, error)
+}
+
+// LogOptions narrows down what a log probe reads and matches.
+type LogOptions struct {
+	Stream      string // stdout, stderr, or "" for both
+	IgnoreCase  bool
+	MaxLines    int    // Most recent lines read; 500 when zero
+	FailPattern string // Fails the probe when a line matches it
+}
+
 // LogProbe checks container logs for a regex pattern
 type LogProbe struct {
-	dockerClient *docker.Client
+	dockerClient logReader
 	containerID  string
+	source       string
 	pattern      *regexp.Regexp
+	failPattern  *regexp.Regexp
+	invalid      string // Why Execute fails without reading, e.g. a bad pattern
+	options      LogOptions
 	windowSec    int
 	config       Config
 	logger       *logging.Logger
@@ -29,23 +60,66 @@ func NewLogProbe(dockerClient *docker.Client, containerID, pattern string, windo
 		windowSec = 30
 	}
 
-	compiled, err := regexp.Compile(pattern)
-	if err != nil {
-		// Store nil pattern; Execute will report a clear failure.
-		logger.WithComponent("log-probe").Warn().
-			Str("pattern", pattern).
-			Err(err).
-			Msg("Invalid log probe regex pattern")
-		compiled = nil
-	}
-
-	return &LogProbe{
-		dockerClient: dockerClient,
-		containerID:  containerID,
-		pattern:      compiled,
-		windowSec:    windowSec,
-		config:       config,
-		logger:       logger.WithComponent("log-probe"),
+	p := &LogProbe{
+		containerID: containerID,
+		source:      pattern,
+		windowSec:   windowSec,
+		config:      config,
+		logger:      logger.WithComponent("log-probe"),
+	}
+	if dockerClient != nil {
+		p.dockerClient = dockerClient
+	}
+	p.compile()
+	return p
+}
+
+// WithOptions filters the logs the probe reads and adds a fail pattern.
+// With only a fail pattern, the probe passes when no recent line matches it.
+func (p *LogProbe) WithOptions(options LogOptions) *LogProbe {
+	p.options = options
+	p.compile()
+	return p
+}
+
+// compile builds the patterns from their sources; an invalid one is left
+// nil and Execute reports it.
+func (p *LogProbe) compile() {
+	p.pattern, p.failPattern, p.invalid = nil, nil, ""
+	compile := func(source string) (*regexp.Regexp, error) {
+		if p.options.IgnoreCase {
+			source = "(?i)" + source
+		}
+		return regexp.Compile(source)
+	}
+
+	if p.source != "" {
+		compiled, err := compile(p.source)
+		if err != nil {
+			// Store nil pattern; Execute will report a clear failure.
+			p.logger.Warn().
+				Str("pattern", p.source).
+				Err(err).
+				Msg("Invalid log probe regex pattern")
+			p.invalid = "invalid regex pattern"
+		}
+		p.pattern = compiled
+	}
+	if p.options.FailPattern != "" {
+		compiled, err := compile(p.options.FailPattern)
+		if err != nil {
+			p.logger.Warn().
+				Str("fail_pattern", p.options.FailPattern).
+				Err(err).
+				Msg("Invalid log probe fail pattern")
+			if p.invalid == "" {
+				p.invalid = "invalid fail regex pattern"
+			}
+		}
+		p.failPattern = compiled
+	}
+	if p.source == "" && p.options.FailPattern == "" {
+		p.invalid = "invalid regex pattern"
 	}
 }
 
@@ -56,17 +130,19 @@ func (p *LogProbe) Type() state.ProbeType {
 
 // Execute runs the log probe
 func (p *LogProbe) Execute(ctx context.Context) *state.ProbeResult {
-	if p.pattern == nil {
+	if p.invalid != "" {
 		return &state.ProbeResult{
 			Type:    p.Type(),
 			Success: false,
-			Message: "invalid regex pattern",
+			Message: p.invalid,
 		}
 	}
 
 	p.logger.Debug().
 		Str("container_id", p.containerID[:min(12, len(p.containerID))]).
-		Str("pattern", p.pattern.String()).
+		Str("pattern", p.source).
+		Str("fail_pattern", p.options.FailPattern).
+		Str("stream", p.options.Stream).
 		Int("window_sec", p.windowSec).
 		Msg("Starting log probe")
 
@@ -83,12 +159,12 @@ func (p *LogProbe) Execute(ctx context.Context) *state.ProbeResult {
 
 	if success {
 		p.logger.Info().
-			Str("pattern", p.pattern.String()).
+			Str("pattern", p.source).
 			Dur("duration", duration).
 			Msg("Log probe succeeded")
 	} else {
 		p.logger.Warn().
-			Str("pattern", p.pattern.String()).
+			Str("pattern", p.source).
 			Str("error", message).
 			Msg("Log probe failed")
 	}
@@ -96,32 +172,153 @@ func (p *LogProbe) Execute(ctx context.Context) *state.ProbeResult {
 	return result
 }
 
-// checkLogs reads recent container logs and checks for the pattern
+// checkLogs reads recent container logs and checks them for the patterns.
+// A line matching the fail pattern fails the probe for good; a missing
+// pattern is retried.
 func (p *LogProbe) checkLogs(ctx context.Context) error {
 	since := time.Now().Add(-time.Duration(p.windowSec) * time.Second)
+	lines := p.options.MaxLines
+	if lines <= 0 {
+		lines = defaultLogLines
+	}
 
-	reader, err := p.dockerClient.ContainerLogsSince(ctx, p.containerID, since, "500")
+	reader, err := p.dockerClient.ContainerLogsSince(ctx, p.containerID, since, strconv.Itoa(lines),
+		p.options.Stream != "stderr", p.options.Stream != "stdout")
 	if err != nil {
 		return fmt.Errorf("failed to fetch container logs: %w", err)
 	}
 	defer func() { _ = reader.Close() }()
 
-	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		// Docker multiplexed log streams have an 8-byte header per frame.
-		// Strip it if present (header byte 0 is stream type: 1=stdout, 2=stderr).
-		if len(line) > 8 && (line[0] == 1 || line[0] == 2) {
-			line = line[8:]
+	found := p.pattern == nil
+	err = eachLogLine(reader, func(line []byte) error {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		if p.pattern.Match(line) {
-			return nil
+		if p.failPattern != nil {
+			matched, err := matchLine(p.failPattern, line)
+			if err != nil {
+				return permanent(err)
+			}
+			if matched {
+				return permanent(fmt.Errorf("fail pattern %q matched container logs: %s", p.failPattern.String(), truncateLine(line)))
+			}
+		}
+		if !found {
+			matched, err := matchLine(p.pattern, line)
+			if err != nil {
+				return permanent(err)
+			}
+			found = matched
 		}
+		return nil
+	})
+	if err != nil {
+		if isPermanent(err) {
+			return err
+		}
+		return fmt.Errorf("error reading logs: %w", err)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("error reading logs: %w", err)
+	if !found {
+		return fmt.Errorf("pattern %q not found in container logs", p.pattern.String())
 	}
+	return nil
+}
+
+// FirstMatch returns the first line of a container's log output that
+// matches pattern, or "" when none does. It reads the output as the log
+// probe does.
+func FirstMatch(r io.Reader, pattern *regexp.Regexp) (string, error) {
+	var match string
+	err := eachLogLine(r, func(line []byte) error {
+		matched, err := matchLine(pattern, line)
+		if err != nil {
+			return err
+		}
+		if matched {
+			match = truncateLine(line)
+			return io.EOF
+		}
+		return nil
+	})
+	if err == io.EOF {
+		err = nil
+	}
+	return match, err
+}
+
+// matchLine matches one log line, failing when that takes longer than
+// logLineTimeout so one pathological pattern cannot stall the probe.
+func matchLine(pattern *regexp.Regexp, line []byte) (bool, error) {
+	start := time.Now()
+	matched := pattern.Match(line)
+	if elapsed := time.Since(start); elapsed > logLineTimeout {
+		return false, fmt.Errorf("pattern %q took %v on one log line; simplify it", pattern.String(), elapsed.Round(time.Millisecond))
+	}
+	return matched, nil
+}
+
+func truncateLine(line []byte) string {
+	const max = 200
+	if len(line) > max {
+		return string(line[:max]) + "…"
+	}
+	return string(line)
+}
+
+// eachLogLine calls fn with every line of a container's log output, taking
+// off the frame headers Docker multiplexes stdout and stderr with when the
+// container has no TTY. Lines are capped at maxLogLineBytes.
+func eachLogLine(r io.Reader, fn func(line []byte) error) error {
+	br := bufio.NewReaderSize(r, maxLogLineBytes)
+	if header, err := br.Peek(8); err == nil && header[0] <= 2 && header[1] == 0 && header[2] == 0 && header[3] == 0 {
+		br = bufio.NewReaderSize(&demuxReader{r: br}, maxLogLineBytes)
+	}
+
+	skipping := false
+	for {
+		line, isPrefix, err := br.ReadLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !skipping {
+			if err := fn(line); err != nil {
+				return err
+			}
+		}
+		skipping = isPrefix
+	}
+}
+
+// demuxReader reads the payload of Docker's multiplexed log frames: an
+// 8-byte header holding the stream and the payload's big-endian length,
+// then the payload.
+type demuxReader struct {
+	r         io.Reader
+	remaining int
+}
 
-	return fmt.Errorf("pattern %q not found in container logs", p.pattern.String())
+func (d *demuxReader) Read(p []byte) (int, error) {
+	for d.remaining == 0 {
+		var header [8]byte
+		if _, err := io.ReadFull(d.r, header[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		d.remaining = int(binary.BigEndian.Uint32(header[4:]))
+	}
+	if len(p) > d.remaining {
+		p = p[:d.remaining]
+	}
+	n, err := d.r.Read(p)
+	d.remaining -= n
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
 }
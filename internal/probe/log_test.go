@@ -1,7 +1,13 @@
 package probe
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/state"
@@ -43,3 +49,93 @@ func TestLogProbe_Type(t *testing.T) {
 		t.Errorf("expected type=log, got %s", probe.Type())
 	}
 }
+
+// frame wraps payload in a Docker log multiplexing header.
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+type fakeLogs struct {
+	data           []byte
+	stdout, stderr bool
+	tail           string
+	calls          int
+}
+
+func (f *fakeLogs) ContainerLogsSince(ctx context.Context, containerID string, since time.Time, tail string, stdout, stderr bool) (io.ReadCloser, error) {
+	f.calls++
+	f.stdout, f.stderr, f.tail = stdout, stderr, tail
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+func TestEachLogLine(t *testing.T) {
+	var multiplexed []byte
+	multiplexed = append(multiplexed, frame(1, "starting\nlistening on ")...)
+	multiplexed = append(multiplexed, frame(2, ":8080\n")...)
+	multiplexed = append(multiplexed, frame(1, strings.Repeat("x", maxLogLineBytes+10)+"\ndone\n")...)
+
+	for name, data := range map[string][]byte{
+		"multiplexed": multiplexed,
+		"tty":         []byte("starting\nlistening on :8080\n" + strings.Repeat("x", maxLogLineBytes+10) + "\ndone\n"),
+	} {
+		var lines []string
+		if err := eachLogLine(bytes.NewReader(data), func(line []byte) error {
+			lines = append(lines, string(line))
+			return nil
+		}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(lines) != 4 || lines[1] != "listening on :8080" || len(lines[2]) != maxLogLineBytes || lines[3] != "done" {
+			t.Errorf("%s: got %d lines %q…", name, len(lines), lines[:min(2, len(lines))])
+		}
+	}
+}
+
+func TestLogProbe_Options(t *testing.T) {
+	logs := []byte("Server READY\nwarning: slow disk\n")
+	tests := []struct {
+		name     string
+		pattern  string
+		options  LogOptions
+		want     bool
+		message  string
+		attempts int
+	}{
+		{"case sensitive miss", "ready", LogOptions{}, false, "not found", 2},
+		{"ignore case", "ready", LogOptions{IgnoreCase: true}, true, "", 1},
+		{"fail pattern", "READY", LogOptions{FailPattern: "slow disk"}, false, "fail pattern", 1},
+		{"fail pattern only, clean", "", LogOptions{FailPattern: "panic"}, true, "", 1},
+		{"invalid fail pattern", "READY", LogOptions{FailPattern: "(["}, false, "invalid fail regex pattern", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := &fakeLogs{data: logs}
+			probe := NewLogProbe(nil, "abc123", tt.pattern, 10, Config{Timeout: time.Second, Retries: 2}, logging.Default()).
+				WithOptions(tt.options)
+			probe.dockerClient = reader
+			result := probe.Execute(context.Background())
+			if result.Success != tt.want || !strings.Contains(result.Message, tt.message) {
+				t.Errorf("got success=%v %q", result.Success, result.Message)
+			}
+			if reader.calls != tt.attempts {
+				t.Errorf("expected %d log reads, got %d", tt.attempts, reader.calls)
+			}
+		})
+	}
+}
+
+func TestLogProbe_Stream(t *testing.T) {
+	reader := &fakeLogs{data: []byte("ready\n")}
+	probe := NewLogProbe(nil, "abc123", "ready", 10, Config{Timeout: time.Second, Retries: 1}, logging.Default()).
+		WithOptions(LogOptions{Stream: "stderr", MaxLines: 50})
+	probe.dockerClient = reader
+	if result := probe.Execute(context.Background()); !result.Success {
+		t.Fatalf("expected success, got %q", result.Message)
+	}
+	if reader.stdout || !reader.stderr || reader.tail != "50" {
+		t.Errorf("expected stderr only and 50 lines, got stdout=%v stderr=%v tail=%s", reader.stdout, reader.stderr, reader.tail)
+	}
+}
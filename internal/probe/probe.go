@@ -2,6 +2,7 @@ package probe
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/itsmrshow/bulwark/internal/state"
@@ -40,6 +41,23 @@ func DefaultConfig() Config {
 	}
 }
 
+// permanentError is a probe failure that retrying would not change, such as
+// a log line matching a fail pattern.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// permanent marks err as a failure executeWithRetries does not retry.
+func permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var target permanentError
+	return errors.As(err, &target)
+}
+
 // executeWithRetries executes a probe function with retries
 func executeWithRetries(ctx context.Context, config Config, probeFn func(context.Context) error) (bool, time.Duration, string) {
 	start := time.Now()
@@ -66,8 +84,8 @@ func executeWithRetries(ctx context.Context, config Config, probeFn func(context
 
 		lastErr = err
 
-		// Don't retry if context is canceled
-		if ctx.Err() != nil {
+		// Don't retry if context is canceled or retrying cannot help
+		if ctx.Err() != nil || isPermanent(err) {
 			break
 		}
 	}
@@ -5,15 +5,25 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
 
+// containerInspector is the subset of the Docker client used for restart checks.
+type containerInspector interface {
+	InspectContainer(ctx context.Context, containerID string) (docker.ContainerJSON, error)
+}
+
 // StabilityProbe waits for a stability window without restarts
 type StabilityProbe struct {
-	windowSec int
-	config    Config
-	logger    *logging.Logger
+	windowSec   int
+	config      Config
+	logger      *logging.Logger
+	inspector   containerInspector
+	containerID string
+	maxRestarts int
+	healthCheck *state.HealthCheck
 }
 
 // NewStabilityProbe creates a new stability probe
@@ -29,6 +39,26 @@ func NewStabilityProbe(windowSec int, config Config, logger *logging.Logger) *St
 	}
 }
 
+// WithRestartCheck makes the probe poll the container during the window and
+// fail if it restarts more than maxRestarts times or is not running at the end.
+// Without it the probe only waits out the window.
+func (p *StabilityProbe) WithRestartCheck(inspector containerInspector, containerID string, maxRestarts int) *StabilityProbe {
+	p.inspector = inspector
+	p.containerID = containerID
+	p.maxRestarts = maxRestarts
+	return p
+}
+
+// WithHealthCheck passes the service's parsed healthcheck, whose timings
+// bound how long the container may take to first report healthy. Whenever
+// the container has a healthcheck, from the image or the compose file, the
+// window only starts once it is healthy and the probe fails if it stops
+// being healthy before the window ends. Needs WithRestartCheck.
+func (p *StabilityProbe) WithHealthCheck(healthCheck *state.HealthCheck) *StabilityProbe {
+	p.healthCheck = healthCheck
+	return p
+}
+
 // Type returns the probe type
 func (p *StabilityProbe) Type() state.ProbeType {
 	return state.ProbeTypeStability
@@ -38,48 +68,204 @@ func (p *StabilityProbe) Type() state.ProbeType {
 func (p *StabilityProbe) Execute(ctx context.Context) *state.ProbeResult {
 	p.logger.Debug().
 		Int("window_sec", p.windowSec).
+		Bool("restart_check", p.inspector != nil).
 		Msg("Starting stability probe")
 
 	start := time.Now()
 	duration := time.Duration(p.windowSec) * time.Second
 
-	// Create a timer for the stability window
-	timer := time.NewTimer(duration)
-	defer timer.Stop()
-
-	select {
-	case <-timer.C:
-		// Successfully waited the full window
-		elapsed := time.Since(start)
-		message := fmt.Sprintf("stable for %d seconds", p.windowSec)
-
-		p.logger.Info().
-			Int("window_sec", p.windowSec).
-			Dur("duration", elapsed).
-			Msg("Stability probe succeeded")
-
-		return &state.ProbeResult{
-			Type:     p.Type(),
-			Success:  true,
-			Duration: elapsed,
-			Message:  message,
+	// Record the restart count before the window so restarts caused by the
+	// update itself are not counted.
+	baseline := -1
+	requireHealth := false
+	var grace time.Duration
+	if p.inspector != nil {
+		inspect, err := p.inspector.InspectContainer(ctx, p.containerID)
+		if err != nil {
+			return p.fail(start, fmt.Sprintf("failed to inspect container: %v", err))
 		}
+		baseline = inspect.RestartCount
+		requireHealth = inspect.State.Health != nil
+		grace = healthGrace(p.healthCheck, inspect)
+	}
+
+	// With a healthcheck the window starts once the container is healthy,
+	// which it gets grace to become.
+	var timer *time.Timer
+	var window, graceC <-chan time.Time
+	startWindow := func() {
+		timer = time.NewTimer(duration)
+		window = timer.C
+		graceC = nil
+	}
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	if requireHealth {
+		graceTimer := time.NewTimer(grace)
+		defer graceTimer.Stop()
+		graceC = graceTimer.C
+	} else {
+		startWindow()
+	}
 
-	case <-ctx.Done():
-		// Context canceled before window elapsed
-		elapsed := time.Since(start)
-		message := fmt.Sprintf("stability window interrupted after %v (needed %ds)", elapsed, p.windowSec)
-
-		p.logger.Warn().
-			Int("window_sec", p.windowSec).
-			Dur("duration", elapsed).
-			Msg("Stability probe interrupted")
-
-		return &state.ProbeResult{
-			Type:     p.Type(),
-			Success:  false,
-			Duration: elapsed,
-			Message:  message,
+	var poll <-chan time.Time
+	if p.inspector != nil {
+		interval := p.config.Interval
+		if interval <= 0 {
+			interval = time.Second
 		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-poll:
+			inspect, message := p.checkRestarts(ctx, baseline, false)
+			if message == "" && requireHealth && inspect != nil {
+				switch status := inspect.State.Health.Status; {
+				case window == nil && status == "healthy":
+					p.logger.Debug().Dur("after", time.Since(start)).Msg("Container healthy, stability window starts")
+					startWindow()
+				case status == "unhealthy":
+					message = fmt.Sprintf("container became unhealthy (failing streak %d)", inspect.State.Health.FailingStreak)
+				case window != nil && status != "healthy":
+					message = fmt.Sprintf("container health was %s during stability window", status)
+				}
+			}
+			if message != "" {
+				return p.fail(start, message)
+			}
+
+		case <-graceC:
+			return p.fail(start, fmt.Sprintf("container did not become healthy within %v", grace))
+
+		case <-window:
+			message := fmt.Sprintf("stable for %d seconds", p.windowSec)
+			if p.inspector != nil {
+				inspect, failure := p.checkRestarts(ctx, baseline, true)
+				if failure == "" && requireHealth && inspect.State.Health != nil && inspect.State.Health.Status != "healthy" {
+					failure = fmt.Sprintf("container health was %s at end of stability window", inspect.State.Health.Status)
+				}
+				if failure != "" {
+					return p.fail(start, failure)
+				}
+				if requireHealth {
+					message = fmt.Sprintf("stable and healthy for %d seconds", p.windowSec)
+				}
+			}
+
+			// Successfully waited the full window
+			elapsed := time.Since(start)
+
+			p.logger.Info().
+				Int("window_sec", p.windowSec).
+				Bool("health_required", requireHealth).
+				Dur("duration", elapsed).
+				Msg("Stability probe succeeded")
+
+			return &state.ProbeResult{
+				Type:     p.Type(),
+				Success:  true,
+				Duration: elapsed,
+				Message:  message,
+			}
+
+		case <-ctx.Done():
+			// Context canceled before window elapsed
+			elapsed := time.Since(start)
+			message := fmt.Sprintf("stability window interrupted after %v (needed %ds)", elapsed, p.windowSec)
+
+			p.logger.Warn().
+				Int("window_sec", p.windowSec).
+				Dur("duration", elapsed).
+				Msg("Stability probe interrupted")
+
+			return &state.ProbeResult{
+				Type:     p.Type(),
+				Success:  false,
+				Duration: elapsed,
+				Message:  message,
+			}
+		}
+	}
+}
+
+// healthGrace is how long a new container may take to first report healthy:
+// its start period plus an interval for each retry and one more. The
+// service's parsed healthcheck wins over the container's, and Docker's
+// defaults fill in what neither sets.
+func healthGrace(healthCheck *state.HealthCheck, inspect docker.ContainerJSON) time.Duration {
+	var interval, startPeriod time.Duration
+	var retries int
+	switch {
+	case healthCheck != nil:
+		interval, startPeriod, retries = healthCheck.Interval, healthCheck.StartPeriod, healthCheck.Retries
+	case inspect.Config != nil && inspect.Config.Healthcheck != nil:
+		hc := inspect.Config.Healthcheck
+		interval, startPeriod, retries = hc.Interval, hc.StartPeriod, hc.Retries
+	}
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	if retries <= 0 {
+		retries = 3
+	}
+	return startPeriod + interval*time.Duration(retries+1)
+}
+
+// checkRestarts inspects the container and returns it with a failure
+// message, or "" if it is still healthy. At the end of the window the
+// container must also be running; mid-window a transient restart is only
+// counted, and a failed inspect returns no container.
+func (p *StabilityProbe) checkRestarts(ctx context.Context, baseline int, final bool) (*docker.ContainerJSON, string) {
+	inspect, err := p.inspector.InspectContainer(ctx, p.containerID)
+	if err != nil {
+		if final {
+			return nil, fmt.Sprintf("failed to inspect container: %v", err)
+		}
+		p.logger.Debug().Err(err).Msg("Stability probe inspect failed, will retry")
+		return nil, ""
+	}
+
+	restarts := inspect.RestartCount - baseline
+	if restarts > p.maxRestarts {
+		return &inspect, fmt.Sprintf("container restarted %d times during stability window (max %d, last exit code %d%s)",
+			restarts, p.maxRestarts, inspect.State.ExitCode, oomSuffix(inspect.State))
+	}
+
+	if final && (!inspect.State.Running || inspect.State.Restarting) {
+		return &inspect, fmt.Sprintf("container not running at end of stability window (status: %s, exit code %d%s)",
+			inspect.State.Status, inspect.State.ExitCode, oomSuffix(inspect.State))
+	}
+
+	return &inspect, ""
+}
+
+func (p *StabilityProbe) fail(start time.Time, message string) *state.ProbeResult {
+	elapsed := time.Since(start)
+
+	p.logger.Warn().
+		Int("window_sec", p.windowSec).
+		Dur("duration", elapsed).
+		Str("reason", message).
+		Msg("Stability probe failed")
+
+	return &state.ProbeResult{
+		Type:     p.Type(),
+		Success:  false,
+		Duration: elapsed,
+		Message:  message,
+	}
+}
+
+func oomSuffix(s docker.ContainerState) string {
+	if s.OOMKilled {
+		return ", OOM killed"
 	}
+	return ""
 }
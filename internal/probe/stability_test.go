@@ -2,9 +2,12 @@ package probe
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/itsmrshow/bulwark/internal/docker"
 	"github.com/itsmrshow/bulwark/internal/logging"
 	"github.com/itsmrshow/bulwark/internal/state"
 )
@@ -53,3 +56,177 @@ func TestStabilityProbe_DefaultWindow(t *testing.T) {
 		t.Errorf("expected default window=10, got %d", probe.windowSec)
 	}
 }
+
+type fakeInspector struct {
+	mu      sync.Mutex
+	calls   int
+	inspect func(call int) (docker.ContainerJSON, error)
+}
+
+func (f *fakeInspector) InspectContainer(ctx context.Context, containerID string) (docker.ContainerJSON, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.inspect(f.calls)
+}
+
+func running(restarts int) docker.ContainerJSON {
+	return docker.ContainerJSON{
+		RestartCount: restarts,
+		State:        docker.ContainerState{Status: "running", Running: true},
+	}
+}
+
+func TestStabilityProbe_RestartLoopFails(t *testing.T) {
+	inspector := &fakeInspector{inspect: func(call int) (docker.ContainerJSON, error) {
+		// Baseline of 2, then the container restarts on every poll but is
+		// always observed as running.
+		c := running(1 + call)
+		c.State.ExitCode = 137
+		return c, nil
+	}}
+
+	probe := NewStabilityProbe(5, Config{Interval: 10 * time.Millisecond}, logging.Default()).
+		WithRestartCheck(inspector, "abc123", 1)
+	result := probe.Execute(context.Background())
+
+	if result.Success {
+		t.Fatal("expected failure for restart loop")
+	}
+	if !strings.Contains(result.Message, "restarted 2 times") || !strings.Contains(result.Message, "exit code 137") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	if result.Duration >= 5*time.Second {
+		t.Errorf("expected probe to fail before the window ends, took %v", result.Duration)
+	}
+}
+
+func TestStabilityProbe_ToleratesRestartsWithinLimit(t *testing.T) {
+	inspector := &fakeInspector{inspect: func(call int) (docker.ContainerJSON, error) {
+		if call == 1 {
+			return running(3), nil
+		}
+		return running(4), nil
+	}}
+
+	probe := NewStabilityProbe(1, Config{Interval: 50 * time.Millisecond}, logging.Default()).
+		WithRestartCheck(inspector, "abc123", 1)
+	result := probe.Execute(context.Background())
+
+	if !result.Success {
+		t.Fatalf("expected success with one tolerated restart, got: %s", result.Message)
+	}
+}
+
+func TestStabilityProbe_FailsWhenExitedAtEnd(t *testing.T) {
+	inspector := &fakeInspector{inspect: func(call int) (docker.ContainerJSON, error) {
+		if call == 1 {
+			return running(0), nil
+		}
+		return docker.ContainerJSON{State: docker.ContainerState{Status: "exited", ExitCode: 1}}, nil
+	}}
+
+	probe := NewStabilityProbe(1, Config{Interval: time.Hour}, logging.Default()).
+		WithRestartCheck(inspector, "abc123", 0)
+	result := probe.Execute(context.Background())
+
+	if result.Success {
+		t.Fatal("expected failure when container exited")
+	}
+	if !strings.Contains(result.Message, "not running") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+func withHealth(c docker.ContainerJSON, status string) docker.ContainerJSON {
+	c.State.Health = &docker.Health{Status: status}
+	return c
+}
+
+func TestStabilityProbe_WaitsForHealthy(t *testing.T) {
+	inspector := &fakeInspector{inspect: func(call int) (docker.ContainerJSON, error) {
+		if call <= 3 {
+			return withHealth(running(0), "starting"), nil
+		}
+		return withHealth(running(0), "healthy"), nil
+	}}
+
+	probe := NewStabilityProbe(1, Config{Interval: 20 * time.Millisecond}, logging.Default()).
+		WithRestartCheck(inspector, "abc123", 0).
+		WithHealthCheck(&state.HealthCheck{Interval: time.Second, Retries: 3})
+	result := probe.Execute(context.Background())
+
+	if !result.Success {
+		t.Fatalf("expected success once healthy, got: %s", result.Message)
+	}
+	if !strings.Contains(result.Message, "healthy") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+	// The window only starts once the container is healthy.
+	if result.Duration < time.Second+40*time.Millisecond {
+		t.Errorf("expected the window to start after the container became healthy, took %v", result.Duration)
+	}
+}
+
+func TestStabilityProbe_FailsWhenHealthDropsDuringWindow(t *testing.T) {
+	inspector := &fakeInspector{inspect: func(call int) (docker.ContainerJSON, error) {
+		if call == 3 {
+			return withHealth(running(0), "starting"), nil
+		}
+		return withHealth(running(0), "healthy"), nil
+	}}
+
+	probe := NewStabilityProbe(5, Config{Interval: 10 * time.Millisecond}, logging.Default()).
+		WithRestartCheck(inspector, "abc123", 0)
+	result := probe.Execute(context.Background())
+
+	if result.Success {
+		t.Fatal("expected failure when health drops during the window")
+	}
+	if !strings.Contains(result.Message, "health was starting") {
+		t.Errorf("unexpected message: %s", result.Message)
+	}
+}
+
+func TestStabilityProbe_FailsWhenUnhealthy(t *testing.T) {
+	inspector := &fakeInspector{inspect: func(call int) (docker.ContainerJSON, error) {
+		return withHealth(running(0), "unhealthy"), nil
+	}}
+
+	probe := NewStabilityProbe(5, Config{Interval: 10 * time.Millisecond}, logging.Default()).
+		WithRestartCheck(inspector, "abc123", 0)
+	result := probe.Execute(context.Background())
+
+	if result.Success || !strings.Contains(result.Message, "unhealthy") {
+		t.Fatalf("expected an unhealthy failure, got %+v", result)
+	}
+}
+
+func TestStabilityProbe_FailsWhenNeverHealthy(t *testing.T) {
+	inspector := &fakeInspector{inspect: func(call int) (docker.ContainerJSON, error) {
+		return withHealth(running(0), "starting"), nil
+	}}
+
+	probe := NewStabilityProbe(1, Config{Interval: 10 * time.Millisecond}, logging.Default()).
+		WithRestartCheck(inspector, "abc123", 0).
+		WithHealthCheck(&state.HealthCheck{Interval: 20 * time.Millisecond, Retries: 1})
+	result := probe.Execute(context.Background())
+
+	if result.Success || !strings.Contains(result.Message, "did not become healthy within 40ms") {
+		t.Fatalf("expected a grace period failure, got %+v", result)
+	}
+}
+
+func TestHealthGrace(t *testing.T) {
+	if got := healthGrace(nil, docker.ContainerJSON{}); got != 2*time.Minute {
+		t.Errorf("Docker defaults: got %v, want 2m", got)
+	}
+	fromImage := docker.ContainerJSON{Config: &docker.ContainerConfig{Healthcheck: &docker.Healthcheck{Interval: 5 * time.Second, Retries: 2, StartPeriod: 10 * time.Second}}}
+	if got := healthGrace(nil, fromImage); got != 25*time.Second {
+		t.Errorf("image healthcheck: got %v, want 25s", got)
+	}
+	service := &state.HealthCheck{Interval: 10 * time.Second, Retries: 5}
+	if got := healthGrace(service, fromImage); got != time.Minute {
+		t.Errorf("service healthcheck: got %v, want 1m", got)
+	}
+}
@@ -0,0 +1,222 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// catalogPageSize is how many entries one catalog or tag list request asks for.
+	catalogPageSize = 100
+	// MaxCatalogEntries caps how many repositories or tags one listing returns,
+	// however many pages the registry offers.
+	MaxCatalogEntries = 1000
+)
+
+// Endpoint is a self-hosted registry whose catalog Bulwark may browse.
+type Endpoint struct {
+	Name string `json:"name"`
+	// URL is the registry's base URL, e.g. https://registry.lan:5000.
+	URL      string `json:"url"`
+	Username string `json:"-"`
+	Password string `json:"-"`
+}
+
+// EndpointsFromEnv reads BULWARK_REGISTRIES, a comma-separated list of
+// name=host entries such as "home=registry.lan:5000". A host without a scheme
+// is reached over HTTPS. Credentials come from BULWARK_REGISTRY_<NAME>_USERNAME
+// and BULWARK_REGISTRY_<NAME>_PASSWORD, with the name upper-cased and dashes
+// turned into underscores. Malformed entries are skipped.
+func EndpointsFromEnv() []Endpoint {
+	var endpoints []Endpoint
+	for _, entry := range strings.Split(os.Getenv("BULWARK_REGISTRIES"), ",") {
+		name, host, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		name, host = strings.TrimSpace(name), strings.TrimSpace(host)
+		if !ok || name == "" || host == "" {
+			continue
+		}
+		if !strings.Contains(host, "://") {
+			host = "https://" + host
+		}
+		prefix := "BULWARK_REGISTRY_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		endpoints = append(endpoints, Endpoint{
+			Name:     name,
+			URL:      strings.TrimRight(host, "/"),
+			Username: os.Getenv(prefix + "_USERNAME"),
+			Password: os.Getenv(prefix + "_PASSWORD"),
+		})
+	}
+	return endpoints
+}
+
+// ListRepositories returns the repositories in the catalog of endpoint, at
+// most MaxCatalogEntries of them.
+func (c *Client) ListRepositories(ctx context.Context, endpoint Endpoint) ([]string, error) {
+	var page struct {
+		Repositories []string `json:"repositories"`
+	}
+	return c.listPaged(ctx, endpoint, "/v2/_catalog", "registry:catalog:*", func(body io.Reader) ([]string, error) {
+		page.Repositories = nil
+		err := json.NewDecoder(body).Decode(&page)
+		return page.Repositories, err
+	})
+}
+
+// ListTags returns the tags of repository in endpoint, at most
+// MaxCatalogEntries of them.
+func (c *Client) ListTags(ctx context.Context, endpoint Endpoint, repository string) ([]string, error) {
+	var page struct {
+		Tags []string `json:"tags"`
+	}
+	path := fmt.Sprintf("/v2/%s/tags/list", repository)
+	scope := fmt.Sprintf("repository:%s:pull", repository)
+	return c.listPaged(ctx, endpoint, path, scope, func(body io.Reader) ([]string, error) {
+		page.Tags = nil
+		err := json.NewDecoder(body).Decode(&page)
+		return page.Tags, err
+	})
+}
+
+// listPaged collects the entries of a paginated registry listing, following
+// the Link headers the distribution API returns.
+func (c *Client) listPaged(ctx context.Context, endpoint Endpoint, path, scope string, decode func(io.Reader) ([]string, error)) ([]string, error) {
+	base, err := url.Parse(endpoint.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry URL: %w", err)
+	}
+	next, err := base.Parse(fmt.Sprintf("%s?n=%d", path, catalogPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry path: %w", err)
+	}
+
+	var entries []string
+	authorization := ""
+	for next != nil && len(entries) < MaxCatalogEntries {
+		resp, auth, err := c.getAuthorized(ctx, next.String(), scope, endpoint, authorization)
+		if err != nil {
+			return nil, err
+		}
+		authorization = auth
+		page, err := decode(resp.Body)
+		link := resp.Header.Get("Link")
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode listing: %w", err)
+		}
+		entries = append(entries, page...)
+
+		next = nil
+		if target := nextLink(link); target != "" {
+			if next, err = base.Parse(target); err != nil {
+				return nil, fmt.Errorf("invalid pagination link: %w", err)
+			}
+		}
+	}
+	if len(entries) > MaxCatalogEntries {
+		entries = entries[:MaxCatalogEntries]
+	}
+	return entries, nil
+}
+
+// getAuthorized GETs rawURL, answering a 401 challenge once with a bearer
+// token or basic credentials. It returns the successful response and the
+// Authorization header that got it, for reuse on later pages.
+func (c *Client) getAuthorized(ctx context.Context, rawURL, scope string, endpoint Endpoint, authorization string) (*http.Response, string, error) {
+	resp, err := c.getWithAuthorization(ctx, rawURL, authorization)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		switch {
+		case strings.HasPrefix(strings.ToLower(challenge), "bearer "):
+			token, err := c.catalogToken(ctx, challenge, scope, endpoint)
+			if err != nil {
+				return nil, "", err
+			}
+			authorization = "Bearer " + token
+		case endpoint.Username != "":
+			credentials := endpoint.Username + ":" + endpoint.Password
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+		default:
+			return nil, "", statusError(http.StatusUnauthorized, nil)
+		}
+		if resp, err = c.getWithAuthorization(ctx, rawURL, authorization); err != nil {
+			return nil, "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return nil, "", statusError(resp.StatusCode, body)
+	}
+	return resp, authorization, nil
+}
+
+func (c *Client) getWithAuthorization(ctx context.Context, rawURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach registry: %w", unavailable(ctx, err))
+	}
+	return resp, nil
+}
+
+// catalogToken requests a bearer token for scope from the realm of
+// challenge, authenticating with the endpoint's credentials when it has any.
+func (c *Client) catalogToken(ctx context.Context, challenge, scope string, endpoint Endpoint) (string, error) {
+	params := parseAuthChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("auth challenge missing realm")
+	}
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if challenged := params["scope"]; challenged != "" {
+		scope = challenged
+	}
+	query.Set("scope", scope)
+
+	separator := "?"
+	if strings.Contains(realm, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm+separator+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	if endpoint.Username != "" {
+		req.SetBasicAuth(endpoint.Username, endpoint.Password)
+	}
+	token, _, err := c.doTokenRequest(ctx, req)
+	return token, err
+}
+
+// nextLink extracts the target of a rel="next" Link header.
+func nextLink(header string) string {
+	for _, link := range strings.Split(header, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+		if !ok || !strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+	return ""
+}
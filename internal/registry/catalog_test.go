@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListRepositories_BearerAndPagination(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if user, pass, ok := r.BasicAuth(); !ok || user != "bob" || pass != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "registry:catalog:*" {
+				t.Errorf("unexpected scope %q", r.URL.Query().Get("scope"))
+			}
+			_ = json.NewEncoder(w).Encode(TokenResponse{Token: "tok"})
+		case "/v2/_catalog":
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+srv.URL+`/token",service="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/_catalog?last=app&n=100>; rel="next"`)
+				_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": {"app"}})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": {"org/team/tool"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	endpoint := Endpoint{Name: "home", URL: srv.URL, Username: "bob", Password: "secret"}
+	repos, err := client.ListRepositories(context.Background(), endpoint)
+	if err != nil {
+		t.Fatalf("ListRepositories failed: %v", err)
+	}
+	if want := []string{"app", "org/team/tool"}; !reflect.DeepEqual(repos, want) {
+		t.Errorf("repos = %v, want %v", repos, want)
+	}
+}
+
+func TestListTags_BasicAuth(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "bob" || pass != "secret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/v2/org/app/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "org/app", "tags": []string{"1.0", "1.1"}})
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	tags, err := client.ListTags(context.Background(), Endpoint{URL: srv.URL, Username: "bob", Password: "secret"}, "org/app")
+	if err != nil {
+		t.Fatalf("ListTags failed: %v", err)
+	}
+	if want := []string{"1.0", "1.1"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+
+	if _, err := client.ListTags(context.Background(), Endpoint{URL: srv.URL}, "org/app"); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
+
+func TestEndpointsFromEnv(t *testing.T) {
+	t.Setenv("BULWARK_REGISTRIES", "home=registry.lan:5000/, dev=http://localhost:5000,broken")
+	t.Setenv("BULWARK_REGISTRY_HOME_USERNAME", "bob")
+	t.Setenv("BULWARK_REGISTRY_HOME_PASSWORD", "secret")
+
+	want := []Endpoint{
+		{Name: "home", URL: "https://registry.lan:5000", Username: "bob", Password: "secret"},
+		{Name: "dev", URL: "http://localhost:5000"},
+	}
+	if got := EndpointsFromEnv(); !reflect.DeepEqual(got, want) {
+		t.Errorf("EndpointsFromEnv() = %+v, want %+v", got, want)
+	}
+}
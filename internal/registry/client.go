@@ -8,6 +8,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -27,12 +29,54 @@ const (
 	// Unresolvable images — private repos, dangling references — otherwise
 	// re-fail on every single plan build.
 	DefaultDigestErrorTTL = 5 * time.Minute
+	// DefaultHTTPTimeout bounds a whole registry request, token fetch included.
+	DefaultHTTPTimeout = 30 * time.Second
+	// DefaultTLSHandshakeTimeout matches net/http's default transport.
+	DefaultTLSHandshakeTimeout = 10 * time.Second
+	// DefaultMaxIdleConnsPerHost keeps a few connections to each registry warm
+	// while a plan resolves many images from the same host.
+	DefaultMaxIdleConnsPerHost = 8
 	// defaultTokenTTL is used when a registry omits expires_in.
 	defaultTokenTTL = 5 * time.Minute
 	// tokenExpiryMargin renews tokens slightly early to avoid racing expiry.
 	tokenExpiryMargin = 30 * time.Second
 )
 
+// ErrRegistryUnavailable marks lookups that failed because the registry could
+// not be reached, or answered with a server error or rate limit, rather than
+// because it rejected the image.
+var ErrRegistryUnavailable = errors.New("registry unavailable")
+
+// ErrManifestNotFound marks lookups the registry answered with 404, e.g. for
+// a digest it has garbage-collected.
+var ErrManifestNotFound = errors.New("manifest not found")
+
+// unavailable tags a transport failure as ErrRegistryUnavailable. A request
+// that ended with its own context is left alone.
+func unavailable(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrRegistryUnavailable, err)
+}
+
+// statusError reports an unexpected registry response, tagging rate limits
+// and server errors as ErrRegistryUnavailable and 404s as
+// ErrManifestNotFound.
+func statusError(code int, body []byte) error {
+	message := fmt.Sprintf("unexpected status code %d", code)
+	if len(body) > 0 {
+		message += ": " + string(body)
+	}
+	if code == http.StatusTooManyRequests || code >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: %s", ErrRegistryUnavailable, message)
+	}
+	if code == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrManifestNotFound, message)
+	}
+	return errors.New(message)
+}
+
 type cachedAuth struct {
 	token   string
 	expires time.Time
@@ -60,12 +104,79 @@ type Client struct {
 	digestGroup  singleflight.Group
 }
 
+// HTTPOptions tunes the HTTP client used to talk to registries.
+type HTTPOptions struct {
+	// Timeout bounds a single request, including reading the response.
+	Timeout             time.Duration
+	TLSHandshakeTimeout time.Duration
+	MaxIdleConnsPerHost int
+}
+
+// DefaultHTTPOptions returns the options NewClient starts with.
+func DefaultHTTPOptions() HTTPOptions {
+	return HTTPOptions{
+		Timeout:             DefaultHTTPTimeout,
+		TLSHandshakeTimeout: DefaultTLSHandshakeTimeout,
+		MaxIdleConnsPerHost: DefaultMaxIdleConnsPerHost,
+	}
+}
+
+// HTTPOptionsFromEnv reads BULWARK_REGISTRY_TIMEOUT,
+// BULWARK_REGISTRY_TLS_HANDSHAKE_TIMEOUT and
+// BULWARK_REGISTRY_MAX_IDLE_CONNS_PER_HOST; unset or invalid values keep the
+// defaults.
+func HTTPOptionsFromEnv() HTTPOptions {
+	opts := DefaultHTTPOptions()
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("BULWARK_REGISTRY_TIMEOUT"))); err == nil && d > 0 {
+		opts.Timeout = d
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(os.Getenv("BULWARK_REGISTRY_TLS_HANDSHAKE_TIMEOUT"))); err == nil && d > 0 {
+		opts.TLSHandshakeTimeout = d
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv("BULWARK_REGISTRY_MAX_IDLE_CONNS_PER_HOST"))); err == nil && n > 0 {
+		opts.MaxIdleConnsPerHost = n
+	}
+	return opts
+}
+
+// transport answers every registry request once UseTransport set it.
+var transport http.RoundTripper
+
+// UseTransport makes registry clients send their requests to rt instead of
+// the network, so Bulwark can run against simulated registries. Call it at
+// startup, before any client is created.
+func UseTransport(rt http.RoundTripper) {
+	transport = rt
+}
+
+func newHTTPClient(opts HTTPOptions) *http.Client {
+	defaults := DefaultHTTPOptions()
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.TLSHandshakeTimeout <= 0 {
+		opts.TLSHandshakeTimeout = defaults.TLSHandshakeTimeout
+	}
+	if opts.MaxIdleConnsPerHost <= 0 {
+		opts.MaxIdleConnsPerHost = defaults.MaxIdleConnsPerHost
+	}
+
+	if transport != nil {
+		return &http.Client{Timeout: opts.Timeout, Transport: transport}
+	}
+	tuned := http.DefaultTransport.(*http.Transport).Clone()
+	tuned.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	tuned.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: tuned,
+	}
+}
+
 // NewClient creates a new registry client
 func NewClient(logger *logging.Logger) *Client {
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient:   newHTTPClient(DefaultHTTPOptions()),
 		logger:       logger.WithComponent("registry"),
 		authCache:    make(map[string]cachedAuth),
 		digestCache:  make(map[string]cachedDigest),
@@ -74,6 +185,20 @@ func NewClient(logger *logging.Logger) *Client {
 	}
 }
 
+// WithHTTPOptions replaces the HTTP client with one tuned by opts. Zero
+// fields keep their defaults.
+func (c *Client) WithHTTPOptions(opts HTTPOptions) *Client {
+	c.httpClient = newHTTPClient(opts)
+	return c
+}
+
+// WithHTTPClient replaces the HTTP client outright, e.g. with one trusting a
+// test registry's certificate.
+func (c *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	c.httpClient = httpClient
+	return c
+}
+
 // WithDigestTTL overrides how long resolved digests are cached. A non-positive
 // TTL disables digest caching.
 func (c *Client) WithDigestTTL(ttl time.Duration) *Client {
@@ -96,6 +221,36 @@ func (c *Client) InvalidateDigests() {
 	c.digestCache = make(map[string]cachedDigest)
 }
 
+// InvalidateDigest drops the cached digest of a single image, so a targeted
+// check re-resolves it without discarding the rest of the cache.
+func (c *Client) InvalidateDigest(image string) {
+	ref, err := tagReference(image)
+	if err != nil {
+		return
+	}
+	c.digestMu.Lock()
+	defer c.digestMu.Unlock()
+	delete(c.digestCache, ref.CacheKey())
+}
+
+// tagReference parses image into the reference FetchDigest resolves.
+func tagReference(image string) (*ImageReference, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image reference: %w", err)
+	}
+
+	// When a reference carries both a tag and a digest (e.g. Compose v2
+	// pins running containers as repo:tag@sha256:...), querying by the
+	// digest just echoes the pin back. The whole point of this call is
+	// "what does the tag currently point to?" — so drop the digest and
+	// resolve by tag.
+	if ref.Tag != "" && ref.Digest != "" {
+		ref.Digest = ""
+	}
+	return ref, nil
+}
+
 // ManifestResponse represents a Docker registry manifest
 type ManifestResponse struct {
 	SchemaVersion int             `json:"schemaVersion"`
@@ -145,18 +300,9 @@ type TokenResponse struct {
 // one is still fresh. Concurrent lookups of the same image collapse into a
 // single registry request.
 func (c *Client) FetchDigest(ctx context.Context, image string) (string, error) {
-	ref, err := ParseImageReference(image)
+	ref, err := tagReference(image)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse image reference: %w", err)
-	}
-
-	// When a reference carries both a tag and a digest (e.g. Compose v2
-	// pins running containers as repo:tag@sha256:...), querying by the
-	// digest just echoes the pin back. The whole point of this call is
-	// "what does the tag currently point to?" — so drop the digest and
-	// resolve by tag.
-	if ref.Tag != "" && ref.Digest != "" {
-		ref.Digest = ""
+		return "", err
 	}
 
 	cacheKey := ref.CacheKey()
@@ -268,7 +414,7 @@ func (c *Client) fetchManifest(ctx context.Context, ref *ImageReference, token s
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to fetch manifest: %w", err)
+		return nil, "", fmt.Errorf("failed to fetch manifest: %w", unavailable(ctx, err))
 	}
 	if resp.StatusCode == http.StatusUnauthorized && allowRetry {
 		challenge := resp.Header.Get("WWW-Authenticate")
@@ -290,13 +436,13 @@ func (c *Client) fetchManifest(ctx context.Context, ref *ImageReference, token s
 				return c.fetchManifest(ctx, ref, newToken, false)
 			}
 		}
-		return nil, "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		return nil, "", statusError(resp.StatusCode, nil)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, "", fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return nil, "", statusError(resp.StatusCode, body)
 	}
 
 	// Get digest from header
@@ -349,16 +495,20 @@ func (c *Client) requestToken(ctx context.Context, tokenURL string) (string, tim
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to create token request: %w", err)
 	}
+	return c.doTokenRequest(ctx, req)
+}
 
+// doTokenRequest sends a prepared token request.
+func (c *Client) doTokenRequest(ctx context.Context, req *http.Request) (string, time.Duration, error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", 0, fmt.Errorf("failed to fetch token: %w", err)
+		return "", 0, fmt.Errorf("failed to fetch token: %w", unavailable(ctx, err))
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", 0, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, string(body))
+		return "", 0, statusError(resp.StatusCode, body)
 	}
 
 	var tokenResp TokenResponse
@@ -3,9 +3,11 @@ package registry
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -48,7 +50,15 @@ func TestParseImageReference(t *testing.T) {
 		{"user/app:v1", "docker.io", "user/app", "v1", false},
 		{"ghcr.io/user/app:v2", "ghcr.io", "user/app", "v2", false},
 		{"registry.example.com/org/app:latest", "registry.example.com", "org/app", "latest", false},
+		{"localhost:5000/img", "localhost:5000", "img", "latest", false},
+		{"localhost:5000/img:v3", "localhost:5000", "img", "v3", false},
+		{"localhost/img", "localhost", "img", "latest", false},
+		{"registry.example.com/img", "registry.example.com", "img", "latest", false},
+		{"registry.example.com:8443/org/team/app:1.2", "registry.example.com:8443", "org/team/app", "1.2", false},
+		{"index.docker.io/library/nginx", "docker.io", "library/nginx", "latest", false},
 		{"", "", "", "", true},
+		{"Nginx:latest", "", "", "", true},
+		{"nginx@", "", "", "", true},
 		// A container running from a dangling image reports a bare digest with
 		// no repository. Resolving it would query docker.io/library/sha256 and
 		// get a guaranteed 401, so it must be rejected before any request.
@@ -76,6 +86,24 @@ func TestParseImageReference(t *testing.T) {
 	}
 }
 
+func TestRetag(t *testing.T) {
+	tests := []struct {
+		image, tag, want string
+	}{
+		{"nginx", "1.27", "nginx:1.27"},
+		{"docker.io/library/nginx:1.25", "1.27", "nginx:1.27"},
+		{"localhost:5000/org/app:v1@sha256:abc", "v2", "localhost:5000/org/app:v2"},
+	}
+	for _, tt := range tests {
+		if got, err := Retag(tt.image, tt.tag); err != nil || got != tt.want {
+			t.Errorf("Retag(%q, %q) = %q, %v; want %q", tt.image, tt.tag, got, err, tt.want)
+		}
+	}
+	if _, err := Retag("nginx", "bad tag"); err == nil {
+		t.Error("expected an error for an invalid tag")
+	}
+}
+
 func TestParseImageReference_Digest(t *testing.T) {
 	ref, err := ParseImageReference("nginx@sha256:abc123")
 	if err != nil {
@@ -211,6 +239,96 @@ func TestFetchDigest_CachesAcrossCalls(t *testing.T) {
 	}
 }
 
+func TestInvalidateDigest_DropsOnlyThatImage(t *testing.T) {
+	hits := make(map[string]int)
+	var mu sync.Mutex
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Docker-Content-Digest", "sha256:cached")
+		_ = json.NewEncoder(w).Encode(ManifestResponse{SchemaVersion: 2})
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	nginx := testImage(srv, "library/nginx:latest")
+	redis := testImage(srv, "library/redis:7")
+
+	for _, image := range []string{nginx, redis} {
+		if _, err := client.FetchDigest(context.Background(), image); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	client.InvalidateDigest(nginx + "@sha256:pinned")
+	for _, image := range []string{nginx, redis} {
+		if _, err := client.FetchDigest(context.Background(), image); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := hits["/v2/library/nginx/manifests/latest"]; got != 2 {
+		t.Errorf("nginx manifest requests = %d, want 2", got)
+	}
+	if got := hits["/v2/library/redis/manifests/7"]; got != 1 {
+		t.Errorf("redis manifest requests = %d, want 1", got)
+	}
+}
+
+func TestFetchDigest_ClassifiesUnavailableRegistry(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv).WithDigestTTL(0)
+	image := testImage(srv, "library/nginx:latest")
+
+	if _, err := client.FetchDigest(context.Background(), image); !errors.Is(err, ErrRegistryUnavailable) {
+		t.Errorf("503: expected ErrRegistryUnavailable, got %v", err)
+	}
+
+	status.Store(http.StatusNotFound)
+	_, err := client.FetchDigest(context.Background(), image)
+	if err == nil || errors.Is(err, ErrRegistryUnavailable) {
+		t.Errorf("404: expected a plain error, got %v", err)
+	}
+
+	srv.Close()
+	if _, err := client.FetchDigest(context.Background(), image); !errors.Is(err, ErrRegistryUnavailable) {
+		t.Errorf("closed server: expected ErrRegistryUnavailable, got %v", err)
+	}
+}
+
+func TestMatchesImage(t *testing.T) {
+	tests := []struct {
+		query string
+		image string
+		want  bool
+	}{
+		{"nginx", "nginx:1.25", true},
+		{"nginx", "docker.io/library/nginx:latest", true},
+		{"nginx:latest", "nginx", true},
+		{"nginx:1.25", "nginx:1.26", false},
+		{"nginx", "ghcr.io/acme/nginx:latest", false},
+		{"ghcr.io/acme/api", "ghcr.io/acme/api:v2@sha256:abc", true},
+		{"registry.local:5000/api", "registry.local:5000/api:dev", true},
+		{"", "nginx", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesImage(tt.query, tt.image); got != tt.want {
+			t.Errorf("MatchesImage(%q, %q) = %v, want %v", tt.query, tt.image, got, tt.want)
+		}
+	}
+}
+
 func TestFetchDigest_CachesFailures(t *testing.T) {
 	var hits int32
 
@@ -371,3 +489,41 @@ func TestNewClient(t *testing.T) {
 		t.Error("expected error for empty image")
 	}
 }
+
+func TestWithHTTPOptions(t *testing.T) {
+	client := NewClient(logging.Default()).WithHTTPOptions(HTTPOptions{
+		Timeout:             90 * time.Second,
+		MaxIdleConnsPerHost: 16,
+	})
+
+	if client.httpClient.Timeout != 90*time.Second {
+		t.Errorf("Timeout = %v, want 90s", client.httpClient.Timeout)
+	}
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("unexpected transport %T", client.httpClient.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != 16 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 16", transport.MaxIdleConnsPerHost)
+	}
+	if transport.TLSHandshakeTimeout != DefaultTLSHandshakeTimeout {
+		t.Errorf("TLSHandshakeTimeout = %v, want default %v", transport.TLSHandshakeTimeout, DefaultTLSHandshakeTimeout)
+	}
+}
+
+func TestHTTPOptionsFromEnv(t *testing.T) {
+	t.Setenv("BULWARK_REGISTRY_TIMEOUT", "5s")
+	t.Setenv("BULWARK_REGISTRY_TLS_HANDSHAKE_TIMEOUT", "not-a-duration")
+	t.Setenv("BULWARK_REGISTRY_MAX_IDLE_CONNS_PER_HOST", "2")
+
+	opts := HTTPOptionsFromEnv()
+	if opts.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want 5s", opts.Timeout)
+	}
+	if opts.TLSHandshakeTimeout != DefaultTLSHandshakeTimeout {
+		t.Errorf("TLSHandshakeTimeout = %v, want default", opts.TLSHandshakeTimeout)
+	}
+	if opts.MaxIdleConnsPerHost != 2 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 2", opts.MaxIdleConnsPerHost)
+	}
+}
@@ -0,0 +1,32 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ManifestExists reports whether the registry still serves digest for
+// image's repository. Registries that garbage-collect untagged manifests
+// answer 404 for digests no tag points to anymore. The lookup bypasses the
+// digest cache.
+func (c *Client) ManifestExists(ctx context.Context, image, digest string) (bool, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse image reference: %w", err)
+	}
+	ref.Digest = digest
+
+	token, err := c.getAuthToken(ctx, ref)
+	if err != nil {
+		token = ""
+	}
+
+	if _, _, err := c.fetchManifest(ctx, ref, token, true); err != nil {
+		if errors.Is(err, ErrManifestNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,37 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestManifestExists(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/manifests/sha256:kept"):
+			_ = json.NewEncoder(w).Encode(ManifestResponse{SchemaVersion: 2})
+		case strings.HasSuffix(r.URL.Path, "/manifests/sha256:broken"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	image := testImage(srv, "library/app:1.2")
+
+	if ok, err := client.ManifestExists(context.Background(), image, "sha256:kept"); err != nil || !ok {
+		t.Errorf("expected the kept digest to exist, got %v, %v", ok, err)
+	}
+	if ok, err := client.ManifestExists(context.Background(), image, "sha256:collected"); err != nil || ok {
+		t.Errorf("expected a collected digest to be missing, got %v, %v", ok, err)
+	}
+	if _, err := client.ManifestExists(context.Background(), image, "sha256:broken"); err == nil {
+		t.Error("expected an error when the registry fails")
+	}
+}
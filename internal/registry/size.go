@@ -0,0 +1,74 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// FetchImageSize returns the compressed size of an image's config and layers
+// as published by the registry. For multi-platform images the manifest for
+// platform ("os/arch[/variant]") is used; an empty platform means
+// linux/<arch of this binary>.
+func (c *Client) FetchImageSize(ctx context.Context, image, platform string) (int64, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse image reference: %w", err)
+	}
+	if ref.Tag != "" && ref.Digest != "" {
+		ref.Digest = ""
+	}
+
+	token, err := c.getAuthToken(ctx, ref)
+	if err != nil {
+		token = ""
+	}
+
+	manifest, _, err := c.fetchManifest(ctx, ref, token, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+
+	if len(manifest.Manifests) > 0 {
+		entry, ok := selectPlatform(manifest.Manifests, platform)
+		if !ok {
+			return 0, fmt.Errorf("no manifest for platform %s", platformOrDefault(platform))
+		}
+		platformRef := *ref
+		platformRef.Digest = entry.Digest
+		if manifest, _, err = c.fetchManifest(ctx, &platformRef, token, true); err != nil {
+			return 0, fmt.Errorf("failed to fetch platform manifest: %w", err)
+		}
+	}
+
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return size, nil
+}
+
+// selectPlatform picks the manifest list entry matching platform. A variant
+// is only compared when the requested platform names one.
+func selectPlatform(entries []ManifestEntry, platform string) (ManifestEntry, bool) {
+	parts := strings.Split(platformOrDefault(platform), "/")
+	for _, entry := range entries {
+		if entry.Platform.OS != parts[0] || entry.Platform.Architecture != parts[1] {
+			continue
+		}
+		if len(parts) > 2 && entry.Platform.Variant != parts[2] {
+			continue
+		}
+		return entry, true
+	}
+	return ManifestEntry{}, false
+}
+
+func platformOrDefault(platform string) string {
+	platform = strings.TrimSpace(platform)
+	if strings.Count(platform, "/") < 1 {
+		return "linux/" + runtime.GOARCH
+	}
+	return platform
+}
@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchImageSize_SelectsPlatformManifest(t *testing.T) {
+	index := ManifestResponse{
+		SchemaVersion: 2,
+		MediaType:     "application/vnd.oci.image.index.v1+json",
+		Manifests: []ManifestEntry{
+			{Digest: "sha256:amd64", Platform: ManifestPlatform{OS: "linux", Architecture: "amd64"}},
+			{Digest: "sha256:armv7", Platform: ManifestPlatform{OS: "linux", Architecture: "arm", Variant: "v7"}},
+		},
+	}
+	images := map[string]ManifestResponse{
+		"sha256:amd64": {Config: ManifestConfig{Size: 100}, Layers: []ManifestLayer{{Size: 1000}, {Size: 2000}}},
+		"sha256:armv7": {Config: ManifestConfig{Size: 50}, Layers: []ManifestLayer{{Size: 500}}},
+	}
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reference := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if manifest, ok := images[reference]; ok {
+			_ = json.NewEncoder(w).Encode(manifest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(index)
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	image := testImage(srv, "library/app:latest")
+
+	size, err := client.FetchImageSize(context.Background(), image, "linux/amd64")
+	if err != nil || size != 3100 {
+		t.Fatalf("amd64 size = %d, %v; want 3100", size, err)
+	}
+	size, err = client.FetchImageSize(context.Background(), image, "linux/arm/v7")
+	if err != nil || size != 550 {
+		t.Fatalf("arm/v7 size = %d, %v; want 550", size, err)
+	}
+	if _, err := client.FetchImageSize(context.Background(), image, "linux/s390x"); err == nil {
+		t.Fatal("expected an error for a platform missing from the index")
+	}
+}
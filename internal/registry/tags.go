@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mutableTags are tags that follow a release channel or branch rather than
+// naming one release, so the image behind them changes without notice.
+var mutableTags = map[string]bool{
+	"latest": true, "stable": true, "edge": true, "nightly": true,
+	"main": true, "master": true, "develop": true, "dev": true,
+	"next": true, "beta": true, "canary": true, "mainline": true,
+	"release": true, "rolling": true, "unstable": true, "lts": true,
+}
+
+// versionTag matches plain release versions such as 1.27, 1.27.3 or v2.0.1.
+var versionTag = regexp.MustCompile(`^v?\d+(\.\d+){1,2}$`)
+
+// MutableTag returns the tag image tracks when that tag is a release channel
+// or branch such as latest or main. Digest-pinned images never are.
+func MutableTag(image string) (string, bool) {
+	ref, err := ParseImageReference(image)
+	if err != nil || ref.Digest != "" {
+		return "", false
+	}
+	return ref.Tag, mutableTags[strings.ToLower(ref.Tag)]
+}
+
+// LatestVersionTag returns the highest plain release version among tags, or
+// "" when there is none. Pre-releases and variant tags such as 1.27-alpine
+// are not considered, and of equal versions the most specific tag wins.
+func LatestVersionTag(tags []string) string {
+	best := ""
+	var bestParts []int
+	for _, tag := range tags {
+		if !versionTag.MatchString(tag) {
+			continue
+		}
+		parts := versionParts(tag)
+		if best == "" || compareVersions(parts, bestParts) > 0 ||
+			(compareVersions(parts, bestParts) == 0 && len(tag) > len(best)) {
+			best, bestParts = tag, parts
+		}
+	}
+	return best
+}
+
+func versionParts(tag string) []int {
+	fields := strings.Split(strings.TrimPrefix(tag, "v"), ".")
+	parts := make([]int, 3)
+	for i, field := range fields {
+		parts[i], _ = strconv.Atoi(field)
+	}
+	return parts
+}
+
+func compareVersions(a, b []int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] > b[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// ImageTags lists the tags of image's repository, at most MaxCatalogEntries
+// of them.
+func (c *Client) ImageTags(ctx context.Context, image string) ([]string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+	host := ref.Registry
+	if ref.IsDockerHub() {
+		host = "registry-1.docker.io"
+	}
+	return c.ListTags(ctx, Endpoint{Name: ref.Registry, URL: "https://" + host}, ref.Repository)
+}
+
+// SuggestVersionTag returns the most recent release version tag of image's
+// repository, as a pin for images tracking a mutable tag. The answer shares
+// the digest cache and its TTLs.
+func (c *Client) SuggestVersionTag(ctx context.Context, image string) (string, error) {
+	ref, err := ParseImageReference(image)
+	if err != nil {
+		return "", err
+	}
+	cacheKey := "versions:" + ref.Registry + "/" + ref.Repository
+	if tag, err, ok := c.cachedDigest(cacheKey); ok {
+		return tag, err
+	}
+	tags, err := c.ImageTags(ctx, image)
+	tag := ""
+	if err == nil {
+		tag = LatestVersionTag(tags)
+	}
+	c.setCachedDigest(cacheKey, tag, err)
+	return tag, err
+}
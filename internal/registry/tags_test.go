@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMutableTag(t *testing.T) {
+	tests := []struct {
+		image   string
+		tag     string
+		mutable bool
+	}{
+		{"nginx", "latest", true},
+		{"nginx:latest", "latest", true},
+		{"ghcr.io/org/app:main", "main", true},
+		{"nginx:1.27", "1.27", false},
+		{"nginx:latest@sha256:abc123", "", false},
+		{"nginx:alpine", "alpine", false},
+	}
+	for _, tt := range tests {
+		tag, mutable := MutableTag(tt.image)
+		if tag != tt.tag || mutable != tt.mutable {
+			t.Errorf("MutableTag(%q) = %q, %v; want %q, %v", tt.image, tag, mutable, tt.tag, tt.mutable)
+		}
+	}
+}
+
+func TestLatestVersionTag(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want string
+	}{
+		{[]string{"1.25", "1.27.3", "1.27", "1.9.10", "latest", "1.28.0-rc1", "1.27-alpine"}, "1.27.3"},
+		{[]string{"v1.2.0", "v1.10.0", "v1.9.9"}, "v1.10.0"},
+		{[]string{"1.27", "1.27.0"}, "1.27.0"},
+		{[]string{"latest", "alpine"}, ""},
+	}
+	for _, tt := range tests {
+		if got := LatestVersionTag(tt.tags); got != tt.want {
+			t.Errorf("LatestVersionTag(%v) = %q, want %q", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestVersionTag(t *testing.T) {
+	requests := 0
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v2/org/app/tags/list" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"tags": []string{"latest", "2.1.0", "2.0.4"}})
+	}))
+	defer srv.Close()
+
+	client := newTestClient(srv)
+	image := strings.TrimPrefix(srv.URL, "https://") + "/org/app:latest"
+	for range 2 {
+		tag, err := client.SuggestVersionTag(context.Background(), image)
+		if err != nil || tag != "2.1.0" {
+			t.Fatalf("SuggestVersionTag() = %q, %v; want 2.1.0", tag, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the suggestion to be cached, got %d requests", requests)
+	}
+}
@@ -3,6 +3,8 @@ package registry
 import (
 	"fmt"
 	"strings"
+
+	"github.com/distribution/reference"
 )
 
 // ImageReference represents a parsed Docker image reference
@@ -13,12 +15,16 @@ type ImageReference struct {
 	Digest     string // e.g., "sha256:abc123..."
 }
 
-// ParseImageReference parses an image reference into components
-// Supports formats:
+// ParseImageReference parses an image reference into components using the
+// same normalization as the Docker CLI. Supports formats:
 //   - nginx:latest
 //   - docker.io/library/nginx:latest
 //   - ghcr.io/user/image:v1.0.0
+//   - localhost:5000/image and registry.example.com:8443/org/team/image
 //   - nginx@sha256:abc123...
+//   - lscr.io/linuxserver/radarr:latest@sha256:abc123...
+//
+// A reference without a tag or digest gets the "latest" tag.
 func ParseImageReference(image string) (*ImageReference, error) {
 	if image == "" {
 		return nil, fmt.Errorf("empty image reference")
@@ -32,63 +38,46 @@ func ParseImageReference(image string) (*ImageReference, error) {
 		return nil, fmt.Errorf("unresolvable image reference %q: bare digest without a repository", image)
 	}
 
-	ref := &ImageReference{
-		Registry: "docker.io", // Default to Docker Hub
-		Tag:      "latest",    // Default tag
-	}
-
-	// Handle digest
-	if strings.Contains(image, "@") {
-		parts := strings.Split(image, "@")
-		image = parts[0]
-		ref.Digest = parts[1]
-		ref.Tag = "" // Digest overrides tag
-	}
-
-	// Handle tag
-	if strings.Contains(image, ":") {
-		parts := strings.Split(image, ":")
-		// Check if this is a port (registry:port/repo) or a tag (repo:tag)
-		if strings.Contains(parts[len(parts)-1], "/") {
-			// This is a port, not a tag
-			image = strings.Join(parts, ":")
-		} else {
-			// This is a tag
-			ref.Tag = parts[len(parts)-1]
-			image = strings.Join(parts[:len(parts)-1], ":")
-		}
-	}
-
-	// Parse registry and repository
-	parts := strings.Split(image, "/")
-
-	switch len(parts) {
-	case 1:
-		// Just image name: nginx
-		ref.Repository = "library/" + parts[0]
-	case 2:
-		// Check if first part is a registry
-		if strings.Contains(parts[0], ".") || strings.Contains(parts[0], ":") {
-			// registry/repo: ghcr.io/image
-			ref.Registry = parts[0]
-			ref.Repository = parts[1]
-		} else {
-			// user/repo: user/nginx
-			ref.Repository = parts[0] + "/" + parts[1]
-		}
-	case 3:
-		// registry/user/repo: ghcr.io/user/nginx
-		ref.Registry = parts[0]
-		ref.Repository = parts[1] + "/" + parts[2]
-	default:
-		// registry/org/team/repo: registry.example.com/org/team/image
-		ref.Registry = parts[0]
-		ref.Repository = strings.Join(parts[1:], "/")
+	// The digest is kept verbatim rather than validated here: the registry is
+	// the authority on which digests exist.
+	name, digest, hasDigest := strings.Cut(image, "@")
+	if hasDigest && digest == "" {
+		return nil, fmt.Errorf("invalid image reference %q: empty digest", image)
+	}
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid image reference %q: %w", image, err)
 	}
 
+	ref := &ImageReference{
+		Registry:   reference.Domain(named),
+		Repository: reference.Path(named),
+		Digest:     digest,
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		ref.Tag = tagged.Tag()
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
 	return ref, nil
 }
 
+// Retag returns image pointing at tag instead of its current tag or digest,
+// in its familiar form, e.g. "nginx:1.27" for "docker.io/library/nginx:1.25".
+func Retag(image, tag string) (string, error) {
+	name, _, _ := strings.Cut(image, "@")
+	named, err := reference.ParseNormalizedNamed(name)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %q: %w", image, err)
+	}
+	tagged, err := reference.WithTag(reference.TrimNamed(named), tag)
+	if err != nil {
+		return "", fmt.Errorf("invalid tag %q: %w", tag, err)
+	}
+	return reference.FamiliarString(tagged), nil
+}
+
 // String returns the full image reference
 func (r *ImageReference) String() string {
 	var sb strings.Builder
@@ -141,6 +130,36 @@ func (r *ImageReference) CacheKey() string {
 	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, reference)
 }
 
+// MatchesImage reports whether image refers to the repository named by query.
+// A query without a tag matches any tag of the repository; "nginx" and
+// "docker.io/library/nginx:latest" name the same image.
+func MatchesImage(query, image string) bool {
+	want, err := ParseImageReference(query)
+	if err != nil {
+		return false
+	}
+	got, err := ParseImageReference(image)
+	if err != nil {
+		return false
+	}
+	if want.Registry != got.Registry || want.Repository != got.Repository {
+		return false
+	}
+	if !hasExplicitTag(query) {
+		return true
+	}
+	return want.Tag == got.Tag
+}
+
+// hasExplicitTag reports whether image names a tag rather than relying on
+// the "latest" default.
+func hasExplicitTag(image string) bool {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	return strings.LastIndex(image, ":") > strings.LastIndex(image, "/")
+}
+
 // AuthURL returns the auth URL for this registry
 func (r *ImageReference) AuthURL() string {
 	if r.IsDockerHub() {
@@ -0,0 +1,157 @@
+// Package sbom captures software bills of materials for the images Bulwark
+// applies, so each digest in the update history can be audited later.
+package sbom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+// Supported output formats. Both are written by syft as JSON documents.
+const (
+	FormatCycloneDX = "cyclonedx-json"
+	FormatSPDX      = "spdx-json"
+)
+
+// DefaultTimeout bounds a single syft scan.
+const DefaultTimeout = 5 * time.Minute
+
+// SyftGenerator produces SBOMs by running the syft CLI against the applied
+// image and stores each document under Dir, named after its digest.
+type SyftGenerator struct {
+	Binary  string
+	Dir     string
+	Format  string
+	Timeout time.Duration
+	logger  *logging.Logger
+
+	// run executes syft and returns its stdout; replaced in tests.
+	run func(ctx context.Context, binary string, args ...string) ([]byte, error)
+}
+
+// NewSyftGenerator creates a generator writing documents in format to dir.
+// An empty binary means "syft" on PATH; an unknown format falls back to
+// CycloneDX.
+func NewSyftGenerator(binary, dir, format string, logger *logging.Logger) *SyftGenerator {
+	if binary == "" {
+		binary = "syft"
+	}
+	if format != FormatSPDX {
+		format = FormatCycloneDX
+	}
+	return &SyftGenerator{
+		Binary:  binary,
+		Dir:     dir,
+		Format:  format,
+		Timeout: DefaultTimeout,
+		logger:  logger.WithComponent("sbom"),
+		run:     runCommand,
+	}
+}
+
+// Available reports whether the syft binary can be found.
+func (g *SyftGenerator) Available() bool {
+	_, err := exec.LookPath(g.Binary)
+	return err == nil
+}
+
+// Generate scans image pinned to the registry digest and stores the resulting
+// document; platform selects the entry of a multi-arch image. A document
+// already captured for the digest is reused.
+func (g *SyftGenerator) Generate(ctx context.Context, image, digest, platform string) (*state.SBOM, error) {
+	if digest == "" {
+		return nil, fmt.Errorf("no digest to scan for %s", image)
+	}
+
+	path := filepath.Join(g.Dir, fileName(digest, g.Format))
+	if data, err := os.ReadFile(path); err == nil {
+		return &state.SBOM{Format: g.Format, Path: path, Packages: countPackages(data)}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.Timeout)
+	defer cancel()
+
+	source := scanSource(image, digest)
+	g.logger.Debug().Str("source", source).Str("format", g.Format).Msg("Generating SBOM")
+
+	args := []string{"scan", source, "-o", g.Format, "-q"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	data, err := g.run(ctx, g.Binary, args...)
+	if err != nil {
+		return nil, fmt.Errorf("syft scan of %s failed: %w", source, err)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("syft scan of %s returned invalid JSON", source)
+	}
+
+	if err := os.MkdirAll(g.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create sbom directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write sbom: %w", err)
+	}
+
+	sbom := &state.SBOM{Format: g.Format, Path: path, Packages: countPackages(data)}
+	g.logger.Info().
+		Str("source", source).
+		Int("packages", sbom.Packages).
+		Msg("Captured SBOM")
+	return sbom, nil
+}
+
+// scanSource pins the scan to the applied digest, since the tag may already
+// point elsewhere by the time the scan runs.
+func scanSource(image, digest string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image + "@" + digest
+}
+
+func fileName(digest, format string) string {
+	name := strings.ReplaceAll(digest, ":", "-")
+	if format == FormatSPDX {
+		return name + ".spdx.json"
+	}
+	return name + ".cdx.json"
+}
+
+// countPackages reads the package count from a CycloneDX or SPDX document.
+func countPackages(data []byte) int {
+	var doc struct {
+		Components []json.RawMessage `json:"components"`
+		Packages   []json.RawMessage `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return 0
+	}
+	return len(doc.Components) + len(doc.Packages)
+}
+
+func runCommand(ctx context.Context, binary string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, binary, args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	return out, nil
+}
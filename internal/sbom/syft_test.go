@@ -0,0 +1,71 @@
+package sbom
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+)
+
+func TestSyftGeneratorStoresDocument(t *testing.T) {
+	g := NewSyftGenerator("", t.TempDir(), "", logging.Default())
+	var calls []string
+	g.run = func(_ context.Context, _ string, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(args, " "))
+		return []byte(`{"bomFormat":"CycloneDX","components":[{"name":"a"},{"name":"b"}]}`), nil
+	}
+
+	sbom, err := g.Generate(context.Background(), "ghcr.io/acme/api:1.2", "sha256:abc", "linux/arm64")
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if sbom.Format != FormatCycloneDX || sbom.Packages != 2 {
+		t.Fatalf("unexpected sbom %+v", sbom)
+	}
+	if _, err := os.Stat(sbom.Path); err != nil {
+		t.Fatalf("document not written: %v", err)
+	}
+	if want := "scan ghcr.io/acme/api@sha256:abc -o cyclonedx-json -q --platform linux/arm64"; len(calls) != 1 || calls[0] != want {
+		t.Fatalf("syft called with %q, want %q", calls, want)
+	}
+
+	// The same digest is served from disk without another scan.
+	if _, err := g.Generate(context.Background(), "ghcr.io/acme/api:1.2", "sha256:abc", ""); err != nil {
+		t.Fatalf("second Generate failed: %v", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("expected cached document, syft ran %d times", len(calls))
+	}
+}
+
+func TestSyftGeneratorErrors(t *testing.T) {
+	g := NewSyftGenerator("", t.TempDir(), FormatSPDX, logging.Default())
+	g.run = func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("exit status 1")
+	}
+
+	if _, err := g.Generate(context.Background(), "nginx", "", ""); err == nil {
+		t.Fatal("expected error without digest")
+	}
+	if _, err := g.Generate(context.Background(), "nginx", "sha256:abc", ""); err == nil {
+		t.Fatal("expected error when syft fails")
+	}
+}
+
+func TestScanSource(t *testing.T) {
+	tests := map[string]string{
+		"nginx":                         "nginx@sha256:abc",
+		"nginx:1.27":                    "nginx@sha256:abc",
+		"registry:5000/app:v1":          "registry:5000/app@sha256:abc",
+		"ghcr.io/acme/api@sha256:old":   "ghcr.io/acme/api@sha256:abc",
+		"ghcr.io/acme/api:1@sha256:old": "ghcr.io/acme/api@sha256:abc",
+	}
+	for image, want := range tests {
+		if got := scanSource(image, "sha256:abc"); got != want {
+			t.Errorf("scanSource(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	historyKey       = "scheduler.history"
+	historyRunsLimit = 10
+)
+
+// JobRun records a single execution of a job.
+type JobRun struct {
+	StartedAt  time.Time `json:"started_at"`
+	DurationMs int64     `json:"duration_ms"`
+	Result     string    `json:"result"`
+	Error      string    `json:"error,omitempty"`
+	Trigger    string    `json:"trigger,omitempty"`
+}
+
+// SettingsStore is the key/value persistence used to keep job history across restarts.
+type SettingsStore interface {
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+}
+
+// History keeps recent runs per job name. It outlives individual Scheduler
+// instances so status survives schedule reloads, and optionally persists to a
+// SettingsStore so it survives process restarts. It also tracks which jobs are
+// running, so a job still running on a replaced Scheduler blocks a new run of
+// the same name.
+type History struct {
+	mu      sync.RWMutex
+	runs    map[string][]JobRun
+	running map[string]bool
+	store   SettingsStore
+}
+
+// NewHistory creates a job history, loading previous runs from store if set.
+func NewHistory(ctx context.Context, store SettingsStore) *History {
+	h := &History{runs: make(map[string][]JobRun), running: make(map[string]bool), store: store}
+	if store == nil {
+		return h
+	}
+	raw, err := store.GetSetting(ctx, historyKey)
+	if err != nil || raw == "" {
+		return h
+	}
+	var runs map[string][]JobRun
+	if err := json.Unmarshal([]byte(raw), &runs); err == nil && runs != nil {
+		h.runs = runs
+	}
+	return h
+}
+
+// Record appends a run for the named job, keeping the most recent runs only.
+func (h *History) Record(ctx context.Context, name string, run JobRun) {
+	h.mu.Lock()
+	runs := append([]JobRun{run}, h.runs[name]...)
+	if len(runs) > historyRunsLimit {
+		runs = runs[:historyRunsLimit]
+	}
+	h.runs[name] = runs
+	var raw []byte
+	if h.store != nil {
+		raw, _ = json.Marshal(h.runs)
+	}
+	h.mu.Unlock()
+
+	if raw != nil {
+		_ = h.store.SetSetting(ctx, historyKey, string(raw))
+	}
+}
+
+// Runs returns recent runs for a job, newest first.
+func (h *History) Runs(name string) []JobRun {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]JobRun(nil), h.runs[name]...)
+}
+
+// Last returns the most recent run of a job, if any.
+func (h *History) Last(name string) (JobRun, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	runs := h.runs[name]
+	if len(runs) == 0 {
+		return JobRun{}, false
+	}
+	return runs[0], true
+}
+
+// start marks the named job running, reporting false if it already was.
+func (h *History) start(name string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.running[name] {
+		return false
+	}
+	h.running[name] = true
+	return true
+}
+
+// finish marks the named job no longer running.
+func (h *History) finish(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.running, name)
+}
+
+// isRunning reports whether the named job is running.
+func (h *History) isRunning(name string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.running[name]
+}
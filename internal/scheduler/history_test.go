@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memorySettings struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func (m *memorySettings) GetSetting(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.values[key], nil
+}
+
+func (m *memorySettings) SetSetting(_ context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = value
+	return nil
+}
+
+func TestHistory_PersistsAcrossInstances(t *testing.T) {
+	store := &memorySettings{values: map[string]string{}}
+	h := NewHistory(context.Background(), store)
+	h.Record(context.Background(), "job", JobRun{StartedAt: time.Now(), Result: "success"})
+
+	reloaded := NewHistory(context.Background(), store)
+	last, ok := reloaded.Last("job")
+	if !ok {
+		t.Fatal("expected persisted run")
+	}
+	if last.Result != "success" {
+		t.Errorf("expected success, got %s", last.Result)
+	}
+}
+
+func TestHistory_KeepsRecentRuns(t *testing.T) {
+	h := NewHistory(context.Background(), nil)
+	for i := 0; i < historyRunsLimit+5; i++ {
+		h.Record(context.Background(), "job", JobRun{DurationMs: int64(i)})
+	}
+	runs := h.Runs("job")
+	if len(runs) != historyRunsLimit {
+		t.Fatalf("expected %d runs, got %d", historyRunsLimit, len(runs))
+	}
+	if runs[0].DurationMs != int64(historyRunsLimit+4) {
+		t.Errorf("expected newest run first, got %d", runs[0].DurationMs)
+	}
+}
+
+func TestStatus_ReportsLastRun(t *testing.T) {
+	s := NewScheduler(testLogger())
+	job := &fakeJob{
+		name:   "failing",
+		execFn: func(ctx context.Context) error { return fmt.Errorf("boom") },
+	}
+	if err := s.AddJob("0 3 * * *", job); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runScheduled(t, s, "failing")
+
+	statuses := s.Status()
+	if len(statuses) != 1 {
+		t.Fatalf("expected 1 status, got %d", len(statuses))
+	}
+	status := statuses[0]
+	if status.Schedule != "0 3 * * *" {
+		t.Errorf("unexpected schedule %s", status.Schedule)
+	}
+	if status.NextRun == nil {
+		t.Error("expected next run")
+	}
+	if status.LastRun == nil || status.LastResult != "failed" || status.LastError != "boom" {
+		t.Errorf("unexpected last run: %+v", status)
+	}
+}
+
+func TestRunNow(t *testing.T) {
+	s := NewScheduler(testLogger())
+	done := make(chan struct{})
+	job := &fakeJob{
+		name:   "manual",
+		execFn: func(ctx context.Context) error { close(done); return nil },
+	}
+	_ = s.AddJob("0 3 * * *", job)
+
+	if err := s.RunNow("manual"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job was not executed")
+	}
+
+	if err := s.RunNow("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("expected ErrJobNotFound, got %v", err)
+	}
+}
@@ -107,7 +107,7 @@ func NewApplyJob(root string, dockerClient *docker.Client, policyEngine *policy.
 	return &ApplyJob{
 		root:         root,
 		dockerClient: dockerClient,
-		executor:     executor.NewExecutor(dockerClient, policyEngine, store, logger, false),
+		executor:     executor.NewExecutor(dockerClient, policyEngine, store, logger, false).WithTrigger(state.TriggerAutoApprove, "apply-updates"),
 		discoverer:   discoverer,
 		logger:       logger.WithComponent("apply-job"),
 	}
@@ -172,7 +172,7 @@ func (j *ApplyJob) Execute(ctx context.Context) error {
 			} else {
 				failedCount++
 				j.logger.Error().
-					Err(result.Error).
+					Str("error", result.ErrorMessage).
 					Str("target", target.Name).
 					Str("service", service.Name).
 					Msg("Update failed")
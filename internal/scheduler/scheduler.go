@@ -2,7 +2,11 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,25 +23,129 @@ type Job interface {
 	Name() string
 }
 
+// DefaultJobTimeout bounds a job execution when no per-job timeout is set.
+const DefaultJobTimeout = 30 * time.Minute
+
+var (
+	// ErrJobNotFound is returned when a job name is not registered.
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobRunning is returned when a job is triggered while a previous run is in progress.
+	ErrJobRunning = errors.New("job already running")
+	// ErrSchedulerStopped is returned when a job is triggered on a stopped scheduler.
+	ErrSchedulerStopped = errors.New("scheduler stopped")
+)
+
+// Triggers describe what started a job execution.
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+	TriggerCatchUp   = "catch-up"
+)
+
+type triggerKey struct{}
+
+// WithTrigger annotates ctx with what started a job execution.
+func WithTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, triggerKey{}, trigger)
+}
+
+// TriggerFromContext returns the trigger set by WithTrigger, or "".
+func TriggerFromContext(ctx context.Context) string {
+	trigger, _ := ctx.Value(triggerKey{}).(string)
+	return trigger
+}
+
+// JobOptions tunes how a single job is executed.
+type JobOptions struct {
+	// Timeout bounds a single execution. Zero uses DefaultJobTimeout.
+	Timeout time.Duration
+}
+
+// JobStatus describes a registered job and its most recent execution.
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Timezone     string     `json:"timezone"`
+	Running      bool       `json:"running"`
+	TimeoutSec   int        `json:"timeout_sec"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastDuration int64      `json:"last_duration_ms,omitempty"`
+	LastResult   string     `json:"last_result,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Recent       []JobRun   `json:"recent,omitempty"`
+}
+
+type scheduledJob struct {
+	job      Job
+	schedule string
+	timeout  time.Duration
+	entryID  cron.EntryID
+}
+
 // Scheduler manages scheduled jobs with cron expressions
 type Scheduler struct {
-	cron   *cron.Cron
-	jobs   map[string]Job
-	logger *logging.Logger
-	mu     sync.RWMutex
+	cron     *cron.Cron
+	jobs     map[string]*scheduledJob
+	history  *History
+	jitter   time.Duration
+	location *time.Location
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	// runs counts the executions started by RunAs, which Stop waits for
+	// like cron waits for its own; stopped refuses new ones once Stop began.
+	runs    sync.WaitGroup
+	stopped bool
+	logger  *logging.Logger
+	mu      sync.RWMutex
 }
 
 // NewScheduler creates a new scheduler
 func NewScheduler(logger *logging.Logger) *Scheduler {
 	return &Scheduler{
-		cron:   cron.New(),
-		jobs:   make(map[string]Job),
-		logger: logger.WithComponent("scheduler"),
+		cron:     cron.New(),
+		jobs:     make(map[string]*scheduledJob),
+		history:  NewHistory(context.Background(), nil),
+		location: time.Local,
+		stopCh:   make(chan struct{}),
+		logger:   logger.WithComponent("scheduler"),
 	}
 }
 
+// WithLocation evaluates schedules without a CRON_TZ=/TZ= prefix in loc.
+// It must be called before any jobs are added.
+func (s *Scheduler) WithLocation(loc *time.Location) *Scheduler {
+	if loc != nil {
+		s.location = loc
+		s.cron = cron.New(cron.WithLocation(loc))
+	}
+	return s
+}
+
+// WithHistory records job executions into a shared history.
+func (s *Scheduler) WithHistory(history *History) *Scheduler {
+	if history != nil {
+		s.history = history
+	}
+	return s
+}
+
+// WithJitter delays each cron-triggered execution by a random
+// duration up to max, so several instances sharing a schedule spread out.
+func (s *Scheduler) WithJitter(max time.Duration) *Scheduler {
+	if max > 0 {
+		s.jitter = max
+	}
+	return s
+}
+
 // AddJob adds a job with a cron expression
 func (s *Scheduler) AddJob(cronExpr string, job Job) error {
+	return s.AddJobWithOptions(cronExpr, job, JobOptions{})
+}
+
+// AddJobWithOptions adds a job with a cron expression and execution options
+func (s *Scheduler) AddJobWithOptions(cronExpr string, job Job, opts JobOptions) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -52,16 +160,21 @@ func (s *Scheduler) AddJob(cronExpr string, job Job) error {
 		return fmt.Errorf("invalid cron expression %q: %w", cronExpr, err)
 	}
 
+	entry := &scheduledJob{job: job, schedule: cronExpr, timeout: opts.Timeout}
+	if entry.timeout <= 0 {
+		entry.timeout = DefaultJobTimeout
+	}
+
 	// Add job to cron
-	_, err = s.cron.AddFunc(cronExpr, func() {
-		s.executeJob(job)
+	entry.entryID, err = s.cron.AddFunc(cronExpr, func() {
+		s.dispatch(entry, entry.job, TriggerScheduled)
 	})
 
 	if err != nil {
 		return fmt.Errorf("failed to add job: %w", err)
 	}
 
-	s.jobs[job.Name()] = job
+	s.jobs[job.Name()] = entry
 
 	s.logger.Info().
 		Str("job", job.Name()).
@@ -83,28 +196,140 @@ func (s *Scheduler) Start() {
 	s.cron.Start()
 }
 
-// Stop stops the scheduler gracefully
+// Stop stops the scheduler gracefully, waiting for running jobs to finish.
 func (s *Scheduler) Stop() {
 	s.logger.Info().Msg("Stopping scheduler")
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.stopOnce.Do(func() { close(s.stopCh) })
 	ctx := s.cron.Stop()
 	<-ctx.Done()
+	s.runs.Wait()
 	s.logger.Info().Msg("Scheduler stopped")
 }
 
-// executeJob runs a job with logging and error handling
-func (s *Scheduler) executeJob(job Job) {
+// RunNow triggers a registered job immediately in the background.
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.RLock()
+	entry, ok := s.jobs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrJobNotFound, name)
+	}
+	return s.RunAs(entry.job, TriggerManual)
+}
+
+// RunAs runs job in the background, recording trigger in its history. If a
+// job with the same name is registered, it shares that job's timeout. Runs are
+// kept from overlapping by name, so a variant of a scheduled job cannot run
+// alongside it.
+func (s *Scheduler) RunAs(job Job, trigger string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return ErrSchedulerStopped
+	}
+	entry, ok := s.jobs[job.Name()]
+	if !ok {
+		entry = &scheduledJob{job: job, timeout: DefaultJobTimeout}
+	}
+	if s.history.isRunning(job.Name()) {
+		return fmt.Errorf("%w: %s", ErrJobRunning, job.Name())
+	}
+
+	s.runs.Add(1)
+	go func() {
+		defer s.runs.Done()
+		s.dispatch(entry, job, trigger)
+	}()
+	return nil
+}
+
+// Status returns the status of every registered job sorted by name.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]JobStatus, 0, len(s.jobs))
+	for name, entry := range s.jobs {
+		status := JobStatus{
+			Name:       name,
+			Schedule:   entry.schedule,
+			Timezone:   ScheduleTimezone(entry.schedule, s.location),
+			Running:    s.history.isRunning(name),
+			TimeoutSec: int(entry.timeout.Seconds()),
+		}
+		if next := s.cron.Entry(entry.entryID).Next; !next.IsZero() {
+			status.NextRun = &next
+		} else if next, err := NextRun(entry.schedule, s.location, time.Now()); err == nil {
+			status.NextRun = &next
+		}
+		status.Recent = s.history.Runs(name)
+		if len(status.Recent) > 0 {
+			last := status.Recent[0]
+			status.LastRun = &last.StartedAt
+			status.LastDuration = last.DurationMs
+			status.LastResult = last.Result
+			status.LastError = last.Error
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// dispatch applies jitter and overlap prevention before running a job.
+func (s *Scheduler) dispatch(entry *scheduledJob, job Job, trigger string) {
+	if trigger == TriggerScheduled && s.jitter > 0 {
+		delay := time.Duration(rand.Int63n(int64(s.jitter)))
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-s.stopCh:
+			timer.Stop()
+			return
+		}
+	}
+
+	if !s.history.start(job.Name()) {
+		s.logger.Warn().
+			Str("job", job.Name()).
+			Msg("Skipping scheduled job, previous run still in progress")
+		s.history.Record(context.Background(), job.Name(), JobRun{
+			StartedAt: time.Now(),
+			Result:    "skipped",
+			Error:     "previous run still in progress",
+			Trigger:   trigger,
+		})
+		return
+	}
+	defer s.history.finish(job.Name())
+
+	s.runJob(job, entry.timeout, trigger)
+}
+
+func (s *Scheduler) runJob(job Job, timeout time.Duration, trigger string) {
 	s.logger.Info().
 		Str("job", job.Name()).
+		Str("trigger", trigger).
 		Msg("Executing scheduled job")
 
 	start := time.Now()
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	ctx, cancel := context.WithTimeout(WithTrigger(context.Background(), trigger), timeout)
 	defer cancel()
 
 	err := job.Execute(ctx)
 	duration := time.Since(start)
 
+	run := JobRun{StartedAt: start, DurationMs: duration.Milliseconds(), Result: "success", Trigger: trigger}
+	if err != nil {
+		run.Result = "failed"
+		run.Error = err.Error()
+	}
+	s.history.Record(context.Background(), job.Name(), run)
+
 	if err != nil {
 		s.logger.Error().
 			Err(err).
@@ -119,6 +344,35 @@ func (s *Scheduler) executeJob(job Job) {
 	}
 }
 
+// ScheduleTimezone returns the timezone a cron expression is evaluated in:
+// its CRON_TZ= or TZ= prefix if present, otherwise fallback.
+func ScheduleTimezone(expr string, fallback *time.Location) string {
+	expr = strings.TrimSpace(expr)
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(expr, prefix) {
+			tz, _, _ := strings.Cut(strings.TrimPrefix(expr, prefix), " ")
+			return tz
+		}
+	}
+	if fallback == nil {
+		fallback = time.Local
+	}
+	return fallback.String()
+}
+
+// NextRun returns the next activation of a cron expression after from,
+// evaluating unprefixed expressions in loc.
+func NextRun(expr string, loc *time.Location, from time.Time) (time.Time, error) {
+	sched, err := cron.ParseStandard(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return sched.Next(from.In(loc)), nil
+}
+
 // GetJobs returns the list of registered jobs
 func (s *Scheduler) GetJobs() []string {
 	s.mu.RLock()
@@ -2,7 +2,9 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -109,11 +111,26 @@ func TestGetJobs_Empty(t *testing.T) {
 	}
 }
 
+// runScheduled runs a registered job the way cron does, through the function
+// its cron entry calls.
+func runScheduled(t *testing.T, s *Scheduler, name string) {
+	t.Helper()
+	s.mu.RLock()
+	entry, ok := s.jobs[name]
+	s.mu.RUnlock()
+	if !ok {
+		t.Errorf("job %s is not registered", name)
+		return
+	}
+	s.cron.Entry(entry.entryID).WrappedJob.Run()
+}
+
 func TestExecuteJob_Success(t *testing.T) {
 	s := NewScheduler(testLogger())
 	job := newFakeJob("ok-job")
+	_ = s.AddJob("*/5 * * * *", job)
 
-	s.executeJob(job)
+	runScheduled(t, s, "ok-job")
 
 	if job.execCount.Load() != 1 {
 		t.Errorf("expected 1 execution, got %d", job.execCount.Load())
@@ -128,9 +145,10 @@ func TestExecuteJob_Error(t *testing.T) {
 			return fmt.Errorf("job failed")
 		},
 	}
+	_ = s.AddJob("*/5 * * * *", job)
 
 	// Should not panic even when job fails
-	s.executeJob(job)
+	runScheduled(t, s, "failing-job")
 	if job.execCount.Load() != 1 {
 		t.Errorf("expected 1 execution, got %d", job.execCount.Load())
 	}
@@ -146,13 +164,14 @@ func TestExecuteJob_RespectsContext(t *testing.T) {
 			return nil
 		},
 	}
+	_ = s.AddJob("*/5 * * * *", job)
 
-	s.executeJob(job)
+	runScheduled(t, s, "ctx-job")
 
 	if receivedCtx == nil {
 		t.Fatal("expected non-nil context")
 	}
-	// executeJob creates a context with 30-minute timeout
+	// Jobs added without a timeout get DefaultJobTimeout, 30 minutes
 	deadline, ok := receivedCtx.Deadline()
 	if !ok {
 		t.Fatal("expected context with deadline")
@@ -161,3 +180,209 @@ func TestExecuteJob_RespectsContext(t *testing.T) {
 		t.Error("expected deadline within ~30 minutes")
 	}
 }
+
+func TestAddJobWithOptions_Timeout(t *testing.T) {
+	s := NewScheduler(testLogger())
+	var deadline time.Time
+	job := &fakeJob{
+		name: "short",
+		execFn: func(ctx context.Context) error {
+			deadline, _ = ctx.Deadline()
+			return nil
+		},
+	}
+	if err := s.AddJobWithOptions("*/5 * * * *", job, JobOptions{Timeout: time.Minute}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	runScheduled(t, s, "short")
+
+	if remaining := time.Until(deadline); remaining > time.Minute || remaining <= 0 {
+		t.Errorf("expected ~1 minute deadline, got %s", remaining)
+	}
+}
+
+func TestExecuteJob_SkipsOverlappingRun(t *testing.T) {
+	s := NewScheduler(testLogger())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	job := &fakeJob{
+		name: "slow",
+		execFn: func(ctx context.Context) error {
+			close(started)
+			<-release
+			return nil
+		},
+	}
+	_ = s.AddJob("*/5 * * * *", job)
+
+	done := make(chan struct{})
+	go func() {
+		runScheduled(t, s, "slow")
+		close(done)
+	}()
+	<-started
+
+	runScheduled(t, s, "slow")
+	if err := s.RunNow("slow"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("expected ErrJobRunning, got %v", err)
+	}
+	close(release)
+	<-done
+
+	if job.execCount.Load() != 1 {
+		t.Errorf("expected 1 execution, got %d", job.execCount.Load())
+	}
+	if last, _ := s.history.Last("slow"); last.Result != "success" {
+		t.Errorf("expected completed run to be recorded last, got %s", last.Result)
+	}
+	if runs := s.history.Runs("slow"); len(runs) != 2 || runs[1].Result != "skipped" {
+		t.Errorf("expected skipped run in history, got %+v", runs)
+	}
+}
+
+func TestRunAs_SharedHistoryBlocksOverlap(t *testing.T) {
+	history := NewHistory(context.Background(), nil)
+	first := NewScheduler(testLogger()).WithHistory(history)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	job := &fakeJob{
+		name: "slow",
+		execFn: func(ctx context.Context) error {
+			once.Do(func() { close(started) })
+			<-release
+			return nil
+		},
+	}
+	if err := first.RunAs(job, TriggerCatchUp); err != nil {
+		t.Fatalf("RunAs: %v", err)
+	}
+	<-started
+
+	// A scheduler replacing the first one must not start the job again
+	// while the first one still runs it.
+	second := NewScheduler(testLogger()).WithHistory(history)
+	_ = second.AddJob("*/5 * * * *", job)
+	if err := second.RunNow("slow"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("expected ErrJobRunning, got %v", err)
+	}
+	if status := second.Status(); len(status) != 1 || !status[0].Running {
+		t.Errorf("expected the job to be reported running, got %+v", status)
+	}
+
+	close(release)
+	first.Stop()
+	if err := second.RunNow("slow"); err != nil {
+		t.Errorf("expected the job to run once the first run finished, got %v", err)
+	}
+	second.Stop()
+	if job.execCount.Load() != 2 {
+		t.Errorf("expected 2 executions, got %d", job.execCount.Load())
+	}
+}
+
+func TestStop_WaitsForRunAs(t *testing.T) {
+	s := NewScheduler(testLogger())
+	started := make(chan struct{})
+	var finished atomic.Bool
+	job := &fakeJob{
+		name: "manual",
+		execFn: func(ctx context.Context) error {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			finished.Store(true)
+			return nil
+		},
+	}
+	if err := s.RunAs(job, TriggerManual); err != nil {
+		t.Fatalf("RunAs: %v", err)
+	}
+	<-started
+
+	s.Stop()
+	if !finished.Load() {
+		t.Error("expected Stop to wait for the running job")
+	}
+	if err := s.RunAs(job, TriggerManual); !errors.Is(err, ErrSchedulerStopped) {
+		t.Errorf("expected ErrSchedulerStopped after Stop, got %v", err)
+	}
+}
+
+func TestJitter_AbortsOnStop(t *testing.T) {
+	s := NewScheduler(testLogger()).WithJitter(time.Hour)
+	job := newFakeJob("jittered")
+	_ = s.AddJob("*/5 * * * *", job)
+
+	done := make(chan struct{})
+	go func() {
+		runScheduled(t, s, "jittered")
+		close(done)
+	}()
+	s.Stop()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("jittered job did not abort on stop")
+	}
+	if job.execCount.Load() != 0 {
+		t.Errorf("expected no execution, got %d", job.execCount.Load())
+	}
+}
+
+func TestScheduleTimezone(t *testing.T) {
+	utc := time.UTC
+	cases := map[string]string{
+		"0 3 * * *":                          "UTC",
+		"CRON_TZ=America/New_York 0 3 * * *": "America/New_York",
+		"TZ=Europe/Berlin 0 3 * * *":         "Europe/Berlin",
+	}
+	for expr, want := range cases {
+		if got := ScheduleTimezone(expr, utc); got != want {
+			t.Errorf("ScheduleTimezone(%q) = %s, want %s", expr, got, want)
+		}
+	}
+}
+
+func TestNextRun_UsesLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	next, err := NextRun("0 3 * * *", loc, from)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if local := next.In(loc); local.Hour() != 3 {
+		t.Errorf("expected 03:00 Tokyo, got %s", local)
+	}
+	if next.UTC().Hour() != 18 {
+		t.Errorf("expected 18:00 UTC, got %s", next.UTC())
+	}
+
+	// An explicit prefix wins over the default location.
+	next, _ = NextRun("TZ=UTC 0 3 * * *", loc, from)
+	if next.UTC().Hour() != 3 {
+		t.Errorf("expected prefix to override location, got %s", next.UTC())
+	}
+}
+
+func TestStatus_ReportsTimezone(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	s := NewScheduler(testLogger()).WithLocation(loc)
+	_ = s.AddJob("0 3 * * *", newFakeJob("paris"))
+
+	statuses := s.Status()
+	if len(statuses) != 1 || statuses[0].Timezone != "Europe/Paris" {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	if statuses[0].NextRun.In(loc).Hour() != 3 {
+		t.Errorf("expected next run at 03:00 Paris, got %s", statuses[0].NextRun.In(loc))
+	}
+}
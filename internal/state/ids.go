@@ -3,8 +3,32 @@ package state
 import (
 	"crypto/sha256"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 )
 
+// IDStrategy is how target IDs are derived.
+type IDStrategy string
+
+const (
+	// IDStrategyPath derives target IDs from the type, name and path, so a
+	// project moved to another directory gets a new ID.
+	IDStrategyPath IDStrategy = "path"
+	// IDStrategyName derives target IDs from the type and project name only,
+	// so moving a project keeps its ID and history.
+	IDStrategyName IDStrategy = "name"
+)
+
+// IDStrategyFromEnv reads BULWARK_ID_STRATEGY, defaulting to path. The CLI
+// and the server read it alike, so both derive the same IDs.
+func IDStrategyFromEnv() IDStrategy {
+	if IDStrategy(strings.ToLower(strings.TrimSpace(os.Getenv("BULWARK_ID_STRATEGY")))) == IDStrategyName {
+		return IDStrategyName
+	}
+	return IDStrategyPath
+}
+
 // GenerateTargetID creates a unique ID for a target
 func GenerateTargetID(targetType TargetType, name, path string) string {
 	data := fmt.Sprintf("%s:%s:%s", targetType, name, path)
@@ -12,9 +36,59 @@ func GenerateTargetID(targetType TargetType, name, path string) string {
 	return fmt.Sprintf("%x", hash[:16]) // Use first 16 bytes (32 hex chars)
 }
 
+// TargetIDFor creates a target ID following strategy.
+func TargetIDFor(strategy IDStrategy, targetType TargetType, name, path string) string {
+	if strategy == IDStrategyName {
+		path = ""
+	}
+	return GenerateTargetID(targetType, name, path)
+}
+
 // GenerateServiceID creates a unique ID for a service
 func GenerateServiceID(targetID, serviceName string) string {
 	data := fmt.Sprintf("%s:%s", targetID, serviceName)
 	hash := sha256.Sum256([]byte(data))
 	return fmt.Sprintf("%x", hash[:16]) // Use first 16 bytes (32 hex chars)
 }
+
+// ConfigHashSettingKey is the settings key holding the compose config hash
+// recorded for a target at its last successful update.
+func ConfigHashSettingKey(targetID string) string {
+	return "compose.config_hash." + targetID
+}
+
+// relinkServices maps the old target's service IDs, by name, to the IDs of
+// the new target's services the relink moves them to. serviceRenames maps an
+// old service name to its new one. A service without a counterpart is an
+// error when it has history, which would otherwise be lost.
+func relinkServices(oldServices, newServices, serviceRenames map[string]string, hasHistory func(serviceID string) (bool, error)) (map[string]string, error) {
+	moves := make(map[string]string)
+	var missing []string
+	for name, oldServiceID := range oldServices {
+		newName := name
+		if renamed, ok := serviceRenames[name]; ok {
+			newName = renamed
+		}
+		if newServiceID, ok := newServices[newName]; ok {
+			moves[oldServiceID] = newServiceID
+			continue
+		}
+		lost, err := hasHistory(oldServiceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check history of %s: %w", name, err)
+		}
+		if lost {
+			missing = append(missing, name)
+		}
+	}
+	for name := range serviceRenames {
+		if _, ok := oldServices[name]; !ok {
+			return nil, fmt.Errorf("the old target has no service %q", name)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return nil, fmt.Errorf("services %s have history but no counterpart in the new target; map them to a new name", strings.Join(missing, ", "))
+	}
+	return moves, nil
+}
@@ -0,0 +1,13 @@
+package state
+
+import "testing"
+
+func TestTargetIDFor(t *testing.T) {
+	moved := TargetIDFor(IDStrategyName, TargetTypeCompose, "media", "/srv/new/media/compose.yaml")
+	if moved != TargetIDFor(IDStrategyName, TargetTypeCompose, "media", "/srv/media/compose.yaml") {
+		t.Error("expected name-based IDs to ignore the path")
+	}
+	if TargetIDFor(IDStrategyPath, TargetTypeCompose, "media", "/srv/media/compose.yaml") != GenerateTargetID(TargetTypeCompose, "media", "/srv/media/compose.yaml") {
+		t.Error("expected path-based IDs to match GenerateTargetID")
+	}
+}
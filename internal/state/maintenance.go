@@ -0,0 +1,79 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceSettingKey is the settings key the maintenance window is kept
+// under, so the server and the CLI share it.
+const MaintenanceSettingKey = "maintenance"
+
+// Maintenance is a host maintenance window. While it is active, scheduled
+// applies are paused and the UI shows Message as a banner. Manual applies
+// still run.
+type Maintenance struct {
+	Message   string     `json:"message,omitempty"`
+	Until     *time.Time `json:"until,omitempty"` // Open-ended when nil
+	StartedBy string     `json:"started_by,omitempty"`
+	StartedAt time.Time  `json:"started_at"`
+}
+
+// Active reports whether the window is on at now.
+func (m *Maintenance) Active(now time.Time) bool {
+	return m != nil && (m.Until == nil || now.Before(*m.Until))
+}
+
+// LoadMaintenance returns the maintenance window, or nil when none is set or
+// it cannot be read.
+func LoadMaintenance(ctx context.Context, store SettingsStore) *Maintenance {
+	raw, err := store.GetSetting(ctx, MaintenanceSettingKey)
+	if err != nil || raw == "" {
+		return nil
+	}
+	var maintenance Maintenance
+	if err := json.Unmarshal([]byte(raw), &maintenance); err != nil {
+		return nil
+	}
+	return &maintenance
+}
+
+// SaveMaintenance stores the maintenance window; nil ends it.
+func SaveMaintenance(ctx context.Context, store SettingsStore, maintenance *Maintenance) error {
+	if maintenance == nil {
+		return store.SetSetting(ctx, MaintenanceSettingKey, "")
+	}
+	encoded, err := json.Marshal(maintenance)
+	if err != nil {
+		return err
+	}
+	return store.SetSetting(ctx, MaintenanceSettingKey, string(encoded))
+}
+
+// ParseMaintenanceUntil parses the end of a maintenance window: an RFC 3339
+// time, a clock time such as "18:00" (the next time it comes around), or a
+// length such as "2h" or "1d".
+func ParseMaintenanceUntil(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if until, err := time.Parse(time.RFC3339, value); err == nil {
+		if !until.After(now) {
+			return time.Time{}, fmt.Errorf("maintenance end %s is in the past", value)
+		}
+		return until, nil
+	}
+	if clock, err := time.ParseInLocation("15:04", value, now.Location()); err == nil {
+		until := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !until.After(now) {
+			until = until.AddDate(0, 0, 1)
+		}
+		return until, nil
+	}
+	d, err := ParseSnoozeDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid maintenance end %q: use a time such as 18:00 or 2026-10-17T18:00:00Z, or a length such as 2h", value)
+	}
+	return now.Add(d), nil
+}
@@ -0,0 +1,59 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseMaintenanceUntil(t *testing.T) {
+	now := time.Date(2026, 10, 16, 14, 30, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"18:00", time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)},
+		{"09:00", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+		{"2h", now.Add(2 * time.Hour)},
+		{"1d", now.Add(24 * time.Hour)},
+		{"2026-10-17T06:00:00Z", time.Date(2026, 10, 17, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseMaintenanceUntil(tt.value, now)
+		if err != nil {
+			t.Errorf("ParseMaintenanceUntil(%q) failed: %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseMaintenanceUntil(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+	for _, value := range []string{"2026-10-16T06:00:00Z", "tonight", "25:00"} {
+		if _, err := ParseMaintenanceUntil(value, now); err == nil {
+			t.Errorf("ParseMaintenanceUntil(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestStoresKeepMaintenance(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		if LoadMaintenance(ctx, store) != nil {
+			t.Fatal("expected no maintenance window in a new store")
+		}
+		until := time.Now().Add(time.Hour).UTC()
+		if err := SaveMaintenance(ctx, store, &Maintenance{Message: "Host maintenance", Until: &until}); err != nil {
+			t.Fatalf("SaveMaintenance failed: %v", err)
+		}
+		got := LoadMaintenance(ctx, store)
+		if got == nil || got.Message != "Host maintenance" || !got.Active(time.Now()) || got.Active(until) {
+			t.Fatalf("unexpected maintenance window %+v", got)
+		}
+		if err := SaveMaintenance(ctx, store, nil); err != nil {
+			t.Fatalf("SaveMaintenance failed: %v", err)
+		}
+		if got := LoadMaintenance(ctx, store); got.Active(time.Now()) {
+			t.Errorf("expected the window to be ended, got %+v", got)
+		}
+	})
+}
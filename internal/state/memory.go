@@ -0,0 +1,887 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/redact"
+)
+
+// MemoryStore implements Store in memory. It keeps what SQLiteStore keeps,
+// with the same defaults, ordering, redaction and cascading deletes, so it
+// can stand in for a database in tests and in runs that should leave no
+// state behind. Its contents are lost when the process exits.
+type MemoryStore struct {
+	mu sync.RWMutex
+
+	targets       map[string]Target  // by ID, without services
+	services      map[string]Service // by ID
+	history       []UpdateResult
+	nextHistoryID int64
+	settings      map[string]string
+	runs          map[string]Run
+	runEvents     []RunEvent
+	nextEventID   int64
+	users         map[string]User
+	ignores       map[string]IgnoredUpdate // by service ID
+	snoozes       map[string]Snooze        // by service ID
+	pausedGroups  map[string]GroupPause
+	scheduledRuns map[string]ScheduledRun
+	aliases       map[string]IDAlias // by old ID
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		targets:       make(map[string]Target),
+		services:      make(map[string]Service),
+		settings:      make(map[string]string),
+		runs:          make(map[string]Run),
+		users:         make(map[string]User),
+		ignores:       make(map[string]IgnoredUpdate),
+		snoozes:       make(map[string]Snooze),
+		pausedGroups:  make(map[string]GroupPause),
+		scheduledRuns: make(map[string]ScheduledRun),
+		aliases:       make(map[string]IDAlias),
+	}
+}
+
+// clone deep-copies v the way a database round trip would.
+func clone[T any](v T) T {
+	var out T
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// Initialize does nothing; a memory store needs no schema.
+func (m *MemoryStore) Initialize(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing; the contents stay readable.
+func (m *MemoryStore) Close() error {
+	return nil
+}
+
+// Ping always succeeds.
+func (m *MemoryStore) Ping(ctx context.Context) error {
+	return nil
+}
+
+// SaveTarget saves or updates a target. Its services are saved separately.
+func (m *MemoryStore) SaveTarget(ctx context.Context, target *Target) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if target.CreatedAt.IsZero() {
+		target.CreatedAt = now
+	}
+	target.UpdatedAt = now
+	// Target names are unique, as in SQLiteStore: keep the stored ID.
+	for id, existing := range m.targets {
+		if existing.Name == target.Name {
+			target.ID = id
+			break
+		}
+	}
+
+	stored := *target
+	stored.Labels = clone(target.Labels)
+	stored.Services = nil
+	if existing, ok := m.targets[target.ID]; ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	m.targets[target.ID] = stored
+	return nil
+}
+
+// GetTarget retrieves a target by ID, with its services.
+func (m *MemoryStore) GetTarget(ctx context.Context, id string) (*Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	target, ok := m.targets[id]
+	if !ok {
+		return nil, fmt.Errorf("target %w: %s", ErrNotFound, id)
+	}
+	return m.withServices(target), nil
+}
+
+// GetTargetByName retrieves a target by name, with its services.
+func (m *MemoryStore) GetTargetByName(ctx context.Context, name string) (*Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, target := range m.targets {
+		if target.Name == name {
+			return m.withServices(target), nil
+		}
+	}
+	return nil, fmt.Errorf("target %w: %s", ErrNotFound, name)
+}
+
+// ListTargets retrieves all targets ordered by name.
+func (m *MemoryStore) ListTargets(ctx context.Context) ([]Target, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var targets []Target
+	for _, target := range m.targets {
+		targets = append(targets, *m.withServices(target))
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return targets, nil
+}
+
+func (m *MemoryStore) withServices(target Target) *Target {
+	target.Labels = clone(target.Labels)
+	target.Services = m.servicesByTarget(target.ID)
+	return &target
+}
+
+// DeleteTarget deletes a target with its services and their history.
+func (m *MemoryStore) DeleteTarget(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteTarget(id)
+	return nil
+}
+
+func (m *MemoryStore) deleteTarget(id string) {
+	delete(m.targets, id)
+	for serviceID, service := range m.services {
+		if service.TargetID == id {
+			m.deleteService(serviceID)
+		}
+	}
+	m.history = filterHistory(m.history, func(r UpdateResult) bool { return r.TargetID != id })
+}
+
+// PruneStaleTargets deletes targets not updated since olderThan.
+func (m *MemoryStore) PruneStaleTargets(ctx context.Context, olderThan time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, target := range m.targets {
+		if target.UpdatedAt.Before(olderThan) {
+			m.deleteTarget(id)
+		}
+	}
+	return nil
+}
+
+// RelinkTarget merges target oldID into newID.
+func (m *MemoryStore) RelinkTarget(ctx context.Context, oldID, newID string, serviceRenames map[string]string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if oldID == newID {
+		return 0, fmt.Errorf("cannot relink target %s to itself", oldID)
+	}
+	for _, id := range []string{oldID, newID} {
+		if _, ok := m.targets[id]; !ok {
+			return 0, fmt.Errorf("target %w: %s", ErrNotFound, id)
+		}
+	}
+	serviceIDs := func(targetID string) map[string]string {
+		ids := make(map[string]string)
+		for id, service := range m.services {
+			if service.TargetID == targetID {
+				ids[service.Name] = id
+			}
+		}
+		return ids
+	}
+	moves, err := relinkServices(serviceIDs(oldID), serviceIDs(newID), serviceRenames, func(serviceID string) (bool, error) {
+		return slices.ContainsFunc(m.history, func(r UpdateResult) bool { return r.ServiceID == serviceID }), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	addAlias := func(oldID, newID, kind string) {
+		for id, alias := range m.aliases {
+			if alias.NewID == oldID {
+				alias.NewID = newID
+				m.aliases[id] = alias
+			}
+		}
+		m.aliases[oldID] = IDAlias{OldID: oldID, NewID: newID, Kind: kind, CreatedAt: now}
+	}
+	moved := 0
+	for oldServiceID, newServiceID := range moves {
+		for i := range m.history {
+			if m.history[i].ServiceID == oldServiceID {
+				m.history[i].TargetID, m.history[i].ServiceID = newID, newServiceID
+				moved++
+			}
+		}
+		// Holds of the new service win over those of the old one.
+		if ignore, ok := m.ignores[oldServiceID]; ok {
+			if _, taken := m.ignores[newServiceID]; !taken {
+				ignore.ServiceID = newServiceID
+				m.ignores[newServiceID] = ignore
+			}
+			delete(m.ignores, oldServiceID)
+		}
+		if snooze, ok := m.snoozes[oldServiceID]; ok {
+			if _, taken := m.snoozes[newServiceID]; !taken {
+				snooze.ServiceID = newServiceID
+				m.snoozes[newServiceID] = snooze
+			}
+			delete(m.snoozes, oldServiceID)
+		}
+		addAlias(oldServiceID, newServiceID, "service")
+	}
+	if hash, ok := m.settings[ConfigHashSettingKey(oldID)]; ok {
+		if _, taken := m.settings[ConfigHashSettingKey(newID)]; !taken {
+			m.settings[ConfigHashSettingKey(newID)] = hash
+		}
+		delete(m.settings, ConfigHashSettingKey(oldID))
+	}
+	addAlias(oldID, newID, "target")
+	m.deleteTarget(oldID)
+	return moved, nil
+}
+
+// ResolveAlias returns the ID id was relinked to.
+func (m *MemoryStore) ResolveAlias(ctx context.Context, id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	alias, ok := m.aliases[id]
+	if !ok {
+		return "", fmt.Errorf("alias %w: %s", ErrNotFound, id)
+	}
+	return alias.NewID, nil
+}
+
+// ListAliases retrieves all ID aliases, newest first.
+func (m *MemoryStore) ListAliases(ctx context.Context) ([]IDAlias, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var aliases []IDAlias
+	for _, alias := range m.aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		if !aliases[i].CreatedAt.Equal(aliases[j].CreatedAt) {
+			return aliases[i].CreatedAt.After(aliases[j].CreatedAt)
+		}
+		return aliases[i].OldID < aliases[j].OldID
+	})
+	return aliases, nil
+}
+
+// SaveService saves or updates a service. Names are unique per target.
+func (m *MemoryStore) SaveService(ctx context.Context, service *Service) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if service.CreatedAt.IsZero() {
+		service.CreatedAt = now
+	}
+	service.UpdatedAt = now
+	for id, existing := range m.services {
+		if existing.TargetID == service.TargetID && existing.Name == service.Name {
+			service.ID = id
+			break
+		}
+	}
+
+	// Only the columns SQLiteStore keeps are stored.
+	stored := Service{
+		ID:            service.ID,
+		TargetID:      service.TargetID,
+		Name:          service.Name,
+		Image:         service.Image,
+		CurrentDigest: service.CurrentDigest,
+		Labels:        clone(service.Labels),
+		CreatedAt:     service.CreatedAt,
+		UpdatedAt:     service.UpdatedAt,
+	}
+	if existing, ok := m.services[service.ID]; ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	m.services[service.ID] = stored
+	return nil
+}
+
+// GetService retrieves a service by ID.
+func (m *MemoryStore) GetService(ctx context.Context, id string) (*Service, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	service, ok := m.services[id]
+	if !ok {
+		return nil, fmt.Errorf("service %w: %s", ErrNotFound, id)
+	}
+	service.Labels = clone(service.Labels)
+	return &service, nil
+}
+
+// GetServicesByTarget retrieves a target's services ordered by name.
+func (m *MemoryStore) GetServicesByTarget(ctx context.Context, targetID string) ([]Service, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.servicesByTarget(targetID), nil
+}
+
+func (m *MemoryStore) servicesByTarget(targetID string) []Service {
+	var services []Service
+	for _, service := range m.services {
+		if service.TargetID == targetID {
+			service.Labels = clone(service.Labels)
+			services = append(services, service)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+// DeleteService deletes a service with its history, ignored update and
+// snooze.
+func (m *MemoryStore) DeleteService(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleteService(id)
+	return nil
+}
+
+func (m *MemoryStore) deleteService(id string) {
+	delete(m.services, id)
+	delete(m.ignores, id)
+	delete(m.snoozes, id)
+	m.history = filterHistory(m.history, func(r UpdateResult) bool { return r.ServiceID != id })
+}
+
+// SaveUpdateResult appends an update result to history and sets its ID.
+func (m *MemoryStore) SaveUpdateResult(ctx context.Context, result *UpdateResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextHistoryID++
+	result.ID = m.nextHistoryID
+
+	stored := *result
+	stored.Attempts = max(result.Attempts, 1)
+	stored.ErrorMessage = redact.String(result.ErrorMessage)
+	if stored.ErrorMessage == "" {
+		stored.ErrorCode = ""
+	} else if stored.ErrorCode == "" {
+		stored.ErrorCode = stored.ResultCode
+	}
+	// Probe output and config changes are redacted as SQLiteStore does.
+	stored.ProbeResults = redactProbeResults(result.ProbeResults)
+	stored.ConfigChanges = nil
+	if len(result.ConfigChanges) > 0 {
+		stored.ConfigChanges = redactConfigChanges(result.ConfigChanges)
+	}
+	stored.Resources = cloneResources(result.Resources)
+	stored.SBOM = nil
+	if result.SBOM != nil && result.SBOM.Path != "" {
+		sbom := *result.SBOM
+		stored.SBOM = &sbom
+	}
+	m.history = append(m.history, stored)
+	return nil
+}
+
+// GetUpdateResult retrieves a single update history entry by ID.
+func (m *MemoryStore) GetUpdateResult(ctx context.Context, id int64) (*UpdateResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, result := range m.history {
+		if result.ID == id {
+			result = cloneResult(result)
+			return &result, nil
+		}
+	}
+	return nil, fmt.Errorf("update %d: %w", id, ErrNotFound)
+}
+
+// GetUpdateHistory retrieves recent update history, newest first.
+func (m *MemoryStore) GetUpdateHistory(ctx context.Context, limit int) ([]UpdateResult, error) {
+	return m.ListUpdateHistory(ctx, HistoryQuery{Limit: limit})
+}
+
+// GetUpdateHistoryByTarget retrieves a target's update history, newest first.
+func (m *MemoryStore) GetUpdateHistoryByTarget(ctx context.Context, targetID string, limit int) ([]UpdateResult, error) {
+	return m.ListUpdateHistory(ctx, HistoryQuery{TargetID: targetID, Limit: limit})
+}
+
+// GetUpdateHistoryByService retrieves a service's update history, newest first.
+func (m *MemoryStore) GetUpdateHistoryByService(ctx context.Context, serviceID string, limit int) ([]UpdateResult, error) {
+	return m.ListUpdateHistory(ctx, HistoryQuery{ServiceID: serviceID, Limit: limit})
+}
+
+// ListUpdateHistory retrieves paginated update history with optional
+// filters, newest first.
+func (m *MemoryStore) ListUpdateHistory(ctx context.Context, query HistoryQuery) ([]UpdateResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var results []UpdateResult
+	for _, result := range m.history {
+		if matchesHistoryQuery(result, query) {
+			results = append(results, result)
+		}
+	}
+	sortHistory(results)
+
+	start := min(max(query.Offset, 0), len(results))
+	end := len(results)
+	// A negative limit means no limit, as in SQLite.
+	if query.Limit >= 0 {
+		end = min(start+query.Limit, len(results))
+	}
+	var page []UpdateResult
+	for _, result := range results[start:end] {
+		page = append(page, cloneResult(result))
+	}
+	return page, nil
+}
+
+func matchesHistoryQuery(result UpdateResult, query HistoryQuery) bool {
+	if query.ServiceID != "" && result.ServiceID != query.ServiceID {
+		return false
+	}
+	if query.TargetID != "" && result.TargetID != query.TargetID {
+		return false
+	}
+	switch query.Result {
+	case "success":
+		if !result.Success {
+			return false
+		}
+	case "failed":
+		if result.Success || result.ResultCode.IsSkip() {
+			return false
+		}
+	case "skipped":
+		if !result.ResultCode.IsSkip() {
+			return false
+		}
+	case "rolled_back":
+		if !result.RollbackPerformed {
+			return false
+		}
+	}
+	if query.ResultCode != "" && result.ResultCode != query.ResultCode {
+		return false
+	}
+	if query.ReasonCode != "" && result.ReasonCode != query.ReasonCode {
+		return false
+	}
+	if query.Trigger != "" && result.Trigger != query.Trigger {
+		return false
+	}
+	if query.Actor != "" && result.Actor != query.Actor {
+		return false
+	}
+	switch query.Kind {
+	case "":
+	case "digest":
+		if result.Kind != UpdateKindDigest {
+			return false
+		}
+	default:
+		if string(result.Kind) != query.Kind {
+			return false
+		}
+	}
+	if !query.Since.IsZero() && result.CompletedAt.Before(query.Since) {
+		return false
+	}
+	return true
+}
+
+// sortHistory orders results newest first; results completed at the same
+// time keep the order they were saved in, latest first.
+func sortHistory(results []UpdateResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if !results[i].CompletedAt.Equal(results[j].CompletedAt) {
+			return results[i].CompletedAt.After(results[j].CompletedAt)
+		}
+		return results[i].ID > results[j].ID
+	})
+}
+
+func cloneResult(result UpdateResult) UpdateResult {
+	result.ProbeResults = clone(result.ProbeResults)
+	if result.ConfigChanges != nil {
+		result.ConfigChanges = clone(result.ConfigChanges)
+	}
+	result.Resources = cloneResources(result.Resources)
+	if result.SBOM != nil {
+		sbom := *result.SBOM
+		result.SBOM = &sbom
+	}
+	return result
+}
+
+func cloneResources(snapshot *ResourceSnapshot) *ResourceSnapshot {
+	if snapshot == nil {
+		return nil
+	}
+	copied := *snapshot
+	if snapshot.Before != nil {
+		before := *snapshot.Before
+		copied.Before = &before
+	}
+	if snapshot.After != nil {
+		after := *snapshot.After
+		copied.After = &after
+	}
+	return &copied
+}
+
+func filterHistory(results []UpdateResult, keep func(UpdateResult) bool) []UpdateResult {
+	kept := results[:0]
+	for _, result := range results {
+		if keep(result) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// GetLastSuccessfulUpdate retrieves the last successful update for a service.
+func (m *MemoryStore) GetLastSuccessfulUpdate(ctx context.Context, serviceID string) (*UpdateResult, error) {
+	results, err := m.ListUpdateHistory(ctx, HistoryQuery{ServiceID: serviceID, Result: "success", Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no successful updates found for service: %s", serviceID)
+	}
+	return &results[0], nil
+}
+
+// PruneHistory deletes update history completed before olderThan.
+func (m *MemoryStore) PruneHistory(ctx context.Context, olderThan time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = filterHistory(m.history, func(r UpdateResult) bool { return !r.CompletedAt.Before(olderThan) })
+	return nil
+}
+
+// GetSetting retrieves a setting value.
+func (m *MemoryStore) GetSetting(ctx context.Context, key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.settings[key]
+	if !ok {
+		return "", fmt.Errorf("setting not found: %s", key)
+	}
+	return value, nil
+}
+
+// SetSetting stores a setting value.
+func (m *MemoryStore) SetSetting(ctx context.Context, key, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.settings[key] = value
+	return nil
+}
+
+// SaveRun saves or updates a run. Updates change only its status,
+// completion time, summary, note and labels.
+func (m *MemoryStore) SaveRun(ctx context.Context, run *Run) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *run
+	if existing, ok := m.runs[run.ID]; ok {
+		existing.Status = run.Status
+		existing.CompletedAt = run.CompletedAt
+		existing.SummaryJSON = run.SummaryJSON
+		existing.Note = run.Note
+		existing.Labels = run.Labels
+		stored = existing
+	}
+	stored.Labels = maps.Clone(stored.Labels)
+	if len(stored.Labels) == 0 {
+		stored.Labels = nil
+	}
+	if stored.CompletedAt != nil {
+		completed := *stored.CompletedAt
+		stored.CompletedAt = &completed
+	}
+	m.runs[run.ID] = stored
+	return nil
+}
+
+// GetRun retrieves a run by ID.
+func (m *MemoryStore) GetRun(ctx context.Context, id string) (*Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	run, ok := m.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("run not found: %s", id)
+	}
+	run.Labels = maps.Clone(run.Labels)
+	return &run, nil
+}
+
+// ListRecentRuns retrieves the most recently created runs.
+func (m *MemoryStore) ListRecentRuns(ctx context.Context, limit int) ([]Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var runs []Run
+	for _, run := range m.runs {
+		run.Labels = maps.Clone(run.Labels)
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].CreatedAt.After(runs[j].CreatedAt) })
+	if limit >= 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+// SaveRunEvent saves a run event and sets its ID.
+func (m *MemoryStore) SaveRunEvent(ctx context.Context, event *RunEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextEventID++
+	event.ID = m.nextEventID
+	m.runEvents = append(m.runEvents, *event)
+	return nil
+}
+
+// GetRunEvents retrieves a run's events in the order they were saved.
+func (m *MemoryStore) GetRunEvents(ctx context.Context, runID string) ([]RunEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var events []RunEvent
+	for _, event := range m.runEvents {
+		if event.RunID == runID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// SaveScheduledRun stores a scheduled run, replacing the one with the same ID.
+func (m *MemoryStore) SaveScheduledRun(ctx context.Context, run *ScheduledRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if run.CreatedAt.IsZero() {
+		run.CreatedAt = time.Now()
+	}
+	stored := *run
+	stored.ServiceIDs = append([]string{}, run.ServiceIDs...)
+	stored.Labels = maps.Clone(run.Labels)
+	if len(stored.Labels) == 0 {
+		stored.Labels = nil
+	}
+	if existing, ok := m.scheduledRuns[run.ID]; ok {
+		stored.CreatedBy = existing.CreatedBy
+		stored.CreatedAt = existing.CreatedAt
+	}
+	m.scheduledRuns[run.ID] = stored
+	return nil
+}
+
+// ListScheduledRuns retrieves all scheduled runs, earliest first.
+func (m *MemoryStore) ListScheduledRuns(ctx context.Context) ([]ScheduledRun, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var runs []ScheduledRun
+	for _, run := range m.scheduledRuns {
+		run.ServiceIDs = append([]string{}, run.ServiceIDs...)
+		run.Labels = maps.Clone(run.Labels)
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		if !runs[i].RunAt.Equal(runs[j].RunAt) {
+			return runs[i].RunAt.Before(runs[j].RunAt)
+		}
+		return runs[i].ID < runs[j].ID
+	})
+	return runs, nil
+}
+
+// DeleteScheduledRun removes a scheduled run.
+func (m *MemoryStore) DeleteScheduledRun(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.scheduledRuns[id]; !ok {
+		return fmt.Errorf("scheduled run %w: %s", ErrNotFound, id)
+	}
+	delete(m.scheduledRuns, id)
+	return nil
+}
+
+// SaveUser creates or updates a user.
+func (m *MemoryStore) SaveUser(ctx context.Context, user *User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.PasswordChangedAt.IsZero() {
+		user.PasswordChangedAt = now
+	}
+	user.UpdatedAt = now
+	stored := *user
+	if existing, ok := m.users[user.Username]; ok {
+		stored.CreatedAt = existing.CreatedAt
+	}
+	m.users[user.Username] = stored
+	return nil
+}
+
+// GetUser retrieves a user by username.
+func (m *MemoryStore) GetUser(ctx context.Context, username string) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	user, ok := m.users[username]
+	if !ok {
+		return nil, fmt.Errorf("user %w: %s", ErrNotFound, username)
+	}
+	return &user, nil
+}
+
+// ListUsers retrieves all users ordered by username.
+func (m *MemoryStore) ListUsers(ctx context.Context) ([]User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var users []User
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+// DeleteUser deletes a user.
+func (m *MemoryStore) DeleteUser(ctx context.Context, username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.users[username]; !ok {
+		return fmt.Errorf("user %w: %s", ErrNotFound, username)
+	}
+	delete(m.users, username)
+	return nil
+}
+
+// SaveIgnoredUpdate stores an ignored update, replacing the service's
+// previous one.
+func (m *MemoryStore) SaveIgnoredUpdate(ctx context.Context, ignore *IgnoredUpdate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ignore.CreatedAt.IsZero() {
+		ignore.CreatedAt = time.Now()
+	}
+	m.ignores[ignore.ServiceID] = *ignore
+	return nil
+}
+
+// ListIgnoredUpdates retrieves all ignored updates ordered by service ID.
+func (m *MemoryStore) ListIgnoredUpdates(ctx context.Context) ([]IgnoredUpdate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var ignores []IgnoredUpdate
+	for _, ignore := range m.ignores {
+		ignores = append(ignores, ignore)
+	}
+	sort.Slice(ignores, func(i, j int) bool { return ignores[i].ServiceID < ignores[j].ServiceID })
+	return ignores, nil
+}
+
+// DeleteIgnoredUpdate clears a service's ignored update.
+func (m *MemoryStore) DeleteIgnoredUpdate(ctx context.Context, serviceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.ignores[serviceID]; !ok {
+		return fmt.Errorf("ignored update %w: %s", ErrNotFound, serviceID)
+	}
+	delete(m.ignores, serviceID)
+	return nil
+}
+
+// SaveSnooze stores a snooze, replacing the service's previous one.
+func (m *MemoryStore) SaveSnooze(ctx context.Context, snooze *Snooze) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if snooze.CreatedAt.IsZero() {
+		snooze.CreatedAt = time.Now()
+	}
+	m.snoozes[snooze.ServiceID] = *snooze
+	return nil
+}
+
+// ListSnoozes retrieves the snoozes that have not expired at now.
+func (m *MemoryStore) ListSnoozes(ctx context.Context, now time.Time) ([]Snooze, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var snoozes []Snooze
+	for _, snooze := range m.snoozes {
+		if snooze.Until.After(now) {
+			snoozes = append(snoozes, snooze)
+		}
+	}
+	sort.Slice(snoozes, func(i, j int) bool { return snoozes[i].ServiceID < snoozes[j].ServiceID })
+	return snoozes, nil
+}
+
+// DeleteSnooze clears a service's snooze.
+func (m *MemoryStore) DeleteSnooze(ctx context.Context, serviceID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.snoozes[serviceID]; !ok {
+		return fmt.Errorf("snooze %w: %s", ErrNotFound, serviceID)
+	}
+	delete(m.snoozes, serviceID)
+	return nil
+}
+
+// PauseGroup puts a target group's updates on hold. Pausing a paused group
+// keeps its original pause time.
+func (m *MemoryStore) PauseGroup(ctx context.Context, pause *GroupPause) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if pause.CreatedAt.IsZero() {
+		pause.CreatedAt = time.Now()
+	}
+	if _, ok := m.pausedGroups[pause.Group]; !ok {
+		m.pausedGroups[pause.Group] = *pause
+	}
+	return nil
+}
+
+// ListPausedGroups retrieves all paused target groups ordered by name.
+func (m *MemoryStore) ListPausedGroups(ctx context.Context) ([]GroupPause, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var pauses []GroupPause
+	for _, pause := range m.pausedGroups {
+		pauses = append(pauses, pause)
+	}
+	sort.Slice(pauses, func(i, j int) bool { return pauses[i].Group < pauses[j].Group })
+	return pauses, nil
+}
+
+// ResumeGroup lifts a target group's pause.
+func (m *MemoryStore) ResumeGroup(ctx context.Context, group string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pausedGroups[group]; !ok {
+		return fmt.Errorf("paused group %w: %s", ErrNotFound, group)
+	}
+	delete(m.pausedGroups, group)
+	return nil
+}
+
+var _ Store = (*MemoryStore)(nil)
@@ -0,0 +1,304 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+)
+
+// forEachStore runs fn against a fresh MemoryStore and SQLiteStore, so the
+// memory store is held to the database's behavior.
+func forEachStore(t *testing.T, fn func(t *testing.T, store Store)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewMemoryStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+		if err != nil {
+			t.Fatalf("NewSQLiteStore failed: %v", err)
+		}
+		defer func() { _ = store.Close() }()
+		if err := store.Initialize(context.Background()); err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		fn(t, store)
+	})
+}
+
+func saveTestService(t *testing.T, store Store, targetName, serviceName string) (*Target, *Service) {
+	t.Helper()
+	ctx := context.Background()
+	target := &Target{ID: "t-" + targetName, Type: TargetTypeCompose, Name: targetName, Path: "/srv/" + targetName, Labels: DefaultLabels()}
+	if err := store.SaveTarget(ctx, target); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	service := &Service{ID: target.ID + "-" + serviceName, TargetID: target.ID, Name: serviceName, Image: "nginx:latest", Labels: DefaultLabels()}
+	if err := store.SaveService(ctx, service); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+	return target, service
+}
+
+func TestStoresKeepTargetsAndServices(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		target, service := saveTestService(t, store, "app", "web")
+
+		moved := &Target{ID: "t-new", Type: TargetTypeCompose, Name: "app", Path: "/srv/moved", Labels: DefaultLabels()}
+		if err := store.SaveTarget(ctx, moved); err != nil {
+			t.Fatalf("SaveTarget failed: %v", err)
+		}
+		if moved.ID != target.ID {
+			t.Fatalf("expected the stored ID %s to be reused, got %s", target.ID, moved.ID)
+		}
+		got, err := store.GetTargetByName(ctx, "app")
+		if err != nil {
+			t.Fatalf("GetTargetByName failed: %v", err)
+		}
+		if got.Path != "/srv/moved" || len(got.Services) != 1 || got.Services[0].ID != service.ID {
+			t.Fatalf("unexpected target %+v", got)
+		}
+
+		if err := store.SaveUpdateResult(ctx, &UpdateResult{TargetID: target.ID, ServiceID: service.ID, ServiceName: "web", Success: true, CompletedAt: time.Now()}); err != nil {
+			t.Fatalf("SaveUpdateResult failed: %v", err)
+		}
+		if err := store.DeleteTarget(ctx, target.ID); err != nil {
+			t.Fatalf("DeleteTarget failed: %v", err)
+		}
+		if _, err := store.GetService(ctx, service.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected the service to be deleted with its target, got %v", err)
+		}
+		if history, _ := store.GetUpdateHistory(ctx, 10); len(history) != 0 {
+			t.Errorf("expected the history to be deleted with its target, got %d entries", len(history))
+		}
+	})
+}
+
+func TestStoresFilterAndPageHistory(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		target, service := saveTestService(t, store, "app", "web")
+		base := time.Now().Add(-time.Hour)
+		results := []UpdateResult{
+			{Success: true, ResultCode: ResultSuccess, Trigger: TriggerManualUI, Actor: "alice"},
+			{ResultCode: ResultProbeFailed, ErrorMessage: "probe failed: DB_PASSWORD=letmein", RollbackPerformed: true, Trigger: TriggerAutoApprove, Actor: "auto-update"},
+			{ResultCode: ResultSkippedLocked, ErrorMessage: "locked", ReasonCode: ReasonSafePolicy, Trigger: TriggerManualUI, Actor: "bob"},
+		}
+		for i := range results {
+			results[i].TargetID = target.ID
+			results[i].ServiceID = service.ID
+			results[i].ServiceName = "web"
+			results[i].StartedAt = base.Add(time.Duration(i) * time.Minute)
+			results[i].CompletedAt = results[i].StartedAt
+			if err := store.SaveUpdateResult(ctx, &results[i]); err != nil {
+				t.Fatalf("SaveUpdateResult failed: %v", err)
+			}
+			if results[i].ID == 0 {
+				t.Fatal("expected the saved result to get an ID")
+			}
+		}
+
+		all, err := store.ListUpdateHistory(ctx, HistoryQuery{Limit: 10})
+		if err != nil {
+			t.Fatalf("ListUpdateHistory failed: %v", err)
+		}
+		if len(all) != 3 || all[0].ResultCode != ResultSkippedLocked || all[2].ResultCode != ResultSuccess {
+			t.Fatalf("expected newest first, got %+v", all)
+		}
+		if all[2].Trigger != TriggerManualUI || all[2].Actor != "alice" {
+			t.Errorf("expected the trigger and actor to be kept, got %s:%s", all[2].Trigger, all[2].Actor)
+		}
+		if all[0].Attempts != 1 {
+			t.Errorf("expected attempts to default to 1, got %d", all[0].Attempts)
+		}
+		if all[1].ErrorCode != ResultProbeFailed || strings.Contains(all[1].ErrorMessage, "letmein") {
+			t.Errorf("expected a redacted, classified error, got %q (%s)", all[1].ErrorMessage, all[1].ErrorCode)
+		}
+
+		for _, tt := range []struct {
+			query HistoryQuery
+			want  ResultCode
+		}{
+			{HistoryQuery{Result: "failed", Limit: 10}, ResultProbeFailed},
+			{HistoryQuery{Result: "skipped", Limit: 10}, ResultSkippedLocked},
+			{HistoryQuery{Result: "rolled_back", Limit: 10}, ResultProbeFailed},
+			{HistoryQuery{ReasonCode: ReasonSafePolicy, Limit: 10}, ResultSkippedLocked},
+			{HistoryQuery{Trigger: TriggerAutoApprove, Limit: 10}, ResultProbeFailed},
+			{HistoryQuery{Trigger: TriggerManualUI, Actor: "alice", Limit: 10}, ResultSuccess},
+			{HistoryQuery{Limit: 1, Offset: 2}, ResultSuccess},
+		} {
+			got, err := store.ListUpdateHistory(ctx, tt.query)
+			if err != nil {
+				t.Fatalf("ListUpdateHistory(%+v) failed: %v", tt.query, err)
+			}
+			if len(got) != 1 || got[0].ResultCode != tt.want {
+				t.Errorf("ListUpdateHistory(%+v) = %+v, want one %s", tt.query, got, tt.want)
+			}
+		}
+
+		last, err := store.GetLastSuccessfulUpdate(ctx, service.ID)
+		if err != nil || last.ID != results[0].ID {
+			t.Errorf("GetLastSuccessfulUpdate = %+v, %v", last, err)
+		}
+		if _, err := store.GetUpdateResult(ctx, 999); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+		if err := store.PruneHistory(ctx, base.Add(90*time.Second)); err != nil {
+			t.Fatalf("PruneHistory failed: %v", err)
+		}
+		if remaining, _ := store.GetUpdateHistory(ctx, 10); len(remaining) != 1 {
+			t.Errorf("expected one entry after pruning, got %d", len(remaining))
+		}
+	})
+}
+
+func TestStoresKeepRuns(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		now := time.Now()
+		run := &Run{ID: "run-1", Mode: "safe", Status: "running", CreatedAt: now, StartedAt: now}
+		if err := store.SaveRun(ctx, run); err != nil {
+			t.Fatalf("SaveRun failed: %v", err)
+		}
+		done := now.Add(time.Minute)
+		if err := store.SaveRun(ctx, &Run{ID: "run-1", Mode: "all", Status: "completed", CompletedAt: &done, SummaryJSON: "{}"}); err != nil {
+			t.Fatalf("SaveRun failed: %v", err)
+		}
+		got, err := store.GetRun(ctx, "run-1")
+		if err != nil {
+			t.Fatalf("GetRun failed: %v", err)
+		}
+		if got.Mode != "safe" || got.Status != "completed" || got.CompletedAt == nil {
+			t.Errorf("expected an update to change only status and completion, got %+v", got)
+		}
+		got.Note, got.Labels = "before game night", map[string]string{"ticket": "42"}
+		if err := store.SaveRun(ctx, got); err != nil {
+			t.Fatalf("SaveRun failed: %v", err)
+		}
+		if runs, err := store.ListRecentRuns(ctx, 1); err != nil || len(runs) != 1 || runs[0].Note != "before game night" || runs[0].Labels["ticket"] != "42" {
+			t.Errorf("expected the note and labels to be saved, got %+v, %v", runs, err)
+		}
+		for _, message := range []string{"first", "second"} {
+			if err := store.SaveRunEvent(ctx, &RunEvent{RunID: "run-1", Timestamp: now, Level: "info", Message: message}); err != nil {
+				t.Fatalf("SaveRunEvent failed: %v", err)
+			}
+		}
+		events, err := store.GetRunEvents(ctx, "run-1")
+		if err != nil || len(events) != 2 || events[0].Message != "first" {
+			t.Errorf("GetRunEvents = %+v, %v", events, err)
+		}
+
+		for _, id := range []string{"b", "a"} {
+			if err := store.SaveScheduledRun(ctx, &ScheduledRun{ID: id, RunAt: now.Add(time.Hour), Mode: "safe", Status: ScheduledRunPending}); err != nil {
+				t.Fatalf("SaveScheduledRun failed: %v", err)
+			}
+		}
+		scheduled, err := store.ListScheduledRuns(ctx)
+		if err != nil || len(scheduled) != 2 || scheduled[0].ID != "a" {
+			t.Errorf("ListScheduledRuns = %+v, %v", scheduled, err)
+		}
+		if err := store.DeleteScheduledRun(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestStoresKeepHolds(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		_, service := saveTestService(t, store, "app", "web")
+		if err := store.SaveSnooze(ctx, &Snooze{ServiceID: service.ID, Until: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("SaveSnooze failed: %v", err)
+		}
+		if snoozes, _ := store.ListSnoozes(ctx, time.Now().Add(2*time.Hour)); len(snoozes) != 0 {
+			t.Errorf("expected expired snoozes to be left out, got %+v", snoozes)
+		}
+		first := time.Now().Add(-time.Hour)
+		if err := store.PauseGroup(ctx, &GroupPause{Group: "media", CreatedAt: first}); err != nil {
+			t.Fatalf("PauseGroup failed: %v", err)
+		}
+		if err := store.PauseGroup(ctx, &GroupPause{Group: "media"}); err != nil {
+			t.Fatalf("PauseGroup failed: %v", err)
+		}
+		pauses, err := store.ListPausedGroups(ctx)
+		if err != nil || len(pauses) != 1 || !pauses[0].CreatedAt.Equal(first) {
+			t.Errorf("expected the first pause time to be kept, got %+v, %v", pauses, err)
+		}
+		if err := store.DeleteService(ctx, service.ID); err != nil {
+			t.Fatalf("DeleteService failed: %v", err)
+		}
+		if err := store.DeleteSnooze(ctx, service.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected the snooze to be deleted with its service, got %v", err)
+		}
+		if err := store.DeleteUser(ctx, "nobody"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestStoresRelinkTargets(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		oldTarget, oldWeb := saveTestService(t, store, "media", "web")
+		oldDB := &Service{ID: oldTarget.ID + "-db", TargetID: oldTarget.ID, Name: "db", Image: "postgres:16", Labels: DefaultLabels()}
+		if err := store.SaveService(ctx, oldDB); err != nil {
+			t.Fatalf("SaveService failed: %v", err)
+		}
+		newTarget, newWeb := saveTestService(t, store, "media-stack", "web")
+		newDB := &Service{ID: newTarget.ID + "-postgres", TargetID: newTarget.ID, Name: "postgres", Image: "postgres:16", Labels: DefaultLabels()}
+		if err := store.SaveService(ctx, newDB); err != nil {
+			t.Fatalf("SaveService failed: %v", err)
+		}
+		for _, service := range []*Service{oldWeb, oldDB} {
+			now := time.Now()
+			result := &UpdateResult{TargetID: oldTarget.ID, ServiceID: service.ID, ServiceName: service.Name, Success: true, StartedAt: now, CompletedAt: now}
+			if err := store.SaveUpdateResult(ctx, result); err != nil {
+				t.Fatalf("SaveUpdateResult failed: %v", err)
+			}
+		}
+		if err := store.SaveSnooze(ctx, &Snooze{ServiceID: oldWeb.ID, Until: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("SaveSnooze failed: %v", err)
+		}
+
+		if _, err := store.RelinkTarget(ctx, oldTarget.ID, newTarget.ID, nil); err == nil || !strings.Contains(err.Error(), "db") {
+			t.Fatalf("expected the unmatched db service to be refused, got %v", err)
+		}
+		moved, err := store.RelinkTarget(ctx, oldTarget.ID, newTarget.ID, map[string]string{"db": "postgres"})
+		if err != nil {
+			t.Fatalf("RelinkTarget failed: %v", err)
+		}
+		if moved != 2 {
+			t.Errorf("moved %d history entries, want 2", moved)
+		}
+
+		history, err := store.GetUpdateHistoryByService(ctx, newDB.ID, 10)
+		if err != nil || len(history) != 1 || history[0].TargetID != newTarget.ID {
+			t.Errorf("expected the db history under postgres, got %+v (%v)", history, err)
+		}
+		snoozes, _ := store.ListSnoozes(ctx, time.Now())
+		if len(snoozes) != 1 || snoozes[0].ServiceID != newWeb.ID {
+			t.Errorf("expected the snooze to move to the new web service, got %+v", snoozes)
+		}
+		if _, err := store.GetTarget(ctx, oldTarget.ID); err == nil {
+			t.Error("expected the old target to be deleted")
+		}
+		if id, err := store.ResolveAlias(ctx, oldTarget.ID); err != nil || id != newTarget.ID {
+			t.Errorf("ResolveAlias(old target) = %q, %v; want %q", id, err, newTarget.ID)
+		}
+		if id, err := store.ResolveAlias(ctx, oldDB.ID); err != nil || id != newDB.ID {
+			t.Errorf("ResolveAlias(old db) = %q, %v; want %q", id, err, newDB.ID)
+		}
+		if _, err := store.ResolveAlias(ctx, newTarget.ID); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected ErrNotFound for an ID without alias, got %v", err)
+		}
+		aliases, err := store.ListAliases(ctx)
+		if err != nil || len(aliases) != 3 {
+			t.Errorf("expected 3 aliases, got %+v (%v)", aliases, err)
+		}
+	})
+}
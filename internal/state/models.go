@@ -2,6 +2,9 @@ package state
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -17,7 +20,7 @@ const (
 type Target struct {
 	ID        string     `json:"id"`
 	Type      TargetType `json:"type"`
-	Name      string     `json:"name"`
+	Name      string     `json:"name"` // For compose: the project name its containers run under
 	Path      string     `json:"path"` // For compose: path to docker-compose.yml
 	Services  []Service  `json:"services"`
 	Labels    Labels     `json:"labels"`
@@ -25,17 +28,50 @@ type Target struct {
 	UpdatedAt time.Time  `json:"updated_at"`
 }
 
+// Group returns the target group the target belongs to: its own
+// bulwark.group label, or else the first one set on its services.
+func (t *Target) Group() string {
+	if t.Labels.Group != "" {
+		return t.Labels.Group
+	}
+	for _, service := range t.Services {
+		if service.Labels.Group != "" {
+			return service.Labels.Group
+		}
+	}
+	return ""
+}
+
 // Service represents a single service/container
 type Service struct {
-	ID            string       `json:"id"`
-	TargetID      string       `json:"target_id"`
-	Name          string       `json:"name"`
-	Image         string       `json:"image"`
-	CurrentDigest string       `json:"current_digest"`
-	Labels        Labels       `json:"labels"`
-	HealthCheck   *HealthCheck `json:"health_check,omitempty"`
-	CreatedAt     time.Time    `json:"created_at"`
-	UpdatedAt     time.Time    `json:"updated_at"`
+	ID              string        `json:"id"`
+	TargetID        string        `json:"target_id"`
+	Name            string        `json:"name"`
+	Image           string        `json:"image"`
+	CurrentDigest   string        `json:"current_digest"`
+	Labels          Labels        `json:"labels"`
+	HealthCheck     *HealthCheck  `json:"health_check,omitempty"`
+	BaseImage       *BaseImage    `json:"base_image,omitempty"`        // Set for locally built images that record their base
+	Build           bool          `json:"build,omitempty"`             // Updated by rebuilding from its compose build: section
+	DependsOn       []string      `json:"depends_on,omitempty"`        // Compose depends_on services in the same project
+	Platform        string        `json:"platform,omitempty"`          // Compose platform:, e.g. linux/arm64
+	PullPolicy      string        `json:"pull_policy,omitempty"`       // Compose pull_policy:, lowercased
+	StopGracePeriod time.Duration `json:"stop_grace_period,omitempty"` // Compose stop_grace_period:
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// Compose pull_policy values Bulwark acts on. Other values pull as usual.
+const (
+	PullPolicyNever = "never"
+	PullPolicyBuild = "build"
+)
+
+// BaseImage is the base a locally built image was built from, as recorded in
+// its org.opencontainers.image.base.* labels.
+type BaseImage struct {
+	Name   string `json:"name"`
+	Digest string `json:"digest"`
 }
 
 // HealthCheck represents Docker HEALTHCHECK configuration
@@ -66,11 +102,100 @@ const (
 
 // Labels holds parsed Bulwark configuration from container labels
 type Labels struct {
-	Enabled    bool        `json:"enabled"`
-	Policy     Policy      `json:"policy"`
-	Tier       Tier        `json:"tier"`
-	Probe      ProbeConfig `json:"probe"`
-	Definition string      `json:"definition"` // For loose containers: "compose:/abs/path/compose.yml#service=service-name"
+	Enabled        bool        `json:"enabled"`
+	Policy         Policy      `json:"policy"`
+	Tier           Tier        `json:"tier"`
+	Probe          ProbeConfig `json:"probe"`
+	Retry          RetryConfig `json:"retry"`
+	Drain          DrainConfig `json:"drain,omitempty"`
+	Strategy       Strategy    `json:"strategy,omitempty"`
+	Lock           LockConfig  `json:"lock,omitempty"`
+	Build          bool        `json:"build,omitempty"`            // Rebuild compose build: services with `docker compose build --pull`
+	Parallel       bool        `json:"parallel,omitempty"`         // Update alongside other parallel services of the same compose project
+	ComposeUpFlags []string    `json:"compose_up_flags,omitempty"` // Extra flags for the compose up that recreates the service
+
+	// ComposeNoForceRecreate leaves --force-recreate out of that compose
+	// up, and ComposeWithDeps leaves out --no-deps.
+	ComposeNoForceRecreate bool `json:"compose_no_force_recreate,omitempty"`
+	ComposeWithDeps        bool `json:"compose_with_deps,omitempty"`
+
+	Definition string `json:"definition"` // For loose containers: "compose:/abs/path/compose.yml#service=service-name"
+
+	// DependsOn lists targets (by name) whose updates this service reacts to
+	// with DependentAction once they complete in the same run.
+	DependsOn       []string `json:"depends_on_target,omitempty"`
+	DependentAction string   `json:"dependent_action,omitempty"`
+
+	// Group names the target group the service's target belongs to, so
+	// fleets of similar stacks can be planned, applied and paused together.
+	Group string `json:"group,omitempty"`
+
+	// CheckTTL is how long an incremental plan reuses the remote digest of
+	// the service's image; zero uses the server default.
+	CheckTTL time.Duration `json:"check_ttl,omitempty"`
+
+	// AllowMutableTag lets the service auto-update on a tag such as latest
+	// while BULWARK_BLOCK_MUTABLE_TAGS is set.
+	AllowMutableTag bool `json:"allow_mutable_tag,omitempty"`
+
+	// Schema is the label schema version the service declares with
+	// bulwark.schema; zero when it declares none.
+	Schema int `json:"schema,omitempty"`
+	// Issues are the bulwark.* labels that were not understood and are
+	// ignored or fell back to a default.
+	Issues []LabelIssue `json:"issues,omitempty"`
+}
+
+// LabelIssue is a bulwark.* label Bulwark could not make sense of, e.g. a
+// misspelled key or an unsupported value.
+type LabelIssue struct {
+	Label   string `json:"label"`
+	Message string `json:"message"`
+	// Suggestion is the known label a misspelled key most likely meant.
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Actions taken on a dependent service after one of its dependencies updates.
+const (
+	DependentActionProbe   = "probe"   // Re-run the service's probes
+	DependentActionRestart = "restart" // Restart the container, then run its probes
+)
+
+// RetryConfig controls how often a failed update is retried within a run.
+type RetryConfig struct {
+	Max     int           `json:"max,omitempty"`     // Extra attempts after the first failure
+	Backoff time.Duration `json:"backoff,omitempty"` // Delay before the first retry; doubles per attempt
+}
+
+// Strategy is how a service's containers are replaced during an update.
+type Strategy string
+
+const (
+	StrategyRecreate  Strategy = "recreate"   // Stop the old container and start the new one
+	StrategyBlueGreen Strategy = "blue-green" // Start the new container alongside the old, probe it, then remove the old
+)
+
+// LockMode decides what an update does when its target is already locked by
+// another update.
+type LockMode string
+
+const (
+	LockModeWait LockMode = "wait" // Wait up to the lock timeout
+	LockModeSkip LockMode = "skip" // Skip the update and move on
+)
+
+// LockConfig overrides the executor's target lock behavior for a service.
+type LockConfig struct {
+	Mode    LockMode      `json:"mode,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty"` // Zero uses the global lock timeout
+}
+
+// DrainConfig takes a service out of its reverse proxy's rotation while it is
+// recreated. Draining is enabled when URL is set.
+type DrainConfig struct {
+	URL     string        `json:"url,omitempty"`     // Webhook called with drain/status/enable actions
+	Backend string        `json:"backend,omitempty"` // Name the proxy knows the service by (default: service name)
+	Timeout time.Duration `json:"timeout,omitempty"` // Maximum wait for connections to drain
 }
 
 // ProbeType represents the type of health probe
@@ -95,6 +220,19 @@ type ProbeConfig struct {
 	LogPattern   string    `json:"log_pattern,omitempty"`   // Regex pattern
 	WindowSec    int       `json:"window_sec,omitempty"`    // For log probe: time window
 	StabilitySec int       `json:"stability_sec,omitempty"` // Seconds to wait before declaring success
+	MaxRestarts  int       `json:"max_restarts,omitempty"`  // Restarts tolerated during the stability window
+
+	// Log probe filters: which stream to read (stdout, stderr or "" for
+	// both), how many recent lines, and a pattern that fails the probe.
+	LogStream      string `json:"log_stream,omitempty"`
+	LogIgnoreCase  bool   `json:"log_ignore_case,omitempty"`
+	LogMaxLines    int    `json:"log_max_lines,omitempty"`
+	LogFailPattern string `json:"log_fail_pattern,omitempty"`
+
+	// FailPattern is watched for in the container's logs for FailWatch
+	// after a successful update; a match rolls the update back.
+	FailPattern string        `json:"fail_pattern,omitempty"`
+	FailWatch   time.Duration `json:"fail_watch,omitempty"`
 }
 
 // UpdateCheck represents an available update
@@ -109,27 +247,225 @@ type UpdateCheck struct {
 
 // UpdateResult represents the outcome of an update
 type UpdateResult struct {
+	ID                int64         `json:"id,omitempty"` // History row ID, set once saved
 	TargetID          string        `json:"target_id"`
 	ServiceID         string        `json:"service_id"`
 	ServiceName       string        `json:"service_name"`
+	Image             string        `json:"image,omitempty"`    // Image reference without tag or digest
+	Tag               string        `json:"tag,omitempty"`      // Empty for digest-pinned images
+	Registry          string        `json:"registry,omitempty"` // e.g. docker.io, ghcr.io
+	Platform          string        `json:"platform,omitempty"` // os/arch[/variant] the update ran with
 	Success           bool          `json:"success"`
 	OldDigest         string        `json:"old_digest"`
 	NewDigest         string        `json:"new_digest"`
 	ProbeResults      []ProbeResult `json:"probe_results"`
 	RollbackPerformed bool          `json:"rollback_performed"`
 	RollbackDigest    string        `json:"rollback_digest,omitempty"`
-	Error             error         `json:"error,omitempty"`
+	Attempts          int           `json:"attempts"`
+	ResultCode        ResultCode    `json:"result_code,omitempty"`
+	SBOM              *SBOM         `json:"sbom,omitempty"`
+	ErrorMessage      string        `json:"error_message,omitempty"` // Why the update failed or was skipped
+	ErrorCode         ResultCode    `json:"error_code,omitempty"`    // Classifies ErrorMessage; empty for a success
+	Timings           UpdateTimings `json:"timings"`
 	StartedAt         time.Time     `json:"started_at"`
 	CompletedAt       time.Time     `json:"completed_at"`
+
+	// Kind tells version changes between tags apart from digest refreshes
+	// of the same tag. PreviousTag is the tag a version change switched from.
+	Kind        UpdateKind `json:"kind,omitempty"`
+	PreviousTag string     `json:"previous_tag,omitempty"`
+
+	// ConfigChanges lists how the container's configuration differs after
+	// the recreate, such as a volume or environment variable the new image
+	// dropped.
+	ConfigChanges []ConfigChange `json:"config_changes,omitempty"`
+
+	// Resources holds the service's CPU and memory use before and after
+	// the update, sampled when the update succeeded.
+	Resources *ResourceSnapshot `json:"resources,omitempty"`
+
+	// ReasonCode is the plan decision behind an update that was skipped.
+	ReasonCode ReasonCode `json:"reason_code,omitempty"`
+
+	// Trigger is what started the update and Actor who: the signed-in user
+	// for manual-ui, the webhook source for webhook, and so on.
+	Trigger UpdateTrigger `json:"trigger,omitempty"`
+	Actor   string        `json:"actor,omitempty"`
+}
+
+// UpdateTrigger records what started an update.
+type UpdateTrigger string
+
+const (
+	// TriggerScheduled is a scheduled run someone queued for a later time.
+	TriggerScheduled UpdateTrigger = "scheduled"
+	// TriggerManualUI is an apply started from the web UI or the API.
+	TriggerManualUI UpdateTrigger = "manual-ui"
+	// TriggerManualCLI is an apply started with the bulwark CLI.
+	TriggerManualCLI UpdateTrigger = "manual-cli"
+	// TriggerWebhook is an apply started by an integration such as Home
+	// Assistant.
+	TriggerWebhook UpdateTrigger = "webhook"
+	// TriggerAutoApprove is an update the auto-update schedule applied
+	// without anyone reviewing it.
+	TriggerAutoApprove UpdateTrigger = "auto-approve"
+)
+
+// ConfigChange is one difference between a service's container
+// configuration before and after an update. Before is empty for additions
+// and After for removals.
+type ConfigChange struct {
+	Field  string `json:"field"` // env, mount, port, label, entrypoint or cmd
+	Key    string `json:"key,omitempty"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
 }
 
+// ResourceUsage is a container's CPU and memory use at one moment.
+type ResourceUsage struct {
+	CPUPercent  float64 `json:"cpu_percent"`
+	MemoryBytes uint64  `json:"memory_bytes"`
+	MemoryLimit uint64  `json:"memory_limit,omitempty"`
+}
+
+// ResourceSnapshot compares a service's resource use before an update with
+// its use once the new version's probes passed.
+type ResourceSnapshot struct {
+	Before *ResourceUsage `json:"before,omitempty"`
+	After  *ResourceUsage `json:"after,omitempty"`
+	// MemoryChangePercent is how much more (or, below zero, less) memory
+	// the new version uses. MemoryWarning is set when the increase exceeds
+	// the configured threshold.
+	MemoryChangePercent float64 `json:"memory_change_percent,omitempty"`
+	MemoryWarning       bool    `json:"memory_warning,omitempty"`
+}
+
+// UpdateKind classifies what an update changed.
+type UpdateKind string
+
+const (
+	// UpdateKindDigest pulls a newer digest of the tag the service runs. It is
+	// stored as the empty kind.
+	UpdateKindDigest UpdateKind = ""
+	// UpdateKindVersionChange switches the service to another tag.
+	UpdateKindVersionChange UpdateKind = "version_change"
+)
+
+// SetError records err under code, or clears the error when err is nil.
+func (r *UpdateResult) SetError(err error, code ResultCode) {
+	if err == nil {
+		r.ErrorMessage, r.ErrorCode = "", ""
+		return
+	}
+	r.ErrorMessage = err.Error()
+	r.ErrorCode = code
+}
+
+// UpdateTimings breaks an update down by step, in milliseconds. Pull covers
+// pulling or rebuilding the image, pre-pulls included. Downtime runs from
+// stopping the old container until the update settled, probes and an
+// automatic rollback included; blue-green updates have none.
+type UpdateTimings struct {
+	PullMs     int64 `json:"pull_ms"`
+	RecreateMs int64 `json:"recreate_ms"`
+	ProbeMs    int64 `json:"probe_ms"`
+	DowntimeMs int64 `json:"downtime_ms"`
+}
+
+// SBOM references the software bill of materials captured for the digest an
+// update applied. The document itself is stored on disk at Path.
+type SBOM struct {
+	Format   string `json:"format"` // e.g. cyclonedx-json, spdx-json
+	Path     string `json:"-"`
+	Packages int    `json:"packages"`
+}
+
+// ResultCode classifies the outcome of an update attempt.
+type ResultCode string
+
+const (
+	ResultSuccess           ResultCode = "success"
+	ResultSkipped           ResultCode = "skipped"
+	ResultSkippedSelfUpdate ResultCode = "skipped_self_update"
+	ResultSkippedDisabled   ResultCode = "skipped_disabled"
+	ResultNotSafe           ResultCode = "not_safe"       // Skipped by a safe-only run
+	ResultPolicyBlocked     ResultCode = "policy_blocked" // Policy did not allow the update
+	ResultInvalidDefinition ResultCode = "invalid_definition"
+	ResultLockTimeout       ResultCode = "lock_timeout"
+	ResultSkippedLocked     ResultCode = "skipped_locked"      // Target busy and the lock mode is skip
+	ResultSkippedPullPolicy ResultCode = "skipped_pull_policy" // Compose pull_policy: never
+	ResultInsufficientDisk  ResultCode = "insufficient_disk"   // Not enough free space on the Docker data root to pull
+	ResultPullFailed        ResultCode = "pull_failed"
+	ResultBuildFailed       ResultCode = "build_failed"
+	ResultRecreateFailed    ResultCode = "recreate_failed"
+	ResultProbeFailed       ResultCode = "probe_failed"    // Probes failed and the rollback succeeded
+	ResultRollbackFailed    ResultCode = "rollback_failed" // Probes failed and the rollback failed too
+	ResultUpdateFailed      ResultCode = "update_failed"   // Any other update failure
+)
+
+// SkipResultCodes lists the codes recorded for updates that were intentionally not attempted.
+var SkipResultCodes = []ResultCode{
+	ResultSkipped,
+	ResultSkippedSelfUpdate,
+	ResultSkippedDisabled,
+	ResultSkippedLocked,
+	ResultSkippedPullPolicy,
+	ResultInsufficientDisk,
+	ResultNotSafe,
+	ResultPolicyBlocked,
+	ResultInvalidDefinition,
+}
+
+// IsSkip reports whether the code marks an intentional skip rather than a failure.
+func (c ResultCode) IsSkip() bool {
+	for _, code := range SkipResultCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ReasonCode identifies the decision behind a plan item or skipped update,
+// so clients can translate or filter reasons without parsing their text.
+type ReasonCode string
+
+const (
+	ReasonNotEnabled         ReasonCode = "not_enabled"          // bulwark.enabled is not true
+	ReasonNoUpdate           ReasonCode = "no_update"            // The service runs the latest digest
+	ReasonIgnored            ReasonCode = "ignored"              // The user skipped the remote digest
+	ReasonDigestFetchFailed  ReasonCode = "digest_fetch_failed"  // The remote digest could not be resolved
+	ReasonInvalidImage       ReasonCode = "invalid_image"        // The image reference cannot be checked against the image rules
+	ReasonImageDenied        ReasonCode = "image_denied"         // The image matches BULWARK_DENIED_IMAGES
+	ReasonRegistryNotAllowed ReasonCode = "registry_not_allowed" // The image is outside BULWARK_ALLOWED_REGISTRIES
+	ReasonMutableTag         ReasonCode = "mutable_tag"          // Blocked by BULWARK_BLOCK_MUTABLE_TAGS
+	ReasonNotifyPolicy       ReasonCode = "notify_policy"
+	ReasonStatefulBlocked    ReasonCode = "stateful_blocked" // Safe policy on a stateful service
+	ReasonUnknownPolicy      ReasonCode = "unknown_policy"
+	ReasonSafePolicy         ReasonCode = "safe_policy"       // Allowed by the safe policy
+	ReasonAggressivePolicy   ReasonCode = "aggressive_policy" // Allowed by the aggressive policy
+	ReasonSnoozed            ReasonCode = "snoozed"
+	ReasonGroupPaused        ReasonCode = "group_paused"
+)
+
 // HistoryQuery defines filtered update history pagination.
 type HistoryQuery struct {
-	TargetID  string
-	ServiceID string
-	Result    string
-	Limit     int
-	Offset    int
+	TargetID   string
+	ServiceID  string
+	Result     string
+	ResultCode ResultCode
+	ReasonCode ReasonCode
+	// Kind, when set, keeps only updates of that kind; "digest" selects
+	// digest refreshes.
+	Kind string
+	// Trigger and Actor, when set, keep only updates started that way or
+	// by that actor.
+	Trigger UpdateTrigger
+	Actor   string
+	// Since, when set, leaves out updates completed before it.
+	Since  time.Time
+	Limit  int
+	Offset int
 }
 
 // ProbeResult represents the result of a single probe
@@ -176,6 +512,11 @@ type Run struct {
 	StartedAt   time.Time  `json:"started_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
 	SummaryJSON string     `json:"summary_json,omitempty"`
+
+	// Note and Labels document why the run happened, e.g. "upgrading before
+	// game night".
+	Note   string            `json:"note,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 // RunEvent represents a single event during a run (for persistence).
@@ -187,6 +528,7 @@ type RunEvent struct {
 	Target    string    `json:"target,omitempty"`
 	Service   string    `json:"service,omitempty"`
 	Step      string    `json:"step,omitempty"`
+	Phase     string    `json:"phase,omitempty"`
 	Message   string    `json:"message"`
 	DataJSON  string    `json:"data_json,omitempty"`
 }
@@ -194,13 +536,130 @@ type RunEvent struct {
 // DefaultLabels returns default label values
 func DefaultLabels() Labels {
 	return Labels{
-		Enabled: false,
-		Policy:  PolicySafe,
-		Tier:    TierStateless,
+		Enabled:  false,
+		Policy:   PolicySafe,
+		Tier:     TierStateless,
+		Strategy: StrategyRecreate,
+		Lock: LockConfig{
+			Mode: LockModeWait,
+		},
 		Probe: ProbeConfig{
 			Type:         ProbeTypeNone,
 			HTTPStatus:   200,
 			StabilitySec: 10,
 		},
+		Retry: RetryConfig{
+			Backoff: 10 * time.Second,
+		},
+		Drain: DrainConfig{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// User is a web console account. PasswordHash is an argon2id hash in PHC
+// string format.
+type User struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"role"`
+	// PasswordChangedAt invalidates sessions issued before it.
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// IgnoredUpdate is an update a user chose to skip. The planner stops
+// offering the service's update while the registry serves Digest, and
+// offers it again once a newer digest appears. Tag records the version the
+// user named, if they named one.
+type IgnoredUpdate struct {
+	ServiceID string    `json:"service_id"`
+	Digest    string    `json:"digest"`
+	Tag       string    `json:"tag,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaxSnooze is the longest a service's updates can be snoozed.
+const MaxSnooze = 365 * 24 * time.Hour
+
+// Snooze defers a service's updates until Until. Plans still list them, but
+// apply runs skip them unless the service is selected explicitly.
+type Snooze struct {
+	ServiceID string    `json:"service_id"`
+	Until     time.Time `json:"until"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GroupPause holds back the updates of every target in Group until it is
+// resumed.
+type GroupPause struct {
+	Group     string    `json:"group"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IDAlias records that a target or service ID was relinked to another one,
+// e.g. after its project directory was renamed. Lookups of OldID resolve to
+// NewID.
+type IDAlias struct {
+	OldID     string    `json:"old_id"`
+	NewID     string    `json:"new_id"`
+	Kind      string    `json:"kind"` // "target" or "service"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ScheduledRun is an apply queued once at RunAt, e.g. after hours for a plan
+// reviewed during the day. The selection fields mirror an apply request.
+type ScheduledRun struct {
+	ID         string             `json:"id"`
+	RunAt      time.Time          `json:"run_at"`
+	Mode       string             `json:"mode"` // safe, selected or all
+	Target     string             `json:"target,omitempty"`
+	Group      string             `json:"group,omitempty"`
+	ServiceIDs []string           `json:"service_ids,omitempty"`
+	Force      bool               `json:"force,omitempty"`
+	PullOnly   bool               `json:"pull_only,omitempty"`
+	CreatedBy  string             `json:"created_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	Status     ScheduledRunStatus `json:"status"`
+	RunID      string             `json:"run_id,omitempty"` // The run it started
+	Note       string             `json:"note,omitempty"`   // Passed on to the run
+	Labels     map[string]string  `json:"labels,omitempty"`
+}
+
+// ScheduledRunStatus tracks a scheduled run.
+type ScheduledRunStatus string
+
+const (
+	ScheduledRunPending ScheduledRunStatus = "pending"
+	ScheduledRunStarted ScheduledRunStatus = "started"
+	// ScheduledRunMissed marks a run whose time passed while Bulwark was
+	// down for longer than the grace period.
+	ScheduledRunMissed ScheduledRunStatus = "missed"
+	ScheduledRunFailed ScheduledRunStatus = "failed" // The run could not be queued
+	// ScheduledRunSkipped marks a run that came due during maintenance.
+	ScheduledRunSkipped ScheduledRunStatus = "skipped"
+)
+
+// ParseSnoozeDuration parses a snooze length: a Go duration such as "12h",
+// or a number of days such as "3d".
+func ParseSnoozeDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid snooze duration %q", value)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid snooze duration %q", value)
+		}
+	}
+	if d < time.Minute || d > MaxSnooze {
+		return 0, fmt.Errorf("snooze duration must be between 1m and 365d")
 	}
+	return d, nil
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/redact"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -87,6 +88,27 @@ func (s *SQLiteStore) Initialize(ctx context.Context) error {
 			rollback_digest TEXT,
 			started_at DATETIME NOT NULL,
 			completed_at DATETIME NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 1,
+			result_code TEXT NOT NULL DEFAULT '',
+			image TEXT NOT NULL DEFAULT '',
+			tag TEXT NOT NULL DEFAULT '',
+			registry TEXT NOT NULL DEFAULT '',
+			platform TEXT NOT NULL DEFAULT '',
+			sbom_path TEXT NOT NULL DEFAULT '',
+			sbom_format TEXT NOT NULL DEFAULT '',
+			sbom_packages INTEGER NOT NULL DEFAULT 0,
+			pull_ms INTEGER NOT NULL DEFAULT 0,
+			recreate_ms INTEGER NOT NULL DEFAULT 0,
+			probe_ms INTEGER NOT NULL DEFAULT 0,
+			downtime_ms INTEGER NOT NULL DEFAULT 0,
+			error_code TEXT NOT NULL DEFAULT '',
+			kind TEXT NOT NULL DEFAULT '',
+			previous_tag TEXT NOT NULL DEFAULT '',
+			config_changes_json TEXT NOT NULL DEFAULT '',
+			resources_json TEXT NOT NULL DEFAULT '',
+			reason_code TEXT NOT NULL DEFAULT '',
+			triggered_by TEXT NOT NULL DEFAULT '',
+			actor TEXT NOT NULL DEFAULT '',
 			FOREIGN KEY(target_id) REFERENCES targets(id) ON DELETE CASCADE,
 			FOREIGN KEY(service_id) REFERENCES services(id) ON DELETE CASCADE
 		);
@@ -115,7 +137,9 @@ func (s *SQLiteStore) Initialize(ctx context.Context) error {
 			created_at DATETIME NOT NULL,
 			started_at DATETIME NOT NULL,
 			completed_at DATETIME,
-			summary_json TEXT
+			summary_json TEXT,
+			note TEXT NOT NULL DEFAULT '',
+			labels_json TEXT NOT NULL DEFAULT ''
 		);
 
 		-- Run events table
@@ -132,6 +156,65 @@ func (s *SQLiteStore) Initialize(ctx context.Context) error {
 			FOREIGN KEY(run_id) REFERENCES runs(id) ON DELETE CASCADE
 		);
 
+		-- Users table
+		CREATE TABLE IF NOT EXISTS users (
+			username TEXT PRIMARY KEY,
+			password_hash TEXT NOT NULL,
+			role TEXT NOT NULL,
+			password_changed_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		);
+
+		-- Updates users chose to skip, at most one per service
+		CREATE TABLE IF NOT EXISTS ignored_updates (
+			service_id TEXT PRIMARY KEY,
+			digest TEXT NOT NULL,
+			tag TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY(service_id) REFERENCES services(id) ON DELETE CASCADE
+		);
+
+		-- Services whose updates are deferred until a time
+		CREATE TABLE IF NOT EXISTS snoozed_updates (
+			service_id TEXT PRIMARY KEY,
+			until DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			FOREIGN KEY(service_id) REFERENCES services(id) ON DELETE CASCADE
+		);
+
+		-- Target groups whose updates are on hold
+		CREATE TABLE IF NOT EXISTS paused_groups (
+			name TEXT PRIMARY KEY,
+			created_at DATETIME NOT NULL
+		);
+
+		-- One-shot apply runs queued for a later time
+		CREATE TABLE IF NOT EXISTS scheduled_runs (
+			id TEXT PRIMARY KEY,
+			run_at DATETIME NOT NULL,
+			mode TEXT NOT NULL,
+			target TEXT NOT NULL DEFAULT '',
+			group_name TEXT NOT NULL DEFAULT '',
+			service_ids_json TEXT NOT NULL DEFAULT '[]',
+			force INTEGER NOT NULL DEFAULT 0,
+			pull_only INTEGER NOT NULL DEFAULT 0,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			status TEXT NOT NULL,
+			run_id TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			labels_json TEXT NOT NULL DEFAULT ''
+		);
+
+		-- Aliases of relinked target and service IDs
+		CREATE TABLE IF NOT EXISTS id_aliases (
+			old_id TEXT PRIMARY KEY,
+			new_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			created_at DATETIME NOT NULL
+		);
+
 		CREATE INDEX IF NOT EXISTS idx_runs_created_at ON runs(created_at);
 		CREATE INDEX IF NOT EXISTS idx_run_events_run_id ON run_events(run_id);
 	`
@@ -140,16 +223,111 @@ func (s *SQLiteStore) Initialize(ctx context.Context) error {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	if err := s.migrateColumns(ctx); err != nil {
+		return err
+	}
+
 	s.logger.Info().Msg("Database schema initialized")
 	return nil
 }
 
+// columnMigration adds a column that newer schema versions expect. Columns are
+// only ever added, so older databases upgrade in place.
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+var columnMigrations = []columnMigration{
+	{"update_history", "attempts", "INTEGER NOT NULL DEFAULT 1"},
+	{"update_history", "result_code", "TEXT NOT NULL DEFAULT ''"},
+	{"run_events", "phase", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "image", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "tag", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "registry", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "platform", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "sbom_path", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "sbom_format", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "sbom_packages", "INTEGER NOT NULL DEFAULT 0"},
+	{"update_history", "pull_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"update_history", "recreate_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"update_history", "probe_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"update_history", "downtime_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"update_history", "error_code", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "kind", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "previous_tag", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "config_changes_json", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "reason_code", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "triggered_by", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "actor", "TEXT NOT NULL DEFAULT ''"},
+	{"update_history", "resources_json", "TEXT NOT NULL DEFAULT ''"},
+	{"runs", "note", "TEXT NOT NULL DEFAULT ''"},
+	{"runs", "labels_json", "TEXT NOT NULL DEFAULT ''"},
+	{"scheduled_runs", "note", "TEXT NOT NULL DEFAULT ''"},
+	{"scheduled_runs", "labels_json", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrateColumns adds any columns missing from databases created by older versions.
+func (s *SQLiteStore) migrateColumns(ctx context.Context) error {
+	for _, m := range columnMigrations {
+		exists, err := s.columnExists(ctx, m.table, m.column)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", m.table, err)
+		}
+		if exists {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+		s.logger.Info().Str("table", m.table).Str("column", m.column).Msg("Migrated database column")
+	}
+	return nil
+}
+
+func (s *SQLiteStore) columnExists(ctx context.Context, table, column string) (bool, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 // Close closes the database connection
 func (s *SQLiteStore) Close() error {
 	s.logger.Info().Msg("Closing database connection")
 	return s.db.Close()
 }
 
+// Ping checks that the database can still be queried
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	var one int
+	if err := s.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("database unavailable: %w", err)
+	}
+	return nil
+}
+
 // SaveTarget saves or updates a target
 func (s *SQLiteStore) SaveTarget(ctx context.Context, target *Target) error {
 	labelsJSON, err := json.Marshal(target.Labels)
@@ -404,7 +582,7 @@ func (s *SQLiteStore) GetService(ctx context.Context, id string) (*Service, erro
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("service not found: %s", id)
+		return nil, fmt.Errorf("service %w: %s", ErrNotFound, id)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get service: %w", err)
@@ -467,44 +645,118 @@ func (s *SQLiteStore) DeleteService(ctx context.Context, id string) error {
 	return nil
 }
 
+// redactProbeResults returns a copy of results with secrets masked in their
+// messages, which may quote responses, URLs or compose env.
+func redactProbeResults(results []ProbeResult) []ProbeResult {
+	if results == nil {
+		return nil
+	}
+	out := make([]ProbeResult, len(results))
+	for i, result := range results {
+		result.Message = redact.String(result.Message)
+		out[i] = result
+	}
+	return out
+}
+
+// redactConfigChanges returns a copy of changes with secrets masked in their
+// values. Values of environment variables named like secrets are masked whole.
+func redactConfigChanges(changes []ConfigChange) []ConfigChange {
+	if changes == nil {
+		return nil
+	}
+	out := make([]ConfigChange, len(changes))
+	for i, change := range changes {
+		change.Before = redact.Value(change.Key, change.Before)
+		change.After = redact.Value(change.Key, change.After)
+		out[i] = change
+	}
+	return out
+}
+
 // SaveUpdateResult saves an update result to history
 func (s *SQLiteStore) SaveUpdateResult(ctx context.Context, result *UpdateResult) error {
-	probeResultsJSON, err := json.Marshal(result.ProbeResults)
+	probeResultsJSON, err := json.Marshal(redactProbeResults(result.ProbeResults))
 	if err != nil {
 		return fmt.Errorf("failed to marshal probe results: %w", err)
 	}
+	configChangesJSON := ""
+	if len(result.ConfigChanges) > 0 {
+		data, err := json.Marshal(redactConfigChanges(result.ConfigChanges))
+		if err != nil {
+			return fmt.Errorf("failed to marshal config changes: %w", err)
+		}
+		configChangesJSON = string(data)
+	}
+	resourcesJSON := ""
+	if result.Resources != nil {
+		data, err := json.Marshal(result.Resources)
+		if err != nil {
+			return fmt.Errorf("failed to marshal resource usage: %w", err)
+		}
+		resourcesJSON = string(data)
+	}
 
 	query := `
 		INSERT INTO update_history (
 			target_id, service_id, service_name, old_digest, new_digest,
 			success, error, probe_results_json, rollback_performed, rollback_digest,
-			started_at, completed_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			started_at, completed_at, attempts, result_code,
+			image, tag, registry, platform,
+			sbom_path, sbom_format, sbom_packages,
+			pull_ms, recreate_ms, probe_ms, downtime_ms, error_code,
+			kind, previous_tag, config_changes_json, reason_code,
+			triggered_by, actor, resources_json
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	errorStr := ""
-	if result.Error != nil {
-		errorStr = result.Error.Error()
+	var sbom SBOM
+	if result.SBOM != nil {
+		sbom = *result.SBOM
 	}
 
-	_, err = s.db.ExecContext(ctx, query,
+	res, err := s.db.ExecContext(ctx, query,
 		result.TargetID,
 		result.ServiceID,
 		result.ServiceName,
 		result.OldDigest,
 		result.NewDigest,
 		result.Success,
-		errorStr,
+		redact.String(result.ErrorMessage),
 		string(probeResultsJSON),
 		result.RollbackPerformed,
 		result.RollbackDigest,
 		result.StartedAt,
 		result.CompletedAt,
+		max(result.Attempts, 1),
+		string(result.ResultCode),
+		result.Image,
+		result.Tag,
+		result.Registry,
+		result.Platform,
+		sbom.Path,
+		sbom.Format,
+		sbom.Packages,
+		result.Timings.PullMs,
+		result.Timings.RecreateMs,
+		result.Timings.ProbeMs,
+		result.Timings.DowntimeMs,
+		string(result.ErrorCode),
+		string(result.Kind),
+		result.PreviousTag,
+		configChangesJSON,
+		string(result.ReasonCode),
+		string(result.Trigger),
+		result.Actor,
+		resourcesJSON,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to save update result: %w", err)
 	}
+	if id, err := res.LastInsertId(); err == nil {
+		result.ID = id
+	}
 
 	s.logger.Debug().
 		Str("service_name", result.ServiceName).
@@ -514,12 +766,29 @@ func (s *SQLiteStore) SaveUpdateResult(ctx context.Context, result *UpdateResult
 	return nil
 }
 
+// GetUpdateResult retrieves a single update history entry by ID
+func (s *SQLiteStore) GetUpdateResult(ctx context.Context, id int64) (*UpdateResult, error) {
+	query := `
+		SELECT ` + updateHistoryColumns + `
+		FROM update_history
+		WHERE id = ?
+	`
+
+	results, err := s.queryUpdateHistory(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("update %d: %w", id, ErrNotFound)
+	}
+
+	return &results[0], nil
+}
+
 // GetUpdateHistory retrieves recent update history
 func (s *SQLiteStore) GetUpdateHistory(ctx context.Context, limit int) ([]UpdateResult, error) {
 	query := `
-		SELECT id, target_id, service_id, service_name, old_digest, new_digest,
-			   success, error, probe_results_json, rollback_performed, rollback_digest,
-			   started_at, completed_at
+		SELECT ` + updateHistoryColumns + `
 		FROM update_history
 		ORDER BY completed_at DESC
 		LIMIT ?
@@ -531,9 +800,7 @@ func (s *SQLiteStore) GetUpdateHistory(ctx context.Context, limit int) ([]Update
 // GetUpdateHistoryByTarget retrieves update history for a target
 func (s *SQLiteStore) GetUpdateHistoryByTarget(ctx context.Context, targetID string, limit int) ([]UpdateResult, error) {
 	query := `
-		SELECT id, target_id, service_id, service_name, old_digest, new_digest,
-			   success, error, probe_results_json, rollback_performed, rollback_digest,
-			   started_at, completed_at
+		SELECT ` + updateHistoryColumns + `
 		FROM update_history
 		WHERE target_id = ?
 		ORDER BY completed_at DESC
@@ -546,9 +813,7 @@ func (s *SQLiteStore) GetUpdateHistoryByTarget(ctx context.Context, targetID str
 // GetUpdateHistoryByService retrieves update history for a service
 func (s *SQLiteStore) GetUpdateHistoryByService(ctx context.Context, serviceID string, limit int) ([]UpdateResult, error) {
 	query := `
-		SELECT id, target_id, service_id, service_name, old_digest, new_digest,
-			   success, error, probe_results_json, rollback_performed, rollback_digest,
-			   started_at, completed_at
+		SELECT ` + updateHistoryColumns + `
 		FROM update_history
 		WHERE service_id = ?
 		ORDER BY completed_at DESC
@@ -562,14 +827,12 @@ func (s *SQLiteStore) GetUpdateHistoryByService(ctx context.Context, serviceID s
 func (s *SQLiteStore) ListUpdateHistory(ctx context.Context, query HistoryQuery) ([]UpdateResult, error) {
 	var builder strings.Builder
 	builder.WriteString(`
-		SELECT id, target_id, service_id, service_name, old_digest, new_digest,
-			   success, error, probe_results_json, rollback_performed, rollback_digest,
-			   started_at, completed_at
+		SELECT ` + updateHistoryColumns + `
 		FROM update_history
 	`)
 
-	args := make([]interface{}, 0, 4)
-	clauses := make([]string, 0, 3)
+	args := make([]interface{}, 0, 5)
+	clauses := make([]string, 0, 4)
 	if query.ServiceID != "" {
 		clauses = append(clauses, "service_id = ?")
 		args = append(args, query.ServiceID)
@@ -578,14 +841,47 @@ func (s *SQLiteStore) ListUpdateHistory(ctx context.Context, query HistoryQuery)
 		clauses = append(clauses, "target_id = ?")
 		args = append(args, query.TargetID)
 	}
+	skipCodes := skipCodePlaceholders()
 	switch query.Result {
 	case "success":
 		clauses = append(clauses, "success = 1")
 	case "failed":
-		clauses = append(clauses, "success = 0")
+		clauses = append(clauses, "success = 0 AND result_code NOT IN ("+skipCodes+")")
+		args = append(args, skipCodeArgs()...)
+	case "skipped":
+		clauses = append(clauses, "result_code IN ("+skipCodes+")")
+		args = append(args, skipCodeArgs()...)
 	case "rolled_back":
 		clauses = append(clauses, "rollback_performed = 1")
 	}
+	if query.ResultCode != "" {
+		clauses = append(clauses, "result_code = ?")
+		args = append(args, string(query.ResultCode))
+	}
+	if query.ReasonCode != "" {
+		clauses = append(clauses, "reason_code = ?")
+		args = append(args, string(query.ReasonCode))
+	}
+	if query.Trigger != "" {
+		clauses = append(clauses, "triggered_by = ?")
+		args = append(args, string(query.Trigger))
+	}
+	if query.Actor != "" {
+		clauses = append(clauses, "actor = ?")
+		args = append(args, query.Actor)
+	}
+	switch query.Kind {
+	case "":
+	case "digest":
+		clauses = append(clauses, "kind = ''")
+	default:
+		clauses = append(clauses, "kind = ?")
+		args = append(args, query.Kind)
+	}
+	if !query.Since.IsZero() {
+		clauses = append(clauses, "completed_at >= ?")
+		args = append(args, query.Since)
+	}
 	if len(clauses) > 0 {
 		builder.WriteString(" WHERE ")
 		builder.WriteString(strings.Join(clauses, " AND "))
@@ -596,12 +892,22 @@ func (s *SQLiteStore) ListUpdateHistory(ctx context.Context, query HistoryQuery)
 	return s.queryUpdateHistory(ctx, builder.String(), args...)
 }
 
+func skipCodePlaceholders() string {
+	return strings.TrimSuffix(strings.Repeat("?, ", len(SkipResultCodes)), ", ")
+}
+
+func skipCodeArgs() []interface{} {
+	args := make([]interface{}, 0, len(SkipResultCodes))
+	for _, code := range SkipResultCodes {
+		args = append(args, string(code))
+	}
+	return args
+}
+
 // GetLastSuccessfulUpdate retrieves the last successful update for a service
 func (s *SQLiteStore) GetLastSuccessfulUpdate(ctx context.Context, serviceID string) (*UpdateResult, error) {
 	query := `
-		SELECT id, target_id, service_id, service_name, old_digest, new_digest,
-			   success, error, probe_results_json, rollback_performed, rollback_digest,
-			   started_at, completed_at
+		SELECT ` + updateHistoryColumns + `
 		FROM update_history
 		WHERE service_id = ? AND success = 1
 		ORDER BY completed_at DESC
@@ -620,6 +926,16 @@ func (s *SQLiteStore) GetLastSuccessfulUpdate(ctx context.Context, serviceID str
 	return &results[0], nil
 }
 
+// updateHistoryColumns is the column list scanned by queryUpdateHistory.
+const updateHistoryColumns = `id, target_id, service_id, service_name, old_digest, new_digest,
+			   success, error, probe_results_json, rollback_performed, rollback_digest,
+			   started_at, completed_at, attempts, result_code,
+			   image, tag, registry, platform,
+			   sbom_path, sbom_format, sbom_packages,
+			   pull_ms, recreate_ms, probe_ms, downtime_ms, error_code,
+			   kind, previous_tag, config_changes_json, reason_code,
+			   triggered_by, actor, resources_json`
+
 // queryUpdateHistory is a helper to execute update history queries
 func (s *SQLiteStore) queryUpdateHistory(ctx context.Context, query string, args ...interface{}) ([]UpdateResult, error) {
 	rows, err := s.db.QueryContext(ctx, query, args...)
@@ -632,11 +948,12 @@ func (s *SQLiteStore) queryUpdateHistory(ctx context.Context, query string, args
 	for rows.Next() {
 		var result UpdateResult
 		var errorStr sql.NullString
-		var probeResultsJSON string
-		var id int64
+		var probeResultsJSON, configChangesJSON, resourcesJSON string
+		var resultCode, errorCode, kind, reasonCode, trigger string
+		var sbom SBOM
 
 		if err := rows.Scan(
-			&id,
+			&result.ID,
 			&result.TargetID,
 			&result.ServiceID,
 			&result.ServiceName,
@@ -649,17 +966,61 @@ func (s *SQLiteStore) queryUpdateHistory(ctx context.Context, query string, args
 			&result.RollbackDigest,
 			&result.StartedAt,
 			&result.CompletedAt,
+			&result.Attempts,
+			&resultCode,
+			&result.Image,
+			&result.Tag,
+			&result.Registry,
+			&result.Platform,
+			&sbom.Path,
+			&sbom.Format,
+			&sbom.Packages,
+			&result.Timings.PullMs,
+			&result.Timings.RecreateMs,
+			&result.Timings.ProbeMs,
+			&result.Timings.DowntimeMs,
+			&errorCode,
+			&kind,
+			&result.PreviousTag,
+			&configChangesJSON,
+			&reasonCode,
+			&trigger,
+			&result.Actor,
+			&resourcesJSON,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan update result: %w", err)
 		}
 
+		result.ResultCode = ResultCode(resultCode)
+		result.Kind = UpdateKind(kind)
+		result.ReasonCode = ReasonCode(reasonCode)
+		result.Trigger = UpdateTrigger(trigger)
 		if errorStr.Valid && errorStr.String != "" {
-			result.Error = fmt.Errorf("%s", errorStr.String)
+			result.ErrorMessage = errorStr.String
+			result.ErrorCode = ResultCode(errorCode)
+			if result.ErrorCode == "" {
+				// Rows from before error_code existed: the result code
+				// classified their error.
+				result.ErrorCode = result.ResultCode
+			}
+		}
+		if sbom.Path != "" {
+			result.SBOM = &sbom
 		}
 
 		if err := json.Unmarshal([]byte(probeResultsJSON), &result.ProbeResults); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal probe results: %w", err)
 		}
+		if configChangesJSON != "" {
+			if err := json.Unmarshal([]byte(configChangesJSON), &result.ConfigChanges); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal config changes: %w", err)
+			}
+		}
+		if resourcesJSON != "" {
+			if err := json.Unmarshal([]byte(resourcesJSON), &result.Resources); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal resource usage: %w", err)
+			}
+		}
 
 		results = append(results, result)
 	}
@@ -728,16 +1089,27 @@ func (s *SQLiteStore) SetSetting(ctx context.Context, key, value string) error {
 // SaveRun saves or updates a run.
 func (s *SQLiteStore) SaveRun(ctx context.Context, run *Run) error {
 	query := `
-		INSERT INTO runs (id, mode, status, created_at, started_at, completed_at, summary_json)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO runs (id, mode, status, created_at, started_at, completed_at, summary_json, note, labels_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			status = excluded.status,
 			completed_at = excluded.completed_at,
-			summary_json = excluded.summary_json
+			summary_json = excluded.summary_json,
+			note = excluded.note,
+			labels_json = excluded.labels_json
 	`
+	labelsJSON := ""
+	if len(run.Labels) > 0 {
+		data, err := json.Marshal(run.Labels)
+		if err != nil {
+			return fmt.Errorf("failed to marshal run labels: %w", err)
+		}
+		labelsJSON = string(data)
+	}
 	_, err := s.db.ExecContext(ctx, query,
 		run.ID, run.Mode, run.Status,
 		run.CreatedAt, run.StartedAt, run.CompletedAt, run.SummaryJSON,
+		run.Note, labelsJSON,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save run: %w", err)
@@ -747,13 +1119,15 @@ func (s *SQLiteStore) SaveRun(ctx context.Context, run *Run) error {
 
 // GetRun retrieves a run by ID.
 func (s *SQLiteStore) GetRun(ctx context.Context, id string) (*Run, error) {
-	query := `SELECT id, mode, status, created_at, started_at, completed_at, summary_json FROM runs WHERE id = ?`
+	query := `SELECT id, mode, status, created_at, started_at, completed_at, summary_json, note, labels_json FROM runs WHERE id = ?`
 	var run Run
 	var summaryJSON sql.NullString
 	var completedAt sql.NullTime
+	var labelsJSON string
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&run.ID, &run.Mode, &run.Status,
 		&run.CreatedAt, &run.StartedAt, &completedAt, &summaryJSON,
+		&run.Note, &labelsJSON,
 	)
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("run not found: %s", id)
@@ -767,12 +1141,15 @@ func (s *SQLiteStore) GetRun(ctx context.Context, id string) (*Run, error) {
 	if summaryJSON.Valid {
 		run.SummaryJSON = summaryJSON.String
 	}
+	if labelsJSON != "" {
+		_ = json.Unmarshal([]byte(labelsJSON), &run.Labels)
+	}
 	return &run, nil
 }
 
 // ListRecentRuns retrieves recent runs.
 func (s *SQLiteStore) ListRecentRuns(ctx context.Context, limit int) ([]Run, error) {
-	query := `SELECT id, mode, status, created_at, started_at, completed_at, summary_json FROM runs ORDER BY created_at DESC LIMIT ?`
+	query := `SELECT id, mode, status, created_at, started_at, completed_at, summary_json, note, labels_json FROM runs ORDER BY created_at DESC LIMIT ?`
 	rows, err := s.db.QueryContext(ctx, query, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list runs: %w", err)
@@ -784,7 +1161,8 @@ func (s *SQLiteStore) ListRecentRuns(ctx context.Context, limit int) ([]Run, err
 		var run Run
 		var summaryJSON sql.NullString
 		var completedAt sql.NullTime
-		if err := rows.Scan(&run.ID, &run.Mode, &run.Status, &run.CreatedAt, &run.StartedAt, &completedAt, &summaryJSON); err != nil {
+		var labelsJSON string
+		if err := rows.Scan(&run.ID, &run.Mode, &run.Status, &run.CreatedAt, &run.StartedAt, &completedAt, &summaryJSON, &run.Note, &labelsJSON); err != nil {
 			return nil, fmt.Errorf("failed to scan run: %w", err)
 		}
 		if completedAt.Valid {
@@ -793,6 +1171,9 @@ func (s *SQLiteStore) ListRecentRuns(ctx context.Context, limit int) ([]Run, err
 		if summaryJSON.Valid {
 			run.SummaryJSON = summaryJSON.String
 		}
+		if labelsJSON != "" {
+			_ = json.Unmarshal([]byte(labelsJSON), &run.Labels)
+		}
 		runs = append(runs, run)
 	}
 	return runs, rows.Err()
@@ -801,12 +1182,12 @@ func (s *SQLiteStore) ListRecentRuns(ctx context.Context, limit int) ([]Run, err
 // SaveRunEvent saves a run event.
 func (s *SQLiteStore) SaveRunEvent(ctx context.Context, event *RunEvent) error {
 	query := `
-		INSERT INTO run_events (run_id, timestamp, level, target, service, step, message, data_json)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO run_events (run_id, timestamp, level, target, service, step, phase, message, data_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	_, err := s.db.ExecContext(ctx, query,
 		event.RunID, event.Timestamp, event.Level,
-		event.Target, event.Service, event.Step, event.Message, event.DataJSON,
+		event.Target, event.Service, event.Step, event.Phase, event.Message, event.DataJSON,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to save run event: %w", err)
@@ -816,7 +1197,7 @@ func (s *SQLiteStore) SaveRunEvent(ctx context.Context, event *RunEvent) error {
 
 // GetRunEvents retrieves events for a run.
 func (s *SQLiteStore) GetRunEvents(ctx context.Context, runID string) ([]RunEvent, error) {
-	query := `SELECT id, run_id, timestamp, level, target, service, step, message, data_json FROM run_events WHERE run_id = ? ORDER BY id`
+	query := `SELECT id, run_id, timestamp, level, target, service, step, phase, message, data_json FROM run_events WHERE run_id = ? ORDER BY id`
 	rows, err := s.db.QueryContext(ctx, query, runID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get run events: %w", err)
@@ -827,7 +1208,7 @@ func (s *SQLiteStore) GetRunEvents(ctx context.Context, runID string) ([]RunEven
 	for rows.Next() {
 		var event RunEvent
 		var target, service, step, dataJSON sql.NullString
-		if err := rows.Scan(&event.ID, &event.RunID, &event.Timestamp, &event.Level, &target, &service, &step, &event.Message, &dataJSON); err != nil {
+		if err := rows.Scan(&event.ID, &event.RunID, &event.Timestamp, &event.Level, &target, &service, &step, &event.Phase, &event.Message, &dataJSON); err != nil {
 			return nil, fmt.Errorf("failed to scan run event: %w", err)
 		}
 		if target.Valid {
@@ -846,3 +1227,459 @@ func (s *SQLiteStore) GetRunEvents(ctx context.Context, runID string) ([]RunEven
 	}
 	return events, rows.Err()
 }
+
+// SaveUser creates or updates a user.
+func (s *SQLiteStore) SaveUser(ctx context.Context, user *User) error {
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.PasswordChangedAt.IsZero() {
+		user.PasswordChangedAt = now
+	}
+	user.UpdatedAt = now
+
+	query := `
+		INSERT INTO users (username, password_hash, role, password_changed_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET
+			password_hash = excluded.password_hash,
+			role = excluded.role,
+			password_changed_at = excluded.password_changed_at,
+			updated_at = excluded.updated_at
+	`
+	_, err := s.db.ExecContext(ctx, query, user.Username, user.PasswordHash, user.Role,
+		user.PasswordChangedAt, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+// GetUser retrieves a user by username.
+func (s *SQLiteStore) GetUser(ctx context.Context, username string) (*User, error) {
+	query := `SELECT username, password_hash, role, password_changed_at, created_at, updated_at FROM users WHERE username = ?`
+	var user User
+	err := s.db.QueryRowContext(ctx, query, username).Scan(
+		&user.Username, &user.PasswordHash, &user.Role,
+		&user.PasswordChangedAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user %w: %s", ErrNotFound, username)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &user, nil
+}
+
+// ListUsers retrieves all users ordered by username.
+func (s *SQLiteStore) ListUsers(ctx context.Context) ([]User, error) {
+	query := `SELECT username, password_hash, role, password_changed_at, created_at, updated_at FROM users ORDER BY username`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		if err := rows.Scan(&user.Username, &user.PasswordHash, &user.Role,
+			&user.PasswordChangedAt, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// DeleteUser deletes a user.
+func (s *SQLiteStore) DeleteUser(ctx context.Context, username string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE username = ?`, username)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("user %w: %s", ErrNotFound, username)
+	}
+	return nil
+}
+
+// SaveIgnoredUpdate stores an ignored update, replacing the service's
+// previous one.
+func (s *SQLiteStore) SaveIgnoredUpdate(ctx context.Context, ignore *IgnoredUpdate) error {
+	if ignore.CreatedAt.IsZero() {
+		ignore.CreatedAt = time.Now()
+	}
+	query := `
+		INSERT INTO ignored_updates (service_id, digest, tag, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(service_id) DO UPDATE SET
+			digest = excluded.digest,
+			tag = excluded.tag,
+			created_at = excluded.created_at
+	`
+	_, err := s.db.ExecContext(ctx, query, ignore.ServiceID, ignore.Digest, ignore.Tag, ignore.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save ignored update: %w", err)
+	}
+	return nil
+}
+
+// ListIgnoredUpdates retrieves all ignored updates.
+func (s *SQLiteStore) ListIgnoredUpdates(ctx context.Context) ([]IgnoredUpdate, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT service_id, digest, tag, created_at FROM ignored_updates ORDER BY service_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ignored updates: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var ignores []IgnoredUpdate
+	for rows.Next() {
+		var ignore IgnoredUpdate
+		if err := rows.Scan(&ignore.ServiceID, &ignore.Digest, &ignore.Tag, &ignore.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ignored update: %w", err)
+		}
+		ignores = append(ignores, ignore)
+	}
+	return ignores, rows.Err()
+}
+
+// DeleteIgnoredUpdate clears a service's ignored update.
+func (s *SQLiteStore) DeleteIgnoredUpdate(ctx context.Context, serviceID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM ignored_updates WHERE service_id = ?`, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete ignored update: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("ignored update %w: %s", ErrNotFound, serviceID)
+	}
+	return nil
+}
+
+// SaveSnooze stores a snooze, replacing the service's previous one.
+func (s *SQLiteStore) SaveSnooze(ctx context.Context, snooze *Snooze) error {
+	if snooze.CreatedAt.IsZero() {
+		snooze.CreatedAt = time.Now()
+	}
+	query := `
+		INSERT INTO snoozed_updates (service_id, until, created_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(service_id) DO UPDATE SET
+			until = excluded.until,
+			created_at = excluded.created_at
+	`
+	if _, err := s.db.ExecContext(ctx, query, snooze.ServiceID, snooze.Until, snooze.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save snooze: %w", err)
+	}
+	return nil
+}
+
+// ListSnoozes retrieves the snoozes that have not expired at now.
+func (s *SQLiteStore) ListSnoozes(ctx context.Context, now time.Time) ([]Snooze, error) {
+	// Expiry is checked here rather than in SQL, where times saved in
+	// different zones would not compare.
+	rows, err := s.db.QueryContext(ctx, `SELECT service_id, until, created_at FROM snoozed_updates ORDER BY service_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snoozes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var snoozes []Snooze
+	for rows.Next() {
+		var snooze Snooze
+		if err := rows.Scan(&snooze.ServiceID, &snooze.Until, &snooze.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snooze: %w", err)
+		}
+		if snooze.Until.After(now) {
+			snoozes = append(snoozes, snooze)
+		}
+	}
+	return snoozes, rows.Err()
+}
+
+// DeleteSnooze clears a service's snooze.
+func (s *SQLiteStore) DeleteSnooze(ctx context.Context, serviceID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM snoozed_updates WHERE service_id = ?`, serviceID)
+	if err != nil {
+		return fmt.Errorf("failed to delete snooze: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("snooze %w: %s", ErrNotFound, serviceID)
+	}
+	return nil
+}
+
+// PauseGroup puts a target group's updates on hold. Pausing a paused group
+// keeps its original pause time.
+func (s *SQLiteStore) PauseGroup(ctx context.Context, pause *GroupPause) error {
+	if pause.CreatedAt.IsZero() {
+		pause.CreatedAt = time.Now()
+	}
+	query := `INSERT INTO paused_groups (name, created_at) VALUES (?, ?) ON CONFLICT(name) DO NOTHING`
+	if _, err := s.db.ExecContext(ctx, query, pause.Group, pause.CreatedAt); err != nil {
+		return fmt.Errorf("failed to pause group: %w", err)
+	}
+	return nil
+}
+
+// ListPausedGroups retrieves all paused target groups.
+func (s *SQLiteStore) ListPausedGroups(ctx context.Context) ([]GroupPause, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, created_at FROM paused_groups ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list paused groups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var pauses []GroupPause
+	for rows.Next() {
+		var pause GroupPause
+		if err := rows.Scan(&pause.Group, &pause.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan paused group: %w", err)
+		}
+		pauses = append(pauses, pause)
+	}
+	return pauses, rows.Err()
+}
+
+// ResumeGroup lifts a target group's pause.
+func (s *SQLiteStore) ResumeGroup(ctx context.Context, group string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM paused_groups WHERE name = ?`, group)
+	if err != nil {
+		return fmt.Errorf("failed to resume group: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("paused group %w: %s", ErrNotFound, group)
+	}
+	return nil
+}
+
+// SaveScheduledRun stores a scheduled run, replacing the one with the same ID.
+func (s *SQLiteStore) SaveScheduledRun(ctx context.Context, run *ScheduledRun) error {
+	if run.CreatedAt.IsZero() {
+		run.CreatedAt = time.Now()
+	}
+	serviceIDs := run.ServiceIDs
+	if serviceIDs == nil {
+		serviceIDs = []string{}
+	}
+	serviceIDsJSON, err := json.Marshal(serviceIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal service IDs: %w", err)
+	}
+	labelsJSON := ""
+	if len(run.Labels) > 0 {
+		data, err := json.Marshal(run.Labels)
+		if err != nil {
+			return fmt.Errorf("failed to marshal run labels: %w", err)
+		}
+		labelsJSON = string(data)
+	}
+	query := `
+		INSERT INTO scheduled_runs (
+			id, run_at, mode, target, group_name, service_ids_json, force, pull_only,
+			created_by, created_at, status, run_id, note, labels_json
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			run_at = excluded.run_at,
+			mode = excluded.mode,
+			target = excluded.target,
+			group_name = excluded.group_name,
+			service_ids_json = excluded.service_ids_json,
+			force = excluded.force,
+			pull_only = excluded.pull_only,
+			status = excluded.status,
+			run_id = excluded.run_id,
+			note = excluded.note,
+			labels_json = excluded.labels_json
+	`
+	if _, err := s.db.ExecContext(ctx, query,
+		run.ID, run.RunAt, run.Mode, run.Target, run.Group, string(serviceIDsJSON), run.Force, run.PullOnly,
+		run.CreatedBy, run.CreatedAt, string(run.Status), run.RunID, run.Note, labelsJSON,
+	); err != nil {
+		return fmt.Errorf("failed to save scheduled run: %w", err)
+	}
+	return nil
+}
+
+// ListScheduledRuns retrieves all scheduled runs, earliest first.
+func (s *SQLiteStore) ListScheduledRuns(ctx context.Context) ([]ScheduledRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, run_at, mode, target, group_name, service_ids_json, force, pull_only,
+			   created_by, created_at, status, run_id, note, labels_json
+		FROM scheduled_runs
+		ORDER BY run_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled runs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []ScheduledRun
+	for rows.Next() {
+		var run ScheduledRun
+		var serviceIDsJSON, status, labelsJSON string
+		if err := rows.Scan(&run.ID, &run.RunAt, &run.Mode, &run.Target, &run.Group, &serviceIDsJSON,
+			&run.Force, &run.PullOnly, &run.CreatedBy, &run.CreatedAt, &status, &run.RunID,
+			&run.Note, &labelsJSON); err != nil {
+			return nil, fmt.Errorf("failed to scan scheduled run: %w", err)
+		}
+		if err := json.Unmarshal([]byte(serviceIDsJSON), &run.ServiceIDs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal service IDs: %w", err)
+		}
+		if labelsJSON != "" {
+			_ = json.Unmarshal([]byte(labelsJSON), &run.Labels)
+		}
+		run.Status = ScheduledRunStatus(status)
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// DeleteScheduledRun removes a scheduled run.
+func (s *SQLiteStore) DeleteScheduledRun(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM scheduled_runs WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete scheduled run: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("scheduled run %w: %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// RelinkTarget merges target oldID into newID in one transaction.
+func (s *SQLiteStore) RelinkTarget(ctx context.Context, oldID, newID string, serviceRenames map[string]string) (int, error) {
+	if oldID == newID {
+		return 0, fmt.Errorf("cannot relink target %s to itself", oldID)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin relink: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	serviceIDs := func(targetID string) (map[string]string, error) {
+		var exists int
+		if err := tx.QueryRowContext(ctx, `SELECT 1 FROM targets WHERE id = ?`, targetID).Scan(&exists); err == sql.ErrNoRows {
+			return nil, fmt.Errorf("target %w: %s", ErrNotFound, targetID)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get target %s: %w", targetID, err)
+		}
+		rows, err := tx.QueryContext(ctx, `SELECT name, id FROM services WHERE target_id = ?`, targetID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services of %s: %w", targetID, err)
+		}
+		defer func() { _ = rows.Close() }()
+		ids := make(map[string]string)
+		for rows.Next() {
+			var name, id string
+			if err := rows.Scan(&name, &id); err != nil {
+				return nil, fmt.Errorf("failed to scan service: %w", err)
+			}
+			ids[name] = id
+		}
+		return ids, rows.Err()
+	}
+	oldServices, err := serviceIDs(oldID)
+	if err != nil {
+		return 0, err
+	}
+	newServices, err := serviceIDs(newID)
+	if err != nil {
+		return 0, err
+	}
+	moves, err := relinkServices(oldServices, newServices, serviceRenames, func(serviceID string) (bool, error) {
+		var n int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM update_history WHERE service_id = ?`, serviceID).Scan(&n)
+		return n > 0, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	moved := 0
+	addAlias := func(oldID, newID, kind string) error {
+		if _, err := tx.ExecContext(ctx, `UPDATE id_aliases SET new_id = ? WHERE new_id = ?`, newID, oldID); err != nil {
+			return fmt.Errorf("failed to update aliases: %w", err)
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO id_aliases (old_id, new_id, kind, created_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(old_id) DO UPDATE SET new_id = excluded.new_id, kind = excluded.kind, created_at = excluded.created_at
+		`, oldID, newID, kind, now)
+		if err != nil {
+			return fmt.Errorf("failed to save alias: %w", err)
+		}
+		return nil
+	}
+	for oldServiceID, newServiceID := range moves {
+		result, err := tx.ExecContext(ctx, `UPDATE update_history SET target_id = ?, service_id = ? WHERE service_id = ?`, newID, newServiceID, oldServiceID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to move history: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		moved += int(n)
+		// Holds of the new service win over those of the old one.
+		for _, table := range []string{"ignored_updates", "snoozed_updates"} {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE OR IGNORE %s SET service_id = ? WHERE service_id = ?`, table), newServiceID, oldServiceID); err != nil {
+				return 0, fmt.Errorf("failed to move %s: %w", table, err)
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE service_id = ?`, table), oldServiceID); err != nil {
+				return 0, fmt.Errorf("failed to move %s: %w", table, err)
+			}
+		}
+		if err := addAlias(oldServiceID, newServiceID, "service"); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE OR IGNORE settings SET key = ? WHERE key = ?`, ConfigHashSettingKey(newID), ConfigHashSettingKey(oldID)); err != nil {
+		return 0, fmt.Errorf("failed to move config hash: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM settings WHERE key = ?`, ConfigHashSettingKey(oldID)); err != nil {
+		return 0, fmt.Errorf("failed to move config hash: %w", err)
+	}
+	if err := addAlias(oldID, newID, "target"); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM targets WHERE id = ?`, oldID); err != nil {
+		return 0, fmt.Errorf("failed to delete target %s: %w", oldID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit relink: %w", err)
+	}
+	return moved, nil
+}
+
+// ResolveAlias returns the ID id was relinked to.
+func (s *SQLiteStore) ResolveAlias(ctx context.Context, id string) (string, error) {
+	var newID string
+	err := s.db.QueryRowContext(ctx, `SELECT new_id FROM id_aliases WHERE old_id = ?`, id).Scan(&newID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("alias %w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve alias: %w", err)
+	}
+	return newID, nil
+}
+
+// ListAliases retrieves all ID aliases, newest first.
+func (s *SQLiteStore) ListAliases(ctx context.Context) ([]IDAlias, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT old_id, new_id, kind, created_at FROM id_aliases ORDER BY created_at DESC, old_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list aliases: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var aliases []IDAlias
+	for rows.Next() {
+		var alias IDAlias
+		if err := rows.Scan(&alias.OldID, &alias.NewID, &alias.Kind, &alias.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan alias: %w", err)
+		}
+		aliases = append(aliases, alias)
+	}
+	return aliases, rows.Err()
+}
@@ -2,11 +2,16 @@ package state
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/redact"
 )
 
 func TestSQLiteStoreReusesIDsWhenTargetPathChanges(t *testing.T) {
@@ -94,3 +99,438 @@ func TestSQLiteStoreReusesIDsWhenTargetPathChanges(t *testing.T) {
 		t.Fatalf("SaveUpdateResult failed: %v", err)
 	}
 }
+
+func TestSQLiteStoreMigratesUpdateHistoryAttempts(t *testing.T) {
+	ctx := context.Background()
+	dbPath := filepath.Join(t.TempDir(), "state.db")
+
+	// Simulate a database created before the attempts column existed.
+	legacy, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("open legacy db: %v", err)
+	}
+	if _, err := legacy.Exec(`CREATE TABLE update_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		target_id TEXT NOT NULL,
+		service_id TEXT NOT NULL,
+		service_name TEXT NOT NULL,
+		old_digest TEXT NOT NULL,
+		new_digest TEXT NOT NULL,
+		success BOOLEAN NOT NULL,
+		error TEXT,
+		probe_results_json TEXT,
+		rollback_performed BOOLEAN NOT NULL DEFAULT 0,
+		rollback_digest TEXT,
+		started_at DATETIME NOT NULL,
+		completed_at DATETIME NOT NULL
+	)`); err != nil {
+		t.Fatalf("create legacy table: %v", err)
+	}
+	_ = legacy.Close()
+
+	store, err := NewSQLiteStore(dbPath, logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	// A second run must be a no-op.
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("second Initialize failed: %v", err)
+	}
+
+	result := &UpdateResult{
+		TargetID:    "target-1",
+		ServiceID:   "service-1",
+		ServiceName: "web",
+		OldDigest:   "sha256:old",
+		NewDigest:   "sha256:new",
+		Image:       "library/nginx",
+		Tag:         "1.27",
+		Registry:    "docker.io",
+		Platform:    "linux/arm64",
+		Success:     true,
+		Attempts:    3,
+		StartedAt:   time.Now().Add(-time.Second),
+		CompletedAt: time.Now(),
+	}
+	if err := store.SaveUpdateResult(ctx, result); err != nil {
+		t.Fatalf("SaveUpdateResult failed: %v", err)
+	}
+
+	history, err := store.GetUpdateHistory(ctx, 10)
+	if err != nil {
+		t.Fatalf("GetUpdateHistory failed: %v", err)
+	}
+	if len(history) != 1 || history[0].Attempts != 3 {
+		t.Fatalf("expected one entry with 3 attempts, got %+v", history)
+	}
+	got := history[0]
+	if got.Image != "library/nginx" || got.Tag != "1.27" || got.Registry != "docker.io" || got.Platform != "linux/arm64" {
+		t.Fatalf("image fields not round-tripped: %+v", got)
+	}
+}
+
+func TestSQLiteStoreFiltersHistoryByResultCode(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	target := &Target{ID: "target-1", Type: TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: DefaultLabels()}
+	if err := store.SaveTarget(ctx, target); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	service := &Service{ID: "service-1", TargetID: target.ID, Name: "web", Image: "nginx:latest", Labels: DefaultLabels()}
+	if err := store.SaveService(ctx, service); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+
+	now := time.Now()
+	for i, code := range []ResultCode{ResultSuccess, ResultPullFailed, ResultSkippedSelfUpdate} {
+		result := &UpdateResult{
+			TargetID:     "target-1",
+			ServiceID:    "service-1",
+			ServiceName:  "web",
+			OldDigest:    "sha256:old",
+			NewDigest:    "sha256:new",
+			Success:      code == ResultSuccess,
+			ProbeResults: []ProbeResult{},
+			ResultCode:   code,
+			Timings:      UpdateTimings{PullMs: int64(i+1) * 100, DowntimeMs: 50},
+			StartedAt:    now,
+			CompletedAt:  now.Add(time.Duration(i) * time.Second),
+		}
+		if code == ResultSkippedSelfUpdate {
+			result.ReasonCode = ReasonAggressivePolicy
+		}
+		if code == ResultSuccess {
+			result.Resources = &ResourceSnapshot{
+				Before:              &ResourceUsage{CPUPercent: 1.5, MemoryBytes: 100 << 20},
+				After:               &ResourceUsage{CPUPercent: 2, MemoryBytes: 180 << 20, MemoryLimit: 1 << 30},
+				MemoryChangePercent: 80,
+				MemoryWarning:       true,
+			}
+		}
+		if code == ResultPullFailed {
+			result.Kind, result.PreviousTag = UpdateKindVersionChange, "1.25"
+			result.ConfigChanges = []ConfigChange{
+				{Field: "mount", Key: "/data", Before: "volume data"},
+				{Field: "env", Key: "DB_PASSWORD", Before: "hunter2", After: "hunter3"},
+			}
+		}
+		if err := store.SaveUpdateResult(ctx, result); err != nil {
+			t.Fatalf("SaveUpdateResult failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query HistoryQuery
+		want  ResultCode
+	}{
+		{HistoryQuery{Result: "failed", Limit: 10}, ResultPullFailed},
+		{HistoryQuery{Result: "skipped", Limit: 10}, ResultSkippedSelfUpdate},
+		{HistoryQuery{ResultCode: ResultPullFailed, Limit: 10}, ResultPullFailed},
+		{HistoryQuery{Since: now.Add(1500 * time.Millisecond), Limit: 10}, ResultSkippedSelfUpdate},
+		{HistoryQuery{Kind: string(UpdateKindVersionChange), Limit: 10}, ResultPullFailed},
+		{HistoryQuery{ReasonCode: ReasonAggressivePolicy, Limit: 10}, ResultSkippedSelfUpdate},
+	}
+	for _, tt := range tests {
+		results, err := store.ListUpdateHistory(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("ListUpdateHistory(%+v) failed: %v", tt.query, err)
+		}
+		if len(results) != 1 || results[0].ResultCode != tt.want {
+			t.Fatalf("ListUpdateHistory(%+v) = %+v, want one %s entry", tt.query, results, tt.want)
+		}
+	}
+
+	results, err := store.ListUpdateHistory(ctx, HistoryQuery{ResultCode: ResultPullFailed, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUpdateHistory failed: %v", err)
+	}
+	if results[0].Kind != UpdateKindVersionChange || results[0].PreviousTag != "1.25" {
+		t.Errorf("expected the version change to round-trip, got %q from %q", results[0].Kind, results[0].PreviousTag)
+	}
+	if changes := results[0].ConfigChanges; len(changes) != 2 || changes[0].Key != "/data" {
+		t.Errorf("expected the config changes to round-trip, got %+v", changes)
+	} else if changes[1].Before != redact.Mask || changes[1].After != redact.Mask {
+		t.Errorf("expected the secret env values to be masked, got %+v", changes[1])
+	}
+	if results[0].Resources != nil {
+		t.Errorf("expected no resource usage for a failed update, got %+v", results[0].Resources)
+	}
+	if succeeded, err := store.ListUpdateHistory(ctx, HistoryQuery{ResultCode: ResultSuccess, Limit: 10}); err != nil || len(succeeded) != 1 {
+		t.Fatalf("expected one success, got %d (%v)", len(succeeded), err)
+	} else if got := succeeded[0].Resources; got == nil || got.After == nil || got.After.MemoryBytes != 180<<20 || !got.MemoryWarning {
+		t.Errorf("expected the resource usage to round-trip, got %+v", got)
+	}
+	if digests, err := store.ListUpdateHistory(ctx, HistoryQuery{Kind: "digest", Limit: 10}); err != nil || len(digests) != 2 {
+		t.Errorf("expected 2 digest refreshes, got %d (%v)", len(digests), err)
+	}
+	if got := results[0].Timings; got != (UpdateTimings{PullMs: 200, DowntimeMs: 50}) {
+		t.Errorf("expected the timings to round-trip, got %+v", got)
+	}
+}
+
+func TestSQLiteStoreUsers(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	if _, err := store.GetUser(ctx, "alice"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	alice := &User{Username: "alice", PasswordHash: "hash-1", Role: "admin"}
+	if err := store.SaveUser(ctx, alice); err != nil {
+		t.Fatalf("SaveUser failed: %v", err)
+	}
+	if err := store.SaveUser(ctx, &User{Username: "bob", PasswordHash: "hash-2", Role: "viewer"}); err != nil {
+		t.Fatalf("SaveUser failed: %v", err)
+	}
+
+	alice.Role = "operator"
+	alice.PasswordHash = "hash-3"
+	if err := store.SaveUser(ctx, alice); err != nil {
+		t.Fatalf("SaveUser update failed: %v", err)
+	}
+	got, err := store.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetUser failed: %v", err)
+	}
+	if got.Role != "operator" || got.PasswordHash != "hash-3" || got.CreatedAt.IsZero() {
+		t.Errorf("unexpected user: %+v", got)
+	}
+
+	users, err := store.ListUsers(ctx)
+	if err != nil {
+		t.Fatalf("ListUsers failed: %v", err)
+	}
+	if len(users) != 2 || users[0].Username != "alice" || users[1].Username != "bob" {
+		t.Fatalf("unexpected users: %+v", users)
+	}
+
+	if err := store.DeleteUser(ctx, "bob"); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	if err := store.DeleteUser(ctx, "bob"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestSQLiteStoreKeepsUpdateErrors(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := store.SaveTarget(ctx, &Target{ID: "target-1", Type: TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	if err := store.SaveService(ctx, &Service{ID: "service-1", TargetID: "target-1", Name: "web", Image: "nginx:latest", Labels: DefaultLabels()}); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+
+	now := time.Now()
+	result := &UpdateResult{
+		TargetID:     "target-1",
+		ServiceID:    "service-1",
+		ServiceName:  "web",
+		ProbeResults: []ProbeResult{},
+		ResultCode:   ResultRecreateFailed,
+		StartedAt:    now,
+		CompletedAt:  now,
+	}
+	result.SetError(errors.New("failed to recreate service: exit status 1"), ResultRecreateFailed)
+	if err := store.SaveUpdateResult(ctx, result); err != nil {
+		t.Fatalf("SaveUpdateResult failed: %v", err)
+	}
+
+	// A row written before error_code existed.
+	if _, err := store.db.ExecContext(ctx, `INSERT INTO update_history (
+		target_id, service_id, service_name, old_digest, new_digest, success, error,
+		probe_results_json, rollback_digest, started_at, completed_at, result_code
+	) VALUES ('target-1', 'service-1', 'web', '', '', 0, 'failed to pull image', '[]', '', ?, ?, 'pull_failed')`,
+		now, now.Add(time.Second)); err != nil {
+		t.Fatalf("insert legacy row: %v", err)
+	}
+
+	history, err := store.GetUpdateHistory(ctx, 10)
+	if err != nil {
+		t.Fatalf("GetUpdateHistory failed: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(history))
+	}
+	if got := history[0]; got.ErrorMessage != "failed to pull image" || got.ErrorCode != ResultPullFailed {
+		t.Errorf("expected the legacy error classified by its result code, got %q (%s)", got.ErrorMessage, got.ErrorCode)
+	}
+	if got := history[1]; got.ErrorMessage != "failed to recreate service: exit status 1" || got.ErrorCode != ResultRecreateFailed {
+		t.Errorf("expected the error to round-trip, got %q (%s)", got.ErrorMessage, got.ErrorCode)
+	}
+
+	encoded, err := json.Marshal(history[1])
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"error_message":"failed to recreate service: exit status 1","error_code":"recreate_failed"`) {
+		t.Errorf("expected the error in JSON, got %s", encoded)
+	}
+}
+
+func TestSQLiteStoreIgnoredUpdates(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := store.SaveTarget(ctx, &Target{ID: "target-1", Type: TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	if err := store.SaveService(ctx, &Service{ID: "service-1", TargetID: "target-1", Name: "web", Image: "nginx:latest", Labels: DefaultLabels()}); err != nil {
+		t.Fatalf("SaveService failed: %v", err)
+	}
+
+	if err := store.SaveIgnoredUpdate(ctx, &IgnoredUpdate{ServiceID: "service-1", Digest: "sha256:one"}); err != nil {
+		t.Fatalf("SaveIgnoredUpdate failed: %v", err)
+	}
+	// A second ignore replaces the first.
+	if err := store.SaveIgnoredUpdate(ctx, &IgnoredUpdate{ServiceID: "service-1", Digest: "sha256:two", Tag: "1.27"}); err != nil {
+		t.Fatalf("SaveIgnoredUpdate failed: %v", err)
+	}
+	ignores, err := store.ListIgnoredUpdates(ctx)
+	if err != nil {
+		t.Fatalf("ListIgnoredUpdates failed: %v", err)
+	}
+	if len(ignores) != 1 || ignores[0].Digest != "sha256:two" || ignores[0].Tag != "1.27" || ignores[0].CreatedAt.IsZero() {
+		t.Fatalf("unexpected ignored updates: %+v", ignores)
+	}
+
+	if err := store.DeleteIgnoredUpdate(ctx, "service-1"); err != nil {
+		t.Fatalf("DeleteIgnoredUpdate failed: %v", err)
+	}
+	if err := store.DeleteIgnoredUpdate(ctx, "service-1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestSQLiteStoreSnoozes(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := store.SaveTarget(ctx, &Target{ID: "target-1", Type: TargetTypeCompose, Name: "app", Path: "/srv/app/compose.yml", Labels: DefaultLabels()}); err != nil {
+		t.Fatalf("SaveTarget failed: %v", err)
+	}
+	for _, id := range []string{"web", "db"} {
+		if err := store.SaveService(ctx, &Service{ID: id, TargetID: "target-1", Name: id, Image: "nginx:latest", Labels: DefaultLabels()}); err != nil {
+			t.Fatalf("SaveService failed: %v", err)
+		}
+	}
+
+	now := time.Now()
+	if err := store.SaveSnooze(ctx, &Snooze{ServiceID: "web", Until: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveSnooze failed: %v", err)
+	}
+	if err := store.SaveSnooze(ctx, &Snooze{ServiceID: "db", Until: now.Add(-time.Minute).UTC()}); err != nil {
+		t.Fatalf("SaveSnooze failed: %v", err)
+	}
+	snoozes, err := store.ListSnoozes(ctx, now)
+	if err != nil {
+		t.Fatalf("ListSnoozes failed: %v", err)
+	}
+	if len(snoozes) != 1 || snoozes[0].ServiceID != "web" {
+		t.Fatalf("expected only the active snooze, got %+v", snoozes)
+	}
+
+	if err := store.DeleteSnooze(ctx, "web"); err != nil {
+		t.Fatalf("DeleteSnooze failed: %v", err)
+	}
+	if err := store.DeleteSnooze(ctx, "web"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound deleting twice, got %v", err)
+	}
+}
+
+func TestSQLiteStorePausedGroups(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "state.db"), logging.Default())
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+
+	pausedAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	if err := store.PauseGroup(ctx, &GroupPause{Group: "media", CreatedAt: pausedAt}); err != nil {
+		t.Fatalf("PauseGroup failed: %v", err)
+	}
+	if err := store.PauseGroup(ctx, &GroupPause{Group: "media"}); err != nil {
+		t.Fatalf("PauseGroup failed pausing twice: %v", err)
+	}
+	pauses, err := store.ListPausedGroups(ctx)
+	if err != nil {
+		t.Fatalf("ListPausedGroups failed: %v", err)
+	}
+	if len(pauses) != 1 || pauses[0].Group != "media" || !pauses[0].CreatedAt.Equal(pausedAt) {
+		t.Fatalf("expected media paused since the first pause, got %+v", pauses)
+	}
+
+	if err := store.ResumeGroup(ctx, "media"); err != nil {
+		t.Fatalf("ResumeGroup failed: %v", err)
+	}
+	if err := store.ResumeGroup(ctx, "media"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound resuming twice, got %v", err)
+	}
+}
+
+func TestParseSnoozeDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"12h", 12 * time.Hour, true},
+		{"3d", 72 * time.Hour, true},
+		{" 90m ", 90 * time.Minute, true},
+		{"0d", 0, false},
+		{"400d", 0, false},
+		{"30s", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseSnoozeDuration(tt.value)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("%q: got %v, %v", tt.value, got, err)
+		}
+	}
+}
@@ -2,10 +2,16 @@ package state
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
-// Store defines the interface for state persistence
+// ErrNotFound is returned when a lookup by ID matches nothing.
+var ErrNotFound = errors.New("not found")
+
+// Store defines the interface for state persistence. It combines the
+// narrower stores below; a backend serving only part of Bulwark, or a
+// component needing only part of the state, can use those on their own.
 type Store interface {
 	// Initialize the store (create tables, run migrations)
 	Initialize(ctx context.Context) error
@@ -13,39 +19,92 @@ type Store interface {
 	// Close the store connection
 	Close() error
 
-	// Target operations
+	// Ping checks that the database can still be queried
+	Ping(ctx context.Context) error
+
+	TargetStore
+	HistoryStore
+	RunStore
+	SettingsStore
+	UserStore
+	HoldStore
+}
+
+// TargetStore persists discovered targets and their services.
+type TargetStore interface {
 	SaveTarget(ctx context.Context, target *Target) error
 	GetTarget(ctx context.Context, id string) (*Target, error)
 	GetTargetByName(ctx context.Context, name string) (*Target, error)
 	ListTargets(ctx context.Context) ([]Target, error)
 	DeleteTarget(ctx context.Context, id string) error
+	PruneStaleTargets(ctx context.Context, olderThan time.Time) error
 
-	// Service operations
 	SaveService(ctx context.Context, service *Service) error
 	GetService(ctx context.Context, id string) (*Service, error)
 	GetServicesByTarget(ctx context.Context, targetID string) ([]Service, error)
 	DeleteService(ctx context.Context, id string) error
 
-	// Update history operations
+	// RelinkTarget merges target oldID into newID: history, ignored updates
+	// and snoozes move to newID's services of the same name, or the name
+	// serviceRenames maps it to. oldID is then deleted and recorded as an
+	// alias of newID. It returns the number of history entries moved.
+	RelinkTarget(ctx context.Context, oldID, newID string, serviceRenames map[string]string) (int, error)
+	// ResolveAlias returns the ID id was relinked to, or ErrNotFound.
+	ResolveAlias(ctx context.Context, id string) (string, error)
+	ListAliases(ctx context.Context) ([]IDAlias, error)
+}
+
+// HistoryStore persists update history.
+type HistoryStore interface {
 	SaveUpdateResult(ctx context.Context, result *UpdateResult) error
+	GetUpdateResult(ctx context.Context, id int64) (*UpdateResult, error)
 	GetUpdateHistory(ctx context.Context, limit int) ([]UpdateResult, error)
 	GetUpdateHistoryByTarget(ctx context.Context, targetID string, limit int) ([]UpdateResult, error)
 	GetUpdateHistoryByService(ctx context.Context, serviceID string, limit int) ([]UpdateResult, error)
 	ListUpdateHistory(ctx context.Context, query HistoryQuery) ([]UpdateResult, error)
 	GetLastSuccessfulUpdate(ctx context.Context, serviceID string) (*UpdateResult, error)
-
-	// Cleanup operations
 	PruneHistory(ctx context.Context, olderThan time.Time) error
-	PruneStaleTargets(ctx context.Context, olderThan time.Time) error
-
-	// Settings operations
-	GetSetting(ctx context.Context, key string) (string, error)
-	SetSetting(ctx context.Context, key, value string) error
+}
 
-	// Run persistence
+// RunStore persists apply runs, their events and scheduled one-shot runs.
+type RunStore interface {
 	SaveRun(ctx context.Context, run *Run) error
 	GetRun(ctx context.Context, id string) (*Run, error)
 	ListRecentRuns(ctx context.Context, limit int) ([]Run, error)
 	SaveRunEvent(ctx context.Context, event *RunEvent) error
 	GetRunEvents(ctx context.Context, runID string) ([]RunEvent, error)
+
+	SaveScheduledRun(ctx context.Context, run *ScheduledRun) error
+	ListScheduledRuns(ctx context.Context) ([]ScheduledRun, error)
+	DeleteScheduledRun(ctx context.Context, id string) error
+}
+
+// SettingsStore persists key-value settings.
+type SettingsStore interface {
+	GetSetting(ctx context.Context, key string) (string, error)
+	SetSetting(ctx context.Context, key, value string) error
+}
+
+// UserStore persists web console user accounts.
+type UserStore interface {
+	SaveUser(ctx context.Context, user *User) error
+	GetUser(ctx context.Context, username string) (*User, error)
+	ListUsers(ctx context.Context) ([]User, error)
+	DeleteUser(ctx context.Context, username string) error
+}
+
+// HoldStore persists what holds updates back: ignored updates, snoozes and
+// paused target groups.
+type HoldStore interface {
+	SaveIgnoredUpdate(ctx context.Context, ignore *IgnoredUpdate) error
+	ListIgnoredUpdates(ctx context.Context) ([]IgnoredUpdate, error)
+	DeleteIgnoredUpdate(ctx context.Context, serviceID string) error
+
+	SaveSnooze(ctx context.Context, snooze *Snooze) error
+	ListSnoozes(ctx context.Context, now time.Time) ([]Snooze, error)
+	DeleteSnooze(ctx context.Context, serviceID string) error
+
+	PauseGroup(ctx context.Context, pause *GroupPause) error
+	ListPausedGroups(ctx context.Context) ([]GroupPause, error)
+	ResumeGroup(ctx context.Context, group string) error
 }
@@ -0,0 +1,123 @@
+// Package telemetry sends opt-in, anonymous usage reports. A report holds
+// coarse counts and the names of features in use, never target, service or
+// image names, paths, addresses or credentials.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// DefaultInterval is how often a report is sent.
+const DefaultInterval = 24 * time.Hour
+
+// Report is exactly what one telemetry report sends.
+type Report struct {
+	// InstallID is a random ID generated once per installation, so reports
+	// of one installation can be told apart from others.
+	InstallID string `json:"install_id"`
+	Version   string `json:"version"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	Profile   string `json:"profile"`
+	// Targets, Services and Updates are counts rounded into ranges such as
+	// "6-20"; Targets and Services are empty before the first plan.
+	Targets  string `json:"targets,omitempty"`
+	Services string `json:"services,omitempty"`
+	Updates  string `json:"updates_30d"`
+	// SuccessRate is the share of the last 30 days' updates that succeeded,
+	// rounded to 10 percent. It is left out without updates.
+	SuccessRate *int     `json:"success_rate_30d,omitempty"`
+	Features    []string `json:"features"`
+}
+
+// buckets are the upper bounds of the ranges counts are reported in.
+var buckets = []int{0, 5, 20, 50, 100, 500}
+
+// Bucket rounds a count into the range it is reported as.
+func Bucket(n int) string {
+	lower := 1
+	for _, upper := range buckets {
+		if n <= upper {
+			if upper == 0 {
+				return "0"
+			}
+			return fmt.Sprintf("%d-%d", lower, upper)
+		}
+		lower = upper + 1
+	}
+	return fmt.Sprintf("%d+", lower)
+}
+
+// SuccessRate returns the percentage of total updates that succeeded,
+// rounded to the nearest 10, or nil without updates.
+func SuccessRate(succeeded, total int) *int {
+	if total <= 0 {
+		return nil
+	}
+	rate := (succeeded*100/total + 5) / 10 * 10
+	return &rate
+}
+
+// Features sorts and deduplicates feature names for a report.
+func Features(names ...string) []string {
+	seen := make(map[string]bool, len(names))
+	features := make([]string, 0, len(names))
+	for _, name := range names {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// NewInstallID returns a random installation ID.
+func NewInstallID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Client sends reports to a telemetry endpoint.
+type Client struct {
+	url  string
+	http *http.Client
+}
+
+// NewClient creates a client that posts reports to url.
+func NewClient(url string) *Client {
+	return &Client{url: url, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Send posts report as JSON.
+func (c *Client) Send(ctx context.Context, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
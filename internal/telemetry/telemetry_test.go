@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBucket(t *testing.T) {
+	for n, want := range map[int]string{
+		0:    "0",
+		1:    "1-5",
+		5:    "1-5",
+		6:    "6-20",
+		50:   "21-50",
+		101:  "101-500",
+		501:  "501+",
+		9000: "501+",
+	} {
+		if got := Bucket(n); got != want {
+			t.Errorf("Bucket(%d) = %q; want %q", n, got, want)
+		}
+	}
+}
+
+func TestSuccessRate(t *testing.T) {
+	if rate := SuccessRate(0, 0); rate != nil {
+		t.Errorf("SuccessRate(0, 0) = %d; want nil", *rate)
+	}
+	for _, tc := range []struct{ succeeded, total, want int }{
+		{10, 10, 100},
+		{94, 100, 90},
+		{95, 100, 100},
+		{1, 3, 30},
+		{0, 4, 0},
+	} {
+		if rate := SuccessRate(tc.succeeded, tc.total); rate == nil || *rate != tc.want {
+			t.Errorf("SuccessRate(%d, %d) = %v; want %d", tc.succeeded, tc.total, rate, tc.want)
+		}
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	got := Features("metrics", "", "auto_update", "metrics")
+	if want := []string{"auto_update", "metrics"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Features() = %v; want %v", got, want)
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	var received Report
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	report := Report{InstallID: "abc", Version: "1.0.0", Updates: "1-5", Features: []string{"metrics"}}
+	if err := NewClient(srv.URL).Send(context.Background(), report); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !reflect.DeepEqual(received, report) {
+		t.Errorf("received %+v; want %+v", received, report)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	if err := NewClient(failing.URL).Send(context.Background(), report); err == nil {
+		t.Error("Send() to a failing endpoint returned no error")
+	}
+}
@@ -0,0 +1,151 @@
+package testharness
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// Service is one service of a compose fixture.
+type Service struct {
+	Name    string
+	Image   string
+	Command []string
+	Labels  map[string]string
+}
+
+// ComposeProject is a compose project written to a temporary directory.
+type ComposeProject struct {
+	Name     string
+	Dir      string
+	File     string
+	Services []Service
+}
+
+type composeFile struct {
+	Services map[string]composeService `yaml:"services"`
+}
+
+type composeService struct {
+	Image   string            `yaml:"image"`
+	Command []string          `yaml:"command,omitempty"`
+	Labels  map[string]string `yaml:"labels,omitempty"`
+}
+
+// NewComposeProject writes a compose.yaml with services to a directory
+// removed when t ends. Every service gets bulwark.enabled=true unless its
+// labels say otherwise.
+func NewComposeProject(t testing.TB, name string, services ...Service) *ComposeProject {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("create project dir: %v", err)
+	}
+	file := composeFile{Services: make(map[string]composeService, len(services))}
+	for i := range services {
+		labels := map[string]string{"bulwark.enabled": "true"}
+		for key, value := range services[i].Labels {
+			labels[key] = value
+		}
+		services[i].Labels = labels
+		file.Services[services[i].Name] = composeService{
+			Image:   services[i].Image,
+			Command: services[i].Command,
+			Labels:  labels,
+		}
+	}
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		t.Fatalf("encode compose file: %v", err)
+	}
+	path := filepath.Join(dir, "compose.yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write compose file: %v", err)
+	}
+	return &ComposeProject{Name: name, Dir: dir, File: path, Services: services}
+}
+
+// Target returns the target discovery would report for the project, with
+// the given current digest per service name. It needs no Docker daemon.
+func (p *ComposeProject) Target(digests map[string]string) state.Target {
+	now := time.Now()
+	target := state.Target{
+		ID:        state.GenerateTargetID(state.TargetTypeCompose, p.Name, p.File),
+		Type:      state.TargetTypeCompose,
+		Name:      p.Name,
+		Path:      p.File,
+		Labels:    state.DefaultLabels(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, svc := range p.Services {
+		target.Services = append(target.Services, state.Service{
+			ID:            state.GenerateServiceID(target.ID, svc.Name),
+			TargetID:      target.ID,
+			Name:          svc.Name,
+			Image:         svc.Image,
+			CurrentDigest: digests[svc.Name],
+			Labels:        discovery.ParseLabels(svc.Labels, svc.Image),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		})
+	}
+	sort.Slice(target.Services, func(i, j int) bool { return target.Services[i].Name < target.Services[j].Name })
+	return target
+}
+
+// Up starts the project with docker compose and takes it down again when t
+// ends.
+func (p *ComposeProject) Up(t testing.TB) {
+	t.Helper()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+		_ = p.compose(ctx, "down", "--volumes", "--remove-orphans")
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if err := p.compose(ctx, "up", "-d", "--wait"); err != nil {
+		t.Fatalf("compose up: %v", err)
+	}
+}
+
+func (p *ComposeProject) compose(ctx context.Context, args ...string) error {
+	args = append([]string{"compose", "-f", p.File, "-p", p.Name}, args...)
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Dir = p.Dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker %v: %w: %s", args, err, out)
+	}
+	return nil
+}
+
+// Discoverer reports a fixed set of targets, standing in for discovery when
+// no Docker daemon is available.
+type Discoverer struct {
+	Targets []state.Target
+}
+
+// Discover returns the fixed targets.
+func (d *Discoverer) Discover(ctx context.Context, basePath string) ([]state.Target, error) {
+	return d.Targets, nil
+}
+
+// DiscoverTarget returns the fixed target with targetID.
+func (d *Discoverer) DiscoverTarget(ctx context.Context, basePath, targetID string) (*state.Target, error) {
+	for i := range d.Targets {
+		if d.Targets[i].ID == targetID || d.Targets[i].Name == targetID {
+			return &d.Targets[i], nil
+		}
+	}
+	return nil, fmt.Errorf("target %w: %s", state.ErrNotFound, targetID)
+}
@@ -0,0 +1,169 @@
+package testharness
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/itsmrshow/bulwark/internal/docker"
+)
+
+// DefaultBaseImage is the image fixtures are built from. Override it with
+// BULWARK_TEST_BASE_IMAGE, e.g. for a mirror.
+const DefaultBaseImage = "busybox:latest"
+
+// Daemon is the Docker daemon integration tests run against, found through
+// DOCKER_HOST like everywhere else in Bulwark.
+type Daemon struct {
+	Client *docker.Client
+	api    *client.Client
+	base   string
+}
+
+// NewDaemon connects to the Docker daemon and pulls the base image if
+// needed. It skips the test when no daemon is reachable, so integration
+// tests pass on machines without Docker.
+func NewDaemon(t testing.TB) *Daemon {
+	t.Helper()
+	dockerClient, err := docker.NewClient()
+	if err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	t.Cleanup(func() { _ = dockerClient.Close() })
+	api, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	t.Cleanup(func() { _ = api.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := dockerClient.Ping(ctx); err != nil {
+		t.Skipf("docker unavailable: %v", err)
+	}
+	base := strings.TrimSpace(os.Getenv("BULWARK_TEST_BASE_IMAGE"))
+	if base == "" {
+		base = DefaultBaseImage
+	}
+	if _, _, err := api.ImageInspectWithRaw(ctx, base); err != nil {
+		out, err := api.ImagePull(ctx, base, types.ImagePullOptions{})
+		if err != nil {
+			t.Skipf("base image %s unavailable: %v", base, err)
+		}
+		err = drain(out)
+		if err != nil {
+			t.Skipf("base image %s unavailable: %v", base, err)
+		}
+	}
+	return &Daemon{Client: dockerClient, api: api, base: base}
+}
+
+// PushImage builds an image from the base image that runs command, pushes
+// it to repository:tag on reg and returns its manifest digest. Each build
+// is labelled with version, so pushes of one tag differ in digest.
+func (d *Daemon) PushImage(t testing.TB, reg *Registry, repository, tag, version string, command ...string) string {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cmd, _ := json.Marshal(command)
+	dockerfile := fmt.Sprintf("FROM %s\nLABEL bulwark.test.version=%q\nCMD %s\n", d.base, version, cmd)
+	buildContext, err := tarFile("Dockerfile", []byte(dockerfile))
+	if err != nil {
+		t.Fatalf("build context: %v", err)
+	}
+	ref := reg.Image(repository, tag)
+	resp, err := d.api.ImageBuild(ctx, buildContext, types.ImageBuildOptions{Tags: []string{ref}, Remove: true})
+	if err != nil {
+		t.Fatalf("build %s: %v", ref, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := drain(resp.Body); err != nil {
+		t.Fatalf("build %s: %v", ref, err)
+	}
+
+	auth := base64.URLEncoding.EncodeToString([]byte("{}"))
+	out, err := d.api.ImagePush(ctx, ref, types.ImagePushOptions{RegistryAuth: auth})
+	if err != nil {
+		t.Fatalf("push %s: %v", ref, err)
+	}
+	if err := drain(out); err != nil {
+		t.Fatalf("push %s: %v", ref, err)
+	}
+	digest := reg.Digest(repository, tag)
+	if digest == "" {
+		t.Fatalf("push %s: registry has no manifest for the tag", ref)
+	}
+	return digest
+}
+
+// ContainerLabel returns a label of the image the container of a compose
+// service runs, e.g. bulwark.test.version.
+func (d *Daemon) ContainerLabel(t testing.TB, project, service, label string) string {
+	t.Helper()
+	ctx := context.Background()
+	containers, err := d.Client.ListContainers(ctx, false)
+	if err != nil {
+		t.Fatalf("list containers: %v", err)
+	}
+	for _, c := range containers {
+		if c.Labels["com.docker.compose.project"] != project || c.Labels["com.docker.compose.service"] != service {
+			continue
+		}
+		inspect, _, err := d.api.ImageInspectWithRaw(ctx, c.ImageID)
+		if err != nil {
+			t.Fatalf("inspect image of %s/%s: %v", project, service, err)
+		}
+		if inspect.Config == nil {
+			return ""
+		}
+		return inspect.Config.Labels[label]
+	}
+	t.Fatalf("no running container for %s/%s", project, service)
+	return ""
+}
+
+// drain reads a JSON message stream of the Docker API to its end and
+// returns the first error it reports.
+func drain(stream io.ReadCloser) error {
+	defer func() { _ = stream.Close() }()
+	decoder := json.NewDecoder(stream)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("%s", msg.Error)
+		}
+	}
+}
+
+func tarFile(name string, content []byte) (io.Reader, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}); err != nil {
+		return nil, err
+	}
+	if _, err := tw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
@@ -0,0 +1,85 @@
+//go:build integration
+
+package testharness
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/itsmrshow/bulwark/internal/discovery"
+	"github.com/itsmrshow/bulwark/internal/executor"
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/policy"
+)
+
+// TestUpdateLoop drives one compose service through plan, apply, probe and
+// rollback against a real daemon and the fake registry: a healthy release
+// is applied, and a release failing its log probe is rolled back.
+func TestUpdateLoop(t *testing.T) {
+	logger := logging.Default()
+	daemon := NewDaemon(t)
+	reg := NewRegistry(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	daemon.PushImage(t, reg, "bulwark/app", "latest", "1", "sh", "-c", "echo ready; sleep 3600")
+	project := NewComposeProject(t, "bulwark-it", Service{
+		Name:  "app",
+		Image: reg.Image("bulwark/app", "latest"),
+		Labels: map[string]string{
+			"bulwark.policy":            "aggressive",
+			"bulwark.probe.type":        "log",
+			"bulwark.probe.log_pattern": "ready",
+			"bulwark.probe.window_sec":  "10",
+		},
+	})
+	project.Up(t)
+
+	engine := policy.NewEngine(logger)
+	plans := planner.NewPlanner(logger, discovery.NewDiscoverer(logger, daemon.Client), newRegistryClient(reg), engine)
+	exec := executor.NewExecutor(daemon.Client, engine, nil, logger, false)
+
+	apply := func(want string) bool {
+		t.Helper()
+		plan, err := plans.BuildPlan(ctx, planner.PlanOptions{Root: project.Dir, TargetFilter: project.Name})
+		if err != nil {
+			t.Fatalf("build plan: %v", err)
+		}
+		if len(plan.Items) != 1 {
+			t.Fatalf("expected one plan item, got %d", len(plan.Items))
+		}
+		item := plan.Items[0]
+		if !item.UpdateAvailable || !item.Allowed {
+			t.Fatalf("expected an allowed update, got %+v", item)
+		}
+		if item.RemoteDigest != want {
+			t.Fatalf("remote digest = %s, want %s", item.RemoteDigest, want)
+		}
+		result := exec.ExecuteUpdate(ctx, item.Target, item.Service, item.RemoteDigest)
+		if !result.Success {
+			if !result.RollbackPerformed {
+				t.Fatalf("update failed without a rollback: %s", result.ErrorMessage)
+			}
+			return false
+		}
+		return true
+	}
+
+	healthy := daemon.PushImage(t, reg, "bulwark/app", "latest", "2", "sh", "-c", "echo ready; sleep 3600")
+	if !apply(healthy) {
+		t.Fatal("expected the healthy release to apply")
+	}
+	if got := daemon.ContainerLabel(t, project.Name, "app", "bulwark.test.version"); got != "2" {
+		t.Fatalf("running version = %q, want 2", got)
+	}
+
+	broken := daemon.PushImage(t, reg, "bulwark/app", "latest", "3", "sh", "-c", "echo starting; sleep 3600")
+	if apply(broken) {
+		t.Fatal("expected the release failing its probe to be rolled back")
+	}
+	if got := daemon.ContainerLabel(t, project.Name, "app", "bulwark.test.version"); got != "2" {
+		t.Fatalf("running version after rollback = %q, want 2", got)
+	}
+}
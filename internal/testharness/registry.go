@@ -0,0 +1,319 @@
+// Package testharness provides fixtures for exercising Bulwark end to end:
+// a fake OCI registry, throwaway compose projects and a Docker daemon
+// helper. Tests that need a daemon are built with the integration tag:
+//
+//	go test -tags integration ./...
+package testharness
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Manifest media types served by the fake registry.
+const (
+	MediaTypeManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeConfig   = "application/vnd.docker.container.image.v1+json"
+)
+
+// Registry is an in-memory OCI distribution registry served over TLS on
+// 127.0.0.1. It answers the pull API Bulwark's registry client uses and the
+// push API of the Docker daemon, which treats loopback registries as
+// insecure and so accepts the self-signed certificate.
+type Registry struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	blobs     map[string][]byte            // digest -> content
+	manifests map[string]map[string]string // repository -> tag or digest -> manifest digest
+	types     map[string]string            // manifest digest -> media type
+	uploads   map[string][]byte            // upload ID -> content so far
+	published int
+	uploadSeq int
+
+	manifestRequests atomic.Int64
+}
+
+// NewRegistry starts a fake registry that is closed when t ends.
+func NewRegistry(t testing.TB) *Registry {
+	t.Helper()
+	r := &Registry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string]map[string]string),
+		types:     make(map[string]string),
+		uploads:   make(map[string][]byte),
+	}
+	r.server = httptest.NewTLSServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// Host returns the registry's host:port, as used in image references.
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.server.URL, "https://")
+}
+
+// Image returns the reference of repository:tag on this registry.
+func (r *Registry) Image(repository, tag string) string {
+	return r.Host() + "/" + repository + ":" + tag
+}
+
+// HTTPClient returns a client that trusts the registry's certificate.
+func (r *Registry) HTTPClient() *http.Client {
+	return r.server.Client()
+}
+
+// ManifestRequests counts the manifest requests served so far.
+func (r *Registry) ManifestRequests() int {
+	return int(r.manifestRequests.Load())
+}
+
+// Publish pushes a new, minimal image to repository:tag and returns its
+// manifest digest. Every call yields a different digest, which is all a plan
+// needs to see an update; images to run are pushed with a Daemon instead.
+func (r *Registry) Publish(repository, tag string) string {
+	r.mu.Lock()
+	r.published++
+	n := r.published
+	r.mu.Unlock()
+
+	config, _ := json.Marshal(map[string]any{
+		"architecture": "amd64",
+		"os":           "linux",
+		"config":       map[string]any{"Labels": map[string]string{"bulwark.test.build": strconv.Itoa(n)}},
+		"rootfs":       map[string]any{"type": "layers", "diff_ids": []string{}},
+	})
+	configDigest := r.putBlob(config)
+	manifest, _ := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeManifest,
+		"config":        map[string]any{"mediaType": MediaTypeConfig, "size": len(config), "digest": configDigest},
+		"layers":        []any{},
+	})
+	return r.putManifest(repository, tag, MediaTypeManifest, manifest)
+}
+
+// Digest returns the manifest digest repository:tag points at, or "".
+func (r *Registry) Digest(repository, tag string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.manifests[repository][tag]
+}
+
+// Delete removes a manifest by tag or digest, like registry garbage
+// collection removing an old digest.
+func (r *Registry) Delete(repository, reference string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	refs := r.manifests[repository]
+	digest, ok := refs[reference]
+	if !ok {
+		return
+	}
+	for ref, d := range refs {
+		if d == digest {
+			delete(refs, ref)
+		}
+	}
+}
+
+func digestOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func (r *Registry) putBlob(content []byte) string {
+	digest := digestOf(content)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blobs[digest] = content
+	return digest
+}
+
+func (r *Registry) putManifest(repository, tag, mediaType string, content []byte) string {
+	digest := r.putBlob(content)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[digest] = mediaType
+	refs := r.manifests[repository]
+	if refs == nil {
+		refs = make(map[string]string)
+		r.manifests[repository] = refs
+	}
+	refs[digest] = digest
+	if tag != "" && !strings.HasPrefix(tag, "sha256:") {
+		refs[tag] = digest
+	}
+	return digest
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	if path == "" || req.URL.Path == "/v2" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if name, rest, ok := strings.Cut(path, "/manifests/"); ok {
+		r.manifestRequests.Add(1)
+		r.serveManifest(w, req, name, rest)
+		return
+	}
+	if name, rest, ok := strings.Cut(path, "/blobs/uploads"); ok {
+		r.serveUpload(w, req, name, strings.TrimPrefix(rest, "/"))
+		return
+	}
+	if name, rest, ok := strings.Cut(path, "/blobs/"); ok {
+		r.serveBlob(w, req, name, rest)
+		return
+	}
+	registryError(w, http.StatusNotFound, "NAME_UNKNOWN", "unknown path "+req.URL.Path)
+}
+
+func (r *Registry) serveManifest(w http.ResponseWriter, req *http.Request, name, reference string) {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		r.mu.Lock()
+		digest := r.manifests[name][reference]
+		content := r.blobs[digest]
+		mediaType := r.types[digest]
+		r.mu.Unlock()
+		if digest == "" {
+			registryError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown")
+			return
+		}
+		w.Header().Set("Content-Type", mediaType)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		if req.Method == http.MethodGet {
+			_, _ = w.Write(content)
+		}
+	case http.MethodPut:
+		content, err := io.ReadAll(req.Body)
+		if err != nil {
+			registryError(w, http.StatusBadRequest, "MANIFEST_INVALID", err.Error())
+			return
+		}
+		digest := r.putManifest(name, reference, req.Header.Get("Content-Type"), content)
+		w.Header().Set("Location", "/v2/"+name+"/manifests/"+digest)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	default:
+		registryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "method not allowed")
+	}
+}
+
+func (r *Registry) serveBlob(w http.ResponseWriter, req *http.Request, name, digest string) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		registryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "method not allowed")
+		return
+	}
+	r.mu.Lock()
+	content, ok := r.blobs[digest]
+	r.mu.Unlock()
+	if !ok {
+		registryError(w, http.StatusNotFound, "BLOB_UNKNOWN", "blob unknown to registry")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	if req.Method == http.MethodGet {
+		_, _ = w.Write(content)
+	}
+}
+
+// serveUpload implements blob pushes: POST starts an upload, PATCH appends
+// a chunk and PUT with ?digest= completes it.
+func (r *Registry) serveUpload(w http.ResponseWriter, req *http.Request, name, id string) {
+	switch {
+	case req.Method == http.MethodPost && id == "":
+		r.mu.Lock()
+		r.uploadSeq++
+		id = strconv.Itoa(r.uploadSeq)
+		r.uploads[id] = nil
+		r.mu.Unlock()
+		if digest := req.URL.Query().Get("digest"); digest != "" {
+			r.completeUpload(w, req, name, id, digest)
+			return
+		}
+		r.uploadAccepted(w, name, id, 0)
+	case req.Method == http.MethodPatch && id != "":
+		chunk, err := io.ReadAll(req.Body)
+		if err != nil {
+			registryError(w, http.StatusBadRequest, "BLOB_UPLOAD_INVALID", err.Error())
+			return
+		}
+		r.mu.Lock()
+		content, ok := r.uploads[id]
+		content = append(content, chunk...)
+		if ok {
+			r.uploads[id] = content
+		}
+		r.mu.Unlock()
+		if !ok {
+			registryError(w, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", "upload unknown")
+			return
+		}
+		r.uploadAccepted(w, name, id, len(content))
+	case req.Method == http.MethodPut && id != "":
+		r.completeUpload(w, req, name, id, req.URL.Query().Get("digest"))
+	default:
+		registryError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "method not allowed")
+	}
+}
+
+func (r *Registry) uploadAccepted(w http.ResponseWriter, name, id string, size int) {
+	w.Header().Set("Location", "/v2/"+name+"/blobs/uploads/"+id)
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", max(size-1, 0)))
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (r *Registry) completeUpload(w http.ResponseWriter, req *http.Request, name, id, digest string) {
+	chunk, err := io.ReadAll(req.Body)
+	if err != nil {
+		registryError(w, http.StatusBadRequest, "BLOB_UPLOAD_INVALID", err.Error())
+		return
+	}
+	r.mu.Lock()
+	content, ok := r.uploads[id]
+	delete(r.uploads, id)
+	r.mu.Unlock()
+	if !ok {
+		registryError(w, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", "upload unknown")
+		return
+	}
+	content = append(content, chunk...)
+	if digestOf(content) != digest {
+		registryError(w, http.StatusBadRequest, "DIGEST_INVALID", "provided digest did not match uploaded content")
+		return
+	}
+	r.putBlob(content)
+	w.Header().Set("Location", "/v2/"+name+"/blobs/"+digest)
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(http.StatusCreated)
+}
+
+// registryError writes an error in the distribution spec's format.
+func registryError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"errors": []map[string]string{{"code": code, "message": message}},
+	})
+}
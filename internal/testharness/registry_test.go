@@ -0,0 +1,98 @@
+package testharness
+
+import (
+	"context"
+	"testing"
+
+	"github.com/itsmrshow/bulwark/internal/logging"
+	"github.com/itsmrshow/bulwark/internal/planner"
+	"github.com/itsmrshow/bulwark/internal/policy"
+	"github.com/itsmrshow/bulwark/internal/registry"
+	"github.com/itsmrshow/bulwark/internal/state"
+)
+
+type noLocalImages struct{}
+
+func (noLocalImages) HasImage(context.Context, string) (bool, error) { return false, nil }
+
+func newRegistryClient(reg *Registry) *registry.Client {
+	return registry.NewClient(logging.Default()).WithHTTPClient(reg.HTTPClient()).WithDigestTTL(0)
+}
+
+func TestRegistryServesPublishedDigests(t *testing.T) {
+	reg := NewRegistry(t)
+	client := newRegistryClient(reg)
+	ctx := context.Background()
+
+	first := reg.Publish("team/app", "1")
+	second := reg.Publish("team/app", "1")
+	if first == second {
+		t.Fatal("expected every publish to yield a new digest")
+	}
+	digest, err := client.FetchDigest(ctx, reg.Image("team/app", "1"))
+	if err != nil {
+		t.Fatalf("fetch digest: %v", err)
+	}
+	if digest != second {
+		t.Errorf("digest = %s, want %s", digest, second)
+	}
+	if reg.ManifestRequests() == 0 {
+		t.Error("expected the registry to count manifest requests")
+	}
+
+	if ok, err := client.ManifestExists(ctx, reg.Image("team/app", "1"), first); err != nil || !ok {
+		t.Fatalf("expected the old digest to exist, got %v, %v", ok, err)
+	}
+	reg.Delete("team/app", first)
+	if ok, _ := client.ManifestExists(ctx, reg.Image("team/app", "1"), first); ok {
+		t.Error("expected the deleted digest to be gone")
+	}
+	if _, err := client.FetchDigest(ctx, reg.Image("team/app", "missing")); err == nil {
+		t.Error("expected an unknown tag to fail")
+	}
+}
+
+func TestPlanAgainstFakeRegistry(t *testing.T) {
+	reg := NewRegistry(t)
+	client := newRegistryClient(reg)
+	current := reg.Publish("team/web", "stable")
+	reg.Publish("team/db", "16")
+
+	project := NewComposeProject(t, "shop",
+		Service{Name: "web", Image: reg.Image("team/web", "stable"), Labels: map[string]string{"bulwark.policy": "aggressive"}},
+		Service{Name: "db", Image: reg.Image("team/db", "16"), Labels: map[string]string{"bulwark.tier": "stateful"}},
+	)
+	latestDB := reg.Digest("team/db", "16")
+	discoverer := &Discoverer{Targets: []state.Target{project.Target(map[string]string{"web": current, "db": latestDB})}}
+	plans := planner.NewPlanner(logging.Default(), discoverer, client, policy.NewEngine(logging.Default())).
+		WithRollbackCheck(noLocalImages{}, client)
+
+	plan, err := plans.BuildPlan(context.Background(), planner.PlanOptions{Root: project.Dir})
+	if err != nil {
+		t.Fatalf("build plan: %v", err)
+	}
+	if plan.UpdateCount != 0 {
+		t.Fatalf("expected no updates before a publish, got %d", plan.UpdateCount)
+	}
+
+	next := reg.Publish("team/web", "stable")
+	reg.Delete("team/web", current)
+	plan, err = plans.BuildPlan(context.Background(), planner.PlanOptions{Root: project.Dir})
+	if err != nil {
+		t.Fatalf("build plan: %v", err)
+	}
+	if plan.UpdateCount != 1 || plan.AllowedCount != 1 {
+		t.Fatalf("expected one allowed update, got %d updates, %d allowed", plan.UpdateCount, plan.AllowedCount)
+	}
+	for _, item := range plan.Items {
+		if item.ServiceName != "web" {
+			continue
+		}
+		if item.RemoteDigest != next {
+			t.Errorf("remote digest = %s, want %s", item.RemoteDigest, next)
+		}
+		if !item.RollbackDegraded {
+			t.Error("expected rollback_degraded once the current digest is gone everywhere")
+		}
+	}
+}
@@ -7,11 +7,11 @@ import {
   Settings,
   Target
 } from "lucide-react";
-import { useEffect } from "react";
-import { useHealth } from "./lib/queries";
-import { apiFetch } from "./lib/api";
+import { useHealth, usePlanWatch } from "./lib/queries";
 import { ErrorBoundary } from "./components/ErrorBoundary";
 import { ReadOnlyBanner } from "./components/ReadOnlyBanner";
+import { MaintenanceBanner } from "./components/MaintenanceBanner";
+import { MockBanner } from "./components/MockBanner";
 import { TokenManager } from "./components/TokenManager";
 import { BulwarkLogo } from "./components/BulwarkLogo";
 import { OverviewPage } from "./pages/OverviewPage";
@@ -40,13 +40,9 @@ function usePageTitle() {
 
 export default function App() {
   const { data: health } = useHealth();
+  const observer = health?.profile === "observer";
   const pageTitle = usePageTitle();
-
-  useEffect(() => {
-    if (health && !health.read_only) {
-      apiFetch("/api/enable-writes", { method: "POST" }).catch(() => {});
-    }
-  }, [health]);
+  usePlanWatch(health !== undefined && health.access !== "none");
 
   return (
     <div className="min-h-screen bg-ink-950 text-ink-100">
@@ -158,12 +154,14 @@ export default function App() {
 
           {/* Page content */}
           <div className="flex-1 overflow-auto px-6 py-6">
-            <ReadOnlyBanner readOnly={health?.read_only ?? true} />
+            <ReadOnlyBanner readOnly={health?.read_only ?? true} observer={observer} />
+            <MaintenanceBanner maintenance={health?.maintenance} />
+            <MockBanner mock={health?.mock} />
             <ErrorBoundary>
               <Routes>
                 <Route path="/"         element={<OverviewPage />} />
                 <Route path="/targets"  element={<TargetsPage />} />
-                <Route path="/plan"     element={<PlanPage readOnly={health?.read_only ?? true} />} />
+                <Route path="/plan"     element={<PlanPage readOnly={(health?.read_only ?? true) || observer} />} />
                 <Route path="/apply"    element={<ApplyPage />} />
                 <Route path="/history"  element={<HistoryPage />} />
                 <Route path="/settings" element={<SettingsPage />} />
@@ -0,0 +1,16 @@
+import { Wrench } from "lucide-react";
+import type { Maintenance } from "../lib/types";
+
+export function MaintenanceBanner({ maintenance }: { maintenance?: Maintenance }) {
+  if (!maintenance) return null;
+  const until = maintenance.until
+    ? ` until ${new Date(maintenance.until).toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" })}`
+    : "";
+  return (
+    <div className="mb-4 flex items-center gap-3 rounded-xl border border-amber-400/30 bg-amber-400/10 px-4 py-3 text-sm text-amber-200">
+      <Wrench className="h-4 w-4" />
+      {maintenance.message || "Maintenance"}
+      {until} — auto-updates paused
+    </div>
+  );
+}
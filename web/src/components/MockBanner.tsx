@@ -0,0 +1,11 @@
+import { FlaskConical } from "lucide-react";
+
+export function MockBanner({ mock }: { mock?: boolean }) {
+  if (!mock) return null;
+  return (
+    <div className="mb-4 flex items-center gap-3 rounded-xl border border-sky-400/30 bg-sky-400/10 px-4 py-3 text-sm text-sky-200">
+      <FlaskConical className="h-4 w-4" />
+      Mock mode — containers, images and registries are simulated
+    </div>
+  );
+}
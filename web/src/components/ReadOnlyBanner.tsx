@@ -1,6 +1,14 @@
 import { ShieldAlert } from "lucide-react";
 
-export function ReadOnlyBanner({ readOnly }: { readOnly: boolean }) {
+export function ReadOnlyBanner({ readOnly, observer = false }: { readOnly: boolean; observer?: boolean }) {
+  if (observer) {
+    return (
+      <div className="mb-4 flex items-center gap-3 rounded-xl border border-signal-500/40 bg-signal-500/10 px-4 py-3 text-sm text-signal-400">
+        <ShieldAlert className="h-4 w-4" />
+        Observer instance. Bulwark checks for updates and sends notifications but never changes containers.
+      </div>
+    );
+  }
   if (!readOnly) return null;
   return (
     <div className="mb-4 flex items-center gap-3 rounded-xl border border-amber-400/30 bg-amber-400/10 px-4 py-3 text-sm text-amber-200">
@@ -1,9 +1,84 @@
+import { useState, type FormEvent } from "react";
+import { useQueryClient } from "@tanstack/react-query";
 import { useHealth } from "../lib/queries";
+import { login, logout } from "../lib/api";
+import { Button } from "./ui/button";
+import { Input } from "./ui/input";
 
 export function TokenManager() {
   const { data: health } = useHealth();
+  const queryClient = useQueryClient();
+  const [username, setUsername] = useState("");
+  const [secret, setSecret] = useState("");
+  const [error, setError] = useState<string | null>(null);
 
-  if (health?.read_only) {
+  const submit = async (event: FormEvent) => {
+    event.preventDefault();
+    setError(null);
+    try {
+      await login(health?.accounts ? { username, password: secret } : { token: secret });
+      setSecret("");
+      await queryClient.invalidateQueries();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "Login failed");
+    }
+  };
+
+  const signOut = async () => {
+    await logout().catch(() => {});
+    await queryClient.invalidateQueries();
+  };
+
+  if (!health) return null;
+
+  // A login is needed to read at all, or to write on a server that allows writes.
+  if (health.access === "none" || (health.access === "read" && !health.read_only && !health.user)) {
+    return (
+      <form onSubmit={submit} className="flex items-center gap-2">
+        {health.access === "read" && (
+          <span className="hidden text-xs text-ink-400 sm:inline">Read access</span>
+        )}
+        {health.accounts && (
+          <Input
+            value={username}
+            onChange={(event) => setUsername(event.target.value)}
+            placeholder="Username"
+            autoComplete="username"
+            className="h-8 w-32"
+          />
+        )}
+        <Input
+          type="password"
+          value={secret}
+          onChange={(event) => setSecret(event.target.value)}
+          placeholder={health.accounts ? "Password" : health.access === "none" ? "Access token" : "Write token"}
+          autoComplete={health.accounts ? "current-password" : "off"}
+          className="h-8 w-40"
+          aria-invalid={error !== null}
+          title={error ?? undefined}
+        />
+        <Button type="submit" size="sm" disabled={!secret || (health.accounts && !username)}>
+          Log in
+        </Button>
+      </form>
+    );
+  }
+
+  if (health.user) {
+    return (
+      <div className="flex items-center gap-2 text-xs text-ink-300">
+        <span>
+          {health.user}
+          <span className="text-ink-500"> · {health.access}</span>
+        </span>
+        <Button type="button" variant="ghost" size="sm" onClick={signOut}>
+          Log out
+        </Button>
+      </div>
+    );
+  }
+
+  if (health.read_only) {
     return (
       <div className="flex items-center gap-1.5 rounded-lg border border-amber-400/30 bg-amber-400/10 px-2.5 py-1 text-xs font-medium text-amber-300">
         <span className="h-1.5 w-1.5 rounded-full bg-amber-400" />
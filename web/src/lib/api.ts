@@ -1,31 +1,68 @@
 const API_BASE = import.meta.env.VITE_API_BASE ?? "";
 
-export async function login(token: string) {
-  const response = await fetch(`${API_BASE}/api/login`, {
+// API_VERSION is the HTTP API version the console is written against.
+export const API_VERSION = "1";
+
+// versioned maps an /api/ path to its /api/v{API_VERSION}/ route.
+export function versioned(path: string) {
+  return path.startsWith("/api/") ? `/api/v${API_VERSION}/${path.slice("/api/".length)}` : path;
+}
+
+// ApiError is a failed API response. requestId matches the server's log
+// lines for the request, so it is worth quoting when reporting a problem.
+export class ApiError extends Error {
+  status: number;
+  code?: string;
+  details?: string;
+  requestId?: string;
+
+  constructor(status: number, message: string, code?: string, details?: string, requestId?: string) {
+    super(requestId ? `${message} (request ${requestId})` : message);
+    this.name = "ApiError";
+    this.status = status;
+    this.code = code;
+    this.details = details;
+    this.requestId = requestId;
+  }
+}
+
+async function apiErrorFrom(response: Response) {
+  const requestId = response.headers.get("X-Request-ID") ?? undefined;
+  try {
+    const data = await response.json();
+    return new ApiError(
+      response.status,
+      data?.message ?? data?.error ?? response.statusText,
+      data?.code,
+      data?.details,
+      data?.request_id ?? requestId
+    );
+  } catch {
+    return new ApiError(response.status, response.statusText, undefined, undefined, requestId);
+  }
+}
+
+export type LoginCredentials = { token: string } | { username: string; password: string };
+
+export async function login(credentials: LoginCredentials) {
+  const response = await fetch(`${API_BASE}${versioned("/api/login")}`, {
     method: "POST",
     headers: {
       "Content-Type": "application/json"
     },
-    body: JSON.stringify({ token }),
+    body: JSON.stringify(credentials),
     credentials: "include" // Important: send cookies
   });
 
   if (!response.ok) {
-    let details = "";
-    try {
-      const data = await response.json();
-      details = data?.error ?? response.statusText;
-    } catch {
-      details = response.statusText;
-    }
-    throw new Error(details);
+    throw await apiErrorFrom(response);
   }
 
   return await response.json();
 }
 
 export async function logout() {
-  const response = await fetch(`${API_BASE}/api/logout`, {
+  const response = await fetch(`${API_BASE}${versioned("/api/logout")}`, {
     method: "POST",
     credentials: "include"
   });
@@ -39,11 +76,12 @@ export async function logout() {
 
 export async function apiFetch<T>(path: string, options: RequestInit = {}) {
   const headers = new Headers(options.headers ?? {});
+  headers.set("Bulwark-API-Version", API_VERSION);
   if (!headers.has("Content-Type") && options.body) {
     headers.set("Content-Type", "application/json");
   }
 
-  const response = await fetch(`${API_BASE}${path}`, {
+  const response = await fetch(`${API_BASE}${versioned(path)}`, {
     ...options,
     headers,
     credentials: "include", // Important: send session cookies
@@ -51,14 +89,7 @@ export async function apiFetch<T>(path: string, options: RequestInit = {}) {
   });
 
   if (!response.ok) {
-    let details = "";
-    try {
-      const data = await response.json();
-      details = data?.error ?? response.statusText;
-    } catch {
-      details = response.statusText;
-    }
-    throw new Error(details);
+    throw await apiErrorFrom(response);
   }
 
   if (response.status === 204) {
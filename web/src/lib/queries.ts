@@ -1,13 +1,39 @@
-import { useMutation, useQuery } from "@tanstack/react-query";
+import { useEffect } from "react";
+import { useMutation, useQuery, useQueryClient } from "@tanstack/react-query";
 import { apiFetch } from "./api";
 import type {
   ApplyResponse,
+  ComposeHistoryResponse,
+  ComposeJournalEntry,
+  ComposeVersion,
   HealthResponse,
   HistoryResponse,
+  IgnoredUpdate,
+  LabelSettings,
   OverviewResponse,
   Plan,
+  PlanSelectResponse,
+  PlanUpdatesResponse,
+  PlanSelector,
+  PolicySimulation,
+  PolicySimulationRequest,
+  RegistryEndpoint,
+  RegistryRepos,
+  RegistryTags,
+  RollbackReport,
   Run,
+  RunArtifactsResponse,
+  ServiceLabelsResponse,
+  RunNotesUpdate,
+  ScheduledRun,
+  ScheduledRunsResponse,
   SettingsResponse,
+  SettingsUpdate,
+  SetupRequest,
+  SetupResponse,
+  SetupStatus,
+  Snooze,
+  TagChangeResponse,
   Target
 } from "./types";
 
@@ -27,16 +53,47 @@ export function useHealth() {
 export function useOverview() {
   return useQuery({
     queryKey: ["overview"],
-    queryFn: () => apiFetch<OverviewResponse>("/api/overview"),
+    queryFn: () => apiFetch<OverviewResponse>("/api/overview", { cache: "no-cache" }),
     refetchInterval: 60000
   });
 }
 
+// usePlanWatch long-polls /api/plan/updates and refetches the plan and
+// overview as soon as the plan changes, e.g. when a check finds updates or
+// a run applies them.
+export function usePlanWatch(enabled = true) {
+  const queryClient = useQueryClient();
+  useEffect(() => {
+    if (!enabled) return;
+    const controller = new AbortController();
+    let since = "";
+    const watch = async () => {
+      while (!controller.signal.aborted) {
+        try {
+          const query = since ? `?since=${encodeURIComponent(since)}` : "";
+          const data = await apiFetch<PlanUpdatesResponse>(`/api/plan/updates${query}`, { signal: controller.signal });
+          if (data.changed && since) {
+            await queryClient.invalidateQueries({ queryKey: ["plan"] });
+            await queryClient.invalidateQueries({ queryKey: ["overview"] });
+          }
+          since = data.revision;
+        } catch {
+          if (controller.signal.aborted) return;
+          // Back off when the server is unreachable or the plan fails.
+          await new Promise((resolve) => setTimeout(resolve, 30000));
+        }
+      }
+    };
+    void watch();
+    return () => controller.abort();
+  }, [enabled, queryClient]);
+}
+
 export function useTargets() {
   return useQuery({
     queryKey: ["targets"],
     queryFn: async () => {
-      const data = await apiFetch<{ targets: Target[] }>("/api/targets");
+      const data = await apiFetch<{ targets: Target[] }>("/api/targets", { cache: "no-cache" });
       return data.targets;
     }
   });
@@ -53,16 +110,18 @@ export function useTarget(id?: string) {
 export function usePlan(options: PlanQueryOptions = {}) {
   return useQuery({
     queryKey: ["plan"],
-    queryFn: () => apiFetch<Plan>("/api/plan", { method: "POST", body: JSON.stringify({}) }),
+    // GET lets the browser revalidate with If-None-Match instead of refetching the plan
+    queryFn: () => apiFetch<Plan>("/api/plan", { cache: "no-cache" }),
     enabled: options.enabled ?? true,
     refetchInterval: options.refetchInterval ?? 60000
   });
 }
 
-export function useRun(runId?: string) {
+export function useRun(runId?: string, filters: Record<string, string> = {}) {
+  const params = new URLSearchParams(filters).toString();
   return useQuery({
-    queryKey: ["run", runId],
-    queryFn: () => apiFetch<Run>(`/api/runs/${runId}`),
+    queryKey: ["run", runId, filters],
+    queryFn: () => apiFetch<Run>(`/api/runs/${runId}${params ? `?${params}` : ""}`),
     enabled: Boolean(runId),
     refetchInterval: (query) => (query.state.data?.status === "running" ? 1000 : false),
     refetchIntervalInBackground: false,
@@ -71,6 +130,14 @@ export function useRun(runId?: string) {
   });
 }
 
+export function useRunArtifacts(runId?: string) {
+  return useQuery({
+    queryKey: ["run-artifacts", runId],
+    queryFn: () => apiFetch<RunArtifactsResponse>(`/api/runs/${runId}/artifacts`),
+    enabled: Boolean(runId)
+  });
+}
+
 export function useHistory(page: number, pageSize: number, filters: Record<string, string>) {
   const params = new URLSearchParams({ page: String(page), page_size: String(pageSize), ...filters });
   return useQuery({
@@ -79,6 +146,117 @@ export function useHistory(page: number, pageSize: number, filters: Record<strin
   });
 }
 
+export function useRegistries() {
+  return useQuery({
+    queryKey: ["registries"],
+    queryFn: async () => {
+      const data = await apiFetch<{ registries: RegistryEndpoint[] }>("/api/registries");
+      return data.registries;
+    }
+  });
+}
+
+export function useRegistryRepos(name?: string) {
+  return useQuery({
+    queryKey: ["registry-repos", name],
+    queryFn: () => apiFetch<RegistryRepos>(`/api/registries/${encodeURIComponent(name ?? "")}/repos`),
+    enabled: Boolean(name)
+  });
+}
+
+export function useRegistryTags(name?: string, repo?: string) {
+  const params = new URLSearchParams({ repo: repo ?? "" }).toString();
+  return useQuery({
+    queryKey: ["registry-tags", name, repo],
+    queryFn: () => apiFetch<RegistryTags>(`/api/registries/${encodeURIComponent(name ?? "")}/tags?${params}`),
+    enabled: Boolean(name && repo)
+  });
+}
+
+export function useComposeHistory(targetId?: string) {
+  return useQuery({
+    queryKey: ["compose-history", targetId],
+    queryFn: () => apiFetch<ComposeHistoryResponse>(`/api/targets/${targetId}/compose/history`),
+    enabled: Boolean(targetId)
+  });
+}
+
+export function useComposeVersion(targetId?: string, id?: number) {
+  return useQuery({
+    queryKey: ["compose-version", targetId, id],
+    queryFn: () => apiFetch<ComposeVersion>(`/api/targets/${targetId}/compose/history/${id}`),
+    enabled: Boolean(targetId && id)
+  });
+}
+
+export function useServiceLabels(serviceId?: string) {
+  return useQuery({
+    queryKey: ["service-labels", serviceId],
+    queryFn: () => apiFetch<ServiceLabelsResponse>(`/api/services/${serviceId}/labels`),
+    enabled: Boolean(serviceId)
+  });
+}
+
+export function useSetServiceLabels() {
+  return useMutation({
+    mutationFn: ({ serviceId, settings, apply }: { serviceId: string; settings: LabelSettings; apply: boolean }) =>
+      apiFetch<ServiceLabelsResponse>(`/api/services/${serviceId}/labels`, {
+        method: "POST",
+        body: JSON.stringify({ ...settings, apply })
+      })
+  });
+}
+
+export function useRestoreCompose() {
+  return useMutation({
+    mutationFn: ({ targetId, id }: { targetId: string; id: number }) =>
+      apiFetch<{ entry: ComposeJournalEntry }>(`/api/targets/${targetId}/compose/history/${id}/restore`, {
+        method: "POST"
+      })
+  });
+}
+
+export function useSimulatePolicy() {
+  return useMutation({
+    mutationFn: (payload: PolicySimulationRequest) =>
+      apiFetch<PolicySimulation>("/api/policy/simulate", { method: "POST", body: JSON.stringify(payload) })
+  });
+}
+
+export function useScheduledRuns() {
+  return useQuery({
+    queryKey: ["scheduled-runs"],
+    queryFn: () => apiFetch<ScheduledRunsResponse>("/api/runs/schedule")
+  });
+}
+
+export function useScheduleRun() {
+  return useMutation({
+    mutationFn: (payload: Record<string, unknown> & { run_at: string }) =>
+      apiFetch<ScheduledRun>("/api/runs/schedule", { method: "POST", body: JSON.stringify(payload) })
+  });
+}
+
+export function useUnscheduleRun() {
+  return useMutation({
+    mutationFn: (id: string) => apiFetch(`/api/runs/schedule/${id}`, { method: "DELETE" })
+  });
+}
+
+export function useUpdateRunNotes(runId: string) {
+  return useMutation({
+    mutationFn: (payload: RunNotesUpdate) =>
+      apiFetch<Run>(`/api/runs/${runId}`, { method: "PATCH", body: JSON.stringify(payload) })
+  });
+}
+
+export function usePlanSelect() {
+  return useMutation({
+    mutationFn: (select: PlanSelector) =>
+      apiFetch<PlanSelectResponse>("/api/plan/select", { method: "POST", body: JSON.stringify({ select }) })
+  });
+}
+
 export function useApply() {
   return useMutation({
     mutationFn: (payload: Record<string, unknown>) =>
@@ -95,11 +273,25 @@ export function useSettings() {
 
 export function useUpdateSettings() {
   return useMutation({
-    mutationFn: (payload: SettingsResponse) =>
+    mutationFn: (payload: SettingsUpdate) =>
       apiFetch<SettingsResponse>("/api/settings", { method: "PUT", body: JSON.stringify(payload) })
   });
 }
 
+export function useSetupStatus(root?: string) {
+  return useQuery({
+    queryKey: ["setup", root ?? ""],
+    queryFn: () => apiFetch<SetupStatus>(root ? `/api/setup?root=${encodeURIComponent(root)}` : "/api/setup")
+  });
+}
+
+export function useCompleteSetup() {
+  return useMutation({
+    mutationFn: (payload: SetupRequest) =>
+      apiFetch<SetupResponse>("/api/setup", { method: "POST", body: JSON.stringify(payload) })
+  });
+}
+
 export function useTestNotification() {
   return useMutation({
     mutationFn: () => apiFetch("/api/notifications/test", { method: "POST" })
@@ -111,3 +303,43 @@ export function useRefresh() {
     mutationFn: () => apiFetch("/api/refresh", { method: "POST" })
   });
 }
+
+export function useIgnoreUpdate() {
+  return useMutation({
+    mutationFn: ({ serviceId, digest }: { serviceId: string; digest: string }) =>
+      apiFetch<IgnoredUpdate>(`/api/services/${serviceId}/ignore`, {
+        method: "POST",
+        body: JSON.stringify({ digest })
+      })
+  });
+}
+
+export function useSnoozeUpdate() {
+  return useMutation({
+    mutationFn: ({ serviceId, duration }: { serviceId: string; duration: string }) =>
+      apiFetch<Snooze>(`/api/services/${serviceId}/snooze`, {
+        method: "POST",
+        body: JSON.stringify({ duration })
+      })
+  });
+}
+
+export function useVerifyRollback() {
+  return useMutation({
+    mutationFn: ({ target, service }: { target: string; service: string }) =>
+      apiFetch<RollbackReport>(
+        `/api/rollback?target=${encodeURIComponent(target)}&service=${encodeURIComponent(service)}&dry_run=true`,
+        { method: "POST" }
+      )
+  });
+}
+
+export function useChangeTag() {
+  return useMutation({
+    mutationFn: ({ serviceId, tag }: { serviceId: string; tag: string }) =>
+      apiFetch<TagChangeResponse>(`/api/services/${serviceId}/tag`, {
+        method: "POST",
+        body: JSON.stringify({ tag })
+      })
+  });
+}
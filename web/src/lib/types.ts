@@ -4,6 +4,29 @@ export interface HealthResponse {
   status: string;
   read_only: boolean;
   ui_enabled: boolean;
+  profile?: "full" | "observer";
+  access: "none" | "read" | "write" | "admin";
+  user?: string;
+  accounts: boolean;
+  maintenance?: Maintenance;
+  mock?: boolean;
+}
+
+export interface Maintenance {
+  message?: string;
+  until?: string;
+  started_by?: string;
+  started_at: string;
+}
+
+export type UserRole = "viewer" | "operator" | "admin";
+
+export interface User {
+  username: string;
+  role: UserRole;
+  password_changed_at: string;
+  created_at: string;
+  updated_at: string;
 }
 
 export interface OverviewResponse {
@@ -12,6 +35,7 @@ export interface OverviewResponse {
   managed_targets: number;
   managed_services: number;
   updates_available: number;
+  mutable_tags?: number;
   last_run?: {
     completed_at: string;
     status: string;
@@ -25,6 +49,46 @@ export interface OverviewResponse {
     service?: string;
     message: string;
   }>;
+  groups?: GroupSummary[];
+  hosts: HostSummary[];
+  plan_stale?: boolean;
+  maintenance?: Maintenance;
+  trends: {
+    applied: TrendDelta;
+    failures: TrendDelta;
+    rollbacks: TrendDelta;
+  };
+}
+
+export interface OverviewFailure {
+  completed_at: string;
+  target_id: string;
+  service: string;
+  message?: string;
+  code?: string;
+}
+
+export interface GroupSummary {
+  name: string;
+  targets: number;
+  services: number;
+  updates_available: number;
+  paused: boolean;
+  last_failure?: OverviewFailure;
+}
+
+export interface HostSummary {
+  name: string;
+  targets: number;
+  services: number;
+  updates_available: number;
+  last_failure?: OverviewFailure;
+}
+
+export interface TrendDelta {
+  current: number;
+  previous: number;
+  delta: number;
 }
 
 export interface Target {
@@ -53,6 +117,14 @@ export interface Labels {
   tier: string;
   probe: ProbeConfig;
   definition?: string;
+  schema?: number;
+  issues?: LabelIssue[];
+}
+
+export interface LabelIssue {
+  label: string;
+  message: string;
+  suggestion?: string;
 }
 
 export interface ProbeConfig {
@@ -63,16 +135,56 @@ export interface ProbeConfig {
   tcp_port?: number;
   log_pattern?: string;
   window_sec?: number;
+  log_stream?: "stdout" | "stderr";
+  log_ignore_case?: boolean;
+  log_max_lines?: number;
+  log_fail_pattern?: string;
+  fail_pattern?: string;
+  fail_watch?: number; // nanoseconds
   stability_sec?: number;
 }
 
+export interface PlanUpdate {
+  target_id: string;
+  target_name: string;
+  service_id: string;
+  service_name: string;
+  image: string;
+  current_digest: string;
+  remote_digest: string;
+  allowed: boolean;
+  risk: string;
+}
+
+export interface PlanUpdatesResponse {
+  revision: string;
+  changed: boolean;
+  generated_at: string;
+  update_count: number;
+  allowed_count: number;
+  updates: PlanUpdate[];
+}
+
 export interface Plan {
   generated_at: string;
   target_count: number;
   service_count: number;
   update_count: number;
   allowed_count: number;
+  base_update_count?: number;
+  ignored_count?: number;
+  digests_reused?: number;
+  mutable_tag_count?: number;
   items: PlanItem[];
+  cache?: PlanCacheInfo;
+}
+
+export interface PlanCacheInfo {
+  cached: boolean;
+  generated_at: string;
+  expires_at: string;
+  ttl_remaining_sec: number;
+  stale?: boolean;
 }
 
 export interface PlanItem {
@@ -90,8 +202,35 @@ export interface PlanItem {
   tier: string;
   probe: ProbeConfig;
   reason: string;
+  reason_code?: ReasonCode;
   risk: RiskLevel;
   warnings?: string[];
+  config_drift?: boolean;
+  ignored?: boolean;
+  snoozed_until?: string;
+  group?: string;
+  paused?: boolean;
+  base_image?: string;
+  base_update_available?: boolean;
+  build?: boolean;
+  depends_on_target?: string[];
+  rollback?: "local" | "registry" | "unavailable";
+  rollback_degraded?: boolean;
+  mutable_tag?: string;
+  suggested_tag?: string;
+}
+
+export interface IgnoredUpdate {
+  service_id: string;
+  digest: string;
+  tag?: string;
+  created_at: string;
+}
+
+export interface Snooze {
+  service_id: string;
+  until: string;
+  created_at: string;
 }
 
 export interface ApplyResponse {
@@ -104,10 +243,27 @@ export interface RunEvent {
   target?: string;
   service?: string;
   step?: string;
+  phase?: "pull" | "switch";
   message: string;
   data?: Record<string, unknown>;
 }
 
+export type ServiceOutcomeResult = "applied" | "skipped" | "failed" | "rolled_back";
+
+export interface ServiceOutcome {
+  service: string;
+  image?: string;
+  outcome: ServiceOutcomeResult;
+  reason?: string;
+  duration_ms?: number;
+  completed_at: string;
+}
+
+export interface TargetOutcome {
+  target: string;
+  services: ServiceOutcome[];
+}
+
 export interface Run {
   id: string;
   mode: string;
@@ -115,29 +271,118 @@ export interface Run {
   created_at: string;
   started_at: string;
   completed_at?: string;
+  phase?: "pull" | "switch";
   summary: {
     updates_applied: number;
     updates_skipped: number;
     updates_failed: number;
     rollbacks: number;
+    images_pulled?: number;
+    targets?: TargetOutcome[];
   };
   events: RunEvent[];
+  note?: string;
+  labels?: Record<string, string>;
+}
+
+export interface SBOMSummary {
+  format: string;
+  packages: number;
 }
 
 export interface HistoryItem {
+  id?: number;
   target_id: string;
   service_id: string;
   service_name: string;
+  image?: string;
+  tag?: string;
+  registry?: string;
+  platform?: string;
   old_digest: string;
   new_digest: string;
   success: boolean;
   rolled_back: boolean;
   error_message?: string;
+  error_code?: string;
   started_at: string;
   completed_at: string;
   probes_passed: number;
   probes_failed: number;
   duration_sec: number;
+  timings?: UpdateTimings;
+  attempts?: number;
+  result_code?: string;
+  reason_code?: ReasonCode;
+  skipped?: boolean;
+  sbom?: SBOMSummary;
+  kind?: "version_change";
+  previous_tag?: string;
+  config_changes?: ConfigChange[];
+  resources?: ResourceSnapshot;
+  trigger?: UpdateTrigger;
+  actor?: string;
+}
+
+export type UpdateTrigger = "scheduled" | "manual-ui" | "manual-cli" | "webhook" | "auto-approve";
+
+export interface ConfigChange {
+  field: "env" | "mount" | "port" | "label" | "entrypoint" | "cmd";
+  key?: string;
+  before?: string;
+  after?: string;
+}
+
+export interface ResourceUsage {
+  cpu_percent: number;
+  memory_bytes: number;
+  memory_limit?: number;
+}
+
+export interface ResourceSnapshot {
+  before?: ResourceUsage;
+  after?: ResourceUsage;
+  memory_change_percent?: number;
+  memory_warning?: boolean;
+}
+
+export interface UpdateTimings {
+  pull_ms: number;
+  recreate_ms: number;
+  probe_ms: number;
+  downtime_ms: number;
+}
+
+export interface StepStats {
+  count: number;
+  avg_ms: number;
+  p50_ms: number;
+  p95_ms: number;
+  max_ms: number;
+}
+
+export interface UpdateStats {
+  since: string;
+  updates: number;
+  failed: number;
+  total: StepStats;
+  pull: StepStats;
+  recreate: StepStats;
+  probe: StepStats;
+  downtime: StepStats;
+  sla?: {
+    downtime_ms: number;
+    met: number;
+    breached: number;
+    compliance: number;
+    breaches: {
+      id: number;
+      target_id: string;
+      service_name: string;
+      downtime_ms: number;
+      completed_at: string;
+    }[];
+  };
 }
 
 export interface HistoryResponse {
@@ -152,6 +397,24 @@ export interface NotificationSettings {
   slack_webhook: string;
   discord_enabled: boolean;
   slack_enabled: boolean;
+  apprise_url?: string;
+  apprise_enabled?: boolean;
+  apprise_tag?: string;
+  apprise_urls?: string;
+  matrix_enabled?: boolean;
+  matrix_homeserver?: string;
+  matrix_access_token?: string;
+  matrix_room_id?: string;
+  teams_enabled?: boolean;
+  teams_webhook?: string;
+  mqtt_enabled?: boolean;
+  mqtt_broker?: string;
+  mqtt_username?: string;
+  mqtt_password?: string;
+  mqtt_topic_prefix?: string;
+  mqtt_tls_insecure?: boolean;
+  ha_discovery_enabled?: boolean;
+  ha_discovery_prefix?: string;
   notify_on_find: boolean;
   digest_enabled: boolean;
   check_cron: string;
@@ -160,9 +423,250 @@ export interface NotificationSettings {
   auto_update_safe: boolean;
   auto_update_unsafe: boolean;
   auto_update_cron: string;
+  catch_up_enabled?: boolean;
+  catch_up_apply?: boolean;
+  catch_up_threshold?: string;
+}
+
+export interface ServerSettings {
+  plan_cache_ttl?: string;
+  digest_cache_ttl?: string;
+  lock_timeout?: string;
+  check_concurrency?: number;
+  cleanup_policy?: "none" | "dangling";
+}
+
+export interface PinnedDigest {
+  digest: string;
+  note?: string;
+  pinned_at: string;
+}
+
+export interface RecentDigest {
+  target_id: string;
+  service_id: string;
+  service_name: string;
+  digest: string;
+}
+
+export interface ProtectedDigests {
+  keep: number;
+  recent: RecentDigest[];
+  pinned: PinnedDigest[];
 }
 
 export interface SettingsResponse {
   notifications: NotificationSettings;
   locked?: NotificationSettings;
+  server: ServerSettings;
+  server_locked?: string[];
+}
+
+export interface DependencyCheck {
+  status: "ok" | "failed" | "disabled";
+  latency_ms?: number;
+  error?: string;
+}
+
+export interface SetupStatus {
+  required: boolean;
+  completed_at?: string;
+  root: string;
+  root_locked: boolean;
+  root_check: DependencyCheck;
+  docker: DependencyCheck;
+  state_persistent: boolean;
+  notifications_configured: boolean;
+}
+
+export interface SetupRequest {
+  root?: string;
+  web_token?: string;
+  notifications: NotificationSettings;
+}
+
+export interface SetupResponse {
+  root: string;
+  web_token: string;
+  completed_at: string;
+}
+
+export interface SettingsUpdate {
+  notifications?: NotificationSettings;
+  server?: ServerSettings;
+}
+
+export interface RegistryEndpoint {
+  name: string;
+  url: string;
+}
+
+export interface RegistryRepos {
+  registry: string;
+  repositories: string[];
+}
+
+export interface RegistryTags {
+  registry: string;
+  repository: string;
+  tags: string[];
+}
+
+export interface TagChangeResponse {
+  result: {
+    service_name: string;
+    tag?: string;
+    previous_tag?: string;
+    success: boolean;
+    rollback_performed: boolean;
+    result_code?: string;
+    error_message?: string;
+  };
+}
+
+export interface LabelSettings {
+  policy?: string;
+  tier?: string;
+  probe: {
+    type?: string;
+    url?: string;
+    expect_status?: number;
+    tcp_host?: string;
+    tcp_port?: number;
+    log_pattern?: string;
+    window_sec?: number;
+  };
+}
+
+export interface ServiceLabelsResponse {
+  service: string;
+  settings: LabelSettings;
+  labels: Record<string, string>;
+  block: string;
+  path?: string;
+  diff?: string;
+  applied: boolean;
+  entry?: ComposeJournalEntry;
+}
+
+export interface RollbackReport {
+  target: string;
+  service: string;
+  current_digest?: string;
+  rollback_digest?: string;
+  image?: string;
+  source?: "local" | "registry";
+  ready: boolean;
+  checks: { name: string; ok: boolean; message: string }[];
+  steps?: string[];
+}
+
+export interface ComposeJournalEntry {
+  id: number;
+  file: string;
+  backup: string;
+  actor?: string;
+  reason: string;
+  diff: string;
+  created_at: string;
+}
+
+export interface ComposeHistoryResponse {
+  entries: ComposeJournalEntry[];
+}
+
+export interface ComposeVersion extends ComposeJournalEntry {
+  content: string;
+}
+
+export interface PolicySimulationRequest {
+  labels: Record<string, string>;
+  image?: string;
+  service_id?: string;
+  update_available?: boolean;
+}
+
+export interface PolicySimulation {
+  image: string;
+  labels: Labels;
+  allowed: boolean;
+  reason: string;
+  reason_code: ReasonCode;
+  policy: string;
+  tier: string;
+  risk: string;
+  warnings: string[];
+}
+
+export type ReasonCode =
+  | "not_enabled"
+  | "no_update"
+  | "ignored"
+  | "digest_fetch_failed"
+  | "invalid_image"
+  | "image_denied"
+  | "registry_not_allowed"
+  | "mutable_tag"
+  | "notify_policy"
+  | "stateful_blocked"
+  | "unknown_policy"
+  | "safe_policy"
+  | "aggressive_policy"
+  | "snoozed"
+  | "group_paused";
+
+export interface PlanSelector {
+  targets?: string[];
+  services?: string[];
+  images?: string[];
+  risks?: Array<"safe" | "notify" | "stateful" | "probe_missing">;
+  policies?: Array<"notify" | "safe" | "aggressive">;
+  labels?: string[];
+  exclude?: string[];
+}
+
+export interface PlanSelectResponse {
+  items: PlanItem[];
+  update_count: number;
+  service_ids: string[];
+}
+
+export interface ScheduledRun {
+  id: string;
+  run_at: string;
+  mode: "safe" | "selected" | "all";
+  target?: string;
+  group?: string;
+  service_ids?: string[];
+  force?: boolean;
+  pull_only?: boolean;
+  created_by?: string;
+  created_at: string;
+  status: "pending" | "started" | "missed" | "failed" | "skipped";
+  run_id?: string;
+  note?: string;
+  labels?: Record<string, string>;
+}
+
+export interface RunNotesUpdate {
+  note?: string;
+  labels?: Record<string, string>;
+}
+
+export interface RunArtifact {
+  name: string;
+  kind: "compose_output" | "probe_transcript" | "rollback_override";
+  target?: string;
+  service?: string;
+  size: number;
+  truncated?: boolean;
+  created_at: string;
+}
+
+export interface RunArtifactsResponse {
+  artifacts: RunArtifact[];
+}
+
+export interface ScheduledRunsResponse {
+  runs: ScheduledRun[];
 }
@@ -17,6 +17,18 @@ function levelStyle(level?: string) {
   return LEVEL_STYLES[level ?? ""] ?? LEVEL_STYLES.info;
 }
 
+const OUTCOME_STYLES: Record<string, string> = {
+  applied:     "text-emerald-300",
+  skipped:     "text-ink-400",
+  failed:      "text-rose-300",
+  rolled_back: "text-amber-300",
+};
+
+function formatDuration(ms?: number) {
+  if (!ms) return "";
+  return ms < 1000 ? `${ms}ms` : `${(ms / 1000).toFixed(1)}s`;
+}
+
 function shortId(id: string) {
   return id.length > 8 ? id.slice(0, 8) : id;
 }
@@ -116,6 +128,37 @@ export function ApplyPage() {
         </div>
       </div>
 
+      {/* ── Per-target outcomes ─────────────────────────── */}
+      {summary.targets && summary.targets.length > 0 && (
+        <div className="rounded-2xl border border-ink-800/60 bg-ink-900/70">
+          <div className="border-b border-ink-800/50 px-5 py-4">
+            <h2 className="font-display text-base font-semibold text-ink-100">Outcomes</h2>
+            <p className="text-xs text-ink-500">What happened to each service in this run</p>
+          </div>
+          <div className="divide-y divide-ink-800/30">
+            {summary.targets.map((target) => (
+              <div key={target.target} className="px-5 py-3">
+                <div className="text-sm font-semibold text-ink-200">{target.target}</div>
+                <div className="mt-1 space-y-1">
+                  {target.services.map((service) => (
+                    <div key={service.service} className="flex flex-wrap items-baseline gap-x-3 text-xs">
+                      <span className="text-ink-300">{service.service}</span>
+                      <span className={`font-semibold uppercase tracking-wider ${OUTCOME_STYLES[service.outcome] ?? "text-ink-400"}`}>
+                        {service.outcome.replace("_", " ")}
+                      </span>
+                      {service.reason && <span className="min-w-0 flex-1 text-ink-500">{service.reason}</span>}
+                      {service.duration_ms ? (
+                        <span className="ml-auto font-mono text-ink-500">{formatDuration(service.duration_ms)}</span>
+                      ) : null}
+                    </div>
+                  ))}
+                </div>
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
+
       {/* ── Event log ───────────────────────────────────── */}
       <div className="rounded-2xl border border-ink-800/60 bg-ink-900/70">
         <div className="flex items-center justify-between border-b border-ink-800/50 px-5 py-4">
@@ -150,6 +193,9 @@ export function ApplyPage() {
                 {/* Content */}
                 <div className="min-w-0 flex-1">
                   <div className="flex flex-wrap items-baseline gap-x-2 gap-y-0.5">
+                    {event.phase && (
+                      <span className="uppercase tracking-wider text-ink-700">{event.phase}</span>
+                    )}
                     {event.step && (
                       <span className={`font-semibold uppercase tracking-wider ${ls.text}`}>
                         {event.step}
@@ -162,7 +208,21 @@ export function ApplyPage() {
                     )}
                     <TimeAgo date={event.ts} className="ml-auto shrink-0 text-ink-700" />
                   </div>
-                  <div className="mt-0.5 font-sans text-sm text-ink-200">{event.message}</div>
+                  <div
+                    className={`mt-0.5 text-ink-200 ${
+                      event.step === "output" ? "break-all font-mono text-xs" : "font-sans text-sm"
+                    }`}
+                  >
+                    {event.message}
+                  </div>
+                  {typeof event.data?.percent === "number" && (
+                    <div className="mt-1 flex items-center gap-2 text-xs text-ink-500">
+                      <div className="h-1 w-32 overflow-hidden rounded-full bg-ink-800">
+                        <div className="h-full bg-signal-500" style={{ width: `${event.data.percent}%` }} />
+                      </div>
+                      {event.data.percent}% of {Object.keys((event.data.layers as Record<string, number>) ?? {}).length} layers
+                    </div>
+                  )}
                 </div>
               </div>
             );
@@ -1,6 +1,7 @@
 import { Fragment, useState } from "react";
 import { ChevronDown, ChevronRight, History, Search } from "lucide-react";
 import { useHistory } from "../lib/queries";
+import { versioned } from "../lib/api";
 import type { HistoryItem } from "../lib/types";
 import { Button } from "../components/ui/button";
 import { Input } from "../components/ui/input";
@@ -15,9 +16,21 @@ function shortDigest(digest?: string) {
   return bare.slice(0, 12) || "—";
 }
 
+function timingsTitle(item: HistoryItem) {
+  const t = item.timings;
+  if (!t || (!t.pull_ms && !t.recreate_ms && !t.probe_ms)) return undefined;
+  const sec = (ms: number) => `${(ms / 1000).toFixed(1)}s`;
+  return `Pull ${sec(t.pull_ms)} · Recreate ${sec(t.recreate_ms)} · Probes ${sec(t.probe_ms)} · Downtime ${sec(t.downtime_ms)}`;
+}
+
+function formatMemory(bytes: number) {
+  return `${(bytes / (1024 * 1024)).toFixed(0)} MiB`;
+}
+
 function resultBadge(item: HistoryItem) {
   if (item.rolled_back) return <Badge variant="warning">Rolled back</Badge>;
   if (item.success)     return <Badge variant="success">Success</Badge>;
+  if (item.skipped)     return <Badge variant="muted">Skipped</Badge>;
   return <Badge variant="danger">Failed</Badge>;
 }
 
@@ -61,7 +74,7 @@ export function HistoryPage() {
           <div>
             <label className="mb-1.5 block text-xs text-ink-500">Result</label>
             <Input
-              placeholder="success · failed · rolled_back"
+              placeholder="success · failed · skipped · rolled_back"
               value={filters.result}
               onChange={(e) => updateFilter("result", e.target.value)}
             />
@@ -124,7 +137,7 @@ export function HistoryPage() {
                         <div className="font-mono text-xs text-ink-500">{item.target_id}</div>
                       </div>
                       <div>{resultBadge(item)}</div>
-                      <div className="hidden text-sm text-ink-400 sm:block">
+                      <div className="hidden text-sm text-ink-400 sm:block" title={timingsTitle(item)}>
                         {item.duration_sec.toFixed(1)}s
                       </div>
                       <div className="hidden text-sm text-ink-400 sm:block">
@@ -168,6 +181,48 @@ export function HistoryPage() {
                               {item.started_at ? new Date(item.started_at).toLocaleString() : "—"}
                             </span>
                           </div>
+                          {item.result_code && (
+                            <div>
+                              <div className="mb-1 text-ink-500 uppercase tracking-wide">Result code</div>
+                              <code className="font-mono text-ink-300">{item.result_code}</code>
+                            </div>
+                          )}
+                          {item.trigger && (
+                            <div>
+                              <div className="mb-1 text-ink-500 uppercase tracking-wide">Triggered by</div>
+                              <code className="font-mono text-ink-300">
+                                {item.actor ? `${item.trigger}:${item.actor}` : item.trigger}
+                              </code>
+                            </div>
+                          )}
+                          {item.resources?.before && item.resources.after && (
+                            <div>
+                              <div className="mb-1 text-ink-500 uppercase tracking-wide">Memory</div>
+                              <span className={item.resources.memory_warning ? "text-amber-300" : "text-ink-300"}>
+                                {formatMemory(item.resources.before.memory_bytes)} → {formatMemory(item.resources.after.memory_bytes)}
+                                {item.resources.memory_change_percent !== undefined && (
+                                  <span className="ml-1">
+                                    ({item.resources.memory_change_percent > 0 ? "+" : ""}
+                                    {item.resources.memory_change_percent.toFixed(0)}%)
+                                  </span>
+                                )}
+                                <span className="ml-1 text-ink-500">
+                                  · CPU {item.resources.after.cpu_percent.toFixed(1)}%
+                                </span>
+                              </span>
+                            </div>
+                          )}
+                          {item.sbom && item.id && (
+                            <div>
+                              <div className="mb-1 text-ink-500 uppercase tracking-wide">SBOM</div>
+                              <a
+                                className="text-signal-400 hover:underline"
+                                href={versioned(`/api/history/${item.id}/sbom`)}
+                              >
+                                {item.sbom.format} · {item.sbom.packages} packages
+                              </a>
+                            </div>
+                          )}
                           {item.error_message && (
                             <div className="col-span-2">
                               <div className="mb-1 text-ink-500 uppercase tracking-wide">Error</div>
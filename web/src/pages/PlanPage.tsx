@@ -2,7 +2,7 @@ import { useMemo, useState } from "react";
 import { useNavigate } from "react-router-dom";
 import { AlertTriangle, ClipboardCheck, RefreshCw } from "lucide-react";
 import { useQueryClient } from "@tanstack/react-query";
-import { useApply, usePlan, useRefresh } from "../lib/queries";
+import { useApply, useIgnoreUpdate, usePlan, useRefresh, useSnoozeUpdate } from "../lib/queries";
 import type { PlanItem } from "../lib/types";
 import { Card, CardHeader, CardTitle, CardDescription } from "../components/ui/card";
 import { Button } from "../components/ui/button";
@@ -12,6 +12,7 @@ import { Skeleton } from "../components/ui/skeleton";
 import { EmptyState } from "../components/EmptyState";
 import { RiskBadge } from "../components/RiskBadge";
 import { useToast } from "../components/Toast";
+import { timeAgo } from "../lib/timeago";
 
 function groupPlan(items: PlanItem[]) {
   return items.filter((item) => item.update_available);
@@ -29,6 +30,8 @@ export function PlanPage({ readOnly }: { readOnly: boolean }) {
   const { toast } = useToast();
   const queryClient = useQueryClient();
   const refresh = useRefresh();
+  const ignoreUpdate = useIgnoreUpdate();
+  const snoozeUpdate = useSnoozeUpdate();
   const [selected, setSelected] = useState<Set<string>>(new Set());
   const [confirmMode, setConfirmMode] = useState<"safe" | "selected" | null>(null);
   const [confirmText, setConfirmText] = useState("");
@@ -76,6 +79,31 @@ export function PlanPage({ readOnly }: { readOnly: boolean }) {
     }
   };
 
+  const skip = async (item: PlanItem) => {
+    try {
+      await ignoreUpdate.mutateAsync({ serviceId: item.service_id, digest: item.remote_digest });
+      toast(`Skipping this update of ${item.service_name}`, "info");
+      setSelected((prev) => {
+        const next = new Set(prev);
+        next.delete(item.service_id);
+        return next;
+      });
+      await queryClient.invalidateQueries({ queryKey: ["plan"] });
+    } catch {
+      toast("Failed to skip update", "error");
+    }
+  };
+
+  const snooze = async (item: PlanItem, duration: string) => {
+    try {
+      await snoozeUpdate.mutateAsync({ serviceId: item.service_id, duration });
+      toast(`Snoozed ${item.service_name} for ${duration}`, "info");
+      await queryClient.invalidateQueries({ queryKey: ["plan"] });
+    } catch {
+      toast("Failed to snooze updates", "error");
+    }
+  };
+
   const canApplySelected = selected.size > 0;
 
   if (isLoading) {
@@ -131,6 +159,15 @@ export function PlanPage({ readOnly }: { readOnly: boolean }) {
           <Badge variant="default">{plan.update_count} updates available</Badge>
           <Badge variant="success">{plan.allowed_count} allowed</Badge>
           <Badge variant="muted">{plan.service_count} services tracked</Badge>
+          {(plan.ignored_count ?? 0) > 0 && <Badge variant="muted">{plan.ignored_count} ignored</Badge>}
+          {plan.cache?.cached && (
+            <span
+              className="text-xs text-ink-500"
+              title={`Cached plan, expires in ${plan.cache.ttl_remaining_sec}s`}
+            >
+              Generated {timeAgo(plan.generated_at)}
+            </span>
+          )}
         </div>
         <div className="flex flex-wrap gap-3">
           <Button
@@ -222,7 +259,11 @@ export function PlanPage({ readOnly }: { readOnly: boolean }) {
                     {item.service_name}
                   </span>
                   <RiskBadge risk={item.risk} />
-                  {!item.allowed && <Badge variant="danger">Blocked</Badge>}
+                  {item.snoozed_until ? (
+                    <Badge variant="muted">Snoozed</Badge>
+                  ) : (
+                    !item.allowed && <Badge variant="danger">Blocked</Badge>
+                  )}
                 </div>
                 <div className="mt-1.5 flex items-center gap-3 pl-[26px] text-xs text-ink-500">
                   <span className="font-medium uppercase tracking-wide">{item.policy}</span>
@@ -243,6 +284,31 @@ export function PlanPage({ readOnly }: { readOnly: boolean }) {
                 <span className="rounded-md bg-signal-500/10 px-2 py-1 text-signal-400 ring-1 ring-signal-500/30">
                   {formatDigest(item.remote_digest)}
                 </span>
+                {item.remote_digest && (
+                  <Button
+                    variant="ghost"
+                    size="sm"
+                    disabled={readOnly || ignoreUpdate.isPending}
+                    onClick={() => skip(item)}
+                    title="Ignore this digest until a newer one appears"
+                  >
+                    Skip
+                  </Button>
+                )}
+                {!item.snoozed_until && (
+                  <select
+                    className="rounded-md border border-ink-700 bg-ink-900 px-2 py-1 text-xs text-ink-300"
+                    aria-label="snooze"
+                    value=""
+                    disabled={readOnly || snoozeUpdate.isPending}
+                    onChange={(event) => event.target.value && snooze(item, event.target.value)}
+                  >
+                    <option value="">Snooze…</option>
+                    <option value="24h">1 day</option>
+                    <option value="3d">3 days</option>
+                    <option value="7d">7 days</option>
+                  </select>
+                )}
               </div>
             </div>
           ))}
@@ -5,6 +5,7 @@ import {
   BellRing,
   CalendarClock,
   Lock,
+  MessageSquare,
   Radio,
   RefreshCw,
   Send,
@@ -174,6 +175,8 @@ export function SettingsPage() {
       if (merged.auto_update_enabled) merged.auto_update_safe = true;
       if (settingsData.locked?.discord_webhook) merged.discord_enabled = true;
       if (settingsData.locked?.slack_webhook) merged.slack_enabled = true;
+      if (settingsData.locked?.matrix_access_token) merged.matrix_enabled = true;
+      if (settingsData.locked?.teams_webhook) merged.teams_enabled = true;
       setForm(merged);
     }
   }, [settingsData]);
@@ -208,6 +211,8 @@ export function SettingsPage() {
   const activeChannels = [
     form.discord_enabled && form.discord_webhook && "Discord",
     form.slack_enabled && form.slack_webhook && "Slack",
+    form.matrix_enabled && form.matrix_homeserver && form.matrix_access_token && form.matrix_room_id && "Matrix",
+    form.teams_enabled && form.teams_webhook && "Teams",
   ].filter(Boolean) as string[];
 
   return (
@@ -309,6 +314,86 @@ export function SettingsPage() {
               </div>
             )}
           </div>
+
+          {/* Matrix */}
+          <div className={`rounded-xl border p-4 ${form.matrix_enabled ? "border-signal-500/25 bg-signal-500/5" : "border-ink-800/60 bg-ink-950/40"}`}>
+            <div className="flex items-center justify-between">
+              <div className="flex items-center gap-2 text-sm font-medium text-ink-100">
+                <MessageSquare className="h-4 w-4 text-[#0DBD8B]" />
+                Matrix
+              </div>
+              <Toggle
+                checked={Boolean(form.matrix_enabled)}
+                onChange={(v) => set("matrix_enabled", v)}
+                disabled={readOnly || Boolean(locked?.matrix_access_token)}
+              />
+            </div>
+            <div className="mt-3">
+              <label className="mb-1.5 block text-xs text-ink-500">Homeserver URL</label>
+              <Input
+                value={form.matrix_homeserver ?? ""}
+                onChange={(e) => set("matrix_homeserver", e.target.value)}
+                placeholder="https://matrix.org"
+                disabled={readOnly || Boolean(locked?.matrix_access_token)}
+              />
+            </div>
+            <div className="mt-3">
+              <label className="mb-1.5 block text-xs text-ink-500">Access token</label>
+              <Input
+                value={form.matrix_access_token ?? ""}
+                onChange={(e) => set("matrix_access_token", e.target.value)}
+                placeholder="syt_…"
+                type="password"
+                disabled={readOnly || Boolean(locked?.matrix_access_token)}
+              />
+            </div>
+            <div className="mt-3">
+              <label className="mb-1.5 block text-xs text-ink-500">Room ID</label>
+              <Input
+                value={form.matrix_room_id ?? ""}
+                onChange={(e) => set("matrix_room_id", e.target.value)}
+                placeholder="!roomid:matrix.org"
+                disabled={readOnly || Boolean(locked?.matrix_access_token)}
+              />
+            </div>
+            {locked?.matrix_access_token && (
+              <div className="mt-2 flex items-center gap-1.5 text-xs text-emerald-400">
+                <Lock className="h-3 w-3" />
+                Managed via <code className="font-mono">MATRIX_ACCESS_TOKEN</code>
+              </div>
+            )}
+          </div>
+
+          {/* Teams */}
+          <div className={`rounded-xl border p-4 ${form.teams_enabled ? "border-signal-500/25 bg-signal-500/5" : "border-ink-800/60 bg-ink-950/40"}`}>
+            <div className="flex items-center justify-between">
+              <div className="flex items-center gap-2 text-sm font-medium text-ink-100">
+                <Webhook className="h-4 w-4 text-[#6264A7]" />
+                Microsoft Teams
+              </div>
+              <Toggle
+                checked={Boolean(form.teams_enabled)}
+                onChange={(v) => set("teams_enabled", v)}
+                disabled={readOnly || Boolean(locked?.teams_webhook)}
+              />
+            </div>
+            <div className="mt-3">
+              <label className="mb-1.5 block text-xs text-ink-500">Webhook URL</label>
+              <Input
+                value={form.teams_webhook ?? ""}
+                onChange={(e) => set("teams_webhook", e.target.value)}
+                placeholder="https://….webhook.office.com/…"
+                type="password"
+                disabled={readOnly || Boolean(locked?.teams_webhook)}
+              />
+            </div>
+            {locked?.teams_webhook && (
+              <div className="mt-2 flex items-center gap-1.5 text-xs text-emerald-400">
+                <Lock className="h-3 w-3" />
+                Managed via <code className="font-mono">TEAMS_WEBHOOK_URL</code>
+              </div>
+            )}
+          </div>
         </div>
       </Section>
 